@@ -0,0 +1,245 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	rtrace "runtime/trace"
+	"time"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/op/clip"
+	"gioui.org/text"
+)
+
+// AggregateComponent loads a directory of traces, such as those written by repeated benchmark runs, and displays the
+// distributions of key metrics across them. It is the graphical counterpart of the aggregate command.
+type AggregateComponent struct {
+	dir   widget.Editor
+	start widget.PrimaryClickable
+
+	// Only accessed from the window's goroutine. The loading goroutine updates the component via actions.
+	loading  bool
+	progress string
+	agg      *Aggregate
+	err      string
+
+	tabbedState theme.TabbedState
+	metrics     struct {
+		table         *theme.Table
+		scrollState   theme.YScrollableListState
+		cellFormatter CellFormatter
+	}
+	runs struct {
+		table         *theme.Table
+		scrollState   theme.YScrollableListState
+		cellFormatter CellFormatter
+	}
+}
+
+func NewAggregateComponent() *AggregateComponent {
+	ac := &AggregateComponent{}
+	ac.dir.SingleLine = true
+	if recent := recentTraces(); len(recent) > 0 {
+		ac.dir.SetText(filepath.Dir(recent[0].Path))
+	}
+	return ac
+}
+
+// Title implements theme.Component.
+func (*AggregateComponent) Title() string {
+	return tr("Aggregate statistics")
+}
+
+// Transition implements theme.Component.
+func (*AggregateComponent) Transition(state theme.ComponentState) {}
+
+// WantsTransition implements theme.Component.
+func (*AggregateComponent) WantsTransition(gtx layout.Context) theme.ComponentState {
+	return theme.ComponentStateNone
+}
+
+func (*AggregateComponent) HoveredLink() ObjectLink {
+	return nil
+}
+
+func (ac *AggregateComponent) load(win *theme.Window) {
+	files, err := traceFiles(uiFileSystem, []string{ac.dir.Text()})
+	if err != nil {
+		ac.err = local.Sprintf("Couldn't find traces: %s", err)
+		return
+	}
+	ac.loading = true
+	ac.err = ""
+	ac.progress = ""
+	go func() {
+		agg := aggregateTraces(uiFileSystem, files, func(i int, path string) {
+			msg := local.Sprintf("Loading trace %d of %d: %s", i+1, len(files), filepath.Base(path))
+			win.EmitAction(theme.ExecuteAction(func(gtx layout.Context) {
+				ac.progress = msg
+			}))
+		})
+		win.EmitAction(theme.ExecuteAction(func(gtx layout.Context) {
+			ac.loading = false
+			if len(agg.Runs) == 0 {
+				ac.err = "None of the files could be loaded as traces."
+				return
+			}
+			ac.agg = agg
+			// The runs table has to be recreated, as its columns don't change otherwise.
+			ac.runs.table = nil
+		}))
+	}()
+}
+
+// Layout implements theme.Component.
+func (ac *AggregateComponent) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.AggregateComponent.Layout").End()
+
+	for ac.start.Clicked(gtx) {
+		if !ac.loading {
+			ac.load(win)
+		}
+	}
+
+	status := func(gtx layout.Context) layout.Dimensions {
+		switch {
+		case ac.loading:
+			return theme.LineLabel(win.Theme, ac.progress).Layout(win, gtx)
+		case ac.err != "":
+			l := theme.LineLabel(win.Theme, ac.err)
+			l.Color = colors[colorStateBlocked]
+			return l.Layout(win, gtx)
+		case ac.agg != nil && len(ac.agg.Skipped) > 0:
+			return theme.LineLabel(win.Theme, local.Sprintf("Skipped %d files that aren't traces.", len(ac.agg.Skipped))).Layout(win, gtx)
+		default:
+			return layout.Dimensions{}
+		}
+	}
+
+	tabs := []string{"Distributions", "Runs"}
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(theme.Dumb(win, theme.LineLabel(win.Theme, tr("Directory containing traces of repeated runs")).Layout)),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return layout.Rigids(gtx, layout.Horizontal,
+				func(gtx layout.Context) layout.Dimensions {
+					gtx.Constraints.Min.X = min(gtx.Dp(500), gtx.Constraints.Max.X)
+					gtx.Constraints.Max.X = gtx.Constraints.Min.X
+					return theme.TextBox(win.Theme, &ac.dir, "Directory").Layout(win, gtx)
+				},
+				layout.Spacer{Width: 5}.Layout,
+				func(gtx layout.Context) layout.Dimensions {
+					return theme.Button(win.Theme, &ac.start.Clickable, tr("Aggregate")).Layout(win, gtx)
+				},
+			)
+		}),
+		layout.Rigid(layout.Spacer{Height: 5}.Layout),
+		layout.Rigid(status),
+		layout.Rigid(layout.Spacer{Height: 5}.Layout),
+		layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+			if ac.agg == nil {
+				return layout.Dimensions{Size: gtx.Constraints.Min}
+			}
+			return theme.Tabbed(&ac.tabbedState, tabs).Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+				gtx.Constraints.Min = gtx.Constraints.Max
+				switch ac.tabbedState.Current {
+				case 0:
+					return ac.layoutMetrics(win, gtx)
+				case 1:
+					return ac.layoutRuns(win, gtx)
+				default:
+					panic("unreachable")
+				}
+			})
+		}),
+	)
+}
+
+// formatAggregateValue formats the value of a metric of the given kind.
+func formatAggregateValue(kind aggregateMetricKind, v float64) string {
+	switch kind {
+	case aggregateDuration:
+		return roundDuration(time.Duration(v)).String()
+	case aggregateCount:
+		return local.Sprintf("%.0f", v)
+	case aggregateFraction:
+		return local.Sprintf("%.2f%%", v*100)
+	default:
+		panic(fmt.Sprintf("unhandled kind %d", kind))
+	}
+}
+
+func (ac *AggregateComponent) layoutMetrics(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	mt := &ac.metrics
+	if mt.table == nil {
+		mt.table = &theme.Table{}
+		mt.table.SetColumns(win, gtx, []theme.Column{
+			{Name: "Metric", Alignment: text.Start},
+			{Name: "Min", Alignment: text.End},
+			{Name: "Median", Alignment: text.End},
+			{Name: "Mean", Alignment: text.End},
+			{Name: "Max", Alignment: text.End},
+			{Name: "Std. dev.", Alignment: text.End},
+			{Name: "Trend per run", Alignment: text.End},
+		})
+	}
+	mt.table.Update(gtx)
+	mt.cellFormatter.Update(win, gtx)
+
+	cellFn := func(win *theme.Window, gtx layout.Context, row, col int) layout.Dimensions {
+		defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+
+		m := &aggregateMetrics[row]
+		d := &ac.agg.Metrics[row]
+		var s string
+		switch col {
+		case 0:
+			s = m.Label
+		case 1:
+			s = formatAggregateValue(m.Kind, d.Min)
+		case 2:
+			s = formatAggregateValue(m.Kind, d.P50)
+		case 3:
+			s = formatAggregateValue(m.Kind, d.Mean)
+		case 4:
+			s = formatAggregateValue(m.Kind, d.Max)
+		case 5:
+			s = formatAggregateValue(m.Kind, d.Stddev)
+		case 6:
+			s = local.Sprintf("%+.2f%%", d.Trend*100)
+		default:
+			panic(fmt.Sprintf("unreachable: %d", col))
+		}
+		return mt.cellFormatter.Text(win, gtx, s)
+	}
+	return theme.SimpleTable(win, gtx, mt.table, &mt.scrollState, len(ac.agg.Metrics), cellFn)
+}
+
+func (ac *AggregateComponent) layoutRuns(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	rt := &ac.runs
+	if rt.table == nil {
+		cols := []theme.Column{{Name: "Trace", Alignment: text.Start}}
+		for _, m := range aggregateMetrics {
+			cols = append(cols, theme.Column{Name: m.Label, Alignment: text.End})
+		}
+		rt.table = &theme.Table{}
+		rt.table.SetColumns(win, gtx, cols)
+	}
+	rt.table.Update(gtx)
+	rt.cellFormatter.Update(win, gtx)
+
+	cellFn := func(win *theme.Window, gtx layout.Context, row, col int) layout.Dimensions {
+		defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+
+		run := &ac.agg.Runs[row]
+		if col == 0 {
+			return rt.cellFormatter.Text(win, gtx, filepath.Base(run.Path))
+		}
+		m := &aggregateMetrics[col-1]
+		return rt.cellFormatter.Text(win, gtx, formatAggregateValue(m.Kind, m.Get(run)))
+	}
+	return theme.SimpleTable(win, gtx, rt.table, &rt.scrollState, len(ac.agg.Runs), cellFn)
+}
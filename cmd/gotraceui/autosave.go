@@ -0,0 +1,459 @@
+package main
+
+// Autosave and crash recovery
+//
+// While traces are open, each main window periodically saves the sessions of all of its traces - the path of the trace,
+// the visible part of the timelines, and the bookmarks - to the autosave file. Fatal errors, including panics in any
+// goroutine, are written to the crash log by the runtime. Every running instance of gotraceui has its own autosave file
+// and crash log, named after its process ID, which it removes when it exits cleanly.
+//
+// Finding the files of a process that isn't running anymore at startup tells us that it crashed or got killed. We
+// take over its files, and the start scene offers restoring its sessions; the crash report gets displayed in a panel
+// of the restored session. Files of instances that are still running are left alone. A process ID that got reused by
+// an unrelated process delays the recovery of its files until that process exits.
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/font"
+	"gioui.org/op"
+	"gioui.org/text"
+	exptrace "golang.org/x/exp/trace"
+)
+
+// autosaveInterval is the minimum time between two autosaves of a window's sessions.
+const autosaveInterval = 30 * time.Second
+
+// A Session is the state of a trace displayed in a main window that gets autosaved.
+type Session struct {
+	// Trace is the absolute path of the trace file.
+	Trace   string    `json:"trace"`
+	SavedAt time.Time `json:"saved_at"`
+	// Start, NsPerPx, and Y describe the visible part of the timelines.
+	Start     exptrace.Time `json:"start"`
+	NsPerPx   float64       `json:"ns_per_px"`
+	Y         float64       `json:"y"`
+	Bookmarks []Bookmark    `json:"bookmarks,omitempty"`
+}
+
+// equal reports whether two sessions are the same, ignoring when they were saved.
+func (s *Session) equal(o *Session) bool {
+	return s.Trace == o.Trace &&
+		s.Start == o.Start &&
+		s.NsPerPx == o.NsPerPx &&
+		s.Y == o.Y &&
+		slices.Equal(s.Bookmarks, o.Bookmarks)
+}
+
+func (s *Session) Description() string {
+	return local.Sprintf("%d bookmarks, saved %s", len(s.Bookmarks), s.SavedAt.Local().Format("2006-01-02 15:04"))
+}
+
+type autosaveFile struct {
+	Sessions []Session `json:"sessions"`
+}
+
+// A Recovery is the state that was autosaved by previous runs of gotraceui that didn't exit cleanly.
+type Recovery struct {
+	Sessions []Session
+	// Crash is the output of the runtime's fatal errors, if previous runs crashed, or the empty string.
+	Crash string
+}
+
+// pendingRecovery is the recovery that the user hasn't restored or discarded yet, if any.
+var pendingRecovery atomic.Pointer[Recovery]
+
+// autosaves holds the sessions of all main windows, which share the process's autosave file.
+var autosaves struct {
+	mu       sync.Mutex
+	sessions map[*MainWindow]windowSessions
+	// closed is set when gotraceui is exiting cleanly, after which we mustn't write the file anymore.
+	closed bool
+}
+
+// windowSessions are the sessions of the traces of a main window.
+type windowSessions struct {
+	sessions []Session
+	savedAt  time.Time
+}
+
+const (
+	autosavePrefix = "autosave-"
+	autosaveSuffix = ".json"
+	crashLogPrefix = "crash-"
+	crashLogSuffix = ".log"
+)
+
+// autosavePath returns the path of the autosave file of the process with the given ID.
+func autosavePath(pid int) (string, error) {
+	path, err := configPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(path), fmt.Sprintf("%s%d%s", autosavePrefix, pid, autosaveSuffix)), nil
+}
+
+// crashLogPath returns the path of the crash log of the process with the given ID.
+func crashLogPath(pid int) (string, error) {
+	path, err := configPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(path), fmt.Sprintf("%s%d%s", crashLogPrefix, pid, crashLogSuffix)), nil
+}
+
+// orphanedPIDs returns the IDs of the processes that left autosave files or crash logs behind and aren't running
+// anymore. Our own process ID can only have been left behind by a previous process that had the same ID.
+func orphanedPIDs() ([]int, error) {
+	path, err := configPath()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := uiFileSystem.ReadDir(filepath.Dir(path))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		// The file system can't list files, which is the case in browsers. We can still find the files left
+		// behind by a previous process with our ID.
+		return []int{os.Getpid()}, nil
+	}
+	var pids []int
+	for _, e := range entries {
+		name := e.Name()
+		var id string
+		if rest, ok := strings.CutPrefix(name, autosavePrefix); ok {
+			id, ok = strings.CutSuffix(rest, autosaveSuffix)
+			if !ok {
+				continue
+			}
+		} else if rest, ok := strings.CutPrefix(name, crashLogPrefix); ok {
+			id, ok = strings.CutSuffix(rest, crashLogSuffix)
+			if !ok {
+				continue
+			}
+		} else {
+			continue
+		}
+		pid, err := strconv.Atoi(id)
+		if err != nil || slices.Contains(pids, pid) {
+			continue
+		}
+		if pid == os.Getpid() || !processAlive(pid) {
+			pids = append(pids, pid)
+		}
+	}
+	slices.Sort(pids)
+	return pids, nil
+}
+
+// loadRecovery reads the autosave files and crash logs of previous runs that didn't exit cleanly and removes them,
+// taking over their recovery. It returns nil if there is nothing to recover.
+func loadRecovery() (*Recovery, error) {
+	pids, err := orphanedPIDs()
+	if err != nil {
+		return nil, err
+	}
+	r := &Recovery{}
+	var crashes []string
+	for _, pid := range pids {
+		path, err := autosavePath(pid)
+		if err != nil {
+			return nil, err
+		}
+		b, err := uiFileSystem.ReadFile(path)
+		if err == nil {
+			var f autosaveFile
+			if err := json.Unmarshal(b, &f); err != nil {
+				log.Printf("couldn't load autosave %s: %s", path, err)
+			} else {
+				r.Sessions = append(r.Sessions, f.Sessions...)
+			}
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+
+		crashPath, err := crashLogPath(pid)
+		if err != nil {
+			return nil, err
+		}
+		if b, err := uiFileSystem.ReadFile(crashPath); err == nil {
+			if crash := strings.TrimSpace(string(b)); crash != "" {
+				crashes = append(crashes, crash)
+			}
+		}
+
+		for _, p := range []string{path, crashPath} {
+			if err := uiFileSystem.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				log.Println("couldn't remove recovered file:", err)
+			}
+		}
+	}
+	r.Crash = strings.Join(crashes, "\n\n")
+	if len(r.Sessions) == 0 && r.Crash == "" {
+		return nil, nil
+	}
+	return r, nil
+}
+
+// startRecordingCrashes makes the runtime write fatal errors to the process's crash log.
+func startRecordingCrashes() error {
+	path, err := crashLogPath(os.Getpid())
+	if err != nil {
+		return err
+	}
+	return recordCrashes(path)
+}
+
+// writeAutosave writes the sessions of all windows to the process's autosave file. It must be called with
+// autosaves.mu held.
+func writeAutosave() {
+	if autosaves.closed {
+		return
+	}
+	path, err := autosavePath(os.Getpid())
+	if err != nil {
+		log.Println("couldn't autosave:", err)
+		return
+	}
+	var f autosaveFile
+	for _, ws := range autosaves.sessions {
+		f.Sessions = append(f.Sessions, ws.sessions...)
+	}
+	slices.SortFunc(f.Sessions, func(a, b Session) int { return strings.Compare(a.Trace, b.Trace) })
+	b, err := json.MarshalIndent(f, "", "\t")
+	if err != nil {
+		log.Println("couldn't autosave:", err)
+		return
+	}
+	if err := uiFileSystem.WriteFile(path, append(b, '\n')); err != nil {
+		log.Println("couldn't autosave:", err)
+	}
+}
+
+// removeAutosave removes the process's autosave file and crash log. It is called when gotraceui exits cleanly. The
+// files of other instances are left alone.
+func removeAutosave() {
+	autosaves.mu.Lock()
+	defer autosaves.mu.Unlock()
+	autosaves.closed = true
+	for _, path := range []func(int) (string, error){autosavePath, crashLogPath} {
+		if p, err := path(os.Getpid()); err == nil {
+			if err := uiFileSystem.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				log.Println("couldn't remove autosave:", err)
+			}
+		}
+	}
+}
+
+// sessions returns the current sessions of all traces displayed in the window, skipping traces that can't be
+// reopened.
+func (mwin *MainWindow) sessions() []Session {
+	var out []Session
+	add := func(cv *Canvas, tr *Trace, path string) {
+		if s, ok := traceSession(cv, tr, path); ok {
+			out = append(out, s)
+		}
+	}
+	if mwin.activeTrace == nil {
+		add(mwin.canvas, mwin.trace, mwin.tracePath)
+	}
+	for _, tt := range mwin.traceTabs {
+		if tt == mwin.activeTrace {
+			// The state of the active trace is held by the window, not the tab.
+			add(mwin.canvas, mwin.trace, mwin.tracePath)
+		} else {
+			add(tt.canvas, tt.trace, tt.tracePath)
+		}
+	}
+	return out
+}
+
+// traceSession returns the current session of a trace. It returns false if the trace can't be reopened.
+func traceSession(cv *Canvas, tr *Trace, path string) (Session, bool) {
+	if tr == nil || path == "" {
+		return Session{}, false
+	}
+	s := Session{
+		Trace:   path,
+		Start:   cv.start,
+		NsPerPx: cv.nsPerPx,
+		Y:       float64(cv.y),
+	}
+	if len(cv.bookmarks) > 0 {
+		s.Bookmarks = make([]Bookmark, len(cv.bookmarks))
+		for i, b := range cv.bookmarks {
+			s.Bookmarks[i] = *b
+		}
+	}
+	return s, true
+}
+
+// autosave saves the sessions of the window's traces if they have changed and the last autosave is long enough ago.
+// If it's too early, it schedules a frame for when it's time to save.
+func (mwin *MainWindow) autosave(gtx layout.Context) {
+	if gtx.Now.Before(mwin.autosaved.at.Add(autosaveInterval)) {
+		if mwin.state == "main" {
+			op.InvalidateOp{At: mwin.autosaved.at.Add(autosaveInterval)}.Add(gtx.Ops)
+		}
+		return
+	}
+	ss := mwin.sessions()
+	if slices.EqualFunc(ss, mwin.autosaved.sessions, func(a, b Session) bool { return a.equal(&b) }) {
+		return
+	}
+	now := time.Now()
+	for i := range ss {
+		ss[i].SavedAt = now
+	}
+	mwin.autosaved.sessions = ss
+	mwin.autosaved.at = gtx.Now
+
+	go func() {
+		autosaves.mu.Lock()
+		defer autosaves.mu.Unlock()
+		if mwin.autosaved.forgotten {
+			// The window got closed before we got to save its sessions.
+			return
+		}
+		if prev, ok := autosaves.sessions[mwin]; ok && prev.savedAt.After(now) {
+			// Newer sessions of the window have already been saved.
+			return
+		}
+		if autosaves.sessions == nil {
+			autosaves.sessions = map[*MainWindow]windowSessions{}
+		}
+		autosaves.sessions[mwin] = windowSessions{sessions: ss, savedAt: now}
+		writeAutosave()
+	}()
+}
+
+// forgetSession removes the window's sessions from the autosave file when the window gets closed. Autosaves of the
+// window that are still pending get dropped.
+func (mwin *MainWindow) forgetSession() {
+	autosaves.mu.Lock()
+	defer autosaves.mu.Unlock()
+	mwin.autosaved.forgotten = true
+	if _, ok := autosaves.sessions[mwin]; ok {
+		delete(autosaves.sessions, mwin)
+		writeAutosave()
+	}
+}
+
+// restoreSession opens the trace of a recovered session. The rest of the session gets restored once the trace has
+// been loaded.
+func (mwin *MainWindow) restoreSession(r *Recovery, idx int) {
+	s := r.Sessions[idx]
+	mwin.restoring = &s
+	mwin.restoringCrash = r.Crash
+
+	// Each session can only be restored once. The crash report gets displayed with the first session restored.
+	rest := &Recovery{Sessions: slices.Delete(slices.Clone(r.Sessions), idx, idx+1)}
+	if len(rest.Sessions) == 0 {
+		rest = nil
+	}
+	pendingRecovery.CompareAndSwap(r, rest)
+
+	mwin.openTraceFile(s.Trace)
+}
+
+// applyRestoredSession restores the view and bookmarks of the session being restored, if the loaded trace is the
+// session's trace.
+func (mwin *MainWindow) applyRestoredSession() {
+	s, crash := mwin.restoring, mwin.restoringCrash
+	mwin.restoring, mwin.restoringCrash = nil, ""
+	if s == nil || s.Trace != mwin.tracePath {
+		return
+	}
+
+	cv := mwin.canvas
+	if s.NsPerPx > 0 {
+		cv.start = s.Start
+		cv.nsPerPx = s.NsPerPx
+		cv.y = normalizedY(s.Y)
+		cv.rememberLocation()
+	}
+	for _, b := range s.Bookmarks {
+		cv.addBookmark(b)
+	}
+	// The restored session is the window's current state; don't autosave it again right away.
+	mwin.autosaved.sessions = mwin.sessions()
+	if crash != "" {
+		mwin.openPanel(NewCrashReportComponent(crash))
+	}
+}
+
+// CrashReportComponent displays the fatal error that terminated the previous run of gotraceui.
+type CrashReportComponent struct {
+	lines []string
+
+	copy widget.PrimaryClickable
+	list widget.List
+}
+
+func NewCrashReportComponent(report string) *CrashReportComponent {
+	cr := &CrashReportComponent{
+		lines: strings.Split(report, "\n"),
+	}
+	cr.list.Axis = layout.Vertical
+	return cr
+}
+
+// Title implements theme.Component.
+func (*CrashReportComponent) Title() string {
+	return tr("Crash report")
+}
+
+// Transition implements theme.Component.
+func (*CrashReportComponent) Transition(state theme.ComponentState) {}
+
+// WantsTransition implements theme.Component.
+func (*CrashReportComponent) WantsTransition(gtx layout.Context) theme.ComponentState {
+	return theme.ComponentStateNone
+}
+
+func (*CrashReportComponent) HoveredLink() ObjectLink { return nil }
+
+// Layout implements theme.Component.
+func (cr *CrashReportComponent) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	for cr.copy.Clicked(gtx) {
+		win.AppWindow.WriteClipboard(strings.Join(cr.lines, "\n"))
+		win.ShowNotification(gtx, tr("Copied crash report to clipboard"))
+	}
+
+	mono := font.Font{Typeface: win.Theme.MonospaceTypeface}
+	return layout.Rigids(gtx, layout.Vertical,
+		func(gtx layout.Context) layout.Dimensions {
+			return theme.Label(win.Theme, tr("gotraceui crashed the last time it ran. Please consider reporting the crash, including the report below.")).Layout(win, gtx)
+		},
+		layout.Spacer{Height: 5}.Layout,
+		theme.Dumb(win, theme.Button(win.Theme, &cr.copy.Clickable, tr("Copy crash report")).Layout),
+		layout.Spacer{Height: 10}.Layout,
+		func(gtx layout.Context) layout.Dimensions {
+			gtx.Constraints.Min = gtx.Constraints.Max
+			return theme.List(win.Theme, &cr.list).Layout(win, gtx, len(cr.lines), func(gtx layout.Context, i int) layout.Dimensions {
+				f := mono
+				if i == 0 {
+					// The first line is the panic's message.
+					f.Weight = font.Bold
+				}
+				return widget.Label{MaxLines: 1, Alignment: text.Start}.Layout(gtx, win.Theme.Shaper, f, win.Theme.TextSize, cr.lines[i], win.ColorMaterial(gtx, win.Theme.Palette.Foreground))
+			})
+		},
+	)
+}
@@ -0,0 +1,31 @@
+package main
+
+import "testing"
+
+func TestSessionsOfAllTraces(t *testing.T) {
+	tabs := []*traceTab{
+		{canvas: &Canvas{nsPerPx: 1}, trace: &Trace{}, tracePath: "/a.trace"},
+		{canvas: &Canvas{nsPerPx: 2}, trace: &Trace{}, tracePath: "/b.trace"},
+		// A trace that was read from standard input can't be reopened.
+		{canvas: &Canvas{nsPerPx: 3}, trace: &Trace{}},
+	}
+	// The state of the active trace lives in the window, and is newer than what is stored in its tab.
+	mwin := &MainWindow{
+		traceTabs:   tabs,
+		activeTrace: tabs[1],
+		canvas:      &Canvas{nsPerPx: 20},
+		trace:       tabs[1].trace,
+		tracePath:   tabs[1].tracePath,
+	}
+
+	ss := mwin.sessions()
+	if len(ss) != 2 {
+		t.Fatalf("got %d sessions, want 2", len(ss))
+	}
+	if ss[0].Trace != "/a.trace" || ss[0].NsPerPx != 1 {
+		t.Errorf("got session %+v for inactive trace", ss[0])
+	}
+	if ss[1].Trace != "/b.trace" || ss[1].NsPerPx != 20 {
+		t.Errorf("got session %+v for active trace", ss[1])
+	}
+}
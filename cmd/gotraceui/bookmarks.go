@@ -0,0 +1,329 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"image"
+	rtrace "runtime/trace"
+	"slices"
+	"time"
+
+	"honnef.co/go/gotraceui/clip"
+	"honnef.co/go/gotraceui/color"
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/f32"
+	"gioui.org/unit"
+	"gioui.org/x/explorer"
+	exptrace "golang.org/x/exp/trace"
+)
+
+// bookmarkColors are the colors that bookmarks can have. Clicking on a bookmark's color cycles through them.
+var bookmarkColors = []color.Oklch{
+	oklch(65, 0.2, 30),
+	oklch(75, 0.15, 85),
+	oklch(70, 0.17, 145),
+	oklch(65, 0.14, 235),
+	oklch(60, 0.2, 300),
+}
+
+// A Bookmark marks a moment in the trace, or a range of time if End is after Start. Ranges are called measurements in
+// the user interface.
+type Bookmark struct {
+	Name string `json:"name"`
+	// Color is an index into bookmarkColors.
+	Color int `json:"color"`
+	// Start and End are timestamps as recorded in the trace, independent of the time format the user chose.
+	Start exptrace.Time `json:"start"`
+	End   exptrace.Time `json:"end"`
+}
+
+func (b *Bookmark) IsMeasurement() bool {
+	return b.End > b.Start
+}
+
+func (b *Bookmark) color() color.Oklch {
+	return bookmarkColors[b.Color%len(bookmarkColors)]
+}
+
+// AddBookmarkAction adds a bookmark to the canvas of the main window.
+type AddBookmarkAction struct {
+	Bookmark Bookmark
+}
+
+func (*AddBookmarkAction) IsAction() {}
+func (l *AddBookmarkAction) Open(gtx layout.Context, mwin *MainWindow) {
+	mwin.canvas.addBookmark(l.Bookmark)
+}
+
+// addBookmark adds a bookmark, giving it a default name and color if it doesn't have a name.
+func (cv *Canvas) addBookmark(b Bookmark) *Bookmark {
+	if b.Name == "" {
+		kind := "Bookmark"
+		if b.IsMeasurement() {
+			kind = "Measurement"
+		}
+		b.Name = local.Sprintf("%s %d", kind, len(cv.bookmarks)+1)
+		b.Color = len(cv.bookmarks) % len(bookmarkColors)
+	}
+	cv.bookmarks = append(cv.bookmarks, &b)
+	return &b
+}
+
+// drawBookmarks draws bookmarks as vertical lines and measurements as shaded ranges spanning the height of the canvas.
+func (cv *Canvas) drawBookmarks(win *theme.Window, gtx layout.Context) {
+	height := float32(gtx.Constraints.Max.Y)
+	width := float32(gtx.Dp(2))
+	for _, b := range cv.bookmarks {
+		start, end := cv.tsToPx(b.Start), cv.tsToPx(b.End)
+		if end < 0 || start > float32(gtx.Constraints.Max.X) {
+			continue
+		}
+		c := b.color()
+		if b.IsMeasurement() {
+			fill := c
+			fill.A = 0.15
+			theme.FillShape(win, gtx.Ops, fill, clip.FRect{Min: f32.Pt(start, 0), Max: f32.Pt(end, height)}.Op(gtx.Ops))
+			theme.FillShape(win, gtx.Ops, c, clip.FRect{Min: f32.Pt(end-width/2, 0), Max: f32.Pt(end+width/2, height)}.Op(gtx.Ops))
+		}
+		theme.FillShape(win, gtx.Ops, c, clip.FRect{Min: f32.Pt(start-width/2, 0), Max: f32.Pt(start+width/2, height)}.Op(gtx.Ops))
+	}
+}
+
+type bookmarkRow struct {
+	name   widget.Editor
+	color  widget.PrimaryClickable
+	jump   widget.PrimaryClickable
+	up     widget.PrimaryClickable
+	down   widget.PrimaryClickable
+	remove widget.PrimaryClickable
+}
+
+// BookmarksComponent lists the bookmarks and measurements of the canvas, and allows renaming, recoloring, reordering,
+// deleting, and jumping to them, as well as exporting them to and importing them from JSON files.
+type BookmarksComponent struct {
+	mwin *MainWindow
+	cv   *Canvas
+
+	list         widget.List
+	rows         map[*Bookmark]*bookmarkRow
+	exportButton widget.PrimaryClickable
+	importButton widget.PrimaryClickable
+}
+
+func NewBookmarksComponent(mwin *MainWindow) *BookmarksComponent {
+	bc := &BookmarksComponent{
+		mwin: mwin,
+		cv:   mwin.canvas,
+		rows: map[*Bookmark]*bookmarkRow{},
+	}
+	bc.list.Axis = layout.Vertical
+	return bc
+}
+
+// Title implements theme.Component.
+func (*BookmarksComponent) Title() string {
+	return tr("Bookmarks")
+}
+
+// Transition implements theme.Component.
+func (*BookmarksComponent) Transition(state theme.ComponentState) {}
+
+// WantsTransition implements theme.Component.
+func (*BookmarksComponent) WantsTransition(gtx layout.Context) theme.ComponentState {
+	return theme.ComponentStateNone
+}
+
+func (*BookmarksComponent) HoveredLink() ObjectLink {
+	return nil
+}
+
+func (bc *BookmarksComponent) row(b *Bookmark) *bookmarkRow {
+	row, ok := bc.rows[b]
+	if !ok {
+		row = &bookmarkRow{}
+		row.name.SingleLine = true
+		row.name.SetText(b.Name)
+		bc.rows[b] = row
+	}
+	return row
+}
+
+func (bc *BookmarksComponent) exportBookmarks(win *theme.Window) {
+	b, err := json.MarshalIndent(bc.cv.bookmarks, "", "\t")
+	if err != nil {
+		win.Notify(theme.NotificationError, local.Sprintf("Couldn't export bookmarks: %s", err))
+		return
+	}
+	if !bc.mwin.showingExplorer.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer bc.mwin.showingExplorer.Store(false)
+		wc, err := bc.mwin.explorer.CreateFile("bookmarks.json")
+		if errors.Is(err, explorer.ErrUserDecline) {
+			return
+		}
+		if err == nil {
+			_, err = wc.Write(append(b, '\n'))
+			if cerr := wc.Close(); err == nil {
+				err = cerr
+			}
+		}
+		if err != nil {
+			win.Notify(theme.NotificationError, local.Sprintf("Couldn't export bookmarks: %s", err))
+		} else {
+			win.Notify(theme.NotificationInfo, tr("Exported bookmarks"))
+		}
+	}()
+}
+
+func (bc *BookmarksComponent) importBookmarks(win *theme.Window) {
+	if !bc.mwin.showingExplorer.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer bc.mwin.showingExplorer.Store(false)
+		rc, err := bc.mwin.explorer.ChooseFile(".json")
+		if errors.Is(err, explorer.ErrUserDecline) {
+			return
+		}
+		var bookmarks []Bookmark
+		if err == nil {
+			err = json.NewDecoder(rc).Decode(&bookmarks)
+			rc.Close()
+		}
+		if err != nil {
+			win.Notify(theme.NotificationError, local.Sprintf("Couldn't import bookmarks: %s", err))
+			return
+		}
+		win.EmitAction(theme.ExecuteAction(func(gtx layout.Context) {
+			for _, b := range bookmarks {
+				if b.Color < 0 {
+					b.Color = 0
+				}
+				if b.End < b.Start {
+					b.End = b.Start
+				}
+				bc.cv.addBookmark(b)
+			}
+			win.Notify(theme.NotificationInfo, local.Sprintf("Imported %d bookmarks", len(bookmarks)))
+		}))
+	}()
+}
+
+// Layout implements theme.Component.
+func (bc *BookmarksComponent) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.BookmarksComponent.Layout").End()
+
+	cv := bc.cv
+	for bc.exportButton.Clicked(gtx) {
+		bc.exportBookmarks(win)
+	}
+	for bc.importButton.Clicked(gtx) {
+		bc.importBookmarks(win)
+	}
+	for i := 0; i < len(cv.bookmarks); i++ {
+		b := cv.bookmarks[i]
+		row := bc.row(b)
+		b.Name = row.name.Text()
+		for row.color.Clicked(gtx) {
+			b.Color = (b.Color + 1) % len(bookmarkColors)
+		}
+		for row.jump.Clicked(gtx) {
+			if b.IsMeasurement() {
+				// Leave some room around the measurement.
+				pad := (b.End - b.Start) / 10
+				win.EmitAction(&ZoomToTimeRangeAction{Start: b.Start - pad, End: b.End + pad})
+			} else {
+				win.EmitAction(ShowTimestampAction(b.Start))
+			}
+		}
+		for row.up.Clicked(gtx) {
+			if i > 0 {
+				cv.bookmarks[i-1], cv.bookmarks[i] = cv.bookmarks[i], cv.bookmarks[i-1]
+			}
+		}
+		for row.down.Clicked(gtx) {
+			if i+1 < len(cv.bookmarks) {
+				cv.bookmarks[i+1], cv.bookmarks[i] = cv.bookmarks[i], cv.bookmarks[i+1]
+			}
+		}
+		for row.remove.Clicked(gtx) {
+			delete(bc.rows, b)
+			cv.bookmarks = slices.Delete(cv.bookmarks, i, i+1)
+			i--
+			break
+		}
+	}
+
+	button := func(c *widget.PrimaryClickable, label string) layout.Widget {
+		return func(gtx layout.Context) layout.Dimensions {
+			return theme.Button(win.Theme, &c.Clickable, label).Layout(win, gtx)
+		}
+	}
+	spacer := layout.Spacer{Width: 5}.Layout
+
+	list := func(gtx layout.Context) layout.Dimensions {
+		if len(cv.bookmarks) == 0 {
+			msg := local.Sprintf("There are no bookmarks yet. Press %s to bookmark the time under the cursor, or save a selected range of time as a measurement.", keymap.Label(keyAddBookmark))
+			return theme.Label(win.Theme, msg).Layout(win, gtx)
+		}
+		return theme.List(win.Theme, &bc.list).Layout(win, gtx, len(cv.bookmarks), func(gtx layout.Context, index int) layout.Dimensions {
+			b := cv.bookmarks[index]
+			row := bc.row(b)
+			var when string
+			if b.IsMeasurement() {
+				when = local.Sprintf("%s, lasting %s", formatTimestamp(nil, cv.trace.AdjustedTime(b.Start)), roundDuration(time.Duration(b.End-b.Start)))
+			} else {
+				when = formatTimestamp(nil, cv.trace.AdjustedTime(b.Start))
+			}
+			return layout.Inset{Bottom: 5}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				return layout.Rigids(gtx, layout.Horizontal,
+					func(gtx layout.Context) layout.Dimensions {
+						return row.color.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+							sz := gtx.Dp(20)
+							theme.FillShape(win, gtx.Ops, b.color(), clip.Rect{Max: image.Pt(sz, sz)}.Op())
+							return layout.Dimensions{Size: image.Pt(sz, sz)}
+						})
+					},
+					spacer,
+					func(gtx layout.Context) layout.Dimensions {
+						gtx.Constraints.Min.X = gtx.Dp(unit.Dp(250))
+						gtx.Constraints.Max.X = gtx.Constraints.Min.X
+						return theme.TextBox(win.Theme, &row.name, "Name").Layout(win, gtx)
+					},
+					spacer,
+					func(gtx layout.Context) layout.Dimensions {
+						return row.jump.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+							l := theme.LineLabel(win.Theme, when)
+							l.Color = win.Theme.Palette.Link
+							return l.Layout(win, gtx)
+						})
+					},
+					spacer,
+					button(&row.up, "Up"),
+					spacer,
+					button(&row.down, "Down"),
+					spacer,
+					button(&row.remove, "Delete"),
+				)
+			})
+		})
+	}
+
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return layout.Rigids(gtx, layout.Horizontal,
+				button(&bc.exportButton, "Export…"),
+				spacer,
+				button(&bc.importButton, "Import…"),
+			)
+		}),
+		layout.Rigid(layout.Spacer{Height: 10}.Layout),
+		layout.Flexed(1, list),
+	)
+}
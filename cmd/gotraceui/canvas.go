@@ -5,8 +5,12 @@ import (
 	"fmt"
 	"image"
 	"math"
+	"runtime"
 	rtrace "runtime/trace"
+	"slices"
 	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"honnef.co/go/gotraceui/clip"
@@ -27,7 +31,6 @@ import (
 	"gioui.org/op"
 	"gioui.org/op/paint"
 	"gioui.org/unit"
-	"gioui.org/x/component"
 	exptrace "golang.org/x/exp/trace"
 )
 
@@ -70,6 +73,20 @@ const (
 
 	// minimum value of nsPerPx that can be set by zooming
 	minNsPerPx = 0.005
+
+	// Kinetic panning starts if the pointer moved faster than kineticMinVelocityDp per second when the user released
+	// the drag, and hadn't been still for more than kineticMaxIdle. It stops when the velocity drops below
+	// kineticStopVelocityDp per second. kineticDecay is the time constant of the exponential deceleration.
+	kineticMinVelocityDp  unit.Dp = 300
+	kineticStopVelocityDp unit.Dp = 20
+	kineticMaxIdle                = 50 * time.Millisecond
+	kineticDecay                  = 325 * time.Millisecond
+
+	// Goroutines that ran for less than idleGoroutineThreshold in total are considered idle, and goroutines that
+	// existed for less than shortLivedGoroutineFraction of the trace's duration are considered short-lived. Both can
+	// be hidden to declutter traces dominated by trivial goroutines.
+	idleGoroutineThreshold      = 100 * time.Microsecond
+	shortLivedGoroutineFraction = 0.001
 )
 
 const animateLength = 250 * time.Millisecond
@@ -123,8 +140,14 @@ type Canvas struct {
 	// Scratch space used for planning textures
 	scratchTexs  []TextureStack
 	scratchDones []chan struct{}
+	// Scratch space used for merging spans
+	scratchMergeJobs []mergeJob
+	// Double buffer for prevFrame.displayedTls, which layoutTimelines uses while collecting the new list.
+	scratchDisplayedTls []*Timeline
 
 	indicateTimestamp container.Option[exptrace.Time]
+	// Bookmarks and measurements, in the order the user arranged them.
+	bookmarks []*Bookmark
 
 	animate theme.Animation[canvasAnimation]
 
@@ -138,6 +161,9 @@ type Canvas struct {
 	memoryGraph    Plot
 	goroutineGraph Plot
 
+	// Marks in the scrollbar showing which timelines are active in the visible time range.
+	minimap scrollbarMinimap
+
 	// State for dragging the canvas
 	drag struct {
 		drag    gesture.Drag
@@ -149,6 +175,47 @@ type Canvas struct {
 		startY  normalizedY
 	}
 
+	// State for kinetic panning, which keeps the canvas moving after the end of a drag.
+	kinetic struct {
+		active bool
+		// Velocity in pixels per second.
+		velocity f32.Point
+		// The position and time of the last drag event.
+		lastPos  f32.Point
+		lastTime time.Duration
+		// The time of the last frame that applied kinetic panning.
+		prevFrame time.Time
+	}
+
+	// The fractional part of vertical scrolling that hasn't been applied yet.
+	scrollRemainderY float32
+
+	// State for touch gestures. Two fingers pan the canvas, and moving them apart or together zooms the time axis.
+	touch struct {
+		// The active touch points. Only the first two take part in gestures.
+		ids       [2]pointer.ID
+		positions [2]f32.Point
+		n         int
+		pinching  bool
+		// The horizontal distance between and the midpoint of the touch points, as of the last applied event.
+		prevDist float32
+		prevMid  f32.Point
+	}
+
+	// State for keyboard navigation. While the canvas has keyboard focus, the arrow keys move a cursor between
+	// timelines and between the spans of a timeline's first track, and Enter opens the span under the cursor. The
+	// span's tooltip is displayed without having to hover it.
+	keyboard struct {
+		focused  bool
+		timeline *Timeline
+		// The index of timeline in Canvas.timelines, which may be outdated if the timelines changed.
+		index int
+		// The index of the span in the track's spans.
+		span int
+		// The height of the timelines area in the last frame.
+		height int
+	}
+
 	// State for zooming to a selection
 	zoomSelection struct {
 		ready   bool
@@ -156,6 +223,13 @@ type Canvas struct {
 		active  bool
 	}
 
+	// State for selecting a time range to summarize
+	rangeSelection struct {
+		ready   bool
+		clickAt f32.Point
+		active  bool
+	}
+
 	// We have multiple sources of the pointer position, which are valid during different times: Canvas.hover and
 	// Canvas.drag.drag – when we're dragging, Canvas.drag.drag grabs pointer input and the hover won't update anymore.
 	pointerAt f32.Point
@@ -166,16 +240,28 @@ type Canvas struct {
 		displayAllLabels   bool
 		compact            bool
 		displayStackTracks bool
+		// Whether to hide the timelines of idle and short-lived goroutines, and how many timelines each option hides.
+		hideIdle, hideShortLived           bool
+		numHiddenIdle, numHiddenShortLived int
+		// Goroutine timelines that don't match goroutineFilter are hidden, too.
+		goroutineFilter TimelineFilter
+		// heightsGen is incremented whenever the user resizes a timeline, or timelines get hidden or revealed.
+		heightsGen uint64
 		// Should tooltips be shown?
 		showTooltips showTooltips
 		// Should GC overlays be shown?
 		showGCOverlays showGCOverlays
+		// Time ranges during which syscalls blocked Ps, highlighted by the long syscalls report, or nil.
+		syscallOverlay Items[ptrace.Span]
 
 		hoveredTimeline *Timeline
 		hover           gesture.Hover
 	}
 
-	resizeMemoryTimelines component.Resize
+	resizeMemoryTimelines theme.SplitterState
+
+	// The generation of the span colors that the canvas's textures were computed with.
+	spanColors uint64
 
 	// prevFrame records the canvas's state in the previous state. It allows reusing the computed displayed spans
 	// between frames if the canvas hasn't changed.
@@ -190,11 +276,15 @@ type Canvas struct {
 		hoveredTimeline    *Timeline
 		width              int
 		filter             Filter
+		spanColors         uint64
+		heightsGen         uint64
+		timeFormat         TimeFormatConfig
 	}
 
 	cachedCanvasHeight struct {
 		compact            bool
 		displayStackTracks bool
+		heightsGen         uint64
 		metric             unit.Metric
 		height             int
 	}
@@ -211,7 +301,7 @@ type Canvas struct {
 
 func NewCanvasInto(cv *Canvas, dwin *DebugWindow, t *Trace) {
 	*cv = Canvas{
-		resizeMemoryTimelines: component.Resize{
+		resizeMemoryTimelines: theme.SplitterState{
 			Axis:  layout.Vertical,
 			Ratio: 0.2,
 		},
@@ -219,7 +309,7 @@ func NewCanvasInto(cv *Canvas, dwin *DebugWindow, t *Trace) {
 		trace:          t,
 		debugWindow:    dwin,
 		itemToTimeline: make(map[any]*Timeline),
-		timelines:      make([]*Timeline, 0, len(t.Goroutines)+len(t.Processors)+len(t.Machines)+2),
+		timelines:      make([]*Timeline, 0, len(t.Goroutines)+len(t.Processors)+len(t.Machines)+3),
 		textures: TextureManager{
 			rgbas:         mysync.NewMutex(&container.RBTree[comparableTimeDuration, *texture]{AllowDuplicates: true}),
 			realizedRGBAs: mysync.NewMutex(container.Set[*texture]{}),
@@ -236,6 +326,9 @@ func NewCanvasInto(cv *Canvas, dwin *DebugWindow, t *Trace) {
 	if len(t.STW) != 0 {
 		cv.timelines = append(cv.timelines, NewSTWTimeline(cv, t, t.STW))
 	}
+	if len(t.NetWaits) != 0 {
+		cv.timelines = append(cv.timelines, NewNetPollerTimeline(cv, t, t.NetWaits))
+	}
 }
 
 func (cv *Canvas) End() exptrace.Time {
@@ -246,6 +339,7 @@ func (cv *Canvas) computeTimelinePositions(gtx layout.Context) {
 	if len(cv.timelineEnds) == len(cv.timelines) &&
 		cv.timeline.compact == cv.prevFrame.compact &&
 		cv.timeline.displayStackTracks == cv.prevFrame.displayStackTracks &&
+		cv.timeline.heightsGen == cv.prevFrame.heightsGen &&
 		gtx.Metric == cv.prevFrame.metric {
 		return
 	}
@@ -321,6 +415,7 @@ func (cv *Canvas) navigateToChecks(start exptrace.Time, nsPerPx float64, y norma
 
 func (cv *Canvas) cancelNavigation() {
 	cv.animate.Cancel()
+	cv.kinetic.active = false
 }
 
 // navigateTo modifes the canvas's start, end and y values, recording the new location in the undo stack.
@@ -352,6 +447,7 @@ func (cv *Canvas) navigateToNoHistory(gtx layout.Context, start exptrace.Time, n
 }
 
 func (cv *Canvas) navigateToImpl(gtx layout.Context, start exptrace.Time, nsPerPx float64, y normalizedY) {
+	cv.kinetic.active = false
 	cv.animate.Start(gtx, canvasAnimation{cv.start, cv.nsPerPx, cv.y}, canvasAnimation{start, nsPerPx, y}, animateLength, nil)
 }
 
@@ -367,6 +463,9 @@ func (cv *Canvas) unchanged(gtx layout.Context) bool {
 		cv.prevFrame.compact == cv.timeline.compact &&
 		cv.prevFrame.displayStackTracks == cv.timeline.displayStackTracks &&
 		cv.prevFrame.filter == cv.timeline.filter &&
+		cv.prevFrame.spanColors == cv.spanColors &&
+		cv.prevFrame.heightsGen == cv.timeline.heightsGen &&
+		cv.prevFrame.timeFormat == currentTimeFormatConfig() &&
 		cv.prevFrame.metric == gtx.Metric
 }
 
@@ -379,11 +478,8 @@ func (cv *Canvas) abortZoomSelection() {
 	cv.zoomSelection.active = false
 }
 
-func (cv *Canvas) endZoomSelection(win *theme.Window, gtx layout.Context, pos f32.Point) {
-	cv.zoomSelection.active = false
-	one := cv.zoomSelection.clickAt.X
-	two := pos.X
-
+// selectedRange returns the time range between two horizontal positions, clamped to the visible area of the canvas.
+func (cv *Canvas) selectedRange(win *theme.Window, gtx layout.Context, one, two float32) (start, end exptrace.Time) {
 	startPx := min(one, two)
 	endPx := max(one, two)
 
@@ -394,8 +490,12 @@ func (cv *Canvas) endZoomSelection(win *theme.Window, gtx layout.Context, pos f3
 		endPx = limit
 	}
 
-	start := cv.pxToTs(startPx)
-	end := cv.pxToTs(endPx)
+	return cv.pxToTs(startPx), cv.pxToTs(endPx)
+}
+
+func (cv *Canvas) endZoomSelection(win *theme.Window, gtx layout.Context, pos f32.Point) {
+	cv.zoomSelection.active = false
+	start, end := cv.selectedRange(win, gtx, cv.zoomSelection.clickAt.X, pos.X)
 	if start == end {
 		// Cannot zoom to a zero width area
 		return
@@ -404,6 +504,25 @@ func (cv *Canvas) endZoomSelection(win *theme.Window, gtx layout.Context, pos f3
 	cv.navigateToStartAndEnd(gtx, start, end, cv.y)
 }
 
+func (cv *Canvas) startRangeSelection(pos f32.Point) {
+	cv.rangeSelection.active = true
+	cv.rangeSelection.clickAt = pos
+}
+
+func (cv *Canvas) abortRangeSelection() {
+	cv.rangeSelection.active = false
+}
+
+func (cv *Canvas) endRangeSelection(win *theme.Window, gtx layout.Context, pos f32.Point) {
+	cv.rangeSelection.active = false
+	start, end := cv.selectedRange(win, gtx, cv.rangeSelection.clickAt.X, pos.X)
+	if start == end {
+		return
+	}
+
+	win.EmitAction(&OpenPanelAction{NewRangeSummary(win, cv.trace, start, end)})
+}
+
 func (cv *Canvas) startDrag(pos f32.Point) {
 	cv.cancelNavigation()
 
@@ -411,13 +530,58 @@ func (cv *Canvas) startDrag(pos f32.Point) {
 	cv.drag.active = true
 	cv.drag.start = cv.start
 	cv.drag.startY = cv.y
+
+	cv.kinetic.active = false
+	cv.kinetic.velocity = f32.Point{}
+	cv.kinetic.lastPos = pos
+	cv.kinetic.lastTime = 0
 }
 
-func (cv *Canvas) endDrag() {
+func (cv *Canvas) endDrag(gtx layout.Context, at time.Duration) {
 	cv.drag.active = false
+	// Only keep moving if the pointer was still moving when it was released.
+	if !currentNavigationConfig().DisableKineticPanning && at-cv.kinetic.lastTime < kineticMaxIdle {
+		v := cv.kinetic.velocity
+		if minV := float32(gtx.Dp(kineticMinVelocityDp)); v.X*v.X+v.Y*v.Y > minV*minV {
+			cv.kinetic.active = true
+			cv.kinetic.prevFrame = gtx.Now
+			return
+		}
+	}
 	cv.rememberLocation()
 }
 
+// trackDragVelocity updates the velocity of the drag, which kinetic panning continues with when the drag ends.
+func (cv *Canvas) trackDragVelocity(pos f32.Point, at time.Duration) {
+	if dt := at - cv.kinetic.lastTime; dt > 0 {
+		v := pos.Sub(cv.kinetic.lastPos).Mul(float32(time.Second) / float32(dt))
+		// Smooth the velocity, because the time between pointer events is irregular.
+		cv.kinetic.velocity = v.Mul(0.8).Add(cv.kinetic.velocity.Mul(0.2))
+	}
+	cv.kinetic.lastPos = pos
+	cv.kinetic.lastTime = at
+}
+
+// stepKinetic continues panning after the end of a drag, decelerating until the canvas comes to a halt.
+func (cv *Canvas) stepKinetic(gtx layout.Context) {
+	if !cv.kinetic.active {
+		return
+	}
+	dt := float32(gtx.Now.Sub(cv.kinetic.prevFrame).Seconds())
+	cv.kinetic.prevFrame = gtx.Now
+	// Dragging moves the contents along with the pointer, which is the opposite of scrolling.
+	cv.scroll(gtx, -cv.kinetic.velocity.X*dt, -cv.kinetic.velocity.Y*dt)
+	cv.kinetic.velocity = cv.kinetic.velocity.Mul(float32(math.Exp(-float64(dt) / kineticDecay.Seconds())))
+
+	v := cv.kinetic.velocity
+	if stopV := float32(gtx.Dp(kineticStopVelocityDp)); v.X*v.X+v.Y*v.Y < stopV*stopV {
+		cv.kinetic.active = false
+		cv.rememberLocation()
+		return
+	}
+	op.InvalidateOp{}.Add(gtx.Ops)
+}
+
 func (cv *Canvas) dragTo(gtx layout.Context, pos f32.Point) {
 	// Pan in increments of nsPerPx, roughly. If we pan by exactly n*nsPerPx
 	// then the timelines and--more importantly--the plots can move by exactly n
@@ -441,25 +605,307 @@ func (cv *Canvas) zoom(ticks float64, at f32.Point) {
 	// TODO(dh): implement location history for zooming. We shouldn't record one entry per call to zoom, and instead
 	// only record on calls that weren't immediately preceeded by other calls to zoom.
 
-	const scrollSpeed = 0.001
+	cfg := currentNavigationConfig()
+	if cfg.ZoomAroundCenter {
+		at.X = float32(cv.width) / 2
+	}
+	scrollSpeed := 0.001 * cfg.ZoomSpeed
+	ratio := min(scrollSpeed*math.Abs(ticks), 0.9)
+	// Scrolling up == into the screen == zooming in. Opposite for scrolling
+	// down.
+	if ticks < 0 {
+		cv.zoomTo(cv.nsPerPx*(1-ratio), at)
+	} else {
+		cv.zoomTo(cv.nsPerPx/(1-ratio), at)
+	}
+}
+
+// zoomTo changes the zoom level to nsPerPx, keeping the timestamp under the horizontal position at in place.
+func (cv *Canvas) zoomTo(nsPerPx float64, at f32.Point) {
 	// Limit canvas to roughly one day. There's no reason to zoom out this
 	// far, and zooming out further will lead to edge cases and eventually
 	// overflow.
 	maxNsPerPx := float64(24*time.Hour) / float64(cv.width)
 	ts := cv.pxToTs(at.X)
-	ratio := scrollSpeed * math.Abs(ticks)
-	// Scrolling up == into the screen == zooming in. Opposite for scrolling
-	// down.
-	var new float64
-	if ticks < 0 {
-		new = cv.nsPerPx * (1 - ratio)
-	} else {
-		new = cv.nsPerPx / (1 - ratio)
+	nsPerPx = max(nsPerPx, minNsPerPx)
+	nsPerPx = min(nsPerPx, maxNsPerPx)
+	cv.nsPerPx = nsPerPx
+	cv.start = ts - exptrace.Time(math.Round(float64(at.X)*nsPerPx))
+}
+
+// handleTouch tracks touch points and implements two-finger gestures. It reports whether it consumed the event, in
+// which case the event must not be treated as part of a normal drag.
+func (cv *Canvas) handleTouch(gtx layout.Context, ev pointer.Event) bool {
+	t := &cv.touch
+	idx := -1
+	for i := 0; i < t.n; i++ {
+		if t.ids[i] == ev.PointerID {
+			idx = i
+		}
+	}
+
+	measure := func() (dist float32, mid f32.Point) {
+		a, b := t.positions[0], t.positions[1]
+		return max(float32(math.Abs(float64(a.X-b.X))), 1), a.Add(b).Mul(0.5)
+	}
+
+	switch ev.Kind {
+	case pointer.Press:
+		if idx == -1 && t.n < len(t.ids) {
+			t.ids[t.n] = ev.PointerID
+			t.positions[t.n] = ev.Position
+			t.n++
+		}
+		if t.n == 2 && !t.pinching {
+			// The second finger turns a drag into a two-finger gesture.
+			cv.cancelNavigation()
+			cv.drag.active = false
+			cv.drag.ready = false
+			cv.abortZoomSelection()
+			cv.abortRangeSelection()
+			t.pinching = true
+			t.prevDist, t.prevMid = measure()
+		}
+		return t.pinching
+
+	case pointer.Drag:
+		if idx == -1 {
+			return t.pinching
+		}
+		t.positions[idx] = ev.Position
+		if !t.pinching {
+			return false
+		}
+		dist, mid := measure()
+		cv.zoomTo(cv.nsPerPx*float64(t.prevDist/dist), mid)
+		cv.scroll(gtx, t.prevMid.X-mid.X, t.prevMid.Y-mid.Y)
+		t.prevDist, t.prevMid = dist, mid
+		return true
+
+	case pointer.Release, pointer.Cancel:
+		if idx != -1 {
+			copy(t.ids[idx:], t.ids[idx+1:])
+			copy(t.positions[idx:], t.positions[idx+1:])
+			t.n--
+		}
+		if t.pinching {
+			// Lifting either finger ends the gesture. The remaining finger doesn't start a new drag.
+			if t.n < 2 {
+				t.pinching = false
+				cv.rememberLocation()
+			}
+			return true
+		}
+		return false
 	}
-	new = max(new, minNsPerPx)
-	new = min(new, maxNsPerPx)
-	cv.nsPerPx = new
-	cv.start = ts - exptrace.Time(math.Round(float64(at.X)*new))
+	return t.pinching
+}
+
+// canvasKeys are the keys that the canvas handles while it has keyboard focus.
+const canvasKeys = "←|→|↑|↓|⏎"
+
+// updateKeyboard processes the canvas's keyboard input.
+func (cv *Canvas) updateKeyboard(win *theme.Window, gtx layout.Context) {
+	for _, ev := range gtx.Events(&cv.keyboard) {
+		switch ev := ev.(type) {
+		case key.FocusEvent:
+			cv.keyboard.focused = ev.Focus
+			if ev.Focus && cv.keyboardTimelineIndex() == -1 {
+				// Start with a visible cursor, so that the user can see where the focus went.
+				cv.moveKeyboardTimeline(gtx, 1)
+			}
+		case key.Event:
+			if ev.State != key.Press {
+				continue
+			}
+			switch ev.Name {
+			case key.NameUpArrow:
+				cv.moveKeyboardTimeline(gtx, -1)
+			case key.NameDownArrow:
+				cv.moveKeyboardTimeline(gtx, 1)
+			case key.NameLeftArrow:
+				cv.moveKeyboardSpan(gtx, -1)
+			case key.NameRightArrow:
+				cv.moveKeyboardSpan(gtx, 1)
+			case key.NameReturn:
+				if spans, ok := cv.keyboardSpan(); ok {
+					win.EmitAction(&OpenSpansAction{Spans: spans})
+				}
+			}
+		}
+	}
+}
+
+// keyboardTimelineIndex returns the index of the timeline under the keyboard cursor, or -1 if there is none.
+func (cv *Canvas) keyboardTimelineIndex() int {
+	tl := cv.keyboard.timeline
+	if tl == nil {
+		return -1
+	}
+	if i := cv.keyboard.index; i < len(cv.timelines) && cv.timelines[i] == tl {
+		return i
+	}
+	cv.keyboard.index = slices.Index(cv.timelines, tl)
+	if cv.keyboard.index == -1 {
+		cv.keyboard.timeline = nil
+	}
+	return cv.keyboard.index
+}
+
+// keyboardTrackSpans returns the spans of a timeline's first track, which the keyboard cursor moves between.
+func keyboardTrackSpans(tl *Timeline) (Items[ptrace.Span], bool) {
+	if len(tl.tracks) == 0 || tl.tracks[0].spans == nil {
+		return nil, false
+	}
+	spans, ok := tl.tracks[0].spans.ResultNoWait()
+	return spans, ok && spans.Len() > 0
+}
+
+// keyboardSpan returns the span under the keyboard cursor.
+func (cv *Canvas) keyboardSpan() (Items[ptrace.Span], bool) {
+	if cv.keyboardTimelineIndex() == -1 {
+		return nil, false
+	}
+	spans, ok := keyboardTrackSpans(cv.keyboard.timeline)
+	if !ok {
+		return nil, false
+	}
+	i := min(max(cv.keyboard.span, 0), spans.Len()-1)
+	return spans.Slice(i, i+1), true
+}
+
+// moveKeyboardTimeline moves the keyboard cursor to the next (dir = 1) or previous (dir = -1) timeline that has
+// spans, selecting the span in the middle of the visible time range. Without a cursor, it starts at the first visible
+// timeline.
+func (cv *Canvas) moveKeyboardTimeline(gtx layout.Context, dir int) {
+	idx := cv.keyboardTimelineIndex()
+	if idx == -1 {
+		start, _ := cv.visibleTimelines(gtx)
+		idx = start - dir
+	}
+	for i := idx + dir; i >= 0 && i < len(cv.timelines); i += dir {
+		tl := cv.timelines[i]
+		if tl.hidden {
+			continue
+		}
+		spans, ok := keyboardTrackSpans(tl)
+		if !ok {
+			continue
+		}
+		mid := cv.start + exptrace.Time(float64(cv.width)*cv.nsPerPx/2)
+		cv.keyboard.timeline = tl
+		cv.keyboard.index = i
+		cv.keyboard.span = min(sort.Search(spans.Len(), func(j int) bool {
+			return spans.AtPtr(j).End > mid
+		}), spans.Len()-1)
+		cv.revealKeyboardCursor(gtx)
+		return
+	}
+}
+
+// moveKeyboardSpan moves the keyboard cursor to the next (dir = 1) or previous (dir = -1) span of the timeline.
+func (cv *Canvas) moveKeyboardSpan(gtx layout.Context, dir int) {
+	if cv.keyboardTimelineIndex() == -1 {
+		cv.moveKeyboardTimeline(gtx, 1)
+		return
+	}
+	spans, ok := keyboardTrackSpans(cv.keyboard.timeline)
+	if !ok {
+		return
+	}
+	cv.keyboard.span = min(max(cv.keyboard.span+dir, 0), spans.Len()-1)
+	cv.revealKeyboardCursor(gtx)
+}
+
+// revealKeyboardCursor scrolls and pans the canvas so that the span under the keyboard cursor is visible, keeping the
+// zoom level.
+func (cv *Canvas) revealKeyboardCursor(gtx layout.Context) {
+	idx := cv.keyboardTimelineIndex()
+	spans, ok := cv.keyboardSpan()
+	if !ok {
+		return
+	}
+
+	y := cv.y
+	top := 0
+	if idx > 0 {
+		top = cv.timelineEnds[idx-1]
+	}
+	if cvy := cv.denormalizeY(gtx, cv.y); top < cvy || cv.timelineEnds[idx] > cvy+cv.keyboard.height {
+		y = cv.normalizeY(gtx, top)
+	}
+
+	start := cv.start
+	s := spans.AtPtr(0)
+	if s.Start < cv.start || s.End > cv.End() {
+		if d := s.End - s.Start; float64(d) >= float64(cv.width)*cv.nsPerPx {
+			start = s.Start
+		} else {
+			// Center the span.
+			start = s.Start + d/2 - exptrace.Time(float64(cv.width)*cv.nsPerPx/2)
+		}
+	}
+
+	if y != cv.y || start != cv.start {
+		cv.navigateTo(gtx, start, cv.nsPerPx, y)
+	}
+}
+
+// layoutKeyboardCursor marks the span under the keyboard cursor and displays its tooltip. gtx must describe the
+// timelines area.
+func (cv *Canvas) layoutKeyboardCursor(win *theme.Window, gtx layout.Context) {
+	idx := cv.keyboardTimelineIndex()
+	spans, ok := cv.keyboardSpan()
+	if !ok {
+		return
+	}
+	cvy := cv.denormalizeY(gtx, cv.y)
+	top := -cvy
+	if idx > 0 {
+		top += cv.timelineEnds[idx-1]
+	}
+	bottom := cv.timelineEnds[idx] - cvy
+	s := spans.AtPtr(0)
+	x0 := int(max(cv.tsToPx(s.Start), 0))
+	x1 := max(int(min(cv.tsToPx(s.End), float32(cv.width))), x0+gtx.Dp(minSpanWidthDp))
+	if bottom <= 0 || top >= gtx.Constraints.Max.Y || x1 <= 0 || x0 >= cv.width {
+		return
+	}
+
+	func() {
+		size := image.Pt(x1-x0, bottom-top)
+		defer op.Offset(image.Pt(x0, top)).Push(gtx.Ops).Pop()
+		gtx := gtx
+		gtx.Constraints = layout.Exact(size)
+		theme.FocusRing{Focused: true}.Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+			return layout.Dimensions{Size: size}
+		})
+	}()
+
+	if cv.timeline.showTooltips == showTooltipsNone {
+		return
+	}
+	tooltip := cv.keyboard.timeline.tracks[0].spanTooltip
+	if tooltip == nil {
+		tooltip = defaultSpanTooltip
+	}
+	tgtx := gtx
+	tgtx.Constraints.Min = image.Point{}
+	m := op.Record(gtx.Ops)
+	dims := tooltip(win, tgtx, cv.trace, spans)
+	call := m.Stop()
+
+	// Display the tooltip below the span, or above it if there isn't enough space.
+	pos := image.Pt(min(x0, gtx.Constraints.Max.X-dims.Size.X), bottom)
+	if pos.Y+dims.Size.Y > gtx.Constraints.Max.Y {
+		pos.Y = top - dims.Size.Y
+	}
+	pos.X = max(pos.X, 0)
+	pos.Y = max(pos.Y, 0)
+	m = op.Record(gtx.Ops)
+	op.Offset(pos).Add(gtx.Ops)
+	call.Add(gtx.Ops)
+	op.Defer(gtx.Ops, m.Stop())
 }
 
 func (cv *Canvas) visibleSpans(spans Items[ptrace.Span]) Items[ptrace.Span] {
@@ -491,6 +937,9 @@ func (cv *Canvas) ZoomToFitCurrentView(gtx layout.Context) {
 	var first, last exptrace.Time = -1, -1
 	start, end := cv.visibleTimelines(gtx)
 	for _, tl := range cv.timelines[start:end] {
+		if tl.hidden {
+			continue
+		}
 		for _, track := range tl.tracks {
 			if track.kind == TrackKindStack && !cv.timeline.displayStackTracks {
 				continue
@@ -538,11 +987,13 @@ func (cv *Canvas) objectY(gtx layout.Context, act any) normalizedY {
 }
 
 func (cv *Canvas) scrollToTimeline(gtx layout.Context, tl *Timeline) {
+	cv.revealTimeline(tl)
 	off := cv.timelineY(gtx, tl)
 	cv.navigateTo(gtx, cv.start, cv.nsPerPx, off)
 }
 
 func (cv *Canvas) scrollToObject(gtx layout.Context, act any) {
+	cv.revealTimeline(cv.itemToTimeline[act])
 	off := cv.objectY(gtx, act)
 	cv.navigateTo(gtx, cv.start, cv.nsPerPx, off)
 }
@@ -558,6 +1009,7 @@ func (cv *Canvas) height(gtx layout.Context) int {
 	cch := &cv.cachedCanvasHeight
 	if cch.compact == cv.timeline.compact &&
 		cch.displayStackTracks == cv.timeline.displayStackTracks &&
+		cch.heightsGen == cv.timeline.heightsGen &&
 		cch.metric == gtx.Metric &&
 		cch.height != 0 {
 		return cch.height
@@ -578,6 +1030,7 @@ func (cv *Canvas) height(gtx layout.Context) int {
 
 	cch.compact = cv.timeline.compact
 	cch.displayStackTracks = cv.timeline.displayStackTracks
+	cch.heightsGen = cv.timeline.heightsGen
 	cch.metric = gtx.Metric
 	cch.height = total
 	return total
@@ -615,10 +1068,93 @@ func (cv *Canvas) ToggleTimelineLabels() {
 	cv.timeline.displayAllLabels = !cv.timeline.displayAllLabels
 }
 
+func (cv *Canvas) ToggleHideIdleGoroutines() {
+	cv.timeline.hideIdle = !cv.timeline.hideIdle
+	cv.updateHiddenTimelines()
+}
+
+func (cv *Canvas) ToggleHideShortLivedGoroutines() {
+	cv.timeline.hideShortLived = !cv.timeline.hideShortLived
+	cv.updateHiddenTimelines()
+}
+
+// NumHiddenTimelines returns the number of timelines that are currently hidden.
+func (cv *Canvas) NumHiddenTimelines() int {
+	n := 0
+	for _, tl := range cv.timelines {
+		if tl.hidden {
+			n++
+		}
+	}
+	return n
+}
+
+// SetTimelineFilter replaces the filter that decides which goroutine timelines to show.
+func (cv *Canvas) SetTimelineFilter(f TimelineFilter) {
+	cv.timeline.goroutineFilter = f
+	cv.updateHiddenTimelines()
+}
+
+// updateHiddenTimelines hides and reveals goroutine timelines according to the hideIdle and hideShortLived options
+// and the timeline filter.
+func (cv *Canvas) updateHiddenTimelines() {
+	match := cv.timeline.goroutineFilter.matcher(cv.trace)
+	minLifetime := exptrace.Time(float64(cv.trace.Duration()) * shortLivedGoroutineFraction)
+	cv.timeline.numHiddenIdle = 0
+	cv.timeline.numHiddenShortLived = 0
+	for _, tl := range cv.timelines {
+		g, ok := tl.item.(*ptrace.Goroutine)
+		if !ok {
+			continue
+		}
+		idle := cv.timeline.hideIdle && runningTime(g) < idleGoroutineThreshold
+		shortLived := cv.timeline.hideShortLived && g.EffectiveEnd()-g.EffectiveStart() < minLifetime
+		if idle {
+			cv.timeline.numHiddenIdle++
+		}
+		if shortLived {
+			cv.timeline.numHiddenShortLived++
+		}
+		tl.hidden = idle || shortLived || !match(g)
+		if tl.hidden {
+			// Don't hold on to the indices of timelines that can't be hovered.
+			tl.dropSpanIndex()
+		}
+	}
+	cv.timeline.heightsGen++
+}
+
+// revealTimeline shows a hidden timeline, for example because the user navigated to it. It stays visible until the
+// hidden timelines are recomputed.
+func (cv *Canvas) revealTimeline(tl *Timeline) {
+	if tl == nil || !tl.hidden {
+		return
+	}
+	tl.hidden = false
+	cv.timeline.heightsGen++
+}
+
+// runningTime returns the total time g spent running.
+func runningTime(g *ptrace.Goroutine) time.Duration {
+	var d time.Duration
+	for i := range g.Spans {
+		switch span := &g.Spans[i]; span.State {
+		case ptrace.StateActive, ptrace.StateGCDedicated, ptrace.StateGCIdle:
+			d += span.Duration()
+		}
+	}
+	return d
+}
+
 func (cv *Canvas) scroll(gtx layout.Context, dx, dy float32) {
 	// TODO(dh): implement location history for scrolling. We shouldn't record one entry per call to scroll, and instead
 	// only record on calls that weren't immediately preceeded by other calls to scroll.
-	cv.y += cv.normalizeY(gtx, int(round32(dy)))
+	// Trackpads produce fractional scroll amounts. Accumulate them instead of rounding them away, so that slow
+	// scrolling still moves the canvas.
+	cv.scrollRemainderY += dy
+	px := int(cv.scrollRemainderY)
+	cv.scrollRemainderY -= float32(px)
+	cv.y += cv.normalizeY(gtx, px)
 	if cv.y < 0 {
 		cv.y = 0
 	}
@@ -627,11 +1163,73 @@ func (cv *Canvas) scroll(gtx layout.Context, dx, dy float32) {
 	cv.start += exptrace.Time(math.Round(float64(dx) * cv.nsPerPx))
 }
 
+// opsMemoryUsage returns the memory used by the op buffers of track widgets that are in use by tracks and that are
+// cached for later reuse.
+func (cv *Canvas) opsMemoryUsage() (used, cached int) {
+	for _, tl := range cv.timelines {
+		for _, track := range tl.tracks {
+			if track.widget != nil {
+				used += track.widget.opsSize()
+			}
+		}
+	}
+	for _, tw := range cv.trackWidgetsCache.Items() {
+		cached += tw.opsSize()
+	}
+	return used, cached
+}
+
+// compactOps frees the op buffers of cached track widgets, least recently used first, until the op buffers of all
+// track widgets fit in their share of cacheMemoryLimit. Buffers of widgets that are in use are never freed.
+func (cv *Canvas) compactOps() {
+	_, _, limit := cacheMemoryLimits()
+	used, cached := cv.opsMemoryUsage()
+	// The cache hands out the most recently cached widgets first, which makes the first widgets the least recently
+	// used ones.
+	for _, tw := range cv.trackWidgetsCache.Items() {
+		if uint64(used+cached) <= limit {
+			break
+		}
+		cached -= tw.opsSize()
+		tw.releaseOps()
+	}
+}
+
+// invalidateTextures discards the contents of all textures, for example because the colors of spans changed.
+func (cv *Canvas) invalidateTextures() {
+	for _, tl := range cv.timelines {
+		for _, track := range tl.tracks {
+			cv.textures.Invalidate(track.rnd.textures)
+		}
+	}
+}
+
+// memoryUsage describes the memory used by the canvas's caches.
+func (cv *Canvas) memoryUsage() string {
+	used, cached := cv.opsMemoryUsage()
+	var (
+		rgbas      = cv.textures.Stats.RealizedRGBAs.Load() * texWidth * 4
+		compressed = cv.textures.Stats.CompressedSize.Load()
+		total      = rgbas + compressed + uint64(used) + uint64(cached)
+	)
+	return fmt.Sprintf("Caches use %.2f MiB of %.2f MiB: textures %.2f MiB, compressed textures %.2f MiB, ops %.2f MiB (%.2f MiB unused)",
+		mib(total), mib(currentCacheMemoryLimit()), mib(rgbas), mib(compressed), mib(uint64(used+cached)), mib(uint64(cached)))
+}
+
+func mib(n uint64) float64 {
+	return float64(n) / 1024 / 1024
+}
+
 func (cv *Canvas) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
 	defer rtrace.StartRegion(context.Background(), "main.Canvas.Layout").End()
 
 	if win.Frame%compactInterval == 0 {
 		cv.textures.Compact()
+		cv.compactOps()
+	}
+	if gen := spanColorsGeneration(); gen != cv.spanColors {
+		cv.spanColors = gen
+		cv.invalidateTextures()
 	}
 
 	// Compute the width. This has to make assumptions about the width of the timelines, because we need it
@@ -656,8 +1254,23 @@ func (cv *Canvas) Layout(win *theme.Window, gtx layout.Context) layout.Dimension
 
 	if cv.hover.Update(gtx.Queue) {
 		cv.pointerAt = cv.hover.Pointer()
+		win.AddStatus(theme.StatusSegment{
+			Text: "Cursor: " + formatTimestamp(nil, cv.trace.AdjustedTime(cv.pxToTs(cv.pointerAt.X))),
+		})
+	}
+	if cv.zoomSelection.active || cv.rangeSelection.active {
+		clickAt := cv.zoomSelection.clickAt
+		if cv.rangeSelection.active {
+			clickAt = cv.rangeSelection.clickAt
+		}
+		d := time.Duration(cv.pxToTs(cv.pointerAt.X) - cv.pxToTs(clickAt.X))
+		win.AddStatus(theme.StatusSegment{
+			Text: "Selection: " + roundDuration(max(d, -d)).String(),
+		})
 	}
 
+	cv.stepKinetic(gtx)
+
 	if !cv.animate.Done() {
 		initialStart := float64(cv.animate.StartValue.start)
 		initialNsPerPx := float64(cv.animate.StartValue.nsPerPx)
@@ -684,29 +1297,33 @@ func (cv *Canvas) Layout(win *theme.Window, gtx layout.Context) layout.Dimension
 		cv.y = v.y
 	}
 
-	win.AddShortcut(theme.Shortcut{Name: key.NameHome})
-	win.AddShortcut(theme.Shortcut{Name: key.NameHome, Modifiers: key.ModShortcut})
-	win.AddShortcut(theme.Shortcut{Name: key.NameHome, Modifiers: key.ModShift})
-	win.AddShortcut(theme.Shortcut{Name: "S"})
-	win.AddShortcut(theme.Shortcut{Name: "Z", Modifiers: key.ModShortcut})
-	win.AddShortcut(theme.Shortcut{Name: "Y", Modifiers: key.ModShortcut})
-	win.AddShortcut(theme.Shortcut{Name: "X"})
-	win.AddShortcut(theme.Shortcut{Name: "C"})
-	win.AddShortcut(theme.Shortcut{Name: "T"})
-	win.AddShortcut(theme.Shortcut{Name: "O"})
+	keymap.Register(win,
+		keyScrollToTop,
+		keyZoomToFit,
+		keyJumpToBeginning,
+		keyToggleStackTracks,
+		keyUndoNavigation,
+		keyRedoNavigation,
+		keyToggleTimelineLabels,
+		keyToggleCompactDisplay,
+		keyCycleTooltips,
+		keyCycleGCOverlays,
+		keyInspectTime,
+		keyAddBookmark,
+	)
 
 	for _, s := range win.PressedShortcuts() {
-		switch s {
-		case theme.Shortcut{Name: key.NameHome, Modifiers: 0}:
+		switch {
+		case keymap.Matches(keyScrollToTop, s):
 			cv.ScrollToTop(gtx)
 
-		case theme.Shortcut{Name: key.NameHome, Modifiers: key.ModShortcut}:
+		case keymap.Matches(keyZoomToFit, s):
 			cv.ZoomToFitCurrentView(gtx)
 
-		case theme.Shortcut{Name: key.NameHome, Modifiers: key.ModShift}:
+		case keymap.Matches(keyJumpToBeginning, s):
 			cv.JumpToBeginning(gtx)
 
-		case theme.Shortcut{Name: "S"}:
+		case keymap.Matches(keyToggleStackTracks, s):
 			cv.ToggleStackTracks()
 			if h := cv.timeline.hoveredTimeline; h != nil {
 				cv.cancelNavigation()
@@ -722,16 +1339,16 @@ func (cv *Canvas) Layout(win *theme.Window, gtx layout.Context) layout.Dimension
 				cv.y = y - cv.normalizeY(gtx, int(cv.timeline.hover.Pointer().Y)-int(offset))
 			}
 
-		case theme.Shortcut{Name: "Z", Modifiers: key.ModShortcut}:
+		case keymap.Matches(keyUndoNavigation, s):
 			cv.UndoNavigation(gtx)
 
-		case theme.Shortcut{Name: "Y", Modifiers: key.ModShortcut}:
+		case keymap.Matches(keyRedoNavigation, s):
 			cv.RedoNavigation(gtx)
 
-		case theme.Shortcut{Name: "X"}:
+		case keymap.Matches(keyToggleTimelineLabels, s):
 			cv.ToggleTimelineLabels()
 
-		case theme.Shortcut{Name: "C"}:
+		case keymap.Matches(keyToggleCompactDisplay, s):
 			cv.ToggleCompactDisplay()
 			if h := cv.timeline.hoveredTimeline; h != nil {
 				cv.cancelNavigation()
@@ -751,13 +1368,21 @@ func (cv *Canvas) Layout(win *theme.Window, gtx layout.Context) layout.Dimension
 				cv.y = y - cv.normalizeY(gtx, int(cv.timeline.hover.Pointer().Y)-int(offset))
 			}
 
-		case theme.Shortcut{Name: "T"}:
+		case keymap.Matches(keyCycleTooltips, s):
 			cv.timeline.showTooltips = (cv.timeline.showTooltips + 1) % (showTooltipsNone + 1)
 			showTooltipSettingNotification(win, gtx, cv.timeline.showTooltips)
 
-		case theme.Shortcut{Name: "O"}:
+		case keymap.Matches(keyCycleGCOverlays, s):
 			cv.timeline.showGCOverlays = (cv.timeline.showGCOverlays + 1) % (showGCOverlaysBoth + 1)
 			showGCOverlaySettingNotification(win, gtx, cv.timeline.showGCOverlays)
+
+		case keymap.Matches(keyInspectTime, s):
+			win.EmitAction(&OpenPanelAction{NewTimeInspector(cv.trace, cv.pxToTs(cv.pointerAt.X))})
+
+		case keymap.Matches(keyAddBookmark, s):
+			ts := cv.pxToTs(cv.pointerAt.X)
+			b := cv.addBookmark(Bookmark{Start: ts, End: ts})
+			win.ShowNotification(gtx, local.Sprintf("Added %s", b.Name))
 		}
 	}
 
@@ -768,6 +1393,8 @@ func (cv *Canvas) Layout(win *theme.Window, gtx layout.Context) layout.Dimension
 			case pointer.Scroll:
 				// XXX deal with Gio's asinine "scroll focused area into view" behavior when shrinking windows
 				cv.abortZoomSelection()
+				cv.abortRangeSelection()
+				cv.kinetic.active = false
 				switch ev.Modifiers {
 				case key.ModShortcut:
 					cv.zoom(float64(ev.Scroll.Y), ev.Position)
@@ -781,14 +1408,21 @@ func (cv *Canvas) Layout(win *theme.Window, gtx layout.Context) layout.Dimension
 		}
 	}
 
+	cv.drag.drag.MultiTouch = true
 	for _, ev := range cv.drag.drag.Update(gtx.Metric, gtx, gesture.Both) {
+		if ev.Source == pointer.Touch && cv.handleTouch(gtx, ev) {
+			continue
+		}
 		switch ev.Kind {
 		case pointer.Press:
+			cv.kinetic.active = false
 			switch ev.Modifiers {
 			case 0:
 				cv.drag.ready = true
 			case key.ModShortcut:
 				cv.zoomSelection.ready = true
+			case key.ModShift:
+				cv.rangeSelection.ready = true
 			}
 		case pointer.Drag:
 			cv.pointerAt = ev.Position
@@ -796,27 +1430,40 @@ func (cv *Canvas) Layout(win *theme.Window, gtx layout.Context) layout.Dimension
 				cv.startDrag(ev.Position)
 			} else if cv.zoomSelection.ready && !cv.zoomSelection.active {
 				cv.startZoomSelection(ev.Position)
+			} else if cv.rangeSelection.ready && !cv.rangeSelection.active {
+				cv.startRangeSelection(ev.Position)
 			}
 			if cv.drag.active {
 				cv.dragTo(gtx, ev.Position)
+				cv.trackDragVelocity(ev.Position, ev.Time)
 			}
 		case pointer.Release, pointer.Cancel:
 			cv.drag.ready = false
 			cv.zoomSelection.ready = false
+			cv.rangeSelection.ready = false
 			if cv.drag.active {
-				cv.endDrag()
+				cv.endDrag(gtx, ev.Time)
 			}
 			if cv.zoomSelection.active {
 				cv.endZoomSelection(win, gtx, ev.Position)
 			}
+			if cv.rangeSelection.active {
+				if ev.Kind == pointer.Cancel {
+					cv.abortRangeSelection()
+				} else {
+					cv.endRangeSelection(win, gtx, ev.Position)
+				}
+			}
 		}
 	}
 
 	cv.computeTimelinePositions(gtx)
+	cv.updateKeyboard(win, gtx)
 
 	func(gtx layout.Context) {
 		defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
 		cv.hover.Add(gtx.Ops)
+		key.InputOp{Tag: &cv.keyboard, Keys: canvasKeys}.Add(gtx.Ops)
 
 		cv.clickedTimelines = cv.clickedTimelines[:0]
 		cv.rightClickedTimelines = cv.rightClickedTimelines[:0]
@@ -915,7 +1562,7 @@ func (cv *Canvas) Layout(win *theme.Window, gtx layout.Context) layout.Dimension
 			},
 
 			func(gtx layout.Context) layout.Dimensions {
-				return theme.Resize(win.Theme, &cv.resizeMemoryTimelines).Layout(win, gtx,
+				return theme.Splitter(win.Theme, &cv.resizeMemoryTimelines).Layout(win, gtx,
 					func(win *theme.Window, gtx layout.Context) layout.Dimensions {
 						return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
 							layout.Flexed(0.5, func(gtx layout.Context) layout.Dimensions {
@@ -993,8 +1640,13 @@ func (cv *Canvas) Layout(win *theme.Window, gtx layout.Context) layout.Dimension
 									}
 									clear(dones)
 								}
+								cv.mergeTracks(win, gtx)
 								dims, tws := cv.layoutTimelines(win, gtx)
 								cv.prevFrame.displayedTls = tws
+								cv.keyboard.height = gtx.Constraints.Max.Y
+								if cv.keyboard.focused {
+									cv.layoutKeyboardCursor(win, gtx)
+								}
 								return dims
 							}),
 
@@ -1008,6 +1660,8 @@ func (cv *Canvas) Layout(win *theme.Window, gtx layout.Context) layout.Dimension
 
 								fraction := float32(gtx.Constraints.Max.Y) / float32(totalHeight)
 								sb := theme.Scrollbar(win.Theme, &cv.scrollbar)
+								sb.Marks = cv.minimap.Marks(win, gtx, cv, gtx.Constraints.Max.Y)
+								sb.MarkColor = colors[colorStateActive]
 								return sb.Layout(win, gtx, layout.Vertical, float32(cv.y), float32(cv.y)+fraction)
 							}),
 						)
@@ -1016,9 +1670,12 @@ func (cv *Canvas) Layout(win *theme.Window, gtx layout.Context) layout.Dimension
 			},
 		)
 
-		// Draw zoom selection
-		if cv.zoomSelection.active {
+		// Draw zoom or range selection
+		if cv.zoomSelection.active || cv.rangeSelection.active {
 			one := cv.zoomSelection.clickAt.X
+			if cv.rangeSelection.active {
+				one = cv.rangeSelection.clickAt.X
+			}
 			two := cv.pointerAt.X
 			rect := clip.FRect{
 				Min: f32.Pt(min(one, two), 0),
@@ -1058,6 +1715,23 @@ func (cv *Canvas) Layout(win *theme.Window, gtx layout.Context) layout.Dimension
 			c.A = 0.2
 			drawRegionOverlays(sSTW, c, gtx.Constraints.Max.Y)
 		}
+		// Dim the parts of the timelines outside of the global filter's time range
+		if f := &cv.trace.filter; f.HasTimeRange() {
+			c := win.Theme.Palette.Background
+			c.A = 0.6
+			if startPx := cv.tsToPx(f.Start); startPx > 0 {
+				theme.FillShape(win, gtx.Ops, c, clip.FRect{Max: f32.Pt(startPx, float32(gtx.Constraints.Max.Y))}.Op(gtx.Ops))
+			}
+			if endPx := cv.tsToPx(f.End); endPx < float32(gtx.Constraints.Max.X) {
+				theme.FillShape(win, gtx.Ops, c, clip.FRect{Min: f32.Pt(max(endPx, 0), 0), Max: f32.Pt(float32(gtx.Constraints.Max.X), float32(gtx.Constraints.Max.Y))}.Op(gtx.Ops))
+			}
+		}
+		if cv.timeline.syscallOverlay != nil {
+			c := colors[colorStateBlockedSyscall]
+			c.A = 0.3
+			drawRegionOverlays(cv.timeline.syscallOverlay, c, gtx.Constraints.Max.Y)
+		}
+		cv.drawBookmarks(win, gtx)
 
 		// Draw cursor
 		rect := clip.Rect{
@@ -1122,6 +1796,9 @@ func (cv *Canvas) Layout(win *theme.Window, gtx layout.Context) layout.Dimension
 	cv.prevFrame.displayStackTracks = cv.timeline.displayStackTracks
 	cv.prevFrame.hoveredTimeline = cv.timeline.hoveredTimeline
 	cv.prevFrame.filter = cv.timeline.filter
+	cv.prevFrame.spanColors = cv.spanColors
+	cv.prevFrame.timeFormat = currentTimeFormatConfig()
+	cv.prevFrame.heightsGen = cv.timeline.heightsGen
 	cv.prevFrame.metric = gtx.Metric
 
 	cv.clickedSpans = cv.clickedSpans[:0]
@@ -1178,12 +1855,77 @@ func (cv *Canvas) planTimelines(win *theme.Window, gtx layout.Context, texs []Te
 
 	for i := start; i < end; i++ {
 		tl := cv.timelines[i]
+		if tl.hidden {
+			continue
+		}
 		texs = tl.Plan(win, texs)
 		y += tl.Height(gtx, cv)
 	}
 	return texs
 }
 
+// The maximum amount of time per frame to spend on merging spans in parallel. Tracks that couldn't be processed in
+// time will merge their spans during layout.
+const mergeBudget = 4 * time.Millisecond
+
+type mergeJob struct {
+	track *Track
+	spans Items[ptrace.Span]
+}
+
+// mergeTracks merges the spans of all visible tracks ahead of layout, spreading the work across multiple goroutines.
+// Layout itself has to happen on a single goroutine, but finding the spans to display is independent for each track
+// and dominates the cost of laying out tracks with many spans.
+func (cv *Canvas) mergeTracks(win *theme.Window, gtx layout.Context) {
+	defer rtrace.StartRegion(context.Background(), "main.Canvas.mergeTracks").End()
+
+	if cv.unchanged(gtx) {
+		// Tracks will reuse the spans they displayed in the previous frame.
+		return
+	}
+
+	jobs := cv.scratchMergeJobs[:0]
+	start, end := cv.visibleTimelines(gtx)
+	for _, tl := range cv.timelines[start:end] {
+		for _, track := range tl.tracks {
+			if track.widget == nil || (track.kind == TrackKindStack && !cv.timeline.displayStackTracks) {
+				continue
+			}
+			spans, ok := track.Spans(win).ResultNoWait()
+			if !ok || spans.Len() == 0 {
+				continue
+			}
+			jobs = append(jobs, mergeJob{track, spans})
+		}
+	}
+
+	if n := min(runtime.GOMAXPROCS(0), len(jobs)); n > 1 {
+		deadline := time.Now().Add(mergeBudget)
+		var next atomic.Int64
+		var wg sync.WaitGroup
+		wg.Add(n)
+		for range n {
+			go func() {
+				defer wg.Done()
+				for {
+					i := int(next.Add(1) - 1)
+					if i >= len(jobs) || time.Now().After(deadline) {
+						return
+					}
+					job := jobs[i]
+					w := job.track.widget
+					w.merged.spans = job.track.mergeSpans(gtx, job.spans, w.merged.spans[:0])
+					w.merged.frame = win.Frame
+				}
+			}()
+		}
+		wg.Wait()
+	}
+
+	clear(jobs)
+	cv.scratchMergeJobs = jobs[:0]
+}
+
 func (cv *Canvas) layoutTimelines(win *theme.Window, gtx layout.Context) (layout.Dimensions, []*Timeline) {
 	defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
 
@@ -1199,8 +1941,13 @@ func (cv *Canvas) layoutTimelines(win *theme.Window, gtx layout.Context) (layout
 		y = cv.timelineEnds[start-1] - cvy
 	}
 
+	displayed := cv.scratchDisplayedTls[:0]
 	for i := start; i < end; i++ {
 		tl := cv.timelines[i]
+		if tl.hidden {
+			continue
+		}
+		displayed = append(displayed, tl)
 		stack := op.Offset(image.Pt(0, y)).Push(gtx.Ops)
 		topBorder := i > 0 && cv.timelines[i-1].widget.Hovered(gtx)
 		tl.Layout(win, gtx, cv, cv.timeline.displayAllLabels, cv.timeline.compact, topBorder, &cv.trackSpanLabels)
@@ -1224,7 +1971,8 @@ func (cv *Canvas) layoutTimelines(win *theme.Window, gtx layout.Context) (layout
 		}
 	}
 
-	return layout.Dimensions{Size: gtx.Constraints.Max}, cv.timelines[start:end]
+	cv.scratchDisplayedTls = cv.prevFrame.displayedTls[:0]
+	return layout.Dimensions{Size: gtx.Constraints.Max}, displayed
 }
 
 // setPointerPosition updates the canvas's pointer position. This is used by Axis to keep the canvas updated while the
@@ -1284,10 +2032,25 @@ func (axis *Axis) Layout(win *theme.Window, gtx layout.Context) (dims layout.Dim
 
 	for _, ev := range axis.click.Update(gtx.Queue) {
 		if ev.Kind == gesture.KindPress && ev.Button == pointer.ButtonSecondary {
+			t := axis.cv.pxToTs(float32(ev.Position.X))
 			win.SetContextMenu(
 				[]*theme.MenuItem{
 					{
-						Label:    PlainLabel("Move origin to the left"),
+						Label: PlainLabel(tr("Inspect this moment")),
+						Action: func() theme.Action {
+							return &OpenPanelAction{NewTimeInspector(axis.cv.trace, t)}
+						},
+					},
+					{
+						Label: PlainLabel(tr("Filter all views to visible time range")),
+						Action: func() theme.Action {
+							return theme.ExecuteAction(func(gtx layout.Context) {
+								axis.cv.trace.filter.SetTimeRange(axis.cv.start, axis.cv.End())
+							})
+						},
+					},
+					{
+						Label:    PlainLabel(tr("Move origin to the left")),
 						Disabled: func() bool { return axis.anchor == AxisAnchorStart },
 						Action: func() theme.Action {
 							return theme.ExecuteAction(func(gtx layout.Context) {
@@ -1296,7 +2059,7 @@ func (axis *Axis) Layout(win *theme.Window, gtx layout.Context) (dims layout.Dim
 						},
 					},
 					{
-						Label:    PlainLabel("Move origin to the center"),
+						Label:    PlainLabel(tr("Move origin to the center")),
 						Disabled: func() bool { return axis.anchor == AxisAnchorCenter },
 						Action: func() theme.Action {
 							return theme.ExecuteAction(func(gtx layout.Context) {
@@ -1305,7 +2068,7 @@ func (axis *Axis) Layout(win *theme.Window, gtx layout.Context) (dims layout.Dim
 						},
 					},
 					{
-						Label:    PlainLabel("Move origin to the right"),
+						Label:    PlainLabel(tr("Move origin to the right")),
 						Disabled: func() bool { return axis.anchor == AxisAnchorEnd },
 						Action: func() theme.Action {
 							return theme.ExecuteAction(func(gtx layout.Context) {
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+
+	"honnef.co/go/gotraceui/gesture"
+	"honnef.co/go/gotraceui/layout"
+
+	"gioui.org/f32"
+	"gioui.org/io/event"
+	"gioui.org/io/pointer"
+	"gioui.org/op"
+)
+
+type touchQueue []event.Event
+
+func (q touchQueue) Events(event.Tag) []event.Event { return q }
+
+func TestCanvasTwoFingerPress(t *testing.T) {
+	var cv Canvas
+	gtx := layout.Context{Ops: new(op.Ops)}
+	cv.drag.drag.MultiTouch = true
+
+	press := func(id pointer.ID, x float32) {
+		q := touchQueue{pointer.Event{
+			Kind:      pointer.Press,
+			Source:    pointer.Touch,
+			PointerID: id,
+			Position:  f32.Pt(x, 10),
+		}}
+		evs := cv.drag.drag.Update(gtx.Metric, q, gesture.Both)
+		if len(evs) != 1 {
+			t.Fatalf("got %d events for press of pointer %d, want 1", len(evs), id)
+		}
+		cv.handleTouch(gtx, evs[0])
+	}
+
+	press(1, 10)
+	if cv.touch.pinching {
+		t.Fatal("pinching after a single touch")
+	}
+	press(2, 50)
+	if cv.touch.n != 2 {
+		t.Fatalf("tracking %d touch points, want 2", cv.touch.n)
+	}
+	if !cv.touch.pinching {
+		t.Fatal("second touch didn't start pinching")
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	rtrace "runtime/trace"
+	"slices"
+	"time"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/trace/ptrace"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/font"
+	"gioui.org/op/clip"
+	"gioui.org/text"
+	exptrace "golang.org/x/exp/trace"
+)
+
+// A cgoCall is a span of a goroutine calling into C.
+type cgoCall struct {
+	g    *ptrace.Goroutine
+	span *ptrace.Span
+}
+
+// A cgoGroup aggregates cgo calls by the function that was called.
+type cgoGroup struct {
+	// The cgo wrapper of the C function, or the empty string if the stack is unknown.
+	Function   string
+	Calls      []cgoCall
+	Total, Max time.Duration
+}
+
+type cgoReportKey struct {
+	filter globalFilterKey
+}
+
+func computeCgoReport(tr *Trace, f *GlobalFilter) []cgoGroup {
+	defer rtrace.StartRegion(context.Background(), "main.computeCgoReport").End()
+
+	end := tr.End()
+	groups := map[string]*cgoGroup{}
+	for _, g := range tr.Goroutines {
+		if !f.MatchGoroutine(g) {
+			continue
+		}
+		for i := range g.Spans {
+			span := &g.Spans[i]
+			if span.State != ptrace.StateCgo || !f.MatchSpan(span) {
+				continue
+			}
+
+			var fn string
+			if stk := tr.Event(span.StartEvent).Stack(); stk != exptrace.NoStack {
+				fn = tr.FuncName(tr.Stacks[tr.StackID(stk)][span.At])
+			}
+			group, ok := groups[fn]
+			if !ok {
+				group = &cgoGroup{Function: fn}
+				groups[fn] = group
+			}
+			d := span.Duration()
+			if span.EndEvent == -1 {
+				d = time.Duration(end - span.Start)
+			}
+			group.Calls = append(group.Calls, cgoCall{g, span})
+			group.Total += d
+			group.Max = max(group.Max, d)
+		}
+	}
+
+	out := make([]cgoGroup, 0, len(groups))
+	for _, group := range groups {
+		out = append(out, *group)
+	}
+	slices.SortFunc(out, func(a, b cgoGroup) int {
+		return cmp(a.Total, b.Total, true)
+	})
+	return out
+}
+
+// CgoComponent aggregates the time goroutines spent in calls into C by the function they called.
+type CgoComponent struct {
+	trace  *Trace
+	canvas *Canvas
+	groups *theme.Future[[]cgoGroup]
+	// The state of the global filter that groups was computed with.
+	globalKey globalFilterKey
+
+	table         *theme.Table
+	scrollState   theme.YScrollableListState
+	cellFormatter CellFormatter
+	// Lazily computed spans of the groups, indexed by row.
+	spans []Items[ptrace.Span]
+}
+
+func NewCgoComponent(win *theme.Window, tr *Trace, cv *Canvas) *CgoComponent {
+	cc := &CgoComponent{
+		trace:  tr,
+		canvas: cv,
+	}
+	cc.compute(win)
+	return cc
+}
+
+func (cc *CgoComponent) compute(win *theme.Window) {
+	f := cc.trace.filter.Clone()
+	cc.globalKey = f.Key()
+	cc.groups = theme.Compute(&cc.trace.analyses, win, cgoReportKey{cc.globalKey}, func() []cgoGroup {
+		return computeCgoReport(cc.trace, f)
+	})
+	cc.spans = nil
+}
+
+// Title implements theme.Component.
+func (*CgoComponent) Title() string {
+	return tr("Cgo calls")
+}
+
+// Transition implements theme.Component.
+func (*CgoComponent) Transition(state theme.ComponentState) {}
+
+// WantsTransition implements theme.Component.
+func (*CgoComponent) WantsTransition(gtx layout.Context) theme.ComponentState {
+	return theme.ComponentStateNone
+}
+
+func (cc *CgoComponent) HoveredLink() ObjectLink {
+	return cc.cellFormatter.HoveredLink()
+}
+
+func (cc *CgoComponent) initTable(win *theme.Window, gtx layout.Context) {
+	if cc.table != nil {
+		return
+	}
+	cc.table = &theme.Table{}
+	cc.table.SetColumns(win, gtx, []theme.Column{
+		{Name: "Calls", Alignment: text.End},
+		{Name: "Function", Alignment: text.Start},
+		{Name: "Total time", Alignment: text.End},
+		{Name: "Mean time", Alignment: text.End},
+		{Name: "Max time", Alignment: text.End},
+	})
+}
+
+// Layout implements theme.Component.
+func (cc *CgoComponent) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.CgoComponent.Layout").End()
+
+	if cc.trace.filter.Key() != cc.globalKey {
+		cc.compute(win)
+	}
+
+	groups, ok := cc.groups.Result()
+	if !ok {
+		return theme.Loading(win.Theme, tr("Aggregating cgo calls…")).Layout(win, gtx)
+	}
+	if len(groups) == 0 {
+		return layout.Center.Layout(gtx, theme.Dumb(win, theme.Label(win.Theme, tr("No cgo calls were made during the trace.")).Layout))
+	}
+
+	cc.initTable(win, gtx)
+	cc.table.Update(gtx)
+	cc.cellFormatter.Update(win, gtx)
+	if cc.spans == nil {
+		cc.spans = make([]Items[ptrace.Span], len(groups))
+	}
+
+	cellFn := func(win *theme.Window, gtx layout.Context, row, col int) layout.Dimensions {
+		defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+
+		group := &groups[row]
+		switch colName := cc.table.Columns[col].Name; colName {
+		case "Calls":
+			spans := cc.spans[row]
+			if spans == nil {
+				spans = goroutineSpans(cc.canvas, group.Calls, func(c *cgoCall) (*ptrace.Goroutine, *ptrace.Span) {
+					return c.g, c.span
+				})
+				cc.spans[row] = spans
+			}
+			return layout.RightAligned(gtx, func(gtx layout.Context) layout.Dimensions {
+				link := cc.cellFormatter.Clicks.Grow()
+				link.Link = &SpansObjectLink{Spans: spans}
+				return link.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+					return widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, font.Font{}, win.Theme.TextSize, local.Sprintf("%d", len(group.Calls)), win.ColorMaterial(gtx, win.Theme.Palette.OpenLink))
+				})
+			})
+		case "Function":
+			if group.Function == "" {
+				return cc.cellFormatter.Text(win, gtx, "unknown")
+			}
+			return cc.cellFormatter.Text(win, gtx, group.Function)
+		case "Total time":
+			return cc.cellFormatter.Duration(win, gtx, group.Total, false)
+		case "Mean time":
+			return cc.cellFormatter.Duration(win, gtx, group.Total/time.Duration(len(group.Calls)), false)
+		case "Max time":
+			return cc.cellFormatter.Duration(win, gtx, group.Max, false)
+		default:
+			panic(colName)
+		}
+	}
+
+	return theme.SimpleTable(win, gtx, cc.table, &cc.scrollState, len(groups), cellFn)
+}
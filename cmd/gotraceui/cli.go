@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"time"
+
+	"honnef.co/go/gotraceui/trace/ptrace"
+
+	exptrace "golang.org/x/exp/trace"
+)
+
+// A command is a subcommand of gotraceui that runs without a GUI.
+type command struct {
+	name     string
+	synopsis string
+	help     string
+	// run runs the command with the arguments following the command's name and returns the process's exit code. prog
+	// is the name of the executable.
+	run func(prog string, cmd command, args []string) int
+}
+
+var commands = []command{
+	{
+		name:     "stats",
+		synopsis: "[flags] <trace file>",
+		help:     "Print statistics about goroutines, GC and scheduling latencies",
+		run:      runStats,
+	},
+	{
+		name:     "aggregate",
+		synopsis: "[flags] <directory or trace file>...",
+		help: "Report the distributions of GC pauses, scheduling latencies and goroutine counts across many traces,\n" +
+			"such as those of repeated benchmark runs. Directories are searched for traces, in the order of their names.",
+		run: runAggregate,
+	},
+	{
+		name:     "compare",
+		synopsis: "[flags] <old trace> <new trace>",
+		help: "Compare CPU time, blocking time and scheduling latencies of goroutines, grouped by their functions.\n" +
+			"Exits with status 1 if regressions were found, and with status 2 on errors.",
+		run: runCompare,
+	},
+	{
+		name:     "cut",
+		synopsis: "[flags] <input trace> <output trace>",
+		help: "Extract a time window of a trace into a smaller trace.\n" +
+			"Traces can only be cut at generation boundaries, which the runtime emits roughly once a second,\n" +
+			"so the output may contain events slightly outside the requested window. Requires Go 1.22 or newer.",
+		run: runCut,
+	},
+	{
+		name:     "query",
+		synopsis: "[flags] <query> <trace file>",
+		help: "Run a query against the spans, goroutines, user regions, tasks and garbage collections of a trace,\n" +
+			"in the language of the query console or in SQL, such as\n\n" +
+			"\tSELECT function, count(*) AS n FROM spans WHERE duration > 1ms GROUP BY function ORDER BY n DESC\n\n" +
+			"Durations and timestamps are printed in nanoseconds. Timestamps are relative to the start of the trace.",
+		run: runQuery,
+	},
+}
+
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+func commandUsage(prog string, cmd command, fs *flag.FlagSet) func() {
+	return func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s %s\n", prog, cmd.name, cmd.synopsis)
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, cmd.help)
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Flags:")
+		printDefaults(fs)
+	}
+}
+
+// parseTraceFile parses a trace without any of the processing needed for displaying it.
+func parseTraceFile(path string) (*ptrace.Trace, error) {
+	return parseTraceFileFS(newFileSystem(), path)
+}
+
+// parseTraceFileFS is like parseTraceFile, but opens the file in fsys.
+func parseTraceFileFS(fsys fileSystem, path string) (*ptrace.Trace, error) {
+	f, err := fsys.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r, err := exptrace.NewReader(bufio.NewReader(f))
+	if err != nil {
+		return nil, err
+	}
+	return ptrace.Parse(r, func(float64) {})
+}
+
+// DurationStats summarizes a distribution of durations.
+type DurationStats struct {
+	Count int           `json:"count"`
+	Total time.Duration `json:"total_ns"`
+	Min   time.Duration `json:"min_ns"`
+	Max   time.Duration `json:"max_ns"`
+	Mean  time.Duration `json:"mean_ns"`
+	P50   time.Duration `json:"p50_ns"`
+	P90   time.Duration `json:"p90_ns"`
+	P99   time.Duration `json:"p99_ns"`
+}
+
+// computeDurationStats computes statistics for ds. It sorts ds in place.
+func computeDurationStats(ds []time.Duration) DurationStats {
+	if len(ds) == 0 {
+		return DurationStats{}
+	}
+	slices.Sort(ds)
+	var total time.Duration
+	for _, d := range ds {
+		total += d
+	}
+	return DurationStats{
+		Count: len(ds),
+		Total: total,
+		Min:   ds[0],
+		Max:   ds[len(ds)-1],
+		Mean:  total / time.Duration(len(ds)),
+		P50:   percentile(ds, 50),
+		P90:   percentile(ds, 90),
+		P99:   percentile(ds, 99),
+	}
+}
+
+// percentile returns the p-th percentile of the sorted durations, using the nearest-rank method.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := sort.Search(len(sorted), func(i int) bool {
+		return float64(i+1) >= p/100*float64(len(sorted))
+	})
+	return sorted[min(idx, len(sorted)-1)]
+}
+
+func (ds DurationStats) rows(prefix string) [][2]string {
+	return [][2]string{
+		{prefix + ".count", fmt.Sprint(ds.Count)},
+		{prefix + ".total_ns", fmt.Sprint(int64(ds.Total))},
+		{prefix + ".min_ns", fmt.Sprint(int64(ds.Min))},
+		{prefix + ".max_ns", fmt.Sprint(int64(ds.Max))},
+		{prefix + ".mean_ns", fmt.Sprint(int64(ds.Mean))},
+		{prefix + ".p50_ns", fmt.Sprint(int64(ds.P50))},
+		{prefix + ".p90_ns", fmt.Sprint(int64(ds.P90))},
+		{prefix + ".p99_ns", fmt.Sprint(int64(ds.P99))},
+	}
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"honnef.co/go/gotraceui/trace/ptrace"
+
+	exptrace "golang.org/x/exp/trace"
+)
+
+// RunMetrics are the key metrics of a single trace, for comparing repeated runs of the same benchmark.
+type RunMetrics struct {
+	Path                 string        `json:"path"`
+	Duration             time.Duration `json:"duration_ns"`
+	GCPauseP99           time.Duration `json:"gc_pause_p99_ns"`
+	GCFraction           float64       `json:"gc_fraction"`
+	SchedulingLatencyP99 time.Duration `json:"scheduling_latency_p99_ns"`
+	MaxGoroutines        int           `json:"max_goroutines"`
+}
+
+type aggregateMetricKind uint8
+
+const (
+	aggregateDuration aggregateMetricKind = iota
+	aggregateCount
+	aggregateFraction
+)
+
+// An aggregateMetric is a metric whose distribution across runs gets reported.
+type aggregateMetric struct {
+	Name  string
+	Label string
+	Kind  aggregateMetricKind
+	Get   func(*RunMetrics) float64
+}
+
+var aggregateMetrics = []aggregateMetric{
+	{"gc_pause_p99", "GC pause p99", aggregateDuration, func(r *RunMetrics) float64 { return float64(r.GCPauseP99) }},
+	{"gc_fraction", "GC fraction", aggregateFraction, func(r *RunMetrics) float64 { return r.GCFraction }},
+	{"scheduling_latency_p99", "Scheduling latency p99", aggregateDuration, func(r *RunMetrics) float64 { return float64(r.SchedulingLatencyP99) }},
+	{"max_goroutines", "Max goroutines", aggregateCount, func(r *RunMetrics) float64 { return float64(r.MaxGoroutines) }},
+	{"duration", "Duration", aggregateDuration, func(r *RunMetrics) float64 { return float64(r.Duration) }},
+}
+
+// MetricDistribution summarizes the values of a metric across runs. Durations are in nanoseconds.
+type MetricDistribution struct {
+	Metric string  `json:"metric"`
+	Min    float64 `json:"min"`
+	P50    float64 `json:"p50"`
+	Mean   float64 `json:"mean"`
+	Max    float64 `json:"max"`
+	Stddev float64 `json:"stddev"`
+	// Trend is the slope of a linear fit of the values over the runs, in the order of the runs, relative to the mean.
+	// A trend of 0.01 means that the metric grows by about 1% of its mean from one run to the next.
+	Trend float64 `json:"trend"`
+}
+
+// Aggregate is the result of aggregating the metrics of many traces.
+type Aggregate struct {
+	// Runs are sorted by path, which is usually the order in which benchmarks wrote them.
+	Runs    []RunMetrics         `json:"runs"`
+	Metrics []MetricDistribution `json:"metrics"`
+	// Skipped lists the files that couldn't be parsed as traces, and why.
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+// maxGoroutines returns the largest number of goroutines that existed at the same time.
+func maxGoroutines(tr *ptrace.Trace) int {
+	type change struct {
+		ts    exptrace.Time
+		delta int
+	}
+	changes := make([]change, 0, 2*len(tr.Goroutines))
+	for _, g := range tr.Goroutines {
+		changes = append(changes, change{g.EffectiveStart(), 1})
+		if end, ok := g.End.Get(); ok {
+			changes = append(changes, change{end, -1})
+		}
+	}
+	slices.SortFunc(changes, func(a, b change) int {
+		if a.ts != b.ts {
+			return cmp(a.ts, b.ts, false)
+		}
+		// Process ends before starts at the same time, so that goroutines replacing each other don't count twice.
+		return a.delta - b.delta
+	})
+	var cur, peak int
+	for _, c := range changes {
+		cur += c.delta
+		peak = max(peak, cur)
+	}
+	return peak
+}
+
+func ComputeRunMetrics(path string, tr *ptrace.Trace) RunMetrics {
+	stats := ComputeTraceStats(tr)
+	return RunMetrics{
+		Path:                 path,
+		Duration:             stats.Duration,
+		GCPauseP99:           stats.GC.STW.P99,
+		GCFraction:           stats.GC.Fraction,
+		SchedulingLatencyP99: stats.SchedulingLatency.P99,
+		MaxGoroutines:        maxGoroutines(tr),
+	}
+}
+
+func computeMetricDistribution(name string, values []float64) MetricDistribution {
+	dist := MetricDistribution{Metric: name}
+	if len(values) == 0 {
+		return dist
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	dist.Mean = sum / float64(len(values))
+
+	// Fit a line over the run indices, before sorting destroys the order of the runs.
+	var num, den, variance float64
+	mid := float64(len(values)-1) / 2
+	for i, v := range values {
+		num += (float64(i) - mid) * (v - dist.Mean)
+		den += (float64(i) - mid) * (float64(i) - mid)
+		variance += (v - dist.Mean) * (v - dist.Mean)
+	}
+	if den != 0 && dist.Mean != 0 {
+		dist.Trend = num / den / dist.Mean
+	}
+	dist.Stddev = math.Sqrt(variance / float64(len(values)))
+
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	dist.Min = sorted[0]
+	dist.Max = sorted[len(sorted)-1]
+	dist.P50 = sorted[(len(sorted)-1)/2]
+	return dist
+}
+
+// traceFiles expands directories in paths to the files they contain, sorted by name. Hidden files are skipped. Files
+// keep the order of the arguments they came from.
+func traceFiles(fsys fileSystem, paths []string) ([]string, error) {
+	var out []string
+	for _, path := range paths {
+		fi, err := fsys.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !fi.IsDir() {
+			out = append(out, path)
+			continue
+		}
+		entries, err := fsys.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		start := len(out)
+		for _, e := range entries {
+			if !e.Type().IsRegular() || strings.HasPrefix(e.Name(), ".") {
+				continue
+			}
+			out = append(out, filepath.Join(path, e.Name()))
+		}
+		slices.Sort(out[start:])
+	}
+	return out, nil
+}
+
+// aggregateTraces computes the metrics of each trace and their distributions. Files that can't be parsed are
+// skipped. progress gets called before each file is parsed.
+func aggregateTraces(fsys fileSystem, files []string, progress func(i int, path string)) *Aggregate {
+	agg := &Aggregate{}
+	for i, path := range files {
+		progress(i, path)
+		tr, err := parseTraceFileFS(fsys, path)
+		if err != nil {
+			agg.Skipped = append(agg.Skipped, fmt.Sprintf("%s: %s", path, err))
+			continue
+		}
+		agg.Runs = append(agg.Runs, ComputeRunMetrics(path, tr))
+	}
+	values := make([]float64, len(agg.Runs))
+	for _, m := range aggregateMetrics {
+		for i := range agg.Runs {
+			values[i] = m.Get(&agg.Runs[i])
+		}
+		agg.Metrics = append(agg.Metrics, computeMetricDistribution(m.Name, values))
+	}
+	return agg
+}
+
+func writeAggregate(w io.Writer, agg *Aggregate, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "\t")
+		return enc.Encode(agg)
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"metric", "min", "p50", "mean", "max", "stddev", "trend"})
+		f := func(v float64) string { return fmt.Sprint(v) }
+		for _, d := range agg.Metrics {
+			cw.Write([]string{d.Metric, f(d.Min), f(d.P50), f(d.Mean), f(d.Max), f(d.Stddev), f(d.Trend)})
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		return fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+func runAggregate(prog string, cmd command, args []string) int {
+	fs := flag.NewFlagSet(cmd.name, flag.ExitOnError)
+	fs.Usage = commandUsage(prog, cmd, fs)
+	format := fs.String("format", "json", "Output format, either json or csv")
+	verbose := fs.Bool("v", false, "Print the names of traces as they are processed")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+	if *format != "json" && *format != "csv" {
+		fmt.Fprintf(os.Stderr, "unsupported format %q\n", *format)
+		return 2
+	}
+
+	files, err := traceFiles(newFileSystem(), fs.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "couldn't find traces:", err)
+		return 1
+	}
+	agg := aggregateTraces(newFileSystem(), files, func(i int, path string) {
+		if *verbose {
+			fmt.Fprintf(os.Stderr, "[%d/%d] %s\n", i+1, len(files), path)
+		}
+	})
+	for _, s := range agg.Skipped {
+		fmt.Fprintln(os.Stderr, "skipping", s)
+	}
+	if len(agg.Runs) == 0 {
+		fmt.Fprintln(os.Stderr, "no traces could be loaded")
+		return 1
+	}
+	if err := writeAggregate(os.Stdout, agg, *format); err != nil {
+		fmt.Fprintln(os.Stderr, "couldn't write statistics:", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"honnef.co/go/gotraceui/trace/ptrace"
+)
+
+// FunctionStats aggregates the statistics of all goroutines that started in a function.
+type FunctionStats struct {
+	Function   string        `json:"function"`
+	Goroutines int           `json:"goroutines"`
+	CPU        time.Duration `json:"cpu_ns"`
+	Blocked    time.Duration `json:"blocked_ns"`
+	// Time goroutines spent runnable before being scheduled.
+	SchedulingLatency DurationStats `json:"scheduling_latency"`
+}
+
+func ComputeFunctionStats(tr *ptrace.Trace) map[string]*FunctionStats {
+	out := map[string]*FunctionStats{}
+	latencies := map[string][]time.Duration{}
+	for _, g := range tr.Goroutines {
+		if g.Function == nil {
+			continue
+		}
+		fn := g.Function.Func
+		fs, ok := out[fn]
+		if !ok {
+			fs = &FunctionStats{Function: fn}
+			out[fn] = fs
+		}
+		s := ptrace.ComputeStatistics(ptrace.ToSpans(g.Spans))
+		fs.Goroutines++
+		fs.CPU += s[ptrace.StateActive].Total
+		fs.Blocked += s.Blocked()
+		for i := range g.Spans {
+			span := &g.Spans[i]
+			if isSchedulingLatencyState(span.State) {
+				latencies[fn] = append(latencies[fn], span.Duration())
+			}
+		}
+	}
+	for fn, ds := range latencies {
+		out[fn].SchedulingLatency = computeDurationStats(ds)
+	}
+	return out
+}
+
+type comparisonMetric struct {
+	name string
+	get  func(fs *FunctionStats) time.Duration
+}
+
+var comparisonMetrics = []comparisonMetric{
+	{"cpu", func(fs *FunctionStats) time.Duration { return fs.CPU }},
+	{"blocked", func(fs *FunctionStats) time.Duration { return fs.Blocked }},
+	{"latency.p50", func(fs *FunctionStats) time.Duration { return fs.SchedulingLatency.P50 }},
+	{"latency.p90", func(fs *FunctionStats) time.Duration { return fs.SchedulingLatency.P90 }},
+	{"latency.p99", func(fs *FunctionStats) time.Duration { return fs.SchedulingLatency.P99 }},
+}
+
+type ComparisonThresholds struct {
+	// The relative increase, in percent, above which a change is considered a regression.
+	Percent float64
+	// The absolute change below which changes are ignored, to filter out noise.
+	MinDelta time.Duration
+}
+
+type FunctionDelta struct {
+	Function   string        `json:"function"`
+	Metric     string        `json:"metric"`
+	Old        time.Duration `json:"old_ns"`
+	New        time.Duration `json:"new_ns"`
+	Delta      time.Duration `json:"delta_ns"`
+	Percent    float64       `json:"delta_percent"`
+	Regression bool          `json:"regression"`
+}
+
+// MarshalJSON encodes an infinite Percent, which functions without cost in the old trace have, as null, because JSON
+// cannot represent infinity.
+func (d FunctionDelta) MarshalJSON() ([]byte, error) {
+	type delta FunctionDelta
+	v := struct {
+		delta
+		Percent *float64 `json:"delta_percent"`
+	}{delta: delta(d)}
+	if !math.IsInf(d.Percent, 0) {
+		v.Percent = &d.Percent
+	}
+	return json.Marshal(v)
+}
+
+type Comparison struct {
+	Deltas []FunctionDelta `json:"deltas"`
+	// Functions that only exist in one of the two traces.
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+}
+
+func (c *Comparison) Regressions() int {
+	n := 0
+	for _, d := range c.Deltas {
+		if d.Regression {
+			n++
+		}
+	}
+	return n
+}
+
+func CompareFunctionStats(old, new map[string]*FunctionStats, th ComparisonThresholds) *Comparison {
+	var c Comparison
+	names := make([]string, 0, len(new))
+	for fn := range new {
+		if _, ok := old[fn]; ok {
+			names = append(names, fn)
+		} else {
+			c.Added = append(c.Added, fn)
+		}
+	}
+	for fn := range old {
+		if _, ok := new[fn]; !ok {
+			c.Removed = append(c.Removed, fn)
+		}
+	}
+	sort.Strings(names)
+	sort.Strings(c.Added)
+	sort.Strings(c.Removed)
+
+	for _, fn := range names {
+		for _, m := range comparisonMetrics {
+			o := m.get(old[fn])
+			n := m.get(new[fn])
+			delta := n - o
+			if delta == 0 || (delta < th.MinDelta && delta > -th.MinDelta) {
+				continue
+			}
+			var pct float64
+			if o != 0 {
+				pct = float64(delta) / float64(o) * 100
+			} else {
+				pct = math.Inf(1)
+			}
+			c.Deltas = append(c.Deltas, FunctionDelta{
+				Function:   fn,
+				Metric:     m.name,
+				Old:        o,
+				New:        n,
+				Delta:      delta,
+				Percent:    pct,
+				Regression: delta > 0 && pct > th.Percent,
+			})
+		}
+	}
+	return &c
+}
+
+func writeComparison(w io.Writer, c *Comparison, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "\t")
+		return enc.Encode(c)
+	case "text":
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(tw, "Function\tMetric\tOld\tNew\tDelta\t\t")
+		for _, d := range c.Deltas {
+			var mark string
+			if d.Regression {
+				mark = "REGRESSION"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%+.1f%%\t%s\t\n", d.Function, d.Metric, roundDuration(d.Old), roundDuration(d.New), d.Percent, mark)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		if len(c.Added) > 0 {
+			fmt.Fprintf(w, "\n%d functions only in new trace\n", len(c.Added))
+		}
+		if len(c.Removed) > 0 {
+			fmt.Fprintf(w, "%d functions only in old trace\n", len(c.Removed))
+		}
+		fmt.Fprintf(w, "\n%d regressions\n", c.Regressions())
+		return nil
+	default:
+		return fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+func runCompare(prog string, cmd command, args []string) int {
+	fs := flag.NewFlagSet(cmd.name, flag.ExitOnError)
+	fs.Usage = commandUsage(prog, cmd, fs)
+	format := fs.String("format", "text", "Output format, either text or json")
+	var th ComparisonThresholds
+	fs.Float64Var(&th.Percent, "threshold", 10, "Relative increase, in percent, that is considered a regression")
+	fs.DurationVar(&th.MinDelta, "min-delta", time.Millisecond, "Ignore changes smaller than this")
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return 2
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "unsupported format %q\n", *format)
+		return 2
+	}
+
+	var stats [2]map[string]*FunctionStats
+	for i, path := range fs.Args() {
+		tr, err := parseTraceFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "couldn't load trace %s: %s\n", path, err)
+			return 2
+		}
+		stats[i] = ComputeFunctionStats(tr)
+	}
+
+	c := CompareFunctionStats(stats[0], stats[1], th)
+	if err := writeComparison(os.Stdout, c, *format); err != nil {
+		fmt.Fprintln(os.Stderr, "couldn't write comparison:", err)
+		return 2
+	}
+	if c.Regressions() > 0 {
+		return 1
+	}
+	return 0
+}
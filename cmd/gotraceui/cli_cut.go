@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"honnef.co/go/gotraceui/trace/rawtrace"
+)
+
+func runCut(prog string, cmd command, args []string) int {
+	fs := flag.NewFlagSet(cmd.name, flag.ExitOnError)
+	fs.Usage = commandUsage(prog, cmd, fs)
+	from := fs.Duration("from", 0, "Start of the time window, relative to the start of the trace")
+	to := fs.Duration("to", 0, "End of the time window, relative to the start of the trace (default end of trace)")
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return 2
+	}
+	if *to != 0 && *to < *from {
+		fmt.Fprintln(os.Stderr, "-to must not be before -from")
+		return 2
+	}
+
+	in, err := os.Open(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "couldn't open trace:", err)
+		return 1
+	}
+	defer in.Close()
+	tr, err := rawtrace.Read(in)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "couldn't read trace:", err)
+		return 1
+	}
+
+	cut := tr.Cut(*from, *to)
+	if len(cut.Generations) == 0 {
+		fmt.Fprintln(os.Stderr, "time window doesn't contain any events")
+		return 1
+	}
+
+	out, err := os.Create(fs.Arg(1))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "couldn't create output:", err)
+		return 1
+	}
+	if _, err := cut.WriteTo(out); err != nil {
+		out.Close()
+		fmt.Fprintln(os.Stderr, "couldn't write trace:", err)
+		return 1
+	}
+	if err := out.Close(); err != nil {
+		fmt.Fprintln(os.Stderr, "couldn't write trace:", err)
+		return 1
+	}
+
+	start := cut.Start() - tr.Start()
+	fmt.Fprintf(os.Stderr, "wrote %d of %d generations, starting at %s\n", len(cut.Generations), len(tr.Generations), time.Duration(start))
+	return 0
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"honnef.co/go/gotraceui/query"
+)
+
+// queryValueString formats a value for CSV output. Durations and timestamps are printed as nanoseconds.
+func queryValueString(v query.Value) string {
+	switch v.Kind {
+	case query.KindDuration, query.KindTime:
+		return strconv.FormatInt(v.Int, 10)
+	default:
+		return v.String()
+	}
+}
+
+// queryValueJSON returns the JSON representation of a value. Durations and timestamps are represented as
+// nanoseconds.
+func queryValueJSON(v query.Value) any {
+	switch v.Kind {
+	case query.KindNull:
+		return nil
+	case query.KindBool:
+		return v.Int != 0
+	case query.KindInt, query.KindDuration, query.KindTime:
+		return v.Int
+	case query.KindFloat:
+		return v.Float
+	case query.KindString:
+		return v.Str
+	default:
+		panic(fmt.Sprintf("unhandled kind %s", v.Kind))
+	}
+}
+
+func writeQueryResult(w io.Writer, res *query.Result, format string) error {
+	switch format {
+	case "json":
+		// Rows are written as objects whose keys are in the order of the columns, one row per line.
+		if _, err := io.WriteString(w, "["); err != nil {
+			return err
+		}
+		sep := "\n"
+		for _, row := range res.Rows {
+			buf := []byte(sep + "{")
+			sep = ",\n"
+			for j, v := range row {
+				if j > 0 {
+					buf = append(buf, ',')
+				}
+				k, _ := json.Marshal(res.Columns[j].Name)
+				val, err := json.Marshal(queryValueJSON(v))
+				if err != nil {
+					return err
+				}
+				buf = append(buf, k...)
+				buf = append(buf, ':')
+				buf = append(buf, val...)
+			}
+			buf = append(buf, '}')
+			if _, err := w.Write(buf); err != nil {
+				return err
+			}
+		}
+		_, err := io.WriteString(w, "\n]\n")
+		return err
+	case "csv":
+		cw := csv.NewWriter(w)
+		header := make([]string, len(res.Columns))
+		for i, c := range res.Columns {
+			header[i] = c.Name
+		}
+		cw.Write(header)
+		record := make([]string, len(res.Columns))
+		for _, row := range res.Rows {
+			for i, v := range row {
+				record[i] = queryValueString(v)
+			}
+			cw.Write(record)
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		return fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+func runQuery(prog string, cmd command, args []string) int {
+	fs := flag.NewFlagSet(cmd.name, flag.ExitOnError)
+	fs.Usage = commandUsage(prog, cmd, fs)
+	format := fs.String("format", "csv", "Output format, either csv or json")
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return 2
+	}
+	if *format != "json" && *format != "csv" {
+		fmt.Fprintf(os.Stderr, "unsupported format %q\n", *format)
+		return 2
+	}
+	q, err := query.Parse(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "invalid query:", err)
+		return 2
+	}
+
+	pt, err := parseTraceFile(fs.Arg(1))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "couldn't load trace:", err)
+		return 1
+	}
+	// Timestamps are relative to the start of the trace, like in the GUI.
+	tr := &Trace{Trace: pt, TimeOffset: -pt.Start()}
+	res, err := query.Run(q, traceCatalog(tr), 0)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "couldn't run query:", err)
+		return 2
+	}
+	if err := writeQueryResult(os.Stdout, res, *format); err != nil {
+		fmt.Fprintln(os.Stderr, "couldn't write results:", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"honnef.co/go/gotraceui/trace/ptrace"
+)
+
+// TraceStats are the statistics printed by the stats command.
+type TraceStats struct {
+	Duration   time.Duration  `json:"duration_ns"`
+	Goroutines GoroutineStats `json:"goroutines"`
+	GC         GCStats        `json:"gc"`
+	// Time goroutines spent runnable before being scheduled.
+	SchedulingLatency DurationStats `json:"scheduling_latency"`
+}
+
+type GoroutineStats struct {
+	Count    int           `json:"count"`
+	Running  time.Duration `json:"running_ns"`
+	Blocked  time.Duration `json:"blocked_ns"`
+	Inactive time.Duration `json:"inactive_ns"`
+	GCAssist time.Duration `json:"gc_assist_ns"`
+	// Total time spent in each state, keyed by the state's name.
+	States map[string]time.Duration `json:"states_ns"`
+}
+
+type GCStats struct {
+	Cycles   DurationStats `json:"cycles"`
+	Fraction float64       `json:"fraction"`
+	STW      DurationStats `json:"stw"`
+}
+
+func isSchedulingLatencyState(state ptrace.SchedulingState) bool {
+	switch state {
+	case ptrace.StateReady, ptrace.StateCreated, ptrace.StateWaitingPreempted:
+		return true
+	default:
+		return false
+	}
+}
+
+func ComputeTraceStats(tr *ptrace.Trace) *TraceStats {
+	stats := &TraceStats{
+		Duration: tr.Duration(),
+		Goroutines: GoroutineStats{
+			Count:  len(tr.Goroutines),
+			States: map[string]time.Duration{},
+		},
+	}
+
+	var latencies []time.Duration
+	var gstats ptrace.Statistics
+	for _, g := range tr.Goroutines {
+		s := ptrace.ComputeStatistics(ptrace.ToSpans(g.Spans))
+		for state := range s {
+			gstats[state].Count += s[state].Count
+			gstats[state].Total += s[state].Total
+		}
+		for i := range g.Spans {
+			span := &g.Spans[i]
+			if isSchedulingLatencyState(span.State) {
+				latencies = append(latencies, span.Duration())
+			}
+		}
+	}
+	stats.Goroutines.Running = gstats.Running()
+	stats.Goroutines.Blocked = gstats.Blocked()
+	stats.Goroutines.Inactive = gstats.Inactive()
+	stats.Goroutines.GCAssist = gstats.GCAssist()
+	for state, stat := range gstats {
+		if stat.Total != 0 {
+			stats.Goroutines.States[stateNames[state]] = stat.Total
+		}
+	}
+	stats.SchedulingLatency = computeDurationStats(latencies)
+
+	spanDurations := func(spans []ptrace.Span) []time.Duration {
+		out := make([]time.Duration, len(spans))
+		for i := range spans {
+			out[i] = spans[i].Duration()
+		}
+		return out
+	}
+	stats.GC.Cycles = computeDurationStats(spanDurations(tr.GC))
+	stats.GC.STW = computeDurationStats(spanDurations(tr.STW))
+	if stats.Duration > 0 {
+		stats.GC.Fraction = float64(stats.GC.Cycles.Total) / float64(stats.Duration)
+	}
+
+	return stats
+}
+
+func (stats *TraceStats) rows() [][2]string {
+	out := [][2]string{
+		{"duration_ns", fmt.Sprint(int64(stats.Duration))},
+		{"goroutines.count", fmt.Sprint(stats.Goroutines.Count)},
+		{"goroutines.running_ns", fmt.Sprint(int64(stats.Goroutines.Running))},
+		{"goroutines.blocked_ns", fmt.Sprint(int64(stats.Goroutines.Blocked))},
+		{"goroutines.inactive_ns", fmt.Sprint(int64(stats.Goroutines.Inactive))},
+		{"goroutines.gc_assist_ns", fmt.Sprint(int64(stats.Goroutines.GCAssist))},
+	}
+	// Iterate over states in their canonical order for stable output.
+	for _, name := range stateNames {
+		if d, ok := stats.Goroutines.States[name]; ok {
+			out = append(out, [2]string{"goroutines.states_ns." + name, fmt.Sprint(int64(d))})
+		}
+	}
+	out = append(out, stats.GC.Cycles.rows("gc.cycles")...)
+	out = append(out, [2]string{"gc.fraction", fmt.Sprint(stats.GC.Fraction)})
+	out = append(out, stats.GC.STW.rows("gc.stw")...)
+	out = append(out, stats.SchedulingLatency.rows("scheduling_latency")...)
+	return out
+}
+
+func writeStats(w io.Writer, stats *TraceStats, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "\t")
+		return enc.Encode(stats)
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"metric", "value"})
+		for _, row := range stats.rows() {
+			cw.Write(row[:])
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		return fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+func runStats(prog string, cmd command, args []string) int {
+	fs := flag.NewFlagSet(cmd.name, flag.ExitOnError)
+	fs.Usage = commandUsage(prog, cmd, fs)
+	format := fs.String("format", "json", "Output format, either json or csv")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	if *format != "json" && *format != "csv" {
+		fmt.Fprintf(os.Stderr, "unsupported format %q\n", *format)
+		return 2
+	}
+
+	tr, err := parseTraceFile(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "couldn't load trace:", err)
+		return 1
+	}
+	if err := writeStats(os.Stdout, ComputeTraceStats(tr), *format); err != nil {
+		fmt.Fprintln(os.Stderr, "couldn't write statistics:", err)
+		return 1
+	}
+	return 0
+}
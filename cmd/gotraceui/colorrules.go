@@ -0,0 +1,415 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"regexp"
+	rtrace "runtime/trace"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"honnef.co/go/gotraceui/color"
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/trace/ptrace"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/unit"
+)
+
+// maxColorRules is the maximum number of color rules. Each rule uses one of the custom color indices, starting at
+// colorCustom0.
+const maxColorRules = 16
+
+// A ColorRule colors spans that match all of its conditions, to make spans that are relevant to the user stand out.
+// Empty conditions match all spans. Rules are tried in order and the first matching rule wins.
+type ColorRule struct {
+	// Function is a regular expression matched against the function of the goroutine the span belongs to.
+	Function string `json:"function,omitempty"`
+	// State is the name of a state as used by statistics, such as "blocked (sync)".
+	State string `json:"state,omitempty"`
+	// Label is a regular expression matched against the names of user regions. Rules with a label only match
+	// user regions.
+	Label string `json:"label,omitempty"`
+	// MinDuration is the minimum duration of matching spans, in the format accepted by time.ParseDuration.
+	MinDuration string `json:"min_duration,omitempty"`
+	// Color is the color of matching spans, in the form #rrggbb.
+	Color string `json:"color"`
+}
+
+type compiledColorRule struct {
+	function    *regexp.Regexp
+	label       *regexp.Regexp
+	state       ptrace.SchedulingState
+	hasState    bool
+	minDuration time.Duration
+}
+
+// colorRuleSet is a set of compiled color rules. It is immutable, except for caches, and can be used concurrently.
+type colorRuleSet struct {
+	rules  []compiledColorRule
+	colors [maxColorRules]color.Oklch
+	mapped [maxColorRules]color.LinearSRGB
+
+	// functions maps *ptrace.Function to a bitmap of the rules whose Function condition matches the function. It saves
+	// us from running regular expressions for every span.
+	functions sync.Map
+
+	// gen identifies the rule set. Canvases use it to notice that span colors changed.
+	gen uint64
+}
+
+var (
+	activeColorRules     atomic.Pointer[colorRuleSet]
+	colorRulesCounter    atomic.Uint64
+	errTooManyColorRules = fmt.Errorf("at most %d color rules are supported", maxColorRules)
+)
+
+// parseColor parses a color of the form #rrggbb.
+func parseColor(s string) (color.Oklch, error) {
+	hex, ok := strings.CutPrefix(strings.TrimSpace(s), "#")
+	if !ok || len(hex) != 6 {
+		return color.Oklch{}, fmt.Errorf("invalid color %q, must be of the form #rrggbb", s)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.Oklch{}, fmt.Errorf("invalid color %q, must be of the form #rrggbb", s)
+	}
+	c := color.SRGB{
+		R: float32(v>>16&0xFF) / 255,
+		G: float32(v>>8&0xFF) / 255,
+		B: float32(v&0xFF) / 255,
+		A: 1,
+	}
+	return c.LinearSRGB().Oklab().Oklch(), nil
+}
+
+// stateByName returns the state with the given name, as used by stateNames.
+func stateByName(name string) (ptrace.SchedulingState, bool) {
+	for state, n := range stateNames {
+		if n != "" && n == name {
+			return ptrace.SchedulingState(state), true
+		}
+	}
+	return 0, false
+}
+
+func compileColorRules(rules []ColorRule) (*colorRuleSet, error) {
+	if len(rules) > maxColorRules {
+		return nil, errTooManyColorRules
+	}
+	rs := &colorRuleSet{rules: make([]compiledColorRule, len(rules))}
+	for i, r := range rules {
+		cr := &rs.rules[i]
+		var err error
+		if r.Function != "" {
+			if cr.function, err = regexp.Compile(r.Function); err != nil {
+				return nil, fmt.Errorf("rule %d: invalid function pattern: %w", i+1, err)
+			}
+		}
+		if r.Label != "" {
+			if cr.label, err = regexp.Compile(r.Label); err != nil {
+				return nil, fmt.Errorf("rule %d: invalid label pattern: %w", i+1, err)
+			}
+		}
+		if r.State != "" {
+			if cr.state, cr.hasState = stateByName(r.State); !cr.hasState {
+				return nil, fmt.Errorf("rule %d: unknown state %q", i+1, r.State)
+			}
+		}
+		if r.MinDuration != "" {
+			if cr.minDuration, err = time.ParseDuration(r.MinDuration); err != nil {
+				return nil, fmt.Errorf("rule %d: invalid minimum duration: %w", i+1, err)
+			}
+		}
+		if r.Color == "" {
+			return nil, fmt.Errorf("rule %d: missing color", i+1)
+		}
+		c, err := parseColor(r.Color)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i+1, err)
+		}
+		rs.colors[i] = c
+		rs.mapped[i] = c.MapToSRGBGamut()
+	}
+	return rs, nil
+}
+
+// applyColorRules compiles the rules and makes them take effect in all canvases.
+func applyColorRules(rules []ColorRule) error {
+	rs, err := compileColorRules(rules)
+	if err != nil {
+		return err
+	}
+	rs.gen = colorRulesCounter.Add(1)
+	activeColorRules.Store(rs)
+	return nil
+}
+
+// colorRulesGeneration returns a number that changes every time the active color rules change.
+func colorRulesGeneration() uint64 {
+	if rs := activeColorRules.Load(); rs != nil {
+		return rs.gen
+	}
+	return 0
+}
+
+func (rs *colorRuleSet) functionMatches(fn *ptrace.Function) uint64 {
+	if v, ok := rs.functions.Load(fn); ok {
+		return v.(uint64)
+	}
+	var bits uint64
+	for i := range rs.rules {
+		if re := rs.rules[i].function; re != nil && re.MatchString(fn.Func) {
+			bits |= 1 << i
+		}
+	}
+	rs.functions.Store(fn, bits)
+	return bits
+}
+
+// match returns the color of the first rule that matches a span displayed in a track.
+func (rs *colorRuleSet) match(track *Track, span *ptrace.Span, tr *Trace) (colorIndex, bool) {
+	if span.State == statePlaceholder {
+		return 0, false
+	}
+	g, _ := track.parent.item.(*ptrace.Goroutine)
+	for i := range rs.rules {
+		r := &rs.rules[i]
+		if r.hasState && span.State != r.state {
+			continue
+		}
+		if r.minDuration > 0 && span.Duration() < r.minDuration {
+			continue
+		}
+		if r.function != nil {
+			if g == nil || g.Function == nil || rs.functionMatches(g.Function)&(1<<i) == 0 {
+				continue
+			}
+		}
+		if r.label != nil {
+			if span.State != ptrace.StateUserRegion || !r.label.MatchString(tr.Event(span.StartEvent).Region().Type) {
+				continue
+			}
+		}
+		return colorCustom0 + colorIndex(i), true
+	}
+	return 0, false
+}
+
+// paletteColor returns the color for a color index, including the colors of color rules, in the active palette.
+func paletteColor(idx colorIndex) color.Oklch {
+	if idx >= colorCustom0 {
+		if rs := activeColorRules.Load(); rs != nil {
+			return rs.colors[idx-colorCustom0]
+		}
+	}
+	if highContrast.Load() {
+		return highContrastColors[idx]
+	}
+	return colors[idx]
+}
+
+// mappedPaletteColor is like paletteColor but returns the color mapped to the sRGB gamut.
+func mappedPaletteColor(idx colorIndex) color.LinearSRGB {
+	if idx >= colorCustom0 {
+		if rs := activeColorRules.Load(); rs != nil {
+			return rs.mapped[idx-colorCustom0]
+		}
+	}
+	if highContrast.Load() {
+		return mappedHighContrastColors[idx]
+	}
+	return mappedColors[idx]
+}
+
+type colorRuleRow struct {
+	function    widget.Editor
+	state       widget.Editor
+	label       widget.Editor
+	minDuration widget.Editor
+	color       widget.Editor
+	remove      widget.PrimaryClickable
+}
+
+func newColorRuleRow(r ColorRule) *colorRuleRow {
+	row := &colorRuleRow{}
+	for _, ed := range []*widget.Editor{&row.function, &row.state, &row.label, &row.minDuration, &row.color} {
+		ed.SingleLine = true
+	}
+	row.function.SetText(r.Function)
+	row.state.SetText(r.State)
+	row.label.SetText(r.Label)
+	row.minDuration.SetText(r.MinDuration)
+	row.color.SetText(r.Color)
+	return row
+}
+
+func (row *colorRuleRow) rule() ColorRule {
+	return ColorRule{
+		Function:    strings.TrimSpace(row.function.Text()),
+		State:       strings.TrimSpace(row.state.Text()),
+		Label:       strings.TrimSpace(row.label.Text()),
+		MinDuration: strings.TrimSpace(row.minDuration.Text()),
+		Color:       strings.TrimSpace(row.color.Text()),
+	}
+}
+
+// ColorRulesDialog lets the user edit the rules for coloring spans.
+type ColorRulesDialog struct {
+	list  widget.List
+	rows  []*colorRuleRow
+	add   widget.PrimaryClickable
+	apply widget.PrimaryClickable
+	reset widget.PrimaryClickable
+	err   string
+}
+
+func NewColorRulesDialog() *ColorRulesDialog {
+	cd := &ColorRulesDialog{}
+	cd.list.Axis = layout.Vertical
+
+	userConfigMu.Lock()
+	rules := userConfig.ColorRules
+	userConfigMu.Unlock()
+	for _, r := range rules {
+		cd.rows = append(cd.rows, newColorRuleRow(r))
+	}
+	return cd
+}
+
+func (cd *ColorRulesDialog) save(win *theme.Window, rules []ColorRule) {
+	if err := applyColorRules(rules); err != nil {
+		cd.err = err.Error()
+		return
+	}
+	cd.err = ""
+
+	userConfigMu.Lock()
+	defer userConfigMu.Unlock()
+	userConfig.ColorRules = rules
+	if err := userConfig.Save(); err != nil {
+		win.Notify(theme.NotificationError, local.Sprintf("Couldn't save configuration: %s", err))
+	}
+}
+
+func (cd *ColorRulesDialog) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.ColorRulesDialog.Layout").End()
+
+	for cd.add.Clicked(gtx) {
+		if len(cd.rows) >= maxColorRules {
+			cd.err = errTooManyColorRules.Error()
+		} else {
+			cd.rows = append(cd.rows, newColorRuleRow(ColorRule{Color: "#ff00ff"}))
+		}
+	}
+	for i := 0; i < len(cd.rows); i++ {
+		for cd.rows[i].remove.Clicked(gtx) {
+			cd.rows = append(cd.rows[:i], cd.rows[i+1:]...)
+			i--
+			break
+		}
+	}
+	for cd.apply.Clicked(gtx) {
+		var rules []ColorRule
+		for _, row := range cd.rows {
+			rules = append(rules, row.rule())
+		}
+		cd.save(win, rules)
+	}
+	for cd.reset.Clicked(gtx) {
+		cd.rows = nil
+		cd.save(win, nil)
+	}
+
+	field := func(ed *widget.Editor, hint string, width int) layout.Widget {
+		return func(gtx layout.Context) layout.Dimensions {
+			gtx.Constraints.Min.X = gtx.Dp(unit.Dp(width))
+			gtx.Constraints.Max.X = gtx.Constraints.Min.X
+			return theme.TextBox(win.Theme, ed, hint).Layout(win, gtx)
+		}
+	}
+	header := func(label string, width int) layout.Widget {
+		return func(gtx layout.Context) layout.Dimensions {
+			gtx.Constraints.Min.X = gtx.Dp(unit.Dp(width))
+			gtx.Constraints.Max.X = gtx.Constraints.Min.X
+			return theme.LineLabel(win.Theme, label).Layout(win, gtx)
+		}
+	}
+	const (
+		wFunction = 250
+		wState    = 170
+		wLabel    = 170
+		wDuration = 110
+		wColor    = 90
+	)
+	spacer := layout.Spacer{Width: 5}.Layout
+
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(theme.Dumb(win, theme.Label(win.Theme, tr("Spans matching all conditions of a rule are drawn in the rule's color. The first matching rule wins. Empty conditions match everything.")).Layout)),
+		layout.Rigid(layout.Spacer{Height: 10}.Layout),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return layout.Rigids(gtx, layout.Horizontal,
+				header("Function (regexp)", wFunction), spacer,
+				header("State", wState), spacer,
+				header("Region (regexp)", wLabel), spacer,
+				header("Min. duration", wDuration), spacer,
+				header("Color", wColor),
+			)
+		}),
+		layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+			return theme.List(win.Theme, &cd.list).Layout(win, gtx, len(cd.rows), func(gtx layout.Context, index int) layout.Dimensions {
+				row := cd.rows[index]
+				return layout.Rigids(gtx, layout.Horizontal,
+					field(&row.function, "net/http\\..*", wFunction), spacer,
+					field(&row.state, "blocked (sync)", wState), spacer,
+					field(&row.label, "", wLabel), spacer,
+					field(&row.minDuration, "10ms", wDuration), spacer,
+					field(&row.color, "#rrggbb", wColor), spacer,
+					func(gtx layout.Context) layout.Dimensions {
+						return theme.Button(win.Theme, &row.remove.Clickable, tr("Remove")).Layout(win, gtx)
+					},
+				)
+			})
+		}),
+		layout.Rigid(layout.Spacer{Height: 10}.Layout),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return layout.Rigids(gtx, layout.Horizontal,
+				func(gtx layout.Context) layout.Dimensions {
+					return theme.Button(win.Theme, &cd.add.Clickable, tr("Add rule")).Layout(win, gtx)
+				},
+				spacer,
+				func(gtx layout.Context) layout.Dimensions {
+					return theme.Button(win.Theme, &cd.apply.Clickable, tr("Apply")).Layout(win, gtx)
+				},
+				spacer,
+				func(gtx layout.Context) layout.Dimensions {
+					return theme.Button(win.Theme, &cd.reset.Clickable, tr("Remove all rules")).Layout(win, gtx)
+				},
+			)
+		}),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			if cd.err == "" {
+				return layout.Dimensions{}
+			}
+			l := theme.Label(win.Theme, cd.err)
+			l.Color = colors[colorStateBlocked]
+			return l.Layout(win, gtx)
+		}),
+	)
+}
+
+func displayColorRulesDialog(win *theme.Window) {
+	cd := NewColorRulesDialog()
+	win.SetModal(func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+		return theme.Dialog(win.Theme, tr("Span colors")).Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+			gtx.Constraints.Min = gtx.Constraints.Constrain(image.Pt(1000, 500))
+			gtx.Constraints.Max = gtx.Constraints.Min
+			return cd.Layout(win, gtx)
+		})
+	})
+}
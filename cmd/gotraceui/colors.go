@@ -1,7 +1,10 @@
 package main
 
 import (
+	"sync/atomic"
+
 	"honnef.co/go/gotraceui/color"
+	"honnef.co/go/gotraceui/theme"
 	"honnef.co/go/gotraceui/trace/ptrace"
 )
 
@@ -35,6 +38,9 @@ var colors = [colorLast]color.Oklch{
 	colorStateBlockedHappensBefore: oklch(colorsLightBase+colorLightStep2, colorsChromaBase, 23.89),
 	colorStateBlockedGC:            oklch(colorsLightBase, colorsChromaBase, 0), // a blend of colorStateGC and red
 
+	// Manually chosen. Calls into C are neither running Go code nor blocked, so they get a hue of their own.
+	colorStateCgo: oklch(colorsLightBase, colorsChromaBase, 262.5),
+
 	colorStateGC:  oklch(colorsLightBase, colorsChromaBase, 302.36),
 	colorStateSTW: oklch(colorsLightBase, colorsChromaBase+0.072, 23.89), // STW is the most severe form of blocking, hence the increased chroma
 
@@ -57,12 +63,126 @@ var colors = [colorLast]color.Oklch{
 	colorStatePlaceholderStackSpan: oklch(92.59, 0.025, 106.88),
 }
 
-var mappedColors [len(colors)]color.LinearSRGB
+// highContrastStateColors replace the colors of span states in the high-contrast palette. Neighboring states differ
+// more in lightness than in the default palette, so that they can be told apart on poor displays and by users who can't
+// distinguish the hues. Colors that aren't listed are the same as in the default palette.
+var highContrastStateColors = map[colorIndex]color.Oklch{
+	colorStateUndetermined: oklch(80, 0.15, 70.54),
+
+	colorStateActive:             oklch(82, 0.2, 143.74),
+	colorStateProcRunningNoG:     oklch(60, 0.16, 245),
+	colorStateProcRunningBlocked: oklch(38, 0.18, 23.89),
+	colorStateProcRunningG:       oklch(82, 0.2, 143.74),
+	colorStateStack:              oklch(92, 0.12, 143.74),
+	colorStateCPUSample:          oklch(96, 0.08, 143.74),
+
+	colorStateReady:            oklch(60, 0.16, 245),
+	colorStateWaitingPreempted: oklch(60, 0.16, 245),
+	colorStateInactive:         oklch(90, 0, 0),
+
+	colorStateUserRegion: oklch(88, 0.1, 331.18),
+
+	colorStateBlocked:              oklch(38, 0.18, 23.89),
+	colorStateBlockedSyscall:       oklch(70, 0.17, 55),
+	colorStateBlockedNet:           oklch(56, 0.2, 35),
+	colorStateBlockedHappensBefore: oklch(47, 0.19, 23.89),
+	colorStateBlockedGC:            oklch(42, 0.2, 0),
+
+	colorStateCgo: oklch(50, 0.2, 280),
+
+	colorStateGC:  oklch(45, 0.24, 302.36),
+	colorStateSTW: oklch(30, 0.2, 23.89),
+
+	colorStateMerged: oklch(93, 0.14, 109.91),
+}
+
+var (
+	mappedColors             [len(colors)]color.LinearSRGB
+	highContrastColors       [len(colors)]color.Oklch
+	mappedHighContrastColors [len(colors)]color.LinearSRGB
+)
 
 func init() {
 	for i, c := range colors {
 		mappedColors[i] = c.MapToSRGBGamut()
 	}
+	highContrastColors = colors
+	for idx, c := range highContrastStateColors {
+		highContrastColors[idx] = c
+	}
+	for i, c := range highContrastColors {
+		mappedHighContrastColors[i] = c.MapToSRGBGamut()
+	}
+}
+
+var (
+	// highContrast selects the high-contrast palette, which uses hatching in addition to colors to distinguish span
+	// states.
+	highContrast      atomic.Bool
+	paletteGeneration atomic.Uint64
+)
+
+// setHighContrast switches between the default and the high-contrast palette for spans and the user interface.
+func setHighContrast(th *theme.Theme, on bool) {
+	if on {
+		th.Palette = theme.HighContrastPalette
+	} else {
+		th.Palette = theme.DefaultPalette
+	}
+	if highContrast.Swap(on) != on {
+		paletteGeneration.Add(1)
+	}
+}
+
+// spanColorsGeneration returns a number that changes every time the colors of spans change, be it because of the palette
+// or because of the color rules.
+func spanColorsGeneration() uint64 {
+	// Both counters only ever grow, so their sum changes whenever either of them does.
+	return paletteGeneration.Load() + colorRulesGeneration()
+}
+
+// hatchPattern is a pattern drawn on top of spans in the high-contrast palette, so that span states can be told apart
+// without relying on color alone.
+type hatchPattern uint8
+
+const (
+	hatchNone hatchPattern = iota
+	// Lines going from the bottom left to the top right.
+	hatchDiagonal
+	// Lines going from the top left to the bottom right.
+	hatchBackDiagonal
+	hatchCross
+	hatchVertical
+	hatchHorizontal
+	hatchDots
+
+	hatchLast
+)
+
+var statePatterns = [colorStateLast]hatchPattern{
+	colorStateReady:            hatchVertical,
+	colorStateWaitingPreempted: hatchVertical,
+	colorStateProcRunningNoG:   hatchVertical,
+
+	colorStateBlocked:              hatchDiagonal,
+	colorStateBlockedHappensBefore: hatchDiagonal,
+	colorStateProcRunningBlocked:   hatchDiagonal,
+	colorStateBlockedSyscall:       hatchBackDiagonal,
+	colorStateBlockedNet:           hatchHorizontal,
+	colorStateBlockedGC:            hatchCross,
+	colorStateGC:                   hatchCross,
+	colorStateSTW:                  hatchCross,
+
+	colorStateCgo: hatchDots,
+}
+
+// spanPattern returns the hatching pattern of spans with the given color, and whether it should be drawn in a light
+// color, because the span's color is dark. Only the high-contrast palette uses patterns.
+func spanPattern(idx colorIndex) (hatchPattern, bool) {
+	if idx >= colorStateLast || !highContrast.Load() {
+		return hatchNone, false
+	}
+	return statePatterns[idx], highContrastColors[idx].L < 0.55
 }
 
 type colorIndex uint8
@@ -80,6 +200,7 @@ const (
 	colorStateBlockedNet
 	colorStateBlockedGC
 	colorStateBlockedSyscall
+	colorStateCgo
 	colorStateGC
 	colorStateSTW
 
@@ -108,7 +229,9 @@ const (
 	colorEvent
 	colorMergedEvents
 
-	colorLast
+	// The colors of the user's color rules, see colorRuleSet.
+	colorCustom0
+	colorLast = colorCustom0 + maxColorRules
 )
 
 var stateColors = [256]colorIndex{
@@ -126,6 +249,7 @@ var stateColors = [256]colorIndex{
 	ptrace.StateBlockedNet:              colorStateBlockedNet,
 	ptrace.StateBlockedGC:               colorStateBlockedGC,
 	ptrace.StateBlockedSyscall:          colorStateBlockedSyscall,
+	ptrace.StateCgo:                     colorStateCgo,
 	ptrace.StateStuck:                   colorStateStuck,
 	ptrace.StateReady:                   colorStateReady,
 	ptrace.StateWaitingPreempted:        colorStateWaitingPreempted,
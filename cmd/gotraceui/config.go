@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+var configFile string
+
+// Config is the persistent, user-editable configuration of gotraceui. It is stored as JSON.
+type Config struct {
+	// Keybindings maps the names of actions to their shortcuts, overriding the defaults. An empty shortcut unbinds the
+	// action. See keybindings for the list of actions.
+	Keybindings map[string]string `json:"keybindings,omitempty"`
+	// Layout is the arrangement of the main window's areas. It is updated when windows close.
+	Layout *LayoutConfig `json:"layout,omitempty"`
+	// RecentTraces are the most recently opened traces, most recent first.
+	RecentTraces []RecentTrace `json:"recent_traces,omitempty"`
+	// UIScale scales the user interface, on top of the scaling of the operating system. It can be changed with
+	// Ctrl+= and Ctrl+-.
+	UIScale float32 `json:"ui_scale,omitempty"`
+	// Fonts configures the fonts and the size of text.
+	Fonts *FontConfig `json:"fonts,omitempty"`
+	// CacheMemory is the maximum memory, in MiB, to spend on caching the rendered contents of timelines. Less
+	// recently used contents get evicted when the limit is exceeded. It defaults to 125.
+	CacheMemory int `json:"cache_memory,omitempty"`
+	// ColorRules color spans that match user-defined conditions. See ColorRule.
+	ColorRules []ColorRule `json:"color_rules,omitempty"`
+	// Navigation configures panning and zooming.
+	Navigation *NavigationConfig `json:"navigation,omitempty"`
+	// Locale is the language of the user interface, as a BCP 47 language tag. It also affects the formatting of
+	// numbers. It defaults to English.
+	Locale string `json:"locale,omitempty"`
+	// TimeFormat configures how durations and timestamps are displayed.
+	TimeFormat *TimeFormatConfig `json:"time_format,omitempty"`
+	// OpenInsights causes the insights, potential problems found by heuristics, to be computed and opened in a tab
+	// after loading a trace.
+	OpenInsights bool `json:"open_insights,omitempty"`
+	// Fidelity is the level of detail at which traces are loaded: full, high, medium or low. Lower fidelities merge
+	// short spans, which makes very large traces more responsive. It defaults to full.
+	Fidelity string `json:"fidelity,omitempty"`
+	// HighContrast selects the high-contrast palette, which makes span states differ more in lightness and
+	// distinguishes them by patterns in addition to colors.
+	HighContrast bool `json:"high_contrast,omitempty"`
+	// Tooltips configures the contents of tooltips.
+	Tooltips *TooltipConfig `json:"tooltips,omitempty"`
+	// QueryHistory are the most recently run queries of the query console, most recent first.
+	QueryHistory []string `json:"query_history,omitempty"`
+}
+
+type FontConfig struct {
+	// Files are TrueType or OpenType files to load in addition to the built-in fonts.
+	Files []string `json:"files,omitempty"`
+	// Typeface is the typeface of most text. It defaults to Go.
+	Typeface string `json:"typeface,omitempty"`
+	// MonospaceTypeface is the typeface of stack traces and similar text. It defaults to Go Mono.
+	MonospaceTypeface string `json:"monospace_typeface,omitempty"`
+	// Fallbacks are the typefaces that provide glyphs that the other typefaces lack, such as symbols and emoji, in
+	// order of preference. They default to the built-in fallback font.
+	Fallbacks []string `json:"fallbacks,omitempty"`
+	// TextSize is the base size of text, in scaled pixels. It defaults to 12.
+	TextSize float32 `json:"text_size,omitempty"`
+}
+
+type LayoutConfig struct {
+	// PanelSide is the side of the window that the panel area is docked to: right, bottom, left or top.
+	PanelSide string `json:"panel_side,omitempty"`
+	// MainRatio is the fraction of the window occupied by the tabs, the rest being occupied by the panel area.
+	MainRatio   float32 `json:"main_ratio,omitempty"`
+	PanelHidden bool    `json:"panel_hidden,omitempty"`
+	// Panels is the structure of the panel area. It is nil if the panel area isn't split.
+	Panels *PanelLayout `json:"panels,omitempty"`
+}
+
+// PanelLayout is a node in the structure of the panel area. Nodes either split their area between two children or are
+// groups of tabbed panels.
+type PanelLayout struct {
+	// Split is the axis along which the children are laid out, horizontal or vertical, or empty for groups.
+	Split string `json:"split,omitempty"`
+	// Ratio is the fraction of the node's area occupied by the first child.
+	Ratio    float32        `json:"ratio,omitempty"`
+	Children []*PanelLayout `json:"children,omitempty"`
+}
+
+func (l *PanelLayout) equal(o *PanelLayout) bool {
+	if l == nil || o == nil {
+		return l == o
+	}
+	return l.Split == o.Split &&
+		l.Ratio == o.Ratio &&
+		slices.EqualFunc(l.Children, o.Children, (*PanelLayout).equal)
+}
+
+var (
+	// userConfig is the loaded configuration. It is shared by all main windows and must only be modified while
+	// holding userConfigMu.
+	userConfig   = &Config{}
+	userConfigMu sync.Mutex
+)
+
+func configPath() (string, error) {
+	if configFile != "" {
+		return configFile, nil
+	}
+	dir, err := uiFileSystem.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gotraceui", "config.json"), nil
+}
+
+// LoadConfig loads the configuration file. A missing file isn't an error and results in the default configuration.
+func LoadConfig() (*Config, error) {
+	path, err := configPath()
+	if err != nil {
+		return &Config{}, err
+	}
+	b, err := uiFileSystem.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Config{}, nil
+	} else if err != nil {
+		return &Config{}, err
+	}
+	cfg := &Config{}
+	if err := json.Unmarshal(b, cfg); err != nil {
+		return &Config{}, fmt.Errorf("couldn't parse %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Save writes the configuration file, replacing it atomically.
+func (cfg *Config) Save() error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(cfg, "", "\t")
+	if err != nil {
+		return err
+	}
+	return uiFileSystem.WriteFile(path, append(b, '\n'))
+}
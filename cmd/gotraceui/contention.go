@@ -0,0 +1,268 @@
+package main
+
+import (
+	"context"
+	rtrace "runtime/trace"
+	"slices"
+	"time"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/trace/ptrace"
+	myunsafe "honnef.co/go/gotraceui/unsafe"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/font"
+	"gioui.org/op/clip"
+	"gioui.org/text"
+	exptrace "golang.org/x/exp/trace"
+)
+
+// A contendedWait is a span of a goroutine being blocked on a lock.
+type contendedWait struct {
+	g    *ptrace.Goroutine
+	span *ptrace.Span
+}
+
+// A contentionGroup aggregates waits by where they blocked, or by the goroutine that unblocked them. The latter is
+// usually the goroutine that held the lock.
+type contentionGroup struct {
+	// The function that blocked, or the empty string if it is unknown or the waits don't agree.
+	Site string
+	// The goroutine that unblocked the waits, or nil if it is unknown or the waits don't agree.
+	Holder     *ptrace.Goroutine
+	Waits      []contendedWait
+	Total, Max time.Duration
+}
+
+func (group *contentionGroup) add(w contendedWait, site string, holder *ptrace.Goroutine) {
+	if len(group.Waits) == 0 {
+		group.Site = site
+		group.Holder = holder
+	} else {
+		if group.Site != site {
+			group.Site = ""
+		}
+		if group.Holder != holder {
+			group.Holder = nil
+		}
+	}
+	group.Waits = append(group.Waits, w)
+	d := w.span.Duration()
+	group.Total += d
+	group.Max = max(group.Max, d)
+}
+
+// contentionReport ranks lock contention by total wait time, grouped by blocking stack and by lock holder.
+type contentionReport struct {
+	BySite   []contentionGroup
+	ByHolder []contentionGroup
+}
+
+type contentionReportKey struct{}
+
+func computeContentionReport(tr *Trace) *contentionReport {
+	defer rtrace.StartRegion(context.Background(), "main.computeContentionReport").End()
+
+	var (
+		bySite   = map[string]*contentionGroup{}
+		byHolder = map[*ptrace.Goroutine]*contentionGroup{}
+	)
+	for _, g := range tr.Goroutines {
+		for i := range g.Spans {
+			span := &g.Spans[i]
+			if span.State != ptrace.StateBlockedSync {
+				continue
+			}
+
+			var pcs []uint64
+			if stk := tr.Event(span.StartEvent).Stack(); stk != exptrace.NoStack {
+				pcs = tr.Stacks[tr.StackID(stk)]
+				if int(span.At) < len(pcs) {
+					pcs = pcs[span.At:]
+				}
+			}
+			var site string
+			if len(pcs) > 0 {
+				site = tr.FuncName(pcs[0])
+			}
+
+			// The span ends when another goroutine unblocks it, which is usually the goroutine that released the
+			// lock.
+			var holder *ptrace.Goroutine
+			if i+1 < len(g.Spans) {
+				if gid := tr.Event(span.EndEvent).Goroutine(); gid != exptrace.NoGoroutine && gid != g.ID {
+					holder = tr.G(gid)
+				}
+			}
+
+			w := contendedWait{g, span}
+			key := string(myunsafe.SliceCast[[]byte](pcs))
+			sg, ok := bySite[key]
+			if !ok {
+				sg = &contentionGroup{}
+				bySite[key] = sg
+			}
+			sg.add(w, site, holder)
+			hg, ok := byHolder[holder]
+			if !ok {
+				hg = &contentionGroup{}
+				byHolder[holder] = hg
+			}
+			hg.add(w, site, holder)
+		}
+	}
+
+	rank := func(groups []contentionGroup) []contentionGroup {
+		slices.SortFunc(groups, func(a, b contentionGroup) int {
+			return cmp(a.Total, b.Total, true)
+		})
+		return groups
+	}
+	var report contentionReport
+	for _, group := range bySite {
+		report.BySite = append(report.BySite, *group)
+	}
+	for _, group := range byHolder {
+		report.ByHolder = append(report.ByHolder, *group)
+	}
+	report.BySite = rank(report.BySite)
+	report.ByHolder = rank(report.ByHolder)
+	return &report
+}
+
+// ContentionComponent displays a ranked report of lock contention.
+type ContentionComponent struct {
+	trace  *Trace
+	canvas *Canvas
+	report *theme.Future[*contentionReport]
+
+	byHolder widget.Bool
+
+	table         *theme.Table
+	scrollState   theme.YScrollableListState
+	cellFormatter CellFormatter
+	// Lazily computed spans of the groups, indexed by whether they're grouped by holder, then by row.
+	spans [2][]Items[ptrace.Span]
+}
+
+func NewContentionComponent(win *theme.Window, tr *Trace, cv *Canvas) *ContentionComponent {
+	return &ContentionComponent{
+		trace:  tr,
+		canvas: cv,
+		report: theme.Compute(&tr.analyses, win, contentionReportKey{}, func() *contentionReport {
+			return computeContentionReport(tr)
+		}),
+	}
+}
+
+// Title implements theme.Component.
+func (*ContentionComponent) Title() string {
+	return tr("Lock contention")
+}
+
+// Transition implements theme.Component.
+func (*ContentionComponent) Transition(state theme.ComponentState) {}
+
+// WantsTransition implements theme.Component.
+func (*ContentionComponent) WantsTransition(gtx layout.Context) theme.ComponentState {
+	return theme.ComponentStateNone
+}
+
+func (cc *ContentionComponent) HoveredLink() ObjectLink {
+	return cc.cellFormatter.HoveredLink()
+}
+
+// groupSpans returns the waits of a group as spans in the timelines of their goroutines.
+func (cc *ContentionComponent) groupSpans(group *contentionGroup) Items[ptrace.Span] {
+	// The waits are grouped by goroutine because we collected them one goroutine at a time.
+	return goroutineSpans(cc.canvas, group.Waits, func(w *contendedWait) (*ptrace.Goroutine, *ptrace.Span) {
+		return w.g, w.span
+	})
+}
+
+func (cc *ContentionComponent) initTable(win *theme.Window, gtx layout.Context) {
+	if cc.table != nil {
+		return
+	}
+	cc.table = &theme.Table{}
+	cc.table.SetColumns(win, gtx, []theme.Column{
+		{Name: "Total wait", Alignment: text.End},
+		{Name: "Max wait", Alignment: text.End},
+		{Name: "Waits", Alignment: text.End},
+		{Name: "Blocked in", Alignment: text.Start},
+		{Name: "Lock holder", Alignment: text.End},
+	})
+}
+
+// Layout implements theme.Component.
+func (cc *ContentionComponent) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.ContentionComponent.Layout").End()
+
+	report, ok := cc.report.Result()
+	if !ok {
+		return theme.Loading(win.Theme, tr("Computing lock contention…")).Layout(win, gtx)
+	}
+
+	cc.byHolder.Update(gtx)
+	cc.initTable(win, gtx)
+	cc.table.Update(gtx)
+	cc.cellFormatter.Update(win, gtx)
+
+	groups := report.BySite
+	mode := 0
+	if cc.byHolder.Value {
+		groups = report.ByHolder
+		mode = 1
+	}
+	if cc.spans[mode] == nil {
+		cc.spans[mode] = make([]Items[ptrace.Span], len(groups))
+	}
+
+	cellFn := func(win *theme.Window, gtx layout.Context, row, col int) layout.Dimensions {
+		defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+
+		group := &groups[row]
+		switch colName := cc.table.Columns[col].Name; colName {
+		case "Total wait":
+			return cc.cellFormatter.Duration(win, gtx, group.Total, false)
+		case "Max wait":
+			return cc.cellFormatter.Duration(win, gtx, group.Max, false)
+		case "Waits":
+			spans := cc.spans[mode][row]
+			if spans == nil {
+				spans = cc.groupSpans(group)
+				cc.spans[mode][row] = spans
+			}
+			return layout.RightAligned(gtx, func(gtx layout.Context) layout.Dimensions {
+				link := cc.cellFormatter.Clicks.Grow()
+				link.Link = &SpansObjectLink{Spans: spans}
+				return link.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+					return widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, font.Font{}, win.Theme.TextSize, local.Sprintf("%d", len(group.Waits)), win.ColorMaterial(gtx, win.Theme.Palette.OpenLink))
+				})
+			})
+		case "Blocked in":
+			if group.Site == "" {
+				return cc.cellFormatter.Text(win, gtx, "various")
+			}
+			return cc.cellFormatter.Text(win, gtx, group.Site)
+		case "Lock holder":
+			if group.Holder == nil {
+				return layout.RightAligned(gtx, func(gtx layout.Context) layout.Dimensions {
+					return cc.cellFormatter.Text(win, gtx, "various")
+				})
+			}
+			return cc.cellFormatter.Goroutine(win, gtx, group.Holder, "")
+		default:
+			panic(colName)
+		}
+	}
+
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(theme.Dumb(win, theme.CheckBox(win.Theme, &cc.byHolder, tr("Group by lock holder")).Layout)),
+		layout.Rigid(layout.Spacer{Height: 5}.Layout),
+		layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+			return theme.SimpleTable(win, gtx, cc.table, &cc.scrollState, len(groups), cellFn)
+		}),
+	)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"reflect"
+	"sync"
+
+	"honnef.co/go/gotraceui/theme"
+)
+
+// A ContextMenuProvider contributes items to the context menus of objects of type T. It may return no items, e.g.
+// when none apply to the specific object.
+type ContextMenuProvider[T any] func(obj T) []*theme.MenuItem
+
+var contextMenuProviders struct {
+	mu sync.RWMutex
+	m  map[reflect.Type][]func(obj any) []*theme.MenuItem
+}
+
+// RegisterContextMenu registers a provider of context menu items for objects of type T, such as *ptrace.Goroutine,
+// Items[ptrace.Span], or exptrace.Time. This allows components to extend the context menus of objects without
+// having to modify every place that displays such menus.
+//
+// Items of providers are displayed in the order the providers were registered, after the items that are specific to
+// where the menu is opened.
+func RegisterContextMenu[T any](fn ContextMenuProvider[T]) {
+	typ := reflect.TypeFor[T]()
+	contextMenuProviders.mu.Lock()
+	defer contextMenuProviders.mu.Unlock()
+	if contextMenuProviders.m == nil {
+		contextMenuProviders.m = map[reflect.Type][]func(obj any) []*theme.MenuItem{}
+	}
+	contextMenuProviders.m[typ] = append(contextMenuProviders.m[typ], func(obj any) []*theme.MenuItem {
+		return fn(obj.(T))
+	})
+}
+
+// contextMenu returns the context menu for obj, consisting of the items in base, followed by the items of all
+// providers registered for T.
+func contextMenu[T any](obj T, base []*theme.MenuItem) []*theme.MenuItem {
+	contextMenuProviders.mu.RLock()
+	providers := contextMenuProviders.m[reflect.TypeFor[T]()]
+	contextMenuProviders.mu.RUnlock()
+
+	items := base
+	for _, fn := range providers {
+		items = append(items, fn(obj)...)
+	}
+	return items
+}
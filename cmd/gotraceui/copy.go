@@ -0,0 +1,292 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/trace/ptrace"
+
+	exptrace "golang.org/x/exp/trace"
+)
+
+type CopyFormat uint8
+
+const (
+	CopyAsText CopyFormat = iota
+	CopyAsJSON
+)
+
+// A Record is a flat description of an object, meant to be copied to the clipboard, e.g. for pasting into issues.
+type Record struct {
+	Title  string
+	Fields []RecordField
+	Stack  exptrace.Stack
+}
+
+// RecordField is a single field of a record. Values of type AdjustedTime and time.Duration are formatted as
+// human-readable text, and as nanoseconds in JSON.
+type RecordField struct {
+	Label string
+	Value any
+}
+
+func (r *Record) Add(label string, value any) {
+	r.Fields = append(r.Fields, RecordField{label, value})
+}
+
+func (r *Record) has(label string) bool {
+	for _, f := range r.Fields {
+		if f.Label == label {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonKey turns a field label into a key for JSON, such as "Created by" into "created_by".
+func jsonKey(label string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(label) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+		} else if sb.Len() > 0 && !strings.HasSuffix(sb.String(), "_") {
+			sb.WriteByte('_')
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "_")
+}
+
+func (r *Record) Text(tr *Trace) string {
+	var sb strings.Builder
+	if r.Title != "" {
+		sb.WriteString(r.Title)
+		sb.WriteString("\n")
+	}
+	for _, f := range r.Fields {
+		var v string
+		switch fv := f.Value.(type) {
+		case AdjustedTime:
+			v = formatTimestamp(nil, fv)
+		case time.Duration:
+			v = roundDuration(fv).String()
+		default:
+			v = fmt.Sprint(fv)
+		}
+		fmt.Fprintf(&sb, "%s: %s\n", f.Label, v)
+	}
+	if len(tr.Stacks[tr.StackID(r.Stack)]) != 0 {
+		sb.WriteString("Stack trace:\n")
+		for _, line := range strings.SplitAfter(strings.TrimSuffix(formatStack(tr, r.Stack, 0), "\n"), "\n") {
+			sb.WriteString("    ")
+			sb.WriteString(line)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func (r *Record) JSON(tr *Trace) (string, error) {
+	// We build the object by hand to preserve the order of fields.
+	var buf bytes.Buffer
+	buf.WriteString("{")
+	add := func(key string, value any) error {
+		if buf.Len() > 1 {
+			buf.WriteString(",")
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return err
+		}
+		v, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		buf.Write(k)
+		buf.WriteString(":")
+		buf.Write(v)
+		return nil
+	}
+
+	if r.Title != "" {
+		if err := add("title", r.Title); err != nil {
+			return "", err
+		}
+	}
+	for _, f := range r.Fields {
+		key := jsonKey(f.Label)
+		var v any
+		switch fv := f.Value.(type) {
+		case AdjustedTime:
+			key += "_ns"
+			v = int64(fv)
+		case time.Duration:
+			key += "_ns"
+			v = int64(fv)
+		default:
+			v = fv
+		}
+		if err := add(key, v); err != nil {
+			return "", err
+		}
+	}
+	if pcs := tr.Stacks[tr.StackID(r.Stack)]; len(pcs) != 0 {
+		type frame struct {
+			Function string `json:"function"`
+			File     string `json:"file"`
+			Line     uint64 `json:"line"`
+		}
+		frames := make([]frame, len(pcs))
+		for i, pc := range pcs {
+			f := tr.StackFrame(pc)
+			frames[i] = frame{f.Func, f.File, f.Line}
+		}
+		if err := add("stack", frames); err != nil {
+			return "", err
+		}
+	}
+	buf.WriteString("}")
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, buf.Bytes(), "", "\t"); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+func (r *Record) Format(tr *Trace, format CopyFormat) (string, error) {
+	switch format {
+	case CopyAsText:
+		return r.Text(tr), nil
+	case CopyAsJSON:
+		return r.JSON(tr)
+	default:
+		panic(fmt.Sprintf("unhandled format %d", format))
+	}
+}
+
+func goroutineRecord(tr *Trace, g *ptrace.Goroutine) *Record {
+	r := &Record{Title: local.Sprintf("Goroutine %d", g.ID)}
+	r.Add("Goroutine", uint64(g.ID))
+	if g.Function != nil {
+		r.Add("Function", g.Function.Func)
+	}
+	if g.Parent != 0 {
+		r.Add("Parent", uint64(g.Parent))
+	}
+	if ts, ok := g.Start.Get(); ok {
+		r.Add("Start", tr.AdjustedTime(ts))
+	}
+	if ts, ok := g.End.Get(); ok {
+		r.Add("End", tr.AdjustedTime(ts))
+	}
+	r.Add("Duration", time.Duration(g.EffectiveEnd()-g.EffectiveStart()))
+	r.Add("Spans", len(g.Spans))
+	return r
+}
+
+func taskRecord(tr *Trace, t *ptrace.Task) *Record {
+	r := &Record{Title: local.Sprintf("Task %d", t.ID)}
+	r.Add("Task", uint64(t.ID))
+	r.Add("Name", t.Name)
+	if t.Parent != 0 {
+		r.Add("Parent", uint64(t.Parent))
+	}
+	if ts, ok := t.Start.Get(); ok {
+		r.Add("Start", tr.AdjustedTime(ts))
+	}
+	if ts, ok := t.End.Get(); ok {
+		r.Add("End", tr.AdjustedTime(ts))
+	}
+	r.Add("Duration", time.Duration(t.EffectiveEnd()-t.EffectiveStart()))
+	return r
+}
+
+func spansRecord(tr *Trace, spans Items[ptrace.Span]) *Record {
+	first := spans.AtPtr(0)
+	last := LastItemPtr(spans)
+	r := &Record{}
+	r.Add("Start", tr.AdjustedTime(first.Start))
+	r.Add("End", tr.AdjustedTime(last.End))
+	r.Add("Duration", AccurateSpansDuration(spans))
+	state := stateNames[first.State]
+	for i := 1; i < spans.Len(); i++ {
+		if spans.AtPtr(i).State != first.State {
+			state = "mixed"
+			break
+		}
+	}
+	r.Add("State", state)
+	r.Add("Spans", spans.Len())
+	if c, ok := spans.Container(); ok {
+		r.Add("In", c.Timeline.shortName)
+		if spans.Len() == 1 && c.Track.kind != TrackKindExternal {
+			r.Stack = tr.Event(first.StartEvent).Stack()
+		}
+	}
+	return r
+}
+
+// objectRecord returns the record describing obj, which must be one of the objects supported by CopyObjectAction.
+func objectRecord(tr *Trace, obj any) *Record {
+	switch obj := obj.(type) {
+	case *ptrace.Goroutine:
+		return goroutineRecord(tr, obj)
+	case *ptrace.Task:
+		return taskRecord(tr, obj)
+	case Items[ptrace.Span]:
+		return spansRecord(tr, obj)
+	case exptrace.Time:
+		r := &Record{}
+		r.Add("Timestamp", tr.AdjustedTime(obj))
+		return r
+	case *Record:
+		return obj
+	default:
+		panic(fmt.Sprintf("unhandled type %T", obj))
+	}
+}
+
+func (l *CopyObjectAction) Open(gtx layout.Context, mwin *MainWindow) {
+	s, err := objectRecord(mwin.trace, l.Object).Format(mwin.trace, l.Format)
+	if err != nil {
+		mwin.twin.Notify(theme.NotificationError, local.Sprintf("Couldn't copy to clipboard: %s", err))
+		return
+	}
+	mwin.twin.AppWindow.WriteClipboard(s)
+	mwin.twin.ShowNotification(gtx, tr("Copied to clipboard"))
+}
+
+func newCopyMenuItem(obj any) *theme.MenuItem {
+	return &theme.MenuItem{
+		Label: PlainLabel(tr("Copy as")),
+		Submenu: func() []*theme.MenuItem {
+			return []*theme.MenuItem{
+				{
+					Label: PlainLabel(tr("Text")),
+					Action: func() theme.Action {
+						return &CopyObjectAction{Object: obj, Format: CopyAsText}
+					},
+				},
+				{
+					Label: PlainLabel(tr("JSON")),
+					Action: func() theme.Action {
+						return &CopyObjectAction{Object: obj, Format: CopyAsJSON}
+					},
+				},
+			}
+		},
+	}
+}
+
+func init() {
+	RegisterContextMenu(func(g *ptrace.Goroutine) []*theme.MenuItem { return []*theme.MenuItem{newCopyMenuItem(g)} })
+	RegisterContextMenu(func(t *ptrace.Task) []*theme.MenuItem { return []*theme.MenuItem{newCopyMenuItem(t)} })
+	RegisterContextMenu(func(spans Items[ptrace.Span]) []*theme.MenuItem { return []*theme.MenuItem{newCopyMenuItem(spans)} })
+	RegisterContextMenu(func(ts exptrace.Time) []*theme.MenuItem { return []*theme.MenuItem{newCopyMenuItem(ts)} })
+}
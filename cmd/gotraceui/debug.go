@@ -61,7 +61,7 @@ func (g *debugGraph) Layout(win *theme.Window, gtx layout.Context) layout.Dimens
 		return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
 			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
 				gtx.Constraints.Min.Y = 0
-				return widget.Label{Alignment: text.Middle}.Layout(gtx, win.Theme.Shaper, font.Font{}, 12, g.title, win.ColorMaterial(gtx, win.Theme.Palette.Foreground))
+				return widget.Label{Alignment: text.Middle}.Layout(gtx, win.Theme.Shaper, font.Font{}, win.Theme.TextSize, g.title, win.ColorMaterial(gtx, win.Theme.Palette.Foreground))
 			}),
 
 			layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
@@ -93,7 +93,7 @@ func (g *debugGraph) Layout(win *theme.Window, gtx layout.Context) layout.Dimens
 					}
 				}
 
-				widget.Label{}.Layout(gtx, win.Theme.Shaper, font.Font{}, 12, local.Sprintf("Min: %f\nMax: %f\nCurrent: %f", min, max, cur), win.ColorMaterial(gtx, win.Theme.Palette.Foreground))
+				widget.Label{}.Layout(gtx, win.Theme.Shaper, font.Font{}, win.Theme.TextSize, local.Sprintf("Min: %f\nMax: %f\nCurrent: %f", min, max, cur), win.ColorMaterial(gtx, win.Theme.Palette.Foreground))
 
 				if g.fixedZero {
 					min = 0
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// editorCommand is the command template used for opening source locations in an external editor. It is split into
+// fields on whitespace, and the placeholders {file} and {line} are replaced in each field. Templates that consist of
+// a single URL, such as vscode://file/{file}:{line}, are opened with the platform's URL handler instead of being
+// executed.
+//
+// The names of well-known editors can be used in place of a template, see editorPresets.
+var editorCommand string
+
+var editorPresets = map[string]string{
+	"vscode":  "vscode://file/{file}:{line}",
+	"goland":  "goland --line {line} {file}",
+	"idea":    "idea --line {line} {file}",
+	"emacs":   "emacsclient -n +{line} {file}",
+	"sublime": "subl {file}:{line}",
+	"zed":     "zed {file}:{line}",
+}
+
+// editorTemplate returns the command template to use, falling back to $VISUAL and $EDITOR if no template has been
+// configured explicitly.
+func editorTemplate() (string, error) {
+	tmpl := editorCommand
+	if tmpl == "" {
+		for _, env := range []string{"VISUAL", "EDITOR"} {
+			if ed := os.Getenv(env); ed != "" {
+				tmpl = ed + " +{line} {file}"
+				break
+			}
+		}
+	}
+	if tmpl == "" {
+		return "", errors.New("no editor configured, use the -editor flag or the GOTRACEUI_EDITOR environment variable")
+	}
+	if preset, ok := editorPresets[tmpl]; ok {
+		tmpl = preset
+	}
+	return tmpl, nil
+}
+
+// editorArgs expands the template for the given source location.
+func editorArgs(tmpl string, file string, line uint64) []string {
+	r := strings.NewReplacer("{file}", file, "{line}", strconv.FormatUint(line, 10))
+	fields := strings.Fields(tmpl)
+	for i, f := range fields {
+		fields[i] = r.Replace(f)
+	}
+	return fields
+}
+
+// openInEditor opens the source location in the configured editor. It does not wait for the editor to exit.
+func openInEditor(file string, line uint64) error {
+	if file == "" {
+		return errors.New("unknown source location")
+	}
+	tmpl, err := editorTemplate()
+	if err != nil {
+		return err
+	}
+	args := editorArgs(tmpl, file, line)
+	if len(args) == 0 {
+		return fmt.Errorf("invalid editor command %q", tmpl)
+	}
+	if len(args) == 1 && strings.Contains(args[0], "://") {
+		return openURL(args[0])
+	}
+	return startDetached(exec.Command(args[0], args[1:]...))
+}
+
+func openURL(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	return startDetached(cmd)
+}
+
+func startDetached(cmd *exec.Cmd) error {
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	// Reap the process once it exits.
+	go cmd.Wait()
+	return nil
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"image"
+	"regexp"
 	rtrace "runtime/trace"
+	"slices"
 	"sort"
 
 	"honnef.co/go/gotraceui/layout"
@@ -19,6 +21,52 @@ import (
 	exptrace "golang.org/x/exp/trace"
 )
 
+// The kinds of events displayed by EventList.
+type eventListKind uint8
+
+const (
+	eventListGoCreate eventListKind = iota
+	eventListGoUnblock
+	eventListSyscall
+	eventListUserLog
+	eventListTaskBegin
+	eventListTaskEnd
+)
+
+var eventListKindNames = [...]string{
+	eventListGoCreate:  "Goroutine creation",
+	eventListGoUnblock: "Goroutine unblock",
+	eventListSyscall:   "Syscall",
+	eventListUserLog:   "User log",
+	eventListTaskBegin: "Task start",
+	eventListTaskEnd:   "Task end",
+}
+
+func classifyEvent(ev *exptrace.Event) eventListKind {
+	switch ev.Kind() {
+	case exptrace.EventStateTransition:
+		trans := ev.StateTransition()
+		from, to := trans.Goroutine()
+		if ptrace.IsGoroutineCreation(&trans) {
+			return eventListGoCreate
+		} else if ptrace.IsGoroutineUnblock(&trans) {
+			return eventListGoUnblock
+		} else if to == exptrace.GoSyscall {
+			return eventListSyscall
+		} else {
+			panic(fmt.Sprintf("unexpected state transition %s -> %s", from, to))
+		}
+	case exptrace.EventLog:
+		return eventListUserLog
+	case exptrace.EventTaskBegin:
+		return eventListTaskBegin
+	case exptrace.EventTaskEnd:
+		return eventListTaskEnd
+	default:
+		panic(fmt.Sprintf("unexpected kind %s", ev.Kind()))
+	}
+}
+
 type EventList struct {
 	Trace  *Trace
 	Events Items[ptrace.EventID]
@@ -28,7 +76,14 @@ type EventList struct {
 		ShowGoSysCall widget.Bool
 		ShowUserLog   widget.Bool
 		ShowTasks     widget.Bool
+		// Only events whose messages match the text are shown. The text is a case-insensitive regular expression,
+		// or a plain substring if it isn't a valid regular expression.
+		Text widget.Editor
 	}
+	prevText string
+	// Filtering and sorting happen in the background, as goroutines can have hundreds of thousands of events. Until
+	// they're done, the previous events are displayed.
+	pending        *theme.Future[SortedItems[ptrace.EventID]]
 	filteredEvents SortedItems[ptrace.EventID]
 
 	table       theme.Table
@@ -39,62 +94,127 @@ type EventList struct {
 	prevSpans        []TextSpan
 }
 
-func (evs *EventList) UpdateFilter() {
-	if evs.Filter.ShowGoCreate.Value &&
-		evs.Filter.ShowGoUnblock.Value &&
-		evs.Filter.ShowGoSysCall.Value &&
-		evs.Filter.ShowUserLog.Value &&
-		evs.Filter.ShowTasks.Value {
+// eventListQuery is the filter and sort order of an EventList, captured for use in the background.
+type eventListQuery struct {
+	// Bitmap of eventListKind
+	kinds     uint64
+	text      *regexp.Regexp
+	sortedBy  int
+	sortOrder theme.SortOrder
+}
 
-		// Everything is shown
-		evs.filteredEvents = NewSortedItems(evs.Events)
-	} else if !evs.Filter.ShowGoCreate.Value &&
-		!evs.Filter.ShowGoUnblock.Value &&
-		!evs.Filter.ShowGoSysCall.Value &&
-		!evs.Filter.ShowUserLog.Value &&
-		!evs.Filter.ShowTasks.Value {
+func (evs *EventList) query() eventListQuery {
+	q := eventListQuery{
+		sortedBy:  evs.table.SortedBy,
+		sortOrder: evs.table.SortOrder,
+	}
+	set := func(b bool, kinds ...eventListKind) {
+		if b {
+			for _, k := range kinds {
+				q.kinds |= 1 << k
+			}
+		}
+	}
+	set(evs.Filter.ShowGoCreate.Value, eventListGoCreate)
+	set(evs.Filter.ShowGoUnblock.Value, eventListGoUnblock)
+	set(evs.Filter.ShowGoSysCall.Value, eventListSyscall)
+	set(evs.Filter.ShowUserLog.Value, eventListUserLog)
+	set(evs.Filter.ShowTasks.Value, eventListTaskBegin, eventListTaskEnd)
+
+	if s := evs.Filter.Text.Text(); s != "" {
+		re, err := regexp.Compile("(?i)" + s)
+		if err != nil {
+			re = regexp.MustCompile("(?i)" + regexp.QuoteMeta(s))
+		}
+		q.text = re
+	}
+	return q
+}
+
+// UpdateFilter starts filtering and sorting the events according to the current state of the filter and table.
+func (evs *EventList) UpdateFilter(win *theme.Window) {
+	q := evs.query()
+	events := evs.Events
+	evs.pending = theme.NewFuture(win, func(cancelled <-chan struct{}) SortedItems[ptrace.EventID] {
+		return evs.filterAndSort(events, q, cancelled)
+	})
+}
+
+func (evs *EventList) filterAndSort(events Items[ptrace.EventID], q eventListQuery, cancelled <-chan struct{}) SortedItems[ptrace.EventID] {
+	defer rtrace.StartRegion(context.Background(), "main.EventList.filterAndSort").End()
 
+	var filtered Items[ptrace.EventID]
+	switch {
+	case q.kinds == 1<<len(eventListKindNames)-1 && q.text == nil:
+		// Everything is shown
+		filtered = events
+	case q.kinds == 0:
 		// Nothing is shown
-		evs.filteredEvents = NewSortedItems(NoItems[ptrace.EventID]{})
-	} else {
-		// OPT(dh): multiple calls to FilterItems should be able to reuse memory
-		evs.filteredEvents = NewSortedItems(FilterItems[ptrace.EventID](evs.Events, func(evID *ptrace.EventID) bool {
-			ev := evs.Trace.Event(*evID)
-			switch ev.Kind() {
-			case exptrace.EventStateTransition:
-				trans := ev.StateTransition()
-				from, to := ev.StateTransition().Goroutine()
-				if ptrace.IsGoroutineCreation(&trans) {
-					return evs.Filter.ShowGoCreate.Value
-				} else if ptrace.IsGoroutineUnblock(&trans) {
-					return evs.Filter.ShowGoUnblock.Value
-				} else if to == exptrace.GoSyscall {
-					return evs.Filter.ShowGoSysCall.Value
-				} else {
-					panic(fmt.Sprintf("unexpected state transition %s -> %s", from, to))
+		filtered = NoItems[ptrace.EventID]{}
+	default:
+		n := 0
+		filtered = FilterItems(events, func(evID *ptrace.EventID) bool {
+			if n++; n%10000 == 0 {
+				select {
+				case <-cancelled:
+					return false
+				default:
 				}
-			case exptrace.EventLog:
-				return evs.Filter.ShowUserLog.Value
-			case exptrace.EventTaskBegin, exptrace.EventTaskEnd:
-				return evs.Filter.ShowTasks.Value
-			default:
-				panic(fmt.Sprintf("unexpected kind %s", ev.Kind()))
 			}
-		}))
+			ev := evs.Trace.Event(*evID)
+			if q.kinds&(1<<classifyEvent(ev)) == 0 {
+				return false
+			}
+			if q.text != nil && !q.text.MatchString(evs.eventMessage(ev)) {
+				return false
+			}
+			return true
+		})
+	}
+	select {
+	case <-cancelled:
+		return SortedItems[ptrace.EventID]{}
+	default:
 	}
 
-	var max int
-	for i := 0; i < evs.filteredEvents.Len(); i++ {
-		evID := evs.filteredEvents.At(i)
-		msgs := evs.eventMessage(evs.Trace.Event(evID))
-		n := 0
-		for _, msg := range msgs {
-			n += len(msg)
+	// Computing messages is expensive, so do it once per event instead of once per comparison.
+	var msgs []string
+	if q.sortedBy == 2 {
+		msgs = make([]string, filtered.Len())
+		for i := range msgs {
+			msgs[i] = evs.eventMessage(evs.Trace.Event(filtered.At(i)))
 		}
-		if n > max {
-			max = n
+	}
+
+	out := NewSortedItems(filtered)
+	descending := q.sortOrder == theme.SortDescending
+	// This function has to stay in sync with the cell function in Layout
+	switch q.sortedBy {
+	case 0: // Time
+		if descending {
+			slices.Reverse(out.Order)
 		}
+	case 1: // Kind
+		// Events are in chronological order, which breaks ties.
+		out.SortIndex(func(a, b int) int {
+			ka := classifyEvent(evs.Trace.Event(filtered.At(a)))
+			kb := classifyEvent(evs.Trace.Event(filtered.At(b)))
+			if c := cmp(eventListKindNames[ka], eventListKindNames[kb], descending); c != 0 {
+				return c
+			}
+			return cmp(a, b, false)
+		})
+	case 2: // Message
+		out.SortIndex(func(a, b int) int {
+			if c := cmp(msgs[a], msgs[b], descending); c != 0 {
+				return c
+			}
+			return cmp(a, b, false)
+		})
+	default:
+		panic(fmt.Sprintf("unreachable: %d", q.sortedBy))
 	}
+	return out
 }
 
 // HoveredLink returns the link that has been hovered during the last call to Layout.
@@ -108,106 +228,60 @@ func (evs *EventList) HoveredLink() ObjectLink {
 	return nil
 }
 
-func (evs *EventList) eventMessage(ev *exptrace.Event) []string {
-	switch ev.Kind() {
-	case exptrace.EventStateTransition:
-		trans := ev.StateTransition()
-		from, to := trans.Goroutine()
-		if from == exptrace.GoNotExist && to == exptrace.GoRunnable {
-			return []string{"Created goroutine ", local.Sprintf("%d", trans.Resource.Goroutine())}
-		} else if from == exptrace.GoWaiting && to == exptrace.GoRunnable {
-			return []string{"Unblocked goroutine ", local.Sprintf("%d", trans.Resource.Goroutine())}
-		} else if to == exptrace.GoSyscall {
-			stk := ev.Stack()
-			if stk != exptrace.NoStack {
-				frame := evs.Trace.PCs[evs.Trace.Stacks[stk][0]]
-				return []string{"Syscall ()", frame.Func}
-			} else {
-				return []string{"Syscall"}
-			}
-		} else {
-			panic(fmt.Sprintf("unexpected state transition %s -> %s", from, to))
+// eventMessage returns the text of the message column for an event, which is used for filtering and sorting.
+func (evs *EventList) eventMessage(ev *exptrace.Event) string {
+	switch classifyEvent(ev) {
+	case eventListGoCreate:
+		return local.Sprintf("Created goroutine %d", ev.StateTransition().Resource.Goroutine())
+	case eventListGoUnblock:
+		return local.Sprintf("Unblocked goroutine %d", ev.StateTransition().Resource.Goroutine())
+	case eventListSyscall:
+		if stk := ev.Stack(); stk != exptrace.NoStack {
+			frame := evs.Trace.StackFrame(evs.Trace.Stacks[evs.Trace.StackID(stk)][0])
+			return local.Sprintf("Syscall (%s)", frame.Func)
 		}
-	case exptrace.EventLog:
+		return "Syscall"
+	case eventListUserLog:
 		l := ev.Log()
 		if l.Category != "" {
-			return []string{"<> ", l.Category, l.Message}
-		} else {
-			return []string{l.Message}
+			return fmt.Sprintf("<%s> %s", l.Category, l.Message)
 		}
-	case exptrace.EventTaskBegin:
-		return []string{"Created task ", local.Sprintf("%d", ev.Task())}
-	case exptrace.EventTaskEnd:
-		return []string{"Subtask ended: ", local.Sprintf("%d", ev.Task())}
+		return l.Message
+	case eventListTaskBegin:
+		return local.Sprintf("Created task %d (%s)", ev.Task().ID, ev.Task().Type)
+	case eventListTaskEnd:
+		return local.Sprintf("Subtask ended: task %d (%s)", ev.Task().ID, ev.Task().Type)
 	default:
-		panic(fmt.Sprintf("unhandled kind %v", ev.Kind()))
+		panic("unreachable")
 	}
 }
 
-func (evs *EventList) sort() {
-	evs.filteredEvents.Sort(func(ap, bp *ptrace.EventID) int {
-		a, b := *ap, *bp
-		// This function has to stay in sync with the cell function in Layout
-		switch evs.table.SortedBy {
-		case 0: // Time
-			ea := evs.Trace.Event(a)
-			eb := evs.Trace.Event(b)
-			return cmp(ea.Time(), eb.Time(), evs.table.SortOrder == theme.SortDescending)
-		case 1: // Message
-			// XXX this code looks an awful lot like EventList.eventMessage
-			cellFn := func(evID ptrace.EventID) string {
-				ev := evs.Trace.Event(evID)
-				switch ev.Kind() {
-				case exptrace.EventStateTransition:
-					trans := ev.StateTransition()
-					from, to := trans.Goroutine()
-					if from == exptrace.GoNotExist && to == exptrace.GoRunnable {
-						return local.Sprintf("Created goroutine %d", trans.Resource.Goroutine())
-					} else if from == exptrace.GoWaiting && to == exptrace.GoRunnable {
-						return local.Sprintf("Unblocked goroutine %d", trans.Resource.Goroutine())
-					} else if to == exptrace.GoSyscall {
-						stk := trans.Stack
-						if stk != exptrace.NoStack {
-							frame := evs.Trace.PCs[evs.Trace.Stacks[stk][0]]
-							return fmt.Sprintf("Syscall (%s)", frame.Func)
-						} else {
-							return "Syscall"
-						}
-					} else {
-						panic(fmt.Sprintf("unexpected state transition %s -> %s", from, to))
-					}
-				case exptrace.EventLog:
-					l := ev.Log()
-					if l.Category != "" {
-						return fmt.Sprintf("<%s> %s", l.Category, l.Message)
-					} else {
-						return l.Message
-					}
-				default:
-					panic(fmt.Sprintf("unhandled kind %v", ev.Kind()))
-				}
-			}
-
-			return cmp(cellFn(a), cellFn(b), evs.table.SortOrder == theme.SortDescending)
-		default:
-			panic(fmt.Sprintf("unreachable: %d", evs.table.SortedBy))
-		}
-	})
-}
-
-func (evs *EventList) Update(gtx layout.Context) []TextEvent {
+func (evs *EventList) Update(win *theme.Window, gtx layout.Context) []TextEvent {
 	evs.table.Update(gtx)
 
+	// Don't short-circuit, so that all inputs consume their changes.
+	changed := false
 	if _, ok := evs.table.SortByClickedColumn(); ok {
-		evs.sort()
+		changed = true
+	}
+	changed = evs.Filter.ShowGoCreate.Update(gtx) || changed
+	changed = evs.Filter.ShowGoUnblock.Update(gtx) || changed
+	changed = evs.Filter.ShowGoSysCall.Update(gtx) || changed
+	changed = evs.Filter.ShowUserLog.Update(gtx) || changed
+	changed = evs.Filter.ShowTasks.Update(gtx) || changed
+	if s := evs.Filter.Text.Text(); s != evs.prevText {
+		evs.prevText = s
+		changed = true
+	}
+	if changed {
+		evs.UpdateFilter(win)
 	}
 
-	if evs.Filter.ShowGoCreate.Update(gtx) ||
-		evs.Filter.ShowGoUnblock.Update(gtx) ||
-		evs.Filter.ShowGoSysCall.Update(gtx) ||
-		evs.Filter.ShowUserLog.Update(gtx) ||
-		evs.Filter.ShowTasks.Update(gtx) {
-		evs.UpdateFilter()
+	if evs.pending != nil {
+		if filtered, ok := evs.pending.Result(); ok {
+			evs.pending = nil
+			evs.filteredEvents = filtered
+		}
 	}
 
 	var out []TextEvent
@@ -224,12 +298,14 @@ func (evs *EventList) Layout(win *theme.Window, gtx layout.Context) layout.Dimen
 	if evs.table.Columns == nil {
 		cols := []theme.Column{
 			{Name: "Time", Clickable: true, Alignment: text.End},
+			{Name: "Kind", Clickable: true, Alignment: text.Start},
 			{Name: "Message", Clickable: true, Alignment: text.Start},
 		}
 		evs.table.SetColumns(win, gtx, cols)
+		evs.Filter.Text.SingleLine = true
 	}
 
-	evs.Update(gtx)
+	evs.Update(win, gtx)
 
 	evs.timestampObjects.Reset()
 	evs.prevSpans = evs.prevSpans[:0]
@@ -271,6 +347,8 @@ func (evs *EventList) Layout(win *theme.Window, gtx layout.Context) layout.Dimen
 			addSpanTs(ev.Time())
 			txt.Alignment = text.End
 		case 1:
+			tb.Span(eventListKindNames[classifyEvent(ev)])
+		case 2:
 			switch ev.Kind() {
 			case exptrace.EventStateTransition:
 				trans := ev.StateTransition()
@@ -284,7 +362,7 @@ func (evs *EventList) Layout(win *theme.Window, gtx layout.Context) layout.Dimen
 				} else if to == exptrace.GoSyscall {
 					stk := ev.Stack()
 					if stk != exptrace.NoStack {
-						frame := evs.Trace.PCs[evs.Trace.Stacks[stk][0]]
+						frame := evs.Trace.StackFrame(evs.Trace.Stacks[evs.Trace.StackID(stk)][0])
 						tb.Span("Syscall (")
 						tb.Span(frame.Func)
 						tb.Span(")")
@@ -296,7 +374,7 @@ func (evs *EventList) Layout(win *theme.Window, gtx layout.Context) layout.Dimen
 				}
 			case exptrace.EventLog:
 				l := ev.Log()
-				if l.Message != "" {
+				if l.Category != "" {
 					tb.Span("<")
 					tb.Span(l.Category)
 					tb.Span("> ")
@@ -331,11 +409,11 @@ func (evs *EventList) Layout(win *theme.Window, gtx layout.Context) layout.Dimen
 		gtx.Constraints.Min = image.Point{}
 
 		checkboxes = append(checkboxes,
-			theme.Record(win, gtx, theme.CheckBox(win.Theme, &evs.Filter.ShowGoCreate, "Goroutine creations").Layout),
-			theme.Record(win, gtx, theme.CheckBox(win.Theme, &evs.Filter.ShowGoUnblock, "Goroutine unblocks").Layout),
-			theme.Record(win, gtx, theme.CheckBox(win.Theme, &evs.Filter.ShowGoSysCall, "Syscalls").Layout),
-			theme.Record(win, gtx, theme.CheckBox(win.Theme, &evs.Filter.ShowUserLog, "User logs").Layout),
-			theme.Record(win, gtx, theme.CheckBox(win.Theme, &evs.Filter.ShowTasks, "Task start/end").Layout),
+			theme.Record(win, gtx, theme.CheckBox(win.Theme, &evs.Filter.ShowGoCreate, tr("Goroutine creations")).Layout),
+			theme.Record(win, gtx, theme.CheckBox(win.Theme, &evs.Filter.ShowGoUnblock, tr("Goroutine unblocks")).Layout),
+			theme.Record(win, gtx, theme.CheckBox(win.Theme, &evs.Filter.ShowGoSysCall, tr("Syscalls")).Layout),
+			theme.Record(win, gtx, theme.CheckBox(win.Theme, &evs.Filter.ShowUserLog, tr("User logs")).Layout),
+			theme.Record(win, gtx, theme.CheckBox(win.Theme, &evs.Filter.ShowTasks, tr("Task start/end")).Layout),
 		)
 
 		for _, checkbox := range checkboxes {
@@ -354,6 +432,18 @@ func (evs *EventList) Layout(win *theme.Window, gtx layout.Context) layout.Dimen
 				return widestCheckbox.Dimensions
 			})
 		},
+		func(gtx layout.Context) layout.Dimensions {
+			return theme.TextBox(win.Theme, &evs.Filter.Text, "Filter messages (regexp)").Layout(win, gtx)
+		},
+		layout.Spacer{Height: 5}.Layout,
+		func(gtx layout.Context) layout.Dimensions {
+			label := local.Sprintf("Showing %d of %d events.", evs.filteredEvents.Len(), evs.Events.Len())
+			if evs.pending != nil {
+				label = "Filtering events…"
+			}
+			return theme.LineLabel(win.Theme, label).Layout(win, gtx)
+		},
+		layout.Spacer{Height: 5}.Layout,
 		func(gtx layout.Context) layout.Dimensions {
 			gtx.Constraints.Min = gtx.Constraints.Max
 
@@ -0,0 +1,495 @@
+package main
+
+// External lanes
+//
+// Programs that aren't linked against gotraceui can add lanes of their own to the timelines, for example to display
+// application-specific events extracted from logs next to the goroutines that were running at the time. A program
+// either gets started by gotraceui (-external), in which case it communicates via its standard input and output, or
+// connects to an address that gotraceui listens on (-external-listen).
+//
+// Both directions consist of JSON objects, one per line. Once a trace has been loaded, gotraceui sends
+//
+//	{"type": "hello", "version": 1, "start": <timestamp>, "end": <timestamp>}
+//
+// where start and end are the timestamps of the first and last events of the trace, in nanoseconds. All timestamps in
+// the protocol use the same clock as the trace. The program then sends any number of the following messages:
+//
+//	{"type": "lane", "lane": "db", "name": "Database queries"}
+//	{"type": "span", "lane": "db", "start": <timestamp>, "end": <timestamp>, "label": "SELECT", "tooltip": "…", "color": "blue"}
+//	{"type": "clear", "lane": "db"}
+//
+// Lanes are identified by their IDs and are created by the first message that refers to them. The lane message sets
+// the name that gets displayed, which defaults to the ID. Spans whose end isn't after their start are displayed as
+// instants. Overlapping spans are displayed in separate tracks of the lane. The color is one of the names in
+// externalColors. The clear message removes all spans from a lane. Malformed messages are answered with
+//
+//	{"type": "error", "message": "…"}
+//
+// and are otherwise ignored. See the manual for a complete description.
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/trace/ptrace"
+
+	exptrace "golang.org/x/exp/trace"
+)
+
+const (
+	externalProtocolVersion = 1
+	// maxExternalTracks limits how many overlapping spans a lane can display.
+	maxExternalTracks = 32
+)
+
+var (
+	externalCommand string
+	externalListen  string
+)
+
+// externalColors maps the color names of the protocol to the colors we use for spans.
+var externalColors = map[string]colorIndex{
+	"green":  colorStateActive,
+	"blue":   colorStateReady,
+	"red":    colorStateBlocked,
+	"gray":   colorStateInactive,
+	"purple": colorStateGC,
+	"violet": colorStateCgo,
+	"pink":   colorStateUserRegion,
+	"yellow": colorStateUndetermined,
+}
+
+type externalMessage struct {
+	Type    string        `json:"type"`
+	Version int           `json:"version,omitempty"`
+	Lane    string        `json:"lane,omitempty"`
+	Name    string        `json:"name,omitempty"`
+	Start   exptrace.Time `json:"start,omitempty"`
+	End     exptrace.Time `json:"end,omitempty"`
+	Label   string        `json:"label,omitempty"`
+	Tooltip string        `json:"tooltip,omitempty"`
+	Color   string        `json:"color,omitempty"`
+	Message string        `json:"message,omitempty"`
+}
+
+func (msg *externalMessage) validate() error {
+	switch msg.Type {
+	case "lane", "span", "clear":
+	case "":
+		return errors.New("missing message type")
+	default:
+		return fmt.Errorf("unknown message type %q", msg.Type)
+	}
+	if msg.Lane == "" {
+		return errors.New("missing lane")
+	}
+	if msg.Color != "" {
+		if _, ok := externalColors[msg.Color]; !ok {
+			return fmt.Errorf("unknown color %q", msg.Color)
+		}
+	}
+	return nil
+}
+
+type externalSpan struct {
+	Label   string
+	Tooltip string
+	Color   colorIndex
+}
+
+// An ExternalLane is a timeline whose spans are provided by an external program.
+type ExternalLane struct {
+	ID   string
+	Name string
+
+	// There are no events for external spans. Instead, the StartEvent of each span is an index into metas.
+	spans []ptrace.Span
+	metas []externalSpan
+	// The number of spans that couldn't be displayed because too many of them overlapped.
+	dropped int
+
+	tl *Timeline
+}
+
+func (lane *ExternalLane) meta(span *ptrace.Span) *externalSpan {
+	return &lane.metas[span.StartEvent]
+}
+
+func (lane *ExternalLane) add(msg *externalMessage) {
+	end := msg.End
+	if end <= msg.Start {
+		end = msg.Start + 1
+	}
+	c, ok := externalColors[msg.Color]
+	if !ok {
+		c = colorStateUserRegion
+	}
+	lane.spans = append(lane.spans, ptrace.Span{
+		Start:      msg.Start,
+		End:        end,
+		StartEvent: ptrace.EventID(len(lane.metas)),
+		State:      ptrace.StateNone,
+	})
+	lane.metas = append(lane.metas, externalSpan{Label: msg.Label, Tooltip: msg.Tooltip, Color: c})
+}
+
+// rebuild replaces the lane's tracks after its spans have changed, distributing overlapping spans across tracks.
+func (lane *ExternalLane) rebuild(cv *Canvas) {
+	if lane.tl == nil {
+		lane.tl = &Timeline{cv: cv, item: lane}
+	}
+	tl := lane.tl
+	tl.label = lane.Name
+	tl.shortName = lane.Name
+	for _, track := range tl.tracks {
+		if track.widget != nil {
+			cv.trackWidgetsCache.Put(track.widget)
+		}
+	}
+	tl.tracks = nil
+	tl.dropSpanIndex()
+
+	sorted := slices.Clone(lane.spans)
+	slices.SortStableFunc(sorted, func(a, b ptrace.Span) int {
+		return cmp(a.Start, b.Start, false)
+	})
+	var packed [][]ptrace.Span
+	lane.dropped = 0
+	for _, s := range sorted {
+		idx := slices.IndexFunc(packed, func(spans []ptrace.Span) bool {
+			return spans[len(spans)-1].End <= s.Start
+		})
+		if idx == -1 {
+			if len(packed) == maxExternalTracks {
+				lane.dropped++
+				continue
+			}
+			packed = append(packed, nil)
+			idx = len(packed) - 1
+		}
+		packed[idx] = append(packed[idx], s)
+	}
+
+	for _, spans := range packed {
+		track := NewTrack(tl, TrackKindExternal)
+		track.Start = spans[0].Start
+		track.End = spans[len(spans)-1].End
+		track.spans = theme.Immediate[Items[ptrace.Span]](SimpleItems[ptrace.Span, any]{
+			items: spans,
+			container: ItemContainer{
+				Timeline: tl,
+				Track:    track,
+			},
+			subslice: true,
+		})
+		track.spanLabel = lane.spanLabel
+		track.spanColor = lane.spanColor
+		track.spanTooltip = lane.spanTooltip
+		tl.tracks = append(tl.tracks, track)
+	}
+}
+
+func (lane *ExternalLane) spanLabel(spans Items[ptrace.Span], tr *Trace, out []string) []string {
+	if spans.Len() != 1 {
+		return out
+	}
+	if label := lane.meta(spans.AtPtr(0)).Label; label != "" {
+		out = append(out, label)
+	}
+	return out
+}
+
+func (lane *ExternalLane) spanColor(span *ptrace.Span, tr *Trace) colorIndex {
+	return lane.meta(span).Color
+}
+
+func (lane *ExternalLane) spanTooltip(win *theme.Window, gtx layout.Context, tr *Trace, spans Items[ptrace.Span]) layout.Dimensions {
+	var label string
+	if spans.Len() == 1 {
+		s := spans.AtPtr(0)
+		m := lane.meta(s)
+		if m.Label != "" {
+			label += m.Label + "\n"
+		}
+		if m.Tooltip != "" {
+			label += m.Tooltip + "\n"
+		}
+		label += local.Sprintf("Duration: %s\n", roundDuration(s.Duration()))
+	} else {
+		label = local.Sprintf("%d spans\n", spans.Len())
+	}
+	label += local.Sprintf("Time span: %s\n", roundDuration(SpansTimeSpan(spans).Duration()))
+	return theme.Tooltip(win.Theme, label).Layout(win, gtx)
+}
+
+// spansDescription describes spans of the lane in span panels. The default description doesn't apply, as it refers to
+// the events of spans.
+func (lane *ExternalLane) spansDescription(tr *Trace, spans Items[ptrace.Span]) func(win *theme.Window, gtx layout.Context) Description {
+	return func(win *theme.Window, gtx layout.Context) Description {
+		tb := TextBuilder{Window: win}
+		first, last := spans.AtPtr(0), LastItemPtr(spans)
+		attrs := []DescriptionAttribute{
+			{
+				Key:   "Start",
+				Value: *tb.DefaultLink(formatTimestamp(nil, tr.AdjustedTime(first.Start)), "Start of span", first.Start),
+			},
+			{
+				Key:   "End",
+				Value: *tb.DefaultLink(formatTimestamp(nil, tr.AdjustedTime(last.End)), "End of span", last.End),
+			},
+			{Key: "Duration", Value: *tb.Span(roundDuration(AccurateSpansDuration(spans)).String())},
+			{Key: "In", Value: *tb.Span(lane.Name)},
+		}
+		if spans.Len() == 1 {
+			m := lane.meta(first)
+			if m.Label != "" {
+				attrs = append(attrs, DescriptionAttribute{Key: "Label", Value: *tb.Span(m.Label)})
+			}
+			if m.Tooltip != "" {
+				attrs = append(attrs, DescriptionAttribute{Key: "Details", Value: *tb.Span(m.Tooltip)})
+			}
+		}
+		return Description{Attributes: attrs}
+	}
+}
+
+// externalSources runs the external programs of a main window and applies their messages to its canvas.
+type externalSources struct {
+	mwin *MainWindow
+	// The trace and canvas that lanes are added to. They don't change when the window switches to another trace.
+	trace  *Trace
+	cv     *Canvas
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu        sync.Mutex
+	pending   []externalMessage
+	scheduled bool
+
+	// Only accessed from the window's goroutine.
+	lanes []*ExternalLane
+}
+
+// startExternalSources starts the programs that feed lanes to the canvas, stopping those of the previous trace.
+func (mwin *MainWindow) startExternalSources() {
+	if mwin.external != nil {
+		mwin.external.cancel()
+		mwin.external = nil
+	}
+	if externalCommand == "" && externalListen == "" {
+		return
+	}
+	es := &externalSources{mwin: mwin, trace: mwin.trace, cv: mwin.canvas}
+	es.ctx, es.cancel = context.WithCancel(context.Background())
+	mwin.external = es
+	if externalCommand != "" {
+		go es.runCommand(externalCommand)
+	}
+	if externalListen != "" {
+		go es.listen(externalListen)
+	}
+}
+
+func (es *externalSources) notifyError(format string, args ...any) {
+	if es.ctx.Err() != nil {
+		return
+	}
+	es.mwin.twin.Notify(theme.NotificationError, local.Sprintf(format, args...))
+}
+
+// runCommand runs a program that communicates via its standard input and output. The command is split into
+// arguments at white space.
+func (es *externalSources) runCommand(command string) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		es.notifyError("Couldn't run external program: no command specified")
+		return
+	}
+	cmd := exec.CommandContext(es.ctx, args[0], args[1:]...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		es.notifyError("Couldn't run external program: %s", err)
+		return
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		es.notifyError("Couldn't run external program: %s", err)
+		return
+	}
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		es.notifyError("Couldn't run external program: %s", err)
+		return
+	}
+	es.serve(stdout, stdin)
+	stdin.Close()
+	if err := cmd.Wait(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%w: %s", err, msg)
+		}
+		es.notifyError("External program failed: %s", err)
+	}
+}
+
+// listen accepts connections from external programs. Addresses of the form unix:path refer to Unix sockets, all
+// other addresses are TCP addresses.
+func (es *externalSources) listen(addr string) {
+	network := "tcp"
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		network, addr = "unix", path
+	}
+	var lc net.ListenConfig
+	l, err := lc.Listen(es.ctx, network, addr)
+	if err != nil {
+		es.notifyError("Couldn't listen for external programs: %s", err)
+		return
+	}
+	context.AfterFunc(es.ctx, func() { l.Close() })
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				es.notifyError("Couldn't accept external program: %s", err)
+			}
+			return
+		}
+		go func() {
+			defer conn.Close()
+			stop := context.AfterFunc(es.ctx, func() { conn.Close() })
+			defer stop()
+			es.serve(conn, conn)
+		}()
+	}
+}
+
+// serve greets a program and reads its messages until it stops sending them.
+func (es *externalSources) serve(r io.Reader, w io.Writer) {
+	tr := es.trace
+	enc := json.NewEncoder(w)
+	// Programs that don't care about the greeting might not read their input at all, so we ignore errors when writing.
+	enc.Encode(externalMessage{Type: "hello", Version: externalProtocolVersion, Start: tr.Start(), End: tr.End()})
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+		var msg externalMessage
+		err := json.Unmarshal(sc.Bytes(), &msg)
+		if err == nil {
+			err = msg.validate()
+		}
+		if err != nil {
+			enc.Encode(externalMessage{Type: "error", Message: fmt.Sprintf("line %d: %s", line, err)})
+			continue
+		}
+		es.enqueue(msg)
+	}
+	if err := sc.Err(); err != nil && !errors.Is(err, net.ErrClosed) && !errors.Is(err, io.ErrClosedPipe) {
+		es.notifyError("Couldn't read from external program: %s", err)
+	}
+}
+
+// enqueue queues a message to be applied by the window's goroutine. Messages that arrive in quick succession get
+// applied together.
+func (es *externalSources) enqueue(msg externalMessage) {
+	es.mu.Lock()
+	es.pending = append(es.pending, msg)
+	schedule := !es.scheduled
+	es.scheduled = true
+	es.mu.Unlock()
+	if schedule {
+		es.mwin.twin.EmitAction(theme.ExecuteAction(func(gtx layout.Context) {
+			es.apply()
+		}))
+	}
+}
+
+func (es *externalSources) lane(id string) *ExternalLane {
+	for _, lane := range es.lanes {
+		if lane.ID == id {
+			return lane
+		}
+	}
+	lane := &ExternalLane{ID: id, Name: id}
+	es.lanes = append(es.lanes, lane)
+	return lane
+}
+
+func (es *externalSources) apply() {
+	es.mu.Lock()
+	msgs := es.pending
+	es.pending = nil
+	es.scheduled = false
+	es.mu.Unlock()
+	if es.ctx.Err() != nil {
+		// The window has loaded a different trace since.
+		return
+	}
+
+	changed := map[*ExternalLane]struct{}{}
+	for i := range msgs {
+		msg := &msgs[i]
+		lane := es.lane(msg.Lane)
+		switch msg.Type {
+		case "lane":
+			if msg.Name != "" {
+				lane.Name = msg.Name
+			}
+		case "span":
+			lane.add(msg)
+		case "clear":
+			// Keep the metadata, which spans in open panels still refer to.
+			lane.spans = nil
+		}
+		changed[lane] = struct{}{}
+	}
+
+	cv := es.cv
+	for _, lane := range es.lanes {
+		if _, ok := changed[lane]; !ok {
+			continue
+		}
+		dropped := lane.dropped
+		lane.rebuild(cv)
+		if lane.dropped > dropped {
+			es.notifyError("Lane %q has too many overlapping spans, not all of them are displayed", lane.Name)
+		}
+
+		idx := slices.Index(cv.timelines, lane.tl)
+		switch {
+		case len(lane.tl.tracks) == 0 && idx != -1:
+			// Timelines without tracks can't be displayed.
+			cv.timelines = slices.Delete(cv.timelines, idx, idx+1)
+		case len(lane.tl.tracks) != 0 && idx == -1:
+			// Display lanes below the GC, STW and network timelines, and in the order they were added.
+			at := slices.IndexFunc(cv.timelines, func(tl *Timeline) bool {
+				switch tl.item.(type) {
+				case *GC, *STW, *NetPoller, *ExternalLane:
+					return false
+				default:
+					return true
+				}
+			})
+			if at == -1 {
+				at = len(cv.timelines)
+			}
+			cv.timelines = slices.Insert(cv.timelines, at, lane.tl)
+		}
+	}
+	cv.timeline.heightsGen++
+}
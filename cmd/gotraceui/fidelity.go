@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"image"
+	rtrace "runtime/trace"
+	"time"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/widget"
+)
+
+// A fidelity is a level of detail at which traces are loaded. Lower fidelities merge short spans into the spans
+// preceding them, which makes gigantic traces more responsive.
+type fidelity struct {
+	// Name is the name used in the configuration.
+	Name  string
+	Label string
+	// Threshold is the duration below which spans get merged.
+	Threshold time.Duration
+}
+
+// fidelities lists the supported fidelities, from highest to lowest. The first one is the default.
+var fidelities = []fidelity{
+	{"full", "Full: keep all spans", 0},
+	{"high", "High: merge spans shorter than 1 µs", time.Microsecond},
+	{"medium", "Medium: merge spans shorter than 10 µs", 10 * time.Microsecond},
+	{"low", "Low: merge spans shorter than 100 µs", 100 * time.Microsecond},
+}
+
+// thinningThreshold returns the duration below which spans should be merged when loading traces, according to the
+// configured fidelity.
+func thinningThreshold() time.Duration {
+	userConfigMu.Lock()
+	name := userConfig.Fidelity
+	userConfigMu.Unlock()
+	for _, f := range fidelities {
+		if f.Name == name {
+			return f.Threshold
+		}
+	}
+	return 0
+}
+
+// FidelityDialog lets the user choose the fidelity at which traces are loaded.
+type FidelityDialog struct {
+	fidelities []widget.PrimaryClickable
+	// The fidelity that has been chosen, as stored in the configuration.
+	chosen string
+}
+
+func NewFidelityDialog() *FidelityDialog {
+	userConfigMu.Lock()
+	defer userConfigMu.Unlock()
+	return &FidelityDialog{
+		fidelities: make([]widget.PrimaryClickable, len(fidelities)),
+		chosen:     userConfig.Fidelity,
+	}
+}
+
+func (fd *FidelityDialog) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.FidelityDialog.Layout").End()
+
+	for i := range fd.fidelities {
+		for fd.fidelities[i].Clicked(gtx) {
+			fd.chosen = fidelities[i].Name
+			if i == 0 {
+				// Don't store the default.
+				fd.chosen = ""
+			}
+			userConfigMu.Lock()
+			userConfig.Fidelity = fd.chosen
+			if err := userConfig.Save(); err != nil {
+				win.Notify(theme.NotificationError, local.Sprintf("Couldn't save configuration: %s", err))
+			}
+			userConfigMu.Unlock()
+		}
+	}
+
+	children := []layout.Widget{
+		func(gtx layout.Context) layout.Dimensions {
+			return theme.LineLabel(win.Theme, tr("Lower fidelities make very large traces more responsive, at the cost of detail.")).Layout(win, gtx)
+		},
+		func(gtx layout.Context) layout.Dimensions {
+			return theme.LineLabel(win.Theme, tr("Changes take effect when traces are opened.")).Layout(win, gtx)
+		},
+		layout.Spacer{Height: 10}.Layout,
+	}
+	for i := range fidelities {
+		children = append(children, func(gtx layout.Context) layout.Dimensions {
+			label := fidelities[i].Label
+			if (i == 0 && fd.chosen == "") || fidelities[i].Name == fd.chosen {
+				label += " ✓"
+			}
+			return theme.Button(win.Theme, &fd.fidelities[i].Clickable, label).Layout(win, gtx)
+		}, layout.Spacer{Height: 5}.Layout)
+	}
+	return layout.Rigids(gtx, layout.Vertical, children...)
+}
+
+func displayFidelityDialog(win *theme.Window) {
+	fd := NewFidelityDialog()
+	win.SetModal(func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+		return theme.Dialog(win.Theme, tr("Trace fidelity")).Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+			gtx.Constraints.Min = gtx.Constraints.Constrain(image.Pt(500, 250))
+			gtx.Constraints.Max = gtx.Constraints.Min
+			return fd.Layout(win, gtx)
+		})
+	})
+}
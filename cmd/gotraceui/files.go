@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	rtrace "runtime/trace"
+	"sort"
+	"strings"
+	"time"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/widget"
+)
+
+const maxRecentTraces = 10
+
+// RecentTrace is a trace that has recently been opened.
+type RecentTrace struct {
+	Path     string    `json:"path"`
+	Size     int64     `json:"size"`
+	OpenedAt time.Time `json:"opened_at"`
+}
+
+func (rt RecentTrace) Description() string {
+	return local.Sprintf("%s, opened %s", formatBytes(rt.Size), rt.OpenedAt.Local().Format("2006-01-02 15:04"))
+}
+
+// addRecentTrace records that a trace has been opened and saves the configuration.
+func addRecentTrace(win *theme.Window, path string) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return
+	}
+	rt := RecentTrace{Path: path, OpenedAt: time.Now()}
+	if fi, err := uiFileSystem.Stat(path); err == nil {
+		rt.Size = fi.Size()
+	}
+
+	userConfigMu.Lock()
+	defer userConfigMu.Unlock()
+	recent := []RecentTrace{rt}
+	for _, o := range userConfig.RecentTraces {
+		if o.Path != path && len(recent) < maxRecentTraces {
+			recent = append(recent, o)
+		}
+	}
+	userConfig.RecentTraces = recent
+	if err := userConfig.Save(); err != nil {
+		win.Notify(theme.NotificationError, local.Sprintf("Couldn't save configuration: %s", err))
+	}
+}
+
+// recentTraces returns the recently opened traces, most recent first.
+func recentTraces() []RecentTrace {
+	userConfigMu.Lock()
+	defer userConfigMu.Unlock()
+	return append([]RecentTrace(nil), userConfig.RecentTraces...)
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// RecentTracesCommandProvider provides commands for opening recent traces in the command palette.
+type RecentTracesCommandProvider []RecentTrace
+
+func (p RecentTracesCommandProvider) Len() int { return len(p) }
+
+func (p RecentTracesCommandProvider) At(idx int) theme.Command {
+	rt := p[idx]
+	return theme.NormalCommand{
+		PrimaryLabel:   rt.Path,
+		SecondaryLabel: rt.Description(),
+		Fn: func() theme.Action {
+			return &OpenTraceFileAction{Path: rt.Path}
+		},
+	}
+}
+
+// FileBrowser is a simple file browser for choosing trace files, for systems that don't have native file dialogs or
+// for users that prefer a consistent experience.
+type FileBrowser struct {
+	// Open is called with the path of the chosen file.
+	Open func(path string)
+
+	dir     string
+	entries []os.DirEntry
+	err     error
+
+	filter     widget.Editor
+	prevFilter string
+	// Indices into entries of the entries that match the filter.
+	filtered []int
+
+	up      widget.PrimaryClickable
+	list    widget.List
+	entryBs []widget.PrimaryClickable
+}
+
+func NewFileBrowser(dir string, open func(path string)) *FileBrowser {
+	fb := &FileBrowser{Open: open}
+	fb.list.Axis = layout.Vertical
+	fb.filter.SingleLine = true
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+	fb.chdir(dir)
+	return fb
+}
+
+func (fb *FileBrowser) chdir(dir string) {
+	fb.dir = filepath.Clean(dir)
+	fb.entries, fb.err = uiFileSystem.ReadDir(fb.dir)
+	// Directories first, then files, each sorted by name.
+	sort.SliceStable(fb.entries, func(i, j int) bool {
+		return fb.entries[i].IsDir() && !fb.entries[j].IsDir()
+	})
+	fb.list.Position = layout.Position{}
+	fb.applyFilter()
+}
+
+// matchesFilter reports whether a file name matches the filter. Filters containing wildcards are treated as globs,
+// other filters match case-insensitive substrings.
+func matchesFilter(name, filter string) bool {
+	if filter == "" {
+		return true
+	}
+	if strings.ContainsAny(filter, "*?[") {
+		ok, _ := filepath.Match(filter, name)
+		return ok
+	}
+	return strings.Contains(strings.ToLower(name), strings.ToLower(filter))
+}
+
+func (fb *FileBrowser) applyFilter() {
+	fb.prevFilter = fb.filter.Text()
+	fb.filtered = fb.filtered[:0]
+	for i, e := range fb.entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		// Directories are always shown so that they can be navigated.
+		if e.IsDir() || matchesFilter(e.Name(), fb.prevFilter) {
+			fb.filtered = append(fb.filtered, i)
+		}
+	}
+	if cap(fb.entryBs) < len(fb.filtered) {
+		fb.entryBs = make([]widget.PrimaryClickable, len(fb.filtered))
+	}
+	fb.entryBs = fb.entryBs[:len(fb.filtered)]
+}
+
+func (fb *FileBrowser) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.FileBrowser.Layout").End()
+
+	if fb.filter.Text() != fb.prevFilter {
+		fb.applyFilter()
+	}
+	for fb.up.Clicked(gtx) {
+		fb.chdir(filepath.Dir(fb.dir))
+	}
+	for i := range fb.entryBs {
+		if !fb.entryBs[i].Clicked(gtx) {
+			continue
+		}
+		e := fb.entries[fb.filtered[i]]
+		path := filepath.Join(fb.dir, e.Name())
+		if e.IsDir() {
+			fb.chdir(path)
+		} else {
+			win.CloseModal()
+			fb.Open(path)
+		}
+		// Navigating replaces the entries, invalidating the remaining clickables.
+		break
+	}
+
+	return layout.Rigids(gtx, layout.Vertical,
+		func(gtx layout.Context) layout.Dimensions {
+			return layout.Rigids(gtx, layout.Horizontal,
+				func(gtx layout.Context) layout.Dimensions {
+					return theme.Button(win.Theme, &fb.up.Clickable, tr("Up")).Layout(win, gtx)
+				},
+				func(gtx layout.Context) layout.Dimensions {
+					return layout.Spacer{Width: 5}.Layout(gtx)
+				},
+				func(gtx layout.Context) layout.Dimensions {
+					return theme.LineLabel(win.Theme, fb.dir).Layout(win, gtx)
+				},
+			)
+		},
+		func(gtx layout.Context) layout.Dimensions {
+			return layout.Spacer{Height: 5}.Layout(gtx)
+		},
+		func(gtx layout.Context) layout.Dimensions {
+			return theme.TextBox(win.Theme, &fb.filter, "Filter file names, e.g. trace or *.out").Layout(win, gtx)
+		},
+		func(gtx layout.Context) layout.Dimensions {
+			return layout.Spacer{Height: 5}.Layout(gtx)
+		},
+		func(gtx layout.Context) layout.Dimensions {
+			if fb.err != nil {
+				return theme.Label(win.Theme, fb.err.Error()).Layout(win, gtx)
+			}
+			gtx.Constraints.Min = gtx.Constraints.Max
+			return theme.List(win.Theme, &fb.list).Layout(win, gtx, len(fb.filtered), func(gtx layout.Context, index int) layout.Dimensions {
+				e := fb.entries[fb.filtered[index]]
+				label := e.Name()
+				if e.IsDir() {
+					label += string(filepath.Separator)
+				} else if fi, err := e.Info(); err == nil {
+					label = fmt.Sprintf("%s (%s, %s)", label, formatBytes(fi.Size()), fi.ModTime().Format("2006-01-02 15:04"))
+				}
+				return fb.entryBs[index].Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+					gtx.Constraints.Min.X = gtx.Constraints.Max.X
+					return theme.LineLabel(win.Theme, label).Layout(win, gtx)
+				})
+			})
+		},
+	)
+}
+
+func displayFileBrowser(win *theme.Window, open func(path string)) {
+	var dir string
+	if recent := recentTraces(); len(recent) > 0 {
+		dir = filepath.Dir(recent[0].Path)
+	}
+	fb := NewFileBrowser(dir, open)
+	win.SetModal(func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+		return theme.Dialog(win.Theme, tr("Open trace")).Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+			gtx.Constraints.Min = gtx.Constraints.Constrain(image.Pt(800, 600))
+			gtx.Constraints.Max = gtx.Constraints.Min
+			return fb.Layout(win, gtx)
+		})
+	})
+}
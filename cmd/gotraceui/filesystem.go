@@ -0,0 +1,37 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+)
+
+// A fileSystem provides the file I/O of the user interface: opening traces, browsing directories, and storing the
+// configuration. Native builds use the operating system's file system. Builds for browsers, which can't access files
+// by path, store the configuration in the browser's local storage and open traces that the user picked or dropped
+// onto the page.
+type fileSystem interface {
+	// Open opens a file for reading. The returned value implements Name() string if the file has a path.
+	Open(path string) (io.ReadCloser, error)
+	ReadDir(path string) ([]fs.DirEntry, error)
+	Stat(path string) (fs.FileInfo, error)
+	// ReadFile and WriteFile read and replace small files, such as the configuration. WriteFile replaces files
+	// atomically.
+	ReadFile(path string) ([]byte, error)
+	WriteFile(path string, data []byte) error
+	Remove(path string) error
+	// ConfigDir returns the directory to store the configuration in.
+	ConfigDir() (string, error)
+}
+
+// uiFileSystem is the file system used by the user interface. The command line interface always uses the operating system.
+var uiFileSystem fileSystem = newFileSystem()
+
+// namedReader is a trace that was read into memory, such as one that was dropped onto the page in a browser.
+type namedReader struct {
+	*bytes.Reader
+	name string
+}
+
+func (r *namedReader) Name() string { return r.name }
+func (r *namedReader) Close() error { return nil }
@@ -0,0 +1,99 @@
+//go:build js
+
+package main
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"syscall/js"
+)
+
+//lint:ignore ST1005 This error is only used for display in the UI.
+var errNoFileSystem = errors.New("Browsers don't allow accessing files by path. Open traces via the file dialog or by dropping them onto the page instead.")
+
+// browserFileSystem stores files in the browser's local storage, keyed by their paths. It can't open traces by path.
+type browserFileSystem struct{}
+
+func newFileSystem() fileSystem { return browserFileSystem{} }
+
+func (browserFileSystem) Open(path string) (io.ReadCloser, error)    { return nil, errNoFileSystem }
+func (browserFileSystem) ReadDir(path string) ([]fs.DirEntry, error) { return nil, errNoFileSystem }
+func (browserFileSystem) Stat(path string) (fs.FileInfo, error)      { return nil, errNoFileSystem }
+func (browserFileSystem) ConfigDir() (string, error)                 { return "/", nil }
+
+func (browserFileSystem) Remove(path string) error {
+	js.Global().Get("localStorage").Call("removeItem", path)
+	return nil
+}
+
+func (browserFileSystem) ReadFile(path string) ([]byte, error) {
+	v := js.Global().Get("localStorage").Call("getItem", path)
+	if v.IsNull() {
+		return nil, fs.ErrNotExist
+	}
+	return []byte(v.String()), nil
+}
+
+func (browserFileSystem) WriteFile(path string, data []byte) (err error) {
+	defer func() {
+		// setItem throws when the storage quota has been exceeded.
+		if r := recover(); r != nil {
+			if jerr, ok := r.(js.Error); ok {
+				err = jerr
+			} else {
+				panic(r)
+			}
+		}
+	}()
+	js.Global().Get("localStorage").Call("setItem", path, string(data))
+	return nil
+}
+
+// recordCrashes does nothing in browsers, which display fatal errors in the console.
+func recordCrashes(path string) error { return nil }
+
+// processAlive reports whether the process with the given ID is running. Browsers don't have processes; all pages
+// share the same process ID and the autosave file that is named after it.
+func processAlive(pid int) bool { return false }
+
+// watchDroppedFiles calls fn, in a new goroutine, for files that get dropped onto the page.
+func watchDroppedFiles(fn func(name string, data []byte, err error)) {
+	doc := js.Global().Get("document")
+	// Dropping is only allowed if dragover gets cancelled.
+	doc.Call("addEventListener", "dragover", js.FuncOf(func(this js.Value, args []js.Value) any {
+		args[0].Call("preventDefault")
+		return nil
+	}))
+	doc.Call("addEventListener", "drop", js.FuncOf(func(this js.Value, args []js.Value) any {
+		ev := args[0]
+		ev.Call("preventDefault")
+		dropped := ev.Get("dataTransfer").Get("files")
+		if dropped.Length() == 0 {
+			return nil
+		}
+		file := dropped.Index(0)
+		name := file.Get("name").String()
+
+		var resolve, reject js.Func
+		release := func() {
+			resolve.Release()
+			reject.Release()
+		}
+		resolve = js.FuncOf(func(this js.Value, args []js.Value) any {
+			release()
+			buf := js.Global().Get("Uint8Array").New(args[0])
+			data := make([]byte, buf.Length())
+			js.CopyBytesToGo(data, buf)
+			go fn(name, data, nil)
+			return nil
+		})
+		reject = js.FuncOf(func(this js.Value, args []js.Value) any {
+			release()
+			go fn(name, nil, errors.New(args[0].Call("toString").String()))
+			return nil
+		})
+		file.Call("arrayBuffer").Call("then", resolve, reject)
+		return nil
+	}))
+}
@@ -0,0 +1,79 @@
+//go:build !js
+
+package main
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	rdebug "runtime/debug"
+	"syscall"
+)
+
+type osFileSystem struct{}
+
+func newFileSystem() fileSystem { return osFileSystem{} }
+
+func (osFileSystem) Open(path string) (io.ReadCloser, error)    { return os.Open(path) }
+func (osFileSystem) ReadDir(path string) ([]fs.DirEntry, error) { return os.ReadDir(path) }
+func (osFileSystem) Stat(path string) (fs.FileInfo, error)      { return os.Stat(path) }
+func (osFileSystem) ReadFile(path string) ([]byte, error)       { return os.ReadFile(path) }
+func (osFileSystem) Remove(path string) error                   { return os.Remove(path) }
+func (osFileSystem) ConfigDir() (string, error)                 { return os.UserConfigDir() }
+
+func (osFileSystem) WriteFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// watchDroppedFiles calls fn for files that get dropped onto the user interface. Native windows don't support
+// dropping files.
+func watchDroppedFiles(fn func(name string, data []byte, err error)) {}
+
+// recordCrashes makes the runtime write fatal errors to the file at path, replacing its contents.
+func recordCrashes(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return rdebug.SetCrashOutput(f, rdebug.CrashOptions{})
+}
+
+// processAlive reports whether the process with the given ID is running.
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		// On Windows, finding a process fails if it isn't running.
+		return false
+	}
+	defer p.Release()
+	if runtime.GOOS == "windows" {
+		return true
+	}
+	// Signal 0 checks for the existence of the process without sending a signal. EPERM means that the process
+	// exists, but belongs to another user.
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
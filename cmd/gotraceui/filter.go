@@ -3,11 +3,15 @@ package main
 import (
 	"context"
 	rtrace "runtime/trace"
+	"slices"
+	"time"
 
 	"honnef.co/go/gotraceui/layout"
 	"honnef.co/go/gotraceui/theme"
 	"honnef.co/go/gotraceui/trace/ptrace"
 	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/io/pointer"
 )
 
 type FilterMode uint8
@@ -22,13 +26,67 @@ type Filter struct {
 
 	// Bitmap of ptrace.SchedulingState
 	States uint64
+
+	// Brush highlights spans independently of the rest of the filter.
+	Brush SpanBrush
+}
+
+// A SpanBrush matches spans of certain states whose durations fall into a range, such as the range selected in a
+// histogram of span durations. The zero value matches nothing.
+type SpanBrush struct {
+	// Bitmap of ptrace.SchedulingState
+	States     uint64
+	Start, End time.Duration
+}
+
+func (b SpanBrush) Active() bool {
+	return b.End != 0
+}
+
+// brushSpans returns the brush matching those of spans whose durations fall into [start, end].
+func brushSpans(spans Items[ptrace.Span], start, end time.Duration) SpanBrush {
+	b := SpanBrush{Start: start, End: end}
+	for i := 0; i < spans.Len(); i++ {
+		b.States |= 1 << spans.AtPtr(i).State
+	}
+	return b
+}
+
+func (b SpanBrush) match(spans ptrace.Spans) bool {
+	for i := 0; i < spans.Len(); i++ {
+		if b.matchSpan(spans.AtPtr(i)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (b SpanBrush) matchSpan(s *ptrace.Span) bool {
+	if b.States&(1<<s.State) == 0 {
+		return false
+	}
+	d := s.Duration()
+	return d >= b.Start && d <= b.End
 }
 
 func (f Filter) HasState(state ptrace.SchedulingState) bool {
 	return f.States&(1<<state) != 0
 }
 
+// Active reports whether the filter highlights anything.
+func (f Filter) Active() bool {
+	return f.States != 0 || f.Brush.Active()
+}
+
+// MatchSpan reports whether the filter highlights an individual goroutine span.
+func (f Filter) MatchSpan(span *ptrace.Span) bool {
+	return (f.Brush.Active() && f.Brush.matchSpan(span)) || f.HasState(span.State)
+}
+
 func (f Filter) Match(spans ptrace.Spans, container ItemContainer) bool {
+	if f.Brush.Active() && f.Brush.match(spans) {
+		return true
+	}
 	if !f.couldMatch(spans, container) {
 		return false
 	}
@@ -81,9 +139,10 @@ func (f Filter) Match(spans ptrace.Spans, container ItemContainer) bool {
 // combinations.
 func (f Filter) couldMatch(spans ptrace.Spans, container ItemContainer) bool {
 	{
-		// Unset Mode so we can compare with the empty literal
+		// Unset Mode and Brush so we can compare with the empty literal
 		f := f
 		f.Mode = 0
+		f.Brush = SpanBrush{}
 		if f == (Filter{}) {
 			return false
 		}
@@ -126,14 +185,92 @@ func (f Filter) couldMatchState(spans ptrace.Spans, container ItemContainer) boo
 
 	case *STW, *GC:
 		return f.HasState(ptrace.StateActive)
+	case *NetPoller:
+		return f.HasState(ptrace.StateBlockedNet)
 	}
 
 	return true
 }
 
-type HighlightDialogStyle struct {
-	Filter *Filter
+// ActiveFilter is a filter that is currently in effect, displayed as a removable chip above the timelines.
+type ActiveFilter struct {
+	Label  string
+	Remove func()
+}
 
+// ActiveFilters returns a removable entry for each part of the filter that is currently in effect.
+func (f *Filter) ActiveFilters() []ActiveFilter {
+	var out []ActiveFilter
+	for state := range ptrace.StateLast {
+		if state == ptrace.StateNone || !f.HasState(state) {
+			continue
+		}
+		out = append(out, ActiveFilter{
+			Label:  local.Sprintf("Highlight: %s", stateNamesCapitalized[state]),
+			Remove: func() { f.States &^= 1 << state },
+		})
+	}
+	if f.Brush.Active() {
+		out = append(out, ActiveFilter{
+			Label:  local.Sprintf("Highlight: durations %s – %s", f.Brush.Start, f.Brush.End),
+			Remove: func() { f.Brush = SpanBrush{} },
+		})
+	}
+	return out
+}
+
+// FilterBar displays active filters as chips that can be removed with a single click.
+type FilterBar struct {
+	closes   []widget.PrimaryClickable
+	clearAll widget.PrimaryClickable
+	list     layout.List
+}
+
+// Layout displays the filters returned by filters, after applying removals requested by the user. It doesn't take up
+// any space if there are no active filters.
+func (fb *FilterBar) Layout(win *theme.Window, gtx layout.Context, filters func() []ActiveFilter) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.FilterBar.Layout").End()
+
+	active := filters()
+	for i := range fb.closes[:min(len(fb.closes), len(active))] {
+		for fb.closes[i].Clicked(gtx) {
+			active[i].Remove()
+		}
+	}
+	for fb.clearAll.Clicked(gtx) {
+		for _, f := range active {
+			f.Remove()
+		}
+	}
+
+	active = filters()
+	if len(active) == 0 {
+		return layout.Dimensions{}
+	}
+	if len(fb.closes) < len(active) {
+		fb.closes = slices.Grow(fb.closes, len(active))[:len(active)]
+	}
+
+	fb.list.Axis = layout.Horizontal
+	return layout.UniformInset(2).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		gtx.Constraints.Min.Y = 0
+		// The last item is the button for clearing all filters.
+		return fb.list.Layout(gtx, len(active)+1, func(gtx layout.Context, i int) layout.Dimensions {
+			if i == len(active) {
+				return fb.clearAll.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+					pointer.CursorPointer.Add(gtx.Ops)
+					return theme.Chip(win.Theme, "Clear all", nil).Layout(win, gtx)
+				})
+			}
+			return layout.Rigids(gtx, layout.Horizontal,
+				theme.Dumb(win, theme.Chip(win.Theme, active[i].Label, &fb.closes[i]).Layout),
+				layout.Spacer{Width: 4}.Layout,
+			)
+		})
+	})
+}
+
+type HighlightDialogStyle struct {
 	bits [ptrace.StateLast]widget.BackedBit[uint64]
 
 	list      widget.List
@@ -144,13 +281,16 @@ type HighlightDialogStyle struct {
 }
 
 func HighlightDialog(win *theme.Window, f *Filter) HighlightDialogStyle {
-	hd := HighlightDialogStyle{
-		Filter: f,
-	}
+	return statesDialog(&f.States)
+}
+
+// statesDialog returns a dialog for toggling the bits of a bitmap of ptrace.SchedulingState.
+func statesDialog(states *uint64) HighlightDialogStyle {
+	var hd HighlightDialogStyle
 	hd.list.Axis = layout.Vertical
 
 	for i := range hd.bits {
-		hd.bits[i].Bits = &f.States
+		hd.bits[i].Bits = states
 		hd.bits[i].Bit = i
 	}
 
@@ -169,6 +309,7 @@ func (hd *HighlightDialogStyle) Layout(win *theme.Window, gtx layout.Context) la
 					return theme.CheckBoxGroup(win.Theme, &hd.stateClickables[0], "General").Layout(win, gtx,
 						theme.CheckBox(win.Theme, &hd.bits[ptrace.StateInactive], stateNamesCapitalized[ptrace.StateInactive]),
 						theme.CheckBox(win.Theme, &hd.bits[ptrace.StateActive], stateNamesCapitalized[ptrace.StateActive]),
+						theme.CheckBox(win.Theme, &hd.bits[ptrace.StateCgo], stateNamesCapitalized[ptrace.StateCgo]),
 						theme.CheckBox(win.Theme, &hd.bits[ptrace.StateStuck], stateNamesCapitalized[ptrace.StateStuck]),
 						theme.CheckBox(win.Theme, &hd.bits[ptrace.StateReady], stateNamesCapitalized[ptrace.StateReady]),
 						theme.CheckBox(win.Theme, &hd.bits[ptrace.StateCreated], stateNamesCapitalized[ptrace.StateCreated]),
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+
+	"honnef.co/go/gotraceui/trace/ptrace"
+)
+
+func TestActiveFilters(t *testing.T) {
+	tr := &Trace{Trace: &ptrace.Trace{}}
+	gf := &GlobalFilter{}
+	gf.SetTimeRange(1000, 2000)
+	tf := &TimelineFilter{Pattern: regexp.MustCompile(`^net/http\.`)}
+	changed := 0
+
+	labels := func() []string {
+		var out []string
+		for _, f := range append(gf.ActiveFilters(tr), tf.ActiveFilters(func() { changed++ })...) {
+			out = append(out, f.Label)
+		}
+		return out
+	}
+	want := []string{"Time: 1,000 ns – 2,000 ns", `Timelines: function matches /^net/http\./`}
+	if got := labels(); !slices.Equal(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	for _, f := range append(gf.ActiveFilters(tr), tf.ActiveFilters(func() { changed++ })...) {
+		f.Remove()
+	}
+	if got := labels(); len(got) != 0 {
+		t.Errorf("got %q after removing all filters, want none", got)
+	}
+	if changed != 1 {
+		t.Errorf("removing the timeline filter notified %d times, want 1", changed)
+	}
+}
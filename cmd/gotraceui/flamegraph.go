@@ -21,9 +21,24 @@ type FlameGraphComponent struct {
 	state theme.FlameGraphState
 }
 
+// cpuSampleDuration computes the duration of a CPU sample by dividing the active time of all Ps by the total number
+// of samples. This should closely approximate the inverse of the configured sampling rate.
+func cpuSampleDuration(tr *Trace) time.Duration {
+	if len(tr.CPUSamples) == 0 {
+		return 0
+	}
+	var totalDuration time.Duration
+	for _, p := range tr.Processors {
+		for _, s := range p.Spans {
+			totalDuration += s.Duration()
+		}
+	}
+	return time.Duration(math.Round(float64(totalDuration) / float64(len(tr.CPUSamples))))
+}
+
 func (fc *FlameGraphComponent) Title() string {
 	if fc.g == nil {
-		return "Flame graph"
+		return tr("Flame graph")
 	} else {
 		// OPT(dh): avoid the allocation
 		return local.Sprintf("Flame graph for goroutine %d", fc.g.ID)
@@ -37,40 +52,32 @@ func (tlc *FlameGraphComponent) WantsTransition(gtx layout.Context) theme.Compon
 	return theme.ComponentStateNone
 }
 
-func NewFlameGraphComponent(win *theme.Window, tr *ptrace.Trace, g *ptrace.Goroutine) *FlameGraphComponent {
+type flameGraphKey struct {
+	// The goroutine to compute the flame graph for, or nil for the global flame graph.
+	g *ptrace.Goroutine
+}
+
+func NewFlameGraphComponent(win *theme.Window, tr *Trace, g *ptrace.Goroutine) *FlameGraphComponent {
 	return &FlameGraphComponent{
 		g: g,
-		fg: theme.NewFuture(win, func(cancelled <-chan struct{}) *widget.FlameGraph {
-			// Compute the sample duration by dividing the active time of all Ps by the total number of samples. This should
-			// closely approximate the inverse of the configured sampling rate.
-			//
-			// For the global flame graph, this is the most obvious choice. For goroutine flame graphs, we could arguably
-			// compute per-G averages, so that a goroutine that ran for 1ms won't show a flame graph span that's 10ms long.
-			// However, this wouldn't solve other, related problems, such as limiting the global flame graph to a portion of
-			// time.
+		fg: theme.Compute(&tr.analyses, win, flameGraphKey{g}, func() *widget.FlameGraph {
+			// For the global flame graph, using the average sample duration is the most obvious choice. For goroutine
+			// flame graphs, we could arguably compute per-G averages, so that a goroutine that ran for 1ms won't show a
+			// flame graph span that's 10ms long. However, this wouldn't solve other, related problems, such as limiting
+			// the global flame graph to a portion of time.
 			//
 			// In the end, samples happen on Ms, not Gs, and using an average is the simplest approximation that we can
 			// explain. It also corresponds to what go tool pprof does, although it doesn't have the trouble of showing
 			// graphs for individual goroutines.
-			var (
-				totalDuration  time.Duration
-				sampleDuration time.Duration
-			)
-			for _, p := range tr.Processors {
-				for _, s := range p.Spans {
-					totalDuration += s.Duration()
-				}
-			}
-			totalSamples := len(tr.CPUSamples)
-			sampleDuration = time.Duration(math.Round(float64(totalDuration) / float64(totalSamples)))
+			sampleDuration := cpuSampleDuration(tr)
 
 			var fg widget.FlameGraph
 			do := func(samples []ptrace.EventID) {
 				for _, sample := range samples {
-					pcs := tr.Stacks[tr.Event(sample).Stack()]
+					pcs := tr.Stacks[tr.StackID(tr.Event(sample).Stack())]
 					var frames widget.FlamegraphSample
 					for i := len(pcs) - 1; i >= 0; i-- {
-						fn := tr.PCs[pcs[i]].Func
+						fn := tr.FuncName(pcs[i])
 						frames = append(frames, widget.FlamegraphFrame{
 							Name:     fn,
 							Duration: sampleDuration,
@@ -118,6 +125,8 @@ func NewFlameGraphComponent(win *theme.Window, tr *ptrace.Trace, g *ptrace.Gorou
 						root = "GC"
 					case ptrace.StateBlockedSyscall:
 						root = "blocking syscall"
+					case ptrace.StateCgo:
+						root = "cgo"
 					case ptrace.StateStuck:
 					case ptrace.StateReady, ptrace.StateCreated, ptrace.StateWaitingPreempted:
 						root = "ready"
@@ -130,9 +139,9 @@ func NewFlameGraphComponent(win *theme.Window, tr *ptrace.Trace, g *ptrace.Gorou
 					if root != "" {
 						var frames widget.FlamegraphSample
 						if root != "ready" {
-							pcs := tr.Stacks[tr.Event(span.StartEvent).Stack()]
+							pcs := tr.Stacks[tr.StackID(tr.Event(span.StartEvent).Stack())]
 							for i := len(pcs) - 1; i >= 0; i-- {
-								fn := tr.PCs[pcs[i]].Func
+								fn := tr.FuncName(pcs[i])
 								frames = append(frames, widget.FlamegraphFrame{
 									Name:     fn,
 									Duration: span.Duration(),
@@ -155,8 +164,7 @@ func (fgc *FlameGraphComponent) Layout(win *theme.Window, gtx layout.Context) la
 	theme.Fill(win, gtx.Ops, win.Theme.Palette.Background)
 	fg, ok := fgc.fg.Result()
 	if !ok {
-		// XXX
-		return layout.Dimensions{}
+		return theme.Loading(win.Theme, tr("Computing flame graph…")).Layout(win, gtx)
 	}
 	fgs := theme.FlameGraph(fg, &fgc.state)
 	fgs.Color = flameGraphColorFn
@@ -199,19 +207,21 @@ func flameGraphColorFn(level, idx int, f *widget.FlamegraphFrame, hovered bool)
 	if level == 0 {
 		switch f.Name {
 		case "Running":
-			return adjustLight(colors[colorStateActive])
+			return adjustLight(paletteColor(colorStateActive))
 		case "blocked":
-			return adjustLight(colors[colorStateBlocked])
+			return adjustLight(paletteColor(colorStateBlocked))
 		case "send", "recv", "select", "sync", "sync.Once", "sync.Cond":
-			return adjustLight(colors[colorStateBlockedHappensBefore])
+			return adjustLight(paletteColor(colorStateBlockedHappensBefore))
 		case "GC", "triggering GC":
-			return adjustLight(colors[colorStateBlockedGC])
+			return adjustLight(paletteColor(colorStateBlockedGC))
 		case "I/O":
-			return adjustLight(colors[colorStateBlockedNet])
+			return adjustLight(paletteColor(colorStateBlockedNet))
 		case "blocking syscall":
-			return adjustLight(colors[colorStateBlockedSyscall])
+			return adjustLight(paletteColor(colorStateBlockedSyscall))
+		case "cgo":
+			return adjustLight(paletteColor(colorStateCgo))
 		case "ready":
-			return adjustLight(colors[colorStateReady])
+			return adjustLight(paletteColor(colorStateReady))
 		}
 	}
 
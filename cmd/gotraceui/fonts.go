@@ -0,0 +1,289 @@
+package main
+
+import (
+	stdcmp "cmp"
+	"context"
+	"fmt"
+	"image"
+	rtrace "runtime/trace"
+	"slices"
+	"strconv"
+	"strings"
+
+	ourfont "honnef.co/go/gotraceui/font"
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/font"
+	"gioui.org/unit"
+)
+
+const (
+	defaultTypeface          = "Go"
+	defaultMonospaceTypeface = "Go Mono"
+	defaultTextSize          = 12
+	minTextSize              = 6
+	maxTextSize              = 48
+)
+
+// fontCollection returns the built-in fonts as well as the fonts loaded from files. If a file cannot be loaded, the
+// remaining fonts are still returned, together with the error.
+func fontCollection(files []string) ([]font.FontFace, error) {
+	coll := slices.Clone(ourfont.Collection())
+	var firstErr error
+	for _, path := range files {
+		faces, err := ourfont.Load(path)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		coll = append(coll, faces...)
+	}
+	return coll, firstErr
+}
+
+// defaultFallbacks are the fallback typefaces used when the user hasn't configured any.
+var defaultFallbacks = []string{ourfont.FallbackTypeface}
+
+// fallbackTypefaces returns the configured fallback typefaces, or the default ones.
+func fallbackTypefaces(cfg FontConfig) []font.Typeface {
+	names := cfg.Fallbacks
+	if len(names) == 0 {
+		names = defaultFallbacks
+	}
+	out := make([]font.Typeface, len(names))
+	for i, name := range names {
+		out[i] = font.Typeface(name)
+	}
+	return out
+}
+
+// applyFontConfig configures the fonts and text size of a theme.
+func applyFontConfig(th *theme.Theme, cfg FontConfig) error {
+	coll, err := fontCollection(cfg.Files)
+	th.SetFonts(coll, font.Typeface(stdcmp.Or(cfg.Typeface, defaultTypeface)), fallbackTypefaces(cfg)...)
+	th.MonospaceTypeface = font.Typeface(stdcmp.Or(cfg.MonospaceTypeface, defaultMonospaceTypeface))
+	size := stdcmp.Or(cfg.TextSize, defaultTextSize)
+	size = min(max(size, minTextSize), maxTextSize)
+	th.TextSize = unit.Sp(size)
+	th.TextSizeLarge = unit.Sp(size + 2)
+	return err
+}
+
+// restoreFonts applies the font configuration to the window.
+func (mwin *MainWindow) restoreFonts() {
+	userConfigMu.Lock()
+	cfg := userConfig.Fonts
+	userConfigMu.Unlock()
+	if cfg == nil {
+		return
+	}
+	if err := applyFontConfig(mwin.twin.Theme, *cfg); err != nil {
+		mwin.twin.Notify(theme.NotificationError, local.Sprintf("Couldn't load fonts: %s", err))
+	}
+}
+
+// FontsDialog lets the user choose the fonts and text size.
+type FontsDialog struct {
+	typeface          widget.Editor
+	monospaceTypeface widget.Editor
+	textSize          widget.Editor
+	files             widget.Editor
+	fallbacks         widget.Editor
+
+	apply        widget.PrimaryClickable
+	reset        widget.PrimaryClickable
+	err          string
+	availableFor []string
+	available    string
+}
+
+func NewFontsDialog() *FontsDialog {
+	fd := &FontsDialog{}
+	fd.typeface.SingleLine = true
+	fd.monospaceTypeface.SingleLine = true
+	fd.textSize.SingleLine = true
+
+	userConfigMu.Lock()
+	var cfg FontConfig
+	if userConfig.Fonts != nil {
+		cfg = *userConfig.Fonts
+	}
+	userConfigMu.Unlock()
+	fd.set(cfg)
+	return fd
+}
+
+// editorLines returns the non-empty lines of an editor.
+func editorLines(ed *widget.Editor) []string {
+	var lines []string
+	for _, line := range strings.Split(ed.Text(), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// fontFiles returns the font files listed in the dialog, one per line.
+func (fd *FontsDialog) fontFiles() []string {
+	return editorLines(&fd.files)
+}
+
+func (fd *FontsDialog) set(cfg FontConfig) {
+	fd.typeface.SetText(stdcmp.Or(cfg.Typeface, defaultTypeface))
+	fd.monospaceTypeface.SetText(stdcmp.Or(cfg.MonospaceTypeface, defaultMonospaceTypeface))
+	fd.textSize.SetText(strconv.FormatFloat(float64(stdcmp.Or(cfg.TextSize, defaultTextSize)), 'g', -1, 32))
+	fd.files.SetText(strings.Join(cfg.Files, "\n"))
+	if len(cfg.Fallbacks) == 0 {
+		fd.fallbacks.SetText(strings.Join(defaultFallbacks, "\n"))
+	} else {
+		fd.fallbacks.SetText(strings.Join(cfg.Fallbacks, "\n"))
+	}
+}
+
+// config returns the configuration described by the dialog's inputs.
+func (fd *FontsDialog) config() (FontConfig, error) {
+	cfg := FontConfig{Files: fd.fontFiles()}
+	coll, err := fontCollection(cfg.Files)
+	if err != nil {
+		return FontConfig{}, err
+	}
+	typefaces := ourfont.Typefaces(coll)
+
+	cfg.Typeface = strings.TrimSpace(fd.typeface.Text())
+	cfg.MonospaceTypeface = strings.TrimSpace(fd.monospaceTypeface.Text())
+	cfg.Fallbacks = editorLines(&fd.fallbacks)
+	for _, tf := range append([]string{cfg.Typeface, cfg.MonospaceTypeface}, cfg.Fallbacks...) {
+		if !slices.Contains(typefaces, font.Typeface(tf)) {
+			return FontConfig{}, fmt.Errorf("unknown typeface %q", tf)
+		}
+	}
+
+	size, err := strconv.ParseFloat(strings.TrimSpace(fd.textSize.Text()), 32)
+	if err != nil || size < minTextSize || size > maxTextSize {
+		return FontConfig{}, fmt.Errorf("text size must be a number between %d and %d", minTextSize, maxTextSize)
+	}
+	cfg.TextSize = float32(size)
+
+	// Don't store defaults, so that changes to them take effect.
+	if cfg.Typeface == defaultTypeface {
+		cfg.Typeface = ""
+	}
+	if cfg.MonospaceTypeface == defaultMonospaceTypeface {
+		cfg.MonospaceTypeface = ""
+	}
+	if cfg.TextSize == defaultTextSize {
+		cfg.TextSize = 0
+	}
+	if slices.Equal(cfg.Fallbacks, defaultFallbacks) {
+		cfg.Fallbacks = nil
+	}
+	return cfg, nil
+}
+
+func (fd *FontsDialog) save(win *theme.Window, cfg FontConfig) {
+	if err := applyFontConfig(win.Theme, cfg); err != nil {
+		fd.err = err.Error()
+		return
+	}
+	fd.err = ""
+
+	userConfigMu.Lock()
+	defer userConfigMu.Unlock()
+	if len(cfg.Files) == 0 && cfg.Typeface == "" && cfg.MonospaceTypeface == "" && cfg.TextSize == 0 && len(cfg.Fallbacks) == 0 {
+		userConfig.Fonts = nil
+	} else {
+		userConfig.Fonts = &cfg
+	}
+	if err := userConfig.Save(); err != nil {
+		win.Notify(theme.NotificationError, local.Sprintf("Couldn't save configuration: %s", err))
+	}
+}
+
+func (fd *FontsDialog) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.FontsDialog.Layout").End()
+
+	for fd.apply.Clicked(gtx) {
+		if cfg, err := fd.config(); err != nil {
+			fd.err = err.Error()
+		} else {
+			fd.save(win, cfg)
+		}
+	}
+	for fd.reset.Clicked(gtx) {
+		fd.set(FontConfig{})
+		fd.save(win, FontConfig{})
+	}
+
+	// Only reload the font files when they change, not every frame.
+	files := fd.fontFiles()
+	if fd.availableFor == nil || !slices.Equal(files, fd.availableFor) {
+		fd.availableFor = files
+		coll, _ := fontCollection(files)
+		var names []string
+		for _, tf := range ourfont.Typefaces(coll) {
+			names = append(names, string(tf))
+		}
+		fd.available = "Available typefaces: " + strings.Join(names, ", ")
+	}
+
+	field := func(label string, ed *widget.Editor, hint string) theme.Widget {
+		return func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+			return layout.Rigids(gtx, layout.Vertical,
+				func(gtx layout.Context) layout.Dimensions {
+					return theme.LineLabel(win.Theme, label).Layout(win, gtx)
+				},
+				func(gtx layout.Context) layout.Dimensions {
+					return theme.TextBox(win.Theme, ed, hint).Layout(win, gtx)
+				},
+				layout.Spacer{Height: 10}.Layout,
+			)
+		}
+	}
+
+	return layout.Rigids(gtx, layout.Vertical,
+		theme.Dumb(win, field("Typeface", &fd.typeface, defaultTypeface)),
+		theme.Dumb(win, field("Monospace typeface, used for stack traces", &fd.monospaceTypeface, defaultMonospaceTypeface)),
+		theme.Dumb(win, field("Text size", &fd.textSize, strconv.Itoa(defaultTextSize))),
+		theme.Dumb(win, field("Additional font files, one per line", &fd.files, "/usr/share/fonts/TTF/DejaVuSans.ttf")),
+		theme.Dumb(win, field("Fallback typefaces for symbols and emoji, one per line, in order of preference", &fd.fallbacks, ourfont.FallbackTypeface)),
+		func(gtx layout.Context) layout.Dimensions {
+			return theme.Label(win.Theme, fd.available).Layout(win, gtx)
+		},
+		layout.Spacer{Height: 10}.Layout,
+		func(gtx layout.Context) layout.Dimensions {
+			return layout.Rigids(gtx, layout.Horizontal,
+				func(gtx layout.Context) layout.Dimensions {
+					return theme.Button(win.Theme, &fd.apply.Clickable, tr("Apply")).Layout(win, gtx)
+				},
+				layout.Spacer{Width: 5}.Layout,
+				func(gtx layout.Context) layout.Dimensions {
+					return theme.Button(win.Theme, &fd.reset.Clickable, tr("Reset to defaults")).Layout(win, gtx)
+				},
+			)
+		},
+		func(gtx layout.Context) layout.Dimensions {
+			if fd.err == "" {
+				return layout.Dimensions{}
+			}
+			l := theme.Label(win.Theme, fd.err)
+			l.Color = colors[colorStateBlocked]
+			return l.Layout(win, gtx)
+		},
+	)
+}
+
+func displayFontsDialog(win *theme.Window) {
+	fd := NewFontsDialog()
+	win.SetModal(func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+		return theme.Dialog(win.Theme, tr("Fonts")).Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+			gtx.Constraints.Min = gtx.Constraints.Constrain(image.Pt(800, 500))
+			gtx.Constraints.Max = gtx.Constraints.Min
+			return fd.Layout(win, gtx)
+		})
+	})
+}
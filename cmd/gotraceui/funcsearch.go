@@ -0,0 +1,515 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	rtrace "runtime/trace"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/trace/ptrace"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/font"
+	"gioui.org/op/clip"
+	"gioui.org/text"
+	exptrace "golang.org/x/exp/trace"
+)
+
+// isFuzzyBoundary reports whether a character following prev starts a new word, such as an element of a package path,
+// an identifier or the upper case part of a camel case identifier.
+func isFuzzyBoundary(prev, r rune) bool {
+	switch prev {
+	case '.', '/', '(', ')', '*', '[', ']', '_', '-', ' ':
+		return true
+	}
+	return unicode.IsUpper(r) && unicode.IsLower(prev)
+}
+
+// fuzzyMatch reports whether the characters of pattern appear in s in order, ignoring case, and scores the match.
+// Higher scores are better matches. Consecutive characters and characters at the start of words score higher, and
+// shorter strings score higher than longer ones.
+func fuzzyMatch(pattern, s string) (int, bool) {
+	if pattern == "" {
+		return 0, true
+	}
+	score := 0
+	consecutive := false
+	var prev rune
+	for i, r := range s {
+		p, size := utf8.DecodeRuneInString(pattern)
+		if unicode.ToLower(r) == unicode.ToLower(p) {
+			score++
+			if consecutive {
+				score += 5
+			}
+			if i == 0 || isFuzzyBoundary(prev, r) {
+				score += 10
+			}
+			consecutive = true
+			pattern = pattern[size:]
+			if pattern == "" {
+				return score*10 - utf8.RuneCountInString(s), true
+			}
+		} else {
+			consecutive = false
+		}
+		prev = r
+	}
+	return 0, false
+}
+
+// functionOccurrences describes where a function appears in the trace's stacks.
+type functionOccurrences struct {
+	Function string
+	// Created are the goroutines whose creation stacks contain the function.
+	Created []*ptrace.Goroutine
+	// The number of goroutine spans and CPU samples whose stacks contain the function.
+	Spans, Samples int
+}
+
+type functionIndexKey struct{}
+
+// computeFunctionIndex finds all functions that appear in the stacks of goroutine creations, goroutine spans, and
+// CPU samples. The result is sorted by function name.
+func computeFunctionIndex(o *theme.Operation, tr *Trace) ([]functionOccurrences, error) {
+	defer rtrace.StartRegion(context.Background(), "main.computeFunctionIndex").End()
+
+	// Count the occurrences of each stack and attribute them to the stack's functions afterwards, which is much
+	// cheaper than walking the stacks of all spans.
+	type stackCounts struct {
+		created        []*ptrace.Goroutine
+		spans, samples int
+	}
+	byStack := map[ptrace.StackID]*stackCounts{}
+	get := func(stk exptrace.Stack) *stackCounts {
+		id := tr.StackID(stk)
+		c, ok := byStack[id]
+		if !ok {
+			c = &stackCounts{}
+			byStack[id] = c
+		}
+		return c
+	}
+	for j, g := range tr.Goroutines {
+		if j%100 == 0 {
+			if err := o.Context().Err(); err != nil {
+				return nil, err
+			}
+			o.SetProgress(float64(j) / float64(len(tr.Goroutines)))
+		}
+		for i, s := range g.Spans {
+			stk := tr.Event(s.StartEvent).Stack()
+			if stk == exptrace.NoStack {
+				continue
+			}
+			if i == 0 && s.State == ptrace.StateCreated {
+				// The stack of the creation event is that of the go statement.
+				c := get(stk)
+				c.created = append(c.created, g)
+			} else {
+				get(stk).spans++
+			}
+		}
+	}
+	for _, id := range tr.CPUSamples {
+		if stk := tr.Event(id).Stack(); stk != exptrace.NoStack {
+			get(stk).samples++
+		}
+	}
+
+	byFn := map[string]*functionOccurrences{}
+	seen := map[string]struct{}{}
+	for id, c := range byStack {
+		// Recursive functions only count once per stack.
+		clear(seen)
+		for _, pc := range tr.Stacks[id] {
+			fn := tr.FuncName(pc)
+			if _, ok := seen[fn]; ok {
+				continue
+			}
+			seen[fn] = struct{}{}
+			o, ok := byFn[fn]
+			if !ok {
+				o = &functionOccurrences{Function: fn}
+				byFn[fn] = o
+			}
+			o.Created = append(o.Created, c.created...)
+			o.Spans += c.spans
+			o.Samples += c.samples
+		}
+	}
+
+	out := make([]functionOccurrences, 0, len(byFn))
+	for _, o := range byFn {
+		out = append(out, *o)
+	}
+	slices.SortFunc(out, func(a, b functionOccurrences) int {
+		return strings.Compare(a.Function, b.Function)
+	})
+	return out, nil
+}
+
+// functionInstances are the goroutine spans and CPU samples whose stacks contain a function.
+type functionInstances struct {
+	Spans []struct {
+		Goroutine *ptrace.Goroutine
+		Spans     []ptrace.Span
+	}
+	Samples []ptrace.EventID
+}
+
+type functionInstancesKey struct {
+	fn string
+}
+
+func computeFunctionInstances(o *theme.Operation, tr *Trace, fn string) (*functionInstances, error) {
+	defer rtrace.StartRegion(context.Background(), "main.computeFunctionInstances").End()
+
+	// Whether each of the trace's stacks contains the function, by stack ID.
+	stacks := make([]bool, len(tr.Stacks))
+	for id, pcs := range tr.Stacks {
+		for _, pc := range pcs {
+			if tr.FuncName(pc) == fn {
+				stacks[id] = true
+				break
+			}
+		}
+	}
+
+	out := &functionInstances{}
+	for j, g := range tr.Goroutines {
+		if j%100 == 0 {
+			if err := o.Context().Err(); err != nil {
+				return nil, err
+			}
+			o.SetProgress(float64(j) / float64(len(tr.Goroutines)))
+		}
+		var spans []ptrace.Span
+		for i, s := range g.Spans {
+			if i == 0 && s.State == ptrace.StateCreated {
+				continue
+			}
+			if stacks[tr.StackID(tr.Event(s.StartEvent).Stack())] {
+				spans = append(spans, s)
+			}
+		}
+		if len(spans) != 0 {
+			out.Spans = append(out.Spans, struct {
+				Goroutine *ptrace.Goroutine
+				Spans     []ptrace.Span
+			}{g, spans})
+		}
+	}
+	for _, id := range tr.CPUSamples {
+		if stacks[tr.StackID(tr.Event(id).Stack())] {
+			out.Samples = append(out.Samples, id)
+		}
+	}
+	return out, nil
+}
+
+// FunctionSearchComponent searches the functions in all stacks of the trace with fuzzy matching. Selecting a
+// function lists the goroutines created by it and the spans and CPU samples it appears in.
+type FunctionSearchComponent struct {
+	trace  *Trace
+	canvas *Canvas
+	win    *theme.Window
+	index  *theme.Future[[]functionOccurrences]
+	// The operations computing the index and the instances of the selected function.
+	indexOp     *theme.Operation
+	instancesOp *theme.Operation
+	progress    theme.OperationProgressState
+	retry       widget.PrimaryClickable
+
+	query     widget.Editor
+	prevQuery string
+	// The indices of the functions matching the query, best match first.
+	results []int
+
+	table         *theme.Table
+	scrollState   theme.YScrollableListState
+	cellFormatter CellFormatter
+	selects       []widget.PrimaryClickable
+
+	selected    string
+	instances   *theme.Future[*functionInstances]
+	split       theme.SplitterState
+	tabbedState theme.TabbedState
+	goroutines  GoroutineList
+	spans       *SpanList
+	samples     struct {
+		table         *theme.Table
+		scrollState   theme.YScrollableListState
+		cellFormatter CellFormatter
+	}
+}
+
+func NewFunctionSearchComponent(win *theme.Window, tr *Trace, canvas *Canvas) *FunctionSearchComponent {
+	fs := &FunctionSearchComponent{
+		trace:  tr,
+		canvas: canvas,
+		win:    win,
+		split: theme.SplitterState{
+			Axis:  layout.Vertical,
+			Ratio: 0.5,
+		},
+		goroutines: GoroutineList{Trace: tr},
+	}
+	fs.computeIndex()
+	fs.query.SingleLine = true
+	fs.query.Focus()
+	return fs
+}
+
+func (fs *FunctionSearchComponent) computeIndex() {
+	tr := fs.trace
+	fs.index, fs.indexOp = theme.ComputeOperation(&tr.analyses, fs.win, functionIndexKey{}, []string{"Collecting functions"}, func(o *theme.Operation) ([]functionOccurrences, error) {
+		return computeFunctionIndex(o, tr)
+	})
+}
+
+func (fs *FunctionSearchComponent) computeInstances() {
+	tr, fn := fs.trace, fs.selected
+	fs.instances, fs.instancesOp = theme.ComputeOperation(&tr.analyses, fs.win, functionInstancesKey{fn}, []string{"Finding instances"}, func(o *theme.Operation) (*functionInstances, error) {
+		return computeFunctionInstances(o, tr, fn)
+	})
+}
+
+// layoutProgress displays the progress of o, or a button for retrying it if it has been cancelled.
+func (fs *FunctionSearchComponent) layoutProgress(win *theme.Window, gtx layout.Context, o *theme.Operation) layout.Dimensions {
+	gtx.Constraints.Min = gtx.Constraints.Max
+	if o.Cancelled() {
+		return layout.Center.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			return layout.Rigids(gtx, layout.Vertical,
+				theme.Dumb(win, theme.Label(win.Theme, tr("The search was cancelled.")).Layout),
+				layout.Spacer{Height: 5}.Layout,
+				theme.Dumb(win, theme.Button(win.Theme, &fs.retry.Clickable, tr("Retry")).Layout),
+			)
+		})
+	}
+	return layout.Center.Layout(gtx, theme.Dumb(win, theme.OperationProgress(o, &fs.progress).Layout))
+}
+
+// Title implements theme.Component.
+func (*FunctionSearchComponent) Title() string {
+	return tr("Function search")
+}
+
+// Transition implements theme.Component.
+func (*FunctionSearchComponent) Transition(state theme.ComponentState) {}
+
+// WantsTransition implements theme.Component.
+func (*FunctionSearchComponent) WantsTransition(gtx layout.Context) theme.ComponentState {
+	return theme.ComponentStateNone
+}
+
+func (fs *FunctionSearchComponent) HoveredLink() ObjectLink {
+	if l := fs.cellFormatter.HoveredLink(); l != nil {
+		return l
+	}
+	if fs.selected == "" {
+		return nil
+	}
+	if l := fs.goroutines.HoveredLink(); l != nil {
+		return l
+	}
+	if fs.spans != nil {
+		if l := fs.spans.HoveredLink(); l != nil {
+			return l
+		}
+	}
+	return fs.samples.cellFormatter.HoveredLink()
+}
+
+func (fs *FunctionSearchComponent) search(index []functionOccurrences, query string) {
+	query = strings.TrimSpace(query)
+	scores := make(map[int]int)
+	fs.results = fs.results[:0]
+	for i := range index {
+		if score, ok := fuzzyMatch(query, index[i].Function); ok {
+			fs.results = append(fs.results, i)
+			scores[i] = score
+		}
+	}
+	// The index is sorted by name, which breaks ties.
+	slices.SortStableFunc(fs.results, func(a, b int) int {
+		return scores[b] - scores[a]
+	})
+	fs.selects = make([]widget.PrimaryClickable, len(fs.results))
+	fs.scrollState = theme.YScrollableListState{}
+}
+
+func (fs *FunctionSearchComponent) selectFunction(win *theme.Window, gtx layout.Context, o *functionOccurrences) {
+	fs.selected = o.Function
+	fs.goroutines.SetGoroutines(win, gtx, o.Created)
+	fs.spans = nil
+	fs.samples.table = nil
+	fs.computeInstances()
+}
+
+func (fs *FunctionSearchComponent) initTable(win *theme.Window, gtx layout.Context) {
+	if fs.table != nil {
+		return
+	}
+	fs.table = &theme.Table{}
+	fs.table.SetColumns(win, gtx, []theme.Column{
+		{Name: "Function", Alignment: text.Start},
+		{Name: "Goroutines created", Alignment: text.End},
+		{Name: "Spans", Alignment: text.End},
+		{Name: "CPU samples", Alignment: text.End},
+	})
+}
+
+// Layout implements theme.Component.
+func (fs *FunctionSearchComponent) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.FunctionSearchComponent.Layout").End()
+
+	for fs.retry.Clicked(gtx) {
+		if fs.indexOp.Cancelled() {
+			fs.computeIndex()
+		} else if fs.instancesOp != nil && fs.instancesOp.Cancelled() {
+			fs.computeInstances()
+		}
+	}
+
+	index, ok := fs.index.Result()
+	if !ok || fs.indexOp.Cancelled() {
+		return fs.layoutProgress(win, gtx, fs.indexOp)
+	}
+	if q := fs.query.Text(); q != fs.prevQuery || fs.selects == nil {
+		fs.prevQuery = q
+		fs.search(index, q)
+	}
+
+	fs.initTable(win, gtx)
+	fs.table.Update(gtx)
+	fs.cellFormatter.Update(win, gtx)
+	for i := range fs.selects {
+		if fs.selects[i].Clicked(gtx) {
+			fs.selectFunction(win, gtx, &index[fs.results[i]])
+		}
+	}
+
+	cellFn := func(win *theme.Window, gtx layout.Context, row, col int) layout.Dimensions {
+		defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+
+		o := &index[fs.results[row]]
+		switch colName := fs.table.Columns[col].Name; colName {
+		case "Function":
+			return fs.selects[row].Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				c := win.Theme.Palette.Link
+				if o.Function == fs.selected {
+					c = win.Theme.Palette.Foreground
+				}
+				return widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, font.Font{}, win.Theme.TextSize, o.Function, win.ColorMaterial(gtx, c))
+			})
+		case "Goroutines created":
+			return fs.cellFormatter.Number(win, gtx, len(o.Created))
+		case "Spans":
+			return fs.cellFormatter.Number(win, gtx, o.Spans)
+		case "CPU samples":
+			return fs.cellFormatter.Number(win, gtx, o.Samples)
+		default:
+			panic(colName)
+		}
+	}
+
+	resultsTable := func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return theme.TextBox(win.Theme, &fs.query, "Search functions").Layout(win, gtx)
+			}),
+			layout.Rigid(layout.Spacer{Height: 5}.Layout),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return theme.LineLabel(win.Theme, local.Sprintf("%d of %d functions match.", len(fs.results), len(index))).Layout(win, gtx)
+			}),
+			layout.Rigid(layout.Spacer{Height: 5}.Layout),
+			layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+				return theme.SimpleTable(win, gtx, fs.table, &fs.scrollState, len(fs.results), cellFn)
+			}),
+		)
+	}
+	if fs.selected == "" {
+		return resultsTable(win, gtx)
+	}
+	return theme.Splitter(win.Theme, &fs.split).Layout(win, gtx, resultsTable, fs.layoutInstances)
+}
+
+// layoutInstances displays where the selected function appears.
+func (fs *FunctionSearchComponent) layoutInstances(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	instances, ok := fs.instances.Result()
+	if !ok || fs.instancesOp.Cancelled() {
+		return fs.layoutProgress(win, gtx, fs.instancesOp)
+	}
+
+	if fs.spans == nil {
+		bases := make([]Items[ptrace.Span], len(instances.Spans))
+		for i, gs := range instances.Spans {
+			base := SimpleItems[ptrace.Span, any]{items: gs.Spans}
+			if tl := fs.canvas.itemToTimeline[gs.Goroutine]; tl != nil {
+				base.container = ItemContainer{Timeline: tl, Track: tl.tracks[0]}
+			}
+			bases[i] = base
+		}
+		fs.spans = &SpanList{
+			Spans: NewSortedItems(MergeItems(bases, func(a, b *ptrace.Span) bool {
+				return a.Start < b.Start
+			})),
+		}
+	}
+
+	tabs := []string{
+		local.Sprintf("Goroutines created (%d)", fs.goroutines.Goroutines.Len()),
+		local.Sprintf("Spans (%d)", fs.spans.Spans.Len()),
+		local.Sprintf("CPU samples (%d)", len(instances.Samples)),
+	}
+	return theme.Tabbed(&fs.tabbedState, tabs).Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+		gtx.Constraints.Min = gtx.Constraints.Max
+		switch fs.tabbedState.Current {
+		case 0:
+			return fs.goroutines.Layout(win, gtx)
+		case 1:
+			return fs.spans.Layout(win, gtx, fs.trace)
+		case 2:
+			return fs.layoutSamples(win, gtx, instances.Samples)
+		default:
+			panic("unreachable")
+		}
+	})
+}
+
+func (fs *FunctionSearchComponent) layoutSamples(win *theme.Window, gtx layout.Context, samples []ptrace.EventID) layout.Dimensions {
+	ss := &fs.samples
+	if ss.table == nil {
+		ss.table = &theme.Table{}
+		ss.table.SetColumns(win, gtx, []theme.Column{
+			{Name: "Time", Alignment: text.End},
+			{Name: "Goroutine", Alignment: text.End},
+		})
+	}
+	ss.table.Update(gtx)
+	ss.cellFormatter.Update(win, gtx)
+
+	cellFn := func(win *theme.Window, gtx layout.Context, row, col int) layout.Dimensions {
+		defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+
+		ev := fs.trace.Event(samples[row])
+		switch col {
+		case 0:
+			return ss.cellFormatter.Timestamp(win, gtx, fs.trace, ev.Time(), "")
+		case 1:
+			if gid := ev.Goroutine(); gid != exptrace.NoGoroutine {
+				return ss.cellFormatter.Goroutine(win, gtx, fs.trace.G(gid), "")
+			}
+			return ss.cellFormatter.Text(win, gtx, "none")
+		default:
+			panic(fmt.Sprintf("unreachable: %d", col))
+		}
+	}
+	return theme.SimpleTable(win, gtx, ss.table, &ss.scrollState, len(samples), cellFn)
+}
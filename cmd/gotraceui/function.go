@@ -4,9 +4,7 @@ import (
 	"context"
 	"fmt"
 	"image"
-	"path/filepath"
 	rtrace "runtime/trace"
-	"strings"
 	"time"
 
 	"honnef.co/go/gotraceui/layout"
@@ -62,34 +60,10 @@ func (fi *FunctionInfo) buildDescription(win *theme.Window, gtx layout.Context)
 		Value: *tb.Span(fi.fn.Func),
 	})
 
-	// TODO(dh): make file link clickable
-	displayPath := fi.fn.File
-	if goroot := fi.trace.GOROOT; goroot != "" && strings.HasPrefix(fi.fn.File, goroot) {
-		displayPath = filepath.Join("$GOROOT", strings.TrimPrefix(fi.fn.File, goroot))
-	} else if gopath := fi.trace.GOPATH; gopath != "" && strings.HasPrefix(fi.fn.File, gopath) {
-		displayPath = filepath.Join("$GOPATH", strings.TrimPrefix(fi.fn.File, gopath))
-	} else if goroot == "" && gopath == "" {
-		// We couldn't detect goroot, which makes it very likely that the executable had paths trimmed. Detect if
-		// the trimmed path is in GOROOT or GOPATH based on if the first path element has a dot in it or not. Module
-		// paths without dots are reserved for the standard library. This has a small but negligible chance of false
-		// positives.
-
-		left, _, ok := strings.Cut(fi.fn.File, "/")
-		if ok {
-			if strings.Contains(left, ".") {
-				if strings.Contains(fi.fn.File, "@v") {
-					displayPath = filepath.Join("$GOPATH", "pkg", "mod", fi.fn.File)
-				} else {
-					displayPath = filepath.Join("$GOPATH", "src", fi.fn.File)
-				}
-			} else {
-				displayPath = filepath.Join("$GOROOT", "src", fi.fn.File)
-			}
-		}
-	}
+	displayPath := fi.trace.displayPath(fi.fn.File)
 	attrs = append(attrs, DescriptionAttribute{
 		Key:   "Location",
-		Value: *tb.Span(fmt.Sprintf("%s:%d", displayPath, fi.fn.Line)),
+		Value: *tb.Link(fmt.Sprintf("%s:%d", displayPath, fi.fn.Line), &SourceLocationObjectLink{File: fi.fn.File, Line: fi.fn.Line}),
 	})
 
 	attrs = append(attrs, DescriptionAttribute{
@@ -193,7 +167,7 @@ func (fi *FunctionInfo) Layout(win *theme.Window, gtx layout.Context) layout.Dim
 				case "Goroutines":
 					return layout.Rigids(gtx, layout.Vertical,
 						func(gtx layout.Context) layout.Dimensions {
-							return theme.CheckBox(win.Theme, &fi.filterGoroutines, "Filter list to range of durations selected in histogram").Layout(win, gtx)
+							return theme.CheckBox(win.Theme, &fi.filterGoroutines, tr("Filter list to range of durations selected in histogram")).Layout(win, gtx)
 						},
 
 						layout.Spacer{Height: 5}.Layout,
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	rtrace "runtime/trace"
+	"slices"
+	"time"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/trace/ptrace"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/op/clip"
+	"gioui.org/text"
+)
+
+// The number of buckets in the assist pressure chart.
+const gcAssistBuckets = 200
+
+// An assistBurden is the time that a goroutine, or all goroutines of a function, spent assisting the GC's mark phase.
+type assistBurden struct {
+	// Exactly one of Goroutine and Function is set.
+	Goroutine *ptrace.Goroutine
+	Function  *ptrace.Function
+	Count     int
+	Total     time.Duration
+}
+
+type gcAssistReport struct {
+	ByGoroutine []assistBurden
+	ByFunction  []assistBurden
+	Total       time.Duration
+	// Pressure is the average number of goroutines assisting the GC in each of gcAssistBuckets buckets.
+	Pressure []float64
+}
+
+type gcAssistReportKey struct{}
+
+func computeGCAssistReport(tr *Trace) *gcAssistReport {
+	defer rtrace.StartRegion(context.Background(), "main.computeGCAssistReport").End()
+
+	report := &gcAssistReport{
+		Pressure: make([]float64, gcAssistBuckets),
+	}
+	byFunction := map[*ptrace.Function]*assistBurden{}
+	start, end := tr.Start(), tr.End()
+	width := float64(end-start) / gcAssistBuckets
+	for _, g := range tr.Goroutines {
+		assists := g.Ranges["GC mark assist"]
+		if len(assists) == 0 {
+			continue
+		}
+		b := assistBurden{Goroutine: g, Count: len(assists)}
+		for _, s := range assists {
+			if s.EndEvent == -1 {
+				// The assist was still going on at the end of the trace.
+				s.End = end
+			}
+			b.Total += s.Duration()
+
+			a := float64(s.Start - start)
+			z := float64(s.End - start)
+			for i := int(a / width); i < gcAssistBuckets && float64(i)*width < z; i++ {
+				lo := max(a, float64(i)*width)
+				hi := min(z, float64(i+1)*width)
+				if hi > lo {
+					report.Pressure[i] += (hi - lo) / width
+				}
+			}
+		}
+		report.Total += b.Total
+		report.ByGoroutine = append(report.ByGoroutine, b)
+
+		fb, ok := byFunction[g.Function]
+		if !ok {
+			fb = &assistBurden{Function: g.Function}
+			byFunction[g.Function] = fb
+		}
+		fb.Count += b.Count
+		fb.Total += b.Total
+	}
+	for _, b := range byFunction {
+		report.ByFunction = append(report.ByFunction, *b)
+	}
+
+	rank := func(a, b assistBurden) int {
+		return cmp(a.Total, b.Total, true)
+	}
+	slices.SortFunc(report.ByGoroutine, rank)
+	slices.SortFunc(report.ByFunction, rank)
+	return report
+}
+
+// GCAssistComponent ranks goroutines and functions by the time they spent assisting the GC, which is the price they
+// pay for allocating while the GC is running.
+type GCAssistComponent struct {
+	report *theme.Future[*gcAssistReport]
+
+	byFunction widget.Bool
+
+	table         *theme.Table
+	scrollState   theme.YScrollableListState
+	cellFormatter CellFormatter
+}
+
+func NewGCAssistComponent(win *theme.Window, tr *Trace) *GCAssistComponent {
+	return &GCAssistComponent{
+		report: theme.Compute(&tr.analyses, win, gcAssistReportKey{}, func() *gcAssistReport {
+			return computeGCAssistReport(tr)
+		}),
+	}
+}
+
+// Title implements theme.Component.
+func (*GCAssistComponent) Title() string {
+	return tr("GC assist burden")
+}
+
+// Transition implements theme.Component.
+func (*GCAssistComponent) Transition(state theme.ComponentState) {}
+
+// WantsTransition implements theme.Component.
+func (*GCAssistComponent) WantsTransition(gtx layout.Context) theme.ComponentState {
+	return theme.ComponentStateNone
+}
+
+func (gc *GCAssistComponent) HoveredLink() ObjectLink {
+	return gc.cellFormatter.HoveredLink()
+}
+
+func (gc *GCAssistComponent) initTable(win *theme.Window, gtx layout.Context) {
+	if gc.table != nil {
+		return
+	}
+	gc.table = &theme.Table{}
+	gc.table.SetColumns(win, gtx, []theme.Column{
+		{Name: "Goroutine", Alignment: text.End},
+		{Name: "Function", Alignment: text.Start},
+		{Name: "Assists", Alignment: text.End},
+		{Name: "Assist time", Alignment: text.End},
+		{Name: "Share", Alignment: text.End},
+	})
+}
+
+// Layout implements theme.Component.
+func (gc *GCAssistComponent) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.GCAssistComponent.Layout").End()
+
+	report, ok := gc.report.Result()
+	if !ok {
+		return theme.Loading(win.Theme, tr("Computing GC assist burden…")).Layout(win, gtx)
+	}
+	if report.Total == 0 {
+		return layout.Center.Layout(gtx, theme.Dumb(win, theme.Label(win.Theme, tr("No goroutines assisted the GC.")).Layout))
+	}
+
+	gc.byFunction.Update(gtx)
+	gc.initTable(win, gtx)
+	gc.table.Update(gtx)
+	gc.cellFormatter.Update(win, gtx)
+
+	burdens := report.ByGoroutine
+	if gc.byFunction.Value {
+		burdens = report.ByFunction
+	}
+
+	cellFn := func(win *theme.Window, gtx layout.Context, row, col int) layout.Dimensions {
+		defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+
+		b := &burdens[row]
+		switch colName := gc.table.Columns[col].Name; colName {
+		case "Goroutine":
+			if b.Goroutine == nil {
+				return layout.Dimensions{Size: gtx.Constraints.Min}
+			}
+			return gc.cellFormatter.Goroutine(win, gtx, b.Goroutine, "")
+		case "Function":
+			fn := b.Function
+			if b.Goroutine != nil {
+				fn = b.Goroutine.Function
+			}
+			return gc.cellFormatter.Function(win, gtx, fn)
+		case "Assists":
+			return layout.RightAligned(gtx, func(gtx layout.Context) layout.Dimensions {
+				return gc.cellFormatter.Number(win, gtx, b.Count)
+			})
+		case "Assist time":
+			return gc.cellFormatter.Duration(win, gtx, b.Total, false)
+		case "Share":
+			return layout.RightAligned(gtx, func(gtx layout.Context) layout.Dimensions {
+				return gc.cellFormatter.Text(win, gtx, fmt.Sprintf("%.2f%%", float64(b.Total)/float64(report.Total)*100))
+			})
+		default:
+			panic(colName)
+		}
+	}
+
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(theme.Dumb(win, theme.LineLabel(win.Theme, tr("Goroutines assisting the GC over time")).Layout)),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			sl := theme.Sparkline(win.Theme, report.Pressure)
+			sl.Kind = theme.SparklineBar
+			sl.Height = 60
+			return sl.Layout(win, gtx)
+		}),
+		layout.Rigid(layout.Spacer{Height: 5}.Layout),
+		layout.Rigid(theme.Dumb(win, theme.CheckBox(win.Theme, &gc.byFunction, tr("Group by function")).Layout)),
+		layout.Rigid(layout.Spacer{Height: 5}.Layout),
+		layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+			return theme.SimpleTable(win, gtx, gc.table, &gc.scrollState, len(burdens), cellFn)
+		}),
+	)
+}
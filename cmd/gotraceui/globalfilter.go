@@ -0,0 +1,202 @@
+package main
+
+import (
+	"image"
+	"strings"
+
+	"honnef.co/go/gotraceui/container"
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/trace/ptrace"
+	"honnef.co/go/gotraceui/widget"
+
+	exptrace "golang.org/x/exp/trace"
+)
+
+// A GlobalFilter narrows down the data displayed by all views that opt into it, so that narrowing the investigation
+// in one place narrows every view consistently. Views that opt in should recompute their data whenever the filter's
+// Key changes, and pass a Clone of the filter to background computations.
+//
+// The zero value doesn't filter anything.
+type GlobalFilter struct {
+	// The time range to restrict to, if Start < End.
+	Start, End exptrace.Time
+	// Bitmap of ptrace.SchedulingState. If it isn't zero, spans in other states are excluded.
+	States uint64
+	// If not empty, goroutines whose function names don't match Label are excluded. Labels containing wildcards are
+	// treated as globs, other labels match case-insensitive substrings.
+	Label string
+
+	// If not empty, goroutines not in the set are excluded.
+	goroutines container.Set[*ptrace.Goroutine]
+	// goroutinesGen is incremented whenever goroutines changes.
+	goroutinesGen uint64
+}
+
+// globalFilterKey identifies the state of a GlobalFilter. Two filters with the same key filter the same data.
+type globalFilterKey struct {
+	start, end    exptrace.Time
+	states        uint64
+	label         string
+	goroutinesGen uint64
+}
+
+func (f *GlobalFilter) Key() globalFilterKey {
+	return globalFilterKey{
+		start:         f.Start,
+		end:           f.End,
+		states:        f.States,
+		label:         f.Label,
+		goroutinesGen: f.goroutinesGen,
+	}
+}
+
+// Clone returns a copy of the filter that can be used concurrently with modifications of the original.
+func (f *GlobalFilter) Clone() *GlobalFilter {
+	out := *f
+	if f.goroutines != nil {
+		out.goroutines = make(container.Set[*ptrace.Goroutine], len(f.goroutines))
+		for g := range f.goroutines {
+			out.goroutines.Add(g)
+		}
+	}
+	return &out
+}
+
+func (f *GlobalFilter) HasTimeRange() bool {
+	return f.Start < f.End
+}
+
+func (f *GlobalFilter) SetTimeRange(start, end exptrace.Time) {
+	f.Start, f.End = start, end
+}
+
+func (f *GlobalFilter) AddGoroutine(g *ptrace.Goroutine) {
+	if f.goroutines == nil {
+		f.goroutines = container.Set[*ptrace.Goroutine]{}
+	}
+	f.goroutines.Add(g)
+	f.goroutinesGen++
+}
+
+func (f *GlobalFilter) RemoveGoroutine(g *ptrace.Goroutine) {
+	f.goroutines.Delete(g)
+	f.goroutinesGen++
+}
+
+// filtersGoroutines reports whether the filter excludes any goroutines.
+func (f *GlobalFilter) filtersGoroutines() bool {
+	return len(f.goroutines) != 0 || f.Label != ""
+}
+
+// MatchGoroutine reports whether the filter includes the goroutine. g may be nil, in which case it only matches if
+// the filter doesn't exclude any goroutines.
+func (f *GlobalFilter) MatchGoroutine(g *ptrace.Goroutine) bool {
+	if g == nil {
+		return !f.filtersGoroutines()
+	}
+	if len(f.goroutines) != 0 {
+		if _, ok := f.goroutines[g]; !ok {
+			return false
+		}
+	}
+	if f.Label != "" {
+		if g.Function == nil || !matchesFilter(g.Function.Func, f.Label) {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchTime reports whether the filter includes the time range [start, end].
+func (f *GlobalFilter) MatchTime(start, end exptrace.Time) bool {
+	return !f.HasTimeRange() || (end >= f.Start && start < f.End)
+}
+
+// MatchState reports whether the filter includes spans of the state.
+func (f *GlobalFilter) MatchState(state ptrace.SchedulingState) bool {
+	return f.States == 0 || f.States&(1<<state) != 0
+}
+
+// MatchSpan reports whether the filter includes the span, based on its state and time.
+func (f *GlobalFilter) MatchSpan(span *ptrace.Span) bool {
+	return f.MatchState(span.State) && f.MatchTime(span.Start, span.End)
+}
+
+// ActiveFilters returns a removable entry for each part of the filter that is currently in effect.
+func (f *GlobalFilter) ActiveFilters(tr *Trace) []ActiveFilter {
+	var out []ActiveFilter
+	if f.HasTimeRange() {
+		out = append(out, ActiveFilter{
+			Label: local.Sprintf("Time: %s – %s",
+				formatTimestamp(nil, tr.AdjustedTime(f.Start)), formatTimestamp(nil, tr.AdjustedTime(f.End))),
+			Remove: func() { f.SetTimeRange(0, 0) },
+		})
+	}
+	for g := range f.goroutines {
+		out = append(out, ActiveFilter{
+			Label:  local.Sprintf("Goroutine: %d", g.ID),
+			Remove: func() { f.RemoveGoroutine(g) },
+		})
+	}
+	if f.Label != "" {
+		out = append(out, ActiveFilter{
+			Label:  local.Sprintf("Function: %s", f.Label),
+			Remove: func() { f.Label = "" },
+		})
+	}
+	for state := range ptrace.StateLast {
+		if f.States&(1<<state) == 0 {
+			continue
+		}
+		out = append(out, ActiveFilter{
+			Label:  local.Sprintf("State: %s", stateNamesCapitalized[state]),
+			Remove: func() { f.States &^= 1 << state },
+		})
+	}
+	return out
+}
+
+type GlobalFilterDialogStyle struct {
+	Filter *GlobalFilter
+
+	label  widget.Editor
+	states HighlightDialogStyle
+}
+
+func GlobalFilterDialog(win *theme.Window, f *GlobalFilter) *GlobalFilterDialogStyle {
+	gd := &GlobalFilterDialogStyle{
+		Filter: f,
+		states: statesDialog(&f.States),
+	}
+	gd.label.SingleLine = true
+	gd.label.SetText(f.Label)
+	return gd
+}
+
+func (gd *GlobalFilterDialogStyle) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	if s := strings.TrimSpace(gd.label.Text()); s != gd.Filter.Label {
+		gd.Filter.Label = s
+	}
+
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(theme.Dumb(win, theme.Label(win.Theme, tr("Only include goroutines whose function matches:")).Layout)),
+		layout.Rigid(layout.Spacer{Height: 5}.Layout),
+		layout.Rigid(theme.Dumb(win, theme.TextBox(win.Theme, &gd.label, "Function name or glob").Layout)),
+		layout.Rigid(layout.Spacer{Height: 10}.Layout),
+		layout.Rigid(theme.Dumb(win, theme.Label(win.Theme, tr("Only include spans in the checked states, or all states if none are checked:")).Layout)),
+		layout.Rigid(layout.Spacer{Height: 5}.Layout),
+		layout.Flexed(1, theme.Dumb(win, gd.states.Layout)),
+	)
+}
+
+func displayGlobalFilterDialog(win *theme.Window, f *GlobalFilter) {
+	gd := GlobalFilterDialog(win, f)
+	win.SetModal(func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+		return theme.Dialog(win.Theme, tr("Filter all views")).Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+			gtx.Constraints.Min = gtx.Constraints.Constrain(image.Pt(1000, 500))
+			gtx.Constraints.Max = gtx.Constraints.Min
+			return gd.Layout(win, gtx)
+		})
+	})
+}
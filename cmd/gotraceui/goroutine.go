@@ -6,6 +6,7 @@ import (
 	"image"
 	"math"
 	rtrace "runtime/trace"
+	"slices"
 	"strings"
 	"time"
 
@@ -31,14 +32,25 @@ func goroutineTrack0SpanLabel(spans Items[ptrace.Span], tr *Trace, out []string)
 		ev := tr.Event(span.StartEvent)
 		stk := ev.Stack()
 		if stk != exptrace.NoStack {
-			fn := tr.PCs[tr.Stacks[stk][0]].Func
+			fn := tr.FuncName(tr.Stacks[tr.StackID(stk)][0])
 			return append(out,
-				fmt.Sprintf("syscall (%s)", fn),
-				fmt.Sprintf("syscall (.%s)", shortenFunctionName(fn)),
+				local.Sprintf("syscall (%s)", fn),
+				local.Sprintf("syscall (.%s)", shortenFunctionName(fn)),
 				"syscall",
 			)
 		}
 	}
+	if state == ptrace.StateCgo {
+		ev := tr.Event(span.StartEvent)
+		if stk := ev.Stack(); stk != exptrace.NoStack {
+			fn := tr.FuncName(tr.Stacks[tr.StackID(stk)][span.At])
+			return append(out,
+				local.Sprintf("cgo (%s)", fn),
+				local.Sprintf("cgo (.%s)", shortenFunctionName(fn)),
+				"cgo",
+			)
+		}
+	}
 	return append(out, spanStateLabels[state]...)
 }
 
@@ -98,7 +110,7 @@ func stackSpanLabel(spans Items[ptrace.Span], tr *Trace, out []string) []string
 		return out
 	}
 	pc := spans.MetadataAtPtr(0).(*stackSpanMeta).pc
-	f := tr.PCs[pc]
+	f := tr.StackFrame(pc)
 
 	short := shortenFunctionName(f.Func)
 
@@ -117,7 +129,7 @@ func stackSpanTooltip(level int) func(win *theme.Window, gtx layout.Context, tr
 			if spans.AtPtr(0).State != statePlaceholder {
 				meta := spans.MetadataAtPtr(0).(*stackSpanMeta)
 				pc := meta.pc
-				f := tr.PCs[pc]
+				f := tr.StackFrame(pc)
 				label = local.Sprintf("Function: %s\n", f.Func)
 				// TODO(dh): for truncated stacks we should display a relative depth instead
 				label += local.Sprintf("Call depth: %d\n", level)
@@ -137,6 +149,21 @@ func stackSpanTooltip(level int) func(win *theme.Window, gtx layout.Context, tr
 	}
 }
 
+func stackSpanContextMenu(spans Items[ptrace.Span], cv *Canvas) []*theme.MenuItem {
+	items := []*theme.MenuItem{
+		newZoomMenuItem(cv, spans),
+		newOpenSpansMenuItem(spans),
+	}
+	if spans.Len() == 1 && spans.AtPtr(0).State != statePlaceholder {
+		f := cv.trace.StackFrame(spans.MetadataAtPtr(0).(*stackSpanMeta).pc)
+		items = append(items,
+			newOpenSourceMenuItem(f.File, f.Line),
+			newOpenInEditorMenuItem(f.File, f.Line),
+		)
+	}
+	return items
+}
+
 func NewGoroutineTimeline(tr *Trace, cv *Canvas, g *ptrace.Goroutine) *Timeline {
 	shortName := local.Sprintf("goroutine %d", g.ID)
 	l := shortName
@@ -222,57 +249,47 @@ func (tt GoroutineTooltip) Layout(win *theme.Window, gtx layout.Context) layout.
 	gcAssistPct := float32(gcAssist) / float32(d) * 100
 	runningPct := float32(running) / float32(d) * 100
 
-	var fmts []string
-	var args []any
-
-	if tt.g.Function != nil {
-		fmts = append(fmts, "Goroutine %d: %s\n")
-		args = append(args, tt.g.ID, tt.g.Function.Func)
-	} else {
-		fmts = append(fmts, "Goroutine %d\n")
-		args = append(args, tt.g.ID)
-	}
-
 	observedStart := !tt.g.Spans[0].StartedBeforeTrace(tt.trace.Trace)
 	observedEnd := tt.g.End.Set()
-	if observedStart {
-		fmts = append(fmts, "Created at: %s")
-		args = append(args, formatTimestamp(nil, tt.trace.AdjustedTime(start)))
-	} else {
-		fmts = append(fmts, "Created at: before trace start")
-	}
-
-	if observedEnd {
-		fmts = append(fmts, "Returned at: %s")
-		args = append(args, formatTimestamp(nil, tt.trace.AdjustedTime(end)))
-	} else {
-		fmts = append(fmts, "Returned at: after trace end")
-	}
-
-	if observedStart && observedEnd {
-		fmts = append(fmts, "Lifetime: %s")
-		args = append(args, roundDuration(d))
-	} else {
-		fmts = append(fmts, "Observed duration: %s")
-		args = append(args, roundDuration(d))
-	}
-
-	fmts = append(fmts, "Spans: %d")
-	args = append(args, len(tt.g.Spans))
-
-	fmts = append(fmts, "Time in blocked states: %s (%.2f%%)")
-	args = append(args, roundDuration(blocked), blockedPct)
-
-	fmts = append(fmts, "Time in inactive states: %s (%.2f%%)")
-	args = append(args, roundDuration(inactive), inactivePct)
-
-	fmts = append(fmts, "Time in GC assist: %s (%.2f%%)")
-	args = append(args, roundDuration(gcAssist), gcAssistPct)
-
-	fmts = append(fmts, "Time in running states: %s (%.2f%%)")
-	args = append(args, roundDuration(running), runningPct)
-
-	l := local.Sprintf(strings.Join(fmts, "\n"), args...)
+	l := currentTooltipTemplates().goroutine.render(func(field string) string {
+		switch field {
+		case "id":
+			return local.Sprintf("%d", tt.g.ID)
+		case "function":
+			if tt.g.Function != nil {
+				return tt.g.Function.Func
+			}
+		case "created":
+			if observedStart {
+				return formatTimestamp(nil, tt.trace.AdjustedTime(start))
+			}
+			return "before trace start"
+		case "returned":
+			if observedEnd {
+				return formatTimestamp(nil, tt.trace.AdjustedTime(end))
+			}
+			return "after trace end"
+		case "lifetime":
+			if observedStart && observedEnd {
+				return roundDuration(d).String()
+			}
+		case "observed":
+			if !observedStart || !observedEnd {
+				return roundDuration(d).String()
+			}
+		case "spans":
+			return local.Sprintf("%d", len(tt.g.Spans))
+		case "blocked":
+			return local.Sprintf("%s (%.2f%%)", roundDuration(blocked), blockedPct)
+		case "inactive":
+			return local.Sprintf("%s (%.2f%%)", roundDuration(inactive), inactivePct)
+		case "gc-assist":
+			return local.Sprintf("%s (%.2f%%)", roundDuration(gcAssist), gcAssistPct)
+		case "running":
+			return local.Sprintf("%s (%.2f%%)", roundDuration(running), runningPct)
+		}
+		return ""
+	})
 
 	return theme.Tooltip(win.Theme, l).Layout(win, gtx)
 }
@@ -298,69 +315,68 @@ func goroutineSpanTooltip(win *theme.Window, gtx layout.Context, tr *Trace, span
 	var label string
 	if debug {
 		label += local.Sprintf("Event ID: %d\n", spans.AtPtr(0).StartEvent)
-		label += fmt.Sprintf("Event kind: %s\n", tr.Event(spans.AtPtr(0).StartEvent).Kind())
+		label += local.Sprintf("Event kind: %s\n", tr.Event(spans.AtPtr(0).StartEvent).Kind())
 	}
-	var at string
-	if spans.Len() == 1 {
-		s := spans.AtPtr(0)
-		ev := tr.Event(s.StartEvent)
-		stk := ev.Stack()
-		if at == "" && stk != exptrace.NoStack {
-			at = tr.PCs[tr.Stacks[stk][s.At]].Func
-		}
-		label += tooltipStateLabels[s.State]
-		tags := spanTagStrings(s.Tags)
-		if len(tags) != 0 {
-			label += " (" + strings.Join(tags, ", ") + ")"
-		}
-		if s.State == ptrace.StateGCSweep {
+	if spans.Len() != 1 {
+		label += local.Sprintf("%d spans\n", spans.Len())
+		label += spansDurationForTooltip(spans)
+		return theme.Tooltip(win.Theme, label).Layout(win, gtx)
+	}
+
+	s := spans.AtPtr(0)
+	ev := tr.Event(s.StartEvent)
+	label += currentTooltipTemplates().span.render(func(field string) string {
+		switch field {
+		case "state":
+			return tooltipStateLabels[s.State]
+		case "tags":
+			return strings.Join(spanTagStrings(s.Tags), ", ")
+		case "start":
+			return formatTimestamp(nil, tr.AdjustedTime(s.Start))
+		case "end":
+			return formatTimestamp(nil, tr.AdjustedTime(s.End))
+		case "duration":
+			return roundDuration(s.Duration()).String()
+		case "function":
+			// TODO(dh): document what In represents. If possible, it is the last frame in user space that triggered
+			// this state. We try to pattern match away the runtime when it makes sense.
+			if stk := ev.Stack(); stk != exptrace.NoStack {
+				return tr.FuncName(tr.Stacks[tr.StackID(stk)][s.At])
+			}
+		case "processor":
+			switch s.State {
+			case ptrace.StateActive, ptrace.StateGCIdle, ptrace.StateGCDedicated, ptrace.StateGCMarkAssist, ptrace.StateGCSweep:
+				return local.Sprintf("%d", ev.Proc())
+			}
+		case "unblocked-by":
+			if g, ok := unblockedByGoroutine(tr, s); ok {
+				return local.Sprintf("%d (%s)", g, tr.G(g).Function)
+			}
+		case "reason":
+			if ev.Kind() == exptrace.EventStateTransition {
+				trans := ev.StateTransition()
+				if ptrace.IsGoroutineCreation(&trans) {
+					return "newly created"
+				}
+			}
+		case "swept", "reclaimed":
+			if s.State != ptrace.StateGCSweep {
+				break
+			}
 			if endEv := tr.Event(s.EndEvent); endEv.Kind() == exptrace.EventRangeEnd {
-				attrs := endEv.RangeAttributes()
-				var swept, reclaimed uint64
-				for _, attr := range attrs {
-					switch attr.Name {
-					case "bytes swept":
-						swept = attr.Value.Uint64()
-					case "bytes reclaimed":
-						reclaimed = attr.Value.Uint64()
+				name := "bytes " + field
+				for _, attr := range endEv.RangeAttributes() {
+					if attr.Name == name {
+						return local.Sprintf("%d", attr.Value.Uint64())
 					}
 				}
-				label += local.Sprintf("\nSwept %d bytes, reclaimed %d bytes", swept, reclaimed)
-			}
-		}
-
-		if g, ok := unblockedByGoroutine(tr, s); ok {
-			label += local.Sprintf("\nUnblocked by goroutine %d (%s)", g, tr.G(g).Function)
-		}
-	} else {
-		label = local.Sprintf("%d spans", spans.Len())
-	}
-	label += "\n"
-
-	if spans.Len() == 1 {
-		ev := tr.Event(spans.AtPtr(0).StartEvent)
-		if ev.Kind() == exptrace.EventStateTransition {
-			trans := ev.StateTransition()
-			if ptrace.IsGoroutineCreation(&trans) {
-				label += "Reason: newly created\n"
+				return "0"
 			}
+		case "event":
+			return local.Sprintf("%d", s.StartEvent)
 		}
-	}
-
-	if at != "" {
-		// TODO(dh): document what In represents. If possible, it is the last frame in user space that triggered this
-		// state. We try to pattern match away the runtime when it makes sense.
-		label += fmt.Sprintf("In: %s\n", at)
-	}
-	if spans.Len() == 1 {
-		switch spans.AtPtr(0).State {
-		case ptrace.StateActive, ptrace.StateGCIdle, ptrace.StateGCDedicated, ptrace.StateGCMarkAssist, ptrace.StateGCSweep:
-			pid := tr.Event(spans.AtPtr(0).StartEvent).Proc()
-			label += local.Sprintf("On: processor %d\n", pid)
-		}
-	}
-
-	label += spansDurationForTooltip(spans)
+		return ""
+	})
 
 	return theme.Tooltip(win.Theme, label).Layout(win, gtx)
 }
@@ -407,10 +423,9 @@ func NewGoroutineInfo(tr *Trace, mwin *theme.Window, canvas *Canvas, g *ptrace.G
 
 	spans := g.Spans
 
-	var stacktrace string
+	stk := exptrace.NoStack
 	if spans[0].State == ptrace.StateCreated {
-		stk := tr.Event(spans[0].StartEvent).Stack()
-		stacktrace = formatStack(tr, stk, 0)
+		stk = tr.Event(spans[0].StartEvent).Stack()
 	}
 
 	buildDescription := func(win *theme.Window, gtx layout.Context) Description {
@@ -506,14 +521,14 @@ func NewGoroutineInfo(tr *Trace, mwin *theme.Window, canvas *Canvas, g *ptrace.G
 	}
 
 	cfg := SpansInfoConfig{
-		Title:      title,
-		Stacktrace: stacktrace,
+		Title: title,
+		Stack: stk,
 		Navigations: SpansInfoConfigNavigations{
 			Scroll: struct {
 				ButtonLabel string
 				Fn          func() theme.Action
 			}{
-				ButtonLabel: "Scroll to goroutine",
+				ButtonLabel: local.Sprintf("Scroll to goroutine"),
 				Fn: func() theme.Action {
 					return &ScrollToObjectAction{Object: g}
 				},
@@ -523,19 +538,23 @@ func NewGoroutineInfo(tr *Trace, mwin *theme.Window, canvas *Canvas, g *ptrace.G
 				ButtonLabel string
 				Fn          func() theme.Action
 			}{
-				ButtonLabel: "Zoom to goroutine",
+				ButtonLabel: local.Sprintf("Zoom to goroutine"),
 				Fn: func() theme.Action {
 					return &ZoomToObjectAction{Object: g}
 				},
 			},
 		},
-		Statistics: func(win *theme.Window) *theme.Future[*SpansStats] {
-			return theme.NewFuture(win, func(cancelled <-chan struct{}) *SpansStats {
-				return NewGoroutineStats(g)
-			})
+		Statistics: func(win *theme.Window) (*theme.Future[ptrace.Statistics], *theme.Operation) {
+			return ComputeGoroutineStats(win, tr, g)
 		},
 		DescriptionBuilder: buildDescription,
 	}
+	if lat := NewGoroutineLatencies(mwin, canvas, g); len(lat.groups) != 0 {
+		cfg.Tabs = append(cfg.Tabs, SpansInfoTab{
+			Name:   "Latencies",
+			Layout: lat.Layout,
+		})
+	}
 
 	tl := canvas.itemToTimeline[g]
 	ss := SimpleItems[ptrace.Span, any]{
@@ -558,11 +577,88 @@ type GoroutineList struct {
 		StartTime bool
 		EndTime   bool
 		Duration  bool
+		Activity  bool
 	}
 
 	table         *theme.Table
 	scrollState   theme.YScrollableListState
 	cellFormatter CellFormatter
+	// Cached activity of goroutines, computed as they get displayed.
+	activity map[*ptrace.Goroutine][]float64
+	// Sorting by activity that is happening in the background.
+	sorting *theme.Future[goroutineActivitySort]
+}
+
+// Sorting by activity has to compute the activity of every goroutine, which is too slow to do on the UI thread for
+// lists with more goroutines than this.
+const goroutineActivitySortThreshold = 5000
+
+// goroutineActivitySort is the result of sorting goroutines by activity in the background.
+type goroutineActivitySort struct {
+	order []int
+	// The activity of each goroutine, to be added to GoroutineList.activity.
+	activity [][]float64
+}
+
+// The number of buckets in a goroutine's activity sparkline.
+const goroutineActivityBuckets = 50
+
+// goroutineActivity returns the fraction of time that g spent running in each of n equally sized buckets spanning
+// [start, end).
+func goroutineActivity(g *ptrace.Goroutine, start, end exptrace.Time, n int) []float64 {
+	out := make([]float64, n)
+	if end <= start {
+		return out
+	}
+	width := float64(end-start) / float64(n)
+	for _, s := range g.Spans {
+		if s.State != ptrace.StateActive {
+			continue
+		}
+		a := float64(max(s.Start, start) - start)
+		b := float64(min(s.End, end) - start)
+		for i := int(a / width); i < n && float64(i)*width < b; i++ {
+			lo := max(a, float64(i)*width)
+			hi := min(b, float64(i+1)*width)
+			if hi > lo {
+				out[i] += (hi - lo) / width
+			}
+		}
+	}
+	return out
+}
+
+// burstiness returns the coefficient of variation of a goroutine's activity, that is, the standard deviation of the
+// buckets divided by their mean. Goroutines that ran steadily have values close to zero, goroutines that ran in
+// short bursts have large values. Goroutines that never ran have a burstiness of zero.
+func burstiness(activity []float64) float64 {
+	var sum float64
+	for _, v := range activity {
+		sum += v
+	}
+	if sum == 0 {
+		return 0
+	}
+	mean := sum / float64(len(activity))
+	var variance float64
+	for _, v := range activity {
+		variance += (v - mean) * (v - mean)
+	}
+	variance /= float64(len(activity))
+	return math.Sqrt(variance) / mean
+}
+
+// goroutineActivity returns the activity of g, computing and caching it if necessary.
+func (gl *GoroutineList) goroutineActivity(g *ptrace.Goroutine) []float64 {
+	act, ok := gl.activity[g]
+	if !ok {
+		if gl.activity == nil {
+			gl.activity = make(map[*ptrace.Goroutine][]float64)
+		}
+		act = goroutineActivity(g, gl.Trace.Start(), gl.Trace.End(), goroutineActivityBuckets)
+		gl.activity[g] = act
+	}
+	return act
 }
 
 func (evs *GoroutineList) HoveredLink() ObjectLink {
@@ -571,11 +667,13 @@ func (evs *GoroutineList) HoveredLink() ObjectLink {
 
 func (gl *GoroutineList) SetGoroutines(win *theme.Window, gtx layout.Context, gs []*ptrace.Goroutine) {
 	gl.initTable(win, gtx)
-	gl.setGoroutines(gtx, gs)
+	gl.setGoroutines(win, gtx, gs)
 }
 
-func (gl *GoroutineList) setGoroutines(gtx layout.Context, gs []*ptrace.Goroutine) {
+func (gl *GoroutineList) setGoroutines(win *theme.Window, gtx layout.Context, gs []*ptrace.Goroutine) {
 	gl.Goroutines.Reset(gs)
+	// A pending sort is for the previous goroutines or sort order.
+	gl.sorting = nil
 
 	switch gl.table.Columns[gl.table.SortedBy].Name {
 	case "Goroutine":
@@ -621,9 +719,57 @@ func (gl *GoroutineList) setGoroutines(gtx layout.Context, gs []*ptrace.Goroutin
 
 			return cmp(di, dj, gl.table.SortOrder == theme.SortDescending)
 		})
+	case "Activity":
+		gl.sortByActivity(win)
 	}
 }
 
+// sortByActivity sorts the goroutines by their burstiness. Computing a goroutine's burstiness requires computing its
+// activity, so we compute it once per goroutine instead of once per comparison, and in the background for long lists.
+func (gl *GoroutineList) sortByActivity(win *theme.Window) {
+	gs := gl.Goroutines.Items
+	descending := gl.table.SortOrder == theme.SortDescending
+	if len(gs) < goroutineActivitySortThreshold {
+		scores := make([]float64, len(gs))
+		for i, g := range gs {
+			scores[i] = burstiness(gl.goroutineActivity(g))
+		}
+		gl.Goroutines.SortIndex(func(a, b int) int {
+			return cmp(scores[a], scores[b], descending)
+		})
+		return
+	}
+
+	// The cache belongs to the UI thread. The background computation only gets to read a copy of what is already
+	// cached, and its results get added to the cache once they're available.
+	activity := make([][]float64, len(gs))
+	for i, g := range gs {
+		activity[i] = gl.activity[g]
+	}
+	start, end := gl.Trace.Start(), gl.Trace.End()
+	gl.sorting = theme.NewFuture(win, func(cancelled <-chan struct{}) goroutineActivitySort {
+		defer rtrace.StartRegion(context.Background(), "main.GoroutineList.sortByActivity").End()
+		scores := make([]float64, len(gs))
+		for i, g := range gs {
+			if i%1000 == 0 && TryRecv(cancelled) {
+				return goroutineActivitySort{}
+			}
+			if activity[i] == nil {
+				activity[i] = goroutineActivity(g, start, end, goroutineActivityBuckets)
+			}
+			scores[i] = burstiness(activity[i])
+		}
+		order := make([]int, len(gs))
+		for i := range order {
+			order[i] = i
+		}
+		slices.SortFunc(order, func(a, b int) int {
+			return cmp(scores[a], scores[b], descending)
+		})
+		return goroutineActivitySort{order: order, activity: activity}
+	})
+}
+
 func (gs *GoroutineList) initTable(win *theme.Window, gtx layout.Context) {
 	if gs.table != nil {
 		return
@@ -665,6 +811,14 @@ func (gs *GoroutineList) initTable(win *theme.Window, gtx layout.Context) {
 			Clickable: true,
 		})
 	}
+	if !gs.HiddenColumns.Activity {
+		cols = append(cols, theme.Column{
+			Name:      "Activity",
+			Alignment: text.Start,
+			// Sorting by activity sorts by burstiness.
+			Clickable: true,
+		})
+	}
 	gs.table.SetColumns(win, gtx, cols)
 	gs.table.SortedBy = 0
 	gs.table.SortOrder = theme.SortAscending
@@ -685,12 +839,12 @@ func (gs *GoroutineList) initTable(win *theme.Window, gtx layout.Context) {
 	r0 := theme.Record(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
 		gtx.Constraints.Min = image.Point{}
 		gtx.Constraints.Max = image.Pt(99999, 99999)
-		return widget.Label{}.Layout(gtx, win.Theme.Shaper, font.Font{Weight: font.Bold}, 12, "Goroutine", win.ColorMaterial(gtx, color.Oklch{}))
+		return widget.Label{}.Layout(gtx, win.Theme.Shaper, font.Font{Weight: font.Bold}, win.Theme.TextSize, "Goroutine", win.ColorMaterial(gtx, color.Oklch{}))
 	})
 	r1 := theme.Record(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
 		gtx.Constraints.Min = image.Point{}
 		gtx.Constraints.Max = image.Pt(99999, 99999)
-		return widget.Label{}.Layout(gtx, win.Theme.Shaper, font.Font{}, 12, local.Sprintf("%d", maxID), win.ColorMaterial(gtx, color.Oklch{}))
+		return widget.Label{}.Layout(gtx, win.Theme.Shaper, font.Font{}, win.Theme.TextSize, local.Sprintf("%d", maxID), win.ColorMaterial(gtx, color.Oklch{}))
 	})
 	w := r0.Dimensions.Size.X
 	if x := r1.Dimensions.Size.X; x > w {
@@ -703,11 +857,24 @@ func (gs *GoroutineList) initTable(win *theme.Window, gtx layout.Context) {
 	gs.table.Columns[1].Width = max(0, gs.table.Columns[1].Width-float32(d))
 }
 
-func (gs *GoroutineList) Update(gtx layout.Context) {
+func (gs *GoroutineList) Update(win *theme.Window, gtx layout.Context) {
 	gs.table.Update(gtx)
 	if _, ok := gs.table.SortByClickedColumn(); ok {
 		// Trigger resorting.
-		gs.setGoroutines(gtx, gs.Goroutines.Items)
+		gs.setGoroutines(win, gtx, gs.Goroutines.Items)
+	}
+
+	if gs.sorting != nil {
+		if res, ok := gs.sorting.Result(); ok {
+			gs.sorting = nil
+			gs.Goroutines.Order = res.order
+			if gs.activity == nil {
+				gs.activity = make(map[*ptrace.Goroutine][]float64)
+			}
+			for i, g := range gs.Goroutines.Items {
+				gs.activity[g] = res.activity[i]
+			}
+		}
 	}
 }
 
@@ -715,7 +882,7 @@ func (gs *GoroutineList) Layout(win *theme.Window, gtx layout.Context) layout.Di
 	defer rtrace.StartRegion(context.Background(), "main.GoroutineList.Layout").End()
 
 	gs.initTable(win, gtx)
-	gs.Update(gtx)
+	gs.Update(win, gtx)
 	gs.cellFormatter.Update(win, gtx)
 
 	cellFn := func(win *theme.Window, gtx layout.Context, row, col int) layout.Dimensions {
@@ -770,6 +937,12 @@ func (gs *GoroutineList) Layout(win *theme.Window, gtx layout.Context) layout.Di
 			}
 
 			return gs.cellFormatter.Duration(win, gtx, d, approx)
+		case "Activity":
+			sl := theme.Sparkline(win.Theme, gs.goroutineActivity(g))
+			sl.Kind = theme.SparklineBar
+			// The values are fractions of time, so we don't want to scale them to the busiest bucket.
+			sl.Max = 1
+			return sl.Layout(win, gtx)
 		default:
 			panic(colName)
 		}
@@ -801,7 +974,7 @@ func NewGoroutinesComponent(gs []*ptrace.Goroutine, tr *Trace) *GoroutinesCompon
 
 // Title implements theme.Component.
 func (*GoroutinesComponent) Title() string {
-	return "Goroutines"
+	return tr("Goroutines")
 }
 
 // Transition implements theme.Component.
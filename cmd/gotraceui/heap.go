@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	rtrace "runtime/trace"
+	"sort"
+	"time"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/trace/ptrace"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/op/clip"
+	"gioui.org/text"
+	exptrace "golang.org/x/exp/trace"
+)
+
+// metricAt returns the value of the metric at time t, or 0 if the metric has no value yet.
+func metricAt(m ptrace.Metric, t exptrace.Time) uint64 {
+	i := sort.Search(len(m.Timestamps), func(i int) bool { return m.Timestamps[i] > t })
+	if i == 0 {
+		return 0
+	}
+	return m.Values[i-1]
+}
+
+func metricSeries(name string, m ptrace.Metric) widget.LineChartSeries {
+	s := widget.LineChartSeries{
+		Name:   name,
+		Points: make([]widget.LineChartPoint, len(m.Timestamps)),
+		Step:   true,
+	}
+	for i, ts := range m.Timestamps {
+		s.Points[i] = widget.LineChartPoint{X: float64(ts), Y: float64(m.Values[i])}
+	}
+	return s
+}
+
+// A gcCycle describes the heap at the start and end of a GC cycle.
+type gcCycle struct {
+	Span *ptrace.Span
+	// The heap size when the cycle started and ended, and the goal that triggered the cycle.
+	HeapStart, HeapEnd, Goal uint64
+}
+
+// HeapComponent plots the heap size and the heap goal over time, with GC cycles marked, and lists the GC cycles. This
+// helps correlating memory growth with what goroutines were doing.
+type HeapComponent struct {
+	trace *Trace
+
+	chart  theme.LineChartState
+	ranges []widget.LineChartRange
+	cycles []gcCycle
+
+	split         theme.SplitterState
+	table         *theme.Table
+	scrollState   theme.YScrollableListState
+	cellFormatter CellFormatter
+}
+
+func NewHeapComponent(tr *Trace) *HeapComponent {
+	heap := tr.Metrics["/memory/classes/heap/objects:bytes"]
+	goal := tr.Metrics["/gc/heap/goal:bytes"]
+
+	hc := &HeapComponent{
+		trace: tr,
+		split: theme.SplitterState{
+			Axis:  layout.Vertical,
+			Ratio: 0.6,
+		},
+	}
+	hc.chart.SetSeries([]widget.LineChartSeries{
+		metricSeries("Heap size", heap),
+		metricSeries("Heap goal", goal),
+	})
+	hc.ranges = make([]widget.LineChartRange, len(tr.GC))
+	hc.cycles = make([]gcCycle, len(tr.GC))
+	for i := range tr.GC {
+		s := &tr.GC[i]
+		end := s.End
+		if s.EndEvent == -1 {
+			end = tr.End()
+		}
+		hc.ranges[i] = widget.LineChartRange{Start: float64(s.Start), End: float64(end)}
+		hc.cycles[i] = gcCycle{
+			Span:      s,
+			HeapStart: metricAt(heap, s.Start),
+			HeapEnd:   metricAt(heap, end),
+			// The goal changes at the end of a cycle, so look it up just before the cycle started.
+			Goal: metricAt(goal, s.Start-1),
+		}
+	}
+	return hc
+}
+
+// Title implements theme.Component.
+func (*HeapComponent) Title() string {
+	return tr("Heap and GC pacing")
+}
+
+// Transition implements theme.Component.
+func (*HeapComponent) Transition(state theme.ComponentState) {}
+
+// WantsTransition implements theme.Component.
+func (*HeapComponent) WantsTransition(gtx layout.Context) theme.ComponentState {
+	return theme.ComponentStateNone
+}
+
+func (hc *HeapComponent) HoveredLink() ObjectLink {
+	return hc.cellFormatter.HoveredLink()
+}
+
+func (hc *HeapComponent) initTable(win *theme.Window, gtx layout.Context) {
+	if hc.table != nil {
+		return
+	}
+	hc.table = &theme.Table{}
+	hc.table.SetColumns(win, gtx, []theme.Column{
+		{Name: "Start", Alignment: text.End},
+		{Name: "Duration", Alignment: text.End},
+		{Name: "Heap at start", Alignment: text.End},
+		{Name: "Heap goal", Alignment: text.End},
+		{Name: "Heap at end", Alignment: text.End},
+	})
+}
+
+// Layout implements theme.Component.
+func (hc *HeapComponent) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.HeapComponent.Layout").End()
+
+	hc.initTable(win, gtx)
+	hc.table.Update(gtx)
+	hc.cellFormatter.Update(win, gtx)
+	if x, ok := hc.chart.Clicked(); ok {
+		win.EmitAction(ShowTimestampAction(exptrace.Time(x)))
+	}
+
+	chart := func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+		lc := theme.LineChart(win.Theme, &hc.chart)
+		lc.XLabel = "Time"
+		lc.YLabel = "Bytes"
+		lc.Ranges = hc.ranges
+		lc.FormatX = func(v float64) string {
+			return formatTimestamp(nil, hc.trace.AdjustedTime(exptrace.Time(v)))
+		}
+		lc.FormatY = func(v float64) string {
+			return formatBytes(int64(v))
+		}
+		return lc.Layout(win, gtx)
+	}
+
+	cellFn := func(win *theme.Window, gtx layout.Context, row, col int) layout.Dimensions {
+		defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+
+		c := &hc.cycles[row]
+		bytes := func(n uint64) layout.Dimensions {
+			return layout.RightAligned(gtx, func(gtx layout.Context) layout.Dimensions {
+				return hc.cellFormatter.Text(win, gtx, formatBytes(int64(n)))
+			})
+		}
+		switch colName := hc.table.Columns[col].Name; colName {
+		case "Start":
+			return hc.cellFormatter.Timestamp(win, gtx, hc.trace, c.Span.Start, "")
+		case "Duration":
+			if c.Span.EndEvent == -1 {
+				return hc.cellFormatter.Duration(win, gtx, time.Duration(hc.trace.End()-c.Span.Start), true)
+			}
+			return hc.cellFormatter.Duration(win, gtx, c.Span.Duration(), false)
+		case "Heap at start":
+			return bytes(c.HeapStart)
+		case "Heap goal":
+			return bytes(c.Goal)
+		case "Heap at end":
+			return bytes(c.HeapEnd)
+		default:
+			panic(colName)
+		}
+	}
+	cycles := func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+		if len(hc.cycles) == 0 {
+			return layout.Center.Layout(gtx, theme.Dumb(win, theme.Label(win.Theme, tr("No GC cycles happened during the trace.")).Layout))
+		}
+		return theme.SimpleTable(win, gtx, hc.table, &hc.scrollState, len(hc.cycles), cellFn)
+	}
+
+	return theme.Splitter(win.Theme, &hc.split).Layout(win, gtx, chart, cycles)
+}
@@ -53,9 +53,8 @@ type Heatmap struct {
 
 	hovered HeatmapBucket
 
-	cacheKey    heatmapCacheKey
-	cachedOps   op.Ops
-	cachedMacro op.CallOp
+	cacheKey heatmapCacheKey
+	cache    theme.Cache
 
 	linearSaturations []uint8
 	rankedSaturations []uint8
@@ -167,18 +166,16 @@ func (hm *Heatmap) Layout(win *theme.Window, gtx layout.Context) layout.Dimensio
 	xStepPx := float32(dims.X) / float32(numXBuckets)
 	yStepPx := float32(dims.Y) / float32(hm.numYBuckets)
 
-	if hm.cacheKey == key {
-		hm.cachedMacro.Add(gtx.Ops)
-	} else {
+	if hm.cacheKey != key {
 		hm.cacheKey = key
-		hm.cachedOps.Reset()
-		m := op.Record(&hm.cachedOps)
-
-		stack := clip.Rect{Max: dims}.Push(&hm.cachedOps)
+		hm.cache.Invalidate()
+	}
+	hm.cache.Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+		stack := clip.Rect{Max: dims}.Push(gtx.Ops)
 		// Use a white background, instead of the yellowish one we use everywhere else, to improve contrast and
 		// legibility.
-		theme.Fill(win, &hm.cachedOps, oklch(100, 0, 0))
-		pointer.InputOp{Tag: hm, Kinds: pointer.Move}.Add(&hm.cachedOps)
+		theme.Fill(win, gtx.Ops, oklch(100, 0, 0))
+		pointer.InputOp{Tag: hm, Kinds: pointer.Move}.Add(gtx.Ops)
 
 		max := 0
 		for _, v := range hm.data {
@@ -238,14 +235,13 @@ func (hm *Heatmap) Layout(win *theme.Window, gtx layout.Context) layout.Dimensio
 			// saturation) pair wasn't intuitive.
 			m := uint8(255 - i)
 			c := color.NRGBA{0xFF, m, m, 0xFF}
-			paint.FillShape(&hm.cachedOps, c, clip.Outline{Path: paths[i].End()}.Op())
+			paint.FillShape(gtx.Ops, c, clip.Outline{Path: paths[i].End()}.Op())
 		}
 
 		stack.Pop()
-		hm.cachedMacro = m.Stop()
 
-		hm.cachedMacro.Add(gtx.Ops)
-	}
+		return layout.Dimensions{Size: dims}
+	})
 
 	if hm.pointerConstraint == dims && hm.pointer.X > 0 && hm.pointer.Y > 0 && hm.pointer.X <= float32(dims.X) && hm.pointer.Y <= float32(dims.Y) {
 		x := int(hm.pointer.X / xStepPx)
@@ -291,6 +287,9 @@ type HeatmapComponent struct {
 
 	yStep     int
 	useLinear widget.Bool
+
+	keys    key.Set
+	keysGen uint64
 }
 
 // bucketByX computes processor busyness for time intervals of size xStep.
@@ -322,7 +321,7 @@ func NewHeatmapComponent(trace *Trace) *HeatmapComponent {
 }
 
 func (hmc *HeatmapComponent) Title() string {
-	return "Processor utilization heatmap"
+	return tr("Processor utilization heatmap")
 }
 
 func (hmc *HeatmapComponent) Transition(theme.ComponentState) {
@@ -342,36 +341,48 @@ func (hmc *HeatmapComponent) Layout(win *theme.Window, gtx layout.Context) layou
 		hmc.hm.UseLinearColors = hmc.useLinear.Value
 	}
 
+	handle := func(s theme.Shortcut) {
+		// TODO(dh): provide visual feedback, displaying the bucket size
+		switch {
+		case keymap.Matches(keyHeatmapYBucketUp, s):
+			hmc.yStep++
+			if hmc.yStep >= len(ySteps) {
+				hmc.yStep = len(ySteps) - 1
+			}
+			hmc.hm.YBucketSize = ySteps[hmc.yStep]
+		case keymap.Matches(keyHeatmapYBucketDown, s):
+			hmc.yStep--
+			if hmc.yStep < 0 {
+				hmc.yStep = 0
+			}
+			hmc.hm.YBucketSize = ySteps[hmc.yStep]
+		case keymap.Matches(keyHeatmapXBucketDown, s):
+			hmc.hm.XBucketSize -= 10 * time.Millisecond
+			if hmc.hm.XBucketSize < 10*time.Millisecond {
+				hmc.hm.XBucketSize = 10 * time.Millisecond
+			}
+			hmc.hm.SetData(bucketByX(hmc.trace, hmc.hm.XBucketSize))
+		case keymap.Matches(keyHeatmapXBucketUp, s):
+			hmc.hm.XBucketSize += 10 * time.Millisecond
+			hmc.hm.SetData(bucketByX(hmc.trace, hmc.hm.XBucketSize))
+		}
+	}
 	for _, e := range gtx.Events(hmc) {
 		if ev, ok := e.(key.Event); ok && ev.State == key.Press {
-			// TODO(dh): provide visual feedback, displaying the bucket size
-			switch ev.Name {
-			case "↑":
-				hmc.yStep++
-				if hmc.yStep >= len(ySteps) {
-					hmc.yStep = len(ySteps) - 1
-				}
-				hmc.hm.YBucketSize = ySteps[hmc.yStep]
-			case "↓":
-				hmc.yStep--
-				if hmc.yStep < 0 {
-					hmc.yStep = 0
-				}
-				hmc.hm.YBucketSize = ySteps[hmc.yStep]
-			case "←":
-				hmc.hm.XBucketSize -= 10 * time.Millisecond
-				if hmc.hm.XBucketSize < 10*time.Millisecond {
-					hmc.hm.XBucketSize = 10 * time.Millisecond
-				}
-				hmc.hm.SetData(bucketByX(hmc.trace, hmc.hm.XBucketSize))
-			case "→":
-				hmc.hm.XBucketSize += 10 * time.Millisecond
-				hmc.hm.SetData(bucketByX(hmc.trace, hmc.hm.XBucketSize))
-			}
+			handle(theme.Shortcut{Modifiers: ev.Modifiers, Name: ev.Name})
 		}
 	}
+	// Shortcuts that keymap.KeySet can't include reach the window instead.
+	for _, s := range win.PressedShortcuts() {
+		handle(s)
+	}
 
-	key.InputOp{Tag: hmc, Keys: "↑|↓|←|→"}.Add(gtx.Ops)
+	if gen := keymap.Generation(); hmc.keys == "" || gen != hmc.keysGen {
+		hmc.keys = keymap.KeySet(keyHeatmapYBucketUp, keyHeatmapYBucketDown, keyHeatmapXBucketDown, keyHeatmapXBucketUp)
+		hmc.keysGen = gen
+	}
+	key.InputOp{Tag: hmc, Keys: hmc.keys}.Add(gtx.Ops)
+	keymap.Register(win, keyHeatmapYBucketUp, keyHeatmapYBucketDown, keyHeatmapXBucketDown, keyHeatmapXBucketUp)
 	key.FocusOp{Tag: hmc}.Add(gtx.Ops)
 
 	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
@@ -395,7 +406,7 @@ func (hmc *HeatmapComponent) Layout(win *theme.Window, gtx layout.Context) layou
 			// TODO(dh): instead of using a checkbox, use a toggle switch that shows the two options (linear and
 			// ranked). With the checkbox, the user doesn't know what's being used when the checkbox isn't
 			// ticked.
-			return theme.CheckBox(win.Theme, &hmc.useLinear, "Use linear saturation").Layout(win, gtx)
+			return theme.CheckBox(win.Theme, &hmc.useLinear, tr("Use linear saturation")).Layout(win, gtx)
 		}),
 	)
 }
@@ -0,0 +1,56 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"image"
+	"strings"
+
+	"honnef.co/go/gotraceui"
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+)
+
+//go:embed help.md
+var helpText string
+
+// helpMarkdown returns the in-app help, including the current keybindings.
+func helpMarkdown() string {
+	var sb strings.Builder
+	sb.WriteString(helpText)
+	sb.WriteString("\n")
+	for _, kb := range keybindings {
+		s := keymap.Label(kb.Name)
+		if s == "" {
+			s = "unbound"
+		}
+		fmt.Fprintf(&sb, "- %s: `%s`\n", kb.Description, s)
+	}
+	return sb.String()
+}
+
+// displayDocumentDialog displays Markdown in a modal dialog. Clicking links opens them in the browser.
+func displayDocumentDialog(win *theme.Window, title, markdown string) {
+	var rt theme.RichTextState
+	rt.SetMarkdown(markdown)
+	win.SetModal(func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+		return theme.Dialog(win.Theme, title).Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+			gtx.Constraints.Min = gtx.Constraints.Constrain(image.Pt(gtx.Dp(800), gtx.Dp(600)))
+			gtx.Constraints.Max = gtx.Constraints.Min
+			for _, link := range rt.Update(gtx) {
+				if err := openURL(link); err != nil {
+					win.Notify(theme.NotificationError, local.Sprintf("Couldn't open link: %s", err))
+				}
+			}
+			return theme.RichText(win.Theme, &rt).Layout(win, gtx)
+		})
+	})
+}
+
+func displayHelpDialog(win *theme.Window) {
+	displayDocumentDialog(win, "Help", helpMarkdown())
+}
+
+func displayReleaseNotesDialog(win *theme.Window) {
+	displayDocumentDialog(win, "Release notes", gotraceui.News)
+}
@@ -2,10 +2,8 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"image"
 	rtrace "runtime/trace"
-	"strconv"
 	"time"
 
 	"honnef.co/go/gotraceui/layout"
@@ -86,13 +84,13 @@ func (hist *InteractiveHistogram) Layout(win *theme.Window, gtx layout.Context)
 
 		menu := []*theme.MenuItem{
 			{
-				Label: PlainLabel("Change settings"),
+				Label: PlainLabel(tr("Change settings")),
 				Action: func() theme.Action {
 					return theme.ExecuteAction(func(gtx layout.Context) {
 						win.SetModal(func(win *theme.Window, gtx layout.Context) layout.Dimensions {
 							gtx.Constraints.Min = gtx.Constraints.Constrain(image.Pt(1000, 500))
 							gtx.Constraints.Max = gtx.Constraints.Min
-							return theme.Dialog(win.Theme, "Histogram settings").Layout(win, gtx, HistogramSettings(&hist.settingsState).Layout)
+							return theme.Dialog(win.Theme, tr("Histogram settings")).Layout(win, gtx, HistogramSettings(&hist.settingsState).Layout)
 						})
 					})
 				},
@@ -100,7 +98,7 @@ func (hist *InteractiveHistogram) Layout(win *theme.Window, gtx layout.Context)
 
 			{
 				// TODO disable when there is nothing to zoom out to
-				Label: PlainLabel("Zoom out"),
+				Label: PlainLabel(tr("Zoom out")),
 				Action: func() theme.Action {
 					return theme.ExecuteAction(func(gtx layout.Context) {
 						hist.Config.Start = 0
@@ -126,12 +124,12 @@ func (hist *InteractiveHistogram) Layout(win *theme.Window, gtx layout.Context)
 
 		return dims
 	} else {
-		return theme.Label(win.Theme, "Computing histogram…").Layout(win, gtx)
+		return theme.Loading(win.Theme, tr("Computing histogram…")).Layout(win, gtx)
 	}
 }
 
 type HistogramSettingsState struct {
-	numBinsEditor  widget.Editor
+	numBins        *theme.NumberInputState[int]
 	filterOutliers widget.Bool
 	save           widget.PrimaryClickable
 	cancel         widget.PrimaryClickable
@@ -149,11 +147,7 @@ func (hs *HistogramSettingsState) Update(gtx layout.Context) (saved, cancelled b
 }
 
 func (hss *HistogramSettingsState) NumBins() int {
-	n, err := strconv.ParseInt(hss.numBinsEditor.Text(), 10, 32)
-	if err != nil || n < 1 || n > 9999 {
-		return 100
-	}
-	return int(n)
+	return hss.numBins.Value()
 }
 
 func (hss *HistogramSettingsState) RejectOutliers() bool {
@@ -166,11 +160,7 @@ type HistogramSettingsStyle struct {
 
 func (s *HistogramSettingsState) Reset(cfg widget.HistogramConfig) {
 	s.filterOutliers.Set(cfg.RejectOutliers)
-	numBinsStr := fmt.Sprintf("%d", cfg.Bins)
-	s.numBinsEditor.SetText(numBinsStr)
-	s.numBinsEditor.SingleLine = true
-	s.numBinsEditor.Filter = "0123456789"
-	s.numBinsEditor.SetCaret(len(numBinsStr), len(numBinsStr))
+	s.numBins = theme.NewIntInput(cfg.Bins, 1, 9999, 10)
 }
 
 func HistogramSettings(state *HistogramSettingsState) HistogramSettingsStyle {
@@ -182,10 +172,6 @@ func HistogramSettings(state *HistogramSettingsState) HistogramSettingsStyle {
 func (hs HistogramSettingsStyle) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
 	defer rtrace.StartRegion(context.Background(), "main.HistogramSettingsStyle.Layout").End()
 
-	validateNumBins := func(s string) bool {
-		return len(s) <= 4
-	}
-
 	settingLabel := func(s string) layout.Dimensions {
 		gtx := gtx
 		gtx.Constraints.Min.Y = 0
@@ -200,9 +186,7 @@ func (hs HistogramSettingsStyle) Layout(win *theme.Window, gtx layout.Context) l
 		},
 
 		func(gtx layout.Context) layout.Dimensions {
-			tb := theme.TextBox(win.Theme, &hs.State.numBinsEditor, "Number of bins")
-			tb.Validate = validateNumBins
-			return tb.Layout(win, gtx)
+			return theme.NumberInput(win.Theme, hs.State.numBins, "Number of bins").Layout(win, gtx)
 		},
 
 		func(gtx layout.Context) layout.Dimensions {
@@ -216,7 +200,7 @@ func (hs HistogramSettingsStyle) Layout(win *theme.Window, gtx layout.Context) l
 		func(gtx layout.Context) layout.Dimensions {
 			ngtx := gtx
 			ngtx.Constraints.Min = image.Point{}
-			dims := theme.Switch(&hs.State.filterOutliers, "No", "Yes").Layout(win, ngtx)
+			dims := theme.Switch(&hs.State.filterOutliers, "No", tr("Yes")).Layout(win, ngtx)
 			return layout.Dimensions{
 				Size:     gtx.Constraints.Constrain(dims.Size),
 				Baseline: dims.Baseline,
@@ -230,10 +214,8 @@ func (hs HistogramSettingsStyle) Layout(win *theme.Window, gtx layout.Context) l
 		func(gtx layout.Context) layout.Dimensions {
 			return layout.Flex{Axis: layout.Horizontal}.Layout(gtx,
 				layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
-					btn := theme.Button(win.Theme, &hs.State.save.Clickable, "Save settings")
-					// We can't put len(t) > 0 in validateNumBins because widget.Editor doesn't support separate
-					// colors for text and caret, and we don't want an empty input box to have a red caret.
-					if t := hs.State.numBinsEditor.Text(); len(t) > 0 && validateNumBins(t) {
+					btn := theme.Button(win.Theme, &hs.State.save.Clickable, tr("Save settings"))
+					if hs.State.numBins.Valid() {
 						return btn.Layout(win, gtx)
 					} else {
 						gtx.Queue = nil
@@ -244,7 +226,7 @@ func (hs HistogramSettingsStyle) Layout(win *theme.Window, gtx layout.Context) l
 				layout.Rigid(func(gtx layout.Context) layout.Dimensions { return layout.Spacer{Width: 5}.Layout(gtx) }),
 
 				layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
-					return theme.Button(win.Theme, &hs.State.cancel.Clickable, "Cancel").Layout(win, gtx)
+					return theme.Button(win.Theme, &hs.State.cancel.Clickable, tr("Cancel")).Layout(win, gtx)
 				}),
 			)
 		},
@@ -0,0 +1,394 @@
+package main
+
+import (
+	"context"
+	rtrace "runtime/trace"
+	"slices"
+	"time"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/trace/ptrace"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/font"
+	"gioui.org/op/clip"
+	"gioui.org/text"
+	exptrace "golang.org/x/exp/trace"
+)
+
+// The thresholds of the heuristics that look for anomalies. They are deliberately conservative, as the insights are
+// meant to be a starting point, not an exhaustive list.
+const (
+	insightSTWWarning        = 10 * time.Millisecond
+	insightSTWCritical       = 100 * time.Millisecond
+	insightLatencyWarning    = 10 * time.Millisecond
+	insightLatencyCritical   = 100 * time.Millisecond
+	insightGoroutineMinCount = 1000
+	insightGoroutineGrowth   = 10
+	insightGoroutineWindow   = time.Second
+	insightIdleMinDuration   = time.Millisecond
+	// The maximum number of insights of a single kind, keeping the most severe ones.
+	maxInsightsPerKind = 25
+)
+
+type insightSeverity uint8
+
+const (
+	insightInfo insightSeverity = iota
+	insightWarning
+	insightCritical
+)
+
+var insightSeverityNames = [...]string{
+	insightInfo:     "Info",
+	insightWarning:  "Warning",
+	insightCritical: "Critical",
+}
+
+// An insight is a potential problem found by a heuristic.
+type insight struct {
+	Severity    insightSeverity
+	Kind        string
+	Description string
+	Start, End  exptrace.Time
+	// Goroutine is the goroutine the insight is about, if any.
+	Goroutine *ptrace.Goroutine
+}
+
+func (in *insight) Duration() time.Duration {
+	return time.Duration(in.End - in.Start)
+}
+
+type insightsKey struct{}
+
+// computeInsights runs all heuristics. The result is sorted by severity, then by time.
+func computeInsights(tr *Trace) []insight {
+	defer rtrace.StartRegion(context.Background(), "main.computeInsights").End()
+
+	var out []insight
+	out = append(out, stwInsights(tr)...)
+	out = append(out, latencyInsights(tr)...)
+	out = append(out, goroutineCountInsights(tr)...)
+	out = append(out, idlePInsights(tr)...)
+	slices.SortStableFunc(out, func(a, b insight) int {
+		if a.Severity != b.Severity {
+			return int(b.Severity) - int(a.Severity)
+		}
+		return cmp(a.Start, b.Start, false)
+	})
+	return out
+}
+
+// worstInsights keeps the maxInsightsPerKind longest insights.
+func worstInsights(ins []insight) []insight {
+	if len(ins) <= maxInsightsPerKind {
+		return ins
+	}
+	slices.SortFunc(ins, func(a, b insight) int {
+		return cmp(a.Duration(), b.Duration(), true)
+	})
+	return ins[:maxInsightsPerKind]
+}
+
+func stwInsights(tr *Trace) []insight {
+	var out []insight
+	for _, s := range tr.STW {
+		d := s.Duration()
+		if d < insightSTWWarning {
+			continue
+		}
+		sev := insightWarning
+		if d >= insightSTWCritical {
+			sev = insightCritical
+		}
+		out = append(out, insight{
+			Severity:    sev,
+			Kind:        "Long stop-the-world",
+			Description: local.Sprintf("%s stopped the world for %s", tr.Event(s.StartEvent).Range().Name, roundDuration(d)),
+			Start:       s.Start,
+			End:         s.End,
+		})
+	}
+	return worstInsights(out)
+}
+
+func latencyInsights(tr *Trace) []insight {
+	var out []insight
+	for _, g := range tr.Goroutines {
+		for _, s := range g.Spans {
+			if s.State != ptrace.StateReady && s.State != ptrace.StateWaitingPreempted {
+				continue
+			}
+			if s.EndEvent == -1 {
+				// The goroutine was still waiting when the trace ended, so we don't know the latency.
+				continue
+			}
+			d := s.Duration()
+			if d < insightLatencyWarning {
+				continue
+			}
+			sev := insightWarning
+			if d >= insightLatencyCritical {
+				sev = insightCritical
+			}
+			out = append(out, insight{
+				Severity:    sev,
+				Kind:        "Scheduling latency",
+				Description: local.Sprintf("Goroutine %d was runnable for %s before it got to run", g.ID, roundDuration(d)),
+				Start:       s.Start,
+				End:         s.End,
+				Goroutine:   g,
+			})
+		}
+	}
+	return worstInsights(out)
+}
+
+// goroutineCountInsights finds periods during which the number of goroutines grew by at least a factor of
+// insightGoroutineGrowth within insightGoroutineWindow, to at least insightGoroutineMinCount.
+func goroutineCountInsights(tr *Trace) []insight {
+	const bucket = insightGoroutineWindow / 10
+	start, end := tr.Start(), tr.End()
+	n := int((end-start)/exptrace.Time(bucket)) + 1
+	// deltas[i] is the change in the number of goroutines during bucket i.
+	deltas := make([]int, n+1)
+	for _, g := range tr.Goroutines {
+		gStart, gEnd := g.EffectiveStart(), g.EffectiveEnd()
+		if _, ok := g.End.Get(); !ok {
+			gEnd = end
+		}
+		deltas[int((gStart-start)/exptrace.Time(bucket))]++
+		deltas[int((gEnd-start)/exptrace.Time(bucket))+1]--
+	}
+	live := make([]int, n)
+	cur := 0
+	for i := range live {
+		cur += deltas[i]
+		live[i] = cur
+	}
+
+	const window = int(insightGoroutineWindow / bucket)
+	var out []insight
+	for i := window; i < n; i++ {
+		before, after := max(live[i-window], 1), live[i]
+		if after < insightGoroutineMinCount || after < before*insightGoroutineGrowth {
+			continue
+		}
+		bStart := start + exptrace.Time(i-window)*exptrace.Time(bucket)
+		bEnd := min(start+exptrace.Time(i+1)*exptrace.Time(bucket), end)
+		desc := local.Sprintf("The number of goroutines grew from %d to %d", live[i-window], after)
+		if k := len(out); k > 0 && out[k-1].End >= bStart {
+			// Merge overlapping windows.
+			out[k-1].End = bEnd
+			out[k-1].Description = local.Sprintf("The number of goroutines grew to %d", after)
+			continue
+		}
+		out = append(out, insight{
+			Severity:    insightWarning,
+			Kind:        "Goroutine explosion",
+			Description: desc,
+			Start:       bStart,
+			End:         bEnd,
+		})
+	}
+	return worstInsights(out)
+}
+
+// stepAverages returns the time-weighted averages of a metric, which holds its value until the next sample, over
+// n buckets of the given width, starting at start.
+func stepAverages(m ptrace.Metric, start, width exptrace.Time, n int) []float64 {
+	out := make([]float64, n)
+	for i, ts := range m.Timestamps {
+		next := start + exptrace.Time(n)*width
+		if i+1 < len(m.Timestamps) {
+			next = min(m.Timestamps[i+1], next)
+		}
+		v := float64(m.Values[i])
+		for b := max(int((ts-start)/width), 0); b < n; b++ {
+			bStart := start + exptrace.Time(b)*width
+			if bStart >= next {
+				break
+			}
+			overlap := min(next, bStart+width) - max(ts, bStart)
+			out[b] += v * float64(overlap) / float64(width)
+		}
+	}
+	return out
+}
+
+// idlePInsights finds periods during which Ps were idle even though goroutines were waiting to run.
+func idlePInsights(tr *Trace) []insight {
+	series := utilization(tr)
+	idle := series[utilizationIdle].Points
+	if len(idle) < 2 {
+		return nil
+	}
+	start := exptrace.Time(idle[0].X)
+	width := exptrace.Time(idle[1].X) - start
+	n := len(idle) - 1
+	runnable := stepAverages(tr.Metrics["/gotraceui/sched/goroutines/runnable:goroutines"], start, width, n)
+
+	var out []insight
+	open := false
+	for b := range n {
+		if min(idle[b].Y, runnable[b]) < 1 {
+			open = false
+			continue
+		}
+		bStart := start + exptrace.Time(b)*width
+		if open {
+			out[len(out)-1].End = bStart + width
+			continue
+		}
+		open = true
+		out = append(out, insight{
+			Severity: insightWarning,
+			Kind:     "Idle Ps with runnable goroutines",
+			Start:    bStart,
+			End:      bStart + width,
+		})
+	}
+	out = slices.DeleteFunc(out, func(in insight) bool { return in.Duration() < insightIdleMinDuration })
+	for i := range out {
+		out[i].Description = local.Sprintf("For %s, at least one P was idle while goroutines were waiting to run", roundDuration(out[i].Duration()))
+	}
+	return worstInsights(out)
+}
+
+// InsightsComponent lists potential problems that heuristics found in the trace, to give a starting point for
+// investigating it. Clicking on an insight zooms to the time it covers.
+type InsightsComponent struct {
+	trace    *Trace
+	insights *theme.Future[[]insight]
+
+	openOnLoad    widget.Bool
+	table         *theme.Table
+	scrollState   theme.YScrollableListState
+	cellFormatter CellFormatter
+	zooms         []widget.PrimaryClickable
+}
+
+func NewInsightsComponent(win *theme.Window, tr *Trace) *InsightsComponent {
+	ic := &InsightsComponent{
+		trace: tr,
+		insights: theme.Compute(&tr.analyses, win, insightsKey{}, func() []insight {
+			return computeInsights(tr)
+		}),
+	}
+	userConfigMu.Lock()
+	ic.openOnLoad.Value = userConfig.OpenInsights
+	userConfigMu.Unlock()
+	return ic
+}
+
+// Title implements theme.Component.
+func (*InsightsComponent) Title() string {
+	return tr("Insights")
+}
+
+// Transition implements theme.Component.
+func (*InsightsComponent) Transition(state theme.ComponentState) {}
+
+// WantsTransition implements theme.Component.
+func (*InsightsComponent) WantsTransition(gtx layout.Context) theme.ComponentState {
+	return theme.ComponentStateNone
+}
+
+func (ic *InsightsComponent) HoveredLink() ObjectLink {
+	return ic.cellFormatter.HoveredLink()
+}
+
+func (ic *InsightsComponent) initTable(win *theme.Window, gtx layout.Context) {
+	if ic.table != nil {
+		return
+	}
+	ic.table = &theme.Table{}
+	ic.table.SetColumns(win, gtx, []theme.Column{
+		{Name: "Severity", Alignment: text.Start},
+		{Name: "Kind", Alignment: text.Start},
+		{Name: "Start", Alignment: text.End},
+		{Name: "Duration", Alignment: text.End},
+		{Name: "Goroutine", Alignment: text.End},
+		{Name: "Description", Alignment: text.Start},
+	})
+}
+
+// Layout implements theme.Component.
+func (ic *InsightsComponent) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.InsightsComponent.Layout").End()
+
+	if ic.openOnLoad.Update(gtx) {
+		userConfigMu.Lock()
+		userConfig.OpenInsights = ic.openOnLoad.Value
+		err := userConfig.Save()
+		userConfigMu.Unlock()
+		if err != nil {
+			win.Notify(theme.NotificationError, local.Sprintf("Couldn't save configuration: %s", err))
+		}
+	}
+
+	insights, ok := ic.insights.Result()
+	if !ok {
+		return theme.Loading(win.Theme, tr("Looking for anomalies…")).Layout(win, gtx)
+	}
+	if len(ic.zooms) != len(insights) {
+		ic.zooms = make([]widget.PrimaryClickable, len(insights))
+	}
+
+	ic.initTable(win, gtx)
+	ic.table.Update(gtx)
+	ic.cellFormatter.Update(win, gtx)
+	for i := range ic.zooms {
+		for ic.zooms[i].Clicked(gtx) {
+			in := &insights[i]
+			// Add some context around the insight.
+			pad := max(in.End-in.Start, 1000) / 10
+			win.EmitAction(&ZoomToTimeRangeAction{Start: in.Start - pad, End: in.End + pad})
+		}
+	}
+
+	cellFn := func(win *theme.Window, gtx layout.Context, row, col int) layout.Dimensions {
+		defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+
+		in := &insights[row]
+		switch colName := ic.table.Columns[col].Name; colName {
+		case "Severity":
+			l := theme.LineLabel(win.Theme, insightSeverityNames[in.Severity])
+			if in.Severity == insightCritical {
+				l.Color = colors[colorStateBlocked]
+			}
+			return l.Layout(win, gtx)
+		case "Kind":
+			return ic.cellFormatter.Text(win, gtx, in.Kind)
+		case "Start":
+			return ic.cellFormatter.Timestamp(win, gtx, ic.trace, in.Start, "")
+		case "Duration":
+			return ic.cellFormatter.Duration(win, gtx, in.Duration(), false)
+		case "Goroutine":
+			if in.Goroutine == nil {
+				return layout.Dimensions{Size: gtx.Constraints.Min}
+			}
+			return ic.cellFormatter.Goroutine(win, gtx, in.Goroutine, "")
+		case "Description":
+			return ic.zooms[row].Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				return widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, font.Font{}, win.Theme.TextSize, in.Description, win.ColorMaterial(gtx, win.Theme.Palette.Link))
+			})
+		default:
+			panic(colName)
+		}
+	}
+
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(theme.Dumb(win, theme.CheckBox(win.Theme, &ic.openOnLoad, tr("Look for anomalies after loading traces")).Layout)),
+		layout.Rigid(layout.Spacer{Height: 5}.Layout),
+		layout.Rigid(theme.Dumb(win, theme.LineLabel(win.Theme, tr("Click on a description to zoom to the time it covers.")).Layout)),
+		layout.Rigid(layout.Spacer{Height: 5}.Layout),
+		layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+			if len(insights) == 0 {
+				return layout.Center.Layout(gtx, theme.Dumb(win, theme.Label(win.Theme, tr("No anomalies found.")).Layout))
+			}
+			return theme.SimpleTable(win, gtx, ic.table, &ic.scrollState, len(insights), cellFn)
+		}),
+	)
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"context"
+	"image"
+	rtrace "runtime/trace"
+	"slices"
+	"sort"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/trace/ptrace"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/font"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/text"
+	exptrace "golang.org/x/exp/trace"
+)
+
+// spanAt returns the span that contains t, or nil. spans must be sorted and non-overlapping, and only the last span
+// may be unterminated.
+func spanAt(spans []ptrace.Span, t exptrace.Time) *ptrace.Span {
+	i := sort.Search(len(spans), func(i int) bool {
+		return spans[i].End > t || spans[i].EndEvent == -1
+	})
+	if i == len(spans) || spans[i].Start > t {
+		return nil
+	}
+	return &spans[i]
+}
+
+type procAtTime struct {
+	P    *ptrace.Processor
+	Span *ptrace.Span
+	// The goroutine that was running on the P, if any.
+	G *ptrace.Goroutine
+	// The function the goroutine was in when it started running, or the empty string if unknown.
+	ResumedIn string
+}
+
+type stateCount struct {
+	State ptrace.SchedulingState
+	Count int
+}
+
+// A crossSection describes the state of the whole program at one moment.
+type crossSection struct {
+	T      exptrace.Time
+	Procs  []procAtTime
+	States []stateCount
+	// The GC cycle and the stop-the-world phase in progress, if any.
+	GC, STW *ptrace.Span
+}
+
+func computeCrossSection(tr *Trace, t exptrace.Time) *crossSection {
+	defer rtrace.StartRegion(context.Background(), "main.computeCrossSection").End()
+
+	cs := &crossSection{
+		T:   t,
+		GC:  spanAt(tr.GC, t),
+		STW: spanAt(tr.STW, t),
+	}
+
+	for _, p := range tr.Processors {
+		s := spanAt(p.Spans, t)
+		if s == nil {
+			continue
+		}
+		pat := procAtTime{P: p, Span: s}
+		if s.State == ptrace.StateProcRunningG || s.State == ptrace.StateProcRunningBlocked {
+			ev := tr.Event(s.StartEvent)
+			pat.G = tr.G(ev.StateTransition().Resource.Goroutine())
+			if stk := ev.Stack(); stk != exptrace.NoStack {
+				if pcs := tr.Stacks[tr.StackID(stk)]; len(pcs) > 0 {
+					pat.ResumedIn = tr.FuncName(pcs[0])
+				}
+			}
+		}
+		cs.Procs = append(cs.Procs, pat)
+	}
+
+	var counts [ptrace.StateLast]int
+	for _, g := range tr.Goroutines {
+		if s := spanAt(g.Spans, t); s != nil {
+			counts[s.State]++
+		}
+	}
+	for state, n := range counts {
+		if n > 0 {
+			cs.States = append(cs.States, stateCount{ptrace.SchedulingState(state), n})
+		}
+	}
+	slices.SortFunc(cs.States, func(a, b stateCount) int {
+		return cmp(a.Count, b.Count, true)
+	})
+	return cs
+}
+
+// TimeInspector is a panel that shows what was happening at one moment: what each P was running, how many
+// goroutines were in each state, and whether the GC was running.
+type TimeInspector struct {
+	trace *Trace
+	cs    *crossSection
+
+	tabbedState     theme.TabbedState
+	procTable       *theme.Table
+	procScroll      theme.YScrollableListState
+	stateTable      *theme.Table
+	stateScroll     theme.YScrollableListState
+	cellFormatter   CellFormatter
+	descriptionText Text
+	prevSpans       []TextSpan
+	hoveredLink     ObjectLink
+
+	theme.ComponentButtons
+}
+
+func NewTimeInspector(tr *Trace, t exptrace.Time) *TimeInspector {
+	return &TimeInspector{
+		trace: tr,
+		cs:    computeCrossSection(tr, t),
+	}
+}
+
+// Title implements theme.Component.
+func (ti *TimeInspector) Title() string {
+	return local.Sprintf("Program at %s", formatTimestamp(nil, ti.trace.AdjustedTime(ti.cs.T)))
+}
+
+func (ti *TimeInspector) HoveredLink() ObjectLink {
+	return ti.hoveredLink
+}
+
+func (ti *TimeInspector) buildDescription(win *theme.Window) Description {
+	tb := TextBuilder{Window: win}
+	var attrs []DescriptionAttribute
+
+	attrs = append(attrs, DescriptionAttribute{
+		Key:   "Time",
+		Value: *tb.DefaultLink(formatTimestamp(nil, ti.trace.AdjustedTime(ti.cs.T)), "Inspected time", ti.cs.T),
+	})
+
+	running := 0
+	for _, p := range ti.cs.Procs {
+		if p.G != nil {
+			running++
+		}
+	}
+	attrs = append(attrs, DescriptionAttribute{
+		Key:   "Running goroutines",
+		Value: *tb.Span(local.Sprintf("%d of %d Ps busy", running, len(ti.cs.Procs))),
+	})
+
+	if gc := ti.cs.GC; gc != nil {
+		attrs = append(attrs, DescriptionAttribute{
+			Key:   "GC",
+			Value: *tb.DefaultLink(local.Sprintf("running since %s", formatTimestamp(nil, ti.trace.AdjustedTime(gc.Start))), "Start of GC cycle", gc.Start),
+		})
+	} else {
+		attrs = append(attrs, DescriptionAttribute{Key: "GC", Value: *tb.Span("not running")})
+	}
+	if stw := ti.cs.STW; stw != nil {
+		attrs = append(attrs, DescriptionAttribute{
+			Key:   "Stop the world",
+			Value: *tb.DefaultLink(local.Sprintf("since %s", formatTimestamp(nil, ti.trace.AdjustedTime(stw.Start))), "Start of STW phase", stw.Start),
+		})
+	}
+
+	return Description{Attributes: attrs}
+}
+
+func (ti *TimeInspector) initTables(win *theme.Window, gtx layout.Context) {
+	if ti.procTable != nil {
+		return
+	}
+	ti.procTable = &theme.Table{}
+	ti.procTable.SetColumns(win, gtx, []theme.Column{
+		{Name: "Processor", Alignment: text.End},
+		{Name: "State", Alignment: text.Start},
+		{Name: "Goroutine", Alignment: text.Start},
+		{Name: "Since", Alignment: text.End},
+		{Name: "Resumed in", Alignment: text.Start},
+	})
+	ti.stateTable = &theme.Table{}
+	ti.stateTable.SetColumns(win, gtx, []theme.Column{
+		{Name: "State", Alignment: text.Start},
+		{Name: "Goroutines", Alignment: text.End},
+	})
+}
+
+// Layout implements theme.Component.
+func (ti *TimeInspector) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.TimeInspector.Layout").End()
+
+	for _, ev := range ti.descriptionText.Update(gtx, ti.prevSpans) {
+		handleLinkClick(win, ev.Event, ev.Span.ObjectLink)
+	}
+	ti.hoveredLink = ti.descriptionText.HoveredLink()
+	if ti.hoveredLink == nil {
+		ti.hoveredLink = ti.cellFormatter.HoveredLink()
+	}
+	for ti.ComponentButtons.Backed(gtx) {
+		win.EmitAction(&PrevPanelAction{})
+	}
+
+	ti.initTables(win, gtx)
+	ti.procTable.Update(gtx)
+	ti.stateTable.Update(gtx)
+	ti.cellFormatter.Update(win, gtx)
+
+	// Inset of 5 pixels on all sides. We can't use layout.Inset because it doesn't decrease the minimum constraint,
+	// which we do care about here.
+	gtx.Constraints.Min = gtx.Constraints.Min.Sub(image.Pt(2*5, 2*5))
+	gtx.Constraints.Max = gtx.Constraints.Max.Sub(image.Pt(2*5, 2*5))
+	gtx.Constraints = layout.Normalize(gtx.Constraints)
+	defer op.Offset(image.Pt(5, 5)).Push(gtx.Ops).Pop()
+
+	procCell := func(win *theme.Window, gtx layout.Context, row, col int) layout.Dimensions {
+		defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+
+		p := &ti.cs.Procs[row]
+		switch colName := ti.procTable.Columns[col].Name; colName {
+		case "Processor":
+			return layout.RightAligned(gtx, func(gtx layout.Context) layout.Dimensions {
+				link := ti.cellFormatter.Clicks.Grow()
+				link.Link = &ProcessorObjectLink{p.P, "Inspected processor"}
+				return link.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+					return widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, font.Font{}, win.Theme.TextSize, local.Sprintf("%d", p.P.ID), win.ColorMaterial(gtx, win.Theme.Palette.Link))
+				})
+			})
+		case "State":
+			return ti.cellFormatter.Text(win, gtx, stateNamesCapitalized[p.Span.State])
+		case "Goroutine":
+			if p.G == nil {
+				return layout.Dimensions{Size: gtx.Constraints.Min}
+			}
+			return ti.cellFormatter.Goroutine(win, gtx, p.G, "")
+		case "Since":
+			return ti.cellFormatter.Timestamp(win, gtx, ti.trace, p.Span.Start, "")
+		case "Resumed in":
+			return ti.cellFormatter.Text(win, gtx, p.ResumedIn)
+		default:
+			panic(colName)
+		}
+	}
+	stateCell := func(win *theme.Window, gtx layout.Context, row, col int) layout.Dimensions {
+		defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+
+		sc := &ti.cs.States[row]
+		switch colName := ti.stateTable.Columns[col].Name; colName {
+		case "State":
+			return ti.cellFormatter.Text(win, gtx, stateNamesCapitalized[sc.State])
+		case "Goroutines":
+			return ti.cellFormatter.Number(win, gtx, sc.Count)
+		default:
+			panic(colName)
+		}
+	}
+
+	nothing := func(gtx layout.Context) layout.Dimensions {
+		return layout.Dimensions{Size: gtx.Constraints.Min}
+	}
+	tabs := []string{"Processors", "Goroutine states"}
+	return layout.Rigids(gtx, layout.Vertical,
+		func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Horizontal}.Layout(gtx,
+				layout.Flexed(1, nothing),
+				layout.Rigid(theme.Dumb(win, ti.ComponentButtons.Layout)),
+			)
+		},
+
+		layout.Spacer{Height: 10}.Layout,
+		func(gtx layout.Context) layout.Dimensions {
+			gtx.Constraints.Min = image.Point{}
+			dims, spans := ti.buildDescription(win).Layout(win, gtx, &ti.descriptionText)
+			ti.prevSpans = spans
+			return dims
+		},
+
+		layout.Spacer{Height: 10}.Layout,
+		func(gtx layout.Context) layout.Dimensions {
+			return theme.Tabbed(&ti.tabbedState, tabs).Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+				gtx.Constraints.Min = gtx.Constraints.Max
+				switch tabs[ti.tabbedState.Current] {
+				case "Processors":
+					return theme.SimpleTable(win, gtx, ti.procTable, &ti.procScroll, len(ti.cs.Procs), procCell)
+				case "Goroutine states":
+					return theme.SimpleTable(win, gtx, ti.stateTable, &ti.stateScroll, len(ti.cs.States), stateCell)
+				default:
+					panic("unreachable")
+				}
+			})
+		},
+	)
+}
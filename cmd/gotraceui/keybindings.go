@@ -0,0 +1,496 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"sort"
+	"strings"
+	"sync"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/io/key"
+)
+
+// Names of actions that can be bound to keys. The names are used in the configuration file and must not change.
+const (
+	keyOpenTrace            = "open-trace"
+	keyOpenTraceNewWindow   = "open-trace-new-window"
+	keyOpenTraceNewTab      = "open-trace-new-tab"
+	keyQuit                 = "quit"
+	keyScrollToTimeline     = "scroll-to-timeline"
+	keyHighlightSpans       = "highlight-spans"
+	keyNextSearchResult     = "next-search-result"
+	keyPrevSearchResult     = "previous-search-result"
+	keyScrollToTop          = "scroll-to-top"
+	keyZoomToFit            = "zoom-to-fit"
+	keyJumpToBeginning      = "jump-to-beginning"
+	keyUndoNavigation       = "undo-navigation"
+	keyRedoNavigation       = "redo-navigation"
+	keyToggleStackTracks    = "toggle-stack-tracks"
+	keyToggleTimelineLabels = "toggle-timeline-labels"
+	keyToggleCompactDisplay = "toggle-compact-display"
+	keyCycleTooltips        = "cycle-tooltips"
+	keyCycleGCOverlays      = "cycle-gc-overlays"
+	keyInspectTime          = "inspect-time"
+	keyAddBookmark          = "add-bookmark"
+	keyHeatmapYBucketUp     = "heatmap.increase-y-bucket"
+	keyHeatmapYBucketDown   = "heatmap.decrease-y-bucket"
+	keyHeatmapXBucketDown   = "heatmap.decrease-x-bucket"
+	keyHeatmapXBucketUp     = "heatmap.increase-x-bucket"
+	keyTogglePerformanceHUD = "toggle-performance-hud"
+	keyIncreaseScale        = "increase-scale"
+	keyDecreaseScale        = "decrease-scale"
+	keyResetScale           = "reset-scale"
+)
+
+// A Keybinding describes an action that can be bound to a key.
+type Keybinding struct {
+	Name        string
+	Description string
+	Default     theme.Shortcut
+}
+
+var keybindings = []Keybinding{
+	{keyOpenTrace, "Open trace", theme.Shortcut{Modifiers: key.ModShortcut, Name: "O"}},
+	{keyOpenTraceNewWindow, "Open trace in new window", theme.Shortcut{Modifiers: key.ModShortcut | key.ModShift, Name: "O"}},
+	{keyOpenTraceNewTab, "Open trace in new tab", theme.Shortcut{Modifiers: key.ModShortcut, Name: "T"}},
+	{keyQuit, "Quit", theme.Shortcut{Modifiers: key.ModShortcut, Name: "Q"}},
+	{keyScrollToTimeline, "Scroll to timeline", theme.Shortcut{Name: "G"}},
+	{keyHighlightSpans, "Highlight spans", theme.Shortcut{Name: "H"}},
+	{keyNextSearchResult, "Go to next search result", theme.Shortcut{Name: "N"}},
+	{keyPrevSearchResult, "Go to previous search result", theme.Shortcut{Modifiers: key.ModShift, Name: "N"}},
+	{keyScrollToTop, "Scroll to top of canvas", theme.Shortcut{Name: key.NameHome}},
+	{keyZoomToFit, "Zoom to fit visible timelines", theme.Shortcut{Modifiers: key.ModShortcut, Name: key.NameHome}},
+	{keyJumpToBeginning, "Jump to beginning of timeline", theme.Shortcut{Modifiers: key.ModShift, Name: key.NameHome}},
+	{keyUndoNavigation, "Undo previous navigation", theme.Shortcut{Modifiers: key.ModShortcut, Name: "Z"}},
+	{keyRedoNavigation, "Redo navigation", theme.Shortcut{Modifiers: key.ModShortcut, Name: "Y"}},
+	{keyToggleStackTracks, "Toggle stack frames", theme.Shortcut{Name: "S"}},
+	{keyToggleTimelineLabels, "Toggle timeline labels", theme.Shortcut{Name: "X"}},
+	{keyToggleCompactDisplay, "Toggle compact display", theme.Shortcut{Name: "C"}},
+	{keyCycleTooltips, "Cycle tooltip display", theme.Shortcut{Name: "T"}},
+	{keyCycleGCOverlays, "Cycle GC overlays", theme.Shortcut{Name: "O"}},
+	{keyInspectTime, "Inspect the moment under the cursor", theme.Shortcut{Name: "I"}},
+	{keyAddBookmark, "Bookmark the moment under the cursor", theme.Shortcut{Name: "B"}},
+	{keyHeatmapYBucketUp, "Heatmap: increase bucket height", theme.Shortcut{Name: key.NameUpArrow}},
+	{keyHeatmapYBucketDown, "Heatmap: decrease bucket height", theme.Shortcut{Name: key.NameDownArrow}},
+	{keyHeatmapXBucketDown, "Heatmap: decrease bucket width", theme.Shortcut{Name: key.NameLeftArrow}},
+	{keyHeatmapXBucketUp, "Heatmap: increase bucket width", theme.Shortcut{Name: key.NameRightArrow}},
+	{keyTogglePerformanceHUD, "Toggle performance HUD", theme.Shortcut{Modifiers: key.ModShortcut | key.ModShift, Name: "P"}},
+	{keyIncreaseScale, "Increase UI scale", theme.Shortcut{Modifiers: key.ModShortcut, Name: "="}},
+	{keyDecreaseScale, "Decrease UI scale", theme.Shortcut{Modifiers: key.ModShortcut, Name: "-"}},
+	{keyResetScale, "Reset UI scale", theme.Shortcut{Modifiers: key.ModShortcut, Name: "0"}},
+}
+
+// handleScaleShortcut changes the scale of win if s is bound to one of the scale actions, and reports whether it was.
+// The caller must have registered the scale actions with the window.
+func handleScaleShortcut(win *theme.Window, s theme.Shortcut) bool {
+	switch {
+	case keymap.Matches(keyIncreaseScale, s):
+		win.SetScale(win.Scale() + theme.ScaleStep)
+	case keymap.Matches(keyDecreaseScale, s):
+		win.SetScale(win.Scale() - theme.ScaleStep)
+	case keymap.Matches(keyResetScale, s):
+		win.SetScale(1)
+	default:
+		return false
+	}
+	return true
+}
+
+// keymap holds the current keybindings. It is shared by all windows.
+var keymap = NewKeymap()
+
+// Keymap maps the names of actions to shortcuts.
+type Keymap struct {
+	mu       sync.RWMutex
+	bindings map[string]theme.Shortcut
+	// gen is incremented whenever a binding changes, allowing users to cache values derived from the keymap.
+	gen uint64
+}
+
+func NewKeymap() *Keymap {
+	km := &Keymap{bindings: map[string]theme.Shortcut{}}
+	for _, kb := range keybindings {
+		km.bindings[kb.Name] = kb.Default
+	}
+	return km
+}
+
+func (km *Keymap) Generation() uint64 {
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+	return km.gen
+}
+
+// Shortcut returns the shortcut bound to an action. Unbound actions have a shortcut with an empty name.
+func (km *Keymap) Shortcut(action string) theme.Shortcut {
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+	return km.bindings[action]
+}
+
+// Matches reports whether s is the shortcut bound to an action.
+func (km *Keymap) Matches(action string, s theme.Shortcut) bool {
+	b := km.Shortcut(action)
+	return b.Name != "" && b == s
+}
+
+// Register registers the shortcuts of actions with the window.
+func (km *Keymap) Register(win *theme.Window, actions ...string) {
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+	for _, action := range actions {
+		if s := km.bindings[action]; s.Name != "" {
+			win.AddShortcut(s)
+		}
+	}
+}
+
+// Label returns the human-readable shortcut of an action, for display in menus.
+func (km *Keymap) Label(action string) string {
+	return formatShortcut(km.Shortcut(action))
+}
+
+// KeySet returns a key set matching the shortcuts of actions, for use in key.InputOp. Shortcuts using the minus key
+// aren't part of the set, because key.Set splits chords at their last "-" and has no way of naming that key. Callers
+// have to Register the actions with the window, too, which receives all key events that no handler accepted.
+func (km *Keymap) KeySet(actions ...string) key.Set {
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+	var sets []string
+	for _, action := range actions {
+		s := km.bindings[action]
+		if s.Name == "" || s.Name == "-" {
+			continue
+		}
+		if s.Modifiers != 0 {
+			sets = append(sets, s.Modifiers.String()+"-"+s.Name)
+		} else {
+			sets = append(sets, s.Name)
+		}
+	}
+	return key.Set(strings.Join(sets, "|"))
+}
+
+func (km *Keymap) Set(action string, s theme.Shortcut) {
+	km.mu.Lock()
+	defer km.mu.Unlock()
+	km.set(action, s)
+}
+
+// set is like Set, but the caller must hold the lock.
+func (km *Keymap) set(action string, s theme.Shortcut) {
+	km.bindings[action] = s
+	km.gen++
+}
+
+func (km *Keymap) Reset(action string) {
+	for _, kb := range keybindings {
+		if kb.Name == action {
+			km.Set(action, kb.Default)
+			return
+		}
+	}
+}
+
+// Conflicts returns the descriptions of other actions that are bound to the same shortcut as action.
+func (km *Keymap) Conflicts(action string) []string {
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+	s := km.bindings[action]
+	if s.Name == "" {
+		return nil
+	}
+	var out []string
+	for _, kb := range keybindings {
+		if kb.Name != action && km.bindings[kb.Name] == s {
+			out = append(out, kb.Description)
+		}
+	}
+	return out
+}
+
+// Apply applies keybindings loaded from the configuration file. Invalid entries are skipped and reported in the
+// returned error.
+func (km *Keymap) Apply(bindings map[string]string) error {
+	names := make([]string, 0, len(bindings))
+	for name := range bindings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	km.mu.Lock()
+	defer km.mu.Unlock()
+	var errs []error
+	for _, name := range names {
+		if _, ok := km.bindings[name]; !ok {
+			errs = append(errs, fmt.Errorf("unknown action %q", name))
+			continue
+		}
+		s, err := parseShortcut(bindings[name])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid shortcut for %q: %w", name, err))
+			continue
+		}
+		km.set(name, s)
+	}
+	return errors.Join(errs...)
+}
+
+// Overrides returns the bindings that differ from the defaults, in the format used by the configuration file.
+func (km *Keymap) Overrides() map[string]string {
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+	out := map[string]string{}
+	for _, kb := range keybindings {
+		if s := km.bindings[kb.Name]; s != kb.Default {
+			out[kb.Name] = formatConfigShortcut(s)
+		}
+	}
+	return out
+}
+
+// keyNames maps human-readable key names to the names used by Gio.
+var keyNames = map[string]string{
+	"Home":      key.NameHome,
+	"End":       key.NameEnd,
+	"Up":        key.NameUpArrow,
+	"Down":      key.NameDownArrow,
+	"Left":      key.NameLeftArrow,
+	"Right":     key.NameRightArrow,
+	"PageUp":    key.NamePageUp,
+	"PageDown":  key.NamePageDown,
+	"Escape":    key.NameEscape,
+	"Return":    key.NameReturn,
+	"Enter":     key.NameEnter,
+	"Backspace": key.NameDeleteBackward,
+	"Delete":    key.NameDeleteForward,
+}
+
+// formatShortcut formats a shortcut as a string such as "Ctrl+Shift+Home". The result can be parsed by parseShortcut.
+func formatShortcut(s theme.Shortcut) string {
+	if s.Name == "" {
+		return ""
+	}
+	name := s.Name
+	for friendly, n := range keyNames {
+		if n == name {
+			name = friendly
+			break
+		}
+	}
+	if s.Modifiers == 0 {
+		return name
+	}
+	return strings.ReplaceAll(s.Modifiers.String(), "-", "+") + "+" + name
+}
+
+// formatConfigShortcut is like formatShortcut, but refers to the platform's shortcut modifier as "Short", so that
+// configuration files can be shared between platforms.
+func formatConfigShortcut(s theme.Shortcut) string {
+	if s.Name == "" || s.Modifiers&key.ModShortcut == 0 {
+		return formatShortcut(s)
+	}
+	s.Modifiers &^= key.ModShortcut
+	return "Short+" + formatShortcut(s)
+}
+
+// parseShortcut parses shortcuts of the form "Ctrl+Shift+Z". "Short" may be used to refer to the platform's shortcut
+// modifier, which is Cmd on macOS and Ctrl elsewhere. The empty string parses as the empty shortcut.
+func parseShortcut(str string) (theme.Shortcut, error) {
+	if str == "" {
+		return theme.Shortcut{}, nil
+	}
+	parts := strings.Split(str, "+")
+	var s theme.Shortcut
+	for _, mod := range parts[:len(parts)-1] {
+		switch mod {
+		case "Short":
+			s.Modifiers |= key.ModShortcut
+		case key.NameCtrl:
+			s.Modifiers |= key.ModCtrl
+		case key.NameShift:
+			s.Modifiers |= key.ModShift
+		case key.NameAlt:
+			s.Modifiers |= key.ModAlt
+		case key.NameSuper:
+			s.Modifiers |= key.ModSuper
+		case key.NameCommand, "Cmd":
+			s.Modifiers |= key.ModCommand
+		default:
+			return theme.Shortcut{}, fmt.Errorf("unknown modifier %q", mod)
+		}
+	}
+	name := parts[len(parts)-1]
+	if n, ok := keyNames[name]; ok {
+		name = n
+	}
+	if name == "" {
+		return theme.Shortcut{}, errors.New("missing key")
+	}
+	s.Name = strings.ToUpper(name)
+	if len([]rune(name)) > 1 {
+		// Only single letters are case-insensitive.
+		s.Name = name
+	}
+	return s, nil
+}
+
+// recordableModifiers are the modifiers that can be part of a shortcut recorded in the keyboard shortcuts dialog.
+const recordableModifiers = key.ModCtrl | key.ModShift | key.ModAlt | key.ModSuper | key.ModCommand
+
+// recordableKeys is the set of keys that can be bound in the keyboard shortcuts dialog, other than the minus key,
+// which a key.Set can't contain.
+var recordableKeys = func() key.Set {
+	const mods = "(Ctrl)-(Shift)-(Alt)-(Super)-(" + key.NameCommand + ")-"
+	keys := []string{
+		key.NameHome, key.NameEnd, key.NameUpArrow, key.NameDownArrow, key.NameLeftArrow, key.NameRightArrow,
+		key.NamePageUp, key.NamePageDown, key.NameEscape, key.NameReturn, key.NameEnter, key.NameDeleteBackward,
+		key.NameDeleteForward, key.NameTab, key.NameSpace,
+		"=", ".", "/", ";", "'", "[", "]", "\\", "`",
+	}
+	for c := 'A'; c <= 'Z'; c++ {
+		keys = append(keys, string(c))
+	}
+	for c := '0'; c <= '9'; c++ {
+		keys = append(keys, string(c))
+	}
+	for i := 1; i <= 12; i++ {
+		keys = append(keys, fmt.Sprintf("F%d", i))
+	}
+	// The comma separates the keys of a bracketed set and needs a chord of its own.
+	return key.Set(mods + "[" + strings.Join(keys, ",") + "]|" + mods + ",")
+}()
+
+// KeybindingsDialog lists all actions and lets the user change their shortcuts. Changes are saved to the
+// configuration file immediately.
+type KeybindingsDialog struct {
+	list widget.List
+	rows []struct {
+		change widget.PrimaryClickable
+		reset  widget.PrimaryClickable
+	}
+	// The index of the action whose shortcut is being recorded, or -1.
+	recording int
+}
+
+func NewKeybindingsDialog() *KeybindingsDialog {
+	kd := &KeybindingsDialog{recording: -1}
+	kd.list.Axis = layout.Vertical
+	kd.rows = make([]struct {
+		change widget.PrimaryClickable
+		reset  widget.PrimaryClickable
+	}, len(keybindings))
+	return kd
+}
+
+func (kd *KeybindingsDialog) save(win *theme.Window) {
+	userConfigMu.Lock()
+	defer userConfigMu.Unlock()
+	userConfig.Keybindings = keymap.Overrides()
+	if err := userConfig.Save(); err != nil {
+		win.Notify(theme.NotificationError, local.Sprintf("Couldn't save configuration: %s", err))
+	}
+}
+
+func (kd *KeybindingsDialog) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	for i := range kd.rows {
+		row := &kd.rows[i]
+		for row.change.Clicked(gtx) {
+			kd.recording = i
+		}
+		for row.reset.Clicked(gtx) {
+			keymap.Reset(keybindings[i].Name)
+			kd.save(win)
+		}
+	}
+
+	record := func(s theme.Shortcut) {
+		if kd.recording == -1 {
+			return
+		}
+		if s.Name == key.NameEscape && s.Modifiers == 0 {
+			// Escape cancels recording. Shift+Escape and others can still be bound.
+			kd.recording = -1
+			return
+		}
+		keymap.Set(keybindings[kd.recording].Name, s)
+		kd.recording = -1
+		kd.save(win)
+	}
+	for _, ev := range gtx.Events(&kd.recording) {
+		if ev, ok := ev.(key.Event); ok && ev.State == key.Press {
+			record(theme.Shortcut{Modifiers: ev.Modifiers, Name: ev.Name})
+		}
+	}
+	// The minus key can't be part of recordableKeys and reaches the window instead.
+	for _, s := range win.PressedShortcuts() {
+		if s.Name == "-" {
+			record(s)
+		}
+	}
+	if kd.recording != -1 {
+		key.InputOp{Tag: &kd.recording, Keys: recordableKeys}.Add(gtx.Ops)
+		key.FocusOp{Tag: &kd.recording}.Add(gtx.Ops)
+		for mods := key.Modifiers(0); mods <= recordableModifiers; mods++ {
+			if mods&^recordableModifiers == 0 {
+				win.AddShortcut(theme.Shortcut{Modifiers: mods, Name: "-"})
+			}
+		}
+	}
+
+	return theme.List(win.Theme, &kd.list).Layout(win, gtx, len(keybindings), func(gtx layout.Context, index int) layout.Dimensions {
+		kb := &keybindings[index]
+		row := &kd.rows[index]
+
+		var binding string
+		if kd.recording == index {
+			binding = "Press a key…"
+		} else if binding = keymap.Label(kb.Name); binding == "" {
+			binding = "Unbound"
+		}
+
+		return layout.Rigids(gtx, layout.Horizontal,
+			func(gtx layout.Context) layout.Dimensions {
+				gtx.Constraints.Min.X = gtx.Dp(300)
+				gtx.Constraints.Max.X = gtx.Constraints.Min.X
+				return theme.LineLabel(win.Theme, kb.Description).Layout(win, gtx)
+			},
+			func(gtx layout.Context) layout.Dimensions {
+				gtx.Constraints.Min.X = gtx.Dp(150)
+				gtx.Constraints.Max.X = gtx.Constraints.Min.X
+				return theme.Button(win.Theme, &row.change.Clickable, binding).Layout(win, gtx)
+			},
+			func(gtx layout.Context) layout.Dimensions {
+				return layout.Spacer{Width: 5}.Layout(gtx)
+			},
+			func(gtx layout.Context) layout.Dimensions {
+				return theme.Button(win.Theme, &row.reset.Clickable, tr("Reset")).Layout(win, gtx)
+			},
+			func(gtx layout.Context) layout.Dimensions {
+				conflicts := keymap.Conflicts(kb.Name)
+				if len(conflicts) == 0 {
+					return layout.Dimensions{}
+				}
+				l := theme.LineLabel(win.Theme, local.Sprintf("  Also bound to: %s", strings.Join(conflicts, ", ")))
+				l.Color = colors[colorStateBlocked]
+				return l.Layout(win, gtx)
+			},
+		)
+	})
+}
+
+func displayKeybindingsDialog(win *theme.Window) {
+	kd := NewKeybindingsDialog()
+	win.SetModal(func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+		return theme.Dialog(win.Theme, tr("Keyboard shortcuts")).Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+			gtx.Constraints.Min = gtx.Constraints.Constrain(image.Pt(1000, 500))
+			gtx.Constraints.Max = gtx.Constraints.Min
+			return kd.Layout(win, gtx)
+		})
+	})
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"testing"
+
+	"honnef.co/go/gotraceui/theme"
+
+	"gioui.org/io/key"
+)
+
+func TestKeymapOverrides(t *testing.T) {
+	km := NewKeymap()
+	if err := km.Apply(map[string]string{
+		keyQuit:           "Short+Shift+Q",
+		keyHighlightSpans: "Alt+Home",
+		"no-such-action":  "A",
+	}); err == nil {
+		t.Error("applying an unknown action succeeded")
+	}
+	if got, want := km.Shortcut(keyQuit), (theme.Shortcut{Modifiers: key.ModShortcut | key.ModShift, Name: "Q"}); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	got := km.Overrides()
+	want := map[string]string{
+		keyQuit:           "Short+Shift+Q",
+		keyHighlightSpans: "Alt+Home",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got overrides %v, want %v", got, want)
+	}
+	for name, s := range want {
+		if got[name] != s {
+			t.Errorf("%s: got %q, want %q", name, got[name], s)
+		}
+	}
+
+	// The overrides parse as the same bindings.
+	km2 := NewKeymap()
+	if err := km2.Apply(got); err != nil {
+		t.Fatal(err)
+	}
+	for name := range want {
+		if km2.Shortcut(name) != km.Shortcut(name) {
+			t.Errorf("%s: got %v after round trip, want %v", name, km2.Shortcut(name), km.Shortcut(name))
+		}
+	}
+}
+
+func TestKeySet(t *testing.T) {
+	km := NewKeymap()
+	km.Set(keyIncreaseScale, theme.Shortcut{Modifiers: key.ModCtrl, Name: "="})
+	km.Set(keyDecreaseScale, theme.Shortcut{Modifiers: key.ModCtrl, Name: "-"})
+	km.Set(keyResetScale, theme.Shortcut{Modifiers: key.ModCtrl, Name: ","})
+	km.Set(keyQuit, theme.Shortcut{Name: "Q"})
+	ks := km.KeySet(keyIncreaseScale, keyDecreaseScale, keyResetScale, keyQuit)
+
+	tests := []struct {
+		name string
+		mods key.Modifiers
+		want bool
+	}{
+		{"=", key.ModCtrl, true},
+		{",", key.ModCtrl, true},
+		{"Q", 0, true},
+		{"=", 0, false},
+		{"Q", key.ModCtrl, false},
+		// The minus key is delivered to the window instead.
+		{"-", key.ModCtrl, false},
+	}
+	for _, tt := range tests {
+		if got := ks.Contains(tt.name, tt.mods); got != tt.want {
+			t.Errorf("%q: Contains(%q, %v) = %t, want %t", ks, tt.name, tt.mods, got, tt.want)
+		}
+	}
+}
+
+func TestRecordableKeys(t *testing.T) {
+	for _, name := range []string{"A", "0", "F12", key.NameHome, "=", ",", ".", "[", "]", "\\", "`"} {
+		for _, mods := range []key.Modifiers{0, key.ModCtrl, key.ModCtrl | key.ModShift, key.ModAlt | key.ModSuper} {
+			if !recordableKeys.Contains(name, mods) {
+				t.Errorf("can't record %v-%s", mods, name)
+			}
+		}
+	}
+}
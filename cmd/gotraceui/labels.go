@@ -21,6 +21,7 @@ var spanStateLabels = [...][]string{
 	ptrace.StateBlockedNet:              {"I/O"},
 	ptrace.StateBlockedGC:               {"GC assist wait", "W"},
 	ptrace.StateBlockedSyscall:          {"syscall"},
+	ptrace.StateCgo:                     {"cgo"},
 	ptrace.StateStuck:                   {"stuck"},
 	ptrace.StateReady:                   {"ready"},
 	ptrace.StateWaitingPreempted:        {"preempted"},
@@ -50,6 +51,7 @@ var tooltipStateLabels = [ptrace.StateLast]string{
 	ptrace.StateBlockedNet:              "State: blocked on polled I/O",
 	ptrace.StateBlockedGC:               "State: GC assist wait",
 	ptrace.StateBlockedSyscall:          "State: blocked on syscall",
+	ptrace.StateCgo:                     "State: in cgo call",
 	ptrace.StateStuck:                   "State: stuck",
 	ptrace.StateReady:                   "State: ready",
 	ptrace.StateWaitingPreempted:        "State: preempted",
@@ -81,6 +83,7 @@ var stateNames = [ptrace.StateLast]string{
 	ptrace.StateBlockedNet:              "blocked (pollable I/O)",
 	ptrace.StateBlockedGC:               "blocked (GC)",
 	ptrace.StateBlockedSyscall:          "blocked (syscall)",
+	ptrace.StateCgo:                     "cgo call",
 	ptrace.StateStuck:                   "stuck",
 	ptrace.StateReady:                   "ready",
 	ptrace.StateWaitingPreempted:        "preempted",
@@ -114,6 +117,7 @@ var stateNamesCapitalized = [ptrace.StateLast]string{
 	ptrace.StateBlockedNet:              "Blocked (pollable I/O)",
 	ptrace.StateBlockedGC:               "Blocked (GC)",
 	ptrace.StateBlockedSyscall:          "Blocked (syscall)",
+	ptrace.StateCgo:                     "Cgo call",
 	ptrace.StateStuck:                   "Stuck",
 	ptrace.StateReady:                   "Ready",
 	ptrace.StateWaitingPreempted:        "Preempted",
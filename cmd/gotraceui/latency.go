@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	rtrace "runtime/trace"
+	"slices"
+	"time"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/trace/ptrace"
+	"honnef.co/go/gotraceui/widget"
+)
+
+// latencyBlockedStates are the states that GoroutineLatencies displays blocked durations for, one histogram per
+// state.
+var latencyBlockedStates = []ptrace.SchedulingState{
+	ptrace.StateBlocked,
+	ptrace.StateBlockedSend,
+	ptrace.StateBlockedRecv,
+	ptrace.StateBlockedSelect,
+	ptrace.StateBlockedSync,
+	ptrace.StateBlockedSyncOnce,
+	ptrace.StateBlockedSyncTriggeringGC,
+	ptrace.StateBlockedCond,
+	ptrace.StateBlockedNet,
+	ptrace.StateBlockedGC,
+	ptrace.StateBlockedSyscall,
+}
+
+// A latencyGroup is a set of a goroutine's spans whose durations are displayed in a single histogram.
+type latencyGroup struct {
+	name  string
+	spans []ptrace.Span
+	hist  InteractiveHistogram
+
+	initialized bool
+}
+
+// compute updates the histogram to show the durations that fall into its configured range and returns the spans with
+// those durations.
+func (grp *latencyGroup) compute(win *theme.Window) []ptrace.Span {
+	cfg := &grp.hist.Config
+	var ds []time.Duration
+	var matched []ptrace.Span
+	for _, s := range grp.spans {
+		d := s.Duration()
+		if fd := widget.FloatDuration(d); fd >= cfg.Start && (cfg.End == 0 || fd <= cfg.End) {
+			ds = append(ds, d)
+			matched = append(matched, s)
+		}
+	}
+	grp.hist.Set(win, ds)
+	return matched
+}
+
+// GoroutineLatencies displays histograms of how long a goroutine waited to be scheduled after becoming runnable, and
+// of how long it was blocked, by reason. Selecting a range of durations zooms the canvas to the matching spans and
+// highlights them.
+type GoroutineLatencies struct {
+	mwin      *theme.Window
+	g         *ptrace.Goroutine
+	container ItemContainer
+	groups    []*latencyGroup
+	tabs      []string
+
+	tabbedState theme.TabbedState
+}
+
+func NewGoroutineLatencies(mwin *theme.Window, canvas *Canvas, g *ptrace.Goroutine) *GoroutineLatencies {
+	gl := &GoroutineLatencies{
+		mwin: mwin,
+		g:    g,
+	}
+	if tl := canvas.itemToTimeline[g]; tl != nil {
+		gl.container = ItemContainer{Timeline: tl, Track: tl.tracks[0]}
+	}
+
+	// Ready and preempted goroutines are runnable and waiting for a processor.
+	sched := &latencyGroup{name: "Scheduling latency"}
+	byState := map[ptrace.SchedulingState]*latencyGroup{
+		ptrace.StateReady:            sched,
+		ptrace.StateWaitingPreempted: sched,
+	}
+	gl.groups = append(gl.groups, sched)
+	for _, state := range latencyBlockedStates {
+		grp := &latencyGroup{name: stateNamesCapitalized[state]}
+		byState[state] = grp
+		gl.groups = append(gl.groups, grp)
+	}
+	for _, s := range g.Spans {
+		if grp := byState[s.State]; grp != nil {
+			grp.spans = append(grp.spans, s)
+		}
+	}
+	gl.groups = slices.DeleteFunc(gl.groups, func(grp *latencyGroup) bool { return len(grp.spans) == 0 })
+	for _, grp := range gl.groups {
+		gl.tabs = append(gl.tabs, grp.name)
+	}
+	return gl
+}
+
+// Title implements theme.Component.
+func (gl *GoroutineLatencies) Title() string {
+	return local.Sprintf("Latencies of goroutine %d", gl.g.ID)
+}
+
+// Transition implements theme.Component.
+func (*GoroutineLatencies) Transition(state theme.ComponentState) {}
+
+// WantsTransition implements theme.Component.
+func (*GoroutineLatencies) WantsTransition(gtx layout.Context) theme.ComponentState {
+	return theme.ComponentStateNone
+}
+
+func (*GoroutineLatencies) HoveredLink() ObjectLink { return nil }
+
+// Layout implements theme.Component.
+func (gl *GoroutineLatencies) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.GoroutineLatencies.Layout").End()
+
+	if len(gl.groups) == 0 {
+		return layout.Center.Layout(gtx, theme.Dumb(win, theme.Label(win.Theme, tr("The goroutine was never runnable or blocked.")).Layout))
+	}
+
+	for _, grp := range gl.groups {
+		if !grp.initialized {
+			grp.initialized = true
+			grp.hist.Config = widget.HistogramConfig{RejectOutliers: true, Bins: widget.DefaultHistogramBins}
+			grp.compute(win)
+		}
+
+		start, end := grp.hist.Config.Start, grp.hist.Config.End
+		if grp.hist.Update(gtx) {
+			matched := grp.compute(win)
+			cfg := grp.hist.Config
+			if cfg.Start == start && cfg.End == end {
+				continue
+			}
+			if cfg.End == 0 {
+				gl.mwin.EmitAction(&BrushSpansAction{})
+				continue
+			}
+			items := SimpleItems[ptrace.Span, any]{items: matched, container: gl.container}
+			gl.mwin.EmitAction(&BrushSpansAction{Brush: brushSpans(items, cfg.Start.Floor(), cfg.End.Ceil())})
+			if len(matched) != 0 && gl.container.Timeline != nil {
+				gl.mwin.EmitAction(&ZoomToSpansAction{Spans: items})
+			}
+		}
+	}
+
+	return layout.Rigids(gtx, layout.Vertical,
+		theme.Dumb(win, theme.LineLabel(win.Theme, tr("Select a range of durations with Ctrl+drag to zoom to and highlight the matching spans.")).Layout),
+		layout.Spacer{Height: 5}.Layout,
+		func(gtx layout.Context) layout.Dimensions {
+			return theme.Tabbed(&gl.tabbedState, gl.tabs).Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+				gtx.Constraints.Min = gtx.Constraints.Max
+				return gl.groups[gl.tabbedState.Current].hist.Layout(win, gtx)
+			})
+		},
+	)
+}
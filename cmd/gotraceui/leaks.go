@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	rtrace "runtime/trace"
+	"slices"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/trace/ptrace"
+	myunsafe "honnef.co/go/gotraceui/unsafe"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/font"
+	"gioui.org/op/clip"
+	"gioui.org/text"
+	exptrace "golang.org/x/exp/trace"
+)
+
+// The number of buckets in a leak group's growth sparkline.
+const leakGrowthBuckets = 50
+
+// A leakGroup is a group of possibly leaked goroutines that were created at the same place.
+type leakGroup struct {
+	// The name of the function containing the go statement, or the empty string if the creation stack is unknown.
+	Creator string
+	// The function of the goroutines, or nil if they don't agree.
+	Function *ptrace.Function
+	// The state the goroutines were blocked in at the end of the trace, or StateNone if they don't agree.
+	State      ptrace.SchedulingState
+	Goroutines []*ptrace.Goroutine
+	// Growth is the cumulative number of the group's goroutines that had been created by the end of each of
+	// leakGrowthBuckets buckets.
+	Growth []float64
+}
+
+type leakedGoroutinesKey struct{}
+
+// isLeakState reports whether a goroutine that is in the state at the end of the trace might have leaked. We don't
+// consider goroutines blocked in syscalls or on the GC, as those states don't last forever.
+func isLeakState(state ptrace.SchedulingState) bool {
+	switch state {
+	case ptrace.StateBlocked, ptrace.StateBlockedSend, ptrace.StateBlockedRecv, ptrace.StateBlockedSelect,
+		ptrace.StateBlockedSync, ptrace.StateBlockedSyncOnce, ptrace.StateBlockedCond, ptrace.StateBlockedNet:
+		return true
+	default:
+		return false
+	}
+}
+
+// leakedGoroutines finds goroutines that were created during the trace, didn't return, and were blocked at the end of
+// the trace. It groups them by the stacks they were created at, ordered by the number of goroutines, descending.
+func leakedGoroutines(tr *Trace) []leakGroup {
+	defer rtrace.StartRegion(context.Background(), "main.leakedGoroutines").End()
+
+	groups := map[string]*leakGroup{}
+	var keys []string
+	for _, g := range tr.Goroutines {
+		if len(g.Spans) == 0 || !g.Start.Set() || g.End.Set() {
+			continue
+		}
+		last := g.Spans[len(g.Spans)-1].State
+		if !isLeakState(last) || g.Spans[0].State != ptrace.StateCreated {
+			continue
+		}
+
+		var pcs []uint64
+		if stk := tr.Event(g.Spans[0].StartEvent).Stack(); stk != exptrace.NoStack {
+			pcs = tr.Stacks[tr.StackID(stk)]
+		}
+		key := string(myunsafe.SliceCast[[]byte](pcs))
+		group, ok := groups[key]
+		if !ok {
+			group = &leakGroup{
+				Function: g.Function,
+				State:    last,
+			}
+			if len(pcs) > 0 {
+				group.Creator = tr.FuncName(pcs[0])
+			}
+			groups[key] = group
+			keys = append(keys, key)
+		}
+		if group.Function != g.Function {
+			group.Function = nil
+		}
+		if group.State != last {
+			group.State = ptrace.StateNone
+		}
+		group.Goroutines = append(group.Goroutines, g)
+	}
+
+	out := make([]leakGroup, 0, len(groups))
+	start, end := tr.Start(), tr.End()
+	width := float64(end-start) / leakGrowthBuckets
+	for _, key := range keys {
+		group := groups[key]
+		group.Growth = make([]float64, leakGrowthBuckets)
+		for _, g := range group.Goroutines {
+			i := int(float64(g.Start.MustGet()-start) / width)
+			group.Growth[min(max(i, 0), leakGrowthBuckets-1)]++
+		}
+		for i := 1; i < len(group.Growth); i++ {
+			group.Growth[i] += group.Growth[i-1]
+		}
+		out = append(out, *group)
+	}
+	slices.SortStableFunc(out, func(a, b leakGroup) int {
+		return len(b.Goroutines) - len(a.Goroutines)
+	})
+	return out
+}
+
+// LeaksComponent displays goroutines that might have leaked, grouped by where they were created. Selecting a group
+// lists its goroutines.
+type LeaksComponent struct {
+	trace  *Trace
+	groups *theme.Future[[]leakGroup]
+
+	table         *theme.Table
+	scrollState   theme.YScrollableListState
+	cellFormatter CellFormatter
+	selects       []widget.PrimaryClickable
+
+	selected  int
+	split     theme.SplitterState
+	goroutine GoroutineList
+}
+
+func NewLeaksComponent(win *theme.Window, tr *Trace) *LeaksComponent {
+	return &LeaksComponent{
+		trace: tr,
+		groups: theme.Compute(&tr.analyses, win, leakedGoroutinesKey{}, func() []leakGroup {
+			return leakedGoroutines(tr)
+		}),
+		selected: -1,
+		split: theme.SplitterState{
+			Axis:  layout.Vertical,
+			Ratio: 0.5,
+		},
+		goroutine: GoroutineList{Trace: tr},
+	}
+}
+
+// Title implements theme.Component.
+func (*LeaksComponent) Title() string {
+	return tr("Leaked goroutines")
+}
+
+// Transition implements theme.Component.
+func (*LeaksComponent) Transition(state theme.ComponentState) {}
+
+// WantsTransition implements theme.Component.
+func (*LeaksComponent) WantsTransition(gtx layout.Context) theme.ComponentState {
+	return theme.ComponentStateNone
+}
+
+func (lc *LeaksComponent) HoveredLink() ObjectLink {
+	if l := lc.cellFormatter.HoveredLink(); l != nil {
+		return l
+	}
+	if lc.selected != -1 {
+		return lc.goroutine.HoveredLink()
+	}
+	return nil
+}
+
+func (lc *LeaksComponent) initTable(win *theme.Window, gtx layout.Context) {
+	if lc.table != nil {
+		return
+	}
+	lc.table = &theme.Table{}
+	lc.table.SetColumns(win, gtx, []theme.Column{
+		{Name: "Goroutines", Alignment: text.End},
+		{Name: "Created by", Alignment: text.Start},
+		{Name: "Function", Alignment: text.Start},
+		{Name: "Blocked in", Alignment: text.Start},
+		{Name: "Growth", Alignment: text.Start},
+	})
+}
+
+// Layout implements theme.Component.
+func (lc *LeaksComponent) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.LeaksComponent.Layout").End()
+
+	groups, ok := lc.groups.Result()
+	if !ok {
+		return theme.Loading(win.Theme, tr("Looking for leaked goroutines…")).Layout(win, gtx)
+	}
+	if len(groups) == 0 {
+		return layout.Center.Layout(gtx, theme.Dumb(win, theme.Label(win.Theme, tr("No goroutines were blocked at the end of the trace after having been created during it.")).Layout))
+	}
+
+	lc.initTable(win, gtx)
+	lc.table.Update(gtx)
+	lc.cellFormatter.Update(win, gtx)
+	if len(lc.selects) != len(groups) {
+		lc.selects = make([]widget.PrimaryClickable, len(groups))
+	}
+	for i := range lc.selects {
+		if lc.selects[i].Clicked(gtx) {
+			lc.selected = i
+			lc.goroutine.SetGoroutines(win, gtx, groups[i].Goroutines)
+		}
+	}
+
+	cellFn := func(win *theme.Window, gtx layout.Context, row, col int) layout.Dimensions {
+		defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+
+		group := &groups[row]
+		switch colName := lc.table.Columns[col].Name; colName {
+		case "Goroutines":
+			return layout.RightAligned(gtx, func(gtx layout.Context) layout.Dimensions {
+				return lc.selects[row].Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+					c := win.Theme.Palette.Link
+					if row == lc.selected {
+						c = win.Theme.Palette.Foreground
+					}
+					return widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, font.Font{}, win.Theme.TextSize, local.Sprintf("%d", len(group.Goroutines)), win.ColorMaterial(gtx, c))
+				})
+			})
+		case "Created by":
+			if group.Creator == "" {
+				return lc.cellFormatter.Text(win, gtx, "unknown")
+			}
+			// Only functions that goroutines start in have Function objects.
+			if fn, ok := lc.trace.Functions[group.Creator]; ok {
+				return lc.cellFormatter.Function(win, gtx, fn)
+			}
+			return lc.cellFormatter.Text(win, gtx, group.Creator)
+		case "Function":
+			if group.Function == nil {
+				return lc.cellFormatter.Text(win, gtx, "various")
+			}
+			return lc.cellFormatter.Function(win, gtx, group.Function)
+		case "Blocked in":
+			if group.State == ptrace.StateNone {
+				return lc.cellFormatter.Text(win, gtx, "various")
+			}
+			return lc.cellFormatter.Text(win, gtx, stateNamesCapitalized[group.State])
+		case "Growth":
+			return theme.Sparkline(win.Theme, group.Growth).Layout(win, gtx)
+		default:
+			panic(colName)
+		}
+	}
+
+	groupsTable := func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+		return theme.SimpleTable(win, gtx, lc.table, &lc.scrollState, len(groups), cellFn)
+	}
+	if lc.selected == -1 {
+		return groupsTable(win, gtx)
+	}
+	return theme.Splitter(win.Theme, &lc.split).Layout(win, gtx, groupsTable, lc.goroutine.Layout)
+}
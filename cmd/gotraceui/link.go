@@ -68,11 +68,22 @@ type OpenGoroutineFlameGraphAction struct {
 	Goroutine  *ptrace.Goroutine
 	Provenance string
 }
+type OpenGoroutineLatenciesAction struct {
+	Goroutine  *ptrace.Goroutine
+	Provenance string
+}
+type OpenStackAction struct {
+	Stack exptrace.Stack
+	Title string
+}
 type OpenTaskAction struct {
 	Task       *ptrace.Task
 	Provenance string
 }
 type ScrollToTimestampAction exptrace.Time
+
+// ShowTimestampAction is like ScrollToTimestampAction, but also switches to the timelines tab.
+type ShowTimestampAction exptrace.Time
 type OpenFunctionAction struct {
 	Function   *ptrace.Function
 	Provenance string
@@ -81,6 +92,11 @@ type SpansAction struct{ Spans Items[ptrace.Span] }
 type OpenSpansAction SpansAction
 type ScrollAndPanToSpansAction SpansAction
 type ZoomToSpansAction SpansAction
+
+// ZoomToTimeRangeAction zooms the canvas to a range of time, keeping the vertical scroll position.
+type ZoomToTimeRangeAction struct {
+	Start, End exptrace.Time
+}
 type ScrollToTimelineAction struct {
 	Timeline   *Timeline
 	Provenance string
@@ -118,8 +134,36 @@ type StopCPUProfileAction struct{}
 type OpenPanelAction struct {
 	Panel Panel
 }
+type OpenInEditorAction struct {
+	File string
+	Line uint64
+}
+type OpenSourceAction OpenInEditorAction
+type OpenTraceFileAction struct {
+	Path string
+}
 type PrevPanelAction struct{}
 
+// FilterGoroutineAction adds a goroutine to the global filter.
+type FilterGoroutineAction struct {
+	Goroutine *ptrace.Goroutine
+}
+
+// BrushSpansAction highlights the spans matched by Brush on the canvas, replacing the previous brush.
+type BrushSpansAction struct {
+	Brush SpanBrush
+}
+
+// SelectObjectAction selects an object, or clears the selection if Object is nil.
+type SelectObjectAction struct {
+	Object any
+}
+type CopyObjectAction struct {
+	// Object is a goroutine, task, spans, timestamp, or record.
+	Object any
+	Format CopyFormat
+}
+
 type GoroutineObjectLink struct {
 	Goroutine  *ptrace.Goroutine
 	Provenance string
@@ -151,16 +195,24 @@ type TaskObjectLink struct {
 	Task       *ptrace.Task
 	Provenance string
 }
+type SourceLocationObjectLink struct {
+	File string
+	Line uint64
+}
 
 func (*OpenGoroutineAction) IsAction()              {}
 func (*OpenGoroutineFlameGraphAction) IsAction()    {}
+func (*OpenGoroutineLatenciesAction) IsAction()     {}
+func (*OpenStackAction) IsAction()                  {}
 func (*OpenTaskAction) IsAction()                   {}
 func (ScrollToTimestampAction) IsAction()           {}
+func (ShowTimestampAction) IsAction()               {}
 func (*OpenFunctionAction) IsAction()               {}
 func (*SpansAction) IsAction()                      {}
 func (*OpenSpansAction) IsAction()                  {}
 func (*ScrollAndPanToSpansAction) IsAction()        {}
 func (*ZoomToSpansAction) IsAction()                {}
+func (*ZoomToTimeRangeAction) IsAction()            {}
 func (*ScrollToTimelineAction) IsAction()           {}
 func (*ZoomToTimelineAction) IsAction()             {}
 func (*ScrollToObjectAction) IsAction()             {}
@@ -185,6 +237,13 @@ func (*StartCPUProfileAction) IsAction()            {}
 func (*StopCPUProfileAction) IsAction()             {}
 func (*OpenPanelAction) IsAction()                  {}
 func (*PrevPanelAction) IsAction()                  {}
+func (*OpenInEditorAction) IsAction()               {}
+func (*OpenSourceAction) IsAction()                 {}
+func (*OpenTraceFileAction) IsAction()              {}
+func (*CopyObjectAction) IsAction()                 {}
+func (*SelectObjectAction) IsAction()               {}
+func (*FilterGoroutineAction) IsAction()            {}
+func (*BrushSpansAction) IsAction()                 {}
 
 func defaultObjectLink(obj any, provenance string) ObjectLink {
 	switch obj := obj.(type) {
@@ -227,9 +286,9 @@ func (l *GoroutineObjectLink) Action(mods key.Modifiers) theme.Action {
 }
 
 func (l *GoroutineObjectLink) ContextMenu() []*theme.MenuItem {
-	return []*theme.MenuItem{
+	items := []*theme.MenuItem{
 		{
-			Label: PlainLabel("Scroll to goroutine"),
+			Label: PlainLabel(tr("Scroll to goroutine")),
 			Action: func() theme.Action {
 				return &ScrollToObjectAction{
 					Object:     l.Goroutine,
@@ -238,7 +297,7 @@ func (l *GoroutineObjectLink) ContextMenu() []*theme.MenuItem {
 			},
 		},
 		{
-			Label: PlainLabel("Zoom to goroutine"),
+			Label: PlainLabel(tr("Zoom to goroutine")),
 			Action: func() theme.Action {
 				return &ZoomToObjectAction{
 					Object:     l.Goroutine,
@@ -247,18 +306,43 @@ func (l *GoroutineObjectLink) ContextMenu() []*theme.MenuItem {
 			},
 		},
 		{
-			Label: PlainLabel("Show goroutine information"),
+			Label: PlainLabel(tr("Show goroutine information")),
 			Action: func() theme.Action {
 				return (*OpenGoroutineAction)(l)
 			},
 		},
 		{
-			Label: PlainLabel("Open flame graph"),
+			Label: PlainLabel(tr("Open flame graph")),
 			Action: func() theme.Action {
 				return (*OpenGoroutineFlameGraphAction)(l)
 			},
 		},
+		{
+			Label: PlainLabel(tr("Show latency histograms")),
+			Action: func() theme.Action {
+				return (*OpenGoroutineLatenciesAction)(l)
+			},
+		},
+		{
+			Label: PlainLabel(tr("Select goroutine")),
+			Action: func() theme.Action {
+				return &SelectObjectAction{Object: l.Goroutine}
+			},
+		},
+		{
+			Label: PlainLabel(tr("Clear selection")),
+			Action: func() theme.Action {
+				return &SelectObjectAction{}
+			},
+		},
+		{
+			Label: PlainLabel(tr("Filter all views to goroutine")),
+			Action: func() theme.Action {
+				return &FilterGoroutineAction{Goroutine: l.Goroutine}
+			},
+		},
 	}
+	return contextMenu(l.Goroutine, items)
 }
 
 func (l *ProcessorObjectLink) Action(mods key.Modifiers) theme.Action {
@@ -278,9 +362,9 @@ func (l *ProcessorObjectLink) Action(mods key.Modifiers) theme.Action {
 }
 
 func (l *ProcessorObjectLink) ContextMenu() []*theme.MenuItem {
-	return []*theme.MenuItem{
+	return contextMenu(l.Processor, []*theme.MenuItem{
 		{
-			Label: PlainLabel("Scroll to processor"),
+			Label: PlainLabel(tr("Scroll to processor")),
 			Action: func() theme.Action {
 				return &ScrollToObjectAction{
 					Object:     l.Processor,
@@ -289,7 +373,7 @@ func (l *ProcessorObjectLink) ContextMenu() []*theme.MenuItem {
 			},
 		},
 		{
-			Label: PlainLabel("Zoom to processor"),
+			Label: PlainLabel(tr("Zoom to processor")),
 			Action: func() theme.Action {
 				return &ZoomToObjectAction{
 					Object:     l.Processor,
@@ -297,7 +381,7 @@ func (l *ProcessorObjectLink) ContextMenu() []*theme.MenuItem {
 				}
 			},
 		},
-	}
+	})
 }
 
 func (l *TimestampObjectLink) Action(mods key.Modifiers) theme.Action {
@@ -305,7 +389,7 @@ func (l *TimestampObjectLink) Action(mods key.Modifiers) theme.Action {
 }
 
 func (l *TimestampObjectLink) ContextMenu() []*theme.MenuItem {
-	return nil
+	return contextMenu(l.Timestamp, nil)
 }
 
 func (l *FunctionObjectLink) Action(mods key.Modifiers) theme.Action {
@@ -313,7 +397,62 @@ func (l *FunctionObjectLink) Action(mods key.Modifiers) theme.Action {
 }
 
 func (l *FunctionObjectLink) ContextMenu() []*theme.MenuItem {
-	return nil
+	return contextMenu(l.Function, []*theme.MenuItem{
+		{
+			Label: PlainLabel(tr("Show function information")),
+			Action: func() theme.Action {
+				return (*OpenFunctionAction)(l)
+			},
+		},
+		newOpenSourceMenuItem(l.Function.File, l.Function.Line),
+		newOpenInEditorMenuItem(l.Function.File, l.Function.Line),
+	})
+}
+
+func (l *SourceLocationObjectLink) Action(mods key.Modifiers) theme.Action {
+	switch mods {
+	default:
+		return (*OpenSourceAction)(l)
+	case key.ModShortcut:
+		return (*OpenInEditorAction)(l)
+	}
+}
+
+func (l *SourceLocationObjectLink) ContextMenu() []*theme.MenuItem {
+	return contextMenu(*l, []*theme.MenuItem{
+		newOpenSourceMenuItem(l.File, l.Line),
+		newOpenInEditorMenuItem(l.File, l.Line),
+	})
+}
+
+func newOpenSourceMenuItem(file string, line uint64) *theme.MenuItem {
+	return &theme.MenuItem{
+		Label: PlainLabel(tr("Show source")),
+		Disabled: func() bool {
+			return file == ""
+		},
+		Action: func() theme.Action {
+			return &OpenSourceAction{
+				File: file,
+				Line: line,
+			}
+		},
+	}
+}
+
+func newOpenInEditorMenuItem(file string, line uint64) *theme.MenuItem {
+	return &theme.MenuItem{
+		Label: PlainLabel(tr("Open in editor")),
+		Disabled: func() bool {
+			return file == ""
+		},
+		Action: func() theme.Action {
+			return &OpenInEditorAction{
+				File: file,
+				Line: line,
+			}
+		},
+	}
 }
 
 func (l *GCObjectLink) Action(mods key.Modifiers) theme.Action {
@@ -336,9 +475,9 @@ func (l *GCObjectLink) Action(mods key.Modifiers) theme.Action {
 }
 
 func (l *GCObjectLink) ContextMenu() []*theme.MenuItem {
-	return []*theme.MenuItem{
+	return contextMenu(l.GC, []*theme.MenuItem{
 		{
-			Label: PlainLabel("Scroll to GC timeline"),
+			Label: PlainLabel(tr("Scroll to GC timeline")),
 			Action: func() theme.Action {
 				return &ScrollToObjectAction{
 					Object:     l.GC,
@@ -347,7 +486,7 @@ func (l *GCObjectLink) ContextMenu() []*theme.MenuItem {
 			},
 		},
 		{
-			Label: PlainLabel("Zoom to GC timeline"),
+			Label: PlainLabel(tr("Zoom to GC timeline")),
 			Action: func() theme.Action {
 				return &ZoomToObjectAction{
 					Object:     l.GC,
@@ -356,14 +495,14 @@ func (l *GCObjectLink) ContextMenu() []*theme.MenuItem {
 			},
 		},
 		{
-			Label: PlainLabel("Show GC information"),
+			Label: PlainLabel(tr("Show GC information")),
 			Action: func() theme.Action {
 				return &OpenSpansAction{
 					Spans: l.GC.Spans,
 				}
 			},
 		},
-	}
+	})
 }
 
 func (l *STWObjectLink) Action(mods key.Modifiers) theme.Action {
@@ -386,9 +525,9 @@ func (l *STWObjectLink) Action(mods key.Modifiers) theme.Action {
 }
 
 func (l *STWObjectLink) ContextMenu() []*theme.MenuItem {
-	return []*theme.MenuItem{
+	return contextMenu(l.STW, []*theme.MenuItem{
 		{
-			Label: PlainLabel("Scroll to STW timeline"),
+			Label: PlainLabel(tr("Scroll to STW timeline")),
 			Action: func() theme.Action {
 				return &ScrollToObjectAction{
 					Object:     l.STW,
@@ -397,7 +536,7 @@ func (l *STWObjectLink) ContextMenu() []*theme.MenuItem {
 			},
 		},
 		{
-			Label: PlainLabel("Zoom to STW timeline"),
+			Label: PlainLabel(tr("Zoom to STW timeline")),
 			Action: func() theme.Action {
 				return &ZoomToObjectAction{
 					Object:     l.STW,
@@ -406,14 +545,14 @@ func (l *STWObjectLink) ContextMenu() []*theme.MenuItem {
 			},
 		},
 		{
-			Label: PlainLabel("Show STW information"),
+			Label: PlainLabel(tr("Show STW information")),
 			Action: func() theme.Action {
 				return &OpenSpansAction{
 					Spans: l.STW.Spans,
 				}
 			},
 		},
-	}
+	})
 }
 
 func (l *SpansObjectLink) Action(mods key.Modifiers) theme.Action {
@@ -434,55 +573,57 @@ func (l *SpansObjectLink) Action(mods key.Modifiers) theme.Action {
 }
 
 func (l *SpansObjectLink) ContextMenu() []*theme.MenuItem {
+	var items []*theme.MenuItem
 	if _, ok := l.Spans.Container(); ok {
-		return []*theme.MenuItem{
+		items = []*theme.MenuItem{
 			{
-				Label: PlainLabel("Scroll to span start"),
+				Label: PlainLabel(tr("Scroll to span start")),
 				Action: func() theme.Action {
 					return ScrollToTimestampAction(l.Spans.AtPtr(0).Start)
 				},
 			},
 			{
-				Label: PlainLabel("Scroll to span end"),
+				Label: PlainLabel(tr("Scroll to span end")),
 				Action: func() theme.Action {
 					return ScrollToTimestampAction(l.Spans.AtPtr(l.Spans.Len() - 1).End)
 				},
 			},
 			{
-				Label: PlainLabel("Zoom to span"),
+				Label: PlainLabel(tr("Zoom to span")),
 				Action: func() theme.Action {
 					return (*ZoomToSpansAction)(l)
 				},
 			},
 			{
-				Label: PlainLabel("Show span information"),
+				Label: PlainLabel(tr("Show span information")),
 				Action: func() theme.Action {
 					return (*OpenSpansAction)(l)
 				},
 			},
 		}
 	} else {
-		return []*theme.MenuItem{
+		items = []*theme.MenuItem{
 			{
-				Label: PlainLabel("Scroll to span start"),
+				Label: PlainLabel(tr("Scroll to span start")),
 				Action: func() theme.Action {
 					return ScrollToTimestampAction(l.Spans.AtPtr(0).Start)
 				},
 			},
 			{
-				Label: PlainLabel("Scroll to span end"),
+				Label: PlainLabel(tr("Scroll to span end")),
 				Action: func() theme.Action {
 					return ScrollToTimestampAction(l.Spans.AtPtr(l.Spans.Len() - 1).End)
 				},
 			},
 			{
-				Label: PlainLabel("Show span information"),
+				Label: PlainLabel(tr("Show span information")),
 				Action: func() theme.Action {
 					return (*OpenSpansAction)(l)
 				},
 			},
 		}
 	}
+	return contextMenu(l.Spans, items)
 }
 
 func (l *TaskObjectLink) Action(mods key.Modifiers) theme.Action {
@@ -503,9 +644,9 @@ func (l *TaskObjectLink) Action(mods key.Modifiers) theme.Action {
 }
 
 func (l *TaskObjectLink) ContextMenu() []*theme.MenuItem {
-	return []*theme.MenuItem{
+	items := []*theme.MenuItem{
 		{
-			Label: PlainLabel("Scroll to task"),
+			Label: PlainLabel(tr("Scroll to task")),
 			Action: func() theme.Action {
 				return &ScrollToObjectAction{
 					Object:     l.Task,
@@ -514,7 +655,7 @@ func (l *TaskObjectLink) ContextMenu() []*theme.MenuItem {
 			},
 		},
 		{
-			Label: PlainLabel("Zoom to task"),
+			Label: PlainLabel(tr("Zoom to task")),
 			Action: func() theme.Action {
 				return &ZoomToObjectAction{
 					Object:     l.Task,
@@ -523,12 +664,13 @@ func (l *TaskObjectLink) ContextMenu() []*theme.MenuItem {
 			},
 		},
 		{
-			Label: PlainLabel("Show task information"),
+			Label: PlainLabel(tr("Show task information")),
 			Action: func() theme.Action {
 				return (*OpenTaskAction)(l)
 			},
 		},
 	}
+	return contextMenu(l.Task, items)
 }
 
 func (l *ScrollToTimelineAction) Open(gtx layout.Context, mwin *MainWindow) {
@@ -565,6 +707,18 @@ func (l *ZoomToObjectAction) Open(gtx layout.Context, mwin *MainWindow) {
 	}
 }
 
+func (l *SelectObjectAction) Open(_ layout.Context, mwin *MainWindow) {
+	mwin.twin.Selection.Set(l.Object)
+}
+
+func (l *FilterGoroutineAction) Open(_ layout.Context, mwin *MainWindow) {
+	mwin.trace.filter.AddGoroutine(l.Goroutine)
+}
+
+func (l *BrushSpansAction) Open(_ layout.Context, mwin *MainWindow) {
+	mwin.canvas.timeline.filter.Brush = l.Brush
+}
+
 func (l *OpenGoroutineAction) Open(_ layout.Context, mwin *MainWindow) {
 	mwin.openGoroutine(l.Goroutine)
 }
@@ -573,6 +727,14 @@ func (l *OpenGoroutineFlameGraphAction) Open(gtx layout.Context, mwin *MainWindo
 	mwin.openFlameGraph(l.Goroutine)
 }
 
+func (l *OpenGoroutineLatenciesAction) Open(gtx layout.Context, mwin *MainWindow) {
+	mwin.openPanel(NewGoroutineLatencies(mwin.twin, mwin.canvas, l.Goroutine))
+}
+
+func (l *OpenStackAction) Open(_ layout.Context, mwin *MainWindow) {
+	mwin.openPanel(NewStackPanel(mwin.trace, l.Stack, l.Title))
+}
+
 func (l *OpenTaskAction) Open(_ layout.Context, mwin *MainWindow) {
 	mwin.openTask(l.Task)
 }
@@ -593,6 +755,11 @@ func (l ScrollToTimestampAction) Open(gtx layout.Context, mwin *MainWindow) {
 	mwin.canvas.navigateTo(gtx, exptrace.Time(l)-off, mwin.canvas.nsPerPx, mwin.canvas.y)
 }
 
+func (l ShowTimestampAction) Open(gtx layout.Context, mwin *MainWindow) {
+	mwin.tabbedState.Current = 0
+	ScrollToTimestampAction(l).Open(gtx, mwin)
+}
+
 func (l *OpenFunctionAction) Open(_ layout.Context, mwin *MainWindow) {
 	mwin.openFunction(l.Function)
 }
@@ -618,8 +785,17 @@ func (l *ZoomToSpansAction) Open(gtx layout.Context, mwin *MainWindow) {
 	mwin.canvas.navigateToStartAndEnd(gtx, l.Spans.AtPtr(0).Start, LastItemPtr(l.Spans).End, y)
 }
 
+func (l *ZoomToTimeRangeAction) Open(gtx layout.Context, mwin *MainWindow) {
+	mwin.tabbedState.Current = 0
+	mwin.canvas.navigateToStartAndEnd(gtx, l.Start, l.End, mwin.canvas.y)
+}
+
 func handleLinkClick(win *theme.Window, ev gesture.ClickEvent, link ObjectLink) {
 	if ev.Kind == gesture.KindClick && ev.Button == pointer.ButtonPrimary {
+		if l, ok := link.(*GoroutineObjectLink); ok {
+			// Following a link to a goroutine selects it, highlighting it in all other views.
+			win.Selection.Set(l.Goroutine)
+		}
 		link := link.Action(ev.Modifiers)
 		win.EmitAction(link)
 	} else if ev.Kind == gesture.KindPress && ev.Button == pointer.ButtonSecondary {
@@ -670,7 +846,7 @@ func (l CanvasToggleStackTracksAction) Open(gtx layout.Context, mwin *MainWindow
 	mwin.canvas.ToggleStackTracks()
 }
 func (l OpenScrollToTimelineAction) Open(gtx layout.Context, mwin *MainWindow) {
-	pl := theme.CommandPalette{Prompt: "Scroll to timeline"}
+	pl := theme.CommandPalette{Prompt: tr("Scroll to timeline")}
 	pl.Set(ScrollToTimelineCommandProvider{mwin.twin, mwin.canvas.timelines})
 	mwin.twin.SetModal(pl.Layout)
 }
@@ -694,20 +870,20 @@ func (l WriteMemoryProfileAction) Open(gtx layout.Context, mwin *MainWindow) {
 		return path, f.Close()
 	}()
 	if err == nil {
-		mwin.twin.ShowNotification(gtx, fmt.Sprintf("Wrote memory profile to %s", path))
+		mwin.twin.ShowNotification(gtx, local.Sprintf("Wrote memory profile to %s", path))
 	} else {
-		mwin.twin.ShowNotification(gtx, fmt.Sprintf("Couldn't write memory profile: %s", err))
+		mwin.twin.Notify(theme.NotificationError, local.Sprintf("Couldn't write memory profile: %s", err))
 	}
 }
 func (l RunGarbageCollectionAction) Open(gtx layout.Context, mwin *MainWindow) {
 	start := time.Now()
 	runtime.GC()
 	d := time.Since(start)
-	mwin.twin.ShowNotification(gtx, fmt.Sprintf("Ran garbage collection in %s", d))
+	mwin.twin.ShowNotification(gtx, local.Sprintf("Ran garbage collection in %s", d))
 }
 func (l RunFreeOSMemoryAction) Open(gtx layout.Context, mwin *MainWindow) {
 	rdebug.FreeOSMemory()
-	mwin.twin.ShowNotification(gtx, "Returned unused memory to OS")
+	mwin.twin.ShowNotification(gtx, tr("Returned unused memory to OS"))
 }
 func (l StartCPUProfileAction) Open(gtx layout.Context, mwin *MainWindow) {
 	if mwin.cpuProfile == nil {
@@ -724,9 +900,9 @@ func (l StartCPUProfileAction) Open(gtx layout.Context, mwin *MainWindow) {
 			return f, path, nil
 		}()
 		if err == nil {
-			mwin.twin.ShowNotification(gtx, fmt.Sprintf("Writing CPU profile to %s…", path))
+			mwin.twin.ShowNotification(gtx, local.Sprintf("Writing CPU profile to %s…", path))
 		} else {
-			mwin.twin.ShowNotification(gtx, fmt.Sprintf("Couldn't start CPU profile: %s", err))
+			mwin.twin.Notify(theme.NotificationError, local.Sprintf("Couldn't start CPU profile: %s", err))
 		}
 		mwin.cpuProfile = f
 	}
@@ -735,9 +911,9 @@ func (l StopCPUProfileAction) Open(gtx layout.Context, mwin *MainWindow) {
 	if mwin.cpuProfile != nil {
 		pprof.StopCPUProfile()
 		if err := mwin.cpuProfile.Close(); err == nil {
-			mwin.twin.ShowNotification(gtx, fmt.Sprintf("Wrote CPU profile to %s", mwin.cpuProfile.Name()))
+			mwin.twin.ShowNotification(gtx, local.Sprintf("Wrote CPU profile to %s", mwin.cpuProfile.Name()))
 		} else {
-			mwin.twin.ShowNotification(gtx, fmt.Sprintf("Couldn't write CPU profile: %s", err))
+			mwin.twin.Notify(theme.NotificationError, local.Sprintf("Couldn't write CPU profile: %s", err))
 		}
 		mwin.cpuProfile = nil
 	}
@@ -751,8 +927,28 @@ func (*PrevPanelAction) Open(gtx layout.Context, mwin *MainWindow) {
 	mwin.prevPanel()
 }
 
+func (l *OpenInEditorAction) Open(gtx layout.Context, mwin *MainWindow) {
+	file := l.File
+	if path, ok := resolveSourcePath(mwin.trace, file); ok {
+		file = path
+	}
+	if err := openInEditor(file, l.Line); err != nil {
+		mwin.twin.Notify(theme.NotificationError, local.Sprintf("Couldn't open editor: %s", err))
+	}
+}
+
+func (l *OpenSourceAction) Open(gtx layout.Context, mwin *MainWindow) {
+	mwin.openSource(l.File, l.Line)
+}
+
+func (l *OpenTraceFileAction) Open(gtx layout.Context, mwin *MainWindow) {
+	mwin.openTraceFile(l.Path)
+}
+
 func (*OpenGoroutineAction) IsOpenAction()                    {}
 func (*OpenGoroutineFlameGraphAction) IsOpenAction()          {}
+func (*OpenGoroutineLatenciesAction) IsOpenAction()           {}
+func (*OpenStackAction) IsOpenAction()                        {}
 func (*OpenTaskAction) IsOpenAction()                         {}
 func (ScrollToTimestampAction) IsNavigationAction()           {}
 func (*OpenFunctionAction) IsOpenAction()                     {}
@@ -760,6 +956,7 @@ func (*SpansAction) IsOpenAction()                            {}
 func (*OpenSpansAction) IsOpenAction()                        {}
 func (*ScrollAndPanToSpansAction) IsNavigationAction()        {}
 func (*ZoomToSpansAction) IsNavigationAction()                {}
+func (*ZoomToTimeRangeAction) IsNavigationAction()            {}
 func (*ScrollToTimelineAction) IsNavigationAction()           {}
 func (*ZoomToTimelineAction) IsNavigationAction()             {}
 func (*ScrollToObjectAction) IsNavigationAction()             {}
@@ -774,3 +971,6 @@ func (*OpenHighlightSpansDialogAction) IsOpenAction()         {}
 func (*OpenScrollToTimelineAction) IsOpenAction()             {}
 func (*OpenFileOpenAction) IsOpenAction()                     {}
 func (*OpenPanelAction) IsOpenAction()                        {}
+func (*OpenInEditorAction) IsOpenAction()                     {}
+func (*OpenSourceAction) IsOpenAction()                       {}
+func (*OpenTraceFileAction) IsOpenAction()                    {}
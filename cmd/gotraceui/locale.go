@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"image"
+	rtrace "runtime/trace"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/widget"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/message/catalog"
+)
+
+// A Locale is a language that the user interface can be displayed in.
+type Locale struct {
+	Tag language.Tag
+	// Name is the name of the language, in the language itself.
+	Name string
+}
+
+// locales are the supported locales. The first one is the default.
+var locales = []Locale{
+	{language.English, "English"},
+	{language.German, "Deutsch"},
+}
+
+// messages is the catalog of translated user-visible strings. The English strings are used as keys, and strings
+// without a translation are displayed as is.
+var messages = catalog.NewBuilder(catalog.Fallback(language.English))
+
+// translations maps the English strings to their translations. User-visible strings are looked up in the catalog by
+// passing them to tr, or to local.Sprintf if they contain formatting verbs or tr is shadowed by a trace. In the
+// latter case, the format string is the key.
+var translations = map[language.Tag]map[string]string{
+	language.German: {
+		"File":                            "Datei",
+		"Display":                         "Ansicht",
+		"Analyze":                         "Analyse",
+		"Help":                            "Hilfe",
+		"Open trace":                      "Trace öffnen",
+		"Open trace in new window":        "Trace in neuem Fenster öffnen",
+		"Browse for trace…":               "Nach Trace suchen…",
+		"Open pprof profile…":             "pprof-Profil öffnen…",
+		"Record trace…":                   "Trace aufzeichnen…",
+		"Aggregate statistics of traces…": "Statistiken mehrerer Traces zusammenfassen…",
+		"Record trace":                    "Trace aufzeichnen",
+		"Record":                          "Aufzeichnen",
+		"Cancel":                          "Abbrechen",
+		"Open recent trace…":              "Zuletzt geöffneten Trace öffnen…",
+		"Keyboard shortcuts…":             "Tastenkürzel…",
+		"Notifications…":                  "Benachrichtigungen…",
+		"Quit":                            "Beenden",
+		"Undo previous navigation":        "Navigation rückgängig machen",
+		"Redo navigation":                 "Navigation wiederholen",
+		"Scroll to top of canvas":         "Zum Anfang der Zeichenfläche scrollen",
+		"Increase UI scale":               "Oberfläche vergrößern",
+		"Decrease UI scale":               "Oberfläche verkleinern",
+		"Reset UI scale":                  "Oberflächengröße zurücksetzen",
+		"Fonts…":                          "Schriftarten…",
+		"Span colors…":                    "Spannenfarben…",
+		"Navigation…":                     "Navigation…",
+		"Time format…":                    "Zeitformat…",
+		"Trace fidelity…":                 "Trace-Genauigkeit…",
+		"Language…":                       "Sprache…",
+		"Help…":                           "Hilfe…",
+		"Release notes…":                  "Versionshinweise…",
+		"Fonts":                           "Schriftarten",
+		"Span colors":                     "Spannenfarben",
+		"Navigation":                      "Navigation",
+		"Time format":                     "Zeitformat",
+		"Trace fidelity":                  "Trace-Genauigkeit",
+		"Language":                        "Sprache",
+		"Keyboard shortcuts":              "Tastenkürzel",
+		"Notifications":                   "Benachrichtigungen",
+		"Apply":                           "Anwenden",
+		"Reset to defaults":               "Auf Standardwerte zurücksetzen",
+
+		"Changes take effect after restarting gotraceui.": "Änderungen werden nach einem Neustart von gotraceui wirksam.",
+
+		"Panel area": "Panelbereich",
+		"More":       "Mehr",
+		"%s (on)":    "%s (an)",
+
+		// Descriptions of widgets for screen readers.
+		"Close %s":                   "%s schließen",
+		"Sorted in ascending order":  "Aufsteigend sortiert",
+		"Sorted in descending order": "Absteigend sortiert",
+		"Row %d":                     "Zeile %d",
+		"Empty histogram":            "Leeres Histogramm",
+		"Histogram of %d values between %s and %s. The fullest bin, from %s to %s, holds %d values.": "Histogramm von %d Werten zwischen %s und %s. Der vollste Behälter, von %s bis %s, enthält %d Werte.",
+	},
+}
+
+func init() {
+	for tag, msgs := range translations {
+		for key, msg := range msgs {
+			if err := messages.SetString(tag, key, msg); err != nil {
+				panic(fmt.Sprintf("invalid translation of %q: %s", key, err))
+			}
+		}
+	}
+}
+
+// setLocale changes the locale used for translations and for formatting numbers. It must be called before any
+// windows are opened, as local is used without synchronization. The empty string selects the default locale.
+func setLocale(name string) error {
+	tag := locales[0].Tag
+	if name != "" {
+		var err error
+		tag, err = language.Parse(name)
+		if err != nil {
+			return err
+		}
+		tags := make([]language.Tag, len(locales))
+		for i, l := range locales {
+			tags[i] = l.Tag
+		}
+		_, idx, conf := language.NewMatcher(tags).Match(tag)
+		if conf == language.No {
+			return fmt.Errorf("unsupported language %q", name)
+		}
+		tag = tags[idx]
+	}
+	local = message.NewPrinter(tag, message.Catalog(messages))
+	// The descriptions of widgets in package theme get translated, too.
+	theme.Sprintf = func(format string, args ...any) string { return local.Sprintf(format, args...) }
+	return nil
+}
+
+// tr translates a user-visible string to the current locale.
+func tr(s string) string {
+	return local.Sprintf(s)
+}
+
+// LocaleDialog lets the user choose the language of the user interface.
+type LocaleDialog struct {
+	locales []widget.PrimaryClickable
+	// The locale that has been chosen, as stored in the configuration.
+	chosen string
+}
+
+func NewLocaleDialog() *LocaleDialog {
+	userConfigMu.Lock()
+	defer userConfigMu.Unlock()
+	return &LocaleDialog{
+		locales: make([]widget.PrimaryClickable, len(locales)),
+		chosen:  userConfig.Locale,
+	}
+}
+
+func (ld *LocaleDialog) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.LocaleDialog.Layout").End()
+
+	for i := range ld.locales {
+		for ld.locales[i].Clicked(gtx) {
+			ld.chosen = locales[i].Tag.String()
+			if i == 0 {
+				// Don't store the default, so that changes to it take effect.
+				ld.chosen = ""
+			}
+			userConfigMu.Lock()
+			userConfig.Locale = ld.chosen
+			if err := userConfig.Save(); err != nil {
+				win.Notify(theme.NotificationError, local.Sprintf("Couldn't save configuration: %s", err))
+			}
+			userConfigMu.Unlock()
+		}
+	}
+
+	children := []layout.Widget{
+		func(gtx layout.Context) layout.Dimensions {
+			return theme.LineLabel(win.Theme, tr("Changes take effect after restarting gotraceui.")).Layout(win, gtx)
+		},
+		layout.Spacer{Height: 10}.Layout,
+	}
+	for i := range locales {
+		children = append(children, func(gtx layout.Context) layout.Dimensions {
+			label := locales[i].Name
+			if (i == 0 && ld.chosen == "") || locales[i].Tag.String() == ld.chosen {
+				label += " ✓"
+			}
+			return theme.Button(win.Theme, &ld.locales[i].Clickable, label).Layout(win, gtx)
+		}, layout.Spacer{Height: 5}.Layout)
+	}
+	return layout.Rigids(gtx, layout.Vertical, children...)
+}
+
+func displayLocaleDialog(win *theme.Window) {
+	ld := NewLocaleDialog()
+	win.SetModal(func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+		return theme.Dialog(win.Theme, tr("Language")).Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+			gtx.Constraints.Min = gtx.Constraints.Constrain(image.Pt(400, 200))
+			gtx.Constraints.Max = gtx.Constraints.Min
+			return ld.Layout(win, gtx)
+		})
+	})
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"flag"
 	"fmt"
@@ -13,6 +15,7 @@ import (
 	"reflect"
 	"runtime"
 	rdebug "runtime/debug"
+	"runtime/metrics"
 	"runtime/pprof"
 	rtrace "runtime/trace"
 	"slices"
@@ -27,7 +30,6 @@ import (
 	"honnef.co/go/gotraceui/cmd/gotraceui/assets"
 	"honnef.co/go/gotraceui/color"
 	"honnef.co/go/gotraceui/container"
-	ourfont "honnef.co/go/gotraceui/font"
 	"honnef.co/go/gotraceui/layout"
 	"honnef.co/go/gotraceui/mysync"
 	"honnef.co/go/gotraceui/theme"
@@ -37,14 +39,12 @@ import (
 
 	"gioui.org/app"
 	"gioui.org/f32"
-	"gioui.org/io/key"
+	"gioui.org/font"
 	"gioui.org/io/pointer"
 	"gioui.org/io/profile"
 	"gioui.org/io/system"
 	"gioui.org/op"
 	"gioui.org/op/clip"
-	"gioui.org/op/paint"
-	"gioui.org/x/component"
 	"gioui.org/x/explorer"
 	"gioui.org/x/styledtext"
 	"golang.org/x/exp/constraints"
@@ -115,12 +115,12 @@ var (
 )
 
 func (mwin *MainWindow) openGoroutine(g *ptrace.Goroutine) {
-	gi := NewGoroutineInfo(mwin.trace, mwin.twin, &mwin.canvas, g, mwin.canvas.timelines)
+	gi := NewGoroutineInfo(mwin.trace, mwin.twin, mwin.canvas, g, mwin.canvas.timelines)
 	mwin.openPanel(gi)
 }
 
 func (mwin *MainWindow) openTask(t *ptrace.Task) {
-	gi := NewTaskInfo(mwin.trace, mwin.twin, &mwin.canvas, t, mwin.canvas.timelines)
+	gi := NewTaskInfo(mwin.trace, mwin.twin, mwin.canvas, t, mwin.canvas.timelines)
 	mwin.openPanel(gi)
 }
 
@@ -129,6 +129,11 @@ func (mwin *MainWindow) openFunction(fn *ptrace.Function) {
 	mwin.openPanel(fi)
 }
 
+func (mwin *MainWindow) openSource(file string, line uint64) {
+	sv := NewSourceView(mwin.trace, mwin.twin, file, line)
+	mwin.openPanel(sv)
+}
+
 func (mwin *MainWindow) openSpan(s Items[ptrace.Span]) {
 	var labels []string
 	var label string
@@ -143,31 +148,26 @@ func (mwin *MainWindow) openSpan(s Items[ptrace.Span]) {
 	cfg := SpansInfoConfig{
 		Label: label,
 	}
+	if c, ok := s.Container(); ok {
+		if lane, ok := c.Timeline.item.(*ExternalLane); ok {
+			cfg.DescriptionBuilder = lane.spansDescription(mwin.trace, s)
+		}
+	}
 	si := NewSpansInfo(cfg, mwin.trace, mwin.twin, theme.Immediate[Items[ptrace.Span]](s), mwin.canvas.timelines)
 	mwin.openPanel(si)
 }
 
 func (mwin *MainWindow) openPanel(p Panel) {
-	if mwin.panel != nil {
-		mwin.panelHistory = append(mwin.panelHistory, mwin.panel)
-		if len(mwin.panelHistory) == 101 {
-			copy(mwin.panelHistory, mwin.panelHistory[1:])
-			mwin.panelHistory = mwin.panelHistory[:100]
-		}
-	}
-	p.Transition(theme.ComponentStatePanel)
-	mwin.panel = p
+	mwin.panels.open(p)
 }
 
+// prevPanel returns the active panel's tab to its previous panel.
 func (mwin *MainWindow) prevPanel() bool {
-	if len(mwin.panelHistory) == 0 {
-		mwin.panel = nil
+	p := mwin.panels.current()
+	if p == nil {
 		return false
 	}
-	p := mwin.panelHistory[len(mwin.panelHistory)-1]
-	mwin.panelHistory = mwin.panelHistory[:len(mwin.panelHistory)-1]
-	mwin.panel = p
-	return true
+	return mwin.panels.back(p)
 }
 
 type PanelWindow struct {
@@ -176,8 +176,11 @@ type PanelWindow struct {
 
 	mu          sync.RWMutex
 	hoveredLink ObjectLink
+	appWindow   *app.Window
 
 	prevHoveredLink ObjectLink
+	// The generation of the shared selection that we last rendered.
+	selectionGen uint64
 }
 
 func (pwin *PanelWindow) HoveredLink() ObjectLink {
@@ -186,9 +189,23 @@ func (pwin *PanelWindow) HoveredLink() ObjectLink {
 	return pwin.hoveredLink
 }
 
+// Invalidate requests a redraw of the window.
+func (pwin *PanelWindow) Invalidate() {
+	pwin.mu.RLock()
+	defer pwin.mu.RUnlock()
+	if pwin.appWindow != nil {
+		pwin.appWindow.Invalidate()
+	}
+}
+
 func (pwin *PanelWindow) Run(win *app.Window) error {
 	var ops op.Ops
 	tWin := theme.NewWindow(win)
+	// Share the selection with the main window so that selecting an object in either window highlights it in both.
+	tWin.Selection = pwin.MainWindow.Selection
+	pwin.mu.Lock()
+	pwin.appWindow = win
+	pwin.mu.Unlock()
 
 	var dead bool
 	for {
@@ -239,6 +256,15 @@ func (pwin *PanelWindow) Run(win *app.Window) error {
 				if l != pwin.prevHoveredLink {
 					pwin.MainWindow.AppWindow.Invalidate()
 				}
+				if gen := twin.Selection.Generation(); gen != pwin.selectionGen {
+					pwin.selectionGen = gen
+					pwin.MainWindow.AppWindow.Invalidate()
+				}
+
+				keymap.Register(twin, keyIncreaseScale, keyDecreaseScale, keyResetScale)
+				for _, s := range twin.PressedShortcuts() {
+					handleScaleShortcut(twin, s)
+				}
 
 				theme.Fill(twin, gtx.Ops, tWin.Theme.Palette.Background)
 				return pwin.Panel.Layout(twin, gtx)
@@ -271,10 +297,136 @@ func (mwin *MainWindow) openHeatmap() {
 }
 
 func (mwin *MainWindow) openFlameGraph(g *ptrace.Goroutine) {
-	c := NewFlameGraphComponent(mwin.twin, mwin.trace.Trace, g)
+	c := NewFlameGraphComponent(mwin.twin, mwin.trace, g)
+	mwin.openTab(Tab{Component: c})
+}
+
+func (mwin *MainWindow) openLeaks() {
+	c := NewLeaksComponent(mwin.twin, mwin.trace)
+	mwin.openTab(Tab{Component: c})
+}
+
+func (mwin *MainWindow) openContention() {
+	c := NewContentionComponent(mwin.twin, mwin.trace, mwin.canvas)
+	mwin.openTab(Tab{Component: c})
+}
+
+func (mwin *MainWindow) openGCAssist() {
+	c := NewGCAssistComponent(mwin.twin, mwin.trace)
+	mwin.openTab(Tab{Component: c})
+}
+
+func (mwin *MainWindow) openSyscalls() {
+	c := NewSyscallsComponent(mwin.twin, mwin.trace, mwin.canvas)
+	mwin.openTab(Tab{Component: c})
+}
+
+func (mwin *MainWindow) openSpawnRate() {
+	c := NewSpawnRateComponent(mwin.twin, mwin.trace)
+	mwin.openTab(Tab{Component: c})
+}
+
+func (mwin *MainWindow) openRunQueues() {
+	c := NewRunQueueComponent(mwin.twin, mwin.trace)
+	mwin.openTab(Tab{Component: c})
+}
+
+func (mwin *MainWindow) openMigrations() {
+	c := NewMigrationComponent(mwin.twin, mwin.trace)
+	mwin.openTab(Tab{Component: c})
+}
+
+func (mwin *MainWindow) openUtilization() {
+	c := NewUtilizationComponent(mwin.twin, mwin.trace)
+	mwin.openTab(Tab{Component: c})
+}
+
+func (mwin *MainWindow) openNetPoller() {
+	c := NewNetPollerComponent(mwin.twin, mwin.trace, mwin.canvas)
+	mwin.openTab(Tab{Component: c})
+}
+
+func (mwin *MainWindow) openInsights() {
+	c := NewInsightsComponent(mwin.twin, mwin.trace)
+	mwin.openTab(Tab{Component: c})
+}
+
+func (mwin *MainWindow) openBookmarks() {
+	c := NewBookmarksComponent(mwin)
+	mwin.openTab(Tab{Component: c})
+}
+
+func (mwin *MainWindow) openUniqueStacks() {
+	c := NewUniqueStacksComponent(mwin.twin, mwin.trace)
+	mwin.openTab(Tab{Component: c})
+}
+
+func (mwin *MainWindow) openWaitForGraph(start, end exptrace.Time) {
+	c := NewWaitForGraphComponent(mwin.twin, mwin.trace, start, end)
+	mwin.openTab(Tab{Component: c})
+}
+
+func (mwin *MainWindow) openHeap() {
+	c := NewHeapComponent(mwin.trace)
 	mwin.openTab(Tab{Component: c})
 }
 
+func (mwin *MainWindow) openThreads() {
+	c := NewThreadsComponent(mwin.twin, mwin.trace)
+	mwin.openTab(Tab{Component: c})
+}
+
+func (mwin *MainWindow) openTimers() {
+	c := NewTimersComponent(mwin.twin, mwin.trace, mwin.canvas)
+	mwin.openTab(Tab{Component: c})
+}
+
+func (mwin *MainWindow) openCgo() {
+	c := NewCgoComponent(mwin.twin, mwin.trace, mwin.canvas)
+	mwin.openTab(Tab{Component: c})
+}
+
+func (mwin *MainWindow) openSelfTime() {
+	c := NewSelfTimeComponent(mwin.twin, mwin.trace)
+	mwin.openTab(Tab{Component: c})
+}
+
+func (mwin *MainWindow) openTopSpans() {
+	c := NewTopSpansComponent(mwin.trace, mwin.canvas)
+	mwin.openTab(Tab{Component: c})
+}
+
+func (mwin *MainWindow) openRegions() {
+	c := NewRegionStatsComponent(mwin.twin, mwin.trace, mwin.canvas)
+	mwin.openTab(Tab{Component: c})
+}
+
+func (mwin *MainWindow) openFunctionSearch() {
+	c := NewFunctionSearchComponent(mwin.twin, mwin.trace, mwin.canvas)
+	mwin.openTab(Tab{Component: c})
+}
+
+func (mwin *MainWindow) openQueryConsole() {
+	c := NewQueryConsoleComponent(mwin.trace)
+	mwin.openTab(Tab{Component: c})
+}
+
+// showSearchResults displays the search results as a panel, unless they're already displayed.
+func (mwin *MainWindow) showSearchResults() {
+	if !mwin.searchResults.Displayed(mwin) {
+		mwin.openPanel(mwin.searchResults)
+	}
+}
+
+// stepSearchResults moves the canvas to the search result that is delta results away from the current one.
+func (mwin *MainWindow) stepSearchResults(delta int) {
+	if mwin.searchResults == nil {
+		return
+	}
+	mwin.showSearchResults()
+	mwin.searchResults.Step(mwin.twin, delta)
+}
+
 func (mwin *MainWindow) openTab(tab Tab) {
 	mwin.tabs = append(mwin.tabs, tab)
 	mwin.tabbedState.Current = len(mwin.tabs) - 1
@@ -307,11 +459,22 @@ type Tab struct {
 
 // TODO(dh): split MainWindow into two types, one for the application as a whole and one for the main window.
 type MainWindow struct {
-	canvas          Canvas
+	// The state of the active trace. See tracetabs.go.
+	canvas          *Canvas
 	trace           *Trace
 	explorer        *explorer.Explorer
 	showingExplorer atomic.Bool
 	mainMenu        *MainMenu
+	// The programs feeding lanes to the canvas, if any.
+	external *externalSources
+	// Additional processes displayed alongside the trace.
+	processes []*processTrace
+	// The absolute path of the trace file, if the trace was loaded from a file.
+	tracePath string
+	// The size at which the current tab was last displayed, for rendering it as an image.
+	tabSize image.Point
+	// Operations running in the background, such as copying images, which are displayed in a corner of the window.
+	background []*backgroundOperation
 
 	cpuProfile *os.File
 
@@ -320,51 +483,109 @@ type MainWindow struct {
 	// Channel used by goroutines to report critical errors.
 	errs chan error
 
-	panel        Panel
-	panelHistory []Panel
+	panels *panelArea
+	// The results of the most recent search, if any.
+	searchResults *SearchResultsComponent
 
 	tabs        []Tab
 	tabbedState theme.TabbedState
 
+	// The traces displayed in the window, and the one whose state is held by the fields above.
+	traceTabs   []*traceTab
+	traceTabBar theme.TabBarState
+	activeTrace *traceTab
+
 	openTraceButton widget.PrimaryClickable
-	resize          component.Resize
+	startScene      struct {
+		browse        widget.PrimaryClickable
+		recent        []RecentTrace
+		recentButtons []widget.PrimaryClickable
+		// Buttons for restoring the sessions of the pending recovery.
+		restoreButtons []widget.PrimaryClickable
+		showCrash      widget.PrimaryClickable
+		discard        widget.PrimaryClickable
+		// The frame in which the start scene was last displayed.
+		frame uint64
+	}
+	dock theme.DockState
+	// The UI scale that was last saved to the configuration.
+	scale float32
+
+	// The sessions that were last autosaved, and when.
+	autosaved struct {
+		sessions []Session
+		at       time.Time
+		// forgotten is set when the window's sessions have been removed from the autosave file. It is guarded by
+		// autosaves.mu.
+		forgotten bool
+	}
+	// The recovered session to restore once its trace has been loaded, and the crash report to display with it.
+	restoring      *Session
+	restoringCrash string
 
 	subwindowsMu sync.RWMutex
 	subwindows   map[Window]struct{}
+	// Displays the parts of the global filter that are in effect.
+	filters FilterBar
+	// The generation of the selection that we last rendered.
+	selectionGen uint64
 
 	pointerAt f32.Point
 
 	win  *app.Window
 	twin *theme.Window
 	// TODO(dh): use enum for state
-	state          string
-	progress       atomic.Uint64
-	progressStage  int
-	progressStages []string
-	err            error
+	state string
+	// loading is the operation loading the current trace, if any.
+	loading      *theme.Operation
+	loadingState theme.OperationProgressState
+	err          error
 
 	debugWindow *DebugWindow
+
+	// The memory usage of the process, as displayed in the status bar. Reading metrics isn't free, so we only sample
+	// it occasionally.
+	memUsage struct {
+		bytes     uint64
+		sampledAt time.Time
+	}
+}
+
+// memoryUsage returns the total memory mapped by the Go runtime, sampling it at most once per second.
+func (mwin *MainWindow) memoryUsage(now time.Time) uint64 {
+	if now.Sub(mwin.memUsage.sampledAt) >= time.Second {
+		samples := []metrics.Sample{{Name: "/memory/classes/total:bytes"}}
+		metrics.Read(samples)
+		mwin.memUsage.bytes = samples[0].Value.Uint64()
+		mwin.memUsage.sampledAt = now
+	}
+	return mwin.memUsage.bytes
 }
 
 func NewMainWindow() *MainWindow {
 	var mwin MainWindow
+	cv := new(Canvas)
 	mwin = MainWindow{
+		canvas:      cv,
 		debugWindow: NewDebugWindow(),
 		errs:        make(chan error),
 		subwindows:  map[Window]struct{}{},
 		gc:          NewGCScheduler(0, 0),
-		resize: component.Resize{
-			Axis:  layout.Horizontal,
-			Ratio: 0.70,
+		dock: theme.DockState{
+			Side:  theme.DockRight,
+			Ratio: defaultMainRatio,
 		},
+		panels: newPanelArea(),
 		tabs: []Tab{
 			{
-				Component:  &TimelinesComponent{cv: &mwin.canvas},
+				Component:  &TimelinesComponent{cv: cv},
 				Unclosable: true,
 			},
 		},
 	}
 
+	mwin.restoreLayout()
+
 	go mwin.gc.Run()
 
 	return &mwin
@@ -372,10 +593,44 @@ func NewMainWindow() *MainWindow {
 
 // OpenTrace initiates loading of a trace. It changes the state to loadingTrace, loads the trace, and notifies the
 // window when it's done. OpenTrace should be called from a different goroutine than the render loop.
+//
+// Loading can be cancelled by the user, in which case the window returns to the previously loaded trace, if any.
 func (mwin *MainWindow) OpenTrace(r io.Reader) {
-	mwin.SetState("loadingTrace")
+	mwin.openTrace(r, false)
+}
 
-	res, err := loadTrace(r, mwin, &mwin.canvas)
+// OpenTraceInNewTab is like OpenTrace, but displays the trace in a new tab instead of replacing the current trace.
+func (mwin *MainWindow) OpenTraceInNewTab(r io.Reader) {
+	mwin.openTrace(r, true)
+}
+
+func (mwin *MainWindow) openTrace(r io.Reader, newTab bool) {
+	o := theme.NewOperation(context.Background())
+	mwin.twin.EmitAction(theme.ExecuteAction(func(gtx layout.Context) {
+		mwin.twin.CloseModal()
+		mwin.twin.Menu.Close()
+		mwin.loading = o
+		mwin.loadingState = theme.OperationProgressState{}
+		mwin.setState("loadingTrace")
+	}))
+
+	cv := mwin.canvas
+	if newTab {
+		cv = new(Canvas)
+	}
+	res, err := loadTrace(o.Context(), r, o, cv)
+	if o.Cancelled() {
+		mwin.twin.EmitAction(theme.ExecuteAction(func(gtx layout.Context) {
+			mwin.loading = nil
+			if mwin.trace != nil {
+				mwin.setState("main")
+			} else {
+				mwin.setState("start")
+			}
+		}))
+		mwin.twin.Notify(theme.NotificationInfo, tr("Cancelled loading trace"))
+		return
+	}
 	if memprofileLoad != "" {
 		writeMemprofile(memprofileLoad)
 	}
@@ -392,12 +647,20 @@ func (mwin *MainWindow) OpenTrace(r io.Reader) {
 		return
 	}
 
+	if f, ok := r.(*os.File); ok {
+		res.path, _ = filepath.Abs(f.Name())
+	}
+	res.title = traceTitle("")
+	if f, ok := r.(interface{ Name() string }); ok {
+		res.title = traceTitle(f.Name())
+	}
+	res.canvas = cv
+	res.newTab = newTab
 	mwin.LoadTrace(res)
 }
 
 func (mwin *MainWindow) setState(state string) {
 	mwin.state = state
-	mwin.progress.Store(0)
 }
 
 func (mwin *MainWindow) SetState(state string) {
@@ -415,25 +678,9 @@ func (mwin *MainWindow) SetError(err error) {
 	}))
 }
 
-func (mwin *MainWindow) SetProgress(p float64) {
-	mwin.progress.Store(math.Float64bits(p))
-}
-
-func (mwin *MainWindow) SetProgressStages(names []string) {
-	mwin.twin.EmitAction(theme.ExecuteAction(func(gtx layout.Context) {
-		mwin.progressStages = names
-	}))
-}
-
-func (mwin *MainWindow) SetProgressStage(idx int) {
-	mwin.twin.EmitAction(theme.ExecuteAction(func(gtx layout.Context) {
-		mwin.progressStage = idx
-		mwin.progress.Store(0)
-	}))
-}
-
 func (mwin *MainWindow) LoadTrace(res loadTraceResult) {
 	mwin.twin.EmitAction(theme.ExecuteAction(func(gtx layout.Context) {
+		mwin.loading = nil
 		mwin.loadTraceImpl(res)
 		mwin.setState("main")
 	}))
@@ -468,10 +715,30 @@ func ToggleLabel(t, f string, b *bool) func() string {
 
 func PlainLabel(s string) func() string { return func() string { return s } }
 
+// canvasToggleLabel is like ToggleLabel, but for an option of the canvas, which changes when switching between traces.
+func (mwin *MainWindow) canvasToggleLabel(t, f string, b func(cv *Canvas) *bool) func() string {
+	return func() string {
+		return ToggleLabel(t, f, b(mwin.canvas))()
+	}
+}
+
 type MainMenu struct {
 	File struct {
-		OpenTrace theme.MenuItem
-		Quit      theme.MenuItem
+		OpenTrace          theme.MenuItem
+		OpenTraceNewWindow theme.MenuItem
+		OpenTraceNewTab    theme.MenuItem
+		BrowseTrace        theme.MenuItem
+		OpenProfile        theme.MenuItem
+		AddProcess         theme.MenuItem
+		ImportOTel         theme.MenuItem
+		CopyTabImage       theme.MenuItem
+		CopyPanelImage     theme.MenuItem
+		RecordTrace        theme.MenuItem
+		AggregateTraces    theme.MenuItem
+		OpenRecentTrace    theme.MenuItem
+		KeyboardShortcuts  theme.MenuItem
+		Notifications      theme.MenuItem
+		Quit               theme.MenuItem
 	}
 
 	Display struct {
@@ -481,14 +748,66 @@ type MainMenu struct {
 		ZoomToFit            theme.MenuItem
 		JumpToBeginning      theme.MenuItem
 		HighlightSpans       theme.MenuItem
+		NextSearchResult     theme.MenuItem
+		PrevSearchResult     theme.MenuItem
+		FilterAllViews       theme.MenuItem
+		FilterTimelines      theme.MenuItem
 		ToggleCompactDisplay theme.MenuItem
 		ToggleTimelineLabels theme.MenuItem
+		HideIdleGoroutines   theme.MenuItem
+		HideShortLived       theme.MenuItem
 		ToggleStackTracks    theme.MenuItem
+		TogglePanelArea      theme.MenuItem
+		TogglePerformanceHUD theme.MenuItem
+		DockPanelRight       theme.MenuItem
+		DockPanelBottom      theme.MenuItem
+		DockPanelLeft        theme.MenuItem
+		DockPanelTop         theme.MenuItem
+		IncreaseScale        theme.MenuItem
+		DecreaseScale        theme.MenuItem
+		ResetScale           theme.MenuItem
+		Fonts                theme.MenuItem
+		SpanColors           theme.MenuItem
+		Navigation           theme.MenuItem
+		TimeFormat           theme.MenuItem
+		Tooltips             theme.MenuItem
+		Fidelity             theme.MenuItem
+		Language             theme.MenuItem
+		Settings             theme.MenuItem
+		ProcessOffsets       theme.MenuItem
 	}
 
 	Analyze struct {
-		OpenHeatmap    theme.MenuItem
-		OpenFlameGraph theme.MenuItem
+		OpenHeatmap      theme.MenuItem
+		OpenFlameGraph   theme.MenuItem
+		OpenLeaks        theme.MenuItem
+		OpenContention   theme.MenuItem
+		OpenGCAssist     theme.MenuItem
+		OpenSyscalls     theme.MenuItem
+		OpenSpawnRate    theme.MenuItem
+		OpenRunQueues    theme.MenuItem
+		OpenMigrations   theme.MenuItem
+		OpenUtilization  theme.MenuItem
+		OpenNetPoller    theme.MenuItem
+		OpenInsights     theme.MenuItem
+		OpenBookmarks    theme.MenuItem
+		OpenUniqueStacks theme.MenuItem
+		OpenWaitForGraph theme.MenuItem
+		OpenHeap         theme.MenuItem
+		OpenThreads      theme.MenuItem
+		OpenTimers       theme.MenuItem
+		OpenCgo          theme.MenuItem
+		OpenSelfTime     theme.MenuItem
+		OpenTopSpans     theme.MenuItem
+		OpenRegions      theme.MenuItem
+		OpenFunctions    theme.MenuItem
+		OpenQueryConsole theme.MenuItem
+		OpenWarnings     theme.MenuItem
+	}
+
+	Help struct {
+		Help         theme.MenuItem
+		ReleaseNotes theme.MenuItem
 	}
 
 	Debug struct {
@@ -496,29 +815,93 @@ type MainMenu struct {
 		Cpuprofile   theme.MenuItem
 		GC           theme.MenuItem
 		FreeOSMemory theme.MenuItem
+		MemoryUsage  theme.MenuItem
 	}
 
-	menu *theme.Menu
+	menu    *theme.Menu
+	Toolbar mainToolbar
+	// The keymap generation that the shortcuts of menu items reflect.
+	keymapGen uint64
 }
 
 func NewMainMenu(mwin *MainWindow, win *theme.Window) *MainMenu {
 	m := &MainMenu{}
 
-	m.File.OpenTrace = theme.MenuItem{Shortcut: key.ModShortcut.String() + "+O", Label: PlainLabel("Open trace")}
-	m.File.Quit = theme.MenuItem{Shortcut: key.ModShortcut.String() + "+Q", Label: PlainLabel("Quit")}
+	m.File.OpenTrace = theme.MenuItem{Label: PlainLabel(tr("Open trace"))}
+	m.File.OpenTraceNewWindow = theme.MenuItem{Label: PlainLabel(tr("Open trace in new window"))}
+	m.File.OpenTraceNewTab = theme.MenuItem{Label: PlainLabel(tr("Open trace in new tab"))}
+	m.File.BrowseTrace = theme.MenuItem{Label: PlainLabel(tr("Browse for trace…"))}
+	m.File.RecordTrace = theme.MenuItem{Label: PlainLabel(tr("Record trace…"))}
+	m.File.AggregateTraces = theme.MenuItem{Label: PlainLabel(tr("Aggregate statistics of traces…"))}
+	m.File.OpenRecentTrace = theme.MenuItem{Label: PlainLabel(tr("Open recent trace…")), Disabled: func() bool { return len(recentTraces()) == 0 }}
+	m.File.KeyboardShortcuts = theme.MenuItem{Label: PlainLabel(tr("Keyboard shortcuts…"))}
+	m.File.Notifications = theme.MenuItem{Label: PlainLabel(tr("Notifications…"))}
+	m.File.Quit = theme.MenuItem{Label: PlainLabel(tr("Quit"))}
 
 	notMainDisabled := func() bool { return mwin.state != "main" }
-	m.Display.UndoNavigation = theme.MenuItem{Shortcut: key.ModShortcut.String() + "+Z", Label: PlainLabel("Undo previous navigation"), Disabled: notMainDisabled}
-	m.Display.RedoNavigation = theme.MenuItem{Shortcut: key.ModShortcut.String() + "+Y", Label: PlainLabel("Redo navigation"), Disabled: notMainDisabled}
-	m.Display.ScrollToTop = theme.MenuItem{Shortcut: "Home", Label: PlainLabel("Scroll to top of canvas"), Disabled: notMainDisabled}
-	m.Display.ZoomToFit = theme.MenuItem{Shortcut: key.ModShortcut.String() + "+Home", Label: PlainLabel("Zoom to fit visible timelines"), Disabled: notMainDisabled}
-	m.Display.JumpToBeginning = theme.MenuItem{Shortcut: "Shift+Home", Label: PlainLabel("Jump to beginning of timeline"), Disabled: notMainDisabled}
-	m.Display.HighlightSpans = theme.MenuItem{Shortcut: "H", Label: PlainLabel("Highlight spans…"), Disabled: notMainDisabled}
-	m.Display.ToggleCompactDisplay = theme.MenuItem{Shortcut: "C", Label: ToggleLabel("Disable compact display", "Enable compact display", &mwin.canvas.timeline.compact), Disabled: notMainDisabled}
-	m.Display.ToggleTimelineLabels = theme.MenuItem{Shortcut: "X", Label: ToggleLabel("Hide timeline labels", "Show timeline labels", &mwin.canvas.timeline.displayAllLabels), Disabled: notMainDisabled}
-	m.Display.ToggleStackTracks = theme.MenuItem{Shortcut: "S", Label: ToggleLabel("Hide stack frames", "Show stack frames", &mwin.canvas.timeline.displayStackTracks), Disabled: notMainDisabled}
-
-	m.Debug.Memprofile = theme.MenuItem{Label: PlainLabel("Write memory profile")}
+	m.File.OpenProfile = theme.MenuItem{Label: PlainLabel(tr("Open pprof profile…")), Disabled: notMainDisabled}
+	m.File.CopyTabImage = theme.MenuItem{Label: PlainLabel(tr("Copy tab as image")), Disabled: notMainDisabled}
+	m.File.CopyPanelImage = theme.MenuItem{Label: PlainLabel(tr("Copy panel as image")), Disabled: func() bool { return mwin.state != "main" || mwin.panels.current() == nil }}
+	m.File.ImportOTel = theme.MenuItem{Label: PlainLabel(tr("Import OpenTelemetry spans…")), Disabled: notMainDisabled}
+	m.File.AddProcess = theme.MenuItem{Label: PlainLabel(tr("Add trace of another process…")), Disabled: notMainDisabled}
+	m.Display.ProcessOffsets = theme.MenuItem{Label: PlainLabel(tr("Process offsets…")), Disabled: func() bool { return mwin.state != "main" || len(mwin.processes) == 0 }}
+	m.Display.UndoNavigation = theme.MenuItem{Label: PlainLabel(tr("Undo previous navigation")), Disabled: notMainDisabled}
+	m.Display.RedoNavigation = theme.MenuItem{Label: PlainLabel(tr("Redo navigation")), Disabled: notMainDisabled}
+	m.Display.ScrollToTop = theme.MenuItem{Label: PlainLabel(tr("Scroll to top of canvas")), Disabled: notMainDisabled}
+	m.Display.ZoomToFit = theme.MenuItem{Label: PlainLabel(tr("Zoom to fit visible timelines")), Disabled: notMainDisabled}
+	m.Display.JumpToBeginning = theme.MenuItem{Label: PlainLabel(tr("Jump to beginning of timeline")), Disabled: notMainDisabled}
+	m.Display.HighlightSpans = theme.MenuItem{Label: PlainLabel(tr("Highlight spans…")), Disabled: notMainDisabled}
+	noSearchResults := func() bool { return mwin.state != "main" || mwin.searchResults == nil }
+	m.Display.NextSearchResult = theme.MenuItem{Label: PlainLabel(tr("Go to next search result")), Disabled: noSearchResults}
+	m.Display.PrevSearchResult = theme.MenuItem{Label: PlainLabel(tr("Go to previous search result")), Disabled: noSearchResults}
+	m.Display.FilterAllViews = theme.MenuItem{Label: PlainLabel(tr("Filter all views…")), Disabled: notMainDisabled}
+	m.Display.FilterTimelines = theme.MenuItem{Label: PlainLabel(tr("Filter timelines…")), Disabled: notMainDisabled}
+	m.Display.ToggleCompactDisplay = theme.MenuItem{Label: mwin.canvasToggleLabel(tr("Disable compact display"), tr("Enable compact display"), func(cv *Canvas) *bool { return &cv.timeline.compact }), Disabled: notMainDisabled}
+	m.Display.ToggleTimelineLabels = theme.MenuItem{Label: mwin.canvasToggleLabel(tr("Hide timeline labels"), tr("Show timeline labels"), func(cv *Canvas) *bool { return &cv.timeline.displayAllLabels }), Disabled: notMainDisabled}
+	m.Display.HideIdleGoroutines = theme.MenuItem{
+		Label: func() string {
+			if mwin.canvas.timeline.hideIdle {
+				return local.Sprintf("Show idle goroutines (%d hidden)", mwin.canvas.timeline.numHiddenIdle)
+			}
+			return "Hide idle goroutines"
+		},
+		Disabled: notMainDisabled,
+	}
+	m.Display.HideShortLived = theme.MenuItem{
+		Label: func() string {
+			if mwin.canvas.timeline.hideShortLived {
+				return local.Sprintf("Show short-lived goroutines (%d hidden)", mwin.canvas.timeline.numHiddenShortLived)
+			}
+			return "Hide short-lived goroutines"
+		},
+		Disabled: notMainDisabled,
+	}
+	m.Display.ToggleStackTracks = theme.MenuItem{Label: mwin.canvasToggleLabel(tr("Hide stack frames"), tr("Show stack frames"), func(cv *Canvas) *bool { return &cv.timeline.displayStackTracks }), Disabled: notMainDisabled}
+
+	m.Display.TogglePanelArea = theme.MenuItem{Label: ToggleLabel(tr("Show panel area"), tr("Hide panel area"), &mwin.dock.Hidden)}
+	dockedTo := func(side theme.DockSide) func() bool {
+		return func() bool { return !mwin.dock.Hidden && mwin.dock.Side == side }
+	}
+	m.Display.DockPanelRight = theme.MenuItem{Label: PlainLabel(tr("Dock panel area to the right")), Disabled: dockedTo(theme.DockRight)}
+	m.Display.DockPanelBottom = theme.MenuItem{Label: PlainLabel(tr("Dock panel area to the bottom")), Disabled: dockedTo(theme.DockBottom)}
+	m.Display.DockPanelLeft = theme.MenuItem{Label: PlainLabel(tr("Dock panel area to the left")), Disabled: dockedTo(theme.DockLeft)}
+	m.Display.DockPanelTop = theme.MenuItem{Label: PlainLabel(tr("Dock panel area to the top")), Disabled: dockedTo(theme.DockTop)}
+
+	m.Display.IncreaseScale = theme.MenuItem{Label: PlainLabel(tr("Increase UI scale"))}
+	m.Display.DecreaseScale = theme.MenuItem{Label: PlainLabel(tr("Decrease UI scale"))}
+	m.Display.ResetScale = theme.MenuItem{Label: PlainLabel(tr("Reset UI scale")), Disabled: func() bool { return win.Scale() == 1 }}
+
+	m.Display.Fonts = theme.MenuItem{Label: PlainLabel(tr("Fonts…"))}
+	m.Display.SpanColors = theme.MenuItem{Label: PlainLabel(tr("Span colors…"))}
+	m.Display.Navigation = theme.MenuItem{Label: PlainLabel(tr("Navigation…"))}
+	m.Display.TimeFormat = theme.MenuItem{Label: PlainLabel(tr("Time format…"))}
+	m.Display.Tooltips = theme.MenuItem{Label: PlainLabel(tr("Tooltips…"))}
+	m.Display.Fidelity = theme.MenuItem{Label: PlainLabel(tr("Trace fidelity…"))}
+	m.Display.Language = theme.MenuItem{Label: PlainLabel(tr("Language…"))}
+	m.Display.Settings = theme.MenuItem{Label: PlainLabel(tr("All settings…"))}
+	m.Display.TogglePerformanceHUD = theme.MenuItem{Label: ToggleLabel(tr("Hide performance HUD"), tr("Show performance HUD"), &win.HUD.Enabled)}
+
+	m.Debug.Memprofile = theme.MenuItem{Label: PlainLabel(tr("Write memory profile"))}
 	m.Debug.Cpuprofile = theme.MenuItem{Label: func() string {
 		if mwin.cpuProfile == nil {
 			return "Start CPU profile"
@@ -526,23 +909,63 @@ func NewMainMenu(mwin *MainWindow, win *theme.Window) *MainMenu {
 			return "Stop CPU profile"
 		}
 	}}
-	m.Debug.GC = theme.MenuItem{Label: PlainLabel("Force garbage collection")}
-	m.Debug.FreeOSMemory = theme.MenuItem{Label: PlainLabel("Force garbage collection & return unused memory to OS")}
-
-	m.Analyze.OpenHeatmap = theme.MenuItem{Label: PlainLabel("Open processor utilization heatmap"), Disabled: notMainDisabled}
-	m.Analyze.OpenFlameGraph = theme.MenuItem{Label: PlainLabel("Open flame graph"), Disabled: notMainDisabled}
+	m.Debug.GC = theme.MenuItem{Label: PlainLabel(tr("Force garbage collection"))}
+	m.Debug.FreeOSMemory = theme.MenuItem{Label: PlainLabel(tr("Force garbage collection & return unused memory to OS"))}
+	m.Debug.MemoryUsage = theme.MenuItem{Label: PlainLabel(tr("Display memory usage of caches")), Disabled: notMainDisabled}
+
+	m.Analyze.OpenHeatmap = theme.MenuItem{Label: PlainLabel(tr("Open processor utilization heatmap")), Disabled: notMainDisabled}
+	m.Analyze.OpenFlameGraph = theme.MenuItem{Label: PlainLabel(tr("Open flame graph")), Disabled: notMainDisabled}
+	m.Analyze.OpenLeaks = theme.MenuItem{Label: PlainLabel(tr("Find leaked goroutines")), Disabled: notMainDisabled}
+	m.Analyze.OpenContention = theme.MenuItem{Label: PlainLabel(tr("Open lock contention report")), Disabled: notMainDisabled}
+	m.Analyze.OpenGCAssist = theme.MenuItem{Label: PlainLabel(tr("Open GC assist burden")), Disabled: notMainDisabled}
+	m.Analyze.OpenSyscalls = theme.MenuItem{Label: PlainLabel(tr("Find long syscalls")), Disabled: notMainDisabled}
+	m.Analyze.OpenSpawnRate = theme.MenuItem{Label: PlainLabel(tr("Open goroutine spawn rate")), Disabled: notMainDisabled}
+	m.Analyze.OpenRunQueues = theme.MenuItem{Label: PlainLabel(tr("Open run queue lengths")), Disabled: notMainDisabled}
+	m.Analyze.OpenMigrations = theme.MenuItem{Label: PlainLabel(tr("Open goroutine migrations")), Disabled: notMainDisabled}
+	m.Analyze.OpenUtilization = theme.MenuItem{Label: PlainLabel(tr("Open P utilization")), Disabled: notMainDisabled}
+	m.Analyze.OpenNetPoller = theme.MenuItem{Label: PlainLabel(tr("Open network waits")), Disabled: notMainDisabled}
+	m.Analyze.OpenInsights = theme.MenuItem{Label: PlainLabel(tr("Open insights")), Disabled: notMainDisabled}
+	m.Analyze.OpenBookmarks = theme.MenuItem{Label: PlainLabel(tr("Open bookmarks")), Disabled: notMainDisabled}
+	m.Analyze.OpenUniqueStacks = theme.MenuItem{Label: PlainLabel(tr("Open unique stacks")), Disabled: notMainDisabled}
+	m.Analyze.OpenWaitForGraph = theme.MenuItem{Label: PlainLabel(tr("Open wait-for graph of visible range")), Disabled: notMainDisabled}
+	m.Analyze.OpenHeap = theme.MenuItem{Label: PlainLabel(tr("Open heap and GC pacing")), Disabled: notMainDisabled}
+	m.Analyze.OpenThreads = theme.MenuItem{Label: PlainLabel(tr("Open OS threads")), Disabled: notMainDisabled}
+	m.Analyze.OpenTimers = theme.MenuItem{Label: PlainLabel(tr("Open timers and sleeps")), Disabled: notMainDisabled}
+	m.Analyze.OpenCgo = theme.MenuItem{Label: PlainLabel(tr("Open cgo calls")), Disabled: notMainDisabled}
+	m.Analyze.OpenSelfTime = theme.MenuItem{Label: PlainLabel(tr("Open time by function")), Disabled: notMainDisabled}
+	m.Analyze.OpenTopSpans = theme.MenuItem{Label: PlainLabel(tr("Find longest spans")), Disabled: notMainDisabled}
+	m.Analyze.OpenRegions = theme.MenuItem{Label: PlainLabel(tr("Open user region statistics")), Disabled: notMainDisabled}
+	m.Analyze.OpenFunctions = theme.MenuItem{Label: PlainLabel(tr("Search functions")), Disabled: notMainDisabled}
+	m.Analyze.OpenQueryConsole = theme.MenuItem{Label: PlainLabel(tr("Open query console")), Disabled: notMainDisabled}
+	m.Analyze.OpenWarnings = theme.MenuItem{Label: PlainLabel(tr("Show warnings")), Disabled: notMainDisabled}
+
+	m.Help.Help = theme.MenuItem{Label: PlainLabel(tr("Help…"))}
+	m.Help.ReleaseNotes = theme.MenuItem{Label: PlainLabel(tr("Release notes…"))}
 
 	m.menu = &theme.Menu{
 		Groups: []theme.MenuGroup{
 			{
-				Label: "File",
+				Label: tr("File"),
 				Items: []theme.Widget{
 					theme.NewMenuItemStyle(win.Theme, &m.File.OpenTrace).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.File.OpenTraceNewWindow).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.File.OpenTraceNewTab).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.File.BrowseTrace).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.File.OpenProfile).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.File.AddProcess).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.File.ImportOTel).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.File.CopyTabImage).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.File.CopyPanelImage).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.File.OpenRecentTrace).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.File.RecordTrace).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.File.AggregateTraces).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.File.KeyboardShortcuts).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.File.Notifications).Layout,
 					theme.NewMenuItemStyle(win.Theme, &m.File.Quit).Layout,
 				},
 			},
 			{
-				Label: "Display",
+				Label: tr("Display"),
 				Items: []theme.Widget{
 					// TODO(dh): disable Undo menu item when there are no more undo steps
 					theme.NewMenuItemStyle(win.Theme, &m.Display.UndoNavigation).Layout,
@@ -557,21 +980,81 @@ func NewMainMenu(mwin *MainWindow, win *theme.Window) *MainMenu {
 					theme.MenuDivider(win.Theme).Layout,
 
 					theme.NewMenuItemStyle(win.Theme, &m.Display.HighlightSpans).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Display.NextSearchResult).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Display.PrevSearchResult).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Display.FilterAllViews).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Display.FilterTimelines).Layout,
 
 					theme.MenuDivider(win.Theme).Layout,
 
 					theme.NewMenuItemStyle(win.Theme, &m.Display.ToggleCompactDisplay).Layout,
 					theme.NewMenuItemStyle(win.Theme, &m.Display.ToggleTimelineLabels).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Display.HideIdleGoroutines).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Display.HideShortLived).Layout,
 					theme.NewMenuItemStyle(win.Theme, &m.Display.ToggleStackTracks).Layout,
+
+					theme.MenuDivider(win.Theme).Layout,
+
+					theme.NewMenuItemStyle(win.Theme, &m.Display.TogglePanelArea).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Display.DockPanelRight).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Display.DockPanelBottom).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Display.DockPanelLeft).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Display.DockPanelTop).Layout,
+
+					theme.MenuDivider(win.Theme).Layout,
+
+					theme.NewMenuItemStyle(win.Theme, &m.Display.IncreaseScale).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Display.DecreaseScale).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Display.ResetScale).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Display.Fonts).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Display.SpanColors).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Display.Navigation).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Display.TimeFormat).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Display.Tooltips).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Display.Fidelity).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Display.ProcessOffsets).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Display.Language).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Display.Settings).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Display.TogglePerformanceHUD).Layout,
 					// TODO(dh): add items for STW and GC overlays
 					// TODO(dh): add item for tooltip display
 				},
 			},
 			{
-				Label: "Analyze",
+				Label: tr("Analyze"),
 				Items: []theme.Widget{
 					theme.NewMenuItemStyle(win.Theme, &m.Analyze.OpenHeatmap).Layout,
 					theme.NewMenuItemStyle(win.Theme, &m.Analyze.OpenFlameGraph).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Analyze.OpenLeaks).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Analyze.OpenContention).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Analyze.OpenGCAssist).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Analyze.OpenSyscalls).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Analyze.OpenSpawnRate).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Analyze.OpenRunQueues).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Analyze.OpenMigrations).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Analyze.OpenUtilization).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Analyze.OpenNetPoller).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Analyze.OpenInsights).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Analyze.OpenBookmarks).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Analyze.OpenUniqueStacks).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Analyze.OpenWaitForGraph).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Analyze.OpenHeap).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Analyze.OpenThreads).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Analyze.OpenTimers).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Analyze.OpenCgo).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Analyze.OpenSelfTime).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Analyze.OpenTopSpans).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Analyze.OpenRegions).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Analyze.OpenFunctions).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Analyze.OpenQueryConsole).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Analyze.OpenWarnings).Layout,
+				},
+			},
+			{
+				Label: tr("Help"),
+				Items: []theme.Widget{
+					theme.NewMenuItemStyle(win.Theme, &m.Help.Help).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Help.ReleaseNotes).Layout,
 				},
 			},
 		},
@@ -579,23 +1062,70 @@ func NewMainMenu(mwin *MainWindow, win *theme.Window) *MainMenu {
 
 	if softDebug {
 		m.menu.Groups = append(m.menu.Groups, theme.MenuGroup{
-			Label: "Debug",
+			Label: tr("Debug"),
 			Items: []theme.Widget{
 				theme.NewMenuItemStyle(win.Theme, &m.Debug.Cpuprofile).Layout,
 				theme.NewMenuItemStyle(win.Theme, &m.Debug.Memprofile).Layout,
 				theme.NewMenuItemStyle(win.Theme, &m.Debug.GC).Layout,
 				theme.NewMenuItemStyle(win.Theme, &m.Debug.FreeOSMemory).Layout,
+				theme.NewMenuItemStyle(win.Theme, &m.Debug.MemoryUsage).Layout,
 			},
 		})
 	}
 
+	m.Toolbar.init(m)
+	m.keymapGen = keymap.Generation()
+	m.setShortcuts()
+
 	return m
 }
 
+// updateShortcuts updates the shortcuts displayed in menu items if the keymap has changed.
+func (m *MainMenu) updateShortcuts() {
+	if gen := keymap.Generation(); gen != m.keymapGen {
+		m.keymapGen = gen
+		m.setShortcuts()
+	}
+}
+
+func (m *MainMenu) setShortcuts() {
+	m.File.OpenTrace.Shortcut = keymap.Label(keyOpenTrace)
+	m.File.OpenTraceNewWindow.Shortcut = keymap.Label(keyOpenTraceNewWindow)
+	m.File.OpenTraceNewTab.Shortcut = keymap.Label(keyOpenTraceNewTab)
+	m.File.Quit.Shortcut = keymap.Label(keyQuit)
+	m.Display.UndoNavigation.Shortcut = keymap.Label(keyUndoNavigation)
+	m.Display.RedoNavigation.Shortcut = keymap.Label(keyRedoNavigation)
+	m.Display.ScrollToTop.Shortcut = keymap.Label(keyScrollToTop)
+	m.Display.ZoomToFit.Shortcut = keymap.Label(keyZoomToFit)
+	m.Display.JumpToBeginning.Shortcut = keymap.Label(keyJumpToBeginning)
+	m.Display.HighlightSpans.Shortcut = keymap.Label(keyHighlightSpans)
+	m.Display.NextSearchResult.Shortcut = keymap.Label(keyNextSearchResult)
+	m.Display.PrevSearchResult.Shortcut = keymap.Label(keyPrevSearchResult)
+	m.Display.ToggleCompactDisplay.Shortcut = keymap.Label(keyToggleCompactDisplay)
+	m.Display.ToggleTimelineLabels.Shortcut = keymap.Label(keyToggleTimelineLabels)
+	m.Display.ToggleStackTracks.Shortcut = keymap.Label(keyToggleStackTracks)
+	m.Display.TogglePerformanceHUD.Shortcut = keymap.Label(keyTogglePerformanceHUD)
+	m.Display.IncreaseScale.Shortcut = keymap.Label(keyIncreaseScale)
+	m.Display.DecreaseScale.Shortcut = keymap.Label(keyDecreaseScale)
+	m.Display.ResetScale.Shortcut = keymap.Label(keyResetScale)
+	m.Toolbar.syncShortcuts()
+}
+
+func displayNotificationCenter(win *theme.Window) {
+	var state theme.NotificationCenterState
+	win.SetModal(func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+		return theme.Dialog(win.Theme, tr("Notifications")).Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+			gtx.Constraints.Min = gtx.Constraints.Constrain(image.Pt(800, 500))
+			gtx.Constraints.Max = gtx.Constraints.Min
+			return theme.NotificationCenter(&state).Layout(win, gtx)
+		})
+	})
+}
+
 func displayHighlightSpansDialog(win *theme.Window, filter *Filter) {
 	hd := HighlightDialog(win, filter)
 	win.SetModal(func(win *theme.Window, gtx layout.Context) layout.Dimensions {
-		return theme.Dialog(win.Theme, "Highlight spans").Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+		return theme.Dialog(win.Theme, tr("Highlight spans")).Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
 			gtx.Constraints.Min = gtx.Constraints.Constrain(image.Pt(1000, 500))
 			gtx.Constraints.Max = gtx.Constraints.Min
 			return hd.Layout(win, gtx)
@@ -611,8 +1141,17 @@ func (tlc *TimelinesComponent) Layout(win *theme.Window, gtx layout.Context) lay
 	return tlc.cv.Layout(win, gtx)
 }
 
+// activeFilters returns all filters that are in effect for the current trace: the global filter, which narrows the
+// canvas and the panels, and the canvas's highlight and timeline filters.
+func (mwin *MainWindow) activeFilters() []ActiveFilter {
+	cv := mwin.canvas
+	out := mwin.trace.filter.ActiveFilters(mwin.trace)
+	out = append(out, cv.timeline.filter.ActiveFilters()...)
+	return append(out, cv.timeline.goroutineFilter.ActiveFilters(cv.updateHiddenTimelines)...)
+}
+
 func (tlc *TimelinesComponent) Title() string {
-	return "Timelines"
+	return tr("Timelines")
 }
 
 func (tlc *TimelinesComponent) Transition(theme.ComponentState) {
@@ -641,6 +1180,10 @@ func (mwin *MainWindow) Run() error {
 
 		switch ev := e.(type) {
 		case system.DestroyEvent:
+			if err := mwin.saveLayout(); err != nil {
+				log.Println("couldn't save configuration:", err)
+			}
+			mwin.forgetSession()
 			return ev.Err
 		case system.FrameEvent:
 			if measureFrameAllocs {
@@ -665,19 +1208,27 @@ func (mwin *MainWindow) Run() error {
 					mwin.pointerAt = ev.(pointer.Event).Position
 				}
 
-				if mwin.mainMenu.Display.UndoNavigation.Clicked(gtx) {
+				mwin.autosave(gtx)
+
+				win.AddStatus(theme.StatusSegment{
+					Text:  "Memory: " + formatBytes(int64(mwin.memoryUsage(gtx.Now))),
+					Right: true,
+				})
+
+				tb := &mwin.mainMenu.Toolbar
+				if mwin.mainMenu.Display.UndoNavigation.Clicked(gtx) || tb.UndoNavigation.Clicked(gtx) {
 					win.Menu.Close()
 					mwin.canvas.UndoNavigation(gtx)
 				}
-				if mwin.mainMenu.Display.RedoNavigation.Clicked(gtx) {
+				if mwin.mainMenu.Display.RedoNavigation.Clicked(gtx) || tb.RedoNavigation.Clicked(gtx) {
 					win.Menu.Close()
 					mwin.canvas.RedoNavigation(gtx)
 				}
-				if mwin.mainMenu.Display.ScrollToTop.Clicked(gtx) {
+				if mwin.mainMenu.Display.ScrollToTop.Clicked(gtx) || tb.ScrollToTop.Clicked(gtx) {
 					win.Menu.Close()
 					mwin.canvas.ScrollToTop(gtx)
 				}
-				if mwin.mainMenu.Display.ZoomToFit.Clicked(gtx) {
+				if mwin.mainMenu.Display.ZoomToFit.Clicked(gtx) || tb.ZoomToFit.Clicked(gtx) {
 					win.Menu.Close()
 					mwin.canvas.ZoomToFitCurrentView(gtx)
 				}
@@ -685,10 +1236,26 @@ func (mwin *MainWindow) Run() error {
 					win.Menu.Close()
 					mwin.canvas.JumpToBeginning(gtx)
 				}
-				if mwin.mainMenu.Display.HighlightSpans.Clicked(gtx) {
+				if mwin.mainMenu.Display.HighlightSpans.Clicked(gtx) || tb.HighlightSpans.Clicked(gtx) {
 					win.Menu.Close()
 					displayHighlightSpansDialog(win, &mwin.canvas.timeline.filter)
 				}
+				if mwin.mainMenu.Display.NextSearchResult.Clicked(gtx) || tb.NextSearchResult.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.stepSearchResults(1)
+				}
+				if mwin.mainMenu.Display.PrevSearchResult.Clicked(gtx) || tb.PrevSearchResult.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.stepSearchResults(-1)
+				}
+				if mwin.mainMenu.Display.FilterAllViews.Clicked(gtx) {
+					win.Menu.Close()
+					displayGlobalFilterDialog(win, &mwin.trace.filter)
+				}
+				if mwin.mainMenu.Display.FilterTimelines.Clicked(gtx) {
+					win.Menu.Close()
+					displayTimelineFilterDialog(win, mwin.canvas)
+				}
 				if mwin.mainMenu.Display.ToggleCompactDisplay.Clicked(gtx) {
 					win.Menu.Close()
 					mwin.canvas.ToggleCompactDisplay()
@@ -697,10 +1264,98 @@ func (mwin *MainWindow) Run() error {
 					win.Menu.Close()
 					mwin.canvas.ToggleTimelineLabels()
 				}
+				if mwin.mainMenu.Display.HideIdleGoroutines.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.canvas.ToggleHideIdleGoroutines()
+					win.ShowNotification(gtx, local.Sprintf("%d timelines hidden", mwin.canvas.NumHiddenTimelines()))
+				}
+				if mwin.mainMenu.Display.HideShortLived.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.canvas.ToggleHideShortLivedGoroutines()
+					win.ShowNotification(gtx, local.Sprintf("%d timelines hidden", mwin.canvas.NumHiddenTimelines()))
+				}
 				if mwin.mainMenu.Display.ToggleStackTracks.Clicked(gtx) {
 					win.Menu.Close()
 					mwin.canvas.ToggleStackTracks()
 				}
+				if mwin.mainMenu.Display.TogglePerformanceHUD.Clicked(gtx) {
+					win.Menu.Close()
+					win.HUD.Enabled = !win.HUD.Enabled
+				}
+				if mwin.mainMenu.Display.TogglePanelArea.Clicked(gtx) || tb.TogglePanelArea.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.dock.Hidden = !mwin.dock.Hidden
+					if err := mwin.saveLayout(); err != nil {
+						win.Notify(theme.NotificationError, local.Sprintf("Couldn't save configuration: %s", err))
+					}
+				}
+				for side, item := range []*theme.MenuItem{
+					theme.DockRight:  &mwin.mainMenu.Display.DockPanelRight,
+					theme.DockBottom: &mwin.mainMenu.Display.DockPanelBottom,
+					theme.DockLeft:   &mwin.mainMenu.Display.DockPanelLeft,
+					theme.DockTop:    &mwin.mainMenu.Display.DockPanelTop,
+				} {
+					if item.Clicked(gtx) {
+						win.Menu.Close()
+						mwin.dock.Side = theme.DockSide(side)
+						mwin.dock.Hidden = false
+						if err := mwin.saveLayout(); err != nil {
+							win.Notify(theme.NotificationError, local.Sprintf("Couldn't save configuration: %s", err))
+						}
+					}
+				}
+				if mwin.mainMenu.Display.IncreaseScale.Clicked(gtx) {
+					win.Menu.Close()
+					win.SetScale(win.Scale() + theme.ScaleStep)
+				}
+				if mwin.mainMenu.Display.DecreaseScale.Clicked(gtx) {
+					win.Menu.Close()
+					win.SetScale(win.Scale() - theme.ScaleStep)
+				}
+				if mwin.mainMenu.Display.ResetScale.Clicked(gtx) {
+					win.Menu.Close()
+					win.SetScale(1)
+				}
+				if mwin.mainMenu.Display.Fonts.Clicked(gtx) {
+					win.Menu.Close()
+					displayFontsDialog(win)
+				}
+				if mwin.mainMenu.Display.SpanColors.Clicked(gtx) {
+					win.Menu.Close()
+					displayColorRulesDialog(win)
+				}
+				if mwin.mainMenu.Display.Navigation.Clicked(gtx) {
+					win.Menu.Close()
+					displayNavigationDialog(win)
+				}
+				if mwin.mainMenu.Display.TimeFormat.Clicked(gtx) {
+					win.Menu.Close()
+					displayTimeFormatDialog(win)
+				}
+				if mwin.mainMenu.Display.Tooltips.Clicked(gtx) {
+					win.Menu.Close()
+					displayTooltipsDialog(win)
+				}
+				if mwin.mainMenu.Display.Fidelity.Clicked(gtx) {
+					win.Menu.Close()
+					displayFidelityDialog(win)
+				}
+				if mwin.mainMenu.Display.ProcessOffsets.Clicked(gtx) {
+					win.Menu.Close()
+					displayProcessOffsetsDialog(mwin, win)
+				}
+				if mwin.mainMenu.Display.Language.Clicked(gtx) {
+					win.Menu.Close()
+					displayLocaleDialog(win)
+				}
+				if mwin.mainMenu.Display.Settings.Clicked(gtx) {
+					win.Menu.Close()
+					displaySettingsDialog(win)
+				}
+				// The scale may also have been changed by keyboard shortcuts.
+				if err := mwin.saveScale(); err != nil {
+					win.Notify(theme.NotificationError, local.Sprintf("Couldn't save configuration: %s", err))
+				}
 				if mwin.mainMenu.Analyze.OpenHeatmap.Clicked(gtx) {
 					win.Menu.Close()
 					mwin.openHeatmap()
@@ -709,14 +1364,106 @@ func (mwin *MainWindow) Run() error {
 					win.Menu.Close()
 					mwin.openFlameGraph(nil)
 				}
+				if mwin.mainMenu.Analyze.OpenLeaks.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.openLeaks()
+				}
+				if mwin.mainMenu.Analyze.OpenContention.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.openContention()
+				}
+				if mwin.mainMenu.Analyze.OpenGCAssist.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.openGCAssist()
+				}
+				if mwin.mainMenu.Analyze.OpenSyscalls.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.openSyscalls()
+				}
+				if mwin.mainMenu.Analyze.OpenSpawnRate.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.openSpawnRate()
+				}
+				if mwin.mainMenu.Analyze.OpenRunQueues.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.openRunQueues()
+				}
+				if mwin.mainMenu.Analyze.OpenMigrations.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.openMigrations()
+				}
+				if mwin.mainMenu.Analyze.OpenUtilization.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.openUtilization()
+				}
+				if mwin.mainMenu.Analyze.OpenNetPoller.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.openNetPoller()
+				}
+				if mwin.mainMenu.Analyze.OpenInsights.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.openInsights()
+				}
+				if mwin.mainMenu.Analyze.OpenBookmarks.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.openBookmarks()
+				}
+				if mwin.mainMenu.Analyze.OpenUniqueStacks.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.openUniqueStacks()
+				}
+				if mwin.mainMenu.Analyze.OpenWaitForGraph.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.openWaitForGraph(mwin.canvas.start, mwin.canvas.End())
+				}
+				if mwin.mainMenu.Analyze.OpenHeap.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.openHeap()
+				}
+				if mwin.mainMenu.Analyze.OpenThreads.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.openThreads()
+				}
+				if mwin.mainMenu.Analyze.OpenTimers.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.openTimers()
+				}
+				if mwin.mainMenu.Analyze.OpenCgo.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.openCgo()
+				}
+				if mwin.mainMenu.Analyze.OpenSelfTime.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.openSelfTime()
+				}
+				if mwin.mainMenu.Analyze.OpenTopSpans.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.openTopSpans()
+				}
+				if mwin.mainMenu.Analyze.OpenRegions.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.openRegions()
+				}
+				if mwin.mainMenu.Analyze.OpenFunctions.Clicked(gtx) || tb.SearchFunctions.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.openFunctionSearch()
+				}
+				if mwin.mainMenu.Analyze.OpenQueryConsole.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.openQueryConsole()
+				}
+				if mwin.mainMenu.Analyze.OpenWarnings.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.openPanel(NewWarningsComponent(mwin.trace))
+				}
 				if mwin.mainMenu.Debug.Cpuprofile.Clicked(gtx) {
 					win.Menu.Close()
 					if mwin.cpuProfile != nil {
 						pprof.StopCPUProfile()
 						if err := mwin.cpuProfile.Close(); err == nil {
-							win.ShowNotification(gtx, fmt.Sprintf("Wrote CPU profile to %s", mwin.cpuProfile.Name()))
+							win.ShowNotification(gtx, local.Sprintf("Wrote CPU profile to %s", mwin.cpuProfile.Name()))
 						} else {
-							win.ShowNotification(gtx, fmt.Sprintf("Couldn't write CPU profile: %s", err))
+							win.Notify(theme.NotificationError, local.Sprintf("Couldn't write CPU profile: %s", err))
 						}
 						mwin.cpuProfile = nil
 					} else {
@@ -733,9 +1480,9 @@ func (mwin *MainWindow) Run() error {
 							return f, path, nil
 						}()
 						if err == nil {
-							win.ShowNotification(gtx, fmt.Sprintf("Writing CPU profile to %s…", path))
+							win.ShowNotification(gtx, local.Sprintf("Writing CPU profile to %s…", path))
 						} else {
-							win.ShowNotification(gtx, fmt.Sprintf("Couldn't start CPU profile: %s", err))
+							win.Notify(theme.NotificationError, local.Sprintf("Couldn't start CPU profile: %s", err))
 						}
 						mwin.cpuProfile = f
 					}
@@ -755,9 +1502,9 @@ func (mwin *MainWindow) Run() error {
 						return path, f.Close()
 					}()
 					if err == nil {
-						win.ShowNotification(gtx, fmt.Sprintf("Wrote memory profile to %s", path))
+						win.ShowNotification(gtx, local.Sprintf("Wrote memory profile to %s", path))
 					} else {
-						win.ShowNotification(gtx, fmt.Sprintf("Couldn't write memory profile: %s", err))
+						win.Notify(theme.NotificationError, local.Sprintf("Couldn't write memory profile: %s", err))
 					}
 				}
 				if mwin.mainMenu.Debug.GC.Clicked(gtx) {
@@ -765,12 +1512,16 @@ func (mwin *MainWindow) Run() error {
 					start := time.Now()
 					runtime.GC()
 					d := time.Since(start)
-					win.ShowNotification(gtx, fmt.Sprintf("Ran garbage collection in %s", d))
+					win.ShowNotification(gtx, local.Sprintf("Ran garbage collection in %s", d))
 				}
 				if mwin.mainMenu.Debug.FreeOSMemory.Clicked(gtx) {
 					win.Menu.Close()
 					rdebug.FreeOSMemory()
-					win.ShowNotification(gtx, "Returned unused memory to OS")
+					win.ShowNotification(gtx, tr("Returned unused memory to OS"))
+				}
+				if mwin.mainMenu.Debug.MemoryUsage.Clicked(gtx) {
+					win.Menu.Close()
+					win.ShowNotification(gtx, mwin.canvas.memoryUsage())
 				}
 				if mwin.mainMenu.File.Quit.Clicked(gtx) {
 					win.Menu.Close()
@@ -780,6 +1531,71 @@ func (mwin *MainWindow) Run() error {
 					win.Menu.Close()
 					mwin.showFileOpenDialog()
 				}
+				if mwin.mainMenu.File.OpenTraceNewWindow.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.showFileOpenDialogNewWindow()
+				}
+				if mwin.mainMenu.File.OpenTraceNewTab.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.showFileOpenDialogNewTab()
+				}
+				if mwin.mainMenu.File.BrowseTrace.Clicked(gtx) {
+					win.Menu.Close()
+					displayFileBrowser(win, mwin.openTraceFile)
+				}
+				if mwin.mainMenu.File.OpenProfile.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.openProfile()
+				}
+				if mwin.mainMenu.File.AddProcess.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.openProcess()
+				}
+				if mwin.mainMenu.File.ImportOTel.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.importOTel()
+				}
+				if mwin.mainMenu.File.CopyTabImage.Clicked(gtx) {
+					win.Menu.Close()
+					if i := mwin.tabbedState.Current; i >= 0 && i < len(mwin.tabs) {
+						mwin.copyComponentImage(win, gtx, mwin.tabs[i].Component, mwin.tabSize)
+					}
+				}
+				if mwin.mainMenu.File.CopyPanelImage.Clicked(gtx) {
+					win.Menu.Close()
+					if p := mwin.panels.current(); p != nil {
+						mwin.copyComponentImage(win, gtx, p, mwin.panels.panelSize)
+					}
+				}
+				if mwin.mainMenu.File.RecordTrace.Clicked(gtx) {
+					win.Menu.Close()
+					displayRecordDialog(mwin, win)
+				}
+				if mwin.mainMenu.File.AggregateTraces.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.openPanelWindow(NewAggregateComponent())
+				}
+				if mwin.mainMenu.File.OpenRecentTrace.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.showRecentTraces()
+				}
+				if mwin.mainMenu.File.KeyboardShortcuts.Clicked(gtx) {
+					win.Menu.Close()
+					displayKeybindingsDialog(win)
+				}
+				if mwin.mainMenu.File.Notifications.Clicked(gtx) {
+					win.Menu.Close()
+					displayNotificationCenter(win)
+				}
+				if mwin.mainMenu.Help.Help.Clicked(gtx) {
+					win.Menu.Close()
+					displayHelpDialog(win)
+				}
+				if mwin.mainMenu.Help.ReleaseNotes.Clicked(gtx) {
+					win.Menu.Close()
+					displayReleaseNotesDialog(win)
+				}
+				mwin.mainMenu.updateShortcuts()
 
 				for _, ev := range gtx.Events(profileTag) {
 					// Yup, profile.Event only contains a string. No structured access to data.
@@ -837,14 +1653,20 @@ func (mwin *MainWindow) Run() error {
 				theme.Fill(win, gtx.Ops, mwin.twin.Theme.Palette.Background)
 
 				var unhandledShortcuts []theme.Shortcut
-				win.AddShortcut(theme.Shortcut{Modifiers: key.ModShortcut, Name: "O"})
-				win.AddShortcut(theme.Shortcut{Modifiers: key.ModShortcut, Name: "Q"})
+				keymap.Register(win, keyOpenTrace, keyOpenTraceNewWindow, keyOpenTraceNewTab, keyQuit, keyTogglePerformanceHUD, keyIncreaseScale, keyDecreaseScale, keyResetScale)
 				for _, s := range win.PressedShortcuts() {
-					switch s {
-					case theme.Shortcut{Modifiers: key.ModShortcut, Name: "O"}:
+					switch {
+					case handleScaleShortcut(win, s):
+					case keymap.Matches(keyOpenTrace, s):
 						mwin.showFileOpenDialog()
-					case theme.Shortcut{Modifiers: key.ModShortcut, Name: "Q"}:
+					case keymap.Matches(keyOpenTraceNewWindow, s):
+						mwin.showFileOpenDialogNewWindow()
+					case keymap.Matches(keyOpenTraceNewTab, s):
+						mwin.showFileOpenDialogNewTab()
+					case keymap.Matches(keyQuit, s):
 						os.Exit(0)
+					case keymap.Matches(keyTogglePerformanceHUD, s):
+						win.HUD.Enabled = !win.HUD.Enabled
 					default:
 						unhandledShortcuts = append(unhandledShortcuts, s)
 					}
@@ -881,115 +1703,211 @@ func (mwin *MainWindow) renderStartScene(win *theme.Window, gtx layout.Context)
 	for mwin.openTraceButton.Clicked(gtx) {
 		mwin.showFileOpenDialog()
 	}
+	for mwin.startScene.browse.Clicked(gtx) {
+		displayFileBrowser(win, mwin.openTraceFile)
+	}
+
+	if win.Frame != mwin.startScene.frame+1 {
+		// Reload the recent traces when the start scene gets displayed, as other windows may have opened traces in
+		// the meantime.
+		mwin.startScene.recent = recentTraces()
+		mwin.startScene.recentButtons = make([]widget.PrimaryClickable, len(mwin.startScene.recent))
+	}
+	mwin.startScene.frame = win.Frame
+	for i := range mwin.startScene.recentButtons {
+		for mwin.startScene.recentButtons[i].Clicked(gtx) {
+			mwin.openTraceFile(mwin.startScene.recent[i].Path)
+		}
+	}
+
+	recovery := pendingRecovery.Load()
+	if recovery != nil {
+		if len(mwin.startScene.restoreButtons) != len(recovery.Sessions) {
+			mwin.startScene.restoreButtons = make([]widget.PrimaryClickable, len(recovery.Sessions))
+		}
+		for i := range mwin.startScene.restoreButtons {
+			for mwin.startScene.restoreButtons[i].Clicked(gtx) {
+				mwin.restoreSession(recovery, i)
+			}
+		}
+		for mwin.startScene.showCrash.Clicked(gtx) {
+			mwin.openPanelWindow(NewCrashReportComponent(recovery.Crash))
+		}
+		for mwin.startScene.discard.Clicked(gtx) {
+			pendingRecovery.CompareAndSwap(recovery, nil)
+		}
+	}
+
 	return layout.Center.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
 		gtx.Constraints.Min.X = gtx.Constraints.Max.X
-		return layout.Rigids(gtx, layout.Vertical,
+		children := []layout.Widget{
 			func(gtx layout.Context) layout.Dimensions {
 				return layout.Center.Layout(gtx, widget.Image{Src: assets.Image(gtx, "logo", 128), Scale: 1.0 / gtx.Metric.PxPerDp}.Layout)
 			},
 
 			func(gtx layout.Context) layout.Dimensions {
-				return layout.Center.Layout(gtx, theme.Dumb(win, theme.Button(win.Theme, &mwin.openTraceButton.Clickable, "Open trace").Layout))
+				return layout.Center.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+					return layout.Rigids(gtx, layout.Horizontal,
+						theme.Dumb(win, theme.Button(win.Theme, &mwin.openTraceButton.Clickable, tr("Open trace")).Layout),
+						layout.Spacer{Width: 5}.Layout,
+						theme.Dumb(win, theme.Button(win.Theme, &mwin.startScene.browse.Clickable, tr("Browse…")).Layout),
+					)
+				})
 			},
-		)
+		}
+
+		if recovery != nil {
+			children = append(children,
+				layout.Spacer{Height: 20}.Layout,
+				func(gtx layout.Context) layout.Dimensions {
+					l := theme.LineLabel(win.Theme, tr("gotraceui didn't exit cleanly the last time it ran"))
+					l.Font.Weight = font.Bold
+					return layout.Center.Layout(gtx, theme.Dumb(win, l.Layout))
+				},
+			)
+			for i := range recovery.Sessions {
+				s := &recovery.Sessions[i]
+				children = append(children, func(gtx layout.Context) layout.Dimensions {
+					return layout.Center.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+						return mwin.startScene.restoreButtons[i].Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+							l := theme.LineLabel(win.Theme, local.Sprintf("Restore %s (%s)", s.Trace, s.Description()))
+							l.Color = win.Theme.Palette.Link
+							return l.Layout(win, gtx)
+						})
+					})
+				})
+			}
+			children = append(children,
+				layout.Spacer{Height: 5}.Layout,
+				func(gtx layout.Context) layout.Dimensions {
+					return layout.Center.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+						var buttons []layout.Widget
+						if recovery.Crash != "" {
+							buttons = append(buttons,
+								theme.Dumb(win, theme.Button(win.Theme, &mwin.startScene.showCrash.Clickable, tr("Show crash report")).Layout),
+								layout.Spacer{Width: 5}.Layout,
+							)
+						}
+						buttons = append(buttons, theme.Dumb(win, theme.Button(win.Theme, &mwin.startScene.discard.Clickable, tr("Discard")).Layout))
+						return layout.Rigids(gtx, layout.Horizontal, buttons...)
+					})
+				},
+			)
+		}
+
+		if len(mwin.startScene.recent) > 0 {
+			children = append(children,
+				layout.Spacer{Height: 20}.Layout,
+				func(gtx layout.Context) layout.Dimensions {
+					l := theme.LineLabel(win.Theme, tr("Recent traces"))
+					l.Font.Weight = font.Bold
+					return layout.Center.Layout(gtx, theme.Dumb(win, l.Layout))
+				},
+			)
+			for i := range mwin.startScene.recent {
+				rt := &mwin.startScene.recent[i]
+				children = append(children, func(gtx layout.Context) layout.Dimensions {
+					return layout.Center.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+						return mwin.startScene.recentButtons[i].Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+							l := theme.LineLabel(win.Theme, fmt.Sprintf("%s (%s)", rt.Path, rt.Description()))
+							l.Color = win.Theme.Palette.Link
+							return l.Layout(win, gtx)
+						})
+					})
+				})
+			}
+		}
+
+		return layout.Rigids(gtx, layout.Vertical, children...)
 	})
 }
 
+func (mwin *MainWindow) showRecentTraces() {
+	pl := theme.CommandPalette{Prompt: tr("Open recent trace")}
+	pl.Set(RecentTracesCommandProvider(recentTraces()))
+	mwin.twin.SetModal(pl.Layout)
+}
+
 func (mwin *MainWindow) renderErrorScene(win *theme.Window, gtx layout.Context) layout.Dimensions {
 	gtx.Constraints.Min = gtx.Constraints.Max
 	return layout.Center.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
-		return theme.Dialog(win.Theme, "Error").Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+		return theme.Dialog(win.Theme, tr("Error")).Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
 			return theme.Label(win.Theme, mwin.err.Error()).Layout(win, gtx)
 		})
 	})
 }
 
 func (mwin *MainWindow) renderLoadingTraceScene(win *theme.Window, gtx layout.Context) layout.Dimensions {
-	paint.ColorOp{Color: mwin.twin.ConvertColor(mwin.twin.Theme.Palette.Foreground)}.Add(gtx.Ops)
-
-	// Redraw continuously to show progress updates
-	op.InvalidateOp{}.Add(gtx.Ops)
-
-	// OPT(dh): only compute this once
-	var maxNameWidth int
-	for _, name := range mwin.progressStages {
-		width := theme.Label(win.Theme, name).Length(win, gtx)
-		if width > maxNameWidth {
-			maxNameWidth = width
-		}
-	}
-	maxLabelWidth := maxNameWidth
-	{
-		width := theme.Label(win.Theme, fmt.Sprintf("100.00%% | (%d/%d) ", len(mwin.progressStages), len(mwin.progressStages))).Length(win, gtx)
-		maxLabelWidth += width
+	if mwin.loading == nil {
+		// OpenTrace hasn't started the operation yet.
+		return layout.Dimensions{}
 	}
 
 	gtx.Constraints.Min = gtx.Constraints.Max
 	return layout.Center.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
-		return theme.Dialog(win.Theme, "Opening trace").Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
-			progress := math.Float64frombits(mwin.progress.Load())
-			return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
-				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-					var name string
-					if mwin.progressStage < len(mwin.progressStages) {
-						name = mwin.progressStages[mwin.progressStage]
-					} else {
-						name = "Unknown"
-					}
-					gtx.Constraints.Min.X = gtx.Constraints.Constrain(image.Pt(maxLabelWidth, 0)).X
-					gtx.Constraints.Max.X = gtx.Constraints.Min.X
-					pct := fmt.Sprintf("%5.2f%%", progress*100)
-					// Replace space with figure space for correct alignment
-					pct = strings.ReplaceAll(pct, " ", "\u2007")
-					return theme.Label(
-						win.Theme,
-						fmt.Sprintf("%s | (%d/%d) %s",
-							pct, mwin.progressStage+1, len(mwin.progressStages), name)).Layout(win, gtx)
-				}),
-				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-					gtx.Constraints.Min = gtx.Constraints.Constrain(image.Pt(maxLabelWidth, 15))
-					gtx.Constraints.Max = gtx.Constraints.Min
-					return theme.ProgressBar(mwin.twin.Theme, float32(progress)).Layout(win, gtx)
-				}))
+		return theme.Dialog(win.Theme, tr("Opening trace")).Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+			return theme.OperationProgress(mwin.loading, &mwin.loadingState).Layout(win, gtx)
 		})
 	})
 }
 
 func (mwin *MainWindow) renderMainScene(win *theme.Window, gtx layout.Context, shortcuts []theme.Shortcut) layout.Dimensions {
-	win.AddShortcut(theme.Shortcut{Name: "G"})
-	win.AddShortcut(theme.Shortcut{Name: "H"})
+	mwin.updateTraceTabs(gtx)
+	keymap.Register(win, keyScrollToTimeline, keyHighlightSpans, keyNextSearchResult, keyPrevSearchResult)
 
 	for _, s := range shortcuts {
-		switch s {
-		case theme.Shortcut{Name: "G"}:
-			pl := &theme.CommandPalette{Prompt: "Scroll to timeline"}
+		switch {
+		case keymap.Matches(keyScrollToTimeline, s):
+			pl := &theme.CommandPalette{Prompt: tr("Scroll to timeline")}
 			pl.Set(ScrollToTimelineCommandProvider{mwin.twin, mwin.canvas.timelines})
 			win.SetModal(pl.Layout)
 
-		case theme.Shortcut{Name: "H"}:
+		case keymap.Matches(keyHighlightSpans, s):
 			displayHighlightSpansDialog(win, &mwin.canvas.timeline.filter)
+
+		case keymap.Matches(keyNextSearchResult, s):
+			mwin.stepSearchResults(1)
+
+		case keymap.Matches(keyPrevSearchResult, s):
+			mwin.stepSearchResults(-1)
+		}
+	}
+
+	// Changing the highlighted spans is a search. Collect its results so that the user can step through them.
+	if f := mwin.canvas.timeline.filter; f.Active() && (mwin.searchResults == nil || mwin.searchResults.query != f) {
+		var prev Filter
+		if mwin.searchResults == nil {
+			mwin.searchResults = NewSearchResultsComponent(mwin.trace, mwin.canvas)
+		} else {
+			prev = mwin.searchResults.query
+		}
+		mwin.searchResults.SetQuery(win, f)
+		// Brushing spans in a histogram also highlights them, but replacing the histogram's panel would get in the way
+		// of brushing.
+		if f.States != prev.States {
+			mwin.showSearchResults()
 		}
 	}
 
 	mwin.canvas.indicateTimestamp = container.None[exptrace.Time]()
-	if mwin.panel != nil {
-		if l := mwin.panel.HoveredLink(); l != nil {
+	for _, p := range mwin.panels.visible() {
+		if l := p.HoveredLink(); l != nil {
 			switch a := l.Action(0).(type) {
 			case ScrollToTimestampAction:
 				mwin.canvas.indicateTimestamp = container.Some(exptrace.Time(a))
 			}
 		}
 
-		switch state := mwin.panel.WantsTransition(gtx); state {
+		switch state := p.WantsTransition(gtx); state {
 		case theme.ComponentStateClosed:
-			mwin.prevPanel()
+			mwin.panels.back(p)
 		case theme.ComponentStateWindow:
-			mwin.openPanelWindow(mwin.panel)
-			mwin.prevPanel()
+			mwin.openPanelWindow(p)
+			mwin.panels.back(p)
 		case theme.ComponentStateTab:
-			mwin.panel.Transition(theme.ComponentStateTab)
-			mwin.openTab(Tab{Component: mwin.panel})
-			mwin.prevPanel()
+			p.Transition(theme.ComponentStateTab)
+			mwin.openTab(Tab{Component: p})
+			mwin.panels.back(p)
 		case theme.ComponentStatePanel, theme.ComponentStateNone:
 			// Nothing to do
 		default:
@@ -998,6 +1916,13 @@ func (mwin *MainWindow) renderMainScene(win *theme.Window, gtx layout.Context, s
 	}
 
 	mwin.subwindowsMu.RLock()
+	if gen := win.Selection.Generation(); gen != mwin.selectionGen {
+		// Panels in other windows may have to highlight the new selection.
+		mwin.selectionGen = gen
+		for w := range mwin.subwindows {
+			w.Invalidate()
+		}
+	}
 	for w := range mwin.subwindows {
 		if l := w.HoveredLink(); l != nil {
 			// TODO(dh): factor out into own function, remove duplication from here and earlier
@@ -1025,24 +1950,49 @@ func (mwin *MainWindow) renderMainScene(win *theme.Window, gtx layout.Context, s
 		for i, tab := range mwin.tabs {
 			titles[i] = tab.Title()
 		}
-		return theme.Tabbed(&mwin.tabbedState, titles).Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
-			gtx.Constraints.Min = gtx.Constraints.Max
-			if mwin.tabbedState.Current < 0 {
-				return layout.Dimensions{}
-			}
+		tabs := func(gtx layout.Context) layout.Dimensions {
+			return theme.Tabbed(&mwin.tabbedState, titles).Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+				gtx.Constraints.Min = gtx.Constraints.Max
+				if mwin.tabbedState.Current < 0 {
+					return layout.Dimensions{}
+				}
 
-			return mwin.tabs[mwin.tabbedState.Current].Layout(win, gtx)
-		})
-	}
-	panelArea := func(win *theme.Window, gtx layout.Context) layout.Dimensions {
-		if mwin.panel != nil {
-			return mwin.panel.Layout(win, gtx)
-		} else {
-			return layout.Dimensions{Size: gtx.Constraints.Constrain(image.Point{})}
+				tab := mwin.tabs[mwin.tabbedState.Current]
+				mwin.tabSize = gtx.Constraints.Max
+				defer win.HUD.StartRegion("tab", titles[mwin.tabbedState.Current]).End()
+				return tab.Layout(win, gtx)
+			})
 		}
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				if mwin.trace.ThinnedSpans == 0 {
+					return layout.Dimensions{}
+				}
+				msg := local.Sprintf("Reduced fidelity: %d spans shorter than %s were merged into the spans preceding them.",
+					mwin.trace.ThinnedSpans, mwin.trace.ThinningThreshold)
+				l := theme.LineLabel(win.Theme, msg)
+				l.Color = colors[colorStateBlocked]
+				return layout.UniformInset(2).Layout(gtx, theme.Dumb(win, l.Layout))
+			}),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return mwin.filters.Layout(win, gtx, mwin.activeFilters)
+			}),
+			layout.Flexed(1, tabs),
+		)
 	}
-
-	dims = theme.Resize(win.Theme, &mwin.resize).Layout(win, gtx, mainArea, panelArea)
+	dims = layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			tb := &mwin.mainMenu.Toolbar
+			tb.TogglePanelArea.Selected = !mwin.dock.Hidden
+			return theme.NewToolbarStyle(win.Theme, &tb.toolbar).Layout(win, gtx)
+		}),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return mwin.layoutTraceTabs(win, gtx)
+		}),
+		layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+			return theme.Dock(win.Theme, &mwin.dock).Layout(win, gtx, mainArea, mwin.panels.Layout)
+		}),
+	)
 
 	func() {
 		// Display a dancing gopher while we're computing textures or unpacking stack tracks.
@@ -1069,9 +2019,12 @@ func (mwin *MainWindow) renderMainScene(win *theme.Window, gtx layout.Context, s
 		}
 	}()
 
+	mwin.layoutBackgroundOperations(win, gtx)
+
 	// TODO(dh): add a public API to Canvas
 	for _, tl := range mwin.canvas.clickedTimelines {
 		if g, ok := tl.item.(*ptrace.Goroutine); ok {
+			win.Selection.Set(g)
 			mwin.openGoroutine(g)
 			// FIXME(dh): canvas does event handling _after_ layout, so we need a second frame
 			op.InvalidateOp{}.Add(gtx.Ops)
@@ -1098,6 +2051,44 @@ func (mwin *MainWindow) renderMainScene(win *theme.Window, gtx layout.Context, s
 }
 
 func (mwin *MainWindow) showFileOpenDialog() {
+	mwin.chooseTraceFile(func(rc io.ReadCloser) {
+		defer rc.Close()
+		if f, ok := rc.(interface{ Name() string }); ok {
+			addRecentTrace(mwin.twin, f.Name())
+		}
+		mwin.OpenTrace(rc)
+	})
+}
+
+// showFileOpenDialogNewWindow lets the user choose a trace and opens it in a new main window, keeping the current trace
+// open.
+func (mwin *MainWindow) showFileOpenDialogNewWindow() {
+	mwin.chooseTraceFile(func(rc io.ReadCloser) {
+		nwin := openMainWindow()
+		if f, ok := rc.(interface{ Name() string }); ok {
+			addRecentTrace(mwin.twin, f.Name())
+		}
+		nwin.SetState("loadingTrace")
+		go func() {
+			defer rc.Close()
+			nwin.OpenTrace(rc)
+		}()
+	})
+}
+
+// showFileOpenDialogNewTab lets the user choose a trace and opens it in a new tab, keeping the current trace open.
+func (mwin *MainWindow) showFileOpenDialogNewTab() {
+	mwin.chooseTraceFile(func(rc io.ReadCloser) {
+		defer rc.Close()
+		if f, ok := rc.(interface{ Name() string }); ok {
+			addRecentTrace(mwin.twin, f.Name())
+		}
+		mwin.OpenTraceInNewTab(rc)
+	})
+}
+
+// chooseTraceFile displays the file system dialog and calls fn, in a new goroutine, with the chosen file.
+func (mwin *MainWindow) chooseTraceFile(fn func(rc io.ReadCloser)) {
 	if mwin.showingExplorer.CompareAndSwap(false, true) {
 		go func() {
 			rc, err := mwin.explorer.ChooseFile()
@@ -1113,14 +2104,21 @@ func (mwin *MainWindow) showFileOpenDialog() {
 				mwin.SetError(err)
 				return
 			}
-			defer rc.Close()
-			mwin.OpenTrace(rc)
+			fn(rc)
 		}()
 	}
 }
 
 func (mwin *MainWindow) loadTraceImpl(res loadTraceResult) {
-	NewCanvasInto(&mwin.canvas, mwin.debugWindow, res.trace)
+	if res.newTab || mwin.activeTrace == nil {
+		mwin.newTraceTab(res.canvas)
+	}
+	// The window title is set to the name of the trace file, which helps with telling apart the windows of multiple
+	// traces.
+	mwin.activeTrace.title = res.title
+	mwin.win.Option(app.Title("gotraceui - " + res.title))
+
+	NewCanvasInto(mwin.canvas, mwin.debugWindow, res.trace)
 	mwin.canvas.memoryGraph = res.plot
 	mwin.canvas.goroutineGraph = res.goroutinePlot
 	mwin.canvas.timelines = append(mwin.canvas.timelines, res.timelines...)
@@ -1131,8 +2129,10 @@ func (mwin *MainWindow) loadTraceImpl(res loadTraceResult) {
 	}
 
 	mwin.trace = res.trace
-	mwin.panel = nil
-	mwin.panelHistory = nil
+	mwin.tracePath = res.path
+	mwin.panels.clear()
+	mwin.searchResults = nil
+	mwin.processes = nil
 	mwin.tabs = mwin.tabs[:1]
 	mwin.tabbedState.Current = 0
 	mwin.openTabBg(Tab{
@@ -1143,6 +2143,20 @@ func (mwin *MainWindow) loadTraceImpl(res loadTraceResult) {
 		Component:  NewTasksComponent(mwin.trace.Tasks, res.trace),
 		Unclosable: true,
 	})
+
+	userConfigMu.Lock()
+	openInsights := userConfig.OpenInsights
+	userConfigMu.Unlock()
+	if openInsights {
+		mwin.openTabBg(Tab{Component: NewInsightsComponent(mwin.twin, res.trace)})
+	}
+	mwin.startExternalSources()
+	mwin.loadProcessFiles()
+	mwin.applyRestoredSession()
+
+	if n := len(res.trace.Warnings); n > 0 {
+		mwin.twin.Notify(theme.NotificationWarning, local.Sprintf("Encountered %d problems while loading the trace. See Analyze → Show warnings.", n))
+	}
 }
 
 type durationNumberFormat uint8
@@ -1154,7 +2168,12 @@ const (
 	durationNumberFormatExact
 )
 
+// roundDuration rounds d to three significant digits, unless the user asked for durations to be displayed with full
+// precision.
 func roundDuration(d time.Duration) time.Duration {
+	if currentTimeFormatConfig().ExactDurations {
+		return d
+	}
 	switch {
 	case d < time.Millisecond:
 		return d
@@ -1245,7 +2264,11 @@ func (nf durationNumberFormat) format(d time.Duration) (value string, unit strin
 			prec = 9
 			unit = " s"
 		}
-		w, u = fmtFrac(buf[:w], u, prec, 3)
+		numSig := 3
+		if currentTimeFormatConfig().ExactDurations {
+			numSig = prec
+		}
+		w, u = fmtFrac(buf[:w], u, prec, numSig)
 		w = fmtInt(buf[:w], u)
 
 		return string(buf[w:]), unit
@@ -1263,6 +2286,7 @@ func scientificDuration(d time.Duration, digits int) string {
 
 type Window interface {
 	Run(win *app.Window) error
+	Invalidate()
 	HoveredLinker
 }
 
@@ -1271,7 +2295,7 @@ func span(win *theme.Window, text string) styledtext.SpanStyle {
 		Content: text,
 		Size:    win.Theme.TextSize,
 		Color:   win.ConvertColor(win.Theme.Palette.Foreground),
-		Font:    ourfont.Collection()[0].Font,
+		Font:    font.Font{},
 	}
 }
 
@@ -1279,6 +2303,9 @@ var local = message.NewPrinter(message.MatchLanguage("en"))
 
 // OPT(dh): find all calls of this function with a nil NumberFormatter and fix them
 func formatTimestamp(nf *NumberFormatter[AdjustedTime], ts AdjustedTime) string {
+	if decimals := currentTimeFormatConfig().TimestampDecimals; decimals > 0 {
+		return formatSeconds(ts, decimals)
+	}
 	if nf != nil {
 		return nf.Format("%d ns", ts)
 	} else {
@@ -1286,12 +2313,14 @@ func formatTimestamp(nf *NumberFormatter[AdjustedTime], ts AdjustedTime) string
 	}
 }
 
-func openTraceFromCmdline(mwin *MainWindow) {
-	f, err := os.Open(flag.Args()[0])
+// openTraceFile opens the trace at path, replacing the currently open trace.
+func (mwin *MainWindow) openTraceFile(path string) {
+	f, err := uiFileSystem.Open(path)
 	if err != nil {
 		mwin.SetError(fmt.Errorf("couldn't load trace: %w", err))
 		return
 	}
+	addRecentTrace(mwin.twin, path)
 	// Set state explicitly so user doesn't see a flash of the start state.
 	mwin.SetState("loadingTrace")
 	go func() {
@@ -1300,9 +2329,50 @@ func openTraceFromCmdline(mwin *MainWindow) {
 	}()
 }
 
+// openTraceFiles opens the first of paths in the current tab and each of the remaining ones in a new tab. The traces
+// are loaded one after another.
+func (mwin *MainWindow) openTraceFiles(paths []string) {
+	if len(paths) == 0 {
+		return
+	}
+	files := make([]io.ReadCloser, 0, len(paths))
+	for _, path := range paths {
+		f, err := uiFileSystem.Open(path)
+		if err != nil {
+			for _, f := range files {
+				f.Close()
+			}
+			mwin.SetError(fmt.Errorf("couldn't load trace: %w", err))
+			return
+		}
+		addRecentTrace(mwin.twin, path)
+		files = append(files, f)
+	}
+	// Set state explicitly so user doesn't see a flash of the start state.
+	mwin.SetState("loadingTrace")
+	go func() {
+		for i, f := range files {
+			if i == 0 {
+				mwin.OpenTrace(f)
+			} else {
+				mwin.OpenTraceInNewTab(f)
+			}
+			f.Close()
+		}
+	}()
+}
+
 func usage(name string, fs *flag.FlagSet) func() {
 	return func() {
-		fmt.Fprintf(os.Stderr, "Usage: %s [flags] [trace file]\n", name)
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] [trace files]\n", name)
+		fmt.Fprintf(os.Stderr, "       %s <command> [flags] [arguments]\n", name)
+
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Commands:")
+		for _, cmd := range commands {
+			help, _, _ := strings.Cut(cmd.help, "\n")
+			fmt.Fprintf(os.Stderr, "  %-10s %s\n", cmd.name, help)
+		}
 
 		fmt.Fprintln(os.Stderr)
 		fmt.Fprintln(os.Stderr, "Flags:")
@@ -1367,6 +2437,12 @@ func isZeroValue(f *flag.Flag, value string) bool {
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		if cmd, ok := findCommand(os.Args[1]); ok {
+			os.Exit(cmd.run("gotraceui", cmd, os.Args[2:]))
+		}
+	}
+
 	flag.Usage = usage("gotraceui", flag.CommandLine)
 	flag.BoolVar(&softDebug, "debug", debug, "Enable basic debug functionality")
 	flag.StringVar(&cpuprofile, "debug.cpuprofile", "", "write CPU profile to this file")
@@ -1378,6 +2454,15 @@ func main() {
 	flag.BoolVar(&exitAfterParsing, "debug.exit-after-parsing", false, "Exit after parsing trace")
 	flag.BoolVar(&measureFrameAllocs, "debug.measure-frame-allocs", false, "Measure the number of allocations per frame")
 	flag.BoolVar(&invalidateFrames, "debug.invalidate-frames", false, "Invalidate frame after drawing it")
+	flag.StringVar(&editorCommand, "editor", os.Getenv("GOTRACEUI_EDITOR"), "Command for opening source locations, using {file} and {line} as placeholders, or one of vscode, goland, idea, emacs, sublime, zed")
+	flag.StringVar(&sourceRoots, "source-roots", "", fmt.Sprintf("List of directories, separated by %q, to search for source files", os.PathListSeparator))
+	flag.StringVar(&externalCommand, "external", "", "Command that adds lanes to the timelines, communicating via its standard input and output")
+	flag.StringVar(&externalListen, "external-listen", "", "Address on which to accept programs that add lanes to the timelines, either host:port or unix:path")
+	flag.Func("process", "Trace of another process to display alongside each trace, such as the other side of a client/server interaction. Can be specified multiple times", func(s string) error {
+		processFiles = append(processFiles, s)
+		return nil
+	})
+	flag.StringVar(&configFile, "config", "", "Path of the configuration file (default <user config dir>/gotraceui/config.json)")
 	fv := flag.Bool("version", false, "Print version and exit")
 	fdv := flag.Bool("debug.version", false, "Print extended version information and exit")
 	flag.Parse()
@@ -1390,6 +2475,40 @@ func main() {
 		return
 	}
 
+	if cfg, err := LoadConfig(); err != nil {
+		fmt.Fprintln(os.Stderr, "couldn't load configuration:", err)
+	} else {
+		userConfig = cfg
+	}
+	if err := setLocale(userConfig.Locale); err != nil {
+		fmt.Fprintln(os.Stderr, "invalid locale in configuration:", err)
+	}
+	if err := keymap.Apply(userConfig.Keybindings); err != nil {
+		fmt.Fprintln(os.Stderr, "invalid keybindings in configuration:", err)
+	}
+	navigationConfig.Store(userConfig.Navigation)
+	timeFormatConfig.Store(userConfig.TimeFormat)
+	if err := applyColorRules(userConfig.ColorRules); err != nil {
+		fmt.Fprintln(os.Stderr, "invalid color rules in configuration:", err)
+	}
+	if userConfig.Tooltips != nil {
+		if err := applyTooltipConfig(*userConfig.Tooltips); err != nil {
+			fmt.Fprintln(os.Stderr, "invalid tooltips in configuration:", err)
+		}
+	}
+	if userConfig.CacheMemory > 0 {
+		cacheMemoryLimit.Store(uint64(userConfig.CacheMemory) * 1024 * 1024)
+	}
+	// Read what the previous run left behind before we start overwriting it.
+	if r, err := loadRecovery(); err != nil {
+		fmt.Fprintln(os.Stderr, "couldn't load autosave:", err)
+	} else if r != nil {
+		pendingRecovery.Store(r)
+	}
+	if err := startRecordingCrashes(); err != nil {
+		fmt.Fprintln(os.Stderr, "couldn't record crashes:", err)
+	}
+
 	go func() {
 		if cpuprofile != "" {
 			f, err := os.Create(cpuprofile)
@@ -1409,11 +2528,7 @@ func main() {
 		}
 	}()
 
-	mwin := NewMainWindow()
-	mwin.win = app.NewWindow(app.Title("gotraceui"))
-	mwin.twin = theme.NewWindow(mwin.win)
-	mwin.explorer = explorer.NewExplorer(mwin.win)
-
+	mwin := openMainWindow()
 	if debug {
 		go func() {
 			win := app.NewWindow(app.Title("gotraceui - debug window"))
@@ -1421,11 +2536,41 @@ func main() {
 		}()
 	}
 
-	mwin.setState("start")
+	// In browsers, traces can be dropped onto the page. They replace the trace of the first window, which is the only
+	// one.
+	first := mwin
+	watchDroppedFiles(func(name string, data []byte, err error) {
+		if err != nil {
+			first.twin.Notify(theme.NotificationError, local.Sprintf("Couldn't read %s: %s", name, err))
+			return
+		}
+		first.SetState("loadingTrace")
+		first.OpenTrace(&namedReader{bytes.NewReader(data), name})
+	})
 
-	if len(flag.Args()) > 0 {
-		openTraceFromCmdline(mwin)
-	}
+	// The first trace passed on the command line is displayed in the window's initial tab, the remaining ones each get
+	// a tab of their own.
+	mwin.openTraceFiles(flag.Args())
+
+	app.Main()
+}
+
+// mainWindows is the number of open main windows. The process exits when the last one has been closed.
+var mainWindows atomic.Int64
+
+// openMainWindow opens a new, empty main window. Each main window displays any number of traces in tabs and has its
+// own navigation state.
+func openMainWindow() *MainWindow {
+	mwin := NewMainWindow()
+	mwin.win = app.NewWindow(app.Title("gotraceui"))
+	mwin.twin = theme.NewWindow(mwin.win)
+	mwin.twin.StatusBar = true
+	mwin.restoreScale()
+	mwin.restoreFonts()
+	mwin.restorePalette()
+	mwin.explorer = explorer.NewExplorer(mwin.win)
+	mwin.setState("start")
+	mainWindows.Add(1)
 
 	go func() {
 		mwin.errs <- mwin.Run()
@@ -1436,6 +2581,9 @@ func main() {
 		if err != nil {
 			log.Println(err)
 		}
+		if mainWindows.Add(-1) > 0 && err != errExitAfterParsing && err != errExitAfterLoading {
+			return
+		}
 
 		if cpuprofile != "" {
 			pprof.StopCPUProfile()
@@ -1446,9 +2594,11 @@ func main() {
 		if memprofileExit != "" {
 			writeMemprofile(memprofileExit)
 		}
+		removeAutosave()
 		os.Exit(0)
 	}()
-	app.Main()
+
+	return mwin
 }
 
 type loadTraceResult struct {
@@ -1456,6 +2606,14 @@ type loadTraceResult struct {
 	plot          Plot
 	goroutinePlot Plot
 	timelines     []*Timeline
+	// The absolute path of the trace file, if known.
+	path string
+	// The title of the trace's tab.
+	title string
+	// The canvas that the timelines belong to.
+	canvas *Canvas
+	// Whether to display the trace in a new tab.
+	newTab bool
 }
 
 type progresser interface {
@@ -1464,7 +2622,21 @@ type progresser interface {
 	SetProgress(p float64)
 }
 
-func loadTrace(f io.Reader, p progresser, cv *Canvas) (loadTraceResult, error) {
+// contextReader is an io.Reader that stops reading once its context has been cancelled.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (cr contextReader) Read(b []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cr.r.Read(b)
+}
+
+// loadTrace parses and processes a trace, reporting progress to p. It returns ctx.Err() once ctx has been cancelled.
+func loadTrace(ctx context.Context, f io.Reader, p progresser, cv *Canvas) (loadTraceResult, error) {
 	names := []string{
 		"Parsing trace",
 		"Parsing trace",
@@ -1475,25 +2647,33 @@ func loadTrace(f io.Reader, p progresser, cv *Canvas) (loadTraceResult, error) {
 		"Processing",
 		"Processing",
 		"Processing",
+		"Building zoom levels",
 	}
 
 	p.SetProgressStages(names)
 
 	p.SetProgressStage(0)
-	r, err := exptrace.NewReader(f)
+	r, err := exptrace.NewReader(contextReader{ctx, f})
 	if err != nil {
-
+		if ctx.Err() != nil {
+			return loadTraceResult{}, ctx.Err()
+		}
 		return loadTraceResult{}, err
 	}
 
 	p.SetProgressStage(1)
 	pt, err := ptrace.Parse(r, p.SetProgress)
+	if ctx.Err() != nil {
+		return loadTraceResult{}, ctx.Err()
+	}
 	if err != nil {
 		return loadTraceResult{}, err
 	}
 	if exitAfterParsing {
 		return loadTraceResult{}, errExitAfterParsing
 	}
+	threshold := thinningThreshold()
+	thinned := ptrace.Thin(pt, threshold)
 
 	p.SetProgressStage(2)
 	// Assign GC tag to all GC spans so we can later determine their span colors cheaply.
@@ -1519,8 +2699,15 @@ func loadTrace(f io.Reader, p progresser, cv *Canvas) (loadTraceResult, error) {
 		p.SetProgress(float64(i+1) / float64(len(pt.Processors)))
 	}
 
+	if err := ctx.Err(); err != nil {
+		return loadTraceResult{}, err
+	}
 	p.SetProgressStage(3)
 	tr := &Trace{Trace: pt}
+	if thinned > 0 {
+		tr.ThinningThreshold = threshold
+		tr.ThinnedSpans = thinned
+	}
 	if len(pt.Goroutines) != 0 {
 		tr.allGoroutineSpanLabels = make([][]string, len(pt.Goroutines))
 
@@ -1545,6 +2732,9 @@ func loadTrace(f io.Reader, p progresser, cv *Canvas) (loadTraceResult, error) {
 		}
 	}
 
+	if err := ctx.Err(); err != nil {
+		return loadTraceResult{}, err
+	}
 	p.SetProgressStage(4)
 	if len(pt.Processors) != 0 {
 		tr.allProcessorSpanLabels = make([][]string, len(pt.Processors))
@@ -1558,6 +2748,9 @@ func loadTrace(f io.Reader, p progresser, cv *Canvas) (loadTraceResult, error) {
 
 	timelines := make([]*Timeline, len(tr.Processors)+len(tr.Goroutines)+len(tr.Tasks))
 
+	if err := ctx.Err(); err != nil {
+		return loadTraceResult{}, err
+	}
 	p.SetProgressStage(5)
 
 	p.SetProgressStage(6)
@@ -1566,11 +2759,17 @@ func loadTrace(f io.Reader, p progresser, cv *Canvas) (loadTraceResult, error) {
 		p.SetProgress(float64(i+1) / float64(len(tr.Processors)))
 	}
 
+	if err := ctx.Err(); err != nil {
+		return loadTraceResult{}, err
+	}
 	p.SetProgressStage(7)
 	goroutineTimelines := make([]*Timeline, len(tr.Goroutines))
 	var progress atomic.Uint64
 	mysync.Distribute(tr.Goroutines, 0, func(group int, step int, subitems []*ptrace.Goroutine) error {
 		for j, g := range subitems {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
 			goroutineTimelines[group*step+j] = NewGoroutineTimeline(tr, cv, g)
 			pr := progress.Add(1)
 			p.SetProgress(float64(pr) / float64(len(tr.Goroutines)))
@@ -1578,17 +2777,26 @@ func loadTrace(f io.Reader, p progresser, cv *Canvas) (loadTraceResult, error) {
 		return nil
 	})
 
+	if err := ctx.Err(); err != nil {
+		return loadTraceResult{}, err
+	}
 	p.SetProgressStage(8)
 	taskTimelines := make([]*Timeline, len(tr.Tasks))
 	progress.Store(0)
 	mysync.Distribute(tr.Tasks, 0, func(group int, step int, subitems []*ptrace.Task) error {
 		for j, t := range subitems {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
 			taskTimelines[group*step+j] = NewTaskTimeline(tr, cv, t)
 			pr := progress.Add(1)
 			p.SetProgress(float64(pr) / float64(len(tr.Tasks)))
 		}
 		return nil
 	})
+	if err := ctx.Err(); err != nil {
+		return loadTraceResult{}, err
+	}
 	sort.Slice(taskTimelines, func(i, j int) bool {
 		taskI := taskTimelines[i].item.(*ptrace.Task)
 		taskJ := taskTimelines[j].item.(*ptrace.Task)
@@ -1603,6 +2811,31 @@ func loadTrace(f io.Reader, p progresser, cv *Canvas) (loadTraceResult, error) {
 
 	mergeTimelines(goroutineTimelines, taskTimelines, timelines[len(pt.Processors):][:0], tr)
 
+	p.SetProgressStage(9)
+	progress.Store(0)
+	mysync.Distribute(timelines, 0, func(group int, step int, subitems []*Timeline) error {
+		for _, tl := range subitems {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			for _, track := range tl.tracks {
+				if track.spans == nil {
+					// The spans of this track are computed on demand.
+					continue
+				}
+				if spans, ok := track.spans.ResultNoWait(); ok && spans.Len() >= minPyramidSpans {
+					track.pyramid = newSpanPyramid(track, spans)
+				}
+			}
+			pr := progress.Add(1)
+			p.SetProgress(float64(pr) / float64(len(timelines)))
+		}
+		return nil
+	})
+	if err := ctx.Err(); err != nil {
+		return loadTraceResult{}, err
+	}
+
 	mg := Plot{
 		Name: "Memory usage",
 		Unit: "bytes",
@@ -1688,6 +2921,7 @@ func loadTrace(f io.Reader, p progresser, cv *Canvas) (loadTraceResult, error) {
 
 	tr.GOROOT = goroot
 	tr.GOPATH = gopath
+	tr.NetWaits, tr.netWaitCounts = netWaits(pt)
 
 	tr.TimeOffset = -tr.Start()
 
@@ -1728,12 +2962,14 @@ type DescriptionAttribute struct {
 	Value TextSpan
 }
 
+// Layout displays the attributes of the description. The keys of attributes are translated here, so that they can be
+// used as field labels when copying records.
 func (desc Description) Layout(win *theme.Window, gtx layout.Context, txt *Text) (layout.Dimensions, []TextSpan) {
 	txt.Reset(win.Theme)
 	// OPT(dh): reuse space
 	tb := TextBuilder{Window: win}
 	for _, attr := range desc.Attributes {
-		tb.Bold(fmt.Sprintf("%s: ", attr.Key))
+		tb.Bold(tr(attr.Key) + ": ")
 		tb.Add(attr.Value)
 		tb.Span("\n")
 	}
@@ -1762,6 +2998,18 @@ func (cmd ScrollToTimelineCommand) Layout(win *theme.Window, gtx layout.Context,
 		numSpans = item.Spans.Len()
 		start = item.Spans.AtPtr(0).Start
 		end = LastItemPtr(item.Spans).End
+	case *NetPoller:
+		numSpans = item.Spans.Len()
+		start = item.Spans.AtPtr(0).Start
+		end = LastItemPtr(item.Spans).End
+	case *ExternalLane:
+		numSpans = len(item.spans)
+		for _, track := range cmd.Timeline.tracks {
+			if start == 0 || track.Start < start {
+				start = track.Start
+			}
+			end = max(end, track.End)
+		}
 	case *ptrace.Goroutine:
 		numSpans = len(item.Spans)
 		start = item.EffectiveStart()
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	rtrace "runtime/trace"
+	"slices"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/trace/ptrace"
+
+	"gioui.org/op/clip"
+	"gioui.org/text"
+	exptrace "golang.org/x/exp/trace"
+)
+
+type migrationsKey struct{}
+
+// goroutineMigrations counts how often a goroutine started running after having run before, and how often it did so on
+// a different P than the last time.
+type goroutineMigrations struct {
+	Goroutine  *ptrace.Goroutine
+	Runs       int
+	Migrations int
+}
+
+// migrations describes how goroutines moved between Ps.
+type migrations struct {
+	// Matrix[i][j] is the number of times a goroutine that last ran on tr.Processors[i] resumed on tr.Processors[j].
+	// The diagonal counts goroutines that resumed on the same P.
+	Matrix [][]int
+	// Goroutines is sorted by the number of migrations, in descending order.
+	Goroutines []goroutineMigrations
+	Resumes    int
+	Migrations int
+}
+
+// computeMigrations finds consecutive runs of goroutines on different Ps. Frequent migrations indicate poor locality,
+// for example because idle Ps steal work from busy ones.
+func computeMigrations(tr *Trace) *migrations {
+	defer rtrace.StartRegion(context.Background(), "main.computeMigrations").End()
+
+	type run struct {
+		start exptrace.Time
+		p     int
+	}
+	runs := map[exptrace.GoID][]run{}
+	for i, p := range tr.Processors {
+		for _, s := range p.Spans {
+			if s.State != ptrace.StateProcRunningG {
+				continue
+			}
+			gid := tr.Event(s.StartEvent).StateTransition().Resource.Goroutine()
+			runs[gid] = append(runs[gid], run{s.Start, i})
+		}
+	}
+
+	out := &migrations{
+		Matrix: make([][]int, len(tr.Processors)),
+	}
+	for i := range out.Matrix {
+		out.Matrix[i] = make([]int, len(tr.Processors))
+	}
+	for gid, rs := range runs {
+		slices.SortFunc(rs, func(a, b run) int {
+			return cmp(a.start, b.start, false)
+		})
+		gm := goroutineMigrations{Goroutine: tr.G(gid)}
+		for i := 1; i < len(rs); i++ {
+			from, to := rs[i-1].p, rs[i].p
+			out.Matrix[from][to]++
+			gm.Runs++
+			if from != to {
+				gm.Migrations++
+			}
+		}
+		out.Resumes += gm.Runs
+		out.Migrations += gm.Migrations
+		if gm.Runs > 0 {
+			out.Goroutines = append(out.Goroutines, gm)
+		}
+	}
+	slices.SortFunc(out.Goroutines, func(a, b goroutineMigrations) int {
+		if a.Migrations != b.Migrations {
+			return b.Migrations - a.Migrations
+		}
+		return a.Goroutine.SeqID - b.Goroutine.SeqID
+	})
+	return out
+}
+
+// MigrationComponent displays how often goroutines migrated between Ps, as a matrix of counts and per goroutine.
+type MigrationComponent struct {
+	trace      *Trace
+	migrations *theme.Future[*migrations]
+
+	tabbedState theme.TabbedState
+	matrix      struct {
+		table         *theme.Table
+		scrollState   theme.YScrollableListState
+		cellFormatter CellFormatter
+	}
+	goroutines struct {
+		table         *theme.Table
+		scrollState   theme.YScrollableListState
+		cellFormatter CellFormatter
+	}
+}
+
+func NewMigrationComponent(win *theme.Window, tr *Trace) *MigrationComponent {
+	return &MigrationComponent{
+		trace: tr,
+		migrations: theme.Compute(&tr.analyses, win, migrationsKey{}, func() *migrations {
+			return computeMigrations(tr)
+		}),
+	}
+}
+
+// Title implements theme.Component.
+func (*MigrationComponent) Title() string {
+	return tr("Goroutine migrations")
+}
+
+// Transition implements theme.Component.
+func (*MigrationComponent) Transition(state theme.ComponentState) {}
+
+// WantsTransition implements theme.Component.
+func (*MigrationComponent) WantsTransition(gtx layout.Context) theme.ComponentState {
+	return theme.ComponentStateNone
+}
+
+func (mc *MigrationComponent) HoveredLink() ObjectLink {
+	return mc.goroutines.cellFormatter.HoveredLink()
+}
+
+// Layout implements theme.Component.
+func (mc *MigrationComponent) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.MigrationComponent.Layout").End()
+
+	m, ok := mc.migrations.Result()
+	if !ok {
+		return theme.Loading(win.Theme, tr("Computing migrations…")).Layout(win, gtx)
+	}
+
+	var pct float64
+	if m.Resumes > 0 {
+		pct = float64(m.Migrations) / float64(m.Resumes) * 100
+	}
+	summary := local.Sprintf("%d of %d times that goroutines resumed running, they did so on a different P (%.2f%%).", m.Migrations, m.Resumes, pct)
+	tabs := []string{"Matrix", "Goroutines"}
+	return layout.Rigids(gtx, layout.Vertical,
+		theme.Dumb(win, theme.LineLabel(win.Theme, summary).Layout),
+		layout.Spacer{Height: 5}.Layout,
+		func(gtx layout.Context) layout.Dimensions {
+			return theme.Tabbed(&mc.tabbedState, tabs).Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+				gtx.Constraints.Min = gtx.Constraints.Max
+				switch mc.tabbedState.Current {
+				case 0:
+					return mc.layoutMatrix(win, gtx, m)
+				case 1:
+					return mc.layoutGoroutines(win, gtx, m)
+				default:
+					panic("unreachable")
+				}
+			})
+		},
+	)
+}
+
+func (mc *MigrationComponent) layoutMatrix(win *theme.Window, gtx layout.Context, m *migrations) layout.Dimensions {
+	mt := &mc.matrix
+	if mt.table == nil {
+		cols := []theme.Column{{Name: "From \\ To", Alignment: text.Start}}
+		for _, p := range mc.trace.Processors {
+			cols = append(cols, theme.Column{Name: fmt.Sprintf("P %d", p.ID), Alignment: text.End})
+		}
+		mt.table = &theme.Table{}
+		mt.table.SetColumns(win, gtx, cols)
+	}
+	mt.table.Update(gtx)
+	mt.cellFormatter.Update(win, gtx)
+
+	cellFn := func(win *theme.Window, gtx layout.Context, row, col int) layout.Dimensions {
+		defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+
+		if col == 0 {
+			return mt.cellFormatter.Text(win, gtx, fmt.Sprintf("P %d", mc.trace.Processors[row].ID))
+		}
+		return mt.cellFormatter.Number(win, gtx, m.Matrix[row][col-1])
+	}
+	return theme.SimpleTable(win, gtx, mt.table, &mt.scrollState, len(m.Matrix), cellFn)
+}
+
+func (mc *MigrationComponent) layoutGoroutines(win *theme.Window, gtx layout.Context, m *migrations) layout.Dimensions {
+	gt := &mc.goroutines
+	if gt.table == nil {
+		gt.table = &theme.Table{}
+		gt.table.SetColumns(win, gtx, []theme.Column{
+			{Name: "Goroutine", Alignment: text.End},
+			{Name: "Function", Alignment: text.Start},
+			{Name: "Resumptions", Alignment: text.End},
+			{Name: "Migrations", Alignment: text.End},
+		})
+	}
+	gt.table.Update(gtx)
+	gt.cellFormatter.Update(win, gtx)
+
+	cellFn := func(win *theme.Window, gtx layout.Context, row, col int) layout.Dimensions {
+		defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+
+		gm := &m.Goroutines[row]
+		switch col {
+		case 0:
+			return gt.cellFormatter.Goroutine(win, gtx, gm.Goroutine, "")
+		case 1:
+			return gt.cellFormatter.Function(win, gtx, gm.Goroutine.Function)
+		case 2:
+			return gt.cellFormatter.Number(win, gtx, gm.Runs)
+		case 3:
+			return gt.cellFormatter.Number(win, gtx, gm.Migrations)
+		default:
+			panic(fmt.Sprintf("unreachable: %d", col))
+		}
+	}
+	return theme.SimpleTable(win, gtx, gt.table, &gt.scrollState, len(m.Goroutines), cellFn)
+}
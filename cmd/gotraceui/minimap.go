@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	rtrace "runtime/trace"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/trace/ptrace"
+
+	exptrace "golang.org/x/exp/trace"
+)
+
+// The height, in device-independent pixels, of one row of the scrollbar minimap. Timelines that fall into the same
+// row are aggregated.
+const minimapRowHeightDp = 2
+
+// scrollbarMinimap computes marks for the canvas's scrollbar that show which timelines have activity in the visible
+// time range, so that users know where to scroll.
+type scrollbarMinimap struct {
+	key     minimapKey
+	pending *theme.Future[[]theme.ScrollMark]
+	marks   []theme.ScrollMark
+}
+
+type minimapKey struct {
+	start, end exptrace.Time
+	heightsGen uint64
+	timelines  int
+	height     int
+	rows       int
+}
+
+// Marks returns the marks for the canvas's current state. Marks are computed in the background; until they are ready,
+// the marks for a previous state are returned. At most one computation is in flight at a time, so that panning
+// doesn't start a new computation on every frame.
+func (mm *scrollbarMinimap) Marks(win *theme.Window, gtx layout.Context, cv *Canvas, trackLen int) []theme.ScrollMark {
+	if mm.pending != nil {
+		if marks, ok := mm.pending.Result(); ok {
+			mm.marks = marks
+			mm.pending = nil
+		} else {
+			return mm.marks
+		}
+	}
+
+	key := minimapKey{
+		start:      cv.start,
+		end:        cv.End(),
+		heightsGen: cv.timeline.heightsGen,
+		timelines:  len(cv.timelines),
+		height:     cv.height(gtx),
+		rows:       max(1, trackLen/gtx.Dp(minimapRowHeightDp)),
+	}
+	if key == mm.key || len(cv.timelineEnds) != len(cv.timelines) {
+		return mm.marks
+	}
+	mm.key = key
+
+	// Capture the state the computation needs, as the canvas may change while it runs.
+	items := make([]any, len(cv.timelines))
+	for i, tl := range cv.timelines {
+		items[i] = tl.item
+	}
+	ends := append([]int(nil), cv.timelineEnds...)
+	tr := cv.trace
+	mm.pending = theme.NewFuture(win, func(cancelled <-chan struct{}) []theme.ScrollMark {
+		return computeMinimap(tr, items, ends, key, cancelled)
+	})
+	return mm.marks
+}
+
+func computeMinimap(tr *Trace, items []any, ends []int, key minimapKey, cancelled <-chan struct{}) []theme.ScrollMark {
+	defer rtrace.StartRegion(context.Background(), "main.computeMinimap").End()
+
+	if key.height == 0 {
+		return nil
+	}
+	active := make([]int, key.rows)
+	total := make([]int, key.rows)
+	prevEnd := 0
+	for i, item := range items {
+		if i%1000 == 0 {
+			select {
+			case <-cancelled:
+				return nil
+			default:
+			}
+		}
+
+		start, end := prevEnd, ends[i]
+		prevEnd = end
+		if end == start {
+			// Hidden timeline
+			continue
+		}
+		// Attribute the timeline to the row containing its center.
+		row := min(int(float64(start+end)/2/float64(key.height)*float64(key.rows)), key.rows-1)
+		total[row]++
+		if timelineActive(tr, item, key.start, key.end) {
+			active[row]++
+		}
+	}
+
+	var marks []theme.ScrollMark
+	for row := range key.rows {
+		if active[row] == 0 {
+			continue
+		}
+		marks = append(marks, theme.ScrollMark{
+			Start: float32(row) / float32(key.rows),
+			End:   float32(row+1) / float32(key.rows),
+			// Make sparse rows visible while keeping busy rows distinguishable.
+			Intensity: 0.4 + 0.6*float32(active[row])/float32(total[row]),
+		})
+	}
+	return marks
+}
+
+// timelineActive reports whether the timeline for item has activity in the time range [start, end]. For goroutines
+// and processors, this means that they were running. Timelines of other kinds count as active if they have any spans in
+// the range. Kinds of timelines whose data can change, such as external lanes, are never active.
+func timelineActive(tr *Trace, item any, start, end exptrace.Time) bool {
+	var spans []ptrace.Span
+	var states uint64
+	switch item := item.(type) {
+	case *ptrace.Goroutine:
+		spans = item.Spans
+		states = 1<<ptrace.StateActive | 1<<ptrace.StateGCDedicated | 1<<ptrace.StateGCIdle
+	case *ptrace.Processor:
+		spans = item.Spans
+		states = 1 << ptrace.StateProcRunningG
+	case *ptrace.Machine:
+		spans = item.Spans
+	case *GC:
+		spans = tr.GC
+	case *STW:
+		spans = tr.STW
+	case *NetPoller:
+		spans = tr.NetWaits
+	default:
+		return false
+	}
+
+	spans = spansOverlapping(spans, start, end)
+	if states == 0 {
+		return len(spans) != 0
+	}
+	for i := range spans {
+		if states&(1<<spans[i].State) != 0 {
+			return true
+		}
+	}
+	return false
+}
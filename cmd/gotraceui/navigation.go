@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"image"
+	rtrace "runtime/trace"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/widget"
+)
+
+const (
+	minZoomSpeed = 0.1
+	maxZoomSpeed = 10
+)
+
+// NavigationConfig configures how the canvas responds to panning and zooming.
+type NavigationConfig struct {
+	// ZoomAroundCenter zooms around the center of the canvas instead of around the cursor.
+	ZoomAroundCenter bool `json:"zoom_around_center,omitempty"`
+	// ZoomSpeed scales how much each scroll step zooms. It defaults to 1.
+	ZoomSpeed float64 `json:"zoom_speed,omitempty"`
+	// DisableKineticPanning stops the canvas from gliding to a halt after the user releases a drag.
+	DisableKineticPanning bool `json:"disable_kinetic_panning,omitempty"`
+}
+
+// navigationConfig is the navigation configuration in effect. It is shared by all main windows.
+var navigationConfig atomic.Pointer[NavigationConfig]
+
+// currentNavigationConfig returns the navigation configuration in effect, with defaults filled in.
+func currentNavigationConfig() NavigationConfig {
+	var cfg NavigationConfig
+	if p := navigationConfig.Load(); p != nil {
+		cfg = *p
+	}
+	if cfg.ZoomSpeed == 0 {
+		cfg.ZoomSpeed = 1
+	}
+	cfg.ZoomSpeed = min(max(cfg.ZoomSpeed, minZoomSpeed), maxZoomSpeed)
+	return cfg
+}
+
+// NavigationDialog lets the user configure panning and zooming.
+type NavigationDialog struct {
+	zoomAroundCenter widget.Bool
+	kineticPanning   widget.Bool
+	zoomSpeed        widget.Editor
+
+	apply widget.PrimaryClickable
+	reset widget.PrimaryClickable
+	err   string
+}
+
+func NewNavigationDialog() *NavigationDialog {
+	nd := &NavigationDialog{}
+	nd.zoomSpeed.SingleLine = true
+	nd.set(currentNavigationConfig())
+	return nd
+}
+
+func (nd *NavigationDialog) set(cfg NavigationConfig) {
+	nd.zoomAroundCenter.Value = cfg.ZoomAroundCenter
+	nd.kineticPanning.Value = !cfg.DisableKineticPanning
+	nd.zoomSpeed.SetText(strconv.FormatFloat(cfg.ZoomSpeed, 'g', -1, 64))
+}
+
+// config returns the configuration described by the dialog's inputs.
+func (nd *NavigationDialog) config() (NavigationConfig, error) {
+	speed, err := strconv.ParseFloat(strings.TrimSpace(nd.zoomSpeed.Text()), 64)
+	if err != nil || speed < minZoomSpeed || speed > maxZoomSpeed {
+		return NavigationConfig{}, fmt.Errorf("zoom speed must be a number between %g and %g", float64(minZoomSpeed), float64(maxZoomSpeed))
+	}
+	cfg := NavigationConfig{
+		ZoomAroundCenter:      nd.zoomAroundCenter.Value,
+		DisableKineticPanning: !nd.kineticPanning.Value,
+	}
+	// Don't store the default, so that changes to it take effect.
+	if speed != 1 {
+		cfg.ZoomSpeed = speed
+	}
+	return cfg, nil
+}
+
+func (nd *NavigationDialog) save(win *theme.Window, cfg NavigationConfig) {
+	nd.err = ""
+	userConfigMu.Lock()
+	defer userConfigMu.Unlock()
+	if cfg == (NavigationConfig{}) {
+		userConfig.Navigation = nil
+	} else {
+		userConfig.Navigation = &cfg
+	}
+	navigationConfig.Store(userConfig.Navigation)
+	if err := userConfig.Save(); err != nil {
+		win.Notify(theme.NotificationError, local.Sprintf("Couldn't save configuration: %s", err))
+	}
+}
+
+func (nd *NavigationDialog) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.NavigationDialog.Layout").End()
+
+	for nd.apply.Clicked(gtx) {
+		if cfg, err := nd.config(); err != nil {
+			nd.err = err.Error()
+		} else {
+			nd.save(win, cfg)
+		}
+	}
+	for nd.reset.Clicked(gtx) {
+		nd.set(NavigationConfig{ZoomSpeed: 1})
+		nd.save(win, NavigationConfig{})
+	}
+
+	return layout.Rigids(gtx, layout.Vertical,
+		theme.Dumb(win, theme.CheckBox(win.Theme, &nd.zoomAroundCenter, tr("Zoom around the center of the timelines instead of the cursor")).Layout),
+		layout.Spacer{Height: 5}.Layout,
+		theme.Dumb(win, theme.CheckBox(win.Theme, &nd.kineticPanning, tr("Keep moving after releasing a drag")).Layout),
+		layout.Spacer{Height: 10}.Layout,
+		func(gtx layout.Context) layout.Dimensions {
+			return theme.LineLabel(win.Theme, local.Sprintf("Zoom speed, between %g and %g", float64(minZoomSpeed), float64(maxZoomSpeed))).Layout(win, gtx)
+		},
+		func(gtx layout.Context) layout.Dimensions {
+			return theme.TextBox(win.Theme, &nd.zoomSpeed, "1").Layout(win, gtx)
+		},
+		layout.Spacer{Height: 10}.Layout,
+		func(gtx layout.Context) layout.Dimensions {
+			return layout.Rigids(gtx, layout.Horizontal,
+				func(gtx layout.Context) layout.Dimensions {
+					return theme.Button(win.Theme, &nd.apply.Clickable, tr("Apply")).Layout(win, gtx)
+				},
+				layout.Spacer{Width: 5}.Layout,
+				func(gtx layout.Context) layout.Dimensions {
+					return theme.Button(win.Theme, &nd.reset.Clickable, tr("Reset to defaults")).Layout(win, gtx)
+				},
+			)
+		},
+		func(gtx layout.Context) layout.Dimensions {
+			if nd.err == "" {
+				return layout.Dimensions{}
+			}
+			l := theme.Label(win.Theme, nd.err)
+			l.Color = colors[colorStateBlocked]
+			return l.Layout(win, gtx)
+		},
+	)
+}
+
+func displayNavigationDialog(win *theme.Window) {
+	nd := NewNavigationDialog()
+	win.SetModal(func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+		return theme.Dialog(win.Theme, tr("Navigation")).Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+			gtx.Constraints.Min = gtx.Constraints.Constrain(image.Pt(600, 250))
+			gtx.Constraints.Max = gtx.Constraints.Min
+			return nd.Layout(win, gtx)
+		})
+	})
+}
@@ -0,0 +1,365 @@
+package main
+
+import (
+	"context"
+	rtrace "runtime/trace"
+	"slices"
+	"sort"
+	"time"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/trace/ptrace"
+	myunsafe "honnef.co/go/gotraceui/unsafe"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/font"
+	"gioui.org/op/clip"
+	"gioui.org/text"
+	exptrace "golang.org/x/exp/trace"
+)
+
+// NetPoller is the item of the timeline that shows when goroutines were parked on network IO.
+type NetPoller struct {
+	Spans Items[ptrace.Span]
+}
+
+// netWaits returns the periods during which at least one goroutine was parked on network IO, split wherever the
+// number of parked goroutines changes, as well as that number for each period. The start and end events of the spans
+// are the events that changed the number.
+func netWaits(tr *ptrace.Trace) ([]ptrace.Span, []int) {
+	defer rtrace.StartRegion(context.Background(), "main.netWaits").End()
+
+	type change struct {
+		t     exptrace.Time
+		ev    ptrace.EventID
+		delta int
+	}
+	var changes []change
+	end := tr.End()
+	for _, g := range tr.Goroutines {
+		for i := range g.Spans {
+			s := &g.Spans[i]
+			if s.State != ptrace.StateBlockedNet {
+				continue
+			}
+			sEnd := s.End
+			if s.EndEvent == -1 {
+				sEnd = end
+			}
+			changes = append(changes, change{s.Start, s.StartEvent, 1}, change{sEnd, s.EndEvent, -1})
+		}
+	}
+	slices.SortStableFunc(changes, func(a, b change) int {
+		return cmp(a.t, b.t, false)
+	})
+
+	var (
+		spans  []ptrace.Span
+		counts []int
+		n      int
+		// Whether the last span is still open.
+		open bool
+	)
+	for i, c := range changes {
+		n += c.delta
+		if i+1 < len(changes) && changes[i+1].t == c.t {
+			// Only the final count at any timestamp matters.
+			continue
+		}
+		if open {
+			if counts[len(counts)-1] == n {
+				continue
+			}
+			spans[len(spans)-1].End = c.t
+			spans[len(spans)-1].EndEvent = c.ev
+			open = false
+		}
+		if n > 0 {
+			spans = append(spans, ptrace.Span{Start: c.t, StartEvent: c.ev, State: ptrace.StateBlockedNet})
+			counts = append(counts, n)
+			open = true
+		}
+	}
+	return spans, counts
+}
+
+// netWaitCount returns the number of goroutines that were parked on network IO during span, which must be one of the
+// spans returned by netWaits.
+func (t *Trace) netWaitCount(span *ptrace.Span) int {
+	idx := sort.Search(len(t.NetWaits), func(i int) bool { return t.NetWaits[i].Start >= span.Start })
+	if idx == len(t.NetWaits) {
+		return 0
+	}
+	return t.netWaitCounts[idx]
+}
+
+func NewNetPollerTimeline(cv *Canvas, tr *Trace, spans []ptrace.Span) *Timeline {
+	tl := &Timeline{
+		label:     "Network",
+		shortName: "Net",
+		cv:        cv,
+	}
+	tl.tracks = []*Track{
+		NewTrack(tl, TrackKindUnspecified),
+	}
+	ss := SimpleItems[ptrace.Span, any]{
+		items: spans,
+		container: ItemContainer{
+			Timeline: tl,
+			Track:    tl.tracks[0],
+		},
+		subslice: true,
+	}
+
+	if len(spans) > 0 {
+		tl.tracks[0].Start = spans[0].Start
+		tl.tracks[0].End = spans[len(spans)-1].End
+	}
+	tl.tracks[0].spans = theme.Immediate[Items[ptrace.Span]](ss)
+	tl.tracks[0].spanLabel = func(spans Items[ptrace.Span], tr *Trace, out []string) []string {
+		if spans.Len() != 1 {
+			return out
+		}
+		n := tr.netWaitCount(spans.AtPtr(0))
+		return append(out, local.Sprintf("%d waiting", n), local.Sprintf("%d", n))
+	}
+	tl.tracks[0].spanColor = singleSpanColor(colorStateBlockedNet)
+	tl.tracks[0].spanTooltip = func(win *theme.Window, gtx layout.Context, tr *Trace, spans Items[ptrace.Span]) layout.Dimensions {
+		var label string
+		if spans.Len() == 1 {
+			label = local.Sprintf("%d goroutines parked on network IO\n", tr.netWaitCount(spans.AtPtr(0)))
+		} else {
+			most := 0
+			for i := range spans.Len() {
+				most = max(most, tr.netWaitCount(spans.AtPtr(i)))
+			}
+			label = local.Sprintf("%d spans\nUp to %d goroutines parked on network IO\n", spans.Len(), most)
+		}
+		label += local.Sprintf("Time span: %s\n", roundDuration(SpansTimeSpan(spans).Duration()))
+		return theme.Tooltip(win.Theme, label).Layout(win, gtx)
+	}
+	tl.item = &NetPoller{ss}
+
+	return tl
+}
+
+// A netWait is a goroutine being parked on network IO.
+type netWait struct {
+	g    *ptrace.Goroutine
+	span *ptrace.Span
+	// The function that waited, skipping over uninteresting runtime frames, and the full stack, used for grouping.
+	site  string
+	stack string
+	// Whether the scheduler, polling the network while looking for work, made the goroutine runnable, as opposed to
+	// another goroutine, such as one closing the connection or the runtime's timer for a deadline.
+	byPoller bool
+}
+
+// A netWaitGroup aggregates network waits with the same stack.
+type netWaitGroup struct {
+	Site       string
+	Waits      []netWait
+	Total, Max time.Duration
+	ByPoller   int
+}
+
+// netWaitStats describes how much time goroutines spent parked on network IO, compared to all other kinds of
+// blocking.
+type netWaitStats struct {
+	Groups       []netWaitGroup
+	Total        time.Duration
+	TotalBlocked time.Duration
+	ByPoller     int
+	Waits        int
+}
+
+type netWaitStatsKey struct{}
+
+func computeNetWaitStats(tr *Trace) *netWaitStats {
+	defer rtrace.StartRegion(context.Background(), "main.computeNetWaitStats").End()
+
+	out := &netWaitStats{}
+	groups := map[string]*netWaitGroup{}
+	end := tr.End()
+	for _, g := range tr.Goroutines {
+		for i := range g.Spans {
+			span := &g.Spans[i]
+			sEnd := span.End
+			if span.EndEvent == -1 {
+				sEnd = end
+			}
+			d := time.Duration(sEnd - span.Start)
+			switch span.State {
+			case ptrace.StateBlocked, ptrace.StateBlockedSend, ptrace.StateBlockedRecv, ptrace.StateBlockedSelect,
+				ptrace.StateBlockedSync, ptrace.StateBlockedSyncOnce, ptrace.StateBlockedSyncTriggeringGC,
+				ptrace.StateBlockedCond, ptrace.StateBlockedGC, ptrace.StateBlockedSyscall:
+				out.TotalBlocked += d
+				continue
+			case ptrace.StateBlockedNet:
+				out.TotalBlocked += d
+			default:
+				continue
+			}
+
+			w := netWait{g: g, span: span}
+			if stk := tr.Event(span.StartEvent).Stack(); stk != exptrace.NoStack {
+				pcs := tr.Stacks[tr.StackID(stk)]
+				w.stack = string(myunsafe.SliceCast[[]byte](pcs))
+				if int(span.At) < len(pcs) {
+					w.site = tr.FuncName(pcs[span.At])
+				}
+			}
+			if span.EndEvent != -1 {
+				w.byPoller = tr.Event(span.EndEvent).Goroutine() == exptrace.NoGoroutine
+			}
+
+			group, ok := groups[w.stack]
+			if !ok {
+				group = &netWaitGroup{Site: w.site}
+				groups[w.stack] = group
+			}
+			group.Waits = append(group.Waits, w)
+			group.Total += d
+			group.Max = max(group.Max, d)
+			out.Total += d
+			out.Waits++
+			if w.byPoller {
+				group.ByPoller++
+				out.ByPoller++
+			}
+		}
+	}
+
+	out.Groups = make([]netWaitGroup, 0, len(groups))
+	for _, group := range groups {
+		out.Groups = append(out.Groups, *group)
+	}
+	slices.SortFunc(out.Groups, func(a, b netWaitGroup) int {
+		return cmp(a.Total, b.Total, true)
+	})
+	return out
+}
+
+// NetPollerComponent lists where goroutines waited for network IO and for how long, to tell apart network-bound waits
+// from other blocking.
+type NetPollerComponent struct {
+	canvas *Canvas
+	stats  *theme.Future[*netWaitStats]
+	// Lazily computed spans of the groups, indexed by row.
+	spans []Items[ptrace.Span]
+
+	table         *theme.Table
+	scrollState   theme.YScrollableListState
+	cellFormatter CellFormatter
+}
+
+func NewNetPollerComponent(win *theme.Window, tr *Trace, cv *Canvas) *NetPollerComponent {
+	return &NetPollerComponent{
+		canvas: cv,
+		stats: theme.Compute(&tr.analyses, win, netWaitStatsKey{}, func() *netWaitStats {
+			return computeNetWaitStats(tr)
+		}),
+	}
+}
+
+// Title implements theme.Component.
+func (*NetPollerComponent) Title() string {
+	return tr("Network waits")
+}
+
+// Transition implements theme.Component.
+func (*NetPollerComponent) Transition(state theme.ComponentState) {}
+
+// WantsTransition implements theme.Component.
+func (*NetPollerComponent) WantsTransition(gtx layout.Context) theme.ComponentState {
+	return theme.ComponentStateNone
+}
+
+func (nc *NetPollerComponent) HoveredLink() ObjectLink {
+	return nc.cellFormatter.HoveredLink()
+}
+
+func (nc *NetPollerComponent) initTable(win *theme.Window, gtx layout.Context) {
+	if nc.table != nil {
+		return
+	}
+	nc.table = &theme.Table{}
+	nc.table.SetColumns(win, gtx, []theme.Column{
+		{Name: "Total time", Alignment: text.End},
+		{Name: "Max time", Alignment: text.End},
+		{Name: "Waits", Alignment: text.End},
+		{Name: "Woken by poller", Alignment: text.End},
+		{Name: "Waited in", Alignment: text.Start},
+	})
+}
+
+// Layout implements theme.Component.
+func (nc *NetPollerComponent) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.NetPollerComponent.Layout").End()
+
+	stats, ok := nc.stats.Result()
+	if !ok {
+		return theme.Loading(win.Theme, tr("Collecting network waits…")).Layout(win, gtx)
+	}
+	if nc.spans == nil {
+		nc.spans = make([]Items[ptrace.Span], len(stats.Groups))
+	}
+
+	nc.initTable(win, gtx)
+	nc.table.Update(gtx)
+	nc.cellFormatter.Update(win, gtx)
+
+	cellFn := func(win *theme.Window, gtx layout.Context, row, col int) layout.Dimensions {
+		defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+
+		group := &stats.Groups[row]
+		switch colName := nc.table.Columns[col].Name; colName {
+		case "Total time":
+			return nc.cellFormatter.Duration(win, gtx, group.Total, false)
+		case "Max time":
+			return nc.cellFormatter.Duration(win, gtx, group.Max, false)
+		case "Waits":
+			spans := nc.spans[row]
+			if spans == nil {
+				spans = goroutineSpans(nc.canvas, group.Waits, func(w *netWait) (*ptrace.Goroutine, *ptrace.Span) {
+					return w.g, w.span
+				})
+				nc.spans[row] = spans
+			}
+			return layout.RightAligned(gtx, func(gtx layout.Context) layout.Dimensions {
+				link := nc.cellFormatter.Clicks.Grow()
+				link.Link = &SpansObjectLink{Spans: spans}
+				return link.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+					return widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, font.Font{}, win.Theme.TextSize, local.Sprintf("%d", len(group.Waits)), win.ColorMaterial(gtx, win.Theme.Palette.OpenLink))
+				})
+			})
+		case "Woken by poller":
+			return nc.cellFormatter.Number(win, gtx, group.ByPoller)
+		case "Waited in":
+			if group.Site == "" {
+				return nc.cellFormatter.Text(win, gtx, "unknown")
+			}
+			return nc.cellFormatter.Text(win, gtx, group.Site)
+		default:
+			panic(colName)
+		}
+	}
+
+	var pct float64
+	if stats.TotalBlocked > 0 {
+		pct = float64(stats.Total) / float64(stats.TotalBlocked) * 100
+	}
+	summary := local.Sprintf("Goroutines waited for network IO %d times, for a total of %s, which is %.2f%% of the time goroutines spent blocked. The network poller woke up goroutines %d times.",
+		stats.Waits, roundDuration(stats.Total), pct, stats.ByPoller)
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(theme.Dumb(win, theme.Label(win.Theme, summary).Layout)),
+		layout.Rigid(layout.Spacer{Height: 5}.Layout),
+		layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+			if len(stats.Groups) == 0 {
+				return layout.Center.Layout(gtx, theme.Dumb(win, theme.Label(win.Theme, tr("No goroutines waited for network IO.")).Layout))
+			}
+			return theme.SimpleTable(win, gtx, nc.table, &nc.scrollState, len(stats.Groups), cellFn)
+		}),
+	)
+}
@@ -0,0 +1,424 @@
+package main
+
+// OpenTelemetry correlation
+//
+// Programs that use both runtime/trace and OpenTelemetry can name their tasks after the distributed spans they work
+// on, for example "handle request 4bf92f3577b34da6a3ce929d0e0e4736 00f067aa0ba902b7". After importing the spans of
+// the distributed trace from an OTLP/JSON file, such as one written by the OpenTelemetry Collector's file exporter,
+// tasks whose names contain a span ID are annotated with that span, and tasks whose names contain only a trace ID are
+// annotated with the root span of that trace.
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	rtrace "runtime/trace"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/trace/ptrace"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/font"
+	"gioui.org/io/key"
+	"gioui.org/op/clip"
+	"gioui.org/text"
+)
+
+// otelIDPattern matches trace IDs (32 hex digits) and span IDs (16 hex digits) in task names.
+var otelIDPattern = regexp.MustCompile(`\b(?:[0-9a-fA-F]{32}|[0-9a-fA-F]{16})\b`)
+
+// An OTelSpan is a span of a distributed trace, imported from an OTLP file.
+type OTelSpan struct {
+	// IDs are in lowercase hex.
+	TraceID      string
+	SpanID       string
+	ParentSpanID string
+	Name         string
+	// Service is the service.name attribute of the span's resource.
+	Service string
+	// Start and End are wall clock times, which can't be related to the timestamps of the Go trace.
+	Start, End time.Time
+	// Tasks are the tasks that correspond to the span.
+	Tasks []*ptrace.Task
+}
+
+func (s *OTelSpan) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// OTelImport is the result of importing an OTLP file and matching its spans against the tasks of a trace.
+type OTelImport struct {
+	Name  string
+	Spans []*OTelSpan
+	// byTask maps tasks to the spans they correspond to.
+	byTask map[*ptrace.Task]*OTelSpan
+}
+
+// SpanForTask returns the distributed span that corresponds to t, if any.
+func (imp *OTelImport) SpanForTask(t *ptrace.Task) *OTelSpan {
+	if imp == nil {
+		return nil
+	}
+	return imp.byTask[t]
+}
+
+// Matched returns the number of tasks that correspond to a distributed span.
+func (imp *OTelImport) Matched() int {
+	return len(imp.byTask)
+}
+
+type otlpNanos uint64
+
+func (n *otlpNanos) UnmarshalJSON(b []byte) error {
+	// The protobuf JSON mapping encodes 64-bit integers as strings, but some producers use numbers.
+	v, err := strconv.ParseUint(string(bytes.Trim(b, `"`)), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp %s", b)
+	}
+	*n = otlpNanos(v)
+	return nil
+}
+
+type otlpAttribute struct {
+	Key   string `json:"key"`
+	Value struct {
+		StringValue string `json:"stringValue"`
+	} `json:"value"`
+}
+
+type otlpSpan struct {
+	TraceID           string    `json:"traceId"`
+	SpanID            string    `json:"spanId"`
+	ParentSpanID      string    `json:"parentSpanId"`
+	Name              string    `json:"name"`
+	StartTimeUnixNano otlpNanos `json:"startTimeUnixNano"`
+	EndTimeUnixNano   otlpNanos `json:"endTimeUnixNano"`
+}
+
+type otlpTracesData struct {
+	ResourceSpans []struct {
+		Resource struct {
+			Attributes []otlpAttribute `json:"attributes"`
+		} `json:"resource"`
+		ScopeSpans []struct {
+			Spans []otlpSpan `json:"spans"`
+		} `json:"scopeSpans"`
+	} `json:"resourceSpans"`
+}
+
+// parseOTLP parses OTLP/JSON trace data. The input may contain multiple objects, as written by exporters that append
+// one object per line.
+func parseOTLP(r io.Reader) ([]*OTelSpan, error) {
+	var out []*OTelSpan
+	dec := json.NewDecoder(r)
+	for {
+		var data otlpTracesData
+		if err := dec.Decode(&data); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+		for _, rs := range data.ResourceSpans {
+			var service string
+			for _, attr := range rs.Resource.Attributes {
+				if attr.Key == "service.name" {
+					service = attr.Value.StringValue
+				}
+			}
+			for _, ss := range rs.ScopeSpans {
+				for _, s := range ss.Spans {
+					out = append(out, &OTelSpan{
+						TraceID:      strings.ToLower(s.TraceID),
+						SpanID:       strings.ToLower(s.SpanID),
+						ParentSpanID: strings.ToLower(s.ParentSpanID),
+						Name:         s.Name,
+						Service:      service,
+						Start:        time.Unix(0, int64(s.StartTimeUnixNano)),
+						End:          time.Unix(0, int64(s.EndTimeUnixNano)),
+					})
+				}
+			}
+		}
+	}
+	if len(out) == 0 {
+		return nil, errors.New("file contains no spans")
+	}
+	return out, nil
+}
+
+// newOTelImport matches the spans against the tasks of tr.
+func newOTelImport(tr *Trace, name string, spans []*OTelSpan) *OTelImport {
+	bySpan := map[string]*OTelSpan{}
+	roots := map[string]*OTelSpan{}
+	for _, s := range spans {
+		bySpan[s.SpanID] = s
+	}
+	for _, s := range spans {
+		if s.ParentSpanID != "" && bySpan[s.ParentSpanID] != nil {
+			continue
+		}
+		// The span has no parent, or its parent wasn't exported. If the root wasn't exported, multiple spans may
+		// qualify, and we use the earliest one.
+		if root, ok := roots[s.TraceID]; !ok || s.Start.Before(root.Start) {
+			roots[s.TraceID] = s
+		}
+	}
+
+	imp := &OTelImport{
+		Name:   name,
+		Spans:  spans,
+		byTask: map[*ptrace.Task]*OTelSpan{},
+	}
+	for _, t := range tr.Tasks {
+		var match *OTelSpan
+		for _, id := range otelIDPattern.FindAllString(t.Name, -1) {
+			id = strings.ToLower(id)
+			if len(id) == 16 {
+				if s := bySpan[id]; s != nil {
+					// Span IDs are more specific than trace IDs.
+					match = s
+					break
+				}
+			} else if s := roots[id]; s != nil && match == nil {
+				match = s
+			}
+		}
+		if match != nil {
+			imp.byTask[t] = match
+			match.Tasks = append(match.Tasks, t)
+		}
+	}
+	return imp
+}
+
+// importOTel lets the user choose an OTLP/JSON file and annotates the tasks of the current trace with its spans.
+func (mwin *MainWindow) importOTel() {
+	tr := mwin.trace
+	mwin.chooseTraceFile(func(rc io.ReadCloser) {
+		defer rc.Close()
+		name := "OTLP file"
+		if f, ok := rc.(interface{ Name() string }); ok {
+			name = filepath.Base(f.Name())
+		}
+		spans, err := parseOTLP(rc)
+		if err != nil {
+			mwin.twin.Notify(theme.NotificationError, local.Sprintf("Couldn't import %s: %s", name, err))
+			return
+		}
+		imp := newOTelImport(tr, name, spans)
+		mwin.twin.EmitAction(theme.ExecuteAction(func(gtx layout.Context) {
+			if mwin.trace != tr {
+				return
+			}
+			tr.otel = imp
+			mwin.twin.Notify(theme.NotificationInfo, local.Sprintf("Matched %d of %d tasks to distributed spans.", imp.Matched(), len(tr.Tasks)))
+			mwin.openTab(Tab{Component: NewOTelComponent(tr, imp)})
+		}))
+	})
+}
+
+type OTelSpanObjectLink struct {
+	Span *OTelSpan
+}
+
+// OpenOTelSpanAction shows a distributed span in the list of imported spans.
+type OpenOTelSpanAction struct {
+	Span *OTelSpan
+}
+
+func (*OpenOTelSpanAction) IsAction() {}
+func (l *OpenOTelSpanAction) Open(gtx layout.Context, mwin *MainWindow) {
+	for i, tab := range mwin.tabs {
+		if oc, ok := tab.Component.(*OTelComponent); ok && oc.imp == mwin.trace.otel {
+			mwin.tabbedState.Current = i
+			oc.reveal(l.Span)
+			return
+		}
+	}
+	if mwin.trace.otel == nil {
+		return
+	}
+	oc := NewOTelComponent(mwin.trace, mwin.trace.otel)
+	mwin.openTab(Tab{Component: oc})
+	oc.reveal(l.Span)
+}
+
+func (l *OTelSpanObjectLink) Action(mods key.Modifiers) theme.Action {
+	return &OpenOTelSpanAction{Span: l.Span}
+}
+
+func (l *OTelSpanObjectLink) ContextMenu() []*theme.MenuItem {
+	return contextMenu(*l, []*theme.MenuItem{
+		{
+			Label: PlainLabel(tr("Show distributed span")),
+			Action: func() theme.Action {
+				return &OpenOTelSpanAction{Span: l.Span}
+			},
+		},
+	})
+}
+
+// OTelComponent lists the spans of an imported distributed trace and the tasks they correspond to.
+type OTelComponent struct {
+	trace *Trace
+	imp   *OTelImport
+	// The spans, in the order they're displayed in.
+	spans []*OTelSpan
+	// The span to highlight, or nil.
+	selected *OTelSpan
+	// The span to scroll to during the next frame, if any.
+	pendingReveal *OTelSpan
+
+	table         *theme.Table
+	scrollState   theme.YScrollableListState
+	cellFormatter CellFormatter
+}
+
+func NewOTelComponent(tr *Trace, imp *OTelImport) *OTelComponent {
+	return &OTelComponent{
+		trace: tr,
+		imp:   imp,
+		spans: slices.Clone(imp.Spans),
+	}
+}
+
+// reveal highlights a span and scrolls to it.
+func (oc *OTelComponent) reveal(s *OTelSpan) {
+	oc.selected = s
+	oc.pendingReveal = s
+}
+
+// Title implements theme.Component.
+func (oc *OTelComponent) Title() string {
+	return local.Sprintf("Distributed spans: %s", oc.imp.Name)
+}
+
+// Transition implements theme.Component.
+func (*OTelComponent) Transition(state theme.ComponentState) {}
+
+// WantsTransition implements theme.Component.
+func (*OTelComponent) WantsTransition(gtx layout.Context) theme.ComponentState {
+	return theme.ComponentStateNone
+}
+
+func (oc *OTelComponent) HoveredLink() ObjectLink {
+	return oc.cellFormatter.HoveredLink()
+}
+
+func (oc *OTelComponent) initTable(win *theme.Window, gtx layout.Context) {
+	if oc.table != nil {
+		return
+	}
+	oc.table = &theme.Table{}
+	oc.table.SetColumns(win, gtx, []theme.Column{
+		{Name: "Name", Clickable: true, Alignment: text.Start},
+		{Name: "Service", Clickable: true, Alignment: text.Start},
+		{Name: "Start", Clickable: true, Alignment: text.Start},
+		{Name: "Duration", Clickable: true, Alignment: text.End},
+		{Name: "Trace ID", Clickable: true, Alignment: text.Start},
+		{Name: "Span ID", Alignment: text.Start},
+		{Name: "Tasks", Clickable: true, Alignment: text.End},
+	})
+	oc.table.SortedBy = 2
+	oc.table.SortOrder = theme.SortAscending
+	oc.sort()
+}
+
+func (oc *OTelComponent) sort() {
+	descending := oc.table.SortOrder == theme.SortDescending
+	slices.SortStableFunc(oc.spans, func(a, b *OTelSpan) int {
+		switch oc.table.SortedBy {
+		case 0: // Name
+			return cmp(a.Name, b.Name, descending)
+		case 1: // Service
+			return cmp(a.Service, b.Service, descending)
+		case 2: // Start
+			return cmp(a.Start.UnixNano(), b.Start.UnixNano(), descending)
+		case 3: // Duration
+			return cmp(a.Duration(), b.Duration(), descending)
+		case 4: // Trace ID
+			return cmp(a.TraceID, b.TraceID, descending)
+		case 6: // Tasks
+			return cmp(len(a.Tasks), len(b.Tasks), descending)
+		default:
+			panic(fmt.Sprintf("unreachable: %d", oc.table.SortedBy))
+		}
+	})
+}
+
+// Layout implements theme.Component.
+func (oc *OTelComponent) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.OTelComponent.Layout").End()
+
+	oc.initTable(win, gtx)
+	oc.table.Update(gtx)
+	oc.cellFormatter.Update(win, gtx)
+	if _, ok := oc.table.SortByClickedColumn(); ok {
+		oc.sort()
+	}
+	if oc.pendingReveal != nil {
+		if i := slices.Index(oc.spans, oc.pendingReveal); i != -1 {
+			oc.scrollState.RevealRow(i)
+		}
+		oc.pendingReveal = nil
+	}
+
+	cellFn := func(win *theme.Window, gtx layout.Context, row, col int) layout.Dimensions {
+		defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+
+		s := oc.spans[row]
+		if s == oc.selected {
+			theme.FillShape(win, gtx.Ops, win.Theme.Palette.PrimarySelection, clip.Rect{Max: gtx.Constraints.Max}.Op())
+		}
+		switch colName := oc.table.Columns[col].Name; colName {
+		case "Name":
+			return oc.cellFormatter.Text(win, gtx, s.Name)
+		case "Service":
+			return oc.cellFormatter.Text(win, gtx, s.Service)
+		case "Start":
+			return oc.cellFormatter.Text(win, gtx, s.Start.Format("2006-01-02 15:04:05.000000"))
+		case "Duration":
+			return oc.cellFormatter.Duration(win, gtx, s.Duration(), false)
+		case "Trace ID":
+			return oc.cellFormatter.Text(win, gtx, s.TraceID)
+		case "Span ID":
+			return oc.cellFormatter.Text(win, gtx, s.SpanID)
+		case "Tasks":
+			switch len(s.Tasks) {
+			case 0:
+				return layout.Dimensions{Size: gtx.Constraints.Min}
+			case 1:
+				return oc.cellFormatter.Task(win, gtx, s.Tasks[0], "")
+			default:
+				return layout.RightAligned(gtx, func(gtx layout.Context) layout.Dimensions {
+					label := local.Sprintf("%d tasks", len(s.Tasks))
+					return widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, font.Font{}, win.Theme.TextSize, label, win.ColorMaterial(gtx, win.Theme.Palette.Foreground))
+				})
+			}
+		default:
+			panic(colName)
+		}
+	}
+
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			l := local.Sprintf("%d spans. %d of %d tasks correspond to distributed spans.", len(oc.spans), oc.imp.Matched(), len(oc.trace.Tasks))
+			return theme.LineLabel(win.Theme, l).Layout(win, gtx)
+		}),
+		layout.Rigid(layout.Spacer{Height: 5}.Layout),
+		layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+			return theme.SimpleTable(win, gtx, oc.table, &oc.scrollState, len(oc.spans), cellFn)
+		}),
+	)
+}
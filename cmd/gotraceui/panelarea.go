@@ -0,0 +1,373 @@
+package main
+
+// Panel area
+//
+// The panel area displays panels next to the main area's tabs. It is a tree of splits whose leaves are groups of
+// panels that are tabbed together. Every tab has its own history of panels, like a tab of a web browser: opening a
+// panel replaces the tab's current panel, and going back returns to the previous one. Panels open in the active
+// group, which is the group that was last clicked. Splitting a group adds an empty group next to it, which becomes the
+// active group. In addition to being tabbed together, panels can be turned into tabs of the main area and be floated
+// in their own windows.
+//
+// The structure of the panel area is saved in the configuration. Panels refer to the loaded trace and aren't saved;
+// restored groups start out with a single empty tab.
+
+import (
+	"context"
+	"image"
+	rtrace "runtime/trace"
+	"slices"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/io/pointer"
+	"gioui.org/op/clip"
+)
+
+// maxPanelHistory is the number of previous panels that each tab remembers.
+const maxPanelHistory = 100
+
+type panelTab struct {
+	// The tab's current panel, or nil if the tab is empty.
+	panel   Panel
+	history []Panel
+}
+
+// panelGroup is a group of tabbed panels.
+type panelGroup struct {
+	tabs   []panelTab
+	tabBar theme.TabBarState
+
+	newTab      widget.PrimaryClickable
+	splitRight  widget.PrimaryClickable
+	splitBottom widget.PrimaryClickable
+	close       widget.PrimaryClickable
+}
+
+func newPanelGroup() *panelGroup {
+	return &panelGroup{tabs: []panelTab{{}}}
+}
+
+func (g *panelGroup) current() *panelTab {
+	return &g.tabs[min(g.tabBar.Current, len(g.tabs)-1)]
+}
+
+// panelNode is a node in the tree of the panel area. Leaves are groups, other nodes split their area between their
+// two children.
+type panelNode struct {
+	parent *panelNode
+	// group is set for leaves.
+	group    *panelGroup
+	children [2]*panelNode
+	split    theme.SplitterState
+}
+
+type panelArea struct {
+	root   *panelNode
+	active *panelNode
+	// The size at which the active group's panel was last displayed, for rendering it as an image.
+	panelSize image.Point
+}
+
+func newPanelArea() *panelArea {
+	n := &panelNode{group: newPanelGroup()}
+	return &panelArea{root: n, active: n}
+}
+
+// leaves calls fn for each group, from left to right and top to bottom.
+func (n *panelNode) leaves(fn func(n *panelNode)) {
+	if n.group != nil {
+		fn(n)
+		return
+	}
+	n.children[0].leaves(fn)
+	n.children[1].leaves(fn)
+}
+
+// current returns the panel of the active group's current tab, or nil.
+func (pa *panelArea) current() Panel {
+	return pa.active.group.current().panel
+}
+
+// visible returns the panels of the current tabs of all groups.
+func (pa *panelArea) visible() []Panel {
+	var out []Panel
+	pa.root.leaves(func(n *panelNode) {
+		if p := n.group.current().panel; p != nil {
+			out = append(out, p)
+		}
+	})
+	return out
+}
+
+// open displays p in the current tab of the active group, remembering the tab's previous panel.
+func (pa *panelArea) open(p Panel) {
+	t := pa.active.group.current()
+	if t.panel != nil {
+		t.history = append(t.history, t.panel)
+		if len(t.history) > maxPanelHistory {
+			t.history = slices.Delete(t.history, 0, 1)
+		}
+	}
+	p.Transition(theme.ComponentStatePanel)
+	t.panel = p
+}
+
+// back returns the tab containing p to its previous panel. It returns false if the tab has no previous panel, in
+// which case the tab becomes empty.
+func (pa *panelArea) back(p Panel) bool {
+	var found *panelTab
+	pa.root.leaves(func(n *panelNode) {
+		for i := range n.group.tabs {
+			if t := &n.group.tabs[i]; t.panel == p {
+				found = t
+			}
+		}
+	})
+	if found == nil {
+		return false
+	}
+	if len(found.history) == 0 {
+		found.panel = nil
+		return false
+	}
+	found.panel = found.history[len(found.history)-1]
+	found.history = found.history[:len(found.history)-1]
+	return true
+}
+
+// splitActive splits the active group along axis, adding an empty group after it, which becomes the active group.
+func (pa *panelArea) splitActive(axis layout.Axis) {
+	n := pa.active
+	old := &panelNode{parent: n, group: n.group}
+	added := &panelNode{parent: n, group: newPanelGroup()}
+	n.group = nil
+	n.children = [2]*panelNode{old, added}
+	n.split = theme.SplitterState{Axis: axis, Ratio: 0.5}
+	pa.active = added
+}
+
+// closeGroup removes a group and its panels, giving its space to its sibling. The last group can't be closed.
+func (pa *panelArea) closeGroup(n *panelNode) {
+	parent := n.parent
+	if parent == nil {
+		return
+	}
+	for _, t := range n.group.tabs {
+		if t.panel != nil {
+			t.panel.Transition(theme.ComponentStateClosed)
+		}
+	}
+	sibling := parent.children[0]
+	if sibling == n {
+		sibling = parent.children[1]
+	}
+	// Replace the parent with the sibling.
+	parent.group = sibling.group
+	parent.children = sibling.children
+	parent.split = sibling.split
+	for _, c := range parent.children {
+		if c != nil {
+			c.parent = parent
+		}
+	}
+	n.parent, n.group = nil, nil
+	pa.active = nil
+	parent.leaves(func(n *panelNode) {
+		if pa.active == nil {
+			pa.active = n
+		}
+	})
+}
+
+// Layout lays out the panel area.
+func (pa *panelArea) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.panelArea.Layout").End()
+	return pa.layoutNode(win, gtx, pa.root)
+}
+
+func (pa *panelArea) layoutNode(win *theme.Window, gtx layout.Context, n *panelNode) layout.Dimensions {
+	if n.group == nil {
+		children := n.children
+		return theme.Splitter(win.Theme, &n.split).Layout(win, gtx,
+			func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+				return pa.layoutNode(win, gtx, children[0])
+			},
+			func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+				return pa.layoutNode(win, gtx, children[1])
+			},
+		)
+	}
+
+	g := n.group
+	for _, ev := range gtx.Events(n) {
+		if ev, ok := ev.(pointer.Event); ok && ev.Kind == pointer.Press {
+			pa.active = n
+		}
+	}
+	for g.newTab.Clicked(gtx) {
+		g.tabs = append(g.tabs, panelTab{})
+		g.tabBar.Current = len(g.tabs) - 1
+		pa.active = n
+	}
+	for g.splitRight.Clicked(gtx) {
+		pa.active = n
+		pa.splitActive(layout.Horizontal)
+	}
+	for g.splitBottom.Clicked(gtx) {
+		pa.active = n
+		pa.splitActive(layout.Vertical)
+	}
+	for g.close.Clicked(gtx) {
+		pa.closeGroup(n)
+	}
+	if n.group == nil {
+		if n.children[0] == nil {
+			// The group was closed. Its sibling takes its space in the next frame.
+			return layout.Dimensions{Size: gtx.Constraints.Max}
+		}
+		// The group was split.
+		return pa.layoutNode(win, gtx, n)
+	}
+	for _, ev := range g.tabBar.Update(gtx) {
+		switch ev.Kind {
+		case theme.TabSelected:
+			pa.active = n
+		case theme.TabClosed:
+			if p := g.tabs[ev.Index].panel; p != nil {
+				p.Transition(theme.ComponentStateClosed)
+			}
+			g.tabs = slices.Delete(g.tabs, ev.Index, ev.Index+1)
+			if len(g.tabs) == 0 {
+				g.tabs = append(g.tabs, panelTab{})
+			}
+		case theme.TabMoved:
+			t := g.tabs[ev.Index]
+			g.tabs = slices.Delete(g.tabs, ev.Index, ev.Index+1)
+			g.tabs = slices.Insert(g.tabs, ev.To, t)
+		}
+	}
+
+	defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+	// Activate the group when it is clicked anywhere, without taking the click away from the panel.
+	func() {
+		defer pointer.PassOp{}.Push(gtx.Ops).Pop()
+		pointer.InputOp{Tag: n, Kinds: pointer.Press}.Add(gtx.Ops)
+	}()
+
+	titles := make([]string, len(g.tabs))
+	for i, t := range g.tabs {
+		if t.panel == nil {
+			titles[i] = tr("Empty")
+		} else {
+			titles[i] = t.panel.Title()
+		}
+	}
+
+	button := func(b *widget.PrimaryClickable, label string) layout.Widget {
+		return func(gtx layout.Context) layout.Dimensions {
+			return layout.Rigids(gtx, layout.Horizontal,
+				layout.Spacer{Width: 5}.Layout,
+				theme.Dumb(win, theme.Button(win.Theme, &b.Clickable, label).Layout),
+			)
+		}
+	}
+	header := func(gtx layout.Context) layout.Dimensions {
+		buttons := []layout.Widget{
+			button(&g.newTab, tr("New tab")),
+			button(&g.splitRight, tr("Split right")),
+			button(&g.splitBottom, tr("Split down")),
+		}
+		if n.parent != nil {
+			buttons = append(buttons, button(&g.close, tr("Close group")))
+		}
+		return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+			layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+				tb := theme.TabBar(win.Theme, &g.tabBar, titles)
+				tb.Closable = true
+				if pa.active != n {
+					// Only highlight the current tab of the active group.
+					tb.ActiveLineColor = win.Theme.Palette.Border
+				}
+				return tb.Layout(win, gtx)
+			}),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return layout.Rigids(gtx, layout.Horizontal, buttons...)
+			}),
+		)
+	}
+
+	t := g.current()
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(header),
+		layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+			gtx.Constraints.Min = gtx.Constraints.Max
+			if t.panel == nil {
+				msg := tr("Panels open in the active group, for example when clicking on goroutines or spans.")
+				return layout.Center.Layout(gtx, theme.Dumb(win, theme.Label(win.Theme, msg).Layout))
+			}
+			if pa.active == n {
+				pa.panelSize = gtx.Constraints.Max
+			}
+			defer win.HUD.StartRegion("panel", t.panel.Title()).End()
+			return t.panel.Layout(win, gtx)
+		}),
+	)
+}
+
+// config returns the structure of the panel area, or nil if the area consists of a single group.
+func (pa *panelArea) config() *PanelLayout {
+	if pa.root.group != nil {
+		return nil
+	}
+	var build func(n *panelNode) *PanelLayout
+	build = func(n *panelNode) *PanelLayout {
+		if n.group != nil {
+			return &PanelLayout{}
+		}
+		l := &PanelLayout{Ratio: n.split.Ratio, Children: []*PanelLayout{build(n.children[0]), build(n.children[1])}}
+		if n.split.Axis == layout.Horizontal {
+			l.Split = "horizontal"
+		} else {
+			l.Split = "vertical"
+		}
+		return l
+	}
+	return build(pa.root)
+}
+
+// restore replaces the panel area with empty groups in the structure described by cfg.
+func (pa *panelArea) restore(cfg *PanelLayout) {
+	var build func(parent *panelNode, l *PanelLayout) *panelNode
+	build = func(parent *panelNode, l *PanelLayout) *panelNode {
+		n := &panelNode{parent: parent}
+		if l == nil || len(l.Children) != 2 || (l.Split != "horizontal" && l.Split != "vertical") {
+			n.group = newPanelGroup()
+			return n
+		}
+		n.split.Axis = layout.Vertical
+		if l.Split == "horizontal" {
+			n.split.Axis = layout.Horizontal
+		}
+		n.split.Ratio = l.Ratio
+		if n.split.Ratio <= 0 || n.split.Ratio >= 1 {
+			n.split.Ratio = 0.5
+		}
+		n.children = [2]*panelNode{build(n, l.Children[0]), build(n, l.Children[1])}
+		return n
+	}
+	pa.root = build(nil, cfg)
+	pa.active = nil
+	pa.root.leaves(func(n *panelNode) {
+		if pa.active == nil {
+			pa.active = n
+		}
+	})
+}
+
+// clear removes all panels, keeping the structure of the panel area.
+func (pa *panelArea) clear() {
+	pa.restore(pa.config())
+}
@@ -182,7 +182,7 @@ func (pl *Plot) Layout(win *theme.Window, gtx layout.Context, cv *Canvas) layout
 		r := rtrace.StartRegion(context.Background(), "context menu")
 		items := []*theme.MenuItem{
 			{
-				Label: PlainLabel("Reset extents"),
+				Label: PlainLabel(tr("Reset extents")),
 				Action: func() theme.Action {
 					return theme.ExecuteAction(func(gtx layout.Context) {
 						pl.min = 0
@@ -192,7 +192,7 @@ func (pl *Plot) Layout(win *theme.Window, gtx layout.Context, cv *Canvas) layout
 				},
 			},
 			{
-				Label: PlainLabel("Set extents to global extrema"),
+				Label: PlainLabel(tr("Set extents to global extrema")),
 				Action: func() theme.Action {
 					return theme.ExecuteAction(func(gtx layout.Context) {
 						pl.min, pl.max = pl.computeExtents(0, math.MaxInt64)
@@ -200,7 +200,7 @@ func (pl *Plot) Layout(win *theme.Window, gtx layout.Context, cv *Canvas) layout
 				},
 			},
 			{
-				Label: PlainLabel("Set extents to local extrema"),
+				Label: PlainLabel(tr("Set extents to local extrema")),
 				Action: func() theme.Action {
 					return theme.ExecuteAction(func(gtx layout.Context) {
 						pl.min, pl.max = pl.computeExtents(cv.start, cv.End())
@@ -208,7 +208,7 @@ func (pl *Plot) Layout(win *theme.Window, gtx layout.Context, cv *Canvas) layout
 				},
 			},
 			{
-				Label: ToggleLabel("Don't auto-set extents", "Auto-set extents to local extrema", &pl.autoScale),
+				Label: ToggleLabel(tr("Don't auto-set extents"), tr("Auto-set extents to local extrema"), &pl.autoScale),
 				Action: func() theme.Action {
 					return theme.ExecuteAction(func(gtx layout.Context) {
 						pl.autoScale = !pl.autoScale
@@ -216,7 +216,7 @@ func (pl *Plot) Layout(win *theme.Window, gtx layout.Context, cv *Canvas) layout
 				},
 			},
 			{
-				Label: ToggleLabel("Show legends", "Hide legends", &pl.hideLegends),
+				Label: ToggleLabel(tr("Show legends"), tr("Hide legends"), &pl.hideLegends),
 				Action: func() theme.Action {
 					return theme.ExecuteAction(func(gtx layout.Context) {
 						pl.hideLegends = !pl.hideLegends
@@ -228,9 +228,9 @@ func (pl *Plot) Layout(win *theme.Window, gtx layout.Context, cv *Canvas) layout
 			s := &pl.series[i]
 			var label string
 			if s.disabled {
-				label = fmt.Sprintf("Show %q series", s.Name)
+				label = local.Sprintf("Show %q series", s.Name)
 			} else {
-				label = fmt.Sprintf("Hide %q series", s.Name)
+				label = local.Sprintf("Hide %q series", s.Name)
 			}
 			item := &theme.MenuItem{
 				Label: PlainLabel(label),
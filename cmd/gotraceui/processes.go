@@ -0,0 +1,315 @@
+package main
+
+// Additional processes
+//
+// Traces of cooperating processes, such as a client and a server that were traced at the same time, can be displayed
+// alongside the main trace. Each additional process gets a section below the main trace's timelines, consisting of a
+// lane that names the process, followed by one lane per goroutine. The lanes are external lanes, which means that the
+// spans of additional processes can be inspected but don't take part in the analyses of the main trace.
+//
+// Traces use the monotonic clock of the machine they were recorded on, so processes that ran on the same machine line
+// up without adjustment. Processes that ran on different machines can be shifted by an offset.
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"io"
+	"path/filepath"
+	rtrace "runtime/trace"
+	"slices"
+	"strings"
+	"time"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/trace/ptrace"
+	"honnef.co/go/gotraceui/widget"
+
+	exptrace "golang.org/x/exp/trace"
+)
+
+// processFiles are the traces of additional processes to display alongside each trace that gets loaded.
+var processFiles []string
+
+// A processTrace is an additional process displayed alongside the main trace.
+type processTrace struct {
+	Name  string
+	trace *ptrace.Trace
+	// Offset is added to all timestamps of the process.
+	Offset time.Duration
+
+	header *ExternalLane
+	lanes  []*ExternalLane
+	// The goroutine of each lane in lanes.
+	goroutines []*ptrace.Goroutine
+}
+
+func newProcessTrace(name string, pt *ptrace.Trace) *processTrace {
+	p := &processTrace{
+		Name:  name,
+		trace: pt,
+		header: &ExternalLane{
+			ID:   name,
+			Name: "Process " + name,
+			metas: []externalSpan{{
+				Label:   name,
+				Tooltip: local.Sprintf("%d goroutines", len(pt.Goroutines)),
+				Color:   colorStateInactive,
+			}},
+		},
+	}
+	for _, g := range pt.Goroutines {
+		if len(g.Spans) == 0 {
+			continue
+		}
+		name := local.Sprintf("goroutine %d", g.ID)
+		if g.Function != nil {
+			name = local.Sprintf("goroutine %d: %s", g.ID, g.Function.Func)
+		}
+		lane := &ExternalLane{
+			ID:    fmt.Sprintf("%s/%d", p.Name, g.ID),
+			Name:  p.Name + ": " + name,
+			metas: make([]externalSpan, len(g.Spans)),
+		}
+		for i := range g.Spans {
+			s := &g.Spans[i]
+			lane.metas[i] = externalSpan{Label: stateNames[s.State], Color: stateColors[s.State]}
+		}
+		p.lanes = append(p.lanes, lane)
+		p.goroutines = append(p.goroutines, g)
+	}
+	return p
+}
+
+// rebuild recomputes the spans of the process's lanes after its offset has changed.
+func (p *processTrace) rebuild(cv *Canvas) {
+	off := exptrace.Time(p.Offset)
+	p.header.spans = []ptrace.Span{{
+		Start:      p.trace.Start() + off,
+		End:        p.trace.End() + off,
+		StartEvent: 0,
+		State:      ptrace.StateNone,
+	}}
+	p.header.rebuild(cv)
+	for i, lane := range p.lanes {
+		g := p.goroutines[i]
+		lane.spans = lane.spans[:0]
+		for j := range g.Spans {
+			s := &g.Spans[j]
+			lane.spans = append(lane.spans, ptrace.Span{
+				Start:      s.Start + off,
+				End:        s.End + off,
+				StartEvent: ptrace.EventID(j),
+				State:      ptrace.StateNone,
+			})
+		}
+		lane.rebuild(cv)
+	}
+}
+
+// timelines returns the timelines of the process's section, starting with the header.
+func (p *processTrace) timelines() []*Timeline {
+	out := make([]*Timeline, 0, len(p.lanes)+1)
+	out = append(out, p.header.tl)
+	for _, lane := range p.lanes {
+		if len(lane.tl.tracks) != 0 {
+			out = append(out, lane.tl)
+		}
+	}
+	return out
+}
+
+// loadProcessTrace parses the trace of an additional process.
+func loadProcessTrace(r io.Reader) (*ptrace.Trace, error) {
+	er, err := exptrace.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return ptrace.Parse(er, func(float64) {})
+}
+
+// loadProcessFiles loads the traces of the additional processes that were specified on the command line.
+func (mwin *MainWindow) loadProcessFiles() {
+	tr := mwin.trace
+	for _, path := range processFiles {
+		go func() {
+			f, err := uiFileSystem.Open(path)
+			if err != nil {
+				mwin.twin.Notify(theme.NotificationError, local.Sprintf("Couldn't load process trace: %s", err))
+				return
+			}
+			defer f.Close()
+			mwin.addProcess(tr, filepath.Base(path), f)
+		}()
+	}
+}
+
+// openProcess lets the user choose the trace of an additional process and displays it alongside the main trace.
+func (mwin *MainWindow) openProcess() {
+	tr := mwin.trace
+	mwin.chooseTraceFile(func(rc io.ReadCloser) {
+		defer rc.Close()
+		name := "process"
+		if f, ok := rc.(interface{ Name() string }); ok {
+			name = filepath.Base(f.Name())
+		}
+		mwin.addProcess(tr, name, rc)
+	})
+}
+
+// addProcess parses the trace of an additional process and adds its section to the canvas, unless the window has
+// stopped displaying tr in the meantime. It must not be called from the window's goroutine.
+func (mwin *MainWindow) addProcess(tr *Trace, name string, r io.Reader) {
+	mwin.twin.Notify(theme.NotificationInfo, local.Sprintf("Loading process trace %s…", name))
+	pt, err := loadProcessTrace(r)
+	if err != nil {
+		mwin.twin.Notify(theme.NotificationError, local.Sprintf("Couldn't load process trace %s: %s", name, err))
+		return
+	}
+	mwin.twin.EmitAction(theme.ExecuteAction(func(gtx layout.Context) {
+		if mwin.trace != tr {
+			return
+		}
+		// Keep lane IDs and names unique when the same file gets added twice.
+		base := name
+		for i := 2; slices.ContainsFunc(mwin.processes, func(p *processTrace) bool { return p.Name == name }); i++ {
+			name = fmt.Sprintf("%s (%d)", base, i)
+		}
+		p := newProcessTrace(name, pt)
+		p.rebuild(mwin.canvas)
+		mwin.processes = append(mwin.processes, p)
+		cv := mwin.canvas
+		cv.timelines = append(cv.timelines, p.timelines()...)
+		cv.timeline.heightsGen++
+	}))
+}
+
+// setProcessOffset shifts the timestamps of an additional process.
+func (mwin *MainWindow) setProcessOffset(p *processTrace, offset time.Duration) {
+	cv := mwin.canvas
+	old := p.timelines()
+	at := slices.Index(cv.timelines, old[0])
+	cv.timelines = slices.DeleteFunc(cv.timelines, func(tl *Timeline) bool {
+		return slices.Contains(old, tl)
+	})
+	p.Offset = offset
+	p.rebuild(cv)
+	if at == -1 || at > len(cv.timelines) {
+		at = len(cv.timelines)
+	}
+	cv.timelines = slices.Insert(cv.timelines, at, p.timelines()...)
+	cv.timeline.heightsGen++
+}
+
+// ProcessOffsetsDialog lets the user shift additional processes in time, to line them up with the main trace.
+type ProcessOffsetsDialog struct {
+	mwin    *MainWindow
+	offsets []widget.Editor
+
+	apply widget.PrimaryClickable
+	reset widget.PrimaryClickable
+	err   string
+}
+
+func NewProcessOffsetsDialog(mwin *MainWindow) *ProcessOffsetsDialog {
+	pd := &ProcessOffsetsDialog{
+		mwin:    mwin,
+		offsets: make([]widget.Editor, len(mwin.processes)),
+	}
+	for i, p := range mwin.processes {
+		pd.offsets[i].SingleLine = true
+		pd.offsets[i].SetText(p.Offset.String())
+	}
+	return pd
+}
+
+// config returns the offsets described by the dialog's inputs.
+func (pd *ProcessOffsetsDialog) config() ([]time.Duration, error) {
+	out := make([]time.Duration, len(pd.offsets))
+	for i := range pd.offsets {
+		d, err := time.ParseDuration(strings.TrimSpace(pd.offsets[i].Text()))
+		if err != nil {
+			return nil, fmt.Errorf("offset of %s must be a duration such as 1.5ms or -20µs", pd.mwin.processes[i].Name)
+		}
+		out[i] = d
+	}
+	return out, nil
+}
+
+func (pd *ProcessOffsetsDialog) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.ProcessOffsetsDialog.Layout").End()
+
+	for pd.apply.Clicked(gtx) {
+		if offsets, err := pd.config(); err != nil {
+			pd.err = err.Error()
+		} else {
+			pd.err = ""
+			for i, p := range pd.mwin.processes[:len(offsets)] {
+				if p.Offset != offsets[i] {
+					pd.mwin.setProcessOffset(p, offsets[i])
+				}
+			}
+		}
+	}
+	for pd.reset.Clicked(gtx) {
+		pd.err = ""
+		for i, p := range pd.mwin.processes[:len(pd.offsets)] {
+			pd.offsets[i].SetText("0s")
+			if p.Offset != 0 {
+				pd.mwin.setProcessOffset(p, 0)
+			}
+		}
+	}
+
+	children := []layout.Widget{
+		func(gtx layout.Context) layout.Dimensions {
+			return theme.Label(win.Theme, tr("Processes that ran on the same machine as the main trace line up without an offset. Positive offsets move processes to the right.")).Layout(win, gtx)
+		},
+		layout.Spacer{Height: 10}.Layout,
+	}
+	for i := range pd.offsets {
+		children = append(children,
+			func(gtx layout.Context) layout.Dimensions {
+				return theme.LineLabel(win.Theme, pd.mwin.processes[i].Name).Layout(win, gtx)
+			},
+			func(gtx layout.Context) layout.Dimensions {
+				return theme.TextBox(win.Theme, &pd.offsets[i], "0s").Layout(win, gtx)
+			},
+			layout.Spacer{Height: 10}.Layout,
+		)
+	}
+	children = append(children,
+		func(gtx layout.Context) layout.Dimensions {
+			return layout.Rigids(gtx, layout.Horizontal,
+				func(gtx layout.Context) layout.Dimensions {
+					return theme.Button(win.Theme, &pd.apply.Clickable, tr("Apply")).Layout(win, gtx)
+				},
+				layout.Spacer{Width: 5}.Layout,
+				func(gtx layout.Context) layout.Dimensions {
+					return theme.Button(win.Theme, &pd.reset.Clickable, tr("Reset offsets")).Layout(win, gtx)
+				},
+			)
+		},
+		func(gtx layout.Context) layout.Dimensions {
+			if pd.err == "" {
+				return layout.Dimensions{}
+			}
+			l := theme.Label(win.Theme, pd.err)
+			l.Color = colors[colorStateBlocked]
+			return l.Layout(win, gtx)
+		},
+	)
+	return layout.Rigids(gtx, layout.Vertical, children...)
+}
+
+func displayProcessOffsetsDialog(mwin *MainWindow, win *theme.Window) {
+	pd := NewProcessOffsetsDialog(mwin)
+	win.SetModal(func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+		return theme.Dialog(win.Theme, tr("Process offsets")).Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+			gtx.Constraints.Min = gtx.Constraints.Constrain(image.Pt(600, 400))
+			gtx.Constraints.Max = gtx.Constraints.Min
+			return pd.Layout(win, gtx)
+		})
+	})
+}
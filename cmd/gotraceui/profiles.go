@@ -0,0 +1,433 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	rtrace "runtime/trace"
+	"slices"
+	"strings"
+	"time"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/mem"
+	"honnef.co/go/gotraceui/profile"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/trace/ptrace"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/font"
+	"gioui.org/op/clip"
+	"gioui.org/text"
+	exptrace "golang.org/x/exp/trace"
+)
+
+// A profileStack is the sum of a profile's samples that share a stack.
+type profileStack struct {
+	Stack   []profile.Frame
+	Values  []int64
+	Samples int
+	// matched are the functions of the stack that are compared with the trace's stacks.
+	matched []string
+}
+
+// isRuntimeFrame reports whether fn belongs to the runtime or is one of the functions that other packages use to call
+// into the runtime, such as sync.runtime_SemacquireMutex. The stacks in profiles and in traces differ in how many of
+// these frames they include.
+func isRuntimeFrame(fn string) bool {
+	return strings.HasPrefix(fn, "runtime.") || strings.Contains(fn, ".runtime_")
+}
+
+// matchableFunctions returns the functions of a stack, leaf first, without the leading runtime frames.
+func matchableFunctions(fns []string) []string {
+	for len(fns) > 0 && isRuntimeFrame(fns[0]) {
+		fns = fns[1:]
+	}
+	return fns
+}
+
+// stacksMatch reports whether a stack in a profile and a stack in the trace are the same stack. Traces and profiles
+// use different maximum stack depths, which is why only the common part of the stacks is compared.
+func stacksMatch(a, b []string) bool {
+	n := min(len(a), len(b))
+	return n > 0 && slices.Equal(a[:n], b[:n])
+}
+
+// aggregateProfile sums the values of samples with identical stacks. The result is sorted by the default sample type,
+// in descending order.
+func aggregateProfile(p *profile.Profile) []profileStack {
+	byKey := map[string]int{}
+	var out []profileStack
+	var key strings.Builder
+	for _, s := range p.Samples {
+		key.Reset()
+		for _, f := range s.Stack {
+			fmt.Fprintf(&key, "%s:%d\n", f.Function, f.Line)
+		}
+		idx, ok := byKey[key.String()]
+		if !ok {
+			idx = len(out)
+			byKey[key.String()] = idx
+			fns := make([]string, len(s.Stack))
+			for i, f := range s.Stack {
+				fns[i] = f.Function
+			}
+			out = append(out, profileStack{
+				Stack:   s.Stack,
+				Values:  make([]int64, len(s.Values)),
+				matched: matchableFunctions(fns),
+			})
+		}
+		ps := &out[idx]
+		ps.Samples++
+		for i, v := range s.Values {
+			ps.Values[i] += v
+		}
+	}
+	if def := p.DefaultValue(); def >= 0 {
+		slices.SortStableFunc(out, func(a, b profileStack) int {
+			switch {
+			case a.Values[def] > b.Values[def]:
+				return -1
+			case a.Values[def] < b.Values[def]:
+				return 1
+			default:
+				return 0
+			}
+		})
+	}
+	return out
+}
+
+// profileMatches maps each of a profile's stacks to the trace's stacks that it matches, and the spans that have
+// those stacks.
+type profileMatches struct {
+	Stacks [][]ptrace.StackID
+	// Spans has the number of matching spans per profile stack.
+	Spans []int
+}
+
+func computeProfileMatches(tr *Trace, stacks []profileStack) *profileMatches {
+	defer rtrace.StartRegion(context.Background(), "main.computeProfileMatches").End()
+
+	spansPerStack := map[ptrace.StackID]int{}
+	for _, g := range tr.Goroutines {
+		for _, s := range g.Spans {
+			if stk := tr.Event(s.StartEvent).Stack(); stk != exptrace.NoStack {
+				spansPerStack[tr.StackID(stk)]++
+			}
+		}
+	}
+
+	out := &profileMatches{
+		Stacks: make([][]ptrace.StackID, len(stacks)),
+		Spans:  make([]int, len(stacks)),
+	}
+	var fns []string
+	for stk, n := range spansPerStack {
+		fns = fns[:0]
+		for _, pc := range tr.Stacks[stk] {
+			fns = append(fns, tr.FuncName(pc))
+		}
+		trimmed := matchableFunctions(fns)
+		for i := range stacks {
+			if stacksMatch(stacks[i].matched, trimmed) {
+				out.Stacks[i] = append(out.Stacks[i], stk)
+				out.Spans[i] += n
+			}
+		}
+	}
+	return out
+}
+
+// ProfileComponent displays a pprof profile, such as a block, mutex, or goroutine profile, that was captured
+// alongside the trace. It lists the profile's stacks and their values, and cross-links them to the trace's spans with
+// matching stacks.
+type ProfileComponent struct {
+	trace  *Trace
+	canvas *Canvas
+	name   string
+	prof   *profile.Profile
+	stacks []profileStack
+
+	matches       *theme.Future[*profileMatches]
+	table         *theme.Table
+	scrollState   theme.YScrollableListState
+	cellFormatter CellFormatter
+	selects       []widget.PrimaryClickable
+
+	selected    int
+	split       theme.SplitterState
+	tabbedState theme.TabbedState
+	spans       *SpanList
+	zoom        widget.PrimaryClickable
+	frames      widget.List
+	clicks      mem.BucketSlice[Link]
+	hoveredLink ObjectLink
+}
+
+func NewProfileComponent(win *theme.Window, tr *Trace, canvas *Canvas, name string, p *profile.Profile) *ProfileComponent {
+	stacks := aggregateProfile(p)
+	pc := &ProfileComponent{
+		trace:  tr,
+		canvas: canvas,
+		name:   name,
+		prof:   p,
+		stacks: stacks,
+		matches: theme.NewFuture(win, func(cancelled <-chan struct{}) *profileMatches {
+			return computeProfileMatches(tr, stacks)
+		}),
+		selects:  make([]widget.PrimaryClickable, len(stacks)),
+		selected: -1,
+		split: theme.SplitterState{
+			Axis:  layout.Vertical,
+			Ratio: 0.5,
+		},
+	}
+	pc.frames.Axis = layout.Vertical
+	return pc
+}
+
+// Title implements theme.Component.
+func (pc *ProfileComponent) Title() string {
+	return local.Sprintf("Profile %s", pc.name)
+}
+
+// Transition implements theme.Component.
+func (*ProfileComponent) Transition(state theme.ComponentState) {}
+
+// WantsTransition implements theme.Component.
+func (*ProfileComponent) WantsTransition(gtx layout.Context) theme.ComponentState {
+	return theme.ComponentStateNone
+}
+
+func (pc *ProfileComponent) HoveredLink() ObjectLink {
+	if pc.hoveredLink != nil {
+		return pc.hoveredLink
+	}
+	if pc.spans != nil {
+		if l := pc.spans.HoveredLink(); l != nil {
+			return l
+		}
+	}
+	return pc.cellFormatter.HoveredLink()
+}
+
+func (pc *ProfileComponent) initTable(win *theme.Window, gtx layout.Context) {
+	if pc.table != nil {
+		return
+	}
+	cols := []theme.Column{
+		{Name: "Function", Alignment: text.Start},
+		{Name: "Samples", Alignment: text.End},
+	}
+	for _, st := range pc.prof.SampleTypes {
+		cols = append(cols, theme.Column{Name: fmt.Sprintf("%s (%s)", st.Type, st.Unit), Alignment: text.End})
+	}
+	cols = append(cols, theme.Column{Name: "Matching spans", Alignment: text.End})
+	pc.table = &theme.Table{}
+	pc.table.SetColumns(win, gtx, cols)
+}
+
+// leafFunction returns the innermost function of a stack that isn't part of the runtime.
+func leafFunction(ps *profileStack) string {
+	if len(ps.matched) > 0 {
+		return ps.matched[0]
+	}
+	if len(ps.Stack) > 0 {
+		return ps.Stack[0].Function
+	}
+	return "<unknown>"
+}
+
+func (pc *ProfileComponent) value(win *theme.Window, gtx layout.Context, st profile.ValueType, v int64) layout.Dimensions {
+	switch st.Unit {
+	case "nanoseconds":
+		return pc.cellFormatter.Duration(win, gtx, time.Duration(v), false)
+	case "bytes":
+		return pc.cellFormatter.Text(win, gtx, formatBytes(v))
+	default:
+		return pc.cellFormatter.Number(win, gtx, int(v))
+	}
+}
+
+func (pc *ProfileComponent) selectStack(idx int, matches *profileMatches) {
+	pc.selected = idx
+	stacks := map[ptrace.StackID]struct{}{}
+	for _, stk := range matches.Stacks[idx] {
+		stacks[stk] = struct{}{}
+	}
+	var bases []Items[ptrace.Span]
+	for _, g := range pc.trace.Goroutines {
+		var spans []ptrace.Span
+		for _, s := range g.Spans {
+			if _, ok := stacks[pc.trace.StackID(pc.trace.Event(s.StartEvent).Stack())]; ok {
+				spans = append(spans, s)
+			}
+		}
+		if len(spans) == 0 {
+			continue
+		}
+		base := SimpleItems[ptrace.Span, any]{items: spans}
+		if tl := pc.canvas.itemToTimeline[g]; tl != nil {
+			base.container = ItemContainer{Timeline: tl, Track: tl.tracks[0]}
+		}
+		bases = append(bases, base)
+	}
+	pc.spans = &SpanList{
+		Spans: NewSortedItems(MergeItems(bases, func(a, b *ptrace.Span) bool {
+			return a.Start < b.Start
+		})),
+	}
+	pc.frames.Position = layout.Position{}
+}
+
+// Layout implements theme.Component.
+func (pc *ProfileComponent) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.ProfileComponent.Layout").End()
+
+	matches, ok := pc.matches.Result()
+	if !ok {
+		return theme.Loading(win.Theme, tr("Matching stacks…")).Layout(win, gtx)
+	}
+
+	pc.initTable(win, gtx)
+	pc.table.Update(gtx)
+	pc.cellFormatter.Update(win, gtx)
+	for i := range pc.selects {
+		if pc.selects[i].Clicked(gtx) {
+			pc.selectStack(i, matches)
+		}
+	}
+
+	cellFn := func(win *theme.Window, gtx layout.Context, row, col int) layout.Dimensions {
+		defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+
+		ps := &pc.stacks[row]
+		switch {
+		case col == 0:
+			return pc.selects[row].Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				c := win.Theme.Palette.Link
+				if row == pc.selected {
+					c = win.Theme.Palette.Foreground
+				}
+				return widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, font.Font{}, win.Theme.TextSize, leafFunction(ps), win.ColorMaterial(gtx, c))
+			})
+		case col == 1:
+			return pc.cellFormatter.Number(win, gtx, ps.Samples)
+		case col-2 < len(ps.Values):
+			return pc.value(win, gtx, pc.prof.SampleTypes[col-2], ps.Values[col-2])
+		default:
+			return pc.cellFormatter.Number(win, gtx, matches.Spans[row])
+		}
+	}
+
+	stacksTable := func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				s := local.Sprintf("%d samples in %d unique stacks.", len(pc.prof.Samples), len(pc.stacks))
+				if !pc.prof.Time.IsZero() {
+					s = local.Sprintf("%s Collected at %s.", s, pc.prof.Time.Format(time.DateTime))
+				}
+				return theme.LineLabel(win.Theme, s).Layout(win, gtx)
+			}),
+			layout.Rigid(layout.Spacer{Height: 5}.Layout),
+			layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+				return theme.SimpleTable(win, gtx, pc.table, &pc.scrollState, len(pc.stacks), cellFn)
+			}),
+		)
+	}
+	if pc.selected == -1 {
+		return stacksTable(win, gtx)
+	}
+	return theme.Splitter(win.Theme, &pc.split).Layout(win, gtx, stacksTable, pc.layoutSelected)
+}
+
+// layoutSelected displays the frames of the selected stack and the trace's spans that have the same stack.
+func (pc *ProfileComponent) layoutSelected(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	tabs := []string{
+		"Stack",
+		local.Sprintf("Matching spans (%d)", pc.spans.Spans.Len()),
+	}
+	return theme.Tabbed(&pc.tabbedState, tabs).Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+		gtx.Constraints.Min = gtx.Constraints.Max
+		switch pc.tabbedState.Current {
+		case 0:
+			return pc.layoutFrames(win, gtx)
+		case 1:
+			for pc.zoom.Clicked(gtx) {
+				win.EmitAction(&ZoomToSpansAction{Spans: pc.spans.Spans})
+			}
+			return layout.Rigids(gtx, layout.Vertical,
+				func(gtx layout.Context) layout.Dimensions {
+					if pc.spans.Spans.Len() == 0 {
+						return theme.LineLabel(win.Theme, tr("No spans in the trace have this stack.")).Layout(win, gtx)
+					}
+					return theme.Button(win.Theme, &pc.zoom.Clickable, tr("Zoom to matching spans")).Layout(win, gtx)
+				},
+				layout.Spacer{Height: 5}.Layout,
+				func(gtx layout.Context) layout.Dimensions {
+					gtx.Constraints.Min = gtx.Constraints.Max
+					return pc.spans.Layout(win, gtx, pc.trace)
+				},
+			)
+		default:
+			panic("unreachable")
+		}
+	})
+}
+
+func (pc *ProfileComponent) layoutFrames(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	handleLinkClicks(win, gtx, &pc.clicks)
+	pc.hoveredLink = nil
+	for i, n := 0, pc.clicks.Len(); i < n; i++ {
+		if c := pc.clicks.Ptr(i); c.Click.Hovered() {
+			pc.hoveredLink = c.Link
+			break
+		}
+	}
+	pc.clicks.Reset()
+
+	frames := pc.stacks[pc.selected].Stack
+	mono := font.Font{Typeface: win.Theme.MonospaceTypeface}
+	return theme.List(win.Theme, &pc.frames).Layout(win, gtx, len(frames), func(gtx layout.Context, i int) layout.Dimensions {
+		f := frames[i]
+		return layout.Inset{Bottom: 5}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			return layout.Rigids(gtx, layout.Vertical,
+				func(gtx layout.Context) layout.Dimensions {
+					return widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, mono, win.Theme.TextSize, f.Function, win.ColorMaterial(gtx, win.Theme.Palette.Foreground))
+				},
+				func(gtx layout.Context) layout.Dimensions {
+					click := pc.clicks.Grow()
+					click.Link = &SourceLocationObjectLink{File: f.File, Line: uint64(f.Line)}
+					return layout.Inset{Left: 40}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+						return click.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+							s := fmt.Sprintf("%s:%d", pc.trace.displayPath(f.File), f.Line)
+							return widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, mono, win.Theme.TextSize, s, win.ColorMaterial(gtx, win.Theme.Palette.Link))
+						})
+					})
+				},
+			)
+		})
+	})
+}
+
+// openProfile loads a pprof profile and opens it in a new tab.
+func (mwin *MainWindow) openProfile() {
+	mwin.chooseTraceFile(func(rc io.ReadCloser) {
+		defer rc.Close()
+		name := "profile"
+		if f, ok := rc.(interface{ Name() string }); ok {
+			name = filepath.Base(f.Name())
+		}
+		p, err := profile.Parse(rc)
+		if err != nil {
+			mwin.twin.Notify(theme.NotificationError, local.Sprintf("Couldn't load profile %s: %s", name, err))
+			return
+		}
+		mwin.twin.EmitAction(theme.ExecuteAction(func(gtx layout.Context) {
+			mwin.openTab(Tab{Component: NewProfileComponent(mwin.twin, mwin.trace, mwin.canvas, name, p)})
+		}))
+	})
+}
@@ -0,0 +1,190 @@
+package main
+
+// Span pyramids
+//
+// Computing a texture has to look at every span in the texture's time range. When zoomed out far enough to see
+// all of a track with millions of spans, that's millions of spans per texture, most of which end up contributing
+// a tiny fraction of a pixel. Span pyramids precompute aggregations of spans for power-of-two zoom levels, similar
+// to mipmaps. Each level consists of runs of consecutive spans, and each run is no wider than the level's
+// resolution. When computing a texture, we pick the coarsest level whose runs are still a fraction of a pixel
+// wide and treat each run like a single, partially covered span. The cost of computing a texture is then
+// proportional to the number of pixels, not the number of spans.
+//
+// The structure of a pyramid only depends on the spans and is built once, when loading the trace. The colors of
+// runs depend on the palette and the color rules and are computed lazily, whenever the colors of spans have
+// changed. Colors are computed level by level, reusing the colors of the level below.
+
+import (
+	"context"
+	rtrace "runtime/trace"
+	"sync"
+
+	"honnef.co/go/gotraceui/color"
+	"honnef.co/go/gotraceui/trace/ptrace"
+
+	exptrace "golang.org/x/exp/trace"
+)
+
+// minPyramidSpans is the number of spans a track has to have for us to build a pyramid for it. Textures for
+// tracks with fewer spans are cheap to compute even at the lowest zoom level.
+const minPyramidSpans = texWidth
+
+// pyramidLevelSlack is the number of zoom levels between a texture's resolution and the pyramid level used to
+// compute it. Runs are at most 2^-slack pixels wide, which keeps the error of spreading a run's coverage evenly
+// across its extent invisible.
+const pyramidLevelSlack = 2
+
+// spanRun is a run of consecutive spans.
+type spanRun struct {
+	Start exptrace.Time
+	End   exptrace.Time
+	// The sum of the durations of the spans in the run, which is less than End - Start if there are gaps
+	// between the spans.
+	covered exptrace.Time
+	// The range [first, last) of runs in the level below that make up this run. For the lowest level, these are
+	// indices of spans.
+	first, last int
+}
+
+type pyramidLevel struct {
+	// The log2 of the maximum width of runs in nanoseconds.
+	shift int
+	runs  []spanRun
+}
+
+type spanPyramid struct {
+	track  *Track
+	spans  Items[ptrace.Span]
+	levels []pyramidLevel
+
+	mu sync.Mutex
+	// The value of spanColorsGeneration that colors were computed for.
+	colorsGeneration uint64
+	// The duration-weighted mean color of each run, per level.
+	colors [][]color.LinearSRGB
+}
+
+// newSpanPyramid builds the pyramid for a track's spans. Only levels that have at most half as many runs as the
+// level below are kept, which bounds the memory usage of the pyramid by the number of spans.
+func newSpanPyramid(track *Track, spans Items[ptrace.Span]) *spanPyramid {
+	p := &spanPyramid{
+		track: track,
+		spans: spans,
+	}
+	n := spans.Len()
+	if n == 0 {
+		return p
+	}
+
+	var below []spanRun
+	bounds := func(i int) (start, end, covered exptrace.Time) {
+		if below == nil {
+			s := spans.AtPtr(i)
+			return s.Start, s.End, s.End - s.Start
+		}
+		r := &below[i]
+		return r.Start, r.End, r.covered
+	}
+	// Once runs are as wide as the track, all spans form a single run and we're done.
+	for shift := 0; shift < 63 && n > 1; shift++ {
+		width := exptrace.Time(1) << shift
+
+		// Count the runs before building them, to avoid allocating for levels that we wouldn't keep.
+		count := 0
+		for i := 0; i < n; count++ {
+			start, _, _ := bounds(i)
+			for i++; i < n; i++ {
+				if _, end, _ := bounds(i); end-start > width {
+					break
+				}
+			}
+		}
+		if count > n/2 {
+			continue
+		}
+
+		runs := make([]spanRun, 0, count)
+		for i := 0; i < n; {
+			start, end, covered := bounds(i)
+			run := spanRun{Start: start, End: end, covered: covered, first: i}
+			for i++; i < n; i++ {
+				_, end, covered := bounds(i)
+				if end-start > width {
+					break
+				}
+				run.End = end
+				run.covered += covered
+			}
+			run.last = i
+			runs = append(runs, run)
+		}
+		p.levels = append(p.levels, pyramidLevel{shift: shift, runs: runs})
+		below = runs
+		n = len(runs)
+	}
+	return p
+}
+
+// level returns the coarsest level whose runs are no wider than 2^shift nanoseconds, or nil if there is no such
+// level.
+func (p *spanPyramid) level(shift int) (*pyramidLevel, int) {
+	for i := len(p.levels) - 1; i >= 0; i-- {
+		if p.levels[i].shift <= shift {
+			return &p.levels[i], i
+		}
+	}
+	return nil, -1
+}
+
+// runColors returns the colors of the runs of level idx, computing the colors of all levels if the colors of spans
+// have changed since they were last computed.
+func (p *spanPyramid) runColors(idx int) []color.LinearSRGB {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	gen := spanColorsGeneration()
+	if p.colors != nil && p.colorsGeneration == gen {
+		return p.colors[idx]
+	}
+	defer rtrace.StartRegion(context.Background(), "main.spanPyramid.runColors").End()
+
+	tr := p.track.parent.cv.trace
+	if p.colors == nil {
+		p.colors = make([][]color.LinearSRGB, len(p.levels))
+	}
+	for i := range p.levels {
+		runs := p.levels[i].runs
+		if p.colors[i] == nil {
+			p.colors[i] = make([]color.LinearSRGB, len(runs))
+		}
+		for j := range runs {
+			run := &runs[j]
+			var sum color.LinearSRGB
+			for k := run.first; k < run.last; k++ {
+				var c color.LinearSRGB
+				var w float32
+				if i == 0 {
+					span := p.spans.AtPtr(k)
+					c = mappedPaletteColor(p.track.SpanColor(span, tr))
+					w = float32(span.Duration())
+				} else {
+					c = p.colors[i-1][k]
+					w = float32(p.levels[i-1].runs[k].covered)
+				}
+				sum.R += c.R * w
+				sum.G += c.G * w
+				sum.B += c.B * w
+			}
+			if run.covered > 0 {
+				w := float32(run.covered)
+				sum.R /= w
+				sum.G /= w
+				sum.B /= w
+			}
+			sum.A = 1
+			p.colors[i][j] = sum
+		}
+	}
+	p.colorsGeneration = gen
+	return p.colors[idx]
+}
@@ -0,0 +1,524 @@
+package main
+
+import (
+	"context"
+	rtrace "runtime/trace"
+	"slices"
+	"strings"
+	"time"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/query"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/trace/ptrace"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/op/clip"
+	"gioui.org/text"
+	exptrace "golang.org/x/exp/trace"
+)
+
+const (
+	maxQueryHistory = 50
+	// The maximum number of rows displayed by the query console. Queries that produce more rows are truncated.
+	maxQueryRows = 10000
+)
+
+// traceCatalog returns the tables of a trace that can be queried. Timestamps are adjusted by the trace's time offset,
+// like everywhere else in the UI.
+func traceCatalog(tr *Trace) query.Catalog {
+	tsValue := func(ts exptrace.Time) query.Value {
+		return query.Time(int64(tr.AdjustedTime(ts)))
+	}
+	optTime := func(ts exptrace.Time, ok bool) query.Value {
+		if !ok {
+			return query.Null()
+		}
+		return tsValue(ts)
+	}
+	fnName := func(g *ptrace.Goroutine) query.Value {
+		if g.Function == nil {
+			return query.Null()
+		}
+		return query.String(g.Function.Func)
+	}
+	intervals := func(spans func() []ptrace.Span) query.Source {
+		return query.NewSource([]query.Column{
+			{Name: "start", Kind: query.KindTime},
+			{Name: "end", Kind: query.KindTime},
+			{Name: "duration", Kind: query.KindDuration},
+		}, func(yield func(row []query.Value) bool) {
+			row := make([]query.Value, 3)
+			for _, s := range spans() {
+				row[0] = tsValue(s.Start)
+				row[1] = tsValue(s.End)
+				row[2] = query.Duration(s.Duration())
+				if !yield(row) {
+					return
+				}
+			}
+		})
+	}
+
+	return query.Catalog{
+		"spans": query.NewSource([]query.Column{
+			{Name: "goroutine", Kind: query.KindInt},
+			{Name: "function", Kind: query.KindString},
+			{Name: "state", Kind: query.KindString},
+			{Name: "tags", Kind: query.KindString},
+			{Name: "in", Kind: query.KindString},
+			{Name: "start", Kind: query.KindTime},
+			{Name: "end", Kind: query.KindTime},
+			{Name: "duration", Kind: query.KindDuration},
+		}, func(yield func(row []query.Value) bool) {
+			row := make([]query.Value, 8)
+			for _, g := range tr.Goroutines {
+				row[0] = query.Int(int64(g.ID))
+				row[1] = fnName(g)
+				for i := range g.Spans {
+					s := &g.Spans[i]
+					row[2] = query.String(stateNames[s.State])
+					row[3] = query.String(strings.Join(spanTagStrings(s.Tags), ", "))
+					row[4] = query.Null()
+					if stk := tr.Event(s.StartEvent).Stack(); stk != exptrace.NoStack {
+						row[4] = query.String(tr.FuncName(tr.Stacks[tr.StackID(stk)][s.At]))
+					}
+					row[5] = tsValue(s.Start)
+					row[6] = tsValue(s.End)
+					row[7] = query.Duration(s.Duration())
+					if !yield(row) {
+						return
+					}
+				}
+			}
+		}),
+
+		"goroutines": query.NewSource([]query.Column{
+			{Name: "goroutine", Kind: query.KindInt},
+			{Name: "function", Kind: query.KindString},
+			{Name: "parent", Kind: query.KindInt},
+			{Name: "start", Kind: query.KindTime},
+			{Name: "end", Kind: query.KindTime},
+			{Name: "lifetime", Kind: query.KindDuration},
+			{Name: "spans", Kind: query.KindInt},
+			{Name: "blocked", Kind: query.KindDuration},
+			{Name: "inactive", Kind: query.KindDuration},
+			{Name: "gc_assist", Kind: query.KindDuration},
+			{Name: "running", Kind: query.KindDuration},
+		}, func(yield func(row []query.Value) bool) {
+			row := make([]query.Value, 11)
+			for _, g := range tr.Goroutines {
+				stats := ptrace.ComputeStatistics(ptrace.ToSpans(g.Spans))
+				row[0] = query.Int(int64(g.ID))
+				row[1] = fnName(g)
+				row[2] = query.Null()
+				if g.Parent != 0 {
+					row[2] = query.Int(int64(g.Parent))
+				}
+				row[3] = optTime(g.Start.Get())
+				row[4] = optTime(g.End.Get())
+				row[5] = query.Duration(time.Duration(g.EffectiveEnd() - g.EffectiveStart()))
+				row[6] = query.Int(int64(len(g.Spans)))
+				row[7] = query.Duration(stats.Blocked())
+				row[8] = query.Duration(stats.Inactive())
+				row[9] = query.Duration(stats.GCAssist())
+				row[10] = query.Duration(stats.Running())
+				if !yield(row) {
+					return
+				}
+			}
+		}),
+
+		"regions": query.NewSource([]query.Column{
+			{Name: "goroutine", Kind: query.KindInt},
+			{Name: "function", Kind: query.KindString},
+			{Name: "type", Kind: query.KindString},
+			{Name: "depth", Kind: query.KindInt},
+			{Name: "start", Kind: query.KindTime},
+			{Name: "end", Kind: query.KindTime},
+			{Name: "duration", Kind: query.KindDuration},
+		}, func(yield func(row []query.Value) bool) {
+			row := make([]query.Value, 7)
+			for _, g := range tr.Goroutines {
+				row[0] = query.Int(int64(g.ID))
+				row[1] = fnName(g)
+				for depth, spans := range g.UserRegions {
+					row[3] = query.Int(int64(depth))
+					for i := range spans {
+						s := &spans[i]
+						row[2] = query.String(tr.Event(s.StartEvent).Region().Type)
+						row[4] = tsValue(s.Start)
+						row[5] = tsValue(s.End)
+						row[6] = query.Duration(s.Duration())
+						if !yield(row) {
+							return
+						}
+					}
+				}
+			}
+		}),
+
+		"tasks": query.NewSource([]query.Column{
+			{Name: "task", Kind: query.KindInt},
+			{Name: "name", Kind: query.KindString},
+			{Name: "parent", Kind: query.KindInt},
+			{Name: "start", Kind: query.KindTime},
+			{Name: "end", Kind: query.KindTime},
+			{Name: "duration", Kind: query.KindDuration},
+		}, func(yield func(row []query.Value) bool) {
+			row := make([]query.Value, 6)
+			for _, t := range tr.Tasks {
+				if t.Stub() {
+					continue
+				}
+				row[0] = query.Int(int64(t.ID))
+				row[1] = query.String(t.Name)
+				row[2] = query.Null()
+				if t.Parent != 0 {
+					row[2] = query.Int(int64(t.Parent))
+				}
+				start, startOk := t.Start.Get()
+				end, endOk := t.End.Get()
+				row[3] = optTime(start, startOk)
+				row[4] = optTime(end, endOk)
+				row[5] = query.Null()
+				if startOk && endOk {
+					row[5] = query.Duration(time.Duration(end - start))
+				}
+				if !yield(row) {
+					return
+				}
+			}
+		}),
+
+		"gc":  intervals(func() []ptrace.Span { return tr.GC }),
+		"stw": intervals(func() []ptrace.Span { return tr.STW }),
+	}
+}
+
+func addQueryHistory(win *theme.Window, q string) {
+	userConfigMu.Lock()
+	defer userConfigMu.Unlock()
+	history := []string{q}
+	for _, o := range userConfig.QueryHistory {
+		if o != q && len(history) < maxQueryHistory {
+			history = append(history, o)
+		}
+	}
+	userConfig.QueryHistory = history
+	if err := userConfig.Save(); err != nil {
+		win.Notify(theme.NotificationError, local.Sprintf("Couldn't save configuration: %s", err))
+	}
+}
+
+// queryHistory returns the previously run queries, most recent first.
+func queryHistory() []string {
+	userConfigMu.Lock()
+	defer userConfigMu.Unlock()
+	return slices.Clone(userConfig.QueryHistory)
+}
+
+// QueryResults displays the result of a query as a table.
+type QueryResults struct {
+	Trace  *Trace
+	Result *query.Result
+
+	table         *theme.Table
+	scrollState   theme.YScrollableListState
+	cellFormatter CellFormatter
+}
+
+func (qr *QueryResults) HoveredLink() ObjectLink {
+	return qr.cellFormatter.HoveredLink()
+}
+
+// goroutine returns the goroutine with the given ID, or nil if there is none.
+func (qr *QueryResults) goroutine(id int64) *ptrace.Goroutine {
+	gs := qr.Trace.Goroutines
+	idx, ok := slices.BinarySearchFunc(gs, exptrace.GoID(id), func(g *ptrace.Goroutine, id exptrace.GoID) int {
+		return cmp(g.ID, id, false)
+	})
+	if !ok {
+		return nil
+	}
+	return gs[idx]
+}
+
+func (qr *QueryResults) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.QueryResults.Layout").End()
+
+	res := qr.Result
+	if qr.table == nil {
+		qr.table = &theme.Table{}
+		cols := make([]theme.Column, len(res.Columns))
+		for i, c := range res.Columns {
+			cols[i] = theme.Column{Name: c.Name, Alignment: text.Start, Clickable: true}
+			switch c.Kind {
+			case query.KindInt, query.KindFloat, query.KindDuration, query.KindTime:
+				cols[i].Alignment = text.End
+			}
+		}
+		qr.table.SetColumns(win, gtx, cols)
+	}
+	qr.table.Update(gtx)
+	qr.cellFormatter.Update(win, gtx)
+
+	if col, ok := qr.table.SortByClickedColumn(); ok {
+		desc := qr.table.SortOrder == theme.SortDescending
+		slices.SortStableFunc(res.Rows, func(a, b []query.Value) int {
+			c := query.Compare(a[col], b[col])
+			if desc {
+				c = -c
+			}
+			return c
+		})
+	}
+
+	if len(res.Rows) == 0 {
+		return layout.Center.Layout(gtx, theme.Dumb(win, theme.Label(win.Theme, tr("The query produced no rows.")).Layout))
+	}
+
+	cellFn := func(win *theme.Window, gtx layout.Context, row, col int) layout.Dimensions {
+		defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+
+		v := res.Rows[row][col]
+		switch v.Kind {
+		case query.KindNull:
+			return layout.Dimensions{Size: gtx.Constraints.Min}
+		case query.KindInt:
+			// Columns named goroutine link to the goroutine, no matter which table or stage they came from.
+			if res.Columns[col].Name == "goroutine" {
+				if g := qr.goroutine(v.Int); g != nil {
+					return qr.cellFormatter.Goroutine(win, gtx, g, "")
+				}
+			}
+			return qr.cellFormatter.Number(win, gtx, int(v.Int))
+		case query.KindFloat:
+			return qr.cellFormatter.Text(win, gtx, local.Sprintf("%.4f", v.Float))
+		case query.KindDuration:
+			return qr.cellFormatter.Duration(win, gtx, v.Duration(), false)
+		case query.KindTime:
+			return qr.cellFormatter.Timestamp(win, gtx, qr.Trace, qr.Trace.UnadjustedTime(AdjustedTime(v.Int)), "")
+		default:
+			return qr.cellFormatter.Text(win, gtx, v.String())
+		}
+	}
+	return theme.SimpleTable(win, gtx, qr.table, &qr.scrollState, len(res.Rows), cellFn)
+}
+
+// QueryConsoleComponent runs queries against the trace's spans, goroutines, user regions, tasks and garbage
+// collections. The query language is documented in the query package. Results can be pinned, which opens them in a
+// panel of their own.
+type QueryConsoleComponent struct {
+	trace *Trace
+
+	editor  widget.Editor
+	run     widget.PrimaryClickable
+	pin     widget.PrimaryClickable
+	history theme.ComboBoxState
+
+	// Only accessed from the window's goroutine. The query goroutine updates the component via actions.
+	running bool
+	err     string
+	// The query that produced the results.
+	ran     string
+	took    time.Duration
+	results *QueryResults
+}
+
+func NewQueryConsoleComponent(tr *Trace) *QueryConsoleComponent {
+	qc := &QueryConsoleComponent{trace: tr}
+	qc.editor.SingleLine = true
+	qc.editor.Submit = true
+	qc.editor.Focus()
+	qc.updateHistory()
+	return qc
+}
+
+func (qc *QueryConsoleComponent) updateHistory() {
+	history := queryHistory()
+	items := make([]theme.ListWindowItem, len(history))
+	for i, q := range history {
+		items[i] = theme.ListWindowItem{Item: q, Label: q}
+	}
+	qc.history.SetItems(items)
+}
+
+// Title implements theme.Component.
+func (*QueryConsoleComponent) Title() string {
+	return tr("Query console")
+}
+
+// Transition implements theme.Component.
+func (*QueryConsoleComponent) Transition(state theme.ComponentState) {}
+
+// WantsTransition implements theme.Component.
+func (*QueryConsoleComponent) WantsTransition(gtx layout.Context) theme.ComponentState {
+	return theme.ComponentStateNone
+}
+
+func (qc *QueryConsoleComponent) HoveredLink() ObjectLink {
+	if qc.results == nil {
+		return nil
+	}
+	return qc.results.HoveredLink()
+}
+
+func (qc *QueryConsoleComponent) runQuery(win *theme.Window) {
+	s := strings.TrimSpace(qc.editor.Text())
+	if s == "" {
+		return
+	}
+	q, err := query.Parse(s)
+	if err != nil {
+		qc.err = local.Sprintf("Invalid query: %s", err)
+		return
+	}
+	addQueryHistory(win, s)
+	qc.updateHistory()
+
+	qc.running = true
+	qc.err = ""
+	go func() {
+		t := time.Now()
+		res, err := query.Run(q, traceCatalog(qc.trace), maxQueryRows)
+		took := time.Since(t)
+		win.EmitAction(theme.ExecuteAction(func(gtx layout.Context) {
+			qc.running = false
+			if err != nil {
+				qc.err = err.Error()
+				return
+			}
+			qc.ran = s
+			qc.took = took
+			qc.results = &QueryResults{Trace: qc.trace, Result: res}
+		}))
+	}()
+}
+
+// Layout implements theme.Component.
+func (qc *QueryConsoleComponent) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.QueryConsoleComponent.Layout").End()
+
+	submitted := false
+	for _, ev := range qc.editor.Events() {
+		if _, ok := ev.(widget.SubmitEvent); ok {
+			submitted = true
+		}
+	}
+	for qc.run.Clicked(gtx) {
+		submitted = true
+	}
+	if submitted && !qc.running {
+		qc.runQuery(win)
+	}
+	if qc.history.Changed() {
+		if item, ok := qc.history.Selected(); ok {
+			qc.editor.SetText(item.Item.(string))
+			qc.editor.SetCaret(qc.editor.Len(), qc.editor.Len())
+			qc.editor.Focus()
+		}
+		qc.history.SetSelected(-1)
+	}
+	for qc.pin.Clicked(gtx) {
+		if qc.results != nil {
+			win.EmitAction(&OpenPanelAction{NewQueryResultsComponent(qc.ran, qc.results)})
+		}
+	}
+
+	status := func(gtx layout.Context) layout.Dimensions {
+		switch {
+		case qc.running:
+			return theme.LineLabel(win.Theme, tr("Running query…")).Layout(win, gtx)
+		case qc.err != "":
+			l := theme.LineLabel(win.Theme, qc.err)
+			l.Color = colors[colorStateBlocked]
+			return l.Layout(win, gtx)
+		case qc.results != nil:
+			res := qc.results.Result
+			msg := local.Sprintf("%d rows in %s.", len(res.Rows), roundDuration(qc.took))
+			if res.Truncated {
+				msg = local.Sprintf("The query produced more than %d rows; only the first %d are shown.", maxQueryRows, maxQueryRows)
+			}
+			return theme.LineLabel(win.Theme, msg).Layout(win, gtx)
+		default:
+			return layout.Dimensions{}
+		}
+	}
+
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+				layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+					return theme.TextBox(win.Theme, &qc.editor, `spans | where duration > 1ms | stats count() by state`).Layout(win, gtx)
+				}),
+				layout.Rigid(layout.Spacer{Width: 5}.Layout),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return theme.Button(win.Theme, &qc.run.Clickable, tr("Run")).Layout(win, gtx)
+				}),
+				layout.Rigid(layout.Spacer{Width: 5}.Layout),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					gtx.Constraints.Max.X = min(gtx.Constraints.Max.X, gtx.Dp(150))
+					gtx.Constraints.Min.X = gtx.Constraints.Max.X
+					cb := theme.ComboBox(win.Theme, &qc.history, "History")
+					cb.PopupWidth = 600
+					return cb.Layout(win, gtx)
+				}),
+				layout.Rigid(layout.Spacer{Width: 5}.Layout),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return theme.Button(win.Theme, &qc.pin.Clickable, tr("Pin results")).Layout(win, gtx)
+				}),
+			)
+		}),
+		layout.Rigid(layout.Spacer{Height: 5}.Layout),
+		layout.Rigid(status),
+		layout.Rigid(layout.Spacer{Height: 5}.Layout),
+		layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+			if qc.results == nil {
+				return theme.Label(win.Theme, tr("Tables: spans, goroutines, regions, tasks, gc, stw.\n"+
+					"Stages: where, select, stats … by, sort, limit. Queries starting with SELECT use SQL.\n"+
+					"See the manual for a description of the query language.")).Layout(win, gtx)
+			}
+			return qc.results.Layout(win, gtx)
+		}),
+	)
+}
+
+// QueryResultsComponent displays the pinned results of a query.
+type QueryResultsComponent struct {
+	query   string
+	results QueryResults
+}
+
+// NewQueryResultsComponent returns a component displaying a copy of results, so that later sorting in either doesn't
+// affect the other.
+func NewQueryResultsComponent(q string, results *QueryResults) *QueryResultsComponent {
+	res := *results.Result
+	res.Rows = slices.Clone(res.Rows)
+	return &QueryResultsComponent{
+		query:   q,
+		results: QueryResults{Trace: results.Trace, Result: &res},
+	}
+}
+
+// Title implements theme.Component.
+func (qc *QueryResultsComponent) Title() string {
+	return qc.query
+}
+
+// Transition implements theme.Component.
+func (*QueryResultsComponent) Transition(state theme.ComponentState) {}
+
+// WantsTransition implements theme.Component.
+func (*QueryResultsComponent) WantsTransition(gtx layout.Context) theme.ComponentState {
+	return theme.ComponentStateNone
+}
+
+func (qc *QueryResultsComponent) HoveredLink() ObjectLink {
+	return qc.results.HoveredLink()
+}
+
+// Layout implements theme.Component.
+func (qc *QueryResultsComponent) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	return qc.results.Layout(win, gtx)
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	rtrace "runtime/trace"
+	"strconv"
+	"strings"
+	"time"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/op"
+)
+
+// tracePlaceholder is replaced with the path of the trace file in commands that record traces.
+const tracePlaceholder = "{trace}"
+
+// traceURL returns the URL of the trace endpoint of net/http/pprof for capturing a trace of duration d. base may be the
+// address of the server, the URL of the pprof index, or the URL of the trace endpoint itself.
+func traceURL(base string, d time.Duration) (string, error) {
+	if !strings.Contains(base, "://") {
+		base = "http://" + base
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case strings.HasSuffix(u.Path, "/debug/pprof/trace"):
+	case strings.HasSuffix(u.Path, "/debug/pprof/"), strings.HasSuffix(u.Path, "/debug/pprof"):
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/trace"
+	default:
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/debug/pprof/trace"
+	}
+	q := u.Query()
+	q.Set("seconds", strconv.FormatFloat(d.Seconds(), 'f', -1, 64))
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// recordFromURL downloads a trace of duration d from a net/http/pprof trace endpoint into w.
+func recordFromURL(ctx context.Context, w io.Writer, base string, d time.Duration) error {
+	u, err := traceURL(base, d)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// recordCommand runs a command that writes a trace to path. The command is split into arguments at white space, and
+// tracePlaceholder is replaced with path. If d is non-zero, the command gets interrupted after d, for commands that
+// don't exit on their own.
+func recordCommand(ctx context.Context, command, path string, d time.Duration) error {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return errors.New("no command specified")
+	}
+	if !strings.Contains(command, tracePlaceholder) {
+		return fmt.Errorf("the command has to contain %s, which gets replaced with the path of the trace file", tracePlaceholder)
+	}
+	for i, arg := range args {
+		args[i] = strings.ReplaceAll(arg, tracePlaceholder, path)
+	}
+
+	if d > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	// Give the command a chance to flush the trace.
+	cmd.Cancel = func() error { return cmd.Process.Signal(os.Interrupt) }
+	cmd.WaitDelay = 10 * time.Second
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if err != nil && d > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		// We interrupted the command ourselves.
+		err = nil
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// RecordDialog captures a trace, either from a running process's net/http/pprof endpoint or by running a command
+// that writes a trace, such as go test -trace, and opens it when done.
+type RecordDialog struct {
+	mwin *MainWindow
+
+	tabbedState theme.TabbedState
+	url         widget.Editor
+	command     widget.Editor
+	urlDuration *theme.NumberInputState[time.Duration]
+	cmdDuration *theme.NumberInputState[time.Duration]
+
+	start  widget.PrimaryClickable
+	cancel widget.PrimaryClickable
+
+	// Only accessed from the window's goroutine. The recording goroutine updates the dialog via actions.
+	recording    context.CancelFunc
+	recordingFor time.Duration
+	started      time.Time
+	err          string
+}
+
+func NewRecordDialog(mwin *MainWindow) *RecordDialog {
+	rd := &RecordDialog{
+		mwin:        mwin,
+		urlDuration: theme.NewDurationInput(5*time.Second, 100*time.Millisecond, time.Hour, time.Second),
+		cmdDuration: theme.NewDurationInput(0, 0, 24*time.Hour, time.Second),
+	}
+	rd.url.SingleLine = true
+	rd.url.SetText("localhost:6060")
+	rd.command.SingleLine = true
+	rd.command.SetText("go test -trace " + tracePlaceholder + " .")
+	return rd
+}
+
+func (rd *RecordDialog) record(win *theme.Window) {
+	f, err := os.CreateTemp("", "gotraceui-*.trace")
+	if err != nil {
+		rd.err = err.Error()
+		return
+	}
+	path := f.Name()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rd.recording = cancel
+	rd.started = time.Now()
+	rd.err = ""
+	fromURL := rd.tabbedState.Current == 0
+	base, command := rd.url.Text(), rd.command.Text()
+	urlDuration, cmdDuration := rd.urlDuration.Value(), rd.cmdDuration.Value()
+	if fromURL {
+		rd.recordingFor = urlDuration
+	} else {
+		rd.recordingFor = cmdDuration
+	}
+
+	go func() {
+		var err error
+		if fromURL {
+			err = recordFromURL(ctx, f, base, urlDuration)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+		} else {
+			// The command writes the file itself.
+			f.Close()
+			err = recordCommand(ctx, command, path, cmdDuration)
+		}
+		if err == nil {
+			if fi, serr := os.Stat(path); serr != nil || fi.Size() == 0 {
+				err = errors.New("no trace was written")
+			}
+		}
+		cancelled := ctx.Err() == context.Canceled
+		cancel()
+
+		win.EmitAction(theme.ExecuteAction(func(gtx layout.Context) {
+			rd.recording = nil
+			switch {
+			case cancelled:
+				os.Remove(path)
+			case err != nil:
+				os.Remove(path)
+				rd.err = local.Sprintf("Couldn't record trace: %s", err)
+			default:
+				win.Notify(theme.NotificationInfo, local.Sprintf("Saved recorded trace to %s", path))
+				rd.mwin.openTraceFile(path)
+			}
+		}))
+	}()
+}
+
+func (rd *RecordDialog) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.RecordDialog.Layout").End()
+
+	for rd.start.Clicked(gtx) {
+		if rd.recording == nil {
+			rd.record(win)
+		}
+	}
+	for rd.cancel.Clicked(gtx) {
+		if rd.recording != nil {
+			rd.recording()
+		}
+	}
+
+	if rd.recording != nil {
+		elapsed := gtx.Now.Sub(rd.started)
+		msg := local.Sprintf("Recording trace… %s", elapsed.Truncate(time.Second))
+		if rd.recordingFor > 0 {
+			msg = local.Sprintf("Recording trace… %s of %s", elapsed.Truncate(time.Second), rd.recordingFor)
+		}
+		op.InvalidateOp{At: gtx.Now.Add(time.Second)}.Add(gtx.Ops)
+		return layout.Rigids(gtx, layout.Vertical,
+			theme.Dumb(win, theme.LineLabel(win.Theme, msg).Layout),
+			layout.Spacer{Height: 10}.Layout,
+			func(gtx layout.Context) layout.Dimensions {
+				return theme.Button(win.Theme, &rd.cancel.Clickable, tr("Cancel")).Layout(win, gtx)
+			},
+		)
+	}
+
+	durationInput := func(state *theme.NumberInputState[time.Duration]) theme.Widget {
+		return func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+			gtx.Constraints.Max.X = min(gtx.Constraints.Max.X, gtx.Dp(200))
+			gtx.Constraints.Min.X = gtx.Constraints.Max.X
+			return theme.NumberInput(win.Theme, state, "Duration").Layout(win, gtx)
+		}
+	}
+
+	tabs := []string{"HTTP endpoint", "Command"}
+	return layout.Rigids(gtx, layout.Vertical,
+		func(gtx layout.Context) layout.Dimensions {
+			return theme.Tabbed(&rd.tabbedState, tabs).Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+				switch rd.tabbedState.Current {
+				case 0:
+					return layout.Rigids(gtx, layout.Vertical,
+						theme.Dumb(win, theme.LineLabel(win.Theme, tr("Address of a server that serves net/http/pprof, or the URL of its trace endpoint")).Layout),
+						func(gtx layout.Context) layout.Dimensions {
+							return theme.TextBox(win.Theme, &rd.url, "localhost:6060").Layout(win, gtx)
+						},
+						layout.Spacer{Height: 10}.Layout,
+						theme.Dumb(win, theme.LineLabel(win.Theme, tr("Duration of the trace")).Layout),
+						theme.Dumb(win, durationInput(rd.urlDuration)),
+					)
+				case 1:
+					return layout.Rigids(gtx, layout.Vertical,
+						theme.Dumb(win, theme.LineLabel(win.Theme, local.Sprintf("Command to run, with %s in place of the trace file", tracePlaceholder)).Layout),
+						func(gtx layout.Context) layout.Dimensions {
+							return theme.TextBox(win.Theme, &rd.command, "go test -trace "+tracePlaceholder+" .").Layout(win, gtx)
+						},
+						layout.Spacer{Height: 10}.Layout,
+						theme.Dumb(win, theme.LineLabel(win.Theme, tr("Interrupt the command after, or 0s to wait for it to exit")).Layout),
+						theme.Dumb(win, durationInput(rd.cmdDuration)),
+					)
+				default:
+					panic("unreachable")
+				}
+			})
+		},
+		layout.Spacer{Height: 10}.Layout,
+		func(gtx layout.Context) layout.Dimensions {
+			return theme.Button(win.Theme, &rd.start.Clickable, tr("Record")).Layout(win, gtx)
+		},
+		func(gtx layout.Context) layout.Dimensions {
+			if rd.err == "" {
+				return layout.Dimensions{}
+			}
+			l := theme.Label(win.Theme, rd.err)
+			l.Color = colors[colorStateBlocked]
+			return l.Layout(win, gtx)
+		},
+	)
+}
+
+func displayRecordDialog(mwin *MainWindow, win *theme.Window) {
+	rd := NewRecordDialog(mwin)
+	win.SetModal(func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+		return theme.Dialog(win.Theme, tr("Record trace")).Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+			gtx.Constraints.Min = gtx.Constraints.Constrain(image.Pt(650, 280))
+			gtx.Constraints.Max = gtx.Constraints.Min
+			return rd.Layout(win, gtx)
+		})
+	})
+}
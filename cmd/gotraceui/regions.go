@@ -0,0 +1,328 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	rtrace "runtime/trace"
+	"slices"
+	"time"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/trace/ptrace"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/font"
+	"gioui.org/op/clip"
+	"gioui.org/text"
+)
+
+// A userRegion is a region of a goroutine, at a nesting depth.
+type userRegion struct {
+	g     *ptrace.Goroutine
+	depth int
+	span  *ptrace.Span
+}
+
+// regionStats aggregates all user regions with the same name.
+type regionStats struct {
+	Name    string
+	Regions []userRegion
+	// The durations of the regions, in ascending order.
+	Durations []time.Duration
+	Total     time.Duration
+}
+
+// Percentile returns the duration that q of the regions didn't exceed, with q in [0, 1].
+func (rs *regionStats) Percentile(q float64) time.Duration {
+	return rs.Durations[int(q*float64(len(rs.Durations)-1))]
+}
+
+type regionStatsKey struct {
+	filter globalFilterKey
+}
+
+// computeRegionStats groups user regions by name. Regions that haven't ended by the end of the trace are ignored
+// because their durations are unknown. Only regions of goroutines and during times included by the global filter f are
+// considered.
+func computeRegionStats(tr *Trace, f *GlobalFilter) []regionStats {
+	defer rtrace.StartRegion(context.Background(), "main.computeRegionStats").End()
+
+	byName := map[string]*regionStats{}
+	for _, g := range tr.Goroutines {
+		if !f.MatchGoroutine(g) {
+			continue
+		}
+		for depth, spans := range g.UserRegions {
+			for i := range spans {
+				span := &spans[i]
+				if span.EndEvent == -1 || !f.MatchTime(span.Start, span.End) {
+					continue
+				}
+				name := tr.Event(span.StartEvent).Region().Type
+				rs, ok := byName[name]
+				if !ok {
+					rs = &regionStats{Name: name}
+					byName[name] = rs
+				}
+				rs.Regions = append(rs.Regions, userRegion{g, depth, span})
+				rs.Durations = append(rs.Durations, span.Duration())
+				rs.Total += span.Duration()
+			}
+		}
+	}
+
+	out := make([]regionStats, 0, len(byName))
+	for _, rs := range byName {
+		slices.Sort(rs.Durations)
+		out = append(out, *rs)
+	}
+	slices.SortFunc(out, func(a, b regionStats) int {
+		return cmp(a.Total, b.Total, true)
+	})
+	return out
+}
+
+// regionSpans turns user regions into items whose containers are the regions' tracks.
+func regionSpans(cv *Canvas, regions []userRegion) Items[ptrace.Span] {
+	type key struct {
+		g     *ptrace.Goroutine
+		depth int
+	}
+	var keys []key
+	groups := map[key][]ptrace.Span{}
+	for _, r := range regions {
+		k := key{r.g, r.depth}
+		if _, ok := groups[k]; !ok {
+			keys = append(keys, k)
+		}
+		groups[k] = append(groups[k], *r.span)
+	}
+
+	bases := make([]Items[ptrace.Span], len(keys))
+	for i, k := range keys {
+		base := SimpleItems[ptrace.Span, any]{items: groups[k]}
+		// The first track shows the goroutine's states, followed by one track per nesting level of regions.
+		if tl := cv.itemToTimeline[k.g]; tl != nil && k.depth+1 < len(tl.tracks) {
+			base.container = ItemContainer{Timeline: tl, Track: tl.tracks[k.depth+1]}
+		}
+		bases[i] = base
+	}
+	return MergeItems(bases, func(a, b *ptrace.Span) bool {
+		return a.Start < b.Start
+	})
+}
+
+// RegionStatsComponent displays duration statistics of user regions, grouped by name, and a histogram of the
+// durations of the selected name.
+type RegionStatsComponent struct {
+	trace  *Trace
+	canvas *Canvas
+	stats  *theme.Future[[]regionStats]
+	// The state of the global filter that stats was computed with.
+	globalKey globalFilterKey
+	// Our own copy of the statistics, which we sort.
+	sorted []regionStats
+
+	split         theme.SplitterState
+	table         *theme.Table
+	scrollState   theme.YScrollableListState
+	cellFormatter CellFormatter
+	selects       []widget.PrimaryClickable
+	// The name whose durations are displayed in the histogram, or the empty string.
+	selected string
+	hist     InteractiveHistogram
+	// Lazily computed spans of the rows, by name.
+	spans map[string]Items[ptrace.Span]
+}
+
+func NewRegionStatsComponent(win *theme.Window, tr *Trace, cv *Canvas) *RegionStatsComponent {
+	rc := &RegionStatsComponent{
+		trace:  tr,
+		canvas: cv,
+		split: theme.SplitterState{
+			Axis:  layout.Vertical,
+			Ratio: 0.5,
+		},
+		hist: InteractiveHistogram{
+			Config: widget.HistogramConfig{RejectOutliers: true, Bins: widget.DefaultHistogramBins},
+		},
+	}
+	rc.compute(win)
+	return rc
+}
+
+func (rc *RegionStatsComponent) compute(win *theme.Window) {
+	f := rc.trace.filter.Clone()
+	rc.globalKey = f.Key()
+	rc.stats = theme.Compute(&rc.trace.analyses, win, regionStatsKey{rc.globalKey}, func() []regionStats {
+		return computeRegionStats(rc.trace, f)
+	})
+	rc.sorted = nil
+	rc.selected = ""
+	rc.spans = map[string]Items[ptrace.Span]{}
+}
+
+// Title implements theme.Component.
+func (*RegionStatsComponent) Title() string {
+	return tr("User regions")
+}
+
+// Transition implements theme.Component.
+func (*RegionStatsComponent) Transition(state theme.ComponentState) {}
+
+// WantsTransition implements theme.Component.
+func (*RegionStatsComponent) WantsTransition(gtx layout.Context) theme.ComponentState {
+	return theme.ComponentStateNone
+}
+
+func (rc *RegionStatsComponent) HoveredLink() ObjectLink {
+	return rc.cellFormatter.HoveredLink()
+}
+
+func (rc *RegionStatsComponent) initTable(win *theme.Window, gtx layout.Context) {
+	if rc.table != nil {
+		return
+	}
+	rc.table = &theme.Table{}
+	rc.table.SetColumns(win, gtx, []theme.Column{
+		{Name: "Region", Clickable: true, Alignment: text.Start},
+		{Name: "Count", Clickable: true, Alignment: text.End},
+		{Name: "Total", Clickable: true, Alignment: text.End},
+		{Name: "p50", Clickable: true, Alignment: text.End},
+		{Name: "p90", Clickable: true, Alignment: text.End},
+		{Name: "p99", Clickable: true, Alignment: text.End},
+		{Name: "Max", Clickable: true, Alignment: text.End},
+	})
+	rc.table.SortedBy = 2
+	rc.table.SortOrder = theme.SortDescending
+}
+
+func (rc *RegionStatsComponent) sort() {
+	desc := rc.table.SortOrder == theme.SortDescending
+	by := func(fn func(rs *regionStats) time.Duration) {
+		slices.SortFunc(rc.sorted, func(a, b regionStats) int {
+			return cmp(fn(&a), fn(&b), desc)
+		})
+	}
+	switch colName := rc.table.Columns[rc.table.SortedBy].Name; colName {
+	case "Region":
+		slices.SortFunc(rc.sorted, func(a, b regionStats) int {
+			return cmp(a.Name, b.Name, desc)
+		})
+	case "Count":
+		slices.SortFunc(rc.sorted, func(a, b regionStats) int {
+			return cmp(len(a.Regions), len(b.Regions), desc)
+		})
+	case "Total":
+		by(func(rs *regionStats) time.Duration { return rs.Total })
+	case "p50":
+		by(func(rs *regionStats) time.Duration { return rs.Percentile(0.5) })
+	case "p90":
+		by(func(rs *regionStats) time.Duration { return rs.Percentile(0.9) })
+	case "p99":
+		by(func(rs *regionStats) time.Duration { return rs.Percentile(0.99) })
+	case "Max":
+		by(func(rs *regionStats) time.Duration { return rs.Percentile(1) })
+	default:
+		panic(fmt.Sprintf("unreachable: %s", colName))
+	}
+}
+
+// Layout implements theme.Component.
+func (rc *RegionStatsComponent) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.RegionStatsComponent.Layout").End()
+
+	if rc.trace.filter.Key() != rc.globalKey {
+		rc.compute(win)
+	}
+
+	stats, ok := rc.stats.Result()
+	if !ok {
+		return theme.Loading(win.Theme, tr("Computing region statistics…")).Layout(win, gtx)
+	}
+	if len(stats) == 0 {
+		return layout.Center.Layout(gtx, theme.Dumb(win, theme.Label(win.Theme, tr("The trace contains no user regions.")).Layout))
+	}
+	if rc.sorted == nil {
+		// computeRegionStats sorts by total duration, which is our default sort order.
+		rc.sorted = slices.Clone(stats)
+		if rc.table != nil {
+			rc.sort()
+		}
+	}
+
+	rc.initTable(win, gtx)
+	rc.table.Update(gtx)
+	if _, ok := rc.table.SortByClickedColumn(); ok {
+		rc.sort()
+	}
+	rc.cellFormatter.Update(win, gtx)
+	if len(rc.selects) != len(rc.sorted) {
+		rc.selects = make([]widget.PrimaryClickable, len(rc.sorted))
+	}
+	for i := range rc.selects {
+		if rc.selects[i].Clicked(gtx) && rc.sorted[i].Name != rc.selected {
+			rc.selected = rc.sorted[i].Name
+			rc.hist.Config.Start = 0
+			rc.hist.Config.End = 0
+			rc.hist.Set(win, rc.sorted[i].Durations)
+		}
+	}
+	if rc.selected != "" && rc.hist.Update(gtx) {
+		i := slices.IndexFunc(rc.sorted, func(rs regionStats) bool { return rs.Name == rc.selected })
+		rc.hist.Set(win, rc.sorted[i].Durations)
+	}
+
+	cellFn := func(win *theme.Window, gtx layout.Context, row, col int) layout.Dimensions {
+		defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+
+		rs := &rc.sorted[row]
+		switch colName := rc.table.Columns[col].Name; colName {
+		case "Region":
+			return rc.selects[row].Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				c := win.Theme.Palette.Link
+				if rs.Name == rc.selected {
+					c = win.Theme.Palette.Foreground
+				}
+				return widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, font.Font{}, win.Theme.TextSize, rs.Name, win.ColorMaterial(gtx, c))
+			})
+		case "Count":
+			spans, ok := rc.spans[rs.Name]
+			if !ok {
+				spans = regionSpans(rc.canvas, rs.Regions)
+				rc.spans[rs.Name] = spans
+			}
+			return layout.RightAligned(gtx, func(gtx layout.Context) layout.Dimensions {
+				link := rc.cellFormatter.Clicks.Grow()
+				link.Link = &SpansObjectLink{Spans: spans}
+				return link.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+					return widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, font.Font{}, win.Theme.TextSize, local.Sprintf("%d", len(rs.Regions)), win.ColorMaterial(gtx, win.Theme.Palette.OpenLink))
+				})
+			})
+		case "Total":
+			return rc.cellFormatter.Duration(win, gtx, rs.Total, false)
+		case "p50":
+			return rc.cellFormatter.Duration(win, gtx, rs.Percentile(0.5), false)
+		case "p90":
+			return rc.cellFormatter.Duration(win, gtx, rs.Percentile(0.9), false)
+		case "p99":
+			return rc.cellFormatter.Duration(win, gtx, rs.Percentile(0.99), false)
+		case "Max":
+			return rc.cellFormatter.Duration(win, gtx, rs.Percentile(1), false)
+		default:
+			panic(colName)
+		}
+	}
+
+	table := func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+		return theme.SimpleTable(win, gtx, rc.table, &rc.scrollState, len(rc.sorted), cellFn)
+	}
+	hist := func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+		if rc.selected == "" {
+			return layout.Center.Layout(gtx, theme.Dumb(win, theme.Label(win.Theme, tr("Click on a region name to display a histogram of its durations.")).Layout))
+		}
+		return rc.hist.Layout(win, gtx)
+	}
+	return theme.Splitter(win.Theme, &rc.split).Layout(win, gtx, table, hist)
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	rtrace "runtime/trace"
+	"slices"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/widget"
+
+	exptrace "golang.org/x/exp/trace"
+)
+
+type runQueuesKey struct{}
+
+// A runQueueChange is a goroutine entering (+1) or leaving (-1) a run queue.
+type runQueueChange struct {
+	t     exptrace.Time
+	delta int
+}
+
+// runQueues returns the length of the global run queue, that is the number of runnable goroutines, and the
+// approximate lengths of the Ps' local run queues over time.
+//
+// The trace doesn't record which run queue a goroutine is on. We attribute runnable goroutines to the P that made them
+// runnable until they start running, which is where the runtime enqueues them, but we can't see goroutines moving
+// between run queues due to work stealing. Goroutines that were made runnable without a P, for example by the
+// netpoller, are attributed to "no P".
+func runQueues(tr *Trace) (global widget.LineChartSeries, perP []widget.LineChartSeries) {
+	defer rtrace.StartRegion(context.Background(), "main.runQueues").End()
+
+	global = metricSeries("All", tr.Metrics["/gotraceui/sched/goroutines/runnable:goroutines"])
+
+	changes := map[exptrace.ProcID][]runQueueChange{}
+	end := tr.End()
+	for _, g := range tr.Goroutines {
+		for i := range g.Spans {
+			span := &g.Spans[i]
+			ev := tr.Event(span.StartEvent)
+			if ev.Kind() != exptrace.EventStateTransition {
+				continue
+			}
+			trans := ev.StateTransition()
+			if trans.Resource.Kind != exptrace.ResourceGoroutine {
+				continue
+			}
+			if _, to := trans.Goroutine(); to != exptrace.GoRunnable {
+				continue
+			}
+			spanEnd := span.End
+			if span.EndEvent == -1 {
+				spanEnd = end
+			}
+			pid := ev.Proc()
+			changes[pid] = append(changes[pid], runQueueChange{span.Start, 1}, runQueueChange{spanEnd, -1})
+		}
+	}
+
+	pids := make([]exptrace.ProcID, 0, len(changes))
+	for pid := range changes {
+		pids = append(pids, pid)
+	}
+	// Sorting puts NoProc, which is -1, first.
+	slices.Sort(pids)
+	for _, pid := range pids {
+		cs := changes[pid]
+		slices.SortFunc(cs, func(a, b runQueueChange) int {
+			return cmp(a.t, b.t, false)
+		})
+		s := widget.LineChartSeries{
+			Name: fmt.Sprintf("P %d", pid),
+			Step: true,
+		}
+		if pid == exptrace.NoProc {
+			s.Name = "no P"
+		}
+		n := 0
+		for _, c := range cs {
+			n += c.delta
+			if len(s.Points) > 0 && s.Points[len(s.Points)-1].X == float64(c.t) {
+				s.Points[len(s.Points)-1].Y = float64(n)
+			} else {
+				s.Points = append(s.Points, widget.LineChartPoint{X: float64(c.t), Y: float64(n)})
+			}
+		}
+		perP = append(perP, s)
+	}
+	return global, perP
+}
+
+// RunQueueComponent plots the lengths of run queues over time, which is a direct visualization of how many
+// goroutines were waiting to be scheduled. Clicking on the chart scrolls the timelines to the clicked time.
+type RunQueueComponent struct {
+	trace  *Trace
+	series *theme.Future[[2][]widget.LineChartSeries]
+
+	perP  widget.Bool
+	chart theme.LineChartState
+	// Whether the chart's series are up to date with perP.
+	initialized bool
+}
+
+func NewRunQueueComponent(win *theme.Window, tr *Trace) *RunQueueComponent {
+	return &RunQueueComponent{
+		trace: tr,
+		series: theme.Compute(&tr.analyses, win, runQueuesKey{}, func() [2][]widget.LineChartSeries {
+			global, perP := runQueues(tr)
+			return [2][]widget.LineChartSeries{{global}, perP}
+		}),
+	}
+}
+
+// Title implements theme.Component.
+func (*RunQueueComponent) Title() string {
+	return tr("Run queues")
+}
+
+// Transition implements theme.Component.
+func (*RunQueueComponent) Transition(state theme.ComponentState) {}
+
+// WantsTransition implements theme.Component.
+func (*RunQueueComponent) WantsTransition(gtx layout.Context) theme.ComponentState {
+	return theme.ComponentStateNone
+}
+
+func (*RunQueueComponent) HoveredLink() ObjectLink {
+	return nil
+}
+
+// Layout implements theme.Component.
+func (rc *RunQueueComponent) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.RunQueueComponent.Layout").End()
+
+	series, ok := rc.series.Result()
+	if !ok {
+		return theme.Loading(win.Theme, tr("Computing run queues…")).Layout(win, gtx)
+	}
+
+	if rc.perP.Update(gtx) || !rc.initialized {
+		rc.initialized = true
+		if rc.perP.Value {
+			rc.chart.SetSeries(series[1])
+		} else {
+			rc.chart.SetSeries(series[0])
+		}
+	}
+	if x, ok := rc.chart.Clicked(); ok {
+		win.EmitAction(ShowTimestampAction(exptrace.Time(x)))
+	}
+
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(theme.Dumb(win, theme.CheckBox(win.Theme, &rc.perP, tr("Show local run queues of Ps (approximate)")).Layout)),
+		layout.Rigid(layout.Spacer{Height: 5}.Layout),
+		layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+			lc := theme.LineChart(win.Theme, &rc.chart)
+			lc.XLabel = "Time"
+			lc.YLabel = "Runnable goroutines"
+			lc.FormatX = func(v float64) string {
+				return formatTimestamp(nil, rc.trace.AdjustedTime(exptrace.Time(v)))
+			}
+			lc.FormatY = func(v float64) string {
+				return local.Sprintf("%.0f", v)
+			}
+			return lc.Layout(win, gtx)
+		}),
+	)
+}
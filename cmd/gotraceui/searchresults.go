@@ -0,0 +1,349 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	rtrace "runtime/trace"
+	"slices"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/mem"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/trace/ptrace"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/font"
+	"gioui.org/op/clip"
+	"gioui.org/text"
+)
+
+// searchResult is a goroutine span that matches a search.
+type searchResult struct {
+	g    *ptrace.Goroutine
+	span *ptrace.Span
+}
+
+// highlightedSpans returns all goroutine spans that are highlighted by f and match the global filter, sorted by start
+// time.
+func highlightedSpans(tr *Trace, f Filter, global *GlobalFilter, cancelled <-chan struct{}) []searchResult {
+	defer rtrace.StartRegion(context.Background(), "main.highlightedSpans").End()
+
+	var out []searchResult
+	for _, g := range tr.Goroutines {
+		select {
+		case <-cancelled:
+			return nil
+		default:
+		}
+		if !global.MatchGoroutine(g) {
+			continue
+		}
+		for i := range g.Spans {
+			if span := &g.Spans[i]; f.MatchSpan(span) && global.MatchSpan(span) {
+				out = append(out, searchResult{g, span})
+			}
+		}
+	}
+	slices.SortFunc(out, func(a, b searchResult) int {
+		return cmp(a.span.Start, b.span.Start, false)
+	})
+	return out
+}
+
+// SearchResultsComponent lists the spans matched by the most recent search, which is the set of highlighted spans,
+// and steps through them, moving the canvas to each one in turn.
+type SearchResultsComponent struct {
+	theme.ComponentButtons
+
+	trace  *Trace
+	canvas *Canvas
+	// The state that the component was last transitioned to.
+	state theme.ComponentState
+
+	// The filter that results are being computed for.
+	query Filter
+	// The state of the global filter that results are being computed with.
+	globalKey globalFilterKey
+	pending   *theme.Future[[]searchResult]
+	// The results, in the order they're displayed in.
+	results []searchResult
+	// The index of the result that was navigated to last, or -1.
+	current int
+
+	prev, next widget.PrimaryClickable
+	// Links for navigating to individual results, one per displayed row.
+	gotos mem.BucketSlice[searchResultLink]
+
+	table         *theme.Table
+	scrollState   theme.YScrollableListState
+	cellFormatter CellFormatter
+}
+
+type searchResultLink struct {
+	click widget.PrimaryClickable
+	index int
+}
+
+func NewSearchResultsComponent(tr *Trace, cv *Canvas) *SearchResultsComponent {
+	return &SearchResultsComponent{
+		trace:   tr,
+		canvas:  cv,
+		current: -1,
+	}
+}
+
+// SetQuery starts collecting the spans matched by f.
+func (sr *SearchResultsComponent) SetQuery(win *theme.Window, f Filter) {
+	sr.query = f
+	sr.globalKey = sr.trace.filter.Key()
+	global := sr.trace.filter.Clone()
+	sr.pending = theme.NewFuture(win, func(cancelled <-chan struct{}) []searchResult {
+		return highlightedSpans(sr.trace, f, global, cancelled)
+	})
+}
+
+// Displayed reports whether the component is currently displayed somewhere.
+func (sr *SearchResultsComponent) Displayed(mwin *MainWindow) bool {
+	if slices.Contains(mwin.panels.visible(), Panel(sr)) || sr.state == theme.ComponentStateWindow {
+		return true
+	}
+	for _, tab := range mwin.tabs {
+		if tab.Component == sr {
+			return true
+		}
+	}
+	return false
+}
+
+// Step navigates to the result that is delta rows away from the current one, wrapping around at either end.
+func (sr *SearchResultsComponent) Step(win *theme.Window, delta int) {
+	if len(sr.results) == 0 {
+		return
+	}
+	n := len(sr.results)
+	var i int
+	switch {
+	case sr.current != -1:
+		i = sr.current + delta
+	case delta > 0:
+		i = delta - 1
+	default:
+		i = n + delta
+	}
+	sr.navigate(win, (i%n+n)%n)
+}
+
+func (sr *SearchResultsComponent) navigate(win *theme.Window, i int) {
+	sr.current = i
+	sr.scrollState.RevealRow(i)
+	r := sr.results[i]
+	tl := sr.canvas.itemToTimeline[r.g]
+	if tl == nil {
+		return
+	}
+	win.Selection.Set(r.g)
+	win.EmitAction(&ScrollAndPanToSpansAction{
+		Spans: SimpleItems[ptrace.Span, any]{
+			items:     []ptrace.Span{*r.span},
+			container: ItemContainer{Timeline: tl, Track: tl.tracks[0]},
+		},
+	})
+}
+
+// Title implements theme.Component.
+func (sr *SearchResultsComponent) Title() string {
+	return tr("Search results")
+}
+
+// Transition implements theme.Component.
+func (sr *SearchResultsComponent) Transition(state theme.ComponentState) {
+	sr.state = state
+	sr.ComponentButtons.Transition(state)
+}
+
+// WantsTransition implements theme.Component.
+func (sr *SearchResultsComponent) WantsTransition(gtx layout.Context) theme.ComponentState {
+	state := sr.ComponentButtons.WantsTransition(gtx)
+	if state == theme.ComponentStateClosed {
+		sr.state = state
+	}
+	return state
+}
+
+func (sr *SearchResultsComponent) HoveredLink() ObjectLink {
+	return sr.cellFormatter.HoveredLink()
+}
+
+func (sr *SearchResultsComponent) initTable(win *theme.Window, gtx layout.Context) {
+	if sr.table != nil {
+		return
+	}
+	sr.table = &theme.Table{}
+	sr.table.SetColumns(win, gtx, []theme.Column{
+		{Name: "#", Alignment: text.End},
+		{Name: "Start", Clickable: true, Alignment: text.End},
+		{Name: "Duration", Clickable: true, Alignment: text.End},
+		{Name: "State", Clickable: true, Alignment: text.Start},
+		{Name: "Goroutine", Clickable: true, Alignment: text.End},
+		{Name: "Function", Clickable: true, Alignment: text.Start},
+	})
+	sr.table.SortedBy = 1
+	sr.table.SortOrder = theme.SortAscending
+}
+
+func (sr *SearchResultsComponent) sort() {
+	var current *ptrace.Span
+	if sr.current != -1 {
+		current = sr.results[sr.current].span
+	}
+
+	descending := sr.table.SortOrder == theme.SortDescending
+	slices.SortStableFunc(sr.results, func(a, b searchResult) int {
+		switch sr.table.SortedBy {
+		case 1: // Start
+			return cmp(a.span.Start, b.span.Start, descending)
+		case 2: // Duration
+			return cmp(a.span.Duration(), b.span.Duration(), descending)
+		case 3: // State
+			return cmp(stateNames[a.span.State], stateNames[b.span.State], descending)
+		case 4: // Goroutine
+			return cmp(a.g.ID, b.g.ID, descending)
+		case 5: // Function
+			var fa, fb string
+			if a.g.Function != nil {
+				fa = a.g.Function.Func
+			}
+			if b.g.Function != nil {
+				fb = b.g.Function.Func
+			}
+			return cmp(fa, fb, descending)
+		default:
+			panic(fmt.Sprintf("unreachable: %d", sr.table.SortedBy))
+		}
+	})
+
+	// Keep the position in the results across sorts.
+	sr.current = slices.IndexFunc(sr.results, func(r searchResult) bool { return r.span == current })
+}
+
+// Layout implements theme.Component.
+func (sr *SearchResultsComponent) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.SearchResultsComponent.Layout").End()
+
+	if k := sr.trace.filter.Key(); k != sr.globalKey {
+		sr.SetQuery(win, sr.query)
+	}
+
+	sr.initTable(win, gtx)
+	sr.table.Update(gtx)
+	sr.cellFormatter.Update(win, gtx)
+
+	if sr.pending != nil {
+		if results, ok := sr.pending.Result(); ok {
+			sr.pending = nil
+			sr.results = results
+			sr.current = -1
+			sr.scrollState = theme.YScrollableListState{}
+			if sr.table.SortedBy != 1 || sr.table.SortOrder != theme.SortAscending {
+				sr.sort()
+			}
+		}
+	}
+
+	if _, ok := sr.table.SortByClickedColumn(); ok {
+		sr.sort()
+	}
+	for i, n := 0, sr.gotos.Len(); i < n; i++ {
+		if l := sr.gotos.Ptr(i); l.click.Clicked(gtx) && l.index < len(sr.results) {
+			sr.navigate(win, l.index)
+		}
+	}
+	sr.gotos.Reset()
+	for sr.ComponentButtons.Backed(gtx) {
+		win.EmitAction(&PrevPanelAction{})
+	}
+	for sr.prev.Clicked(gtx) {
+		sr.Step(win, -1)
+	}
+	for sr.next.Clicked(gtx) {
+		sr.Step(win, 1)
+	}
+
+	var status string
+	switch {
+	case sr.pending != nil:
+		status = "Searching…"
+	case sr.current == -1:
+		status = local.Sprintf("%d spans match.", len(sr.results))
+	default:
+		status = local.Sprintf("Result %d of %d.", sr.current+1, len(sr.results))
+	}
+
+	nothing := func(gtx layout.Context) layout.Dimensions {
+		return layout.Dimensions{Size: gtx.Constraints.Min}
+	}
+	header := func(gtx layout.Context) layout.Dimensions {
+		// Right-aligned buttons should be aligned with the right side of the visible panel.
+		gtx.Constraints.Max.X = gtx.Constraints.Min.X
+		return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+			layout.Rigid(theme.Dumb(win, theme.Button(win.Theme, &sr.prev.Clickable, tr("Previous")).Layout)),
+			layout.Rigid(layout.Spacer{Width: 5}.Layout),
+			layout.Rigid(theme.Dumb(win, theme.Button(win.Theme, &sr.next.Clickable, tr("Next")).Layout)),
+			layout.Rigid(layout.Spacer{Width: 10}.Layout),
+			layout.Rigid(theme.Dumb(win, theme.LineLabel(win.Theme, status).Layout)),
+			layout.Flexed(1, nothing),
+			layout.Rigid(theme.Dumb(win, sr.ComponentButtons.Layout)),
+		)
+	}
+
+	cellFn := func(win *theme.Window, gtx layout.Context, row, col int) layout.Dimensions {
+		defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+
+		if row == sr.current {
+			theme.FillShape(win, gtx.Ops, win.Theme.Palette.PrimarySelection, clip.Rect{Max: gtx.Constraints.Max}.Op())
+		}
+		r := &sr.results[row]
+		switch colName := sr.table.Columns[col].Name; colName {
+		case "#":
+			l := sr.gotos.Grow()
+			l.index = row
+			return layout.RightAligned(gtx, func(gtx layout.Context) layout.Dimensions {
+				return l.click.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+					label := local.Sprintf("%d", row+1)
+					return widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, font.Font{}, win.Theme.TextSize, label, win.ColorMaterial(gtx, win.Theme.Palette.NavigationLink))
+				})
+			})
+		case "Start":
+			return sr.cellFormatter.Timestamp(win, gtx, sr.trace, r.span.Start, "")
+		case "Duration":
+			return sr.cellFormatter.Duration(win, gtx, r.span.Duration(), false)
+		case "State":
+			return sr.cellFormatter.Text(win, gtx, stateNamesCapitalized[r.span.State])
+		case "Goroutine":
+			return sr.cellFormatter.Goroutine(win, gtx, r.g, "")
+		case "Function":
+			if r.g.Function == nil {
+				return sr.cellFormatter.Text(win, gtx, "unknown")
+			}
+			return sr.cellFormatter.Function(win, gtx, r.g.Function)
+		default:
+			panic(colName)
+		}
+	}
+
+	results := func(gtx layout.Context) layout.Dimensions {
+		if sr.pending != nil && sr.results == nil {
+			return theme.Loading(win.Theme, tr("Collecting highlighted spans…")).Layout(win, gtx)
+		}
+		if len(sr.results) == 0 {
+			return layout.Center.Layout(gtx, theme.Dumb(win, theme.Label(win.Theme, tr("No goroutine spans are highlighted.")).Layout))
+		}
+		return theme.SimpleTable(win, gtx, sr.table, &sr.scrollState, len(sr.results), cellFn)
+	}
+
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(header),
+		layout.Rigid(layout.Spacer{Height: 5}.Layout),
+		layout.Flexed(1, results),
+	)
+}
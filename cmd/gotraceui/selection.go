@@ -0,0 +1,325 @@
+package main
+
+import (
+	"context"
+	"image"
+	rtrace "runtime/trace"
+	"slices"
+	"time"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/mysync"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/trace/ptrace"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/text"
+	exptrace "golang.org/x/exp/trace"
+)
+
+type goroutineRunningTime struct {
+	G       *ptrace.Goroutine
+	Running time.Duration
+}
+
+// A rangeSummary aggregates what happened during a time range.
+type rangeSummary struct {
+	Start, End exptrace.Time
+	// The time Ps spent running goroutines, summed over all Ps.
+	ProcBusy time.Duration
+	NumProcs int
+	// The time during which the GC was running or the world was stopped.
+	GC, STW time.Duration
+	// Goroutines that ran during the range, sorted by running time in descending order.
+	Goroutines []goroutineRunningTime
+	// The scheduler latencies of goroutines that started running during the range, in ascending order.
+	Latencies []time.Duration
+}
+
+func (rs *rangeSummary) Duration() time.Duration {
+	return time.Duration(rs.End - rs.Start)
+}
+
+// fraction returns x as a fraction of total, or zero if total is zero.
+func fraction(x, total time.Duration) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(x) / float64(total)
+}
+
+// overlap returns how much of span lies within [start, end).
+func overlap(span *ptrace.Span, start, end exptrace.Time) time.Duration {
+	return time.Duration(max(0, min(span.End, end)-max(span.Start, start)))
+}
+
+func computeRangeSummary(tr *Trace, start, end exptrace.Time, cancelled <-chan struct{}) *rangeSummary {
+	defer rtrace.StartRegion(context.Background(), "main.computeRangeSummary").End()
+
+	rs := &rangeSummary{Start: start, End: end, NumProcs: len(tr.Processors)}
+	for _, p := range tr.Processors {
+		for i, spans := 0, spansOverlapping(p.Spans, start, end); i < len(spans); i++ {
+			if span := &spans[i]; span.State == ptrace.StateProcRunningG {
+				rs.ProcBusy += overlap(span, start, end)
+			}
+		}
+	}
+	for i, spans := 0, spansOverlapping(tr.GC, start, end); i < len(spans); i++ {
+		rs.GC += overlap(&spans[i], start, end)
+	}
+	for i, spans := 0, spansOverlapping(tr.STW, start, end); i < len(spans); i++ {
+		rs.STW += overlap(&spans[i], start, end)
+	}
+
+	// Goroutines make up the bulk of the work, so we spread them across workers and merge their results.
+	type partialSummary struct {
+		goroutines []goroutineRunningTime
+		latencies  []time.Duration
+	}
+	parts, _ := mysync.Map(tr.Goroutines, 0, nil, func(gs []*ptrace.Goroutine) (partialSummary, error) {
+		var part partialSummary
+		for _, g := range gs {
+			select {
+			case <-cancelled:
+				return part, nil
+			default:
+			}
+
+			var running time.Duration
+			spans := spansOverlapping(g.Spans, start, end)
+			for i := range spans {
+				span := &spans[i]
+				switch span.State {
+				case ptrace.StateActive, ptrace.StateGCDedicated, ptrace.StateGCIdle:
+					running += overlap(span, start, end)
+				case ptrace.StateReady:
+					// Only count latencies that ended within the range, that is, goroutines that got scheduled during
+					// the range.
+					if span.EndEvent != -1 && span.End >= start && span.End < end {
+						part.latencies = append(part.latencies, span.Duration())
+					}
+				}
+			}
+			if running > 0 {
+				part.goroutines = append(part.goroutines, goroutineRunningTime{g, running})
+			}
+		}
+		return part, nil
+	})
+	select {
+	case <-cancelled:
+		return nil
+	default:
+	}
+	for _, part := range parts {
+		rs.Goroutines = append(rs.Goroutines, part.goroutines...)
+		rs.Latencies = append(rs.Latencies, part.latencies...)
+	}
+	slices.SortFunc(rs.Goroutines, func(a, b goroutineRunningTime) int {
+		return cmp(a.Running, b.Running, true)
+	})
+	slices.Sort(rs.Latencies)
+	return rs
+}
+
+// RangeSummary is a panel that shows aggregate statistics for a time range selected on the canvas.
+type RangeSummary struct {
+	trace      *Trace
+	start, end exptrace.Time
+	summary    *theme.Future[*rangeSummary]
+
+	table           *theme.Table
+	scrollState     theme.YScrollableListState
+	cellFormatter   CellFormatter
+	descriptionText Text
+	prevSpans       []TextSpan
+	hoveredLink     ObjectLink
+	saveMeasurement widget.PrimaryClickable
+	waitForGraph    widget.PrimaryClickable
+
+	theme.ComponentButtons
+}
+
+func NewRangeSummary(win *theme.Window, tr *Trace, start, end exptrace.Time) *RangeSummary {
+	return &RangeSummary{
+		trace: tr,
+		start: start,
+		end:   end,
+		summary: theme.NewFuture(win, func(cancelled <-chan struct{}) *rangeSummary {
+			return computeRangeSummary(tr, start, end, cancelled)
+		}),
+	}
+}
+
+// Title implements theme.Component.
+func (rs *RangeSummary) Title() string {
+	return local.Sprintf("Summary of %s – %s",
+		formatTimestamp(nil, rs.trace.AdjustedTime(rs.start)), formatTimestamp(nil, rs.trace.AdjustedTime(rs.end)))
+}
+
+func (rs *RangeSummary) HoveredLink() ObjectLink {
+	return rs.hoveredLink
+}
+
+func (rs *RangeSummary) buildDescription(win *theme.Window, summary *rangeSummary) Description {
+	tb := TextBuilder{Window: win}
+	var attrs []DescriptionAttribute
+
+	d := summary.Duration()
+	share := func(x, total time.Duration) string {
+		return local.Sprintf("%.2f%%", fraction(x, total)*100)
+	}
+
+	attrs = append(attrs,
+		DescriptionAttribute{
+			Key:   "Start",
+			Value: *tb.DefaultLink(formatTimestamp(nil, rs.trace.AdjustedTime(summary.Start)), "Start of selection", summary.Start),
+		},
+		DescriptionAttribute{
+			Key:   "End",
+			Value: *tb.DefaultLink(formatTimestamp(nil, rs.trace.AdjustedTime(summary.End)), "End of selection", summary.End),
+		},
+		DescriptionAttribute{Key: "Duration", Value: *tb.Span(roundDuration(d).String())},
+		DescriptionAttribute{
+			Key:   "CPU utilization",
+			Value: *tb.Span(share(summary.ProcBusy, d*time.Duration(summary.NumProcs))),
+		},
+		DescriptionAttribute{Key: "Time in GC", Value: *tb.Span(share(summary.GC, d))},
+		DescriptionAttribute{Key: "Time stopped", Value: *tb.Span(share(summary.STW, d))},
+	)
+
+	if len(summary.Latencies) == 0 {
+		attrs = append(attrs, DescriptionAttribute{Key: "Scheduler latency", Value: *tb.Span("no goroutines were scheduled")})
+	} else {
+		lat := summary.Latencies
+		attrs = append(attrs, DescriptionAttribute{
+			Key: "Scheduler latency",
+			Value: *tb.Span(local.Sprintf("p50 %s, p90 %s, p99 %s, max %s (%d goroutines scheduled)",
+				roundDuration(percentile(lat, 50)), roundDuration(percentile(lat, 90)),
+				roundDuration(percentile(lat, 99)), roundDuration(percentile(lat, 100)), len(lat))),
+		})
+	}
+
+	return Description{Attributes: attrs}
+}
+
+func (rs *RangeSummary) initTable(win *theme.Window, gtx layout.Context) {
+	if rs.table != nil {
+		return
+	}
+	rs.table = &theme.Table{}
+	rs.table.SetColumns(win, gtx, []theme.Column{
+		{Name: "Goroutine", Alignment: text.Start},
+		{Name: "Function", Alignment: text.Start},
+		{Name: "Running time", Alignment: text.End},
+		{Name: "Share of range", Alignment: text.End},
+	})
+}
+
+// Layout implements theme.Component.
+func (rs *RangeSummary) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.RangeSummary.Layout").End()
+
+	for _, ev := range rs.descriptionText.Update(gtx, rs.prevSpans) {
+		handleLinkClick(win, ev.Event, ev.Span.ObjectLink)
+	}
+	rs.hoveredLink = rs.descriptionText.HoveredLink()
+	if rs.hoveredLink == nil {
+		rs.hoveredLink = rs.cellFormatter.HoveredLink()
+	}
+	for rs.ComponentButtons.Backed(gtx) {
+		win.EmitAction(&PrevPanelAction{})
+	}
+	for rs.saveMeasurement.Clicked(gtx) {
+		win.EmitAction(&AddBookmarkAction{Bookmark{Start: rs.start, End: rs.end}})
+		win.ShowNotification(gtx, tr("Saved selection as a measurement"))
+	}
+	for rs.waitForGraph.Clicked(gtx) {
+		win.EmitAction(&OpenWaitForGraphAction{Start: rs.start, End: rs.end})
+	}
+
+	summary, ok := rs.summary.Result()
+	if !ok {
+		return theme.Loading(win.Theme, tr("Summarizing selection…")).Layout(win, gtx)
+	}
+
+	rs.initTable(win, gtx)
+	rs.table.Update(gtx)
+	rs.cellFormatter.Update(win, gtx)
+
+	// Inset of 5 pixels on all sides. We can't use layout.Inset because it doesn't decrease the minimum constraint,
+	// which we do care about here.
+	gtx.Constraints.Min = gtx.Constraints.Min.Sub(image.Pt(2*5, 2*5))
+	gtx.Constraints.Max = gtx.Constraints.Max.Sub(image.Pt(2*5, 2*5))
+	gtx.Constraints = layout.Normalize(gtx.Constraints)
+	defer op.Offset(image.Pt(5, 5)).Push(gtx.Ops).Pop()
+
+	cellFn := func(win *theme.Window, gtx layout.Context, row, col int) layout.Dimensions {
+		defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+
+		grt := &summary.Goroutines[row]
+		switch colName := rs.table.Columns[col].Name; colName {
+		case "Goroutine":
+			return rs.cellFormatter.Goroutine(win, gtx, grt.G, "")
+		case "Function":
+			if grt.G.Function == nil {
+				return rs.cellFormatter.Text(win, gtx, "unknown")
+			}
+			return rs.cellFormatter.Function(win, gtx, grt.G.Function)
+		case "Running time":
+			return rs.cellFormatter.Duration(win, gtx, grt.Running, false)
+		case "Share of range":
+			return layout.RightAligned(gtx, func(gtx layout.Context) layout.Dimensions {
+				return rs.cellFormatter.Text(win, gtx, local.Sprintf("%.2f%%", float64(grt.Running)/float64(summary.Duration())*100))
+			})
+		default:
+			panic(colName)
+		}
+	}
+
+	nothing := func(gtx layout.Context) layout.Dimensions {
+		return layout.Dimensions{Size: gtx.Constraints.Min}
+	}
+	return layout.Rigids(gtx, layout.Vertical,
+		func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Horizontal}.Layout(gtx,
+				layout.Rigid(theme.Dumb(win, theme.Button(win.Theme, &rs.saveMeasurement.Clickable, tr("Save as measurement")).Layout)),
+				layout.Rigid(layout.Spacer{Width: 5}.Layout),
+				layout.Rigid(theme.Dumb(win, theme.Button(win.Theme, &rs.waitForGraph.Clickable, tr("Wait-for graph")).Layout)),
+				layout.Flexed(1, nothing),
+				layout.Rigid(theme.Dumb(win, rs.ComponentButtons.Layout)),
+			)
+		},
+
+		layout.Spacer{Height: 10}.Layout,
+		func(gtx layout.Context) layout.Dimensions {
+			gtx.Constraints.Min = image.Point{}
+			dims, spans := rs.buildDescription(win, summary).Layout(win, gtx, &rs.descriptionText)
+			rs.prevSpans = spans
+			return dims
+		},
+
+		layout.Spacer{Height: 10}.Layout,
+		func(gtx layout.Context) layout.Dimensions {
+			d := summary.Duration()
+			return layout.Rigids(gtx, layout.Horizontal,
+				theme.Dumb(win, theme.Gauge(win.Theme, fraction(summary.ProcBusy, d*time.Duration(summary.NumProcs)), "CPU utilization").Layout),
+				layout.Spacer{Width: 20}.Layout,
+				theme.Dumb(win, theme.Gauge(win.Theme, fraction(summary.GC, d), "Time in GC").Layout),
+				layout.Spacer{Width: 20}.Layout,
+				theme.Dumb(win, theme.Gauge(win.Theme, fraction(summary.STW, d), "Time stopped").Layout),
+			)
+		},
+
+		layout.Spacer{Height: 10}.Layout,
+		func(gtx layout.Context) layout.Dimensions {
+			gtx.Constraints.Min = gtx.Constraints.Max
+			if len(summary.Goroutines) == 0 {
+				return layout.Center.Layout(gtx, theme.Dumb(win, theme.Label(win.Theme, tr("No goroutines ran during the selection.")).Layout))
+			}
+			return theme.SimpleTable(win, gtx, rs.table, &rs.scrollState, len(summary.Goroutines), cellFn)
+		},
+	)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"honnef.co/go/gotraceui/trace/ptrace"
+
+	exptrace "golang.org/x/exp/trace"
+)
+
+func TestComputeRangeSummary(t *testing.T) {
+	span := func(start, end exptrace.Time, state ptrace.SchedulingState) ptrace.Span {
+		return ptrace.Span{Start: start, End: end, State: state, EndEvent: 1}
+	}
+	tr := &Trace{Trace: &ptrace.Trace{}}
+	// Enough goroutines to be spread across several workers.
+	for i := range 100 {
+		g := &ptrace.Goroutine{ID: exptrace.GoID(i)}
+		g.Spans = []ptrace.Span{
+			span(0, 50, ptrace.StateReady),
+			span(50, 50+exptrace.Time(i), ptrace.StateActive),
+		}
+		tr.Goroutines = append(tr.Goroutines, g)
+	}
+
+	rs := computeRangeSummary(tr, 10, 1000, nil)
+	if len(rs.Goroutines) != 99 {
+		t.Fatalf("got %d running goroutines, want 99", len(rs.Goroutines))
+	}
+	for i, g := range rs.Goroutines {
+		if want := time.Duration(99 - i); g.Running != want {
+			t.Errorf("goroutine %d: got running time %v, want %v", i, g.Running, want)
+		}
+	}
+	if len(rs.Latencies) != 100 || !slices.IsSorted(rs.Latencies) {
+		t.Errorf("got latencies %v, want 100 sorted latencies", rs.Latencies)
+	}
+
+	cancelled := make(chan struct{})
+	close(cancelled)
+	if rs := computeRangeSummary(tr, 10, 1000, cancelled); rs != nil {
+		t.Error("got a summary for a cancelled computation")
+	}
+}
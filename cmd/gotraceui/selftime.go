@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	rtrace "runtime/trace"
+	"slices"
+	"strings"
+	"time"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/trace/ptrace"
+	myunsafe "honnef.co/go/gotraceui/unsafe"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/op/clip"
+	"gioui.org/text"
+	exptrace "golang.org/x/exp/trace"
+)
+
+// A selfTimeEntry is the running time attributed to a function, or to a stack if grouping by full stacks.
+type selfTimeEntry struct {
+	Function string
+	// The callers of Function, starting at the immediate caller. Only set when grouping by full stacks.
+	Callers []string
+	// Time spent in the function itself, and time spent in the function or any of the functions it called.
+	// Cumulative time is only computed when grouping by function.
+	Self, Cumulative time.Duration
+}
+
+type selfTimeReport struct {
+	Entries []selfTimeEntry
+	Total   time.Duration
+}
+
+type selfTimeKey struct {
+	byStack bool
+	filter  globalFilterKey
+}
+
+// computeSelfTime aggregates CPU samples by the top frames of their stacks, or by their full stacks. Like go tool
+// pprof, it assumes that every sample accounts for the same amount of time. Samples are restricted to the time range
+// and goroutines of the global filter f.
+func computeSelfTime(tr *Trace, byStack bool, f *GlobalFilter) *selfTimeReport {
+	defer rtrace.StartRegion(context.Background(), "main.computeSelfTime").End()
+
+	d := cpuSampleDuration(tr)
+	entries := map[string]*selfTimeEntry{}
+	get := func(key string, fn string) *selfTimeEntry {
+		e, ok := entries[key]
+		if !ok {
+			e = &selfTimeEntry{Function: fn}
+			entries[key] = e
+		}
+		return e
+	}
+
+	report := &selfTimeReport{}
+	seen := map[string]struct{}{}
+	for _, sample := range tr.CPUSamples {
+		ev := tr.Event(sample)
+		if !f.MatchTime(ev.Time(), ev.Time()) {
+			continue
+		}
+		if f.filtersGoroutines() {
+			var g *ptrace.Goroutine
+			if gid := ev.Goroutine(); gid != exptrace.NoGoroutine {
+				g = tr.G(gid)
+			}
+			if !f.MatchGoroutine(g) {
+				continue
+			}
+		}
+		pcs := tr.Stacks[tr.StackID(ev.Stack())]
+		if len(pcs) == 0 {
+			continue
+		}
+		report.Total += d
+		top := tr.FuncName(pcs[0])
+		if byStack {
+			e := get(string(myunsafe.SliceCast[[]byte](pcs)), top)
+			if e.Callers == nil {
+				e.Callers = make([]string, len(pcs)-1)
+				for i, pc := range pcs[1:] {
+					e.Callers[i] = tr.FuncName(pc)
+				}
+			}
+			e.Self += d
+			continue
+		}
+
+		get(top, top).Self += d
+		// Recursive functions only count once towards cumulative time.
+		clear(seen)
+		for _, pc := range pcs {
+			fn := tr.FuncName(pc)
+			if _, ok := seen[fn]; ok {
+				continue
+			}
+			seen[fn] = struct{}{}
+			get(fn, fn).Cumulative += d
+		}
+	}
+
+	report.Entries = make([]selfTimeEntry, 0, len(entries))
+	for _, e := range entries {
+		report.Entries = append(report.Entries, *e)
+	}
+	return report
+}
+
+// SelfTimeComponent displays a table of where goroutines spent their running time, based on CPU samples, similar to
+// the top view of go tool pprof.
+type SelfTimeComponent struct {
+	trace *Trace
+	win   *theme.Window
+
+	byStack widget.Bool
+	// The state of the global filter that report was computed with.
+	globalKey globalFilterKey
+	report    *theme.Future[*selfTimeReport]
+	// Our own copy of the report's entries, which we sort.
+	entries []selfTimeEntry
+
+	table         *theme.Table
+	scrollState   theme.YScrollableListState
+	cellFormatter CellFormatter
+}
+
+func NewSelfTimeComponent(win *theme.Window, tr *Trace) *SelfTimeComponent {
+	sc := &SelfTimeComponent{
+		trace: tr,
+		win:   win,
+	}
+	sc.compute()
+	return sc
+}
+
+func (sc *SelfTimeComponent) compute() {
+	byStack := sc.byStack.Value
+	f := sc.trace.filter.Clone()
+	sc.globalKey = f.Key()
+	sc.report = theme.Compute(&sc.trace.analyses, sc.win, selfTimeKey{byStack, sc.globalKey}, func() *selfTimeReport {
+		return computeSelfTime(sc.trace, byStack, f)
+	})
+	sc.entries = nil
+	sc.table = nil
+}
+
+// Title implements theme.Component.
+func (*SelfTimeComponent) Title() string {
+	return tr("Time by function")
+}
+
+// Transition implements theme.Component.
+func (*SelfTimeComponent) Transition(state theme.ComponentState) {}
+
+// WantsTransition implements theme.Component.
+func (*SelfTimeComponent) WantsTransition(gtx layout.Context) theme.ComponentState {
+	return theme.ComponentStateNone
+}
+
+func (sc *SelfTimeComponent) HoveredLink() ObjectLink {
+	return sc.cellFormatter.HoveredLink()
+}
+
+func (sc *SelfTimeComponent) initTable(win *theme.Window, gtx layout.Context) {
+	if sc.table != nil {
+		return
+	}
+	sc.table = &theme.Table{}
+	cols := []theme.Column{
+		{Name: "Function", Clickable: true, Alignment: text.Start},
+	}
+	if sc.byStack.Value {
+		cols = append(cols, theme.Column{Name: "Called from", Alignment: text.Start})
+	}
+	cols = append(cols,
+		theme.Column{Name: "Self", Clickable: true, Alignment: text.End},
+		theme.Column{Name: "Self %", Clickable: true, Alignment: text.End},
+	)
+	if !sc.byStack.Value {
+		cols = append(cols,
+			theme.Column{Name: "Cumulative", Clickable: true, Alignment: text.End},
+			theme.Column{Name: "Cumulative %", Clickable: true, Alignment: text.End},
+		)
+	}
+	sc.table.SetColumns(win, gtx, cols)
+	sc.table.SortedBy = slices.IndexFunc(cols, func(col theme.Column) bool { return col.Name == "Self" })
+	sc.table.SortOrder = theme.SortDescending
+	sc.sort()
+}
+
+func (sc *SelfTimeComponent) sort() {
+	desc := sc.table.SortOrder == theme.SortDescending
+	switch colName := sc.table.Columns[sc.table.SortedBy].Name; colName {
+	case "Function":
+		slices.SortFunc(sc.entries, func(a, b selfTimeEntry) int {
+			return cmp(a.Function, b.Function, desc)
+		})
+	case "Self", "Self %":
+		slices.SortFunc(sc.entries, func(a, b selfTimeEntry) int {
+			return cmp(a.Self, b.Self, desc)
+		})
+	case "Cumulative", "Cumulative %":
+		slices.SortFunc(sc.entries, func(a, b selfTimeEntry) int {
+			return cmp(a.Cumulative, b.Cumulative, desc)
+		})
+	default:
+		panic(fmt.Sprintf("unreachable: %s", colName))
+	}
+}
+
+// Layout implements theme.Component.
+func (sc *SelfTimeComponent) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.SelfTimeComponent.Layout").End()
+
+	if sc.byStack.Update(gtx) || sc.trace.filter.Key() != sc.globalKey {
+		sc.compute()
+	}
+
+	report, ok := sc.report.Result()
+	if !ok {
+		return theme.Loading(win.Theme, tr("Aggregating CPU samples…")).Layout(win, gtx)
+	}
+	if len(report.Entries) == 0 {
+		return layout.Center.Layout(gtx, theme.Dumb(win, theme.Label(win.Theme, tr("The trace contains no CPU samples.")).Layout))
+	}
+	if sc.entries == nil {
+		sc.entries = slices.Clone(report.Entries)
+	}
+
+	sc.initTable(win, gtx)
+	sc.table.Update(gtx)
+	if _, ok := sc.table.SortByClickedColumn(); ok {
+		sc.sort()
+	}
+	sc.cellFormatter.Update(win, gtx)
+
+	percent := func(d time.Duration) layout.Dimensions {
+		return layout.RightAligned(gtx, func(gtx layout.Context) layout.Dimensions {
+			return sc.cellFormatter.Text(win, gtx, local.Sprintf("%.2f%%", float64(d)/float64(report.Total)*100))
+		})
+	}
+	cellFn := func(win *theme.Window, gtx layout.Context, row, col int) layout.Dimensions {
+		defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+
+		e := &sc.entries[row]
+		switch colName := sc.table.Columns[col].Name; colName {
+		case "Function":
+			return sc.cellFormatter.Text(win, gtx, e.Function)
+		case "Called from":
+			return sc.cellFormatter.Text(win, gtx, strings.Join(e.Callers, " ← "))
+		case "Self":
+			return sc.cellFormatter.Duration(win, gtx, e.Self, false)
+		case "Self %":
+			return percent(e.Self)
+		case "Cumulative":
+			return sc.cellFormatter.Duration(win, gtx, e.Cumulative, false)
+		case "Cumulative %":
+			return percent(e.Cumulative)
+		default:
+			panic(colName)
+		}
+	}
+
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(theme.Dumb(win, theme.CheckBox(win.Theme, &sc.byStack, tr("Group by full stack")).Layout)),
+		layout.Rigid(layout.Spacer{Height: 5}.Layout),
+		layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+			return theme.SimpleTable(win, gtx, sc.table, &sc.scrollState, len(sc.entries), cellFn)
+		}),
+	)
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"image"
+	rtrace "runtime/trace"
+	"strconv"
+	"strings"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/widget"
+)
+
+const (
+	minCacheMemory = 16
+	maxCacheMemory = 64 * 1024
+)
+
+// livePreview applies a dialog's configuration whenever its inputs change, instead of waiting for the user to click
+// Apply.
+type livePreview struct {
+	// A summary of the inputs as of the last call to update.
+	inputs      string
+	initialized bool
+}
+
+// update calls apply if inputs differ from the ones passed to the previous call. The first call only records the
+// inputs, as they reflect the configuration already in effect.
+func (lp *livePreview) update(inputs string, apply func()) {
+	if !lp.initialized {
+		lp.initialized = true
+		lp.inputs = inputs
+		return
+	}
+	if inputs == lp.inputs {
+		return
+	}
+	lp.inputs = inputs
+	apply()
+}
+
+// GeneralSettings lets the user configure settings that don't have a dialog of their own.
+type GeneralSettings struct {
+	cacheMemory  widget.Editor
+	openInsights widget.Bool
+	highContrast widget.Bool
+	fidelity     *FidelityDialog
+
+	err string
+}
+
+func NewGeneralSettings() *GeneralSettings {
+	gs := &GeneralSettings{fidelity: NewFidelityDialog()}
+	gs.cacheMemory.SingleLine = true
+	gs.cacheMemory.SetText(strconv.FormatUint(currentCacheMemoryLimit()/1024/1024, 10))
+	userConfigMu.Lock()
+	gs.openInsights.Value = userConfig.OpenInsights
+	gs.highContrast.Value = userConfig.HighContrast
+	userConfigMu.Unlock()
+	return gs
+}
+
+func (gs *GeneralSettings) inputs() string {
+	return fmt.Sprintf("%q %t %t", gs.cacheMemory.Text(), gs.openInsights.Value, gs.highContrast.Value)
+}
+
+func (gs *GeneralSettings) save(win *theme.Window) {
+	mib, err := strconv.Atoi(strings.TrimSpace(gs.cacheMemory.Text()))
+	if err != nil || mib < minCacheMemory || mib > maxCacheMemory {
+		gs.err = fmt.Sprintf("cache memory must be a whole number between %d and %d", minCacheMemory, maxCacheMemory)
+		return
+	}
+	gs.err = ""
+
+	userConfigMu.Lock()
+	defer userConfigMu.Unlock()
+	// Don't store the default, so that changes to it take effect.
+	if mib == defaultCacheMemoryLimit/1024/1024 {
+		mib = 0
+	}
+	userConfig.CacheMemory = mib
+	userConfig.OpenInsights = gs.openInsights.Value
+	userConfig.HighContrast = gs.highContrast.Value
+	setHighContrast(win.Theme, gs.highContrast.Value)
+	cacheMemoryLimit.Store(uint64(mib) * 1024 * 1024)
+	if err := userConfig.Save(); err != nil {
+		win.Notify(theme.NotificationError, local.Sprintf("Couldn't save configuration: %s", err))
+	}
+}
+
+func (gs *GeneralSettings) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.GeneralSettings.Layout").End()
+
+	return layout.Rigids(gtx, layout.Vertical,
+		func(gtx layout.Context) layout.Dimensions {
+			return theme.LineLabel(win.Theme, fmt.Sprintf("Memory to spend on caching the contents of timelines, in MiB, between %d and %d", minCacheMemory, maxCacheMemory)).Layout(win, gtx)
+		},
+		func(gtx layout.Context) layout.Dimensions {
+			return theme.TextBox(win.Theme, &gs.cacheMemory, strconv.Itoa(defaultCacheMemoryLimit/1024/1024)).Layout(win, gtx)
+		},
+		func(gtx layout.Context) layout.Dimensions {
+			if gs.err == "" {
+				return layout.Dimensions{}
+			}
+			l := theme.Label(win.Theme, gs.err)
+			l.Color = colors[colorStateBlocked]
+			return l.Layout(win, gtx)
+		},
+		layout.Spacer{Height: 10}.Layout,
+		theme.Dumb(win, theme.CheckBox(win.Theme, &gs.openInsights, tr("Open insights after loading a trace")).Layout),
+		theme.Dumb(win, theme.CheckBox(win.Theme, &gs.highContrast, tr("High contrast: stronger colors, with patterns that tell span states apart")).Layout),
+		layout.Spacer{Height: 20}.Layout,
+		func(gtx layout.Context) layout.Dimensions {
+			return gs.fidelity.Layout(win, gtx)
+		},
+	)
+}
+
+// restorePalette applies the configured palette to the window.
+func (mwin *MainWindow) restorePalette() {
+	userConfigMu.Lock()
+	on := userConfig.HighContrast
+	userConfigMu.Unlock()
+	setHighContrast(mwin.twin.Theme, on)
+}
+
+// SettingsDialog combines all configurable settings in one window. Changes take effect as they are made and are
+// written to the configuration file.
+type SettingsDialog struct {
+	tabbedState theme.TabbedState
+
+	general     *GeneralSettings
+	fonts       *FontsDialog
+	timeFormat  *TimeFormatDialog
+	tooltips    *TooltipsDialog
+	navigation  *NavigationDialog
+	colorRules  *ColorRulesDialog
+	keybindings *KeybindingsDialog
+	locale      *LocaleDialog
+
+	generalPreview    livePreview
+	fontsPreview      livePreview
+	timeFormatPreview livePreview
+	tooltipsPreview   livePreview
+	navigationPreview livePreview
+	colorRulesPreview livePreview
+}
+
+func NewSettingsDialog() *SettingsDialog {
+	return &SettingsDialog{
+		general:     NewGeneralSettings(),
+		fonts:       NewFontsDialog(),
+		timeFormat:  NewTimeFormatDialog(),
+		tooltips:    NewTooltipsDialog(),
+		navigation:  NewNavigationDialog(),
+		colorRules:  NewColorRulesDialog(),
+		keybindings: NewKeybindingsDialog(),
+		locale:      NewLocaleDialog(),
+	}
+}
+
+// preview applies the settings whose inputs have changed since the last frame.
+func (sd *SettingsDialog) preview(win *theme.Window) {
+	sd.generalPreview.update(sd.general.inputs(), func() {
+		sd.general.save(win)
+	})
+
+	fd := sd.fonts
+	// Computing the font configuration loads font files, so only do it when the inputs have changed.
+	sd.fontsPreview.update(fmt.Sprintf("%q %q %q %q %q", fd.typeface.Text(), fd.monospaceTypeface.Text(), fd.textSize.Text(), fd.files.Text(), fd.fallbacks.Text()), func() {
+		if cfg, err := fd.config(); err != nil {
+			fd.err = err.Error()
+		} else {
+			fd.save(win, cfg)
+		}
+	})
+
+	td := sd.timeFormat
+	sd.timeFormatPreview.update(fmt.Sprintf("%t %t %q", td.exactDurations.Value, td.absoluteTimestamps.Value, td.decimals.Text()), func() {
+		if cfg, err := td.config(); err != nil {
+			td.err = err.Error()
+		} else {
+			td.save(win, cfg)
+		}
+	})
+
+	ttd := sd.tooltips
+	sd.tooltipsPreview.update(fmt.Sprintf("%q %q", ttd.span.Text(), ttd.goroutine.Text()), func() {
+		if cfg, err := ttd.config(); err != nil {
+			ttd.err = err.Error()
+		} else {
+			ttd.save(win, cfg)
+		}
+	})
+
+	nd := sd.navigation
+	sd.navigationPreview.update(fmt.Sprintf("%t %t %q", nd.zoomAroundCenter.Value, nd.kineticPanning.Value, nd.zoomSpeed.Text()), func() {
+		if cfg, err := nd.config(); err != nil {
+			nd.err = err.Error()
+		} else {
+			nd.save(win, cfg)
+		}
+	})
+
+	cd := sd.colorRules
+	rules := make([]ColorRule, 0, len(cd.rows))
+	for _, row := range cd.rows {
+		rules = append(rules, row.rule())
+	}
+	sd.colorRulesPreview.update(fmt.Sprintf("%#v", rules), func() {
+		cd.save(win, rules)
+	})
+}
+
+func (sd *SettingsDialog) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.SettingsDialog.Layout").End()
+
+	sd.preview(win)
+
+	tabs := []string{
+		tr("General"),
+		tr("Fonts"),
+		tr("Time format"),
+		tr("Tooltips"),
+		tr("Navigation"),
+		tr("Span colors"),
+		tr("Keyboard shortcuts"),
+		tr("Language"),
+	}
+	return theme.Tabbed(&sd.tabbedState, tabs).Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+		gtx.Constraints.Min = gtx.Constraints.Max
+		return layout.UniformInset(5).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			switch sd.tabbedState.Current {
+			case 0:
+				return sd.general.Layout(win, gtx)
+			case 1:
+				return sd.fonts.Layout(win, gtx)
+			case 2:
+				return sd.timeFormat.Layout(win, gtx)
+			case 3:
+				return sd.tooltips.Layout(win, gtx)
+			case 4:
+				return sd.navigation.Layout(win, gtx)
+			case 5:
+				return sd.colorRules.Layout(win, gtx)
+			case 6:
+				return sd.keybindings.Layout(win, gtx)
+			case 7:
+				return sd.locale.Layout(win, gtx)
+			default:
+				panic("unreachable")
+			}
+		})
+	})
+}
+
+func displaySettingsDialog(win *theme.Window) {
+	sd := NewSettingsDialog()
+	win.SetModal(func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+		return theme.Dialog(win.Theme, tr("Settings")).Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+			gtx.Constraints.Min = gtx.Constraints.Constrain(image.Pt(1000, 600))
+			gtx.Constraints.Max = gtx.Constraints.Min
+			return sd.Layout(win, gtx)
+		})
+	})
+}
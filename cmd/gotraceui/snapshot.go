@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"os/exec"
+	"runtime"
+	rtrace "runtime/trace"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+
+	"gioui.org/gpu/headless"
+	"gioui.org/io/event"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+)
+
+// noEvents is an event queue without events. Widgets treat a nil queue as a request to draw themselves as disabled,
+// which we don't want for snapshots.
+type noEvents struct{}
+
+func (noEvents) Events(event.Tag) []event.Event { return nil }
+
+// layoutSnapshot lays out c, at the given size, into a new list of operations. The component doesn't receive any input
+// while doing so. It must be called from the window's goroutine.
+func layoutSnapshot(win *theme.Window, gtx layout.Context, c theme.Component, size image.Point) *op.Ops {
+	defer rtrace.StartRegion(context.Background(), "main.layoutSnapshot").End()
+
+	ops := new(op.Ops)
+	sgtx := layout.Context{
+		Ops:         ops,
+		Metric:      gtx.Metric,
+		Now:         gtx.Now,
+		Locale:      gtx.Locale,
+		Queue:       noEvents{},
+		Constraints: layout.Exact(size),
+	}
+	theme.FillShape(win, ops, win.Theme.Palette.Background, clip.Rect{Max: size}.Op())
+	c.Layout(win, sgtx)
+	return ops
+}
+
+// renderSnapshot renders ops offscreen. It uses its own GPU context and can be called from any goroutine.
+func renderSnapshot(ops *op.Ops, size image.Point) (*image.RGBA, error) {
+	hw, err := headless.NewWindow(size.X, size.Y)
+	if err != nil {
+		return nil, err
+	}
+	defer hw.Release()
+	if err := hw.Frame(ops); err != nil {
+		return nil, err
+	}
+	img := image.NewRGBA(image.Rectangle{Max: size})
+	if err := hw.Screenshot(img); err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// clipboardImageCommand returns the command that puts the PNG file at path on the clipboard.
+func clipboardImageCommand(path string) (*exec.Cmd, error) {
+	switch runtime.GOOS {
+	case "linux", "freebsd", "openbsd", "netbsd":
+		if os.Getenv("WAYLAND_DISPLAY") != "" {
+			if _, err := exec.LookPath("wl-copy"); err == nil {
+				return exec.Command("sh", "-c", `exec wl-copy --type image/png < "$1"`, "sh", path), nil
+			}
+		}
+		if _, err := exec.LookPath("xclip"); err == nil {
+			return exec.Command("xclip", "-selection", "clipboard", "-t", "image/png", "-i", path), nil
+		}
+		return nil, errors.New("neither wl-copy nor xclip is installed")
+	case "darwin":
+		script := fmt.Sprintf(`set the clipboard to (read (POSIX file %q) as «class PNGf»)`, path)
+		return exec.Command("osascript", "-e", script), nil
+	case "windows":
+		script := fmt.Sprintf(`Add-Type -AssemblyName System.Windows.Forms; Add-Type -AssemblyName System.Drawing; [Windows.Forms.Clipboard]::SetImage([Drawing.Image]::FromFile('%s'))`, strings.ReplaceAll(path, "'", "''"))
+		return exec.Command("powershell", "-NoProfile", "-STA", "-Command", script), nil
+	default:
+		return nil, fmt.Errorf("copying images isn't supported on %s", runtime.GOOS)
+	}
+}
+
+// The stages of copying a component as an image.
+var copyImageStages = []string{"Rendering", "Encoding", "Copying to clipboard"}
+
+// copyImage puts img on the clipboard as a PNG. Gio's clipboard only supports text, so we rely on the platform's
+// tools. If that fails, the image is kept in a temporary file, whose path is returned along with the error. It returns
+// the operation's context's error if o gets cancelled before the image has been copied.
+func copyImage(o *theme.Operation, img image.Image) (string, error) {
+	o.SetProgressStage(1)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return "", err
+	}
+	if err := o.Context().Err(); err != nil {
+		return "", err
+	}
+	o.SetProgressStage(2)
+	f, err := os.CreateTemp("", "gotraceui-*.png")
+	if err != nil {
+		return "", err
+	}
+	path := f.Name()
+	_, err = f.Write(buf.Bytes())
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+		return "", err
+	}
+
+	cmd, err := clipboardImageCommand(path)
+	if err != nil {
+		return path, err
+	}
+	// Don't collect the tools' output: xclip and wl-copy fork to serve the clipboard, and the forked processes would
+	// keep the pipes open.
+	if err := cmd.Run(); err != nil {
+		return path, err
+	}
+	os.Remove(path)
+	return "", nil
+}
+
+// backgroundOperation is an operation that runs in the background of a main window. Its progress is displayed until
+// it is done.
+type backgroundOperation struct {
+	title    string
+	op       *theme.Operation
+	progress theme.OperationProgressState
+	done     atomic.Bool
+}
+
+// startBackgroundOperation runs fn in a new goroutine and displays its progress until it returns.
+func (mwin *MainWindow) startBackgroundOperation(win *theme.Window, title string, stages []string, fn func(o *theme.Operation)) {
+	bo := &backgroundOperation{
+		title: title,
+		op:    theme.NewOperation(context.Background(), stages...),
+	}
+	mwin.background = append(mwin.background, bo)
+	go func() {
+		defer win.AppWindow.Invalidate()
+		defer bo.done.Store(true)
+		fn(bo.op)
+	}()
+}
+
+// layoutBackgroundOperations displays the progress of the background operations in the bottom right corner of the
+// window.
+func (mwin *MainWindow) layoutBackgroundOperations(win *theme.Window, gtx layout.Context) {
+	mwin.background = slices.DeleteFunc(mwin.background, func(bo *backgroundOperation) bool {
+		return bo.done.Load()
+	})
+	if len(mwin.background) == 0 {
+		return
+	}
+
+	gtx.Constraints.Min = gtx.Constraints.Max
+	layout.SE.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		gtx.Constraints.Min = image.Point{}
+		children := make([]layout.Widget, 0, len(mwin.background))
+		for _, bo := range mwin.background {
+			children = append(children, func(gtx layout.Context) layout.Dimensions {
+				return layout.UniformInset(5).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+					return theme.Dialog(win.Theme, bo.title).Layout(win, gtx, theme.OperationProgress(bo.op, &bo.progress).Layout)
+				})
+			})
+		}
+		return layout.Rigids(gtx, layout.Vertical, children...)
+	})
+}
+
+// copyComponentImage renders a displayed component and puts the image on the clipboard. This runs as a background
+// operation, which the user can cancel.
+func (mwin *MainWindow) copyComponentImage(win *theme.Window, gtx layout.Context, c theme.Component, size image.Point) {
+	if size.X <= 0 || size.Y <= 0 {
+		return
+	}
+	ops := layoutSnapshot(win, gtx, c, size)
+	title := c.Title()
+	mwin.startBackgroundOperation(win, local.Sprintf("Copying %s as an image", title), copyImageStages, func(o *theme.Operation) {
+		img, err := renderSnapshot(ops, size)
+		if err != nil {
+			win.Notify(theme.NotificationError, local.Sprintf("Couldn't render %s: %s", title, err))
+			return
+		}
+		if o.Cancelled() {
+			return
+		}
+		path, err := copyImage(o, img)
+		switch {
+		case o.Cancelled():
+			if path != "" {
+				os.Remove(path)
+			}
+		case err == nil:
+			win.Notify(theme.NotificationInfo, local.Sprintf("Copied %s to clipboard as an image", title))
+		case path != "":
+			win.Notify(theme.NotificationError, local.Sprintf("Couldn't copy image to clipboard (%s). Saved it as %s instead.", err, path))
+		default:
+			win.Notify(theme.NotificationError, local.Sprintf("Couldn't copy image to clipboard: %s", err))
+		}
+	})
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"go/build"
+	"image"
+	"os"
+	"path/filepath"
+	"runtime"
+	rtrace "runtime/trace"
+	"strings"
+
+	"honnef.co/go/gotraceui/clip"
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/op"
+)
+
+// sourceRoots is a list of directories, separated by os.PathListSeparator, that are searched for source files that
+// cannot be found at the location recorded in the trace.
+var sourceRoots string
+
+// sourceContextLines is the number of lines to show above the highlighted line when first opening a source view.
+const sourceContextLines = 10
+
+// resolveSourcePath tries to find the file on the local file system. Paths in traces refer to the machine that built
+// the traced executable, and might have been trimmed. We try the path as-is, then reinterpret it relative to the local
+// GOROOT and GOPATH, and finally look in the configured source roots.
+func resolveSourcePath(tr *Trace, file string) (string, bool) {
+	if file == "" {
+		return "", false
+	}
+
+	exists := func(path string) bool {
+		fi, err := os.Stat(path)
+		return err == nil && fi.Mode().IsRegular()
+	}
+
+	var candidates []string
+	if filepath.IsAbs(file) {
+		candidates = append(candidates, file)
+	}
+
+	goroot := runtime.GOROOT()
+	gopath := filepath.SplitList(build.Default.GOPATH)
+	if tr != nil && tr.GOROOT != "" && strings.HasPrefix(file, tr.GOROOT) {
+		candidates = append(candidates, filepath.Join(goroot, strings.TrimPrefix(file, tr.GOROOT)))
+	} else if tr != nil && tr.GOPATH != "" && strings.HasPrefix(file, tr.GOPATH) {
+		for _, p := range gopath {
+			candidates = append(candidates, filepath.Join(p, strings.TrimPrefix(file, tr.GOPATH)))
+		}
+	} else if !filepath.IsAbs(file) {
+		// The path has been trimmed. See FunctionInfo.buildDescription for the heuristic.
+		if left, _, ok := strings.Cut(file, "/"); ok && strings.Contains(left, ".") {
+			for _, p := range gopath {
+				if strings.Contains(file, "@v") {
+					candidates = append(candidates, filepath.Join(p, "pkg", "mod", file))
+				} else {
+					candidates = append(candidates, filepath.Join(p, "src", file))
+				}
+			}
+		} else {
+			candidates = append(candidates, filepath.Join(goroot, "src", file))
+		}
+	}
+
+	for _, root := range filepath.SplitList(sourceRoots) {
+		if root == "" {
+			continue
+		}
+		// Try successively shorter suffixes of the path, so that a root can point at a checkout of a repository
+		// without knowing where it was located on the build machine. We require at least two path elements to avoid
+		// matching unrelated files that share a name.
+		elems := strings.Split(filepath.ToSlash(file), "/")
+		for i := range elems {
+			if len(elems)-i < 2 {
+				break
+			}
+			candidates = append(candidates, filepath.Join(root, filepath.Join(elems[i:]...)))
+		}
+	}
+
+	for _, c := range candidates {
+		if exists(c) {
+			return c, true
+		}
+	}
+	return "", false
+}
+
+type sourceFile struct {
+	path  string
+	lines []string
+	err   error
+}
+
+func loadSourceFile(tr *Trace, file string) sourceFile {
+	path, ok := resolveSourcePath(tr, file)
+	if !ok {
+		return sourceFile{err: fmt.Errorf("couldn't find %s, consider using the -source-roots flag", file)}
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return sourceFile{err: err}
+	}
+	defer f.Close()
+
+	var lines []string
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, 1024*1024)
+	for sc.Scan() {
+		lines = append(lines, strings.ReplaceAll(sc.Text(), "\t", "    "))
+	}
+	if err := sc.Err(); err != nil {
+		return sourceFile{err: err}
+	}
+	return sourceFile{path: path, lines: lines}
+}
+
+// SourceView is a panel that displays the source code around a source location.
+type SourceView struct {
+	mwin  *theme.Window
+	trace *Trace
+	file  string
+	line  uint64
+
+	source *theme.Future[sourceFile]
+	list   widget.List
+
+	openInEditor widget.PrimaryClickable
+
+	initialized bool
+	scrolled    bool
+
+	theme.ComponentButtons
+}
+
+func NewSourceView(tr *Trace, mwin *theme.Window, file string, line uint64) *SourceView {
+	return &SourceView{
+		mwin:  mwin,
+		trace: tr,
+		file:  file,
+		line:  line,
+	}
+}
+
+func (sv *SourceView) Title() string {
+	return fmt.Sprintf("%s:%d", filepath.Base(sv.file), sv.line)
+}
+
+func (sv *SourceView) HoveredLink() ObjectLink {
+	return nil
+}
+
+func (sv *SourceView) init(win *theme.Window) {
+	sv.list.Axis = layout.Vertical
+	sv.source = theme.NewFuture(win, func(cancelled <-chan struct{}) sourceFile {
+		return loadSourceFile(sv.trace, sv.file)
+	})
+}
+
+func (sv *SourceView) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.SourceView.Layout").End()
+
+	if !sv.initialized {
+		sv.init(win)
+		sv.initialized = true
+	}
+
+	for sv.ComponentButtons.Backed(gtx) {
+		sv.mwin.EmitAction(&PrevPanelAction{})
+	}
+	for sv.openInEditor.Clicked(gtx) {
+		sv.mwin.EmitAction(&OpenInEditorAction{File: sv.file, Line: sv.line})
+	}
+
+	// Inset of 5 pixels on all sides. We can't use layout.Inset because it doesn't decrease the minimum constraint,
+	// which we do care about here.
+	gtx.Constraints.Min = gtx.Constraints.Min.Sub(image.Pt(2*5, 2*5))
+	gtx.Constraints.Max = gtx.Constraints.Max.Sub(image.Pt(2*5, 2*5))
+	gtx.Constraints = layout.Normalize(gtx.Constraints)
+	defer op.Offset(image.Pt(5, 5)).Push(gtx.Ops).Pop()
+
+	src, haveSource := sv.source.Result()
+
+	return layout.Rigids(gtx, layout.Vertical,
+		func(gtx layout.Context) layout.Dimensions {
+			// Right-aligned buttons should be aligned with the right side of the visible panel.
+			gtx.Constraints.Max.X = gtx.Constraints.Min.X
+			return layout.Flex{Axis: layout.Horizontal}.Layout(gtx,
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return theme.Button(win.Theme, &sv.openInEditor.Clickable, tr("Open in editor")).Layout(win, gtx)
+				}),
+				layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+					return layout.Dimensions{Size: gtx.Constraints.Min}
+				}),
+				layout.Rigid(theme.Dumb(win, sv.ComponentButtons.Layout)),
+			)
+		},
+
+		layout.Spacer{Height: 10}.Layout,
+
+		func(gtx layout.Context) layout.Dimensions {
+			path := sv.file
+			if haveSource && src.path != "" {
+				path = src.path
+			}
+			return theme.Label(win.Theme, fmt.Sprintf("%s:%d", path, sv.line)).Layout(win, gtx)
+		},
+
+		layout.Spacer{Height: 5}.Layout,
+
+		func(gtx layout.Context) layout.Dimensions {
+			if !haveSource {
+				return theme.Loading(win.Theme, tr("Loading source…")).Layout(win, gtx)
+			}
+			if src.err != nil {
+				return theme.Label(win.Theme, local.Sprintf("Couldn't load source: %s", src.err)).Layout(win, gtx)
+			}
+
+			if !sv.scrolled {
+				sv.list.Position.First = max(int(sv.line)-1-sourceContextLines, 0)
+				sv.scrolled = true
+			}
+
+			gtx.Constraints.Min = gtx.Constraints.Max
+			numWidth := len(fmt.Sprint(len(src.lines)))
+			return theme.List(win.Theme, &sv.list).Layout(win, gtx, len(src.lines), func(gtx layout.Context, index int) layout.Dimensions {
+				gtx.Constraints.Min.Y = 0
+				lbl := theme.Label(win.Theme, fmt.Sprintf("%*d  %s", numWidth, index+1, src.lines[index]))
+				lbl.MaxLines = 1
+				if index+1 == int(sv.line) {
+					rec := theme.Record(win, gtx, lbl.Layout)
+					theme.FillShape(win, gtx.Ops, win.Theme.Palette.PrimarySelection, clip.Rect{Max: image.Pt(gtx.Constraints.Max.X, rec.Dimensions.Size.Y)}.Op())
+					return rec.Layout(win, gtx)
+				}
+				return lbl.Layout(win, gtx)
+			})
+		},
+	)
+}
@@ -14,7 +14,6 @@ import (
 	"honnef.co/go/gotraceui/trace/ptrace"
 	"honnef.co/go/gotraceui/widget"
 
-	"gioui.org/font"
 	"gioui.org/op"
 	"gioui.org/text"
 	exptrace "golang.org/x/exp/trace"
@@ -73,21 +72,36 @@ type SpansInfo struct {
 		scrollAndPanToSpans widget.PrimaryClickable
 		zoomToSpans         widget.PrimaryClickable
 		copyAsCSV           widget.PrimaryClickable
+		retryStatistics     widget.PrimaryClickable
 		selectUserRegion    widget.PrimaryClickable
+		copyStacktrace      widget.PrimaryClickable
+		openStacktrace      widget.PrimaryClickable
+		copyAsText          widget.PrimaryClickable
+		copyAsJSON          widget.PrimaryClickable
 	}
 
-	tabbedState     theme.TabbedState
-	stackSelectable widget.Selectable
+	tabbedState theme.TabbedState
 
 	descriptionBuilder func(win *theme.Window, gtx layout.Context) Description
 	descriptionText    Text
+	// Whether some of the description's attributes are still being computed.
+	descriptionPending bool
 	hoveredLink        ObjectLink
 	prevSpans          []TextSpan
 
-	stacktraceList widget.List
+	stacktraceList      widget.List
+	stacktraceText      Text
+	prevStacktraceSpans []TextSpan
 
-	statistics *theme.Future[*SpansStats]
-	hist       InteractiveHistogram
+	statistics         *theme.Future[ptrace.Statistics]
+	statisticsOp       *theme.Operation
+	statisticsProgress theme.OperationProgressState
+	// stats is created from the computed statistics and holds the table's state.
+	stats *SpansStats
+	hist  InteractiveHistogram
+	// The range of durations selected in the histogram, and the spans in it. brushed is nil if no range is selected.
+	brushedRange [2]widget.FloatDuration
+	brushed      *SpanList
 
 	duration *theme.Future[time.Duration]
 	state    *theme.Future[string]
@@ -101,10 +115,20 @@ type SpansInfoConfig struct {
 	Title              string
 	Label              string
 	DescriptionBuilder func(win *theme.Window, gtx layout.Context) Description
-	Stacktrace         string
-	Statistics         func(win *theme.Window) *theme.Future[*SpansStats]
+	Stack              exptrace.Stack
+	Statistics         func(win *theme.Window) (*theme.Future[ptrace.Statistics], *theme.Operation)
 	Navigations        SpansInfoConfigNavigations
 	ShowHistogram      bool
+	// Tabs are displayed in addition to the default tabs.
+	Tabs []SpansInfoTab
+}
+
+// A SpansInfoTab is an additional tab of a SpansInfo.
+type SpansInfoTab struct {
+	Name   string
+	Layout theme.Widget
+	// HoveredLink, if set, returns the link that has been hovered during the last call to Layout.
+	HoveredLink func() ObjectLink
 }
 
 type SpansInfoConfigNavigations struct {
@@ -144,27 +168,13 @@ func (si *SpansInfo) init(win *theme.Window) {
 		}
 	}
 
-	if si.cfg.Stacktrace == "" && haveContainer && spans.Len() == 1 {
-		ev := si.trace.Event(spans.AtPtr(0).StartEvent)
-		stk := ev.Stack()
-		sb := strings.Builder{}
-		stk.Frames(func(frame exptrace.StackFrame) bool {
-			fmt.Fprintf(&sb, "%s\n        %s:%d\n", frame.Func, frame.File, frame.Line)
-			return true
-		})
-		stacktrace := sb.String()
-		if len(stacktrace) > 0 && stacktrace[len(stacktrace)-1] == '\n' {
-			stacktrace = stacktrace[:len(stacktrace)-1]
-		}
-
-		si.cfg.Stacktrace = stacktrace
+	if si.cfg.Stack == exptrace.NoStack && haveContainer && spans.Len() == 1 && c.Track.kind != TrackKindExternal {
+		si.cfg.Stack = si.trace.Event(spans.AtPtr(0).StartEvent).Stack()
 	}
 
 	if si.cfg.Statistics == nil {
-		si.cfg.Statistics = func(win *theme.Window) *theme.Future[*SpansStats] {
-			return theme.NewFuture(win, func(cancelled <-chan struct{}) *SpansStats {
-				return NewSpansStats(spans)
-			})
+		si.cfg.Statistics = func(win *theme.Window) (*theme.Future[ptrace.Statistics], *theme.Operation) {
+			return ComputeSpansStats(win, spans)
 		}
 	}
 
@@ -179,7 +189,7 @@ func (si *SpansInfo) init(win *theme.Window) {
 	si.eventList.Filter.ShowUserLog.Value = true
 	si.eventList.Filter.ShowTasks.Value = true
 	si.eventList.Events = Events(spans, si.trace)
-	si.eventList.UpdateFilter()
+	si.eventList.UpdateFilter(win)
 
 	if si.cfg.DescriptionBuilder != nil {
 		si.descriptionBuilder = si.cfg.DescriptionBuilder
@@ -187,7 +197,7 @@ func (si *SpansInfo) init(win *theme.Window) {
 		si.descriptionBuilder = si.buildDefaultDescription
 	}
 
-	si.statistics = si.cfg.Statistics(win)
+	si.statistics, si.statisticsOp = si.cfg.Statistics(win)
 	if si.cfg.ShowHistogram {
 		// XXX computeHistogram looks at all spans before starting a future; that part should probably be concurrent, too.
 		histCfg := &widget.HistogramConfig{RejectOutliers: true, Bins: widget.DefaultHistogramBins}
@@ -224,21 +234,25 @@ func (si *SpansInfo) computeHistogram(win *theme.Window, cfg *widget.HistogramCo
 	si.hist.Set(win, spanDurations)
 }
 
-func (si *SpansInfo) Title() string {
-	return si.cfg.Title
+// brush highlights the spans whose durations are in [start, end] on the canvas and lists them below the histogram. An
+// end of zero clears the highlight.
+func (si *SpansInfo) brush(start, end widget.FloatDuration) {
+	if end == 0 {
+		si.brushed = nil
+		si.mwin.EmitAction(&BrushSpansAction{})
+		return
+	}
+	lo, hi := start.Floor(), end.Ceil()
+	matched := FilterItems(si.spans.MustResult(), func(s *ptrace.Span) bool {
+		d := s.Duration()
+		return d >= lo && d <= hi
+	})
+	si.brushed = &SpanList{Spans: NewSortedItems(matched)}
+	si.mwin.EmitAction(&BrushSpansAction{Brush: brushSpans(matched, lo, hi)})
 }
 
-func textSpinner(now time.Time) string {
-	switch (now.UnixMilli() / 500) % 3 {
-	case 0:
-		return "."
-	case 1:
-		return ".."
-	case 2:
-		return "..."
-	default:
-		panic("unreachable")
-	}
+func (si *SpansInfo) Title() string {
+	return si.cfg.Title
 }
 
 func (si *SpansInfo) buildDefaultDescription(win *theme.Window, gtx layout.Context) Description {
@@ -266,23 +280,24 @@ func (si *SpansInfo) buildDefaultDescription(win *theme.Window, gtx layout.Conte
 		Value: link,
 	})
 
+	// Attributes that are still being computed show a placeholder, so that the description doesn't change its shape
+	// once they're done, and a spinner is displayed next to the description.
 	a := DescriptionAttribute{
 		Key: "Duration",
 	}
 	if v, ok := si.duration.Result(); ok {
 		a.Value = *tb.Span(v.String())
 	} else {
-		a.Value = *tb.Span(textSpinner(gtx.Now))
-		op.InvalidateOp{}.Add(gtx.Ops)
+		a.Value = *tb.Span("…")
+		si.descriptionPending = true
 	}
 	attrs = append(attrs, a)
 
 	if spans.Len() > 1 && !spans.Contiguous() {
-		a = DescriptionAttribute{
+		attrs = append(attrs, DescriptionAttribute{
 			Key:   "Time span",
 			Value: *tb.Span(SpansTimeSpan(spans).Duration().String()),
-		}
-		attrs = append(attrs, a)
+		})
 	}
 
 	a = DescriptionAttribute{
@@ -291,8 +306,8 @@ func (si *SpansInfo) buildDefaultDescription(win *theme.Window, gtx layout.Conte
 	if v, ok := si.state.Result(); ok {
 		a.Value = *tb.Span(v)
 	} else {
-		a.Value = *tb.Span(textSpinner(gtx.Now))
-		op.InvalidateOp{}.Add(gtx.Ops)
+		a.Value = *tb.Span("…")
+		si.descriptionPending = true
 	}
 	attrs = append(attrs, a)
 
@@ -336,6 +351,19 @@ func (si *SpansInfo) zoomToSpans(win *theme.Window) {
 	}
 }
 
+// record describes the spans, including all attributes displayed by the panel.
+func (si *SpansInfo) record(win *theme.Window, gtx layout.Context) *Record {
+	r := spansRecord(si.trace, si.spans.MustResult())
+	r.Title = si.cfg.Title
+	r.Stack = si.cfg.Stack
+	for _, attr := range si.descriptionBuilder(win, gtx).Attributes {
+		if !r.has(attr.Key) {
+			r.Add(attr.Key, attr.Value.Content)
+		}
+	}
+	return r
+}
+
 func (si *SpansInfo) HoveredLink() ObjectLink {
 	return si.hoveredLink
 }
@@ -351,17 +379,35 @@ func (si *SpansInfo) Layout(win *theme.Window, gtx layout.Context) layout.Dimens
 	spans, haveSpans := si.spans.Result()
 
 	for si.buttons.copyAsCSV.Clicked(gtx) {
-		if stats, ok := si.statistics.Result(); ok {
-			win.AppWindow.WriteClipboard(statisticsToCSV(stats.stats.Items))
+		if si.stats != nil {
+			win.AppWindow.WriteClipboard(statisticsToCSV(si.stats.stats.Items))
+		}
+	}
+	for si.buttons.retryStatistics.Clicked(gtx) {
+		si.statistics, si.statisticsOp = si.cfg.Statistics(win)
+	}
+	if si.initialized && si.stats == nil && !si.statisticsOp.Cancelled() {
+		if stats, ok := si.statistics.Result(); ok && !si.statisticsOp.Cancelled() {
+			si.stats = NewStats(stats)
 		}
 	}
 
 	for _, ev := range si.descriptionText.Update(gtx, si.prevSpans) {
 		handleLinkClick(win, ev.Event, ev.Span.ObjectLink)
 	}
-	for _, ev := range si.eventList.Update(gtx) {
+	for _, ev := range si.eventList.Update(win, gtx) {
+		handleLinkClick(win, ev.Event, ev.Span.ObjectLink)
+	}
+	for _, ev := range si.stacktraceText.Update(gtx, si.prevStacktraceSpans) {
 		handleLinkClick(win, ev.Event, ev.Span.ObjectLink)
 	}
+	for si.buttons.copyStacktrace.Clicked(gtx) {
+		win.AppWindow.WriteClipboard(formatStack(si.trace, si.cfg.Stack, 0))
+		win.ShowNotification(gtx, tr("Copied stack trace to clipboard"))
+	}
+	for si.buttons.openStacktrace.Clicked(gtx) {
+		win.EmitAction(&OpenStackAction{Stack: si.cfg.Stack, Title: local.Sprintf("Stack trace of %s", si.cfg.Title)})
+	}
 
 	firstNonNil := func(els ...ObjectLink) ObjectLink {
 		for _, el := range els {
@@ -375,7 +421,19 @@ func (si *SpansInfo) Layout(win *theme.Window, gtx layout.Context) layout.Dimens
 		si.descriptionText.HoveredLink(),
 		si.eventList.HoveredLink(),
 		si.spanList.HoveredLink(),
+		si.stacktraceText.HoveredLink(),
 	)
+	if si.hoveredLink == nil && si.brushed != nil {
+		si.hoveredLink = si.brushed.HoveredLink()
+	}
+	for _, tab := range si.cfg.Tabs {
+		if si.hoveredLink != nil {
+			break
+		}
+		if tab.HoveredLink != nil {
+			si.hoveredLink = tab.HoveredLink()
+		}
+	}
 
 	for si.buttons.scrollAndPanToSpans.Clicked(gtx) {
 		si.scrollAndPanToSpans(win)
@@ -383,6 +441,12 @@ func (si *SpansInfo) Layout(win *theme.Window, gtx layout.Context) layout.Dimens
 	for si.buttons.zoomToSpans.Clicked(gtx) {
 		si.zoomToSpans(win)
 	}
+	for si.buttons.copyAsText.Clicked(gtx) {
+		win.EmitAction(&CopyObjectAction{Object: si.record(win, gtx), Format: CopyAsText})
+	}
+	for si.buttons.copyAsJSON.Clicked(gtx) {
+		win.EmitAction(&CopyObjectAction{Object: si.record(win, gtx), Format: CopyAsJSON})
+	}
 	for si.ComponentButtons.Backed(gtx) {
 		si.mwin.EmitAction(&PrevPanelAction{})
 	}
@@ -417,8 +481,8 @@ func (si *SpansInfo) Layout(win *theme.Window, gtx layout.Context) layout.Dimens
 			})
 		})
 		cfg := SpansInfoConfig{
-			Title:         fmt.Sprintf("All %q user regions", needle),
-			Label:         fmt.Sprintf("All %q user regions", needle),
+			Title:         local.Sprintf("All %q user regions", needle),
+			Label:         local.Sprintf("All %q user regions", needle),
 			ShowHistogram: true,
 		}
 		si.mwin.EmitAction(&OpenPanelAction{NewSpansInfo(cfg, si.trace, si.mwin, ft, si.allTimelines)})
@@ -427,6 +491,12 @@ func (si *SpansInfo) Layout(win *theme.Window, gtx layout.Context) layout.Dimens
 	if si.hist.Update(gtx) {
 		si.computeHistogram(win, &si.hist.Config)
 	}
+	// The histogram's range may also change while laying it out, so compare with the brushed range instead of relying
+	// on Update's result.
+	if r := [2]widget.FloatDuration{si.hist.Config.Start, si.hist.Config.End}; r != si.brushedRange {
+		si.brushedRange = r
+		si.brush(r[0], r[1])
+	}
 
 	if gtx.Constraints.Max.X <= 5 || gtx.Constraints.Max.Y <= 5 {
 		return layout.Dimensions{Size: gtx.Constraints.Min}
@@ -471,6 +541,10 @@ func (si *SpansInfo) Layout(win *theme.Window, gtx layout.Context) layout.Dimens
 						buttonsLeft[1].label = si.cfg.Navigations.Zoom.ButtonLabel
 					}
 				}
+				buttonsLeft = append(buttonsLeft,
+					button{&si.buttons.copyAsText.Clickable, "Copy as text"},
+					button{&si.buttons.copyAsJSON.Clickable, "Copy as JSON"},
+				)
 
 				children := make([]layout.FlexChild, 0, len(buttonsLeft)+2)
 				for _, btn := range buttonsLeft {
@@ -495,15 +569,28 @@ func (si *SpansInfo) Layout(win *theme.Window, gtx layout.Context) layout.Dimens
 			func(gtx layout.Context) layout.Dimensions {
 				gtx.Constraints.Min = image.Point{}
 				si.descriptionText.Reset(win.Theme)
+				si.descriptionPending = false
 				desc := si.descriptionBuilder(win, gtx)
-				dims, spans := desc.Layout(win, gtx, &si.descriptionText)
-				si.prevSpans = spans
-				return dims
+				return layout.Rigids(gtx, layout.Horizontal,
+					func(gtx layout.Context) layout.Dimensions {
+						dims, spans := desc.Layout(win, gtx, &si.descriptionText)
+						si.prevSpans = spans
+						return dims
+					},
+					layout.Spacer{Width: 10}.Layout,
+					func(gtx layout.Context) layout.Dimensions {
+						if !si.descriptionPending {
+							return layout.Dimensions{}
+						}
+						// The spinner sits next to the description so that it doesn't take up a row of its own.
+						return theme.Dumb(win, theme.Spinner(win.Theme).Layout)(gtx)
+					},
+				)
 			},
 			func(gtx layout.Context) layout.Dimensions {
 				if spans.Len() == 1 && spans.AtPtr(0).State == ptrace.StateUserRegion {
 					gtx.Constraints.Min = image.Point{}
-					return theme.Button(win.Theme, &si.buttons.selectUserRegion.Clickable, "Select user region").Layout(win, gtx)
+					return theme.Button(win.Theme, &si.buttons.selectUserRegion.Clickable, tr("Select user region")).Layout(win, gtx)
 				}
 				return layout.Dimensions{}
 			},
@@ -514,53 +601,79 @@ func (si *SpansInfo) Layout(win *theme.Window, gtx layout.Context) layout.Dimens
 				if si.eventList.Events.Len() != 0 {
 					tabs = append(tabs, "Events")
 				}
-				if si.cfg.Stacktrace != "" {
+				if len(si.trace.Stacks[si.trace.StackID(si.cfg.Stack)]) != 0 {
 					tabs = append(tabs, "Stack trace")
 				}
 				if si.cfg.ShowHistogram {
 					tabs = append(tabs, "Histogram")
 				}
+				for _, tab := range si.cfg.Tabs {
+					tabs = append(tabs, tab.Name)
+				}
 				return theme.Tabbed(&si.tabbedState, tabs).Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
 					gtx.Constraints.Min = gtx.Constraints.Max
 					switch tabs[si.tabbedState.Current] {
 					case "Stack trace":
-						return theme.List(win.Theme, &si.stacktraceList).Layout(
-							win,
-							gtx,
-							1,
-							func(gtx layout.Context, index int) layout.Dimensions {
-								if index != 0 {
-									panic("impossible")
-								}
-								if si.stackSelectable.Text() == "" {
-									si.stackSelectable.SetText(si.cfg.Stacktrace)
-								}
+						return layout.Rigids(gtx, layout.Vertical,
+							func(gtx layout.Context) layout.Dimensions {
+								gtx.Constraints.Min.X = 0
+								return layout.Rigids(gtx, layout.Horizontal,
+									func(gtx layout.Context) layout.Dimensions {
+										return theme.Button(win.Theme, &si.buttons.copyStacktrace.Clickable, tr("Copy stack trace")).Layout(win, gtx)
+									},
+									layout.Spacer{Width: 5}.Layout,
+									func(gtx layout.Context) layout.Dimensions {
+										return theme.Button(win.Theme, &si.buttons.openStacktrace.Clickable, tr("Show frame details")).Layout(win, gtx)
+									},
+								)
+							},
+
+							layout.Spacer{Height: 5}.Layout,
 
-								return si.stackSelectable.Layout(
+							func(gtx layout.Context) layout.Dimensions {
+								return theme.List(win.Theme, &si.stacktraceList).Layout(
+									win,
 									gtx,
-									win.Theme.Shaper,
-									font.Font{},
-									win.Theme.TextSize,
-									win.ColorMaterial(gtx, win.Theme.Palette.Foreground),
-									win.ColorMaterial(gtx, win.Theme.Palette.PrimarySelection))
+									1,
+									func(gtx layout.Context, index int) layout.Dimensions {
+										if index != 0 {
+											panic("impossible")
+										}
+										si.stacktraceText.Reset(win.Theme)
+										si.prevStacktraceSpans = stacktraceText(win, si.trace, si.cfg.Stack)
+										return si.stacktraceText.Layout(win, gtx, si.prevStacktraceSpans)
+									},
+								)
 							},
 						)
 
 					case "Statistics":
 						return layout.Rigids(gtx, layout.Vertical,
 							func(gtx layout.Context) layout.Dimensions {
-								if stats, ok := si.statistics.Result(); ok {
-									return stats.Layout(win, gtx)
-								} else {
-									return theme.Label(win.Theme, "Computing statistics…").Layout(win, gtx)
+								switch {
+								case si.stats != nil:
+									return si.stats.Layout(win, gtx)
+								case si.statisticsOp.Cancelled():
+									gtx.Constraints.Min = image.Point{}
+									return layout.Rigids(gtx, layout.Vertical,
+										theme.Dumb(win, theme.Label(win.Theme, tr("Computing the statistics was cancelled.")).Layout),
+										layout.Spacer{Height: 5}.Layout,
+										theme.Dumb(win, theme.Button(win.Theme, &si.buttons.retryStatistics.Clickable, tr("Retry")).Layout),
+									)
+								default:
+									gtx.Constraints.Min = image.Point{}
+									return theme.OperationProgress(si.statisticsOp, &si.statisticsProgress).Layout(win, gtx)
 								}
 							},
 
 							layout.Spacer{Height: 1}.Layout,
 
 							func(gtx layout.Context) layout.Dimensions {
+								if si.stats == nil {
+									return layout.Dimensions{}
+								}
 								gtx.Constraints.Min.X = 0
-								return theme.Button(win.Theme, &si.buttons.copyAsCSV.Clickable, "Copy as CSV").Layout(win, gtx)
+								return theme.Button(win.Theme, &si.buttons.copyAsCSV.Clickable, tr("Copy as CSV")).Layout(win, gtx)
 							},
 						)
 
@@ -571,9 +684,25 @@ func (si *SpansInfo) Layout(win *theme.Window, gtx layout.Context) layout.Dimens
 						return si.eventList.Layout(win, gtx)
 
 					case "Histogram":
-						return si.hist.Layout(win, gtx)
+						if si.brushed == nil {
+							return si.hist.Layout(win, gtx)
+						}
+						return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+							layout.Flexed(0.5, theme.Dumb(win, si.hist.Layout)),
+							layout.Rigid(layout.Spacer{Height: 5}.Layout),
+							layout.Rigid(theme.Dumb(win, theme.LineLabel(win.Theme, local.Sprintf("%d spans in the selected range, highlighted in the timelines", si.brushed.Spans.Len())).Layout)),
+							layout.Rigid(layout.Spacer{Height: 5}.Layout),
+							layout.Flexed(0.5, func(gtx layout.Context) layout.Dimensions {
+								return si.brushed.Layout(win, gtx, si.trace)
+							}),
+						)
 
 					default:
+						for _, tab := range si.cfg.Tabs {
+							if tab.Name == tabs[si.tabbedState.Current] {
+								return tab.Layout(win, gtx)
+							}
+						}
 						panic("impossible")
 					}
 				})
@@ -594,11 +723,7 @@ func (si *SpansInfo) Layout(win *theme.Window, gtx layout.Context) layout.Dimens
 			},
 
 			layout.Spacer{Height: 10}.Layout,
-			func(gtx layout.Context) layout.Dimensions {
-				l := "Collecting spans" + textSpinner(gtx.Now)
-				op.InvalidateOp{}.Add(gtx.Ops)
-				return theme.Label(win.Theme, l).Layout(win, gtx)
-			},
+			theme.Dumb(win, theme.Loading(win.Theme, tr("Collecting spans…")).Layout),
 		)
 	}
 
@@ -763,13 +888,44 @@ func spansDurationForTooltipWithQualifier(spans Items[ptrace.Span], q string) st
 	var label string
 	if d, ok := SpansDuration(spans); ok {
 		if q != "" {
-			label = fmt.Sprintf("Duration: %s %s\n", q, roundDuration(d))
+			label = local.Sprintf("Duration: %s %s\n", q, roundDuration(d))
 		} else {
-			label = fmt.Sprintf("Duration: %s\n", roundDuration(d))
+			label = local.Sprintf("Duration: %s\n", roundDuration(d))
 		}
 	}
 	if !spans.Contiguous() && spans.Len() > 1 {
-		label += fmt.Sprintf("Time span: %s\n", roundDuration(SpansTimeSpan(spans).Duration()))
+		label += local.Sprintf("Time span: %s\n", roundDuration(SpansTimeSpan(spans).Duration()))
 	}
 	return label
 }
+
+// goroutineSpans turns spans of goroutines into items whose containers are the goroutines' timelines, so that they
+// can be displayed and navigated to. The items must be grouped by goroutine, and get merged in order of their start
+// times. get returns the goroutine and span of an item.
+func goroutineSpans[T any](cv *Canvas, items []T, get func(item *T) (*ptrace.Goroutine, *ptrace.Span)) Items[ptrace.Span] {
+	var bases []Items[ptrace.Span]
+	for len(items) > 0 {
+		g, _ := get(&items[0])
+		n := 1
+		for n < len(items) {
+			if gn, _ := get(&items[n]); gn != g {
+				break
+			}
+			n++
+		}
+		spans := make([]ptrace.Span, n)
+		for i := range items[:n] {
+			_, s := get(&items[i])
+			spans[i] = *s
+		}
+		base := SimpleItems[ptrace.Span, any]{items: spans}
+		if tl := cv.itemToTimeline[g]; tl != nil {
+			base.container = ItemContainer{Timeline: tl, Track: tl.tracks[0]}
+		}
+		bases = append(bases, base)
+		items = items[n:]
+	}
+	return MergeItems(bases, func(a, b *ptrace.Span) bool {
+		return a.Start < b.Start
+	})
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"context"
+	"math"
+	rtrace "runtime/trace"
+	"slices"
+	"time"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/trace/ptrace"
+	myunsafe "honnef.co/go/gotraceui/unsafe"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/font"
+	"gioui.org/op/clip"
+	"gioui.org/text"
+	exptrace "golang.org/x/exp/trace"
+)
+
+// The number of buckets in the spawn rate charts.
+const spawnRateBuckets = 200
+
+// A spawnSite is a place that created goroutines during the trace.
+type spawnSite struct {
+	// The name of the function containing the go statement, or the empty string if the creation stack is unknown.
+	Creator string
+	// The function of the goroutines, or nil if they don't agree.
+	Function   *ptrace.Function
+	Goroutines []*ptrace.Goroutine
+	// Counts is the number of goroutines created in each of spawnRateBuckets buckets.
+	Counts []float64
+	Bursts []bool
+	// The number of buckets that are bursts.
+	NumBursts int
+	// The highest rate of goroutine creation, in goroutines per second.
+	Peak float64
+}
+
+type spawnReport struct {
+	Sites []spawnSite
+	// All is the sum of all sites.
+	All spawnSite
+}
+
+type spawnReportKey struct{}
+
+// findBursts marks buckets that have noticeably more goroutine creations than the average bucket. It returns the
+// number of bursts.
+func findBursts(counts []float64, bursts []bool) int {
+	var sum, sumSq float64
+	for _, c := range counts {
+		sum += c
+		sumSq += c * c
+	}
+	n := float64(len(counts))
+	mean := sum / n
+	stddev := math.Sqrt(max(sumSq/n-mean*mean, 0))
+
+	// A handful of goroutines aren't a burst, no matter how quiet the rest of the trace is.
+	const minBurst = 10
+	threshold := max(mean+3*stddev, minBurst)
+	num := 0
+	for i, c := range counts {
+		if c >= threshold {
+			bursts[i] = true
+			num++
+		}
+	}
+	return num
+}
+
+func computeSpawnReport(tr *Trace) *spawnReport {
+	defer rtrace.StartRegion(context.Background(), "main.computeSpawnReport").End()
+
+	start, end := tr.Start(), tr.End()
+	width := float64(end-start) / spawnRateBuckets
+	bucket := func(g *ptrace.Goroutine) int {
+		i := int(float64(g.Start.MustGet()-start) / width)
+		return min(max(i, 0), spawnRateBuckets-1)
+	}
+
+	report := &spawnReport{
+		All: spawnSite{Counts: make([]float64, spawnRateBuckets)},
+	}
+	sites := map[string]*spawnSite{}
+	var keys []string
+	for _, g := range tr.Goroutines {
+		if len(g.Spans) == 0 || !g.Start.Set() || g.Spans[0].State != ptrace.StateCreated {
+			continue
+		}
+
+		var pcs []uint64
+		if stk := tr.Event(g.Spans[0].StartEvent).Stack(); stk != exptrace.NoStack {
+			pcs = tr.Stacks[tr.StackID(stk)]
+		}
+		key := string(myunsafe.SliceCast[[]byte](pcs))
+		site, ok := sites[key]
+		if !ok {
+			site = &spawnSite{
+				Function: g.Function,
+				Counts:   make([]float64, spawnRateBuckets),
+			}
+			if len(pcs) > 0 {
+				site.Creator = tr.FuncName(pcs[0])
+			}
+			sites[key] = site
+			keys = append(keys, key)
+		}
+		if site.Function != g.Function {
+			site.Function = nil
+		}
+		site.Goroutines = append(site.Goroutines, g)
+		site.Counts[bucket(g)]++
+		report.All.Goroutines = append(report.All.Goroutines, g)
+		report.All.Counts[bucket(g)]++
+	}
+
+	perSecond := float64(time.Second) / width
+	finish := func(site *spawnSite) {
+		site.Bursts = make([]bool, spawnRateBuckets)
+		site.NumBursts = findBursts(site.Counts, site.Bursts)
+		site.Peak = slices.Max(site.Counts) * perSecond
+	}
+	finish(&report.All)
+	report.Sites = make([]spawnSite, 0, len(sites))
+	for _, key := range keys {
+		site := sites[key]
+		finish(site)
+		report.Sites = append(report.Sites, *site)
+	}
+	slices.SortStableFunc(report.Sites, func(a, b spawnSite) int {
+		return len(b.Goroutines) - len(a.Goroutines)
+	})
+	return report
+}
+
+// SpawnRateComponent displays the rate at which goroutines were created over time, broken down by where they were
+// created. Bursts of goroutine creation are highlighted, which helps finding code that spawns goroutines in hot
+// loops.
+type SpawnRateComponent struct {
+	trace  *Trace
+	report *theme.Future[*spawnReport]
+
+	table         *theme.Table
+	scrollState   theme.YScrollableListState
+	cellFormatter CellFormatter
+	selects       []widget.PrimaryClickable
+	// The site whose rate is displayed in the chart, or -1 to display all sites.
+	selected int
+}
+
+func NewSpawnRateComponent(win *theme.Window, tr *Trace) *SpawnRateComponent {
+	return &SpawnRateComponent{
+		trace: tr,
+		report: theme.Compute(&tr.analyses, win, spawnReportKey{}, func() *spawnReport {
+			return computeSpawnReport(tr)
+		}),
+		selected: -1,
+	}
+}
+
+// Title implements theme.Component.
+func (*SpawnRateComponent) Title() string {
+	return tr("Goroutine spawn rate")
+}
+
+// Transition implements theme.Component.
+func (*SpawnRateComponent) Transition(state theme.ComponentState) {}
+
+// WantsTransition implements theme.Component.
+func (*SpawnRateComponent) WantsTransition(gtx layout.Context) theme.ComponentState {
+	return theme.ComponentStateNone
+}
+
+func (sc *SpawnRateComponent) HoveredLink() ObjectLink {
+	return sc.cellFormatter.HoveredLink()
+}
+
+func (sc *SpawnRateComponent) initTable(win *theme.Window, gtx layout.Context) {
+	if sc.table != nil {
+		return
+	}
+	sc.table = &theme.Table{}
+	sc.table.SetColumns(win, gtx, []theme.Column{
+		{Name: "Goroutines", Alignment: text.End},
+		{Name: "Created by", Alignment: text.Start},
+		{Name: "Function", Alignment: text.Start},
+		{Name: "Peak rate", Alignment: text.End},
+		{Name: "Bursts", Alignment: text.End},
+		{Name: "Rate", Alignment: text.Start},
+	})
+}
+
+// Layout implements theme.Component.
+func (sc *SpawnRateComponent) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.SpawnRateComponent.Layout").End()
+
+	report, ok := sc.report.Result()
+	if !ok {
+		return theme.Loading(win.Theme, tr("Computing goroutine spawn rate…")).Layout(win, gtx)
+	}
+	if len(report.Sites) == 0 {
+		return layout.Center.Layout(gtx, theme.Dumb(win, theme.Label(win.Theme, tr("No goroutines were created during the trace.")).Layout))
+	}
+
+	sc.initTable(win, gtx)
+	sc.table.Update(gtx)
+	sc.cellFormatter.Update(win, gtx)
+	if len(sc.selects) != len(report.Sites) {
+		sc.selects = make([]widget.PrimaryClickable, len(report.Sites))
+	}
+	for i := range sc.selects {
+		if sc.selects[i].Clicked(gtx) {
+			if sc.selected == i {
+				sc.selected = -1
+			} else {
+				sc.selected = i
+			}
+		}
+	}
+
+	cellFn := func(win *theme.Window, gtx layout.Context, row, col int) layout.Dimensions {
+		defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+
+		site := &report.Sites[row]
+		switch colName := sc.table.Columns[col].Name; colName {
+		case "Goroutines":
+			return layout.RightAligned(gtx, func(gtx layout.Context) layout.Dimensions {
+				return sc.selects[row].Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+					c := win.Theme.Palette.Link
+					if row == sc.selected {
+						c = win.Theme.Palette.Foreground
+					}
+					return widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, font.Font{}, win.Theme.TextSize, local.Sprintf("%d", len(site.Goroutines)), win.ColorMaterial(gtx, c))
+				})
+			})
+		case "Created by":
+			if site.Creator == "" {
+				return sc.cellFormatter.Text(win, gtx, "unknown")
+			}
+			// Only functions that goroutines start in have Function objects.
+			if fn, ok := sc.trace.Functions[site.Creator]; ok {
+				return sc.cellFormatter.Function(win, gtx, fn)
+			}
+			return sc.cellFormatter.Text(win, gtx, site.Creator)
+		case "Function":
+			if site.Function == nil {
+				return sc.cellFormatter.Text(win, gtx, "various")
+			}
+			return sc.cellFormatter.Function(win, gtx, site.Function)
+		case "Peak rate":
+			return layout.RightAligned(gtx, func(gtx layout.Context) layout.Dimensions {
+				return sc.cellFormatter.Text(win, gtx, local.Sprintf("%.0f/s", site.Peak))
+			})
+		case "Bursts":
+			return sc.cellFormatter.Number(win, gtx, site.NumBursts)
+		case "Rate":
+			sl := theme.Sparkline(win.Theme, site.Counts)
+			sl.Kind = theme.SparklineBar
+			sl.Highlights = site.Bursts
+			return sl.Layout(win, gtx)
+		default:
+			panic(colName)
+		}
+	}
+
+	chartSite := &report.All
+	label := "Goroutines created over time, bursts highlighted"
+	if sc.selected != -1 {
+		chartSite = &report.Sites[sc.selected]
+		label = "Goroutines created over time by the selected site, bursts highlighted"
+	}
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(theme.Dumb(win, theme.LineLabel(win.Theme, label).Layout)),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			sl := theme.Sparkline(win.Theme, chartSite.Counts)
+			sl.Kind = theme.SparklineBar
+			sl.Height = 60
+			sl.Highlights = chartSite.Bursts
+			return sl.Layout(win, gtx)
+		}),
+		layout.Rigid(layout.Spacer{Height: 5}.Layout),
+		layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+			return theme.SimpleTable(win, gtx, sc.table, &sc.scrollState, len(report.Sites), cellFn)
+		}),
+	)
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"honnef.co/go/gotraceui/mem"
+	"honnef.co/go/gotraceui/theme"
 	"honnef.co/go/gotraceui/trace/ptrace"
 
 	exptrace "golang.org/x/exp/trace"
@@ -52,7 +53,7 @@ func computeStackTrack(track *Track, cancelled <-chan struct{}) Items[ptrace.Spa
 		}
 
 		ev := tr.Event(idSpan.id)
-		pcs := tr.Stacks[ev.Stack()]
+		pcs := tr.Stacks[tr.StackID(ev.Stack())]
 		state := ptrace.StateStack
 		if idSpan.span == nil {
 			state = ptrace.StateCPUSample
@@ -90,7 +91,7 @@ func computeStackTrack(track *Track, cancelled <-chan struct{}) Items[ptrace.Spa
 			// This isn't the first span. Check if we should merge this stack into the previous span.
 			prevEnd := last(spans).End
 			prevState := last(spans).State
-			fn := tr.PCs[pc].Func
+			fn := tr.FuncName(pc)
 			if prevEnd == ev.Time() && prevFn == fn && state == prevState {
 				// This is a continuation of the previous span. Merging these can have massive memory usage savings,
 				// which is why we do it here and not during display.
@@ -113,7 +114,7 @@ func computeStackTrack(track *Track, cancelled <-chan struct{}) Items[ptrace.Spa
 					pc:  pc,
 					num: 1,
 				})
-				prevFn = tr.PCs[pc].Func
+				prevFn = tr.FuncName(pc)
 			}
 		} else {
 			// This is the first span
@@ -127,7 +128,7 @@ func computeStackTrack(track *Track, cancelled <-chan struct{}) Items[ptrace.Spa
 				pc:  pc,
 				num: 1,
 			})
-			prevFn = tr.PCs[pc].Func
+			prevFn = tr.FuncName(pc)
 		}
 	}
 
@@ -223,10 +224,10 @@ func (it *samplesAndSpansIterator) next(advance bool) (out idAndMaybeSpan, ok bo
 func trimSampleRuntimeFrames(stk []uint64, tr *Trace) []uint64 {
 	// CPU samples include two runtime functions at the start of the stack trace that isn't present for stacks
 	// collected by the runtime tracer.
-	if len(stk) > 0 && tr.PCs[stk[len(stk)-1]].Func == "runtime.goexit" {
+	if len(stk) > 0 && tr.FuncName(stk[len(stk)-1]) == "runtime.goexit" {
 		stk = stk[:len(stk)-1]
 	}
-	if len(stk) > 0 && tr.PCs[stk[len(stk)-1]].Func == "runtime.main" {
+	if len(stk) > 0 && tr.FuncName(stk[len(stk)-1]) == "runtime.main" {
 		stk = stk[:len(stk)-1]
 	}
 	return stk
@@ -261,7 +262,7 @@ func addStackTracks[C *ptrace.Goroutine | *ptrace.Processor](tl *Timeline, c C,
 	}
 	processEvent := func(idSpan idAndMaybeSpan) {
 		ev := tr.Event(idSpan.id)
-		pcs := tr.Stacks[ev.Stack()]
+		pcs := tr.Stacks[tr.StackID(ev.Stack())]
 
 		if idSpan.span == nil {
 			pcs = trimSampleRuntimeFrames(pcs, tr)
@@ -317,7 +318,8 @@ func addStackTracks[C *ptrace.Goroutine | *ptrace.Processor](tl *Timeline, c C,
 			stackLevel: i,
 			spanLabel:  stackSpanLabel,
 			// OPT(dh): this allocates a closure for every stack track. it's not even stored in TrackWidget.
-			spanTooltip: stackSpanTooltip(i + 1),
+			spanTooltip:     stackSpanTooltip(i + 1),
+			spanContextMenu: stackSpanContextMenu,
 			spanColor: func(span *ptrace.Span, tr *Trace) colorIndex {
 				if state := span.State; state == statePlaceholder {
 					return colorStatePlaceholderStackSpan
@@ -340,23 +342,23 @@ func formatStack(tr *Trace, stk exptrace.Stack, limit int) string {
 	if limit == 1 {
 		limit = 2
 	}
-	pcs := tr.Stacks[stk]
+	pcs := tr.Stacks[tr.StackID(stk)]
 	if limit > 0 && len(pcs) > limit {
 		top := pcs[:limit-limit/2]
 		bottom := pcs[len(pcs)-limit/2:]
 
 		for _, pc := range top {
-			frame := tr.PCs[pc]
+			frame := tr.StackFrame(pc)
 			fmt.Fprintf(&sb, "%s\n        %s:%d\n", frame.Func, frame.File, frame.Line)
 		}
 		fmt.Fprintf(&sb, "[%d frames hidden]\n", len(pcs)-limit)
 		for _, pc := range bottom {
-			frame := tr.PCs[pc]
+			frame := tr.StackFrame(pc)
 			fmt.Fprintf(&sb, "%s\n        %s:%d\n", frame.Func, frame.File, frame.Line)
 		}
 	} else {
 		for _, pc := range pcs {
-			frame := tr.PCs[pc]
+			frame := tr.StackFrame(pc)
 			fmt.Fprintf(&sb, "%s\n        %s:%d\n", frame.Func, frame.File, frame.Line)
 		}
 	}
@@ -366,3 +368,26 @@ func formatStack(tr *Trace, stk exptrace.Stack, limit int) string {
 	}
 	return stacktrace
 }
+
+// stacktraceText builds the text spans for displaying a stack trace, with links to the functions and source locations.
+func stacktraceText(win *theme.Window, tr *Trace, stk exptrace.Stack) []TextSpan {
+	tb := TextBuilder{Window: win}
+	pcs := tr.Stacks[tr.StackID(stk)]
+	for i, pc := range pcs {
+		frame := tr.StackFrame(pc)
+		if fn, ok := tr.Functions[frame.Func]; ok {
+			tb.Link(frame.Func, &FunctionObjectLink{Function: fn})
+		} else {
+			tb.Span(frame.Func)
+		}
+		tb.Span("\n        ")
+		tb.Link(fmt.Sprintf("%s:%d", frame.File, frame.Line), &SourceLocationObjectLink{File: frame.File, Line: frame.Line})
+		if i < len(pcs)-1 {
+			tb.Span("\n")
+		}
+	}
+	for i := range tb.Spans {
+		tb.Spans[i].Font.Typeface = win.Theme.MonospaceTypeface
+	}
+	return tb.Spans
+}
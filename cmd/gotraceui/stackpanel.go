@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	rtrace "runtime/trace"
+	"strings"
+
+	"honnef.co/go/gotraceui/color"
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/mem"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/trace/ptrace"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/font"
+	"gioui.org/text"
+	exptrace "golang.org/x/exp/trace"
+)
+
+func init() {
+	RegisterContextMenu(func(spans Items[ptrace.Span]) []*theme.MenuItem {
+		if spans.Len() != 1 || spans.AtPtr(0).State == statePlaceholder {
+			return nil
+		}
+		c, ok := spans.Container()
+		if !ok || c.Timeline == nil {
+			return nil
+		}
+		tr := c.Timeline.cv.trace
+		stk := tr.Event(spans.AtPtr(0).StartEvent).Stack()
+		if len(tr.Stacks[tr.StackID(stk)]) == 0 {
+			return nil
+		}
+		return []*theme.MenuItem{newOpenStackMenuItem(stk, "span")}
+	})
+}
+
+func newOpenStackMenuItem(stk exptrace.Stack, of string) *theme.MenuItem {
+	return &theme.MenuItem{
+		Label: PlainLabel(tr("Show stack trace")),
+		Action: func() theme.Action {
+			return &OpenStackAction{Stack: stk, Title: local.Sprintf("Stack trace of %s", of)}
+		},
+	}
+}
+
+// trimPackagePath removes the import path of the package from a fully qualified function name, turning
+// "net/http.(*Server).Serve" into "http.(*Server).Serve". Slashes in type arguments are left alone.
+func trimPackagePath(fn string) string {
+	name := fn
+	if idx := strings.IndexByte(name, '['); idx != -1 {
+		name = name[:idx]
+	}
+	if idx := strings.LastIndexByte(name, '/'); idx != -1 {
+		return fn[idx+1:]
+	}
+	return fn
+}
+
+// StackPanel displays all frames of a stack trace, with links to functions and source locations and buttons for
+// copying individual frames or the whole stack.
+type StackPanel struct {
+	trace *Trace
+	stack ptrace.StackID
+	title string
+
+	shortenPaths    widget.Bool
+	trimPackagePath widget.Bool
+	copyStack       widget.PrimaryClickable
+	copyFrames      []widget.PrimaryClickable
+	list            widget.List
+	clicks          mem.BucketSlice[Link]
+	hoveredLink     ObjectLink
+}
+
+func NewStackPanel(tr *Trace, stk exptrace.Stack, title string) *StackPanel {
+	sp := &StackPanel{
+		trace:      tr,
+		stack:      tr.StackID(stk),
+		title:      title,
+		copyFrames: make([]widget.PrimaryClickable, len(tr.Stacks[tr.StackID(stk)])),
+	}
+	sp.shortenPaths.Value = true
+	sp.list.Axis = layout.Vertical
+	return sp
+}
+
+// Title implements theme.Component.
+func (sp *StackPanel) Title() string {
+	return sp.title
+}
+
+// Transition implements theme.Component.
+func (*StackPanel) Transition(state theme.ComponentState) {}
+
+// WantsTransition implements theme.Component.
+func (*StackPanel) WantsTransition(gtx layout.Context) theme.ComponentState {
+	return theme.ComponentStateNone
+}
+
+func (sp *StackPanel) HoveredLink() ObjectLink { return sp.hoveredLink }
+
+// inlined reports whether the i-th frame has been inlined into its caller. The frames of inlined calls share the PC
+// of the physical frame they were inlined into.
+func (sp *StackPanel) inlined(i int) bool {
+	pcs := sp.trace.Stacks[sp.stack]
+	return i+1 < len(pcs) && pcs[i] == pcs[i+1]
+}
+
+func (sp *StackPanel) function(frame exptrace.StackFrame) string {
+	if sp.trimPackagePath.Value {
+		return trimPackagePath(frame.Func)
+	}
+	return frame.Func
+}
+
+func (sp *StackPanel) location(frame exptrace.StackFrame) string {
+	file := frame.File
+	if sp.shortenPaths.Value {
+		file = sp.trace.displayPath(file)
+	}
+	return fmt.Sprintf("%s:%d", file, frame.Line)
+}
+
+// frameText formats the i-th frame like formatStack does, applying the panel's options.
+func (sp *StackPanel) frameText(i int) string {
+	frame := sp.trace.StackFrame(sp.trace.Stacks[sp.stack][i])
+	fn := sp.function(frame)
+	if sp.inlined(i) {
+		fn += " (inlined)"
+	}
+	return fmt.Sprintf("%s\n        %s", fn, sp.location(frame))
+}
+
+// Layout implements theme.Component.
+func (sp *StackPanel) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.StackPanel.Layout").End()
+
+	pcs := sp.trace.Stacks[sp.stack]
+
+	handleLinkClicks(win, gtx, &sp.clicks)
+	sp.hoveredLink = nil
+	for i, n := 0, sp.clicks.Len(); i < n; i++ {
+		if c := sp.clicks.Ptr(i); c.Click.Hovered() {
+			sp.hoveredLink = c.Link
+			break
+		}
+	}
+	sp.clicks.Reset()
+
+	for sp.copyStack.Clicked(gtx) {
+		lines := make([]string, len(pcs))
+		for i := range pcs {
+			lines[i] = sp.frameText(i)
+		}
+		win.AppWindow.WriteClipboard(strings.Join(lines, "\n"))
+		win.ShowNotification(gtx, tr("Copied stack trace to clipboard"))
+	}
+	for i := range sp.copyFrames {
+		for sp.copyFrames[i].Clicked(gtx) {
+			win.AppWindow.WriteClipboard(sp.frameText(i))
+			win.ShowNotification(gtx, tr("Copied stack frame to clipboard"))
+		}
+	}
+
+	mono := font.Font{Typeface: win.Theme.MonospaceTypeface}
+	label := func(gtx layout.Context, s string, c color.Oklch) layout.Dimensions {
+		return widget.Label{MaxLines: 1, Alignment: text.Start}.Layout(gtx, win.Theme.Shaper, mono, win.Theme.TextSize, s, win.ColorMaterial(gtx, c))
+	}
+	link := func(gtx layout.Context, s string, l ObjectLink) layout.Dimensions {
+		click := sp.clicks.Grow()
+		click.Link = l
+		return click.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			return label(gtx, s, win.Theme.Palette.Link)
+		})
+	}
+
+	return layout.Rigids(gtx, layout.Vertical,
+		func(gtx layout.Context) layout.Dimensions {
+			return layout.Rigids(gtx, layout.Horizontal,
+				theme.Dumb(win, theme.CheckBox(win.Theme, &sp.shortenPaths, tr("Shorten paths to GOROOT and GOPATH")).Layout),
+				layout.Spacer{Width: 10}.Layout,
+				theme.Dumb(win, theme.CheckBox(win.Theme, &sp.trimPackagePath, tr("Omit import paths from function names")).Layout),
+				layout.Spacer{Width: 10}.Layout,
+				func(gtx layout.Context) layout.Dimensions {
+					return theme.Button(win.Theme, &sp.copyStack.Clickable, tr("Copy whole stack")).Layout(win, gtx)
+				},
+			)
+		},
+		layout.Spacer{Height: 10}.Layout,
+		func(gtx layout.Context) layout.Dimensions {
+			gtx.Constraints.Min = gtx.Constraints.Max
+			return theme.List(win.Theme, &sp.list).Layout(win, gtx, len(pcs), func(gtx layout.Context, i int) layout.Dimensions {
+				frame := sp.trace.StackFrame(pcs[i])
+				return layout.Inset{Bottom: 5}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+					return layout.Flex{Axis: layout.Horizontal}.Layout(gtx,
+						layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+							return layout.Rigids(gtx, layout.Vertical,
+								func(gtx layout.Context) layout.Dimensions {
+									return layout.Rigids(gtx, layout.Horizontal,
+										func(gtx layout.Context) layout.Dimensions {
+											return label(gtx, fmt.Sprintf("#%-3d ", i), win.Theme.Palette.Foreground)
+										},
+										func(gtx layout.Context) layout.Dimensions {
+											if fn, ok := sp.trace.Functions[frame.Func]; ok {
+												return link(gtx, sp.function(frame), &FunctionObjectLink{Function: fn})
+											}
+											return label(gtx, sp.function(frame), win.Theme.Palette.Foreground)
+										},
+										func(gtx layout.Context) layout.Dimensions {
+											if !sp.inlined(i) {
+												return layout.Dimensions{}
+											}
+											return label(gtx, " (inlined)", win.Theme.Palette.ForegroundDisabled)
+										},
+									)
+								},
+								func(gtx layout.Context) layout.Dimensions {
+									return layout.Inset{Left: 40}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+										return link(gtx, sp.location(frame), &SourceLocationObjectLink{File: frame.File, Line: frame.Line})
+									})
+								},
+							)
+						}),
+						layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+							return theme.Button(win.Theme, &sp.copyFrames[i].Clickable, tr("Copy")).Layout(win, gtx)
+						}),
+					)
+				})
+			})
+		},
+	)
+}
@@ -8,6 +8,7 @@ import (
 	"image"
 	"image/color"
 	rtrace "runtime/trace"
+	"slices"
 	"time"
 
 	"honnef.co/go/gotraceui/layout"
@@ -38,8 +39,15 @@ var statLabels = [...][numStatLabels]string{
 	},
 }
 
+// maxDonutSlices is the number of states that the breakdown of SpansStats shows individually. The remaining states
+// are combined into a single slice, so that each slice has a distinct color.
+const maxDonutSlices = 5
+
 type SpansStats struct {
-	stats        SortedIndices[ptrace.Statistic, []ptrace.Statistic]
+	stats SortedIndices[ptrace.Statistic, []ptrace.Statistic]
+	// The shares of the states of the total time, for the breakdown.
+	breakdown    []theme.DonutSlice
+	total        time.Duration
 	table        theme.Table
 	scrollState  theme.YScrollableListState
 	numberFormat durationNumberFormat
@@ -95,17 +103,59 @@ func NewStats(stats ptrace.Statistics) *SpansStats {
 	}
 
 	gst.sort()
+	gst.computeBreakdown()
 
 	return gst
 }
 
-func NewSpansStats(spans ptrace.Spans) *SpansStats {
-	return NewStats(ptrace.ComputeStatistics(spans))
+// computeBreakdown computes the slices of the donut chart that breaks down the total time by state, in descending
+// order of time.
+func (gs *SpansStats) computeBreakdown() {
+	order := slices.Clone(gs.stats.Order)
+	slices.SortFunc(order, func(a, b int) int {
+		return cmp(gs.stats.Items[a].Total, gs.stats.Items[b].Total, true)
+	})
+	order = slices.DeleteFunc(order, func(i int) bool { return gs.stats.Items[i].Total <= 0 })
+
+	gs.breakdown = gs.breakdown[:0]
+	var other time.Duration
+	for j, i := range order {
+		d := gs.stats.Items[i].Total
+		gs.total += d
+		if j >= maxDonutSlices-1 && len(order) > maxDonutSlices {
+			other += d
+			continue
+		}
+		gs.breakdown = append(gs.breakdown, theme.DonutSlice{Label: stateNamesCapitalized[i], Value: float64(d)})
+	}
+	if other > 0 {
+		gs.breakdown = append(gs.breakdown, theme.DonutSlice{Label: tr("Other"), Value: float64(other)})
+	}
 }
 
-func NewGoroutineStats(g *ptrace.Goroutine) *SpansStats {
-	// XXX reintroduce caching of statistics
-	return NewStats(ptrace.ComputeStatistics(ptrace.ToSpans(g.Spans)))
+var statisticsStages = []string{"Computing statistics"}
+
+// ComputeSpansStats computes the statistics of spans as an operation that can be cancelled. If it is cancelled, the
+// future's value is meaningless.
+func ComputeSpansStats(win *theme.Window, spans ptrace.Spans) (*theme.Future[ptrace.Statistics], *theme.Operation) {
+	o := theme.NewOperation(context.Background(), statisticsStages...)
+	ft := theme.NewFuture(win, func(cancelled <-chan struct{}) ptrace.Statistics {
+		stats, _ := ptrace.ComputeStatisticsProgress(o.Context(), spans, o.SetProgress)
+		return stats
+	})
+	return ft, o
+}
+
+type goroutineStatsKey struct {
+	g *ptrace.Goroutine
+}
+
+// ComputeGoroutineStats is like ComputeSpansStats, but for the spans of a goroutine. The statistics are memoized and
+// shared with other panels showing the same goroutine.
+func ComputeGoroutineStats(win *theme.Window, tr *Trace, g *ptrace.Goroutine) (*theme.Future[ptrace.Statistics], *theme.Operation) {
+	return theme.ComputeOperation(&tr.analyses, win, goroutineStatsKey{g}, statisticsStages, func(o *theme.Operation) (ptrace.Statistics, error) {
+		return ptrace.ComputeStatisticsProgress(o.Context(), ptrace.ToSpans(g.Spans), o.SetProgress)
+	})
 }
 
 func (gs *SpansStats) computeSizes(gtx layout.Context, th *theme.Theme) [numStatLabels]image.Point {
@@ -121,7 +171,7 @@ func (gs *SpansStats) computeSizes(gtx layout.Context, th *theme.Theme) [numStat
 	fContent := font.Font{}
 	fValue := font.Font{}
 	fUnit := font.Font{
-		Typeface: "Go Mono",
+		Typeface: th.MonospaceTypeface,
 	}
 
 	var columnSizes [numStatLabels]image.Point
@@ -315,14 +365,25 @@ func (gs *SpansStats) Layout(win *theme.Window, gtx layout.Context) layout.Dimen
 		// TODO(dh): explicitly select tabular figures from the font. It's not crucial because most fonts default to
 		// it, anyway.
 		txt := styledtext.Text(win.Theme.Shaper, span(win, value), span(win, " "), span(win, unit))
-		txt.Styles[2].Font.Typeface = "Go Mono"
+		txt.Styles[2].Font.Typeface = win.Theme.MonospaceTypeface
 		if col != 0 {
 			txt.Alignment = text.End
 		}
 		return txt.Layout(gtx, nil)
 	}
 
-	dims := theme.SimpleTable(win, gtx, &gs.table, &gs.scrollState, gs.stats.Len(), cellFn)
-
-	return dims
+	if len(gs.breakdown) == 0 {
+		return theme.SimpleTable(win, gtx, &gs.table, &gs.scrollState, gs.stats.Len(), cellFn)
+	}
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			donut := theme.Donut(win.Theme, gs.breakdown)
+			donut.Center = roundDuration(gs.total).String()
+			return donut.Layout(win, gtx)
+		}),
+		layout.Rigid(layout.Spacer{Height: 10}.Layout),
+		layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+			return theme.SimpleTable(win, gtx, &gs.table, &gs.scrollState, gs.stats.Len(), cellFn)
+		}),
+	)
 }
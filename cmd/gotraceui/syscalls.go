@@ -0,0 +1,304 @@
+package main
+
+import (
+	"context"
+	rtrace "runtime/trace"
+	"slices"
+	"time"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/trace/ptrace"
+	myunsafe "honnef.co/go/gotraceui/unsafe"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/font"
+	"gioui.org/op/clip"
+	"gioui.org/text"
+	exptrace "golang.org/x/exp/trace"
+)
+
+const defaultSyscallThreshold = 10 * time.Millisecond
+
+// A blockingSyscall is a syscall that kept its P from running other goroutines, either until the syscall returned or
+// until the runtime handed the P off to another thread.
+type blockingSyscall struct {
+	g    *ptrace.Goroutine
+	span *ptrace.Span
+	// How long the P was blocked by the syscall.
+	blocked time.Duration
+	// The syscall's stack, used for grouping, and the function that made the syscall.
+	stack string
+	site  string
+}
+
+// A syscallGroup aggregates blocking syscalls that were made from the same stack.
+type syscallGroup struct {
+	// The function that made the syscall, or the empty string if the stack is unknown.
+	Site     string
+	Syscalls []blockingSyscall
+	// Total and Max describe how long the syscalls blocked their Ps, not how long the syscalls took.
+	Total, Max time.Duration
+}
+
+type blockingSyscallsKey struct{}
+
+// blockingSyscalls finds all syscalls that blocked their P, grouped by goroutine.
+func blockingSyscalls(tr *Trace) []blockingSyscall {
+	defer rtrace.StartRegion(context.Background(), "main.blockingSyscalls").End()
+
+	// When a goroutine enters a syscall, its P switches to StateProcRunningBlocked at the same event. That span ends
+	// when the syscall returns or when the P gets handed off, whichever happens first.
+	blocked := map[ptrace.EventID]time.Duration{}
+	for _, p := range tr.Processors {
+		for i := range p.Spans {
+			s := &p.Spans[i]
+			if s.State != ptrace.StateProcRunningBlocked {
+				continue
+			}
+			end := s.End
+			if s.EndEvent == -1 {
+				end = tr.End()
+			}
+			blocked[s.StartEvent] = time.Duration(end - s.Start)
+		}
+	}
+
+	var out []blockingSyscall
+	for _, g := range tr.Goroutines {
+		for i := range g.Spans {
+			span := &g.Spans[i]
+			// Calls into C block Ps just like syscalls do.
+			if span.State != ptrace.StateBlockedSyscall && span.State != ptrace.StateCgo {
+				continue
+			}
+			d, ok := blocked[span.StartEvent]
+			if !ok {
+				// The goroutine didn't have a P, for example because the syscall started before the trace did.
+				continue
+			}
+			var pcs []uint64
+			if stk := tr.Event(span.StartEvent).Stack(); stk != exptrace.NoStack {
+				pcs = tr.Stacks[tr.StackID(stk)]
+			}
+			bs := blockingSyscall{
+				g:       g,
+				span:    span,
+				blocked: d,
+				stack:   string(myunsafe.SliceCast[[]byte](pcs)),
+			}
+			if len(pcs) > 0 {
+				bs.site = tr.FuncName(pcs[0])
+			}
+			out = append(out, bs)
+		}
+	}
+	return out
+}
+
+// groupSyscalls groups the syscalls that blocked their P for at least threshold by their stacks, ordered by the total
+// time they blocked Ps, descending.
+func groupSyscalls(syscalls []blockingSyscall, threshold time.Duration) []syscallGroup {
+	groups := map[string]*syscallGroup{}
+	for _, sc := range syscalls {
+		if sc.blocked < threshold {
+			continue
+		}
+		group, ok := groups[sc.stack]
+		if !ok {
+			group = &syscallGroup{Site: sc.site}
+			groups[sc.stack] = group
+		}
+		group.Syscalls = append(group.Syscalls, sc)
+		group.Total += sc.blocked
+		group.Max = max(group.Max, sc.blocked)
+	}
+
+	out := make([]syscallGroup, 0, len(groups))
+	for _, group := range groups {
+		out = append(out, *group)
+	}
+	slices.SortFunc(out, func(a, b syscallGroup) int {
+		return cmp(a.Total, b.Total, true)
+	})
+	return out
+}
+
+// syscallOverlay returns the union of the time ranges during which the syscalls blocked Ps, for highlighting them in
+// the timelines.
+func syscallOverlay(groups []syscallGroup) Items[ptrace.Span] {
+	var spans []ptrace.Span
+	for _, group := range groups {
+		for _, sc := range group.Syscalls {
+			spans = append(spans, ptrace.Span{
+				Start: sc.span.Start,
+				End:   sc.span.Start + exptrace.Time(sc.blocked),
+				State: ptrace.StateBlockedSyscall,
+			})
+		}
+	}
+	slices.SortFunc(spans, func(a, b ptrace.Span) int {
+		return cmp(a.Start, b.Start, false)
+	})
+
+	// Overlays have to be sorted by both start and end, so merge overlapping spans.
+	merged := spans[:0]
+	for _, s := range spans {
+		if n := len(merged); n > 0 && s.Start <= merged[n-1].End {
+			merged[n-1].End = max(merged[n-1].End, s.End)
+		} else {
+			merged = append(merged, s)
+		}
+	}
+	return SimpleItems[ptrace.Span, any]{items: merged}
+}
+
+// SyscallsComponent lists syscalls that blocked their Ps for longer than a threshold. Such syscalls, often caused by
+// file IO or DNS lookups, can starve other goroutines of Ps.
+type SyscallsComponent struct {
+	canvas   *Canvas
+	syscalls *theme.Future[[]blockingSyscall]
+
+	threshold   *theme.NumberInputState[time.Duration]
+	showOverlay widget.Bool
+
+	// Groups for the current threshold, or nil if they need to be recomputed.
+	groups []syscallGroup
+	// Lazily computed spans of the groups, indexed by row.
+	spans []Items[ptrace.Span]
+
+	table         *theme.Table
+	scrollState   theme.YScrollableListState
+	cellFormatter CellFormatter
+}
+
+func NewSyscallsComponent(win *theme.Window, tr *Trace, cv *Canvas) *SyscallsComponent {
+	return &SyscallsComponent{
+		canvas: cv,
+		syscalls: theme.Compute(&tr.analyses, win, blockingSyscallsKey{}, func() []blockingSyscall {
+			return blockingSyscalls(tr)
+		}),
+		threshold: theme.NewDurationInput(defaultSyscallThreshold, time.Microsecond, time.Hour, time.Millisecond),
+	}
+}
+
+// Title implements theme.Component.
+func (*SyscallsComponent) Title() string {
+	return tr("Long syscalls")
+}
+
+// Transition implements theme.Component.
+func (*SyscallsComponent) Transition(state theme.ComponentState) {}
+
+// WantsTransition implements theme.Component.
+func (*SyscallsComponent) WantsTransition(gtx layout.Context) theme.ComponentState {
+	return theme.ComponentStateNone
+}
+
+func (sc *SyscallsComponent) HoveredLink() ObjectLink {
+	return sc.cellFormatter.HoveredLink()
+}
+
+// groupSpans returns the syscalls of a group as spans in the timelines of their goroutines.
+func (sc *SyscallsComponent) groupSpans(group *syscallGroup) Items[ptrace.Span] {
+	// The syscalls are grouped by goroutine because we collected them one goroutine at a time.
+	return goroutineSpans(sc.canvas, group.Syscalls, func(s *blockingSyscall) (*ptrace.Goroutine, *ptrace.Span) {
+		return s.g, s.span
+	})
+}
+
+func (sc *SyscallsComponent) initTable(win *theme.Window, gtx layout.Context) {
+	if sc.table != nil {
+		return
+	}
+	sc.table = &theme.Table{}
+	sc.table.SetColumns(win, gtx, []theme.Column{
+		{Name: "Total P time", Alignment: text.End},
+		{Name: "Max P time", Alignment: text.End},
+		{Name: "Syscalls", Alignment: text.End},
+		{Name: "Called from", Alignment: text.Start},
+	})
+}
+
+// Layout implements theme.Component.
+func (sc *SyscallsComponent) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.SyscallsComponent.Layout").End()
+
+	syscalls, ok := sc.syscalls.Result()
+	if !ok {
+		return theme.Loading(win.Theme, tr("Looking for long syscalls…")).Layout(win, gtx)
+	}
+
+	sc.initTable(win, gtx)
+	sc.table.Update(gtx)
+	sc.cellFormatter.Update(win, gtx)
+	overlayChanged := sc.showOverlay.Update(gtx)
+	// The threshold's input updates the threshold when it gets laid out, so this reacts to changes made in the
+	// previous frame.
+	if sc.threshold.Changed() || sc.groups == nil {
+		sc.groups = groupSyscalls(syscalls, sc.threshold.Value())
+		sc.spans = make([]Items[ptrace.Span], len(sc.groups))
+		overlayChanged = true
+	}
+	if overlayChanged {
+		if sc.showOverlay.Value {
+			sc.canvas.timeline.syscallOverlay = syscallOverlay(sc.groups)
+		} else {
+			sc.canvas.timeline.syscallOverlay = nil
+		}
+	}
+
+	cellFn := func(win *theme.Window, gtx layout.Context, row, col int) layout.Dimensions {
+		defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+
+		group := &sc.groups[row]
+		switch colName := sc.table.Columns[col].Name; colName {
+		case "Total P time":
+			return sc.cellFormatter.Duration(win, gtx, group.Total, false)
+		case "Max P time":
+			return sc.cellFormatter.Duration(win, gtx, group.Max, false)
+		case "Syscalls":
+			spans := sc.spans[row]
+			if spans == nil {
+				spans = sc.groupSpans(group)
+				sc.spans[row] = spans
+			}
+			return layout.RightAligned(gtx, func(gtx layout.Context) layout.Dimensions {
+				link := sc.cellFormatter.Clicks.Grow()
+				link.Link = &SpansObjectLink{Spans: spans}
+				return link.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+					return widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, font.Font{}, win.Theme.TextSize, local.Sprintf("%d", len(group.Syscalls)), win.ColorMaterial(gtx, win.Theme.Palette.OpenLink))
+				})
+			})
+		case "Called from":
+			if group.Site == "" {
+				return sc.cellFormatter.Text(win, gtx, "unknown")
+			}
+			return sc.cellFormatter.Text(win, gtx, group.Site)
+		default:
+			panic(colName)
+		}
+	}
+
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+				layout.Rigid(theme.Dumb(win, theme.LineLabel(win.Theme, tr("Minimum time blocking P: ")).Layout)),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					gtx.Constraints.Max.X = min(gtx.Constraints.Max.X, gtx.Dp(200))
+					gtx.Constraints.Min.X = gtx.Constraints.Max.X
+					return theme.NumberInput(win.Theme, sc.threshold, "Threshold").Layout(win, gtx)
+				}),
+				layout.Rigid(layout.Spacer{Width: 10}.Layout),
+				layout.Rigid(theme.Dumb(win, theme.CheckBox(win.Theme, &sc.showOverlay, tr("Highlight in timelines")).Layout)),
+			)
+		}),
+		layout.Rigid(layout.Spacer{Height: 5}.Layout),
+		layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+			if len(sc.groups) == 0 {
+				return layout.Center.Layout(gtx, theme.Dumb(win, theme.Label(win.Theme, tr("No syscalls blocked their P for that long.")).Layout))
+			}
+			return theme.SimpleTable(win, gtx, sc.table, &sc.scrollState, len(sc.groups), cellFn)
+		}),
+	)
+}
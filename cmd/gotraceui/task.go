@@ -6,6 +6,7 @@ import (
 	"image"
 	"math"
 	rtrace "runtime/trace"
+	"slices"
 	"strings"
 	"time"
 
@@ -168,6 +169,11 @@ func (tt TaskTooltip) Layout(win *theme.Window, gtx layout.Context) layout.Dimen
 		args = append(args, goroutineID)
 	}
 
+	if ds := tt.trace.otel.SpanForTask(tt.t); ds != nil {
+		fmts = append(fmts, "Distributed span: %s")
+		args = append(args, ds.Name)
+	}
+
 	l := local.Sprintf(strings.Join(fmts, "\n"), args...)
 
 	return theme.Tooltip(win.Theme, l).Layout(win, gtx)
@@ -193,10 +199,9 @@ func NewTaskInfo(tr *Trace, mwin *theme.Window, canvas *Canvas, t *ptrace.Task,
 
 	spans := t.Spans
 
-	var stacktrace string
+	stk := exptrace.NoStack
 	if spans[0].State == ptrace.StateCreated {
-		stk := tr.Event(spans[0].StartEvent).Stack()
-		stacktrace = formatStack(tr, stk, 0)
+		stk = tr.Event(spans[0].StartEvent).Stack()
 	}
 
 	buildDescription := func(win *theme.Window, gtx layout.Context) Description {
@@ -304,20 +309,34 @@ func NewTaskInfo(tr *Trace, mwin *theme.Window, canvas *Canvas, t *ptrace.Task,
 				Value: *tb.Span(d.String()),
 			})
 		}
+		if ds := tr.otel.SpanForTask(t); ds != nil {
+			label := ds.Name
+			if ds.Service != "" {
+				label += " (" + ds.Service + ")"
+			}
+			attrs = append(attrs, DescriptionAttribute{
+				Key:   "Distributed span",
+				Value: *tb.Link(label, &OTelSpanObjectLink{Span: ds}),
+			})
+			attrs = append(attrs, DescriptionAttribute{
+				Key:   "Distributed trace",
+				Value: *tb.Span(ds.TraceID),
+			})
+		}
 		var desc Description
 		desc.Attributes = attrs
 		return desc
 	}
 
 	cfg := SpansInfoConfig{
-		Title:      title,
-		Stacktrace: stacktrace,
+		Title: title,
+		Stack: stk,
 		Navigations: SpansInfoConfigNavigations{
 			Scroll: struct {
 				ButtonLabel string
 				Fn          func() theme.Action
 			}{
-				ButtonLabel: "Scroll to task",
+				ButtonLabel: local.Sprintf("Scroll to task"),
 				Fn: func() theme.Action {
 					return &ScrollToObjectAction{Object: t}
 				},
@@ -327,7 +346,7 @@ func NewTaskInfo(tr *Trace, mwin *theme.Window, canvas *Canvas, t *ptrace.Task,
 				ButtonLabel string
 				Fn          func() theme.Action
 			}{
-				ButtonLabel: "Zoom to task",
+				ButtonLabel: local.Sprintf("Zoom to task"),
 				Fn: func() theme.Action {
 					return &ZoomToObjectAction{Object: t}
 				},
@@ -335,6 +354,7 @@ func NewTaskInfo(tr *Trace, mwin *theme.Window, canvas *Canvas, t *ptrace.Task,
 		},
 		Statistics:         nil,
 		DescriptionBuilder: buildDescription,
+		Tabs:               taskInfoTabs(tr, canvas, t),
 	}
 
 	tl := canvas.itemToTimeline[t]
@@ -349,6 +369,94 @@ func NewTaskInfo(tr *Trace, mwin *theme.Window, canvas *Canvas, t *ptrace.Task,
 	return NewSpansInfo(cfg, tr, mwin, theme.Immediate[Items[ptrace.Span]](ss), allTimelines)
 }
 
+// taskInfoTabs returns tabs listing the goroutines that worked on a task, its user regions, and its subtasks. The
+// task's log events are already part of its events.
+func taskInfoTabs(tr *Trace, canvas *Canvas, t *ptrace.Task) []SpansInfoTab {
+	var (
+		regions  []userRegion
+		subtasks []*ptrace.Task
+		gs       []*ptrace.Goroutine
+	)
+	seen := map[*ptrace.Goroutine]struct{}{}
+	addG := func(gid exptrace.GoID) {
+		if gid == exptrace.NoGoroutine {
+			return
+		}
+		g := tr.G(gid)
+		if _, ok := seen[g]; !ok {
+			seen[g] = struct{}{}
+			gs = append(gs, g)
+		}
+	}
+
+	addG(goroutineIDForTask(t, tr))
+	for _, ev := range t.Events {
+		addG(tr.Event(ev).Goroutine())
+	}
+	for _, g := range tr.Goroutines {
+		for depth, spans := range g.UserRegions {
+			for i := range spans {
+				span := &spans[i]
+				if tr.Event(span.StartEvent).Region().Task == t.ID {
+					regions = append(regions, userRegion{g, depth, span})
+					addG(g.ID)
+				}
+			}
+		}
+	}
+	for _, other := range tr.Tasks {
+		if other.Parent == t.ID && other != t {
+			subtasks = append(subtasks, other)
+		}
+	}
+	slices.SortFunc(gs, func(a, b *ptrace.Goroutine) int {
+		return cmp(a.ID, b.ID, false)
+	})
+
+	var tabs []SpansInfoTab
+	if len(gs) != 0 {
+		gl := &GoroutineList{Trace: tr}
+		initialized := false
+		tabs = append(tabs, SpansInfoTab{
+			Name: "Goroutines",
+			Layout: func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+				if !initialized {
+					initialized = true
+					gl.SetGoroutines(win, gtx, gs)
+				}
+				return gl.Layout(win, gtx)
+			},
+			HoveredLink: gl.HoveredLink,
+		})
+	}
+	if len(regions) != 0 {
+		sl := &SpanList{Spans: NewSortedItems(regionSpans(canvas, regions))}
+		tabs = append(tabs, SpansInfoTab{
+			Name: "User regions",
+			Layout: func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+				return sl.Layout(win, gtx, tr)
+			},
+			HoveredLink: sl.HoveredLink,
+		})
+	}
+	if len(subtasks) != 0 {
+		tl := &TaskList{Trace: tr}
+		initialized := false
+		tabs = append(tabs, SpansInfoTab{
+			Name: "Subtasks",
+			Layout: func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+				if !initialized {
+					initialized = true
+					tl.SetTasks(win, gtx, subtasks)
+				}
+				return tl.Layout(win, gtx)
+			},
+			HoveredLink: tl.HoveredLink,
+		})
+	}
+	return tabs
+}
+
 type TaskList struct {
 	Trace         *Trace
 	Tasks         SortedIndices[*ptrace.Task, []*ptrace.Task]
@@ -477,12 +585,12 @@ func (gs *TaskList) initTable(win *theme.Window, gtx layout.Context) {
 	r0 := theme.Record(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
 		gtx.Constraints.Min = image.Point{}
 		gtx.Constraints.Max = image.Pt(99999, 99999)
-		return widget.Label{}.Layout(gtx, win.Theme.Shaper, font.Font{Weight: font.Bold}, 12, "Task", win.ColorMaterial(gtx, color.Oklch{}))
+		return widget.Label{}.Layout(gtx, win.Theme.Shaper, font.Font{Weight: font.Bold}, win.Theme.TextSize, "Task", win.ColorMaterial(gtx, color.Oklch{}))
 	})
 	r1 := theme.Record(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
 		gtx.Constraints.Min = image.Point{}
 		gtx.Constraints.Max = image.Pt(99999, 99999)
-		return widget.Label{}.Layout(gtx, win.Theme.Shaper, font.Font{}, 12, local.Sprintf("%d", maxID), win.ColorMaterial(gtx, color.Oklch{}))
+		return widget.Label{}.Layout(gtx, win.Theme.Shaper, font.Font{}, win.Theme.TextSize, local.Sprintf("%d", maxID), win.ColorMaterial(gtx, color.Oklch{}))
 	})
 	w := r0.Dimensions.Size.X
 	if x := r1.Dimensions.Size.X; x > w {
@@ -594,7 +702,7 @@ func NewTasksComponent(tasks []*ptrace.Task, tr *Trace) *TasksComponent {
 
 // Title implements theme.Component.
 func (*TasksComponent) Title() string {
-	return "Tasks"
+	return tr("Tasks")
 }
 
 // Transition implements theme.Component.
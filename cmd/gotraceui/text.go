@@ -5,7 +5,6 @@ import (
 	rtrace "runtime/trace"
 
 	"honnef.co/go/gotraceui/clip"
-	ourfont "honnef.co/go/gotraceui/font"
 	"honnef.co/go/gotraceui/gesture"
 	"honnef.co/go/gotraceui/layout"
 	"honnef.co/go/gotraceui/theme"
@@ -59,7 +58,7 @@ func (txt *TextBuilder) Span(label string) *TextSpan {
 		Content: label,
 		Size:    txt.Window.Theme.TextSize,
 		Color:   txt.Window.ConvertColor(txt.Window.Theme.Palette.Foreground),
-		Font:    ourfont.Collection()[0].Font,
+		Font:    font.Font{},
 	}
 	s := TextSpan{
 		SpanStyle: style,
@@ -58,10 +58,24 @@ package main
 //
 // We'd rather keep the textures around in case we need them in the future (for example when the user zooms
 // out again). If they're truly useless they'll eventually be deleted as part of texture compaction.
+//
+//
+// Prefetching
+//
+// Once all textures for the visible time range are ready, we compute the textures to the left and right of it in the
+// background. Because textures are aligned to multiples of their width, these are the same textures that will be
+// requested when the user pans, allowing panning through large traces without ever showing placeholders, as long as
+// the user doesn't pan faster than we can compute textures.
+//
+//
+// Span pyramids
+//
+// Tracks with many spans have a span pyramid (see pyramid.go) that is built when loading the trace. Computing a
+// zoomed out texture uses the pyramid's precomputed runs of spans instead of looking at every span, which makes
+// computing textures for zoomed out views of tracks with millions of spans about as cheap as for small tracks.
 
 // TODO ahead of time generation of textures. when we request the texture for a zoom level, also generate the
-// next zoom level in the background (depending on the direction in which the user is zooming.). Similar for
-// panning to the left and right.
+// next zoom level in the background (depending on the direction in which the user is zooming.).
 
 import (
 	"context"
@@ -113,14 +127,31 @@ const (
 
 	// How many frames to wait between compaction attempts
 	compactInterval = 100
-	// Maximum memory to spend on storing textures
-	maxTextureMemoryUsage = 100 * 1024 * 1024
-	// 10% for compressed textures
-	maxCompressedMemoryUsage = maxTextureMemoryUsage / 10
-	// the remainder for decompressed textures
-	maxRGBAMemoryUsage = maxTextureMemoryUsage - maxCompressedMemoryUsage
+	// The default for cacheMemoryLimit
+	defaultCacheMemoryLimit = 125 * 1024 * 1024
 )
 
+// cacheMemoryLimit is the maximum memory to spend on caching textures and recorded ops, in bytes, or 0 to use
+// defaultCacheMemoryLimit. It is set from the configuration and can be changed while traces are open. 80% of it is
+// spent on textures, the remainder on ops.
+var cacheMemoryLimit atomic.Uint64
+
+// currentCacheMemoryLimit returns the cache memory limit in effect, in bytes.
+func currentCacheMemoryLimit() uint64 {
+	if n := cacheMemoryLimit.Load(); n != 0 {
+		return n
+	}
+	return defaultCacheMemoryLimit
+}
+
+func cacheMemoryLimits() (rgbas, compressed, ops uint64) {
+	limit := currentCacheMemoryLimit()
+	textures := limit / 5 * 4
+	// 10% of the textures' budget for compressed textures, the remainder for decompressed textures
+	compressed = textures / 10
+	return textures - compressed, compressed, limit - textures
+}
+
 // Statically check that 4 * texWidth is a multiple of 8
 const _ = -uint((4 * texWidth) % 8)
 
@@ -161,8 +192,8 @@ func (tex TextureStack) Realize(tm *TextureManager, tr *Trace) (bestReady chan s
 	return firstDone
 }
 
-func (tex TextureStack) Add(win *theme.Window, gtx layout.Context, tm *TextureManager, tr *Trace, ops *op.Ops, fudge float32) (best bool) {
-	trackHeight := float32(gtx.Dp(timelineTrackHeightDp))
+func (tex TextureStack) Add(win *theme.Window, gtx layout.Context, tm *TextureManager, tr *Trace, ops *op.Ops, height int, fudge float32) (best bool) {
+	trackHeight := float32(height)
 	for i, t := range tex.texs {
 		_, imgOp, ok := tm.Image(win, tr, t.tex)
 		if !ok {
@@ -532,13 +563,6 @@ func computeTexture(tex *texture) textureImage {
 		panic("got zero nsPerPx")
 	}
 
-	first := sort.Search(spans.Len(), func(i int) bool {
-		return spans.AtPtr(i).End >= start
-	})
-	last := sort.Search(spans.Len(), func(i int) bool {
-		return spans.AtPtr(i).Start >= end
-	})
-
 	var pixels [texWidth]pixel
 	addSample := func(bin int, w float64, v color.LinearSRGB) {
 		if w == 0 {
@@ -562,29 +586,25 @@ func computeTexture(tex *texture) textureImage {
 		px.sumWeight += w
 	}
 
-	for i := first; i < last; i++ {
-		span := spans.AtPtr(i)
-
-		firstBucket := float64(span.Start-start) / nsPerPx
-		lastBucket := float64(span.End-start) / nsPerPx
+	// addSpan adds a span, or a run of spans, that covers the fraction density of its extent. It returns false if the
+	// span starts after the end of the texture.
+	addSpan := func(spanStart, spanEnd exptrace.Time, density float64, c color.LinearSRGB) bool {
+		firstBucket := float64(spanStart-start) / nsPerPx
+		lastBucket := float64(spanEnd-start) / nsPerPx
 
 		if firstBucket >= texWidth {
-			break
+			return false
 		}
 		if lastBucket < 0 {
-			continue
+			return true
 		}
 
 		firstBucket = max(firstBucket, 0)
 		lastBucket = min(lastBucket, texWidth)
 
-		colorIdx := track.SpanColor(span, tr)
-		c := mappedColors[colorIdx]
-
 		if int(firstBucket) == int(lastBucket) {
 			// falls into a single bucket
-			w := float64(span.Duration()) / nsPerPx
-			addSample(int(firstBucket), w, c)
+			addSample(int(firstBucket), (lastBucket-firstBucket)*density, c)
 		} else {
 			// falls into at least two buckets
 
@@ -593,15 +613,61 @@ func computeTexture(tex *texture) textureImage {
 			_, frac = math.Modf(lastBucket)
 			w2 := frac
 
-			addSample(int(firstBucket), w1, c)
-			addSample(int(lastBucket), w2, c)
+			addSample(int(firstBucket), w1*density, c)
+			addSample(int(lastBucket), w2*density, c)
 		}
 
 		for i := int(firstBucket) + 1; i < int(lastBucket); i++ {
 			// All the full buckets between the first and last one
 			pixels[i] = pixel{
-				sum:       c,
-				sumWeight: 1,
+				sum: color.LinearSRGB{
+					R: c.R * float32(density),
+					G: c.G * float32(density),
+					B: c.B * float32(density),
+				},
+				sumWeight: density,
+			}
+		}
+		return true
+	}
+
+	var lvl *pyramidLevel
+	var lvlIdx int
+	if track.pyramid != nil && nsPerPx >= 1 {
+		lvl, lvlIdx = track.pyramid.level(int(math.Log2(nsPerPx)) - pyramidLevelSlack)
+	}
+	if lvl != nil {
+		// Use the pyramid's runs of spans instead of looking at every single span.
+		runs := lvl.runs
+		colors := track.pyramid.runColors(lvlIdx)
+		first := sort.Search(len(runs), func(i int) bool {
+			return runs[i].End >= start
+		})
+		last := sort.Search(len(runs), func(i int) bool {
+			return runs[i].Start >= end
+		})
+		for i := first; i < last; i++ {
+			run := &runs[i]
+			if run.End == run.Start {
+				continue
+			}
+			density := float64(run.covered) / float64(run.End-run.Start)
+			if !addSpan(run.Start, run.End, density, colors[i]) {
+				break
+			}
+		}
+	} else {
+		first := sort.Search(spans.Len(), func(i int) bool {
+			return spans.AtPtr(i).End >= start
+		})
+		last := sort.Search(spans.Len(), func(i int) bool {
+			return spans.AtPtr(i).Start >= end
+		})
+		for i := first; i < last; i++ {
+			span := spans.AtPtr(i)
+			c := mappedPaletteColor(track.SpanColor(span, tr))
+			if !addSpan(span.Start, span.End, 1, c) {
+				break
 			}
 		}
 	}
@@ -646,6 +712,41 @@ func computeTexture(tex *texture) textureImage {
 	}
 }
 
+// alignTextures computes the zoom level and time range of the textures that Render uses to cover the time range
+// [start, end] at nsPerPx. Textures start at start and are spaced step apart. If start >= end, no textures are needed.
+func alignTextures(spans Items[ptrace.Span], nsPerPx float64, start, end exptrace.Time) (_ float64, _, _, step exptrace.Time) {
+	// TODO does log2(nsPerPx) grow the way we expect? in particular, the more zoomed out we are, the longer we want to
+	// spend scaling the same zoom level.
+	//
+	// Round nsPerPx to the next lower power of 2. A smaller nsPerPx will render a texture that is more zoomed in (and
+	// thus detailed) than requested. This also means that the time range covered by the texture is smaller than
+	// requested, and we may need to use multiple textures to cover the whole range.
+	nsPerPx = math.Pow(2, math.Floor(math.Log2(nsPerPx)))
+	m := texWidth * nsPerPx
+	// Shift start point to the left to align with a multiple of texWidth * nsPerPx...
+	start = exptrace.Time(m * math.Floor(float64(start)/m))
+	// ... but don't set start point to before the track's start.
+	start = max(start, spans.AtPtr(0).Start)
+	// Don't set end beyond track's end. This doesn't have any effect on the computed texture, but limits how many
+	// textures we compute to cover the requested area.
+	end = min(end, spans.AtPtr(spans.Len()-1).End)
+
+	// texWidth is an integer pixel amount, and nsPerPx is rounded to a power of 2, ergo also integer.
+	step = exptrace.Time(texWidth * nsPerPx)
+	if step == 0 {
+		// For a texWidth, if the user zooms to log2(pxPerNs) < -log2(texWidth), step will truncate to zero. As long as
+		// texWidth >= 8192, this should be impossible, because Gio doesn't support clip areas larger than 8192, and
+		// nsPerPx has to be at least 1 / window_width.
+		if texWidth < 8192 {
+			panic("got zero step with a texWidth < 8192")
+		} else {
+			panic("got zero step despite a texWidth >= 8192")
+		}
+	}
+
+	return nsPerPx, start, end, step
+}
+
 // Render returns a series of textures that when placed next to each other will display spans for the time range [start,
 // end]. Each texture contains instructions for applying scaling and offsetting, and the series of textures may have
 // gaps, expecting the background to already look as desired.
@@ -710,22 +811,7 @@ func (r *Renderer) Render(
 
 	origStart := start
 	origNsPerPx := nsPerPx
-	// TODO does log2(nsPerPx) grow the way we expect? in particular, the more zoomed out we are, the longer we want to
-	// spend scaling the same zoom level.
-	//
-	// Round nsPerPx to the next lower power of 2. A smaller nsPerPx will render a texture that is more zoomed in (and
-	// thus detailed) than requested. This also means that the time range covered by the texture is smaller than
-	// requested, and we may need to use multiple textures to cover the whole range.
-	nsPerPx = math.Pow(2, math.Floor(math.Log2(nsPerPx)))
-	m := texWidth * nsPerPx
-	// Shift start point to the left to align with a multiple of texWidth * nsPerPx...
-	start = exptrace.Time(m * math.Floor(float64(start)/m))
-	// ... but don't set start point to before the track's start.
-	start = max(start, spans.AtPtr(0).Start)
-	// Don't set end beyond track's end. This doesn't have any effect on the computed texture, but limits how many
-	// textures we compute to cover the requested area.
-	end = min(end, spans.AtPtr(spans.Len()-1).End)
-
+	nsPerPx, start, end, step := alignTextures(spans, nsPerPx, start, end)
 	if start >= end {
 		// We don't need a texture for an empty time interval
 		if rtrace.IsEnabled() {
@@ -734,18 +820,6 @@ func (r *Renderer) Render(
 		return textureStacks, textures
 	}
 
-	// texWidth is an integer pixel amount, and nsPerPx is rounded to a power of 2, ergo also integer.
-	step := exptrace.Time(texWidth * nsPerPx)
-	if step == 0 {
-		// For a texWidth, if the user zooms to log2(pxPerNs) < -log2(texWidth), step will truncate to zero. As long as
-		// texWidth >= 8192, this should be impossible, because Gio doesn't support clip areas larger than 8192, and
-		// nsPerPx has to be at least 1 / window_width.
-		if texWidth < 8192 {
-			panic("got zero step with a texWidth < 8192")
-		} else {
-			panic("got zero step despite a texWidth >= 8192")
-		}
-	}
 	texs := r.texsOut
 	for start := start; start < end; start += step {
 		texs = r.planTextures(win, track, spans, start, nsPerPx, texs[:0])
@@ -772,6 +846,60 @@ func (r *Renderer) Render(
 	return textureStacks, textures
 }
 
+// Prefetch starts computing the textures immediately to the left and right of the ones that Render returns for the time
+// range [start, end], so that they are likely to be ready by the time the user pans to them.
+func (r *Renderer) Prefetch(
+	win *theme.Window,
+	tm *TextureManager,
+	tr *Trace,
+	track *Track,
+	spans Items[ptrace.Span],
+	nsPerPx float64,
+	start exptrace.Time,
+	end exptrace.Time,
+) {
+	defer rtrace.StartRegion(context.Background(), "main.Renderer.Prefetch").End()
+
+	if spans.Len() == 0 || spans.AtPtr(0).State == statePlaceholder {
+		return
+	}
+
+	nsPerPx, start, end, step := alignTextures(spans, nsPerPx, start, end)
+	if start >= end {
+		return
+	}
+
+	trackStart := spans.AtPtr(0).Start
+	trackEnd := spans.AtPtr(spans.Len() - 1).End
+	// Render covers [start, end] with textures starting at start + n * step. The next texture to the right is the first
+	// one that starts at or after end.
+	var prefetch [2]exptrace.Time
+	n := 0
+	if start > trackStart {
+		prefetch[n] = max(start-step, trackStart)
+		n++
+	}
+	if next := start + (end-start+step-1)/step*step; next < trackEnd {
+		prefetch[n] = next
+		n++
+	}
+
+	for _, pstart := range prefetch[:n] {
+		texs := r.planTextures(win, track, spans, pstart, nsPerPx, r.texsOut[:0])
+		// planTextures returns a new texture as the first element if it needs to be computed. Otherwise, the first
+		// element is an existing texture that is either already computed, or in the process of being computed.
+		if tex := texs[0]; tex.computed.done == nil && !tex.ephemeral {
+			if rtrace.IsEnabled() {
+				rtrace.Logf(context.Background(), "texture renderer", "prefetching texture at %d ns @ %f ns/px", tex.start, tex.nsPerPx)
+			}
+			tex.lastUse = win.Frame
+			tm.Realize(tex, tr)
+		}
+		clear(texs)
+		r.texsOut = texs[:0]
+	}
+}
+
 type TextureManager struct {
 	Stats RendererStatistics
 
@@ -844,6 +972,33 @@ func (tm *TextureManager) unrealize(texs []*texture) {
 	tm.Stats.RealizedRGBAs.Add(uint64(-len(texs)))
 }
 
+// Invalidate discards the contents of textures so that they get computed again the next time they're used. This is
+// needed when the colors of spans change. Textures that are still being computed or realized are left alone.
+func (tm *TextureManager) Invalidate(texs []*texture) {
+	var rgbas, compressed []*texture
+	for _, tex := range texs {
+		if (tex.computed.done != nil && !TryRecv(tex.computed.done)) ||
+			(tex.realized.done != nil && !TryRecv(tex.realized.done)) {
+			continue
+		}
+		if tex.realized.image.pix != nil {
+			rgbas = append(rgbas, tex)
+		} else {
+			if tex.realized.done != nil && tex.computed.uniform != nil && !tex.ephemeral {
+				tm.Stats.RealizedUniforms.Add(^uint64(0))
+			}
+			tex.realized = textureRealized{}
+		}
+		if tex.computed.compressed != nil {
+			compressed = append(compressed, tex)
+		} else {
+			tex.computed = textureComputed{}
+		}
+	}
+	tm.unrealize(rgbas)
+	tm.uncompute(compressed)
+}
+
 func (tm *TextureManager) Realize(tex *texture, tr *Trace) (done chan struct{}) {
 	defer rtrace.StartRegion(context.Background(), "main.TextureManager.realize").End()
 	if tex.realized.done != nil {
@@ -1098,6 +1253,8 @@ func (tm *TextureManager) Compact() {
 		sizeRGBAs      = uint64(numRGBAs) * 4 * texWidth
 		sizeCompressed = tm.Stats.CompressedSize.Load()
 
+		maxRGBAMemoryUsage, maxCompressedMemoryUsage, _ = cacheMemoryLimits()
+
 		// The following variables are used for debug logging
 		t                     time.Time
 		deletedCompressedSize int
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	rtrace "runtime/trace"
+	"slices"
+	"time"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/trace/ptrace"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/op/clip"
+	"gioui.org/text"
+	exptrace "golang.org/x/exp/trace"
+)
+
+// The number of buckets in a thread's activity sparkline.
+const threadActivityBuckets = 100
+
+type threadStats struct {
+	M *ptrace.Machine
+	// Time spent running goroutines and time spent blocked in syscalls.
+	Running, Syscall time.Duration
+	// Activity is the fraction of each of threadActivityBuckets buckets that the thread was running goroutines or
+	// was blocked in syscalls.
+	Activity []float64
+}
+
+type threadReport struct {
+	// Count is the number of threads over time, and Seen the cumulative number of threads that have been seen.
+	Count, Seen widget.LineChartSeries
+	Threads     []threadStats
+}
+
+type threadReportKey struct{}
+
+func computeThreadReport(tr *Trace) *threadReport {
+	defer rtrace.StartRegion(context.Background(), "main.computeThreadReport").End()
+
+	type change struct {
+		t     exptrace.Time
+		delta int
+	}
+	changes := make([]change, 0, 2*len(tr.Machines))
+	for _, m := range tr.Machines {
+		changes = append(changes, change{m.Start, 1}, change{m.End, -1})
+	}
+	slices.SortStableFunc(changes, func(a, b change) int {
+		return cmp(a.t, b.t, false)
+	})
+
+	report := &threadReport{
+		Count: widget.LineChartSeries{Name: "Threads", Step: true},
+		Seen:  widget.LineChartSeries{Name: "Threads seen", Step: true},
+	}
+	var n, seen int
+	for _, c := range changes {
+		n += c.delta
+		if c.delta > 0 {
+			seen++
+		}
+		if k := len(report.Count.Points); k > 0 && report.Count.Points[k-1].X == float64(c.t) {
+			report.Count.Points[k-1].Y = float64(n)
+			report.Seen.Points[k-1].Y = float64(seen)
+		} else {
+			report.Count.Points = append(report.Count.Points, widget.LineChartPoint{X: float64(c.t), Y: float64(n)})
+			report.Seen.Points = append(report.Seen.Points, widget.LineChartPoint{X: float64(c.t), Y: float64(seen)})
+		}
+	}
+
+	start, end := tr.Start(), tr.End()
+	width := float64(end-start) / threadActivityBuckets
+	report.Threads = make([]threadStats, len(tr.Machines))
+	for i, m := range tr.Machines {
+		ts := threadStats{
+			M:        m,
+			Activity: make([]float64, threadActivityBuckets),
+		}
+		for _, s := range m.Goroutines {
+			switch s.State {
+			case ptrace.StateActive:
+				ts.Running += s.Duration()
+			case ptrace.StateBlockedSyscall:
+				ts.Syscall += s.Duration()
+			}
+
+			a := float64(s.Start - start)
+			z := float64(s.End - start)
+			for i := int(a / width); i < threadActivityBuckets && float64(i)*width < z; i++ {
+				lo := max(a, float64(i)*width)
+				hi := min(z, float64(i+1)*width)
+				if hi > lo {
+					ts.Activity[i] += (hi - lo) / width
+				}
+			}
+		}
+		report.Threads[i] = ts
+	}
+	return report
+}
+
+// ThreadsComponent displays the number of OS threads over time and, optionally, what each thread was doing. A growing
+// number of threads usually means that goroutines are blocking threads in cgo calls or syscalls.
+type ThreadsComponent struct {
+	trace  *Trace
+	report *theme.Future[*threadReport]
+
+	chart       theme.LineChartState
+	initialized bool
+
+	showThreads   widget.Bool
+	split         theme.SplitterState
+	table         *theme.Table
+	scrollState   theme.YScrollableListState
+	cellFormatter CellFormatter
+}
+
+func NewThreadsComponent(win *theme.Window, tr *Trace) *ThreadsComponent {
+	return &ThreadsComponent{
+		trace: tr,
+		report: theme.Compute(&tr.analyses, win, threadReportKey{}, func() *threadReport {
+			return computeThreadReport(tr)
+		}),
+		split: theme.SplitterState{
+			Axis:  layout.Vertical,
+			Ratio: 0.5,
+		},
+	}
+}
+
+// Title implements theme.Component.
+func (*ThreadsComponent) Title() string {
+	return tr("OS threads")
+}
+
+// Transition implements theme.Component.
+func (*ThreadsComponent) Transition(state theme.ComponentState) {}
+
+// WantsTransition implements theme.Component.
+func (*ThreadsComponent) WantsTransition(gtx layout.Context) theme.ComponentState {
+	return theme.ComponentStateNone
+}
+
+func (tc *ThreadsComponent) HoveredLink() ObjectLink {
+	return tc.cellFormatter.HoveredLink()
+}
+
+func (tc *ThreadsComponent) initTable(win *theme.Window, gtx layout.Context) {
+	if tc.table != nil {
+		return
+	}
+	tc.table = &theme.Table{}
+	tc.table.SetColumns(win, gtx, []theme.Column{
+		{Name: "Thread", Alignment: text.End},
+		{Name: "First seen", Alignment: text.End},
+		{Name: "Last seen", Alignment: text.End},
+		{Name: "Running", Alignment: text.End},
+		{Name: "In syscalls", Alignment: text.End},
+		{Name: "Activity", Alignment: text.Start},
+	})
+}
+
+// Layout implements theme.Component.
+func (tc *ThreadsComponent) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.ThreadsComponent.Layout").End()
+
+	report, ok := tc.report.Result()
+	if !ok {
+		return theme.Loading(win.Theme, tr("Computing thread statistics…")).Layout(win, gtx)
+	}
+	if !tc.initialized {
+		tc.initialized = true
+		tc.chart.SetSeries([]widget.LineChartSeries{report.Count, report.Seen})
+	}
+	if x, ok := tc.chart.Clicked(); ok {
+		win.EmitAction(ShowTimestampAction(exptrace.Time(x)))
+	}
+	tc.showThreads.Update(gtx)
+	tc.initTable(win, gtx)
+	tc.table.Update(gtx)
+	tc.cellFormatter.Update(win, gtx)
+
+	chart := func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+		lc := theme.LineChart(win.Theme, &tc.chart)
+		lc.XLabel = "Time"
+		lc.YLabel = "Threads"
+		lc.FormatX = func(v float64) string {
+			return formatTimestamp(nil, tc.trace.AdjustedTime(exptrace.Time(v)))
+		}
+		lc.FormatY = func(v float64) string {
+			return local.Sprintf("%.0f", v)
+		}
+		return lc.Layout(win, gtx)
+	}
+
+	cellFn := func(win *theme.Window, gtx layout.Context, row, col int) layout.Dimensions {
+		defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+
+		ts := &report.Threads[row]
+		switch colName := tc.table.Columns[col].Name; colName {
+		case "Thread":
+			return layout.RightAligned(gtx, func(gtx layout.Context) layout.Dimensions {
+				return tc.cellFormatter.Text(win, gtx, local.Sprintf("m%d", ts.M.ID))
+			})
+		case "First seen":
+			return tc.cellFormatter.Timestamp(win, gtx, tc.trace, ts.M.Start, "")
+		case "Last seen":
+			return tc.cellFormatter.Timestamp(win, gtx, tc.trace, ts.M.End, "")
+		case "Running":
+			return tc.cellFormatter.Duration(win, gtx, ts.Running, false)
+		case "In syscalls":
+			return tc.cellFormatter.Duration(win, gtx, ts.Syscall, false)
+		case "Activity":
+			sl := theme.Sparkline(win.Theme, ts.Activity)
+			sl.Kind = theme.SparklineBar
+			sl.Max = 1
+			return sl.Layout(win, gtx)
+		default:
+			panic(colName)
+		}
+	}
+	threads := func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+		return theme.SimpleTable(win, gtx, tc.table, &tc.scrollState, len(report.Threads), cellFn)
+	}
+
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(theme.Dumb(win, theme.CheckBox(win.Theme, &tc.showThreads, tr("Show activity of individual threads")).Layout)),
+		layout.Rigid(layout.Spacer{Height: 5}.Layout),
+		layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+			if !tc.showThreads.Value {
+				return chart(win, gtx)
+			}
+			return theme.Splitter(win.Theme, &tc.split).Layout(win, gtx, chart, threads)
+		}),
+	)
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"image"
+	rtrace "runtime/trace"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/widget"
+)
+
+const maxTimestampDecimals = 9
+
+// TimeFormatConfig configures how durations and timestamps are displayed, in tooltips, tables, axes and copied
+// descriptions alike.
+type TimeFormatConfig struct {
+	// ExactDurations displays durations with nanosecond precision instead of rounding them to three significant
+	// digits.
+	ExactDurations bool `json:"exact_durations,omitempty"`
+	// AbsoluteTimestamps displays timestamps as they were recorded in the trace instead of as offsets from the start
+	// of the trace.
+	AbsoluteTimestamps bool `json:"absolute_timestamps,omitempty"`
+	// TimestampDecimals, if non-zero, displays timestamps in seconds with this many decimal places instead of in
+	// nanoseconds.
+	TimestampDecimals int `json:"timestamp_decimals,omitempty"`
+}
+
+// timeFormatConfig is the time format configuration in effect. It is shared by all main windows.
+var timeFormatConfig atomic.Pointer[TimeFormatConfig]
+
+// currentTimeFormatConfig returns the time format configuration in effect.
+func currentTimeFormatConfig() TimeFormatConfig {
+	var cfg TimeFormatConfig
+	if p := timeFormatConfig.Load(); p != nil {
+		cfg = *p
+	}
+	cfg.TimestampDecimals = min(max(cfg.TimestampDecimals, 0), maxTimestampDecimals)
+	return cfg
+}
+
+// formatSeconds formats a timestamp in nanoseconds as seconds with the given number of decimal places, truncating
+// the remaining digits.
+func formatSeconds(ts AdjustedTime, decimals int) string {
+	var sign string
+	if ts < 0 {
+		sign = "-"
+		ts = -ts
+	}
+	secs, frac := int64(ts)/1e9, int64(ts)%1e9
+	for range maxTimestampDecimals - decimals {
+		frac /= 10
+	}
+	// Format the fraction without the printer so that its digits don't get grouped.
+	return fmt.Sprintf("%s%s.%0*d s", sign, local.Sprintf("%d", secs), decimals, frac)
+}
+
+// TimeFormatDialog lets the user configure how durations and timestamps are displayed.
+type TimeFormatDialog struct {
+	exactDurations     widget.Bool
+	absoluteTimestamps widget.Bool
+	decimals           widget.Editor
+
+	apply widget.PrimaryClickable
+	reset widget.PrimaryClickable
+	err   string
+}
+
+func NewTimeFormatDialog() *TimeFormatDialog {
+	td := &TimeFormatDialog{}
+	td.decimals.SingleLine = true
+	td.set(currentTimeFormatConfig())
+	return td
+}
+
+func (td *TimeFormatDialog) set(cfg TimeFormatConfig) {
+	td.exactDurations.Value = cfg.ExactDurations
+	td.absoluteTimestamps.Value = cfg.AbsoluteTimestamps
+	td.decimals.SetText(strconv.Itoa(cfg.TimestampDecimals))
+}
+
+// config returns the configuration described by the dialog's inputs.
+func (td *TimeFormatDialog) config() (TimeFormatConfig, error) {
+	decimals, err := strconv.Atoi(strings.TrimSpace(td.decimals.Text()))
+	if err != nil || decimals < 0 || decimals > maxTimestampDecimals {
+		return TimeFormatConfig{}, fmt.Errorf("decimal places must be a whole number between 0 and %d", maxTimestampDecimals)
+	}
+	return TimeFormatConfig{
+		ExactDurations:     td.exactDurations.Value,
+		AbsoluteTimestamps: td.absoluteTimestamps.Value,
+		TimestampDecimals:  decimals,
+	}, nil
+}
+
+func (td *TimeFormatDialog) save(win *theme.Window, cfg TimeFormatConfig) {
+	td.err = ""
+	userConfigMu.Lock()
+	defer userConfigMu.Unlock()
+	if cfg == (TimeFormatConfig{}) {
+		userConfig.TimeFormat = nil
+	} else {
+		userConfig.TimeFormat = &cfg
+	}
+	timeFormatConfig.Store(userConfig.TimeFormat)
+	if err := userConfig.Save(); err != nil {
+		win.Notify(theme.NotificationError, local.Sprintf("Couldn't save configuration: %s", err))
+	}
+}
+
+func (td *TimeFormatDialog) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.TimeFormatDialog.Layout").End()
+
+	for td.apply.Clicked(gtx) {
+		if cfg, err := td.config(); err != nil {
+			td.err = err.Error()
+		} else {
+			td.save(win, cfg)
+		}
+	}
+	for td.reset.Clicked(gtx) {
+		td.set(TimeFormatConfig{})
+		td.save(win, TimeFormatConfig{})
+	}
+
+	return layout.Rigids(gtx, layout.Vertical,
+		theme.Dumb(win, theme.CheckBox(win.Theme, &td.exactDurations, tr("Display durations with full precision instead of rounding them")).Layout),
+		layout.Spacer{Height: 5}.Layout,
+		theme.Dumb(win, theme.CheckBox(win.Theme, &td.absoluteTimestamps, tr("Display timestamps as recorded instead of relative to the start of the trace")).Layout),
+		layout.Spacer{Height: 10}.Layout,
+		func(gtx layout.Context) layout.Dimensions {
+			return theme.LineLabel(win.Theme, local.Sprintf("Decimal places of timestamps in seconds, between 1 and %d, or 0 for nanoseconds", maxTimestampDecimals)).Layout(win, gtx)
+		},
+		func(gtx layout.Context) layout.Dimensions {
+			return theme.TextBox(win.Theme, &td.decimals, "0").Layout(win, gtx)
+		},
+		layout.Spacer{Height: 5}.Layout,
+		func(gtx layout.Context) layout.Dimensions {
+			return theme.LineLabel(win.Theme, tr("Panels that are already open keep some of their text until they are reopened.")).Layout(win, gtx)
+		},
+		layout.Spacer{Height: 10}.Layout,
+		func(gtx layout.Context) layout.Dimensions {
+			return layout.Rigids(gtx, layout.Horizontal,
+				func(gtx layout.Context) layout.Dimensions {
+					return theme.Button(win.Theme, &td.apply.Clickable, tr("Apply")).Layout(win, gtx)
+				},
+				layout.Spacer{Width: 5}.Layout,
+				func(gtx layout.Context) layout.Dimensions {
+					return theme.Button(win.Theme, &td.reset.Clickable, tr("Reset to defaults")).Layout(win, gtx)
+				},
+			)
+		},
+		func(gtx layout.Context) layout.Dimensions {
+			if td.err == "" {
+				return layout.Dimensions{}
+			}
+			l := theme.Label(win.Theme, td.err)
+			l.Color = colors[colorStateBlocked]
+			return l.Layout(win, gtx)
+		},
+	)
+}
+
+func displayTimeFormatDialog(win *theme.Window) {
+	td := NewTimeFormatDialog()
+	win.SetModal(func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+		return theme.Dialog(win.Theme, tr("Time format")).Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+			gtx.Constraints.Min = gtx.Constraints.Constrain(image.Pt(650, 280))
+			gtx.Constraints.Max = gtx.Constraints.Min
+			return td.Layout(win, gtx)
+		})
+	})
+}
@@ -40,6 +40,10 @@ const (
 	timelineTrackHeightDp     unit.Dp = 16
 	timelineGapDp             unit.Dp = 5 - timelineMinitrackGapDp
 	timelineTrackGapDp        unit.Dp = timelineMinitrackHeightDp
+
+	// The range of heights that the user can resize the main tracks of a timeline to.
+	timelineMinTrackHeightDp unit.Dp = 4
+	timelineMaxTrackHeightDp unit.Dp = 160
 )
 
 const statePlaceholder = ptrace.StateLast + 1
@@ -51,6 +55,7 @@ const (
 	TrackKindStack
 	TrackKindUserRegions
 	TrackKindTask
+	TrackKindExternal
 )
 
 type Timeline struct {
@@ -64,8 +69,26 @@ type Timeline struct {
 	// Set to true by Timeline.Layout. This is used to track which timelines have been shown during a frame.
 	displayed bool
 	cv        *Canvas
+	// The height of the timeline's main tracks as chosen by the user by resizing the timeline, or zero to use the
+	// default height.
+	trackHeight unit.Dp
+	// Hidden timelines have no height and aren't displayed.
+	hidden bool
 
 	widget *TimelineWidget
+
+	// Index of the spans of all displayed tracks, built on demand by spanIndex and dropped by dropSpanIndex.
+	spanIdx struct {
+		tree *theme.Future[*container.IntervalTree[exptrace.Time, trackSpanRef]]
+		// Whether stack tracks were displayed when the index was built.
+		stackTracks bool
+	}
+}
+
+// trackSpanRef refers to a span in one of a timeline's tracks.
+type trackSpanRef struct {
+	track *Track
+	idx   int
 }
 
 type TimelineWidget struct {
@@ -75,6 +98,13 @@ type TimelineWidget struct {
 
 	hover gesture.Hover
 
+	// The handle at the bottom of the timeline for resizing it.
+	resizeDrag  gesture.Drag
+	resizeClick gesture.Click
+	// The pointer position and track height at the start of a resize.
+	resizeStartY      float32
+	resizeStartHeight unit.Dp
+
 	// OPT(dh): Only one timeline can have hovered or activated spans, so we could track this directly in Canvas, and
 	// save 48 bytes per timeline (which means per goroutine). However, the current API is cleaner, because
 	// TimelineWidget doesn't have to mutate Timeline's state.
@@ -114,6 +144,9 @@ type Track struct {
 
 	rnd    Renderer
 	widget *TrackWidget
+	// pyramid holds precomputed runs of spans used when computing zoomed out textures. It is only built for tracks
+	// with many spans whose spans are known when loading the trace, and is nil otherwise.
+	pyramid *spanPyramid
 }
 
 func (tl *Timeline) ensureTrackWidgets() {
@@ -126,11 +159,7 @@ func (tl *Timeline) ensureTrackWidgets() {
 	// timelines.
 	for i := range tl.tracks {
 		tl.tracks[i].widget = tl.cv.trackWidgetsCache.Get()
-		*tl.tracks[i].widget = TrackWidget{
-			eventsMt:  MiniTrack[eventWithGetters, *eventWithGetters]{MiniTrackBehavior: mtrackEvents},
-			tinyMt:    MiniTrack[spanWithGetters, *spanWithGetters]{MiniTrackBehavior: mtrackTiny},
-			samplesMt: MiniTrack[eventWithGetters, *eventWithGetters]{MiniTrackBehavior: mtrackSamples},
-		}
+		tl.tracks[i].widget.reset()
 	}
 	if tl.buildTrackWidgets != nil {
 		tl.buildTrackWidgets(tl.tracks)
@@ -138,6 +167,11 @@ func (tl *Timeline) ensureTrackWidgets() {
 }
 
 func (track *Track) SpanColor(span *ptrace.Span, tr *Trace) colorIndex {
+	if rs := activeColorRules.Load(); rs != nil {
+		if c, ok := rs.match(track, span, tr); ok {
+			return c
+		}
+	}
 	if track.spanColor != nil {
 		return track.spanColor(span, tr)
 	} else {
@@ -164,7 +198,7 @@ func (tr *Track) Spans(win *theme.Window) *theme.Future[Items[ptrace.Span]] {
 
 func newZoomMenuItem(cv *Canvas, spans Items[ptrace.Span]) *theme.MenuItem {
 	return &theme.MenuItem{
-		Label:    PlainLabel("Zoom"),
+		Label:    PlainLabel(tr("Zoom")),
 		Shortcut: key.ModShortcut.String() + "+LMB",
 		Action: func() theme.Action {
 			return theme.ExecuteAction(func(gtx layout.Context) {
@@ -178,7 +212,7 @@ func newZoomMenuItem(cv *Canvas, spans Items[ptrace.Span]) *theme.MenuItem {
 
 func newOpenSpansMenuItem(spans Items[ptrace.Span]) *theme.MenuItem {
 	return &theme.MenuItem{
-		Label: PlainLabel("Show span info"),
+		Label: PlainLabel(tr("Show span info")),
 		Action: func() theme.Action {
 			return &OpenSpansAction{
 				Spans: spans,
@@ -187,6 +221,132 @@ func newOpenSpansMenuItem(spans Items[ptrace.Span]) *theme.MenuItem {
 	}
 }
 
+// Merged spans are shaded according to the number of spans per pixel they contain, making dense regions stand out
+// without having to draw each of their spans. densityThresholds are the minimum number of spans per pixel for each
+// level of shading.
+var densityThresholds = [...]float32{1, 10, 100, 1000}
+
+// densityLevel returns the level of shading to use for n spans displayed across width pixels, or -1 if the spans
+// shouldn't be shaded.
+func densityLevel(n int, width float32) int {
+	density := float32(n) / width
+	level := -1
+	for i, t := range densityThresholds {
+		if density < t {
+			break
+		}
+		level = i
+	}
+	return level
+}
+
+// addHatch adds the shapes of pattern, clipped to the rectangle [minP, maxP], to p. origin is the x coordinate that the
+// pattern is anchored at, so that it moves along with the span when panning. Lines are spacing pixels apart and width
+// pixels wide. All shapes have the same winding, so that overlapping shapes don't cancel each other out.
+func addHatch(p *clip.Path, pattern hatchPattern, origin float32, minP, maxP f32.Point, spacing, width float32) {
+	h := maxP.Y - minP.Y
+	// first returns the first multiple of spacing, offset by origin, that is at least x.
+	first := func(x float32) float32 {
+		return origin + float32(math.Floor(float64((x-origin)/spacing)))*spacing
+	}
+	rect := func(lo, hi f32.Point) {
+		lo.X, lo.Y = max(lo.X, minP.X), max(lo.Y, minP.Y)
+		hi.X, hi.Y = min(hi.X, maxP.X), min(hi.Y, maxP.Y)
+		if lo.X >= hi.X || lo.Y >= hi.Y {
+			return
+		}
+		p.MoveTo(lo)
+		p.LineTo(f32.Pt(hi.X, lo.Y))
+		p.LineTo(hi)
+		p.LineTo(f32.Pt(lo.X, hi.Y))
+		p.Close()
+	}
+
+	switch pattern {
+	case hatchNone:
+	case hatchDiagonal, hatchBackDiagonal, hatchCross:
+		if pattern != hatchBackDiagonal {
+			for x := first(minP.X - h - width); x < maxP.X; x += spacing {
+				addClippedPolygon(p, [4]f32.Point{
+					{X: x + h, Y: minP.Y},
+					{X: x + h + width, Y: minP.Y},
+					{X: x + width, Y: maxP.Y},
+					{X: x, Y: maxP.Y},
+				}, minP, maxP)
+			}
+		}
+		if pattern != hatchDiagonal {
+			for x := first(minP.X - h - width); x < maxP.X; x += spacing {
+				addClippedPolygon(p, [4]f32.Point{
+					{X: x, Y: minP.Y},
+					{X: x + width, Y: minP.Y},
+					{X: x + h + width, Y: maxP.Y},
+					{X: x + h, Y: maxP.Y},
+				}, minP, maxP)
+			}
+		}
+	case hatchVertical:
+		for x := first(minP.X - width); x < maxP.X; x += spacing {
+			rect(f32.Pt(x, minP.Y), f32.Pt(x+width, maxP.Y))
+		}
+	case hatchHorizontal:
+		for y := minP.Y + spacing/2; y < maxP.Y; y += spacing {
+			rect(f32.Pt(minP.X, y), f32.Pt(maxP.X, y+width))
+		}
+	case hatchDots:
+		for y := minP.Y + spacing/2; y < maxP.Y; y += spacing {
+			for x := first(minP.X - 2*width); x < maxP.X; x += spacing {
+				rect(f32.Pt(x, y-width), f32.Pt(x+2*width, y+width))
+			}
+		}
+	default:
+		panic(fmt.Sprintf("unhandled hatch pattern %d", pattern))
+	}
+}
+
+// addClippedPolygon adds the convex polygon pts, clipped to the rectangle [minP, maxP], to p.
+func addClippedPolygon(p *clip.Path, pts [4]f32.Point, minP, maxP f32.Point) {
+	// Clipping a quadrilateral against four edges adds at most one vertex per edge.
+	var bufs [2][8]f32.Point
+	in := append(bufs[0][:0], pts[:]...)
+	clipEdge := func(in, out []f32.Point, inside func(f32.Point) bool, intersect func(a, b f32.Point) f32.Point) []f32.Point {
+		for i, cur := range in {
+			prev := in[(i+len(in)-1)%len(in)]
+			switch {
+			case inside(cur) && inside(prev):
+				out = append(out, cur)
+			case inside(cur):
+				out = append(out, intersect(prev, cur), cur)
+			case inside(prev):
+				out = append(out, intersect(prev, cur))
+			}
+		}
+		return out
+	}
+	atX := func(x float32) func(a, b f32.Point) f32.Point {
+		return func(a, b f32.Point) f32.Point {
+			return f32.Pt(x, a.Y+(b.Y-a.Y)*(x-a.X)/(b.X-a.X))
+		}
+	}
+	atY := func(y float32) func(a, b f32.Point) f32.Point {
+		return func(a, b f32.Point) f32.Point {
+			return f32.Pt(a.X+(b.X-a.X)*(y-a.Y)/(b.Y-a.Y), y)
+		}
+	}
+	out := clipEdge(in, bufs[1][:0], func(pt f32.Point) bool { return pt.X >= minP.X }, atX(minP.X))
+	out = clipEdge(out, bufs[0][:0], func(pt f32.Point) bool { return pt.X <= maxP.X }, atX(maxP.X))
+	out = clipEdge(out, bufs[1][:0], func(pt f32.Point) bool { return pt.Y >= minP.Y }, atY(minP.Y))
+	out = clipEdge(out, bufs[0][:0], func(pt f32.Point) bool { return pt.Y <= maxP.Y }, atY(maxP.Y))
+	if len(out) < 3 {
+		return
+	}
+	p.MoveTo(out[0])
+	for _, pt := range out[1:] {
+		p.LineTo(pt)
+	}
+	p.Close()
+}
+
 type TrackWidget struct {
 	// OPT(dh): Only one track can have hovered or activated spans, so we could track this directly in TimelineWidget,
 	// and save 48 bytes per track. However, the current API is cleaner, because TimelineWidgetTrack doesn't have to
@@ -199,6 +359,9 @@ type TrackWidget struct {
 
 	outlinesOps mem.ReusableOps
 	labelsOps   mem.ReusableOps
+	densityOps  [len(densityThresholds)]mem.ReusableOps
+	// The hatching of spans in the high-contrast palette, in dark and in light ink.
+	hatchOps [2]mem.ReusableOps
 
 	hover gesture.Hover
 	click gesture.Click
@@ -211,23 +374,89 @@ type TrackWidget struct {
 	scratchTextureStacks []TextureStack
 	scratchTextures      []Texture
 
+	// The recorded ops of the main track, replayed as long as nothing affecting its appearance changes.
+	cache theme.Cache
+
 	// cached state
 	prevFrame struct {
 		hovered          bool
-		constraints      layout.Constraints
-		ops              mem.ReusableOps
-		call             op.CallOp
-		dims             layout.Dimensions
 		placeholder      bool
 		lowQualityRender bool
 
-		dspSpans []struct {
-			dspSpans       Items[ptrace.Span]
-			startPx, endPx float32
+		dspSpans []displayedSpans
+		// dspSpansGen is incremented whenever dspSpans is recomputed, so that mini tracks know to lay out again.
+		dspSpansGen uint64
+	}
+
+	// Spans merged ahead of layout by Canvas.mergeTracks. They are only valid for the frame they were computed in.
+	merged struct {
+		frame uint64
+		spans []displayedSpans
+	}
+}
+
+// displayedSpans are one or more spans that are displayed as a single span, because they are too small to be
+// displayed individually.
+type displayedSpans struct {
+	dspSpans       Items[ptrace.Span]
+	startPx, endPx float32
+}
+
+// reset prepares the widget for use by a track. It keeps the widget's op buffers, which have likely already grown to
+// the size needed by any track.
+func (tw *TrackWidget) reset() {
+	tw.caches((*theme.Cache).Invalidate)
+	*tw = TrackWidget{
+		outlinesOps: tw.outlinesOps,
+		labelsOps:   tw.labelsOps,
+		densityOps:  tw.densityOps,
+		hatchOps:    tw.hatchOps,
+		cache:       tw.cache,
+		eventsMt:    MiniTrack[eventWithGetters, *eventWithGetters]{MiniTrackBehavior: mtrackEvents, ops: tw.eventsMt.ops, cache: tw.eventsMt.cache},
+		tinyMt:      MiniTrack[spanWithGetters, *spanWithGetters]{MiniTrackBehavior: mtrackTiny, ops: tw.tinyMt.ops, cache: tw.tinyMt.cache},
+		samplesMt:   MiniTrack[eventWithGetters, *eventWithGetters]{MiniTrackBehavior: mtrackSamples, ops: tw.samplesMt.ops, cache: tw.samplesMt.cache},
+	}
+}
+
+// caches calls fn for the caches of the main track and of each mini track.
+func (tw *TrackWidget) caches(fn func(c *theme.Cache)) {
+	fn(&tw.cache)
+	fn(&tw.tinyMt.cache)
+	fn(&tw.eventsMt.cache)
+	fn(&tw.samplesMt.cache)
+}
+
+// opsBuffers calls fn for each of the widget's op buffers, not including those of its caches.
+func (tw *TrackWidget) opsBuffers(fn func(rops *mem.ReusableOps)) {
+	fn(&tw.outlinesOps)
+	fn(&tw.labelsOps)
+	for i := range tw.densityOps {
+		fn(&tw.densityOps[i])
+	}
+	for i := range tw.hatchOps {
+		fn(&tw.hatchOps[i])
+	}
+	for _, mt := range [...]*[colorLast]mem.ReusableOps{&tw.eventsMt.ops, &tw.tinyMt.ops, &tw.samplesMt.ops} {
+		for i := range mt {
+			fn(&mt[i])
 		}
 	}
 }
 
+// opsSize returns the memory used by the widget's op buffers.
+func (tw *TrackWidget) opsSize() int {
+	var n int
+	tw.opsBuffers(func(rops *mem.ReusableOps) { n += rops.Size() })
+	tw.caches(func(c *theme.Cache) { n += c.Size() })
+	return n
+}
+
+// releaseOps frees the widget's op buffers. It must only be called on widgets that aren't in use by a track.
+func (tw *TrackWidget) releaseOps() {
+	tw.opsBuffers((*mem.ReusableOps).Release)
+	tw.caches((*theme.Cache).Release)
+}
+
 func (track *TrackWidget) ClickedSpans() Items[ptrace.Span] {
 	return track.clickedSpans
 }
@@ -262,7 +491,38 @@ func (tw *TimelineWidget) LabelRightClicked() bool {
 	}
 }
 
+// baseTrackHeight returns the height of the timeline's main tracks, ignoring compact display.
+func (tl *Timeline) baseTrackHeight() unit.Dp {
+	if tl.trackHeight != 0 {
+		return tl.trackHeight
+	}
+	return timelineTrackHeightDp
+}
+
+// mainTrackHeight returns the height in pixels of the timeline's main tracks. Compact display halves the height.
+func (tl *Timeline) mainTrackHeight(gtx layout.Context) int {
+	h := tl.baseTrackHeight()
+	if tl.cv.timeline.compact {
+		h /= 2
+	}
+	return max(gtx.Dp(h), 1)
+}
+
+// SetTrackHeight sets the height of the timeline's main tracks. Zero restores the default height.
+func (tl *Timeline) SetTrackHeight(h unit.Dp) {
+	if h != 0 {
+		h = min(max(h, timelineMinTrackHeightDp), timelineMaxTrackHeightDp)
+	}
+	if h != tl.trackHeight {
+		tl.trackHeight = h
+		tl.cv.timeline.heightsGen++
+	}
+}
+
 func (tl *Timeline) Height(gtx layout.Context, cv *Canvas) int {
+	if tl.hidden {
+		return 0
+	}
 	var height int
 	enabledTracks := 0
 	for _, track := range tl.tracks {
@@ -299,10 +559,93 @@ func (tl *Timeline) notifyHidden(cv *Canvas) {
 			track.spans = nil
 		}
 	}
+	// The index refers to spans that we may have just dropped.
+	tl.dropSpanIndex()
 	cv.timelineWidgetsCache.Put(tl.widget)
 	tl.widget = nil
 }
 
+// dropSpanIndex discards the index of the timeline's spans. It has to be called whenever the timeline's tracks or
+// their spans change. The index will be rebuilt when it is next needed.
+func (tl *Timeline) dropSpanIndex() {
+	tl.spanIdx.tree = nil
+}
+
+// spanIndex returns an index of the spans in all of the timeline's displayed tracks. The index is built in the
+// background, and spanIndex returns false until it is ready or if the spans of some tracks haven't been computed yet.
+func (tl *Timeline) spanIndex(win *theme.Window) (*container.IntervalTree[exptrace.Time, trackSpanRef], bool) {
+	stackTracks := tl.cv.timeline.displayStackTracks
+	if tl.spanIdx.tree != nil && tl.spanIdx.stackTracks == stackTracks {
+		return tl.spanIdx.tree.ResultNoWait()
+	}
+
+	type trackSpans struct {
+		track *Track
+		spans Items[ptrace.Span]
+	}
+	var all []trackSpans
+	for _, track := range tl.tracks {
+		if track.kind == TrackKindStack && !stackTracks {
+			continue
+		}
+		spans, ok := track.Spans(win).ResultNoWait()
+		if !ok {
+			return nil, false
+		}
+		if spans.Len() != 0 && spans.AtPtr(0).State == statePlaceholder {
+			continue
+		}
+		all = append(all, trackSpans{track, spans})
+	}
+
+	tl.spanIdx.stackTracks = stackTracks
+	tl.spanIdx.tree = theme.NewFuture(win, func(cancelled <-chan struct{}) *container.IntervalTree[exptrace.Time, trackSpanRef] {
+		defer rtrace.StartRegion(context.Background(), "main.Timeline.spanIndex").End()
+		t := container.NewIntervalTree[exptrace.Time, trackSpanRef]()
+		// Spans of different tracks can have identical bounds.
+		t.AllowDuplicates = true
+		for _, ts := range all {
+			for i := range ts.spans.Len() {
+				if i%20000 == 0 && TryRecv(cancelled) {
+					return nil
+				}
+				s := ts.spans.AtPtr(i)
+				if s.End <= s.Start {
+					continue
+				}
+				// The tree uses closed intervals, while spans are half-open.
+				t.Insert(s.Start, s.End-1, trackSpanRef{ts.track, i})
+			}
+		}
+		return t
+	})
+	return tl.spanIdx.tree.ResultNoWait()
+}
+
+// SpansOverlapping calls fn with each span in the timeline that overlaps the time range [start, end), sorted by
+// start. It stops early if fn returns false. It returns false if the timeline's spans aren't available yet.
+func (tl *Timeline) SpansOverlapping(win *theme.Window, start, end exptrace.Time, fn func(track *Track, spans Items[ptrace.Span]) bool) bool {
+	idx, ok := tl.spanIndex(win)
+	if !ok {
+		return false
+	}
+	idx.FindIter(start, end-1, func(n *container.RBNode[container.Interval[exptrace.Time], container.Value[exptrace.Time, trackSpanRef]]) bool {
+		for _, v := range n.Values {
+			ref := v.Value
+			if !fn(ref.track, ref.track.Spans(win).MustResult().Slice(ref.idx, ref.idx+1)) {
+				return true
+			}
+		}
+		return false
+	})
+	return true
+}
+
+// SpansAt calls fn with each span in the timeline that contains ts. See SpansOverlapping.
+func (tl *Timeline) SpansAt(win *theme.Window, ts exptrace.Time, fn func(track *Track, spans Items[ptrace.Span]) bool) bool {
+	return tl.SpansOverlapping(win, ts, ts+1, fn)
+}
+
 func (tl *Timeline) Plan(win *theme.Window, texs []TextureStack) []TextureStack {
 	defer rtrace.StartRegion(context.Background(), "main.TimelineWidget.Plan").End()
 
@@ -372,13 +715,18 @@ func (tl *Timeline) Layout(
 	defer clip.Rect{Max: image.Pt(gtx.Constraints.Max.X, timelineHeight)}.Push(gtx.Ops).Pop()
 	tl.widget.hover.Add(gtx.Ops)
 
+	selected := win.Selection.Is(tl.item)
+	if selected {
+		theme.FillShape(win, gtx.Ops, win.Theme.Palette.PrimarySelection, clip.Rect{Max: image.Pt(gtx.Constraints.Max.X, timelineHeight)}.Op())
+	}
+
 	if !compact {
-		if tl.widget.Hovered(gtx) || forceLabel || topBorder {
+		if tl.widget.Hovered(gtx) || forceLabel || topBorder || selected {
 			// Draw border at top of the timeline
 			theme.FillShape(win, gtx.Ops, colors[colorTimelineBorder], clip.Rect{Max: image.Pt(gtx.Constraints.Max.X, gtx.Dp(1))}.Op())
 		}
 
-		if tl.widget.Hovered(gtx) || forceLabel {
+		if tl.widget.Hovered(gtx) || forceLabel || selected {
 			tl.widget.labelClick.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
 				labelGtx := gtx
 				labelGtx.Constraints.Min = image.Point{}
@@ -425,6 +773,15 @@ func (tl *Timeline) Layout(
 	}
 	stack.Pop()
 
+	tl.layoutResizeHandle(gtx, timelineHeight)
+
+	if g, ok := tl.item.(*ptrace.Goroutine); ok && !cv.trace.filter.MatchGoroutine(g) {
+		// Dim goroutines excluded by the global filter.
+		c := win.Theme.Palette.Background
+		c.A = 0.6
+		theme.FillShape(win, gtx.Ops, c, clip.Rect{Max: image.Pt(gtx.Constraints.Max.X, timelineHeight)}.Op())
+	}
+
 	if !suboptimal {
 		tl.widget.usedSuboptimalTexture = time.Time{}
 	} else if tl.widget.usedSuboptimalTexture.IsZero() {
@@ -434,6 +791,44 @@ func (tl *Timeline) Layout(
 	return layout.Dimensions{Size: image.Pt(gtx.Constraints.Max.X, timelineHeight)}
 }
 
+// layoutResizeHandle handles the area at the bottom of the timeline, in the gap to the next timeline, which can be
+// dragged to resize the timeline's tracks. Double-clicking it restores the default height.
+func (tl *Timeline) layoutResizeHandle(gtx layout.Context, timelineHeight int) {
+	tw := tl.widget
+	for _, ev := range tw.resizeClick.Update(gtx.Queue) {
+		if ev.Kind == gesture.KindClick && ev.NumClicks == 2 {
+			tl.SetTrackHeight(0)
+		}
+	}
+	for _, ev := range tw.resizeDrag.Update(gtx.Metric, gtx.Queue, gesture.Vertical) {
+		switch ev.Kind {
+		case pointer.Press:
+			tw.resizeStartY = ev.Position.Y
+			tw.resizeStartHeight = tl.baseTrackHeight()
+		case pointer.Drag:
+			enabledTracks := 0
+			for _, track := range tl.tracks {
+				if track.kind != TrackKindStack || tl.cv.timeline.displayStackTracks {
+					enabledTracks++
+				}
+			}
+			// Distribute the change in height over all tracks.
+			d := unit.Dp((ev.Position.Y - tw.resizeStartY) / gtx.Metric.PxPerDp / float32(max(enabledTracks, 1)))
+			if tl.cv.timeline.compact {
+				d *= 2
+			}
+			tl.SetTrackHeight(tw.resizeStartHeight + d)
+		}
+	}
+
+	// The handle's input is added without offsetting it, so that pointer positions are relative to the top of the
+	// timeline, which doesn't move while the timeline is being resized.
+	defer clip.Rect{Min: image.Pt(0, timelineHeight-gtx.Dp(timelineGapDp)), Max: image.Pt(gtx.Constraints.Max.X, timelineHeight)}.Push(gtx.Ops).Pop()
+	pointer.CursorRowResize.Add(gtx.Ops)
+	tw.resizeDrag.Add(gtx.Ops)
+	tw.resizeClick.Add(gtx.Ops)
+}
+
 func defaultSpanColor(span *ptrace.Span, tr *Trace) colorIndex {
 	return stateColors[span.State]
 }
@@ -567,7 +962,7 @@ func (it *renderedSpansIterator[T, PT]) next(gtx layout.Context) (spansOut Items
 }
 
 func (track *Track) Height(gtx layout.Context) int {
-	height := gtx.Dp(timelineTrackHeightDp)
+	height := track.parent.mainTrackHeight(gtx)
 	// Tiny spans mini track
 	height += gtx.Dp(timelineMinitrackHeightDp) + gtx.Dp(timelineMinitrackGapDp)
 	// Events mini track
@@ -601,11 +996,45 @@ func (track *Track) Plan(win *theme.Window, texs []TextureStack) []TextureStack
 
 	cv := track.parent.cv
 	textures := track.widget.scratchTextures[:0]
+	n := len(texs)
 	texs, textures = track.rnd.Render(win, track, spans, cv.nsPerPx, cv.start, cv.End(), texs, textures)
 	track.widget.scratchTextures = textures[:0]
+
+	if haveSpans {
+		// Only prefetch neighboring textures once the visible ones are ready, so that we don't compete with them.
+		ready := true
+		for _, stack := range texs[n:] {
+			if !stack.texs[0].tex.ready() {
+				ready = false
+				break
+			}
+		}
+		if ready {
+			track.rnd.Prefetch(win, &cv.textures, cv.trace, track, spans, cv.nsPerPx, cv.start, cv.End())
+		}
+	}
 	return texs
 }
 
+// mergeSpans computes the spans to display for the canvas's current viewport, merging spans that are too small to be
+// displayed individually. It doesn't modify any state and may be called concurrently for different tracks.
+func (track *Track) mergeSpans(gtx layout.Context, spans Items[ptrace.Span], out []displayedSpans) []displayedSpans {
+	defer rtrace.StartRegion(context.Background(), "main.Track.mergeSpans").End()
+
+	it := renderedSpansIterator[spanWithGetters, *spanWithGetters]{
+		cv:    track.parent.cv,
+		spans: myunsafe.Cast[Items[spanWithGetters]](spans),
+	}
+	for {
+		dspSpans, startPx, endPx, ok := it.next(gtx)
+		if !ok {
+			break
+		}
+		out = append(out, displayedSpans{myunsafe.Cast[Items[ptrace.Span]](dspSpans), startPx, endPx})
+	}
+	return out
+}
+
 func (track *Track) Layout(
 	win *theme.Window,
 	gtx layout.Context,
@@ -652,10 +1081,7 @@ func (track *Track) layoutMain(
 	labelsOut *[]string,
 ) (dims layout.Dimensions) {
 	cv := tl.cv
-	tr := cv.trace
-	mainTrackHeight := gtx.Dp(timelineTrackHeightDp)
-	spanBorderWidth := gtx.Dp(spanBorderWidthDp)
-	minSpanWidth := gtx.Dp(minSpanWidthDp)
+	mainTrackHeight := tl.mainTrackHeight(gtx)
 
 	defer clip.Rect{Max: image.Pt(gtx.Constraints.Max.X, mainTrackHeight)}.Push(gtx.Ops).Pop()
 	track.widget.click.Add(gtx.Ops)
@@ -689,33 +1115,58 @@ func (track *Track) layoutMain(
 		track.widget.lowQualityRender = true
 	}
 
+	// Hovering only affects the track's appearance if the pointer is over a span, so that moving the pointer over gaps
+	// can reuse the cached ops.
+	hovered := track.widget.hover.Update(gtx.Queue) && track.spansNear(win, gtx, track.widget.hover.Pointer().X)
+
 	// // OPT(dh): don't redraw if the only change is cv.y
-	if !track.widget.hover.Update(gtx.Queue) &&
-		!track.widget.prevFrame.hovered &&
-		cv.unchanged(gtx) &&
-		track.widget.prevFrame.placeholder == !haveSpans &&
-		!track.widget.prevFrame.lowQualityRender &&
-		gtx.Constraints == track.widget.prevFrame.constraints {
-
-		track.widget.prevFrame.call.Add(gtx.Ops)
-		debugCaching(win, gtx)
-		return track.widget.prevFrame.dims
-	}
-
-	track.widget.prevFrame.hovered = track.widget.hover.Update(gtx.Queue)
-	track.widget.prevFrame.constraints = gtx.Constraints
-
-	origOps := gtx.Ops
-	gtx.Ops = track.widget.prevFrame.ops.Get()
-	macro := op.Record(gtx.Ops)
-	defer func() {
-		call := macro.Stop()
-		call.Add(origOps)
-		track.widget.prevFrame.placeholder = !haveSpans
-		track.widget.prevFrame.call = call
-		track.widget.prevFrame.dims = dims
-		track.widget.prevFrame.lowQualityRender = track.widget.lowQualityRender
-	}()
+	if hovered ||
+		track.widget.prevFrame.hovered ||
+		!cv.unchanged(gtx) ||
+		track.widget.prevFrame.placeholder != !haveSpans ||
+		track.widget.prevFrame.lowQualityRender {
+		track.widget.cache.Invalidate()
+	}
+	track.widget.prevFrame.hovered = hovered
+	track.widget.prevFrame.placeholder = !haveSpans
+
+	dims = track.widget.cache.Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+		return track.layoutSpans(win, gtx, tl, &tsi, spans, haveSpans, filter, labelsOut)
+	})
+	track.widget.prevFrame.lowQualityRender = track.widget.lowQualityRender
+	return dims
+}
+
+// spansNear reports whether the track has spans that may be displayed at the horizontal position x. Spans are padded
+// to the minimum span width, which can make them extend past their time range. spansNear conservatively returns true
+// if the timeline's spans haven't been indexed yet.
+func (track *Track) spansNear(win *theme.Window, gtx layout.Context, x float32) bool {
+	cv := track.parent.cv
+	pad := float32(gtx.Dp(minSpanWidthDp))
+	found := false
+	ok := track.parent.SpansOverlapping(win, cv.pxToTs(x-pad), cv.pxToTs(x+pad)+1, func(t *Track, _ Items[ptrace.Span]) bool {
+		found = t == track
+		return !found
+	})
+	return !ok || found
+}
+
+// layoutSpans lays out the spans of the main track. Its output is cached by layoutMain.
+func (track *Track) layoutSpans(
+	win *theme.Window,
+	gtx layout.Context,
+	tl *Timeline,
+	tsi *trackSpanInteractivity,
+	spans Items[ptrace.Span],
+	haveSpans bool,
+	filter Filter,
+	labelsOut *[]string,
+) layout.Dimensions {
+	cv := tl.cv
+	tr := cv.trace
+	mainTrackHeight := tl.mainTrackHeight(gtx)
+	spanBorderWidth := gtx.Dp(spanBorderWidthDp)
+	minSpanWidth := gtx.Dp(minSpanWidthDp)
 
 	var hoveredSpan clip.FRect
 	highlightedSpans := track.widget.scratchHighlighted[:0]
@@ -724,6 +1175,16 @@ func (track *Track) layoutMain(
 	outlinesPath.Begin(track.widget.outlinesOps.Get())
 	labelsOps := track.widget.labelsOps.Get()
 	labelsMacro := op.Record(labelsOps)
+	var densityPaths [len(densityThresholds)]clip.Path
+	for i := range densityPaths {
+		densityPaths[i].Begin(track.widget.densityOps[i].Get())
+	}
+	var hatchPaths [2]clip.Path
+	for i := range hatchPaths {
+		hatchPaths[i].Begin(track.widget.hatchOps[i].Get())
+	}
+	hatchSpacing := float32(gtx.Dp(6))
+	hatchWidth := float32(max(gtx.Dp(1.5), 1))
 
 	first := true
 	var prevEndPx float32
@@ -772,6 +1233,27 @@ func (track *Track) layoutMain(
 			if hovered {
 				hoveredSpan = clip.FRect{Min: minP, Max: maxP}
 			}
+
+			if dspSpans.Len() == 1 {
+				if pattern, light := spanPattern(track.SpanColor(dspSpans.AtPtr(0), tr)); pattern != hatchNone {
+					ink := 0
+					if light {
+						ink = 1
+					}
+					addHatch(&hatchPaths[ink], pattern, startPx, minP, maxP, hatchSpacing, hatchWidth)
+				}
+			}
+
+			if n := dspSpans.Len(); n > 1 {
+				if level := densityLevel(n, endPx-startPx); level >= 0 {
+					p := &densityPaths[level]
+					p.MoveTo(minP)
+					p.LineTo(f32.Point{X: maxP.X, Y: minP.Y})
+					p.LineTo(maxP)
+					p.LineTo(f32.Point{X: minP.X, Y: maxP.Y})
+					p.Close()
+				}
+			}
 		}
 
 		if track.spanLabel != nil && maxP.X-minP.X > float32(2*minSpanWidth) && dspSpans.Len() == 1 {
@@ -850,7 +1332,7 @@ func (track *Track) layoutMain(
 			// full pixel.
 			fudge = -0.5
 		}
-		if !tex.Add(win, gtx, &cv.textures, tr, gtx.Ops, fudge) {
+		if !tex.Add(win, gtx, &cv.textures, tr, gtx.Ops, mainTrackHeight, fudge) {
 			track.widget.lowQualityRender = true
 		}
 	}
@@ -860,27 +1342,20 @@ func (track *Track) layoutMain(
 			doSpans(prevSpans.dspSpans, prevSpans.startPx, prevSpans.endPx)
 		}
 	} else {
-		it := renderedSpansIterator[spanWithGetters, *spanWithGetters]{
-			cv:    cv,
-			spans: myunsafe.Cast[Items[spanWithGetters]](spans),
+		var allDspSpans []displayedSpans
+		if haveSpans && track.widget.merged.frame == win.Frame {
+			// The canvas has already merged our spans. Swap buffers so that both can be reused.
+			allDspSpans = track.widget.merged.spans
+			track.widget.merged.spans = track.widget.prevFrame.dspSpans[:0]
+		} else {
+			allDspSpans = track.mergeSpans(gtx, spans, track.widget.prevFrame.dspSpans[:0])
 		}
-
-		allDspSpans := track.widget.prevFrame.dspSpans[:0]
-		for {
-			dspSpans_, startPx, endPx, ok := it.next(gtx)
-			if !ok {
-				break
-			}
-			dspSpans := myunsafe.Cast[Items[ptrace.Span]](dspSpans_)
-
-			allDspSpans = append(allDspSpans, struct {
-				dspSpans       Items[ptrace.Span]
-				startPx, endPx float32
-			}{dspSpans, startPx, endPx})
-			doSpans(dspSpans, startPx, endPx)
+		for _, dsp := range allDspSpans {
+			doSpans(dsp.dspSpans, dsp.startPx, dsp.endPx)
 		}
 
 		track.widget.prevFrame.dspSpans = allDspSpans
+		track.widget.prevFrame.dspSpansGen++
 	}
 
 	if track.kind == TrackKindUnspecified {
@@ -944,6 +1419,16 @@ func (track *Track) layoutMain(
 		}
 	}
 
+	// Shade merged spans by their density
+	for i := range densityPaths {
+		c := oklcha(0, 0, 0, 0.08*float32(i+1))
+		theme.FillShape(win, gtx.Ops, c, clip.Outline{Path: densityPaths[i].End()}.Op())
+	}
+
+	// Draw the patterns that distinguish span states in the high-contrast palette
+	theme.FillShape(win, gtx.Ops, oklcha(0, 0, 0, 0.6), clip.Outline{Path: hatchPaths[0].End()}.Op())
+	theme.FillShape(win, gtx.Ops, oklcha(100, 0, 0, 0.7), clip.Outline{Path: hatchPaths[1].End()}.Op())
+
 	// Highlight the hovered span
 	if hoveredSpan != (clip.FRect{}) {
 		stack := hoveredSpan.Op(gtx.Ops).Push(gtx.Ops)
@@ -1027,14 +1512,16 @@ func (tsi *trackSpanInteractivity) Handle(win *theme.Window, gtx layout.Context,
 		tsi.track.widget.clickedSpans = spans
 	}
 	if tsi.trackContextMenuSpans {
+		var items []*theme.MenuItem
 		if tsi.track.spanContextMenu != nil {
-			win.SetContextMenu(tsi.track.spanContextMenu(spans, cv))
+			items = tsi.track.spanContextMenu(spans, cv)
 		} else {
-			win.SetContextMenu([]*theme.MenuItem{
+			items = []*theme.MenuItem{
 				newZoomMenuItem(cv, spans),
 				newOpenSpansMenuItem(spans),
-			})
+			}
 		}
+		win.SetContextMenu(contextMenu(spans, items))
 	}
 
 	spanTooltip := tsi.track.spanTooltip
@@ -1058,6 +1545,12 @@ type MiniTrack[T any, PT interface {
 	ops          [colorLast]mem.ReusableOps
 	clickedItems Items[T]
 	*MiniTrackBehavior[T]
+
+	// The recorded ops of the mini track, replayed as long as nothing affecting its appearance changes.
+	cache   theme.Cache
+	hovered bool
+	// The generation of the main track's displayed spans that the cache was recorded for.
+	dspSpansGen uint64
 }
 
 type MiniTrackBehavior[T any] struct {
@@ -1094,14 +1587,16 @@ var (
 		},
 		contextMenu: func(items Items[spanWithGetters], track *Track) []*theme.MenuItem {
 			spans := myunsafe.Cast[Items[ptrace.Span]](items)
+			var menu []*theme.MenuItem
 			if track.spanContextMenu != nil {
-				return track.spanContextMenu(spans, track.parent.cv)
+				menu = track.spanContextMenu(spans, track.parent.cv)
 			} else {
-				return []*theme.MenuItem{
+				menu = []*theme.MenuItem{
 					newZoomMenuItem(track.parent.cv, spans),
 					newOpenSpansMenuItem(spans),
 				}
 			}
+			return contextMenu(spans, menu)
 		},
 	}
 
@@ -1147,13 +1642,13 @@ var (
 					trans := ev.StateTransition()
 					from, to := trans.Goroutine()
 					if from == exptrace.GoNotExist && to == exptrace.GoRunnable {
-						label = fmt.Sprintf("Created goroutine %s", GoroutineLabel(tr.G(trans.Resource.Goroutine())))
+						label = local.Sprintf("Created goroutine %s", GoroutineLabel(tr.G(trans.Resource.Goroutine())))
 					} else if from == exptrace.GoWaiting && to == exptrace.GoRunnable {
-						label = fmt.Sprintf("Unblocked goroutine %s", GoroutineLabel(tr.G(trans.Resource.Goroutine())))
+						label = local.Sprintf("Unblocked goroutine %s", GoroutineLabel(tr.G(trans.Resource.Goroutine())))
 					} else if to == exptrace.GoSyscall {
 						stk := ev.Stack()
 						if stk != exptrace.NoStack {
-							frame := tr.PCs[tr.Stacks[stk][0]]
+							frame := tr.StackFrame(tr.Stacks[tr.StackID(stk)][0])
 							label = "Syscall: " + frame.Func
 						} else {
 							label = "Syscall"
@@ -1166,7 +1661,7 @@ var (
 					cat := l.Category
 					msg := l.Message
 					if cat != "" {
-						label = fmt.Sprintf("Log: <%s> %s", cat, msg)
+						label = local.Sprintf("Log: <%s> %s", cat, msg)
 					} else {
 						label = "Log: " + msg
 					}
@@ -1211,7 +1706,7 @@ var (
 					noun = "events"
 				}
 				label = local.Sprintf("%d %s\n", events.Len(), noun)
-				label += fmt.Sprintf("Time span: %s\n", roundDuration(time.Duration(LastItemPtr(events).Time()-events.AtPtr(0).Time())))
+				label += local.Sprintf("Time span: %s\n", roundDuration(time.Duration(LastItemPtr(events).Time()-events.AtPtr(0).Time())))
 			}
 
 			return theme.Tooltip(win.Theme, label).Layout
@@ -1222,12 +1717,15 @@ var (
 		color: func(items Items[eventWithGetters], _ *Track) colorIndex {
 			return colorEvent
 		},
+		contextMenu: func(items Items[eventWithGetters], track *Track) []*theme.MenuItem {
+			return eventStackContextMenu(items, track, "event")
+		},
 	}
 
 	mtrackSamples = &MiniTrackBehavior[eventWithGetters]{
 		tooltip: func(win *theme.Window, items Items[eventWithGetters], track *Track) theme.Widget {
 			if items.Len() > 1 {
-				return theme.Tooltip(win.Theme, fmt.Sprintf("%d stack samples", items.Len())).Layout
+				return theme.Tooltip(win.Theme, local.Sprintf("%d stack samples", items.Len())).Layout
 			} else {
 				return theme.Tooltip(win.Theme, formatStack(track.parent.cv.trace, items.AtPtr(0).Stack(), 6)).Layout
 			}
@@ -1238,9 +1736,24 @@ var (
 		color: func(items Items[eventWithGetters], track *Track) colorIndex {
 			return colorStateCPUSample
 		},
+		contextMenu: func(items Items[eventWithGetters], track *Track) []*theme.MenuItem {
+			return eventStackContextMenu(items, track, "stack sample")
+		},
 	}
 )
 
+// eventStackContextMenu returns the context menu of a single event that has a stack trace.
+func eventStackContextMenu(items Items[eventWithGetters], track *Track, noun string) []*theme.MenuItem {
+	if items.Len() != 1 {
+		return nil
+	}
+	stk := items.AtPtr(0).Stack()
+	if len(track.parent.cv.trace.Stacks[track.parent.cv.trace.StackID(stk)]) == 0 {
+		return nil
+	}
+	return []*theme.MenuItem{newOpenStackMenuItem(stk, noun)}
+}
+
 func (mtrack *MiniTrack[T, PT]) Layout(
 	win *theme.Window,
 	gtx layout.Context,
@@ -1249,7 +1762,6 @@ func (mtrack *MiniTrack[T, PT]) Layout(
 	items func(yield func(el Items[T]) bool),
 ) layout.Dimensions {
 	timelineMinitrackHeight := gtx.Dp(timelineMinitrackHeightDp)
-	minSpanWidth := gtx.Dp(minSpanWidthDp)
 
 	defer clip.Rect{Max: image.Pt(gtx.Constraints.Max.X, timelineMinitrackHeight)}.Push(gtx.Ops).Pop()
 	mtrack.click.Add(gtx.Ops)
@@ -1275,7 +1787,33 @@ func (mtrack *MiniTrack[T, PT]) Layout(
 		}
 	}
 
-	mtrack.hover.Update(gtx.Queue)
+	// Clicks are only handled for hovered items, so we only need to lay out again when the hover state or the items
+	// changed.
+	hovered := mtrack.hover.Update(gtx.Queue)
+	if hovered ||
+		mtrack.hovered ||
+		!cv.unchanged(gtx) ||
+		mtrack.dspSpansGen != track.widget.prevFrame.dspSpansGen {
+		mtrack.cache.Invalidate()
+	}
+	mtrack.hovered = hovered
+	mtrack.dspSpansGen = track.widget.prevFrame.dspSpansGen
+
+	return mtrack.cache.Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+		return mtrack.layoutItems(win, gtx, track, cv, items, trackNavigated, trackClicked, trackContextMenu)
+	})
+}
+
+func (mtrack *MiniTrack[T, PT]) layoutItems(
+	win *theme.Window,
+	gtx layout.Context,
+	track *Track,
+	cv *Canvas,
+	items func(yield func(el Items[T]) bool),
+	trackNavigated, trackClicked, trackContextMenu bool,
+) layout.Dimensions {
+	timelineMinitrackHeight := gtx.Dp(timelineMinitrackHeightDp)
+	minSpanWidth := gtx.Dp(minSpanWidthDp)
 
 	var paths [colorLast]clip.Path
 	var initPaths [colorLast]bool
@@ -1304,7 +1842,9 @@ func (mtrack *MiniTrack[T, PT]) Layout(
 					mtrack.clickedItems = items
 				}
 				if trackContextMenu && mtrack.contextMenu != nil {
-					win.SetContextMenu(mtrack.contextMenu(items, track))
+					if menu := mtrack.contextMenu(items, track); len(menu) != 0 {
+						win.SetContextMenu(menu)
+					}
 				}
 
 				win.SetTooltip(mtrack.tooltip(win, items, track))
@@ -1355,7 +1895,7 @@ func (mtrack *MiniTrack[T, PT]) Layout(
 		if !initPaths[i] {
 			continue
 		}
-		theme.FillShape(win, gtx.Ops, colors[i], clip.Outline{Path: paths[i].End()}.Op())
+		theme.FillShape(win, gtx.Ops, paletteColor(colorIndex(i)), clip.Outline{Path: paths[i].End()}.Op())
 	}
 	return layout.Dimensions{
 		Size: image.Pt(gtx.Constraints.Max.X, timelineMinitrackHeight),
@@ -1521,9 +2061,9 @@ func NewSTWTimeline(cv *Canvas, tr *Trace, spans []ptrace.Span) *Timeline {
 		}
 		label := tr.Event(spans.AtPtr(0).StartEvent).Range().Name + "\n"
 		if d, ok := SpansDuration(spans); ok {
-			label += fmt.Sprintf("Duration: %s\n", roundDuration(d))
+			label += local.Sprintf("Duration: %s\n", roundDuration(d))
 		}
-		label += fmt.Sprintf("Time span: %s\n", roundDuration(SpansTimeSpan(spans).Duration()))
+		label += local.Sprintf("Time span: %s\n", roundDuration(SpansTimeSpan(spans).Duration()))
 		return theme.Tooltip(win.Theme, label).Layout(win, gtx)
 	}
 	tl.item = &STW{ss}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"regexp"
+	rtrace "runtime/trace"
+	"strconv"
+	"strings"
+	"time"
+
+	"honnef.co/go/gotraceui/container"
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/trace/ptrace"
+	"honnef.co/go/gotraceui/widget"
+
+	exptrace "golang.org/x/exp/trace"
+)
+
+// A TimelineFilter hides goroutine timelines based on aggregate properties of the goroutines. A timeline is only shown
+// if it matches all criteria that are set. The zero value matches all timelines.
+type TimelineFilter struct {
+	// If not nil, only goroutines whose function names match Pattern are shown.
+	Pattern *regexp.Regexp
+	// Only goroutines that ran for at least MinRunning and were blocked for at least MinBlocked in total are shown.
+	MinRunning, MinBlocked time.Duration
+	// Bitmap of ptrace.SchedulingState. If it isn't zero, only goroutines that were in at least one of the states at
+	// some point are shown.
+	States uint64
+	// If set, only goroutines that ran on the processor are shown.
+	Processor container.Option[exptrace.ProcID]
+	// If set, only goroutines that created the task or emitted events for it are shown.
+	Task container.Option[exptrace.TaskID]
+}
+
+func (f *TimelineFilter) Active() bool {
+	return f.Pattern != nil || f.MinRunning != 0 || f.MinBlocked != 0 || f.States != 0 || f.Processor.Set() || f.Task.Set()
+}
+
+// matcher returns a function that reports whether the filter matches a goroutine. It does the work that is shared by
+// all goroutines up front.
+func (f *TimelineFilter) matcher(tr *Trace) func(g *ptrace.Goroutine) bool {
+	if !f.Active() {
+		return func(*ptrace.Goroutine) bool { return true }
+	}
+
+	var onProc, inTask container.Set[exptrace.GoID]
+	if id, ok := f.Processor.Get(); ok {
+		onProc = container.Set[exptrace.GoID]{}
+		for _, p := range tr.Processors {
+			if p.ID != id {
+				continue
+			}
+			for i := range p.Spans {
+				if span := &p.Spans[i]; span.State == ptrace.StateProcRunningG {
+					onProc.Add(tr.Event(span.StartEvent).StateTransition().Resource.Goroutine())
+				}
+			}
+		}
+	}
+	if id, ok := f.Task.Get(); ok {
+		inTask = container.Set[exptrace.GoID]{}
+		if t := tr.Task(id); t != nil {
+			if gid := goroutineIDForTask(t, tr); gid != exptrace.NoGoroutine {
+				inTask.Add(gid)
+			}
+			for _, ev := range t.Events {
+				inTask.Add(tr.Event(ev).Goroutine())
+			}
+		}
+	}
+
+	return func(g *ptrace.Goroutine) bool {
+		if f.Pattern != nil && (g.Function == nil || !f.Pattern.MatchString(g.Function.Func)) {
+			return false
+		}
+		if onProc != nil {
+			if _, ok := onProc[g.ID]; !ok {
+				return false
+			}
+		}
+		if inTask != nil {
+			if _, ok := inTask[g.ID]; !ok {
+				return false
+			}
+		}
+		if f.States != 0 {
+			found := false
+			for i := range g.Spans {
+				if f.States&(1<<g.Spans[i].State) != 0 {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+		if f.MinRunning != 0 || f.MinBlocked != 0 {
+			stats := ptrace.ComputeStatistics(ptrace.ToSpans(g.Spans))
+			if stats.Running() < f.MinRunning || stats.Blocked() < f.MinBlocked {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// ActiveFilters returns a removable entry for each criterion of the filter that is set. changed is called after a
+// criterion has been removed.
+func (f *TimelineFilter) ActiveFilters(changed func()) []ActiveFilter {
+	var out []ActiveFilter
+	add := func(label string, remove func()) {
+		out = append(out, ActiveFilter{
+			Label: label,
+			Remove: func() {
+				remove()
+				changed()
+			},
+		})
+	}
+	if f.Pattern != nil {
+		add(local.Sprintf("Timelines: function matches /%s/", f.Pattern), func() { f.Pattern = nil })
+	}
+	if f.MinRunning != 0 {
+		add(local.Sprintf("Timelines: running ≥ %s", f.MinRunning), func() { f.MinRunning = 0 })
+	}
+	if f.MinBlocked != 0 {
+		add(local.Sprintf("Timelines: blocked ≥ %s", f.MinBlocked), func() { f.MinBlocked = 0 })
+	}
+	for state := range ptrace.StateLast {
+		if f.States&(1<<state) == 0 {
+			continue
+		}
+		add(local.Sprintf("Timelines: ever %s", stateNames[state]), func() { f.States &^= 1 << state })
+	}
+	if id, ok := f.Processor.Get(); ok {
+		add(local.Sprintf("Timelines: ran on processor %d", id), func() { f.Processor = container.None[exptrace.ProcID]() })
+	}
+	if id, ok := f.Task.Get(); ok {
+		add(local.Sprintf("Timelines: touched task %d", id), func() { f.Task = container.None[exptrace.TaskID]() })
+	}
+	return out
+}
+
+// TimelineFilterDialog builds a TimelineFilter from user input and applies it to a canvas.
+type TimelineFilterDialog struct {
+	cv *Canvas
+
+	pattern    widget.Editor
+	minRunning widget.Editor
+	minBlocked widget.Editor
+	processor  widget.Editor
+	task       widget.Editor
+	states     uint64
+	statesList HighlightDialogStyle
+
+	apply widget.PrimaryClickable
+	clear widget.PrimaryClickable
+	err   string
+}
+
+func NewTimelineFilterDialog(cv *Canvas) *TimelineFilterDialog {
+	fd := &TimelineFilterDialog{cv: cv}
+	for _, ed := range []*widget.Editor{&fd.pattern, &fd.minRunning, &fd.minBlocked, &fd.processor, &fd.task} {
+		ed.SingleLine = true
+	}
+	fd.set(cv.timeline.goroutineFilter)
+	fd.statesList = statesDialog(&fd.states)
+	return fd
+}
+
+func (fd *TimelineFilterDialog) set(f TimelineFilter) {
+	fd.pattern.SetText("")
+	if f.Pattern != nil {
+		fd.pattern.SetText(f.Pattern.String())
+	}
+	formatDuration := func(d time.Duration) string {
+		if d == 0 {
+			return ""
+		}
+		return d.String()
+	}
+	fd.minRunning.SetText(formatDuration(f.MinRunning))
+	fd.minBlocked.SetText(formatDuration(f.MinBlocked))
+	fd.processor.SetText("")
+	if id, ok := f.Processor.Get(); ok {
+		fd.processor.SetText(strconv.FormatInt(int64(id), 10))
+	}
+	fd.task.SetText("")
+	if id, ok := f.Task.Get(); ok {
+		fd.task.SetText(strconv.FormatUint(uint64(id), 10))
+	}
+	fd.states = f.States
+}
+
+// filter returns the filter described by the dialog's inputs.
+func (fd *TimelineFilterDialog) filter() (TimelineFilter, error) {
+	f := TimelineFilter{States: fd.states}
+	if s := strings.TrimSpace(fd.pattern.Text()); s != "" {
+		re, err := regexp.Compile(s)
+		if err != nil {
+			return TimelineFilter{}, fmt.Errorf("invalid function pattern: %s", err)
+		}
+		f.Pattern = re
+	}
+	parseDuration := func(ed *widget.Editor, what string) (time.Duration, error) {
+		s := strings.TrimSpace(ed.Text())
+		if s == "" {
+			return 0, nil
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil || d < 0 {
+			return 0, fmt.Errorf("%s must be a duration such as 10ms", what)
+		}
+		return d, nil
+	}
+	var err error
+	if f.MinRunning, err = parseDuration(&fd.minRunning, "minimum running time"); err != nil {
+		return TimelineFilter{}, err
+	}
+	if f.MinBlocked, err = parseDuration(&fd.minBlocked, "minimum blocked time"); err != nil {
+		return TimelineFilter{}, err
+	}
+	if s := strings.TrimSpace(fd.processor.Text()); s != "" {
+		id, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || id < 0 {
+			return TimelineFilter{}, errors.New("processor must be a processor ID")
+		}
+		f.Processor = container.Some(exptrace.ProcID(id))
+	}
+	if s := strings.TrimSpace(fd.task.Text()); s != "" {
+		id, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return TimelineFilter{}, errors.New("task must be a task ID")
+		}
+		f.Task = container.Some(exptrace.TaskID(id))
+	}
+	return f, nil
+}
+
+func (fd *TimelineFilterDialog) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.TimelineFilterDialog.Layout").End()
+
+	for fd.apply.Clicked(gtx) {
+		if f, err := fd.filter(); err != nil {
+			fd.err = err.Error()
+		} else {
+			fd.err = ""
+			fd.cv.SetTimelineFilter(f)
+			win.ShowNotification(gtx, local.Sprintf("%d timelines hidden", fd.cv.NumHiddenTimelines()))
+		}
+	}
+	for fd.clear.Clicked(gtx) {
+		fd.err = ""
+		fd.set(TimelineFilter{})
+		fd.cv.SetTimelineFilter(TimelineFilter{})
+	}
+
+	field := func(label string, ed *widget.Editor, hint string) layout.Widget {
+		return func(gtx layout.Context) layout.Dimensions {
+			return layout.Rigids(gtx, layout.Vertical,
+				theme.Dumb(win, theme.LineLabel(win.Theme, label).Layout),
+				theme.Dumb(win, theme.TextBox(win.Theme, ed, hint).Layout),
+				layout.Spacer{Height: 5}.Layout,
+			)
+		}
+	}
+
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(theme.Dumb(win, theme.Label(win.Theme, tr("Only show goroutine timelines that match all of the following criteria. Empty fields match all goroutines.")).Layout)),
+		layout.Rigid(layout.Spacer{Height: 10}.Layout),
+		layout.Rigid(field("Function (regexp)", &fd.pattern, "^net/http\\.")),
+		layout.Rigid(field("Minimum running time", &fd.minRunning, "1ms")),
+		layout.Rigid(field("Minimum blocked time", &fd.minBlocked, "10ms")),
+		layout.Rigid(field("Ran on processor", &fd.processor, "Processor ID")),
+		layout.Rigid(field("Touched task", &fd.task, "Task ID")),
+		layout.Rigid(layout.Spacer{Height: 5}.Layout),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return layout.Rigids(gtx, layout.Horizontal,
+				theme.Dumb(win, theme.Button(win.Theme, &fd.apply.Clickable, tr("Apply")).Layout),
+				layout.Spacer{Width: 5}.Layout,
+				theme.Dumb(win, theme.Button(win.Theme, &fd.clear.Clickable, tr("Clear")).Layout),
+			)
+		}),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			if fd.err == "" {
+				return layout.Dimensions{}
+			}
+			l := theme.Label(win.Theme, fd.err)
+			l.Color = colors[colorStateBlocked]
+			return l.Layout(win, gtx)
+		}),
+		layout.Rigid(layout.Spacer{Height: 10}.Layout),
+		layout.Rigid(theme.Dumb(win, theme.Label(win.Theme, tr("Was in one of the checked states at some point, or any state if none are checked:")).Layout)),
+		layout.Rigid(layout.Spacer{Height: 5}.Layout),
+		layout.Flexed(1, theme.Dumb(win, fd.statesList.Layout)),
+	)
+}
+
+func displayTimelineFilterDialog(win *theme.Window, cv *Canvas) {
+	fd := NewTimelineFilterDialog(cv)
+	win.SetModal(func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+		return theme.Dialog(win.Theme, tr("Filter timelines")).Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+			gtx.Constraints.Min = gtx.Constraints.Constrain(image.Pt(1000, 700))
+			gtx.Constraints.Max = gtx.Constraints.Min
+			return fd.Layout(win, gtx)
+		})
+	})
+}
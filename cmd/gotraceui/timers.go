@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	rtrace "runtime/trace"
+	"slices"
+	"time"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/trace/ptrace"
+	myunsafe "honnef.co/go/gotraceui/unsafe"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/font"
+	"gioui.org/op/clip"
+	"gioui.org/text"
+	exptrace "golang.org/x/exp/trace"
+)
+
+// A timerWakeup is a span of a goroutine sleeping or waiting that was ended by a timer.
+type timerWakeup struct {
+	g    *ptrace.Goroutine
+	span *ptrace.Span
+}
+
+// A timerGroup aggregates timer wakeups by the stack the goroutines blocked at.
+type timerGroup struct {
+	// The function that blocked, or the empty string if the stack is unknown.
+	Site    string
+	Wakeups []timerWakeup
+	// The number of wakeups that were due to time.Sleep, as opposed to other timers, such as time.After.
+	Sleeps int
+	Total  time.Duration
+	// The wakeup with the median duration, as a representative of the group.
+	Median *timerWakeup
+}
+
+type timerReportKey struct{}
+
+// isTimerWakeup reports whether span, which must be followed by another span, was a sleep or a wait that was ended by
+// a timer, and whether it was a sleep.
+//
+// The trace doesn't say why goroutines were woken up, but timers are run by the scheduler, not by goroutines. We
+// treat goroutines that were woken up by no goroutine as having been woken up by a timer, unless they were waiting
+// for the network, as those get woken up by the netpoller.
+func isTimerWakeup(tr *Trace, span *ptrace.Span) (timer, sleep bool) {
+	switch span.State {
+	case ptrace.StateInactive, ptrace.StateBlocked, ptrace.StateBlockedSend, ptrace.StateBlockedRecv,
+		ptrace.StateBlockedSelect, ptrace.StateBlockedSync, ptrace.StateBlockedSyncOnce, ptrace.StateBlockedCond:
+	default:
+		return false, false
+	}
+
+	start := tr.Event(span.StartEvent)
+	if start.Kind() != exptrace.EventStateTransition {
+		return false, false
+	}
+	if _, to := start.StateTransition().Goroutine(); to != exptrace.GoWaiting {
+		// Inactive spans also include goroutines that yielded and are runnable.
+		return false, false
+	}
+	sleep = start.StateTransition().Reason == "sleep"
+
+	end := tr.Event(span.EndEvent)
+	if end.Kind() != exptrace.EventStateTransition || end.Goroutine() != exptrace.NoGoroutine {
+		return false, false
+	}
+	return true, sleep
+}
+
+func computeTimerReport(tr *Trace) []timerGroup {
+	defer rtrace.StartRegion(context.Background(), "main.computeTimerReport").End()
+
+	groups := map[string]*timerGroup{}
+	for _, g := range tr.Goroutines {
+		// The last span can't have been ended by a wakeup.
+		for i := range g.Spans[:max(len(g.Spans)-1, 0)] {
+			span := &g.Spans[i]
+			timer, sleep := isTimerWakeup(tr, span)
+			if !timer {
+				continue
+			}
+
+			var pcs []uint64
+			if stk := tr.Event(span.StartEvent).Stack(); stk != exptrace.NoStack {
+				pcs = tr.Stacks[tr.StackID(stk)]
+				if int(span.At) < len(pcs) {
+					pcs = pcs[span.At:]
+				}
+			}
+			key := string(myunsafe.SliceCast[[]byte](pcs))
+			group, ok := groups[key]
+			if !ok {
+				group = &timerGroup{}
+				if len(pcs) > 0 {
+					group.Site = tr.FuncName(pcs[0])
+				}
+				groups[key] = group
+			}
+			group.Wakeups = append(group.Wakeups, timerWakeup{g, span})
+			group.Total += span.Duration()
+			if sleep {
+				group.Sleeps++
+			}
+		}
+	}
+
+	out := make([]timerGroup, 0, len(groups))
+	for _, group := range groups {
+		sorted := slices.Clone(group.Wakeups)
+		slices.SortFunc(sorted, func(a, b timerWakeup) int {
+			return cmp(a.span.Duration(), b.span.Duration(), false)
+		})
+		median := sorted[len(sorted)/2]
+		group.Median = &median
+		out = append(out, *group)
+	}
+	slices.SortFunc(out, func(a, b timerGroup) int {
+		return len(b.Wakeups) - len(a.Wakeups)
+	})
+	return out
+}
+
+// TimersComponent aggregates wakeups caused by timers and time.Sleep by where goroutines went to sleep. Sites with
+// high wakeup rates often belong to code that polls in a loop.
+type TimersComponent struct {
+	trace  *Trace
+	canvas *Canvas
+	groups *theme.Future[[]timerGroup]
+
+	table         *theme.Table
+	scrollState   theme.YScrollableListState
+	cellFormatter CellFormatter
+	// Lazily computed spans of the groups, indexed by row.
+	spans []Items[ptrace.Span]
+}
+
+func NewTimersComponent(win *theme.Window, tr *Trace, cv *Canvas) *TimersComponent {
+	return &TimersComponent{
+		trace:  tr,
+		canvas: cv,
+		groups: theme.Compute(&tr.analyses, win, timerReportKey{}, func() []timerGroup {
+			return computeTimerReport(tr)
+		}),
+	}
+}
+
+// Title implements theme.Component.
+func (*TimersComponent) Title() string {
+	return tr("Timers and sleeps")
+}
+
+// Transition implements theme.Component.
+func (*TimersComponent) Transition(state theme.ComponentState) {}
+
+// WantsTransition implements theme.Component.
+func (*TimersComponent) WantsTransition(gtx layout.Context) theme.ComponentState {
+	return theme.ComponentStateNone
+}
+
+func (tc *TimersComponent) HoveredLink() ObjectLink {
+	return tc.cellFormatter.HoveredLink()
+}
+
+// groupSpans returns the wakeups of a group as spans in the timelines of their goroutines.
+func (tc *TimersComponent) groupSpans(group *timerGroup) Items[ptrace.Span] {
+	// The wakeups are grouped by goroutine because we collected them one goroutine at a time.
+	return goroutineSpans(tc.canvas, group.Wakeups, func(w *timerWakeup) (*ptrace.Goroutine, *ptrace.Span) {
+		return w.g, w.span
+	})
+}
+
+func (tc *TimersComponent) initTable(win *theme.Window, gtx layout.Context) {
+	if tc.table != nil {
+		return
+	}
+	tc.table = &theme.Table{}
+	tc.table.SetColumns(win, gtx, []theme.Column{
+		{Name: "Wakeups", Alignment: text.End},
+		{Name: "Rate", Alignment: text.End},
+		{Name: "Kind", Alignment: text.Start},
+		{Name: "Blocked in", Alignment: text.Start},
+		{Name: "Total time", Alignment: text.End},
+		{Name: "Example", Alignment: text.Start},
+	})
+}
+
+// Layout implements theme.Component.
+func (tc *TimersComponent) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.TimersComponent.Layout").End()
+
+	groups, ok := tc.groups.Result()
+	if !ok {
+		return theme.Loading(win.Theme, tr("Aggregating timer wakeups…")).Layout(win, gtx)
+	}
+	if len(groups) == 0 {
+		return layout.Center.Layout(gtx, theme.Dumb(win, theme.Label(win.Theme, tr("No goroutines were woken up by timers.")).Layout))
+	}
+
+	tc.initTable(win, gtx)
+	tc.table.Update(gtx)
+	tc.cellFormatter.Update(win, gtx)
+	if tc.spans == nil {
+		tc.spans = make([]Items[ptrace.Span], len(groups))
+	}
+	seconds := tc.trace.Duration().Seconds()
+
+	cellFn := func(win *theme.Window, gtx layout.Context, row, col int) layout.Dimensions {
+		defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+
+		group := &groups[row]
+		switch colName := tc.table.Columns[col].Name; colName {
+		case "Wakeups":
+			spans := tc.spans[row]
+			if spans == nil {
+				spans = tc.groupSpans(group)
+				tc.spans[row] = spans
+			}
+			return layout.RightAligned(gtx, func(gtx layout.Context) layout.Dimensions {
+				link := tc.cellFormatter.Clicks.Grow()
+				link.Link = &SpansObjectLink{Spans: spans}
+				return link.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+					return widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, font.Font{}, win.Theme.TextSize, local.Sprintf("%d", len(group.Wakeups)), win.ColorMaterial(gtx, win.Theme.Palette.OpenLink))
+				})
+			})
+		case "Rate":
+			return layout.RightAligned(gtx, func(gtx layout.Context) layout.Dimensions {
+				return tc.cellFormatter.Text(win, gtx, local.Sprintf("%.1f/s", float64(len(group.Wakeups))/seconds))
+			})
+		case "Kind":
+			switch group.Sleeps {
+			case 0:
+				return tc.cellFormatter.Text(win, gtx, "timer")
+			case len(group.Wakeups):
+				return tc.cellFormatter.Text(win, gtx, "sleep")
+			default:
+				return tc.cellFormatter.Text(win, gtx, "various")
+			}
+		case "Blocked in":
+			if group.Site == "" {
+				return tc.cellFormatter.Text(win, gtx, "unknown")
+			}
+			return tc.cellFormatter.Text(win, gtx, group.Site)
+		case "Total time":
+			return tc.cellFormatter.Duration(win, gtx, group.Total, false)
+		case "Example":
+			w := group.Median
+			items := SimpleItems[ptrace.Span, any]{items: []ptrace.Span{*w.span}}
+			if tl := tc.canvas.itemToTimeline[w.g]; tl != nil {
+				items.container = ItemContainer{Timeline: tl, Track: tl.tracks[0]}
+			}
+			return tc.cellFormatter.Spans(win, gtx, items)
+		default:
+			panic(colName)
+		}
+	}
+
+	return theme.SimpleTable(win, gtx, tc.table, &tc.scrollState, len(groups), cellFn)
+}
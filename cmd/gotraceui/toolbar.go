@@ -0,0 +1,65 @@
+package main
+
+import (
+	"honnef.co/go/gotraceui/theme"
+)
+
+// mainToolbar offers the most common actions of the main menu as buttons. The items mirror the labels, shortcuts, and
+// disabled states of the menu items, and clicking an item has the same effect as clicking the menu item.
+type mainToolbar struct {
+	toolbar theme.Toolbar
+
+	UndoNavigation   theme.ToolbarItem
+	RedoNavigation   theme.ToolbarItem
+	ScrollToTop      theme.ToolbarItem
+	ZoomToFit        theme.ToolbarItem
+	HighlightSpans   theme.ToolbarItem
+	PrevSearchResult theme.ToolbarItem
+	NextSearchResult theme.ToolbarItem
+	SearchFunctions  theme.ToolbarItem
+	TogglePanelArea  theme.ToolbarItem
+
+	// The menu items that the toolbar items mirror.
+	mirrors []toolbarMirror
+}
+
+type toolbarMirror struct {
+	item *theme.ToolbarItem
+	menu *theme.MenuItem
+}
+
+func (tb *mainToolbar) init(m *MainMenu) {
+	// The icons are limited to glyphs that are included in the Go fonts.
+	add := func(item *theme.ToolbarItem, icon string, mi *theme.MenuItem) *theme.ToolbarItem {
+		item.Icon = icon
+		item.Label = mi.Label
+		item.Disabled = mi.Disabled
+		tb.mirrors = append(tb.mirrors, toolbarMirror{item, mi})
+		return item
+	}
+	tb.toolbar.Items = []*theme.ToolbarItem{
+		add(&tb.UndoNavigation, "←", &m.Display.UndoNavigation),
+		add(&tb.RedoNavigation, "→", &m.Display.RedoNavigation),
+		nil,
+		add(&tb.ScrollToTop, "↑", &m.Display.ScrollToTop),
+		add(&tb.ZoomToFit, "↔", &m.Display.ZoomToFit),
+		nil,
+		add(&tb.HighlightSpans, "▒", &m.Display.HighlightSpans),
+		add(&tb.PrevSearchResult, "▲", &m.Display.PrevSearchResult),
+		add(&tb.NextSearchResult, "▼", &m.Display.NextSearchResult),
+		add(&tb.SearchFunctions, "ƒ", &m.Analyze.OpenFunctions),
+		nil,
+		add(&tb.TogglePanelArea, "▐", &m.Display.TogglePanelArea),
+	}
+	// The menu item's label describes what clicking it does, which flips with the state of the panel area. The toolbar
+	// item displays the state instead.
+	tb.TogglePanelArea.Label = func() string { return tr("Panel area") }
+	tb.TogglePanelArea.Toggle = true
+}
+
+// syncShortcuts copies the shortcuts of the menu items to the toolbar items.
+func (tb *mainToolbar) syncShortcuts() {
+	for _, m := range tb.mirrors {
+		m.item.Shortcut = m.menu.Shortcut
+	}
+}
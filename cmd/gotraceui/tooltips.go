@@ -0,0 +1,400 @@
+package main
+
+import (
+	stdcmp "cmp"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	rtrace "runtime/trace"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/widget"
+)
+
+// Tooltip templates
+//
+// The contents of the tooltips of goroutine spans and goroutines are described by templates, which users can change to
+// add or remove information. A template consists of lines of text, in which {field} is replaced by the value of a
+// field. Text in square brackets is only displayed if all the fields it refers to have values, and lines that end up
+// empty that way are left out. For example, "[In: {function}]" displays the function a span is in, and nothing at all
+// if that isn't known.
+
+// TooltipConfig configures the contents of tooltips. Empty templates use the defaults.
+type TooltipConfig struct {
+	// Span is the template of tooltips of individual goroutine spans.
+	Span string `json:"span,omitempty"`
+	// Goroutine is the template of tooltips of goroutines, as displayed when hovering timeline labels.
+	Goroutine string `json:"goroutine,omitempty"`
+}
+
+const defaultSpanTooltipTemplate = `{state}[ ({tags})]
+[Swept {swept} bytes, reclaimed {reclaimed} bytes]
+[Unblocked by goroutine {unblocked-by}]
+[Reason: {reason}]
+[In: {function}]
+[On: processor {processor}]
+Duration: {duration}`
+
+const defaultGoroutineTooltipTemplate = `Goroutine {id}[: {function}]
+
+Created at: {created}
+Returned at: {returned}
+[Lifetime: {lifetime}][Observed duration: {observed}]
+Spans: {spans}
+Time in blocked states: {blocked}
+Time in inactive states: {inactive}
+Time in GC assist: {gc-assist}
+Time in running states: {running}`
+
+// A tooltipField is a field that can be used in a tooltip template.
+type tooltipField struct {
+	Name        string
+	Description string
+}
+
+var spanTooltipFields = []tooltipField{
+	{"state", "the state of the goroutine"},
+	{"tags", "tags further describing the state"},
+	{"start", "the start of the span"},
+	{"end", "the end of the span"},
+	{"duration", "the duration of the span"},
+	{"function", "the function the goroutine was in"},
+	{"processor", "the processor that ran the goroutine"},
+	{"unblocked-by", "the goroutine that unblocked the goroutine"},
+	{"reason", "why the goroutine is in its state"},
+	{"swept", "the bytes swept by GC sweeping"},
+	{"reclaimed", "the bytes reclaimed by GC sweeping"},
+	{"event", "the ID of the event that started the span"},
+}
+
+var goroutineTooltipFields = []tooltipField{
+	{"id", "the goroutine's ID"},
+	{"function", "the goroutine's function"},
+	{"created", "when the goroutine was created"},
+	{"returned", "when the goroutine returned"},
+	{"lifetime", "how long the goroutine existed, if it was created and returned during the trace"},
+	{"observed", "how long the goroutine was observed, if it wasn't created or didn't return during the trace"},
+	{"spans", "the number of spans"},
+	{"blocked", "the time spent in blocked states"},
+	{"inactive", "the time spent in inactive states"},
+	{"gc-assist", "the time spent assisting the GC"},
+	{"running", "the time spent running"},
+}
+
+// A templatePart is a literal text, a field, or an optional sequence of texts and fields.
+type templatePart struct {
+	text     string
+	field    string
+	optional []templatePart
+}
+
+// tooltipTemplate is a parsed tooltip template.
+type tooltipTemplate struct {
+	lines [][]templatePart
+}
+
+// parseTooltipTemplate parses a template that may only refer to the given fields.
+func parseTooltipTemplate(s string, fields []tooltipField) (*tooltipTemplate, error) {
+	tmpl := &tooltipTemplate{}
+	for i, line := range strings.Split(s, "\n") {
+		parts, err := parseTemplateLine(line, fields)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		tmpl.lines = append(tmpl.lines, parts)
+	}
+	return tmpl, nil
+}
+
+func parseTemplateLine(line string, fields []tooltipField) ([]templatePart, error) {
+	var (
+		parts    []templatePart
+		optional *templatePart
+	)
+	add := func(p templatePart) {
+		if optional != nil {
+			optional.optional = append(optional.optional, p)
+		} else {
+			parts = append(parts, p)
+		}
+	}
+	for line != "" {
+		switch line[0] {
+		case '{':
+			name, rest, ok := strings.Cut(line[1:], "}")
+			if !ok {
+				return nil, errors.New("missing }")
+			}
+			if !slices.ContainsFunc(fields, func(f tooltipField) bool { return f.Name == name }) {
+				return nil, fmt.Errorf("unknown field %q", name)
+			}
+			add(templatePart{field: name})
+			line = rest
+		case '}':
+			return nil, errors.New("unexpected }")
+		case '[':
+			if optional != nil {
+				return nil, errors.New("square brackets can't be nested")
+			}
+			optional = &templatePart{}
+			line = line[1:]
+		case ']':
+			if optional == nil {
+				return nil, errors.New("unexpected ]")
+			}
+			parts = append(parts, *optional)
+			optional = nil
+			line = line[1:]
+		default:
+			n := strings.IndexAny(line, "{}[]")
+			if n == -1 {
+				n = len(line)
+			}
+			add(templatePart{text: line[:n]})
+			line = line[n:]
+		}
+	}
+	if optional != nil {
+		return nil, errors.New("missing ]")
+	}
+	return parts, nil
+}
+
+// render fills in the template, using value to look up the values of fields. value returns the empty string for
+// fields that don't have a value.
+func (tmpl *tooltipTemplate) render(value func(field string) string) string {
+	var out []string
+	var line strings.Builder
+	renderParts := func(parts []templatePart) {
+		for _, p := range parts {
+			if p.field != "" {
+				line.WriteString(value(p.field))
+			} else {
+				line.WriteString(p.text)
+			}
+		}
+	}
+	for _, parts := range tmpl.lines {
+		line.Reset()
+		for _, p := range parts {
+			if p.optional == nil {
+				renderParts([]templatePart{p})
+				continue
+			}
+			complete := true
+			for _, op := range p.optional {
+				if op.field != "" && value(op.field) == "" {
+					complete = false
+					break
+				}
+			}
+			if complete {
+				renderParts(p.optional)
+			}
+		}
+		if line.Len() == 0 && len(parts) != 0 {
+			// All of the line was optional and left out.
+			continue
+		}
+		out = append(out, line.String())
+	}
+	return strings.Join(out, "\n")
+}
+
+type tooltipTemplates struct {
+	span      *tooltipTemplate
+	goroutine *tooltipTemplate
+}
+
+// activeTooltipTemplates are the tooltip templates in effect. They are shared by all main windows.
+var activeTooltipTemplates atomic.Pointer[tooltipTemplates]
+
+func compileTooltipConfig(cfg TooltipConfig) (*tooltipTemplates, error) {
+	span, err := parseTooltipTemplate(stdcmp.Or(cfg.Span, defaultSpanTooltipTemplate), spanTooltipFields)
+	if err != nil {
+		return nil, fmt.Errorf("span tooltip: %w", err)
+	}
+	g, err := parseTooltipTemplate(stdcmp.Or(cfg.Goroutine, defaultGoroutineTooltipTemplate), goroutineTooltipFields)
+	if err != nil {
+		return nil, fmt.Errorf("goroutine tooltip: %w", err)
+	}
+	return &tooltipTemplates{span: span, goroutine: g}, nil
+}
+
+// applyTooltipConfig makes the tooltip templates take effect in all windows.
+func applyTooltipConfig(cfg TooltipConfig) error {
+	tt, err := compileTooltipConfig(cfg)
+	if err != nil {
+		return err
+	}
+	activeTooltipTemplates.Store(tt)
+	return nil
+}
+
+var defaultTooltipTemplates = func() *tooltipTemplates {
+	tt, err := compileTooltipConfig(TooltipConfig{})
+	if err != nil {
+		panic(err)
+	}
+	return tt
+}()
+
+// currentTooltipTemplates returns the tooltip templates in effect.
+func currentTooltipTemplates() *tooltipTemplates {
+	if tt := activeTooltipTemplates.Load(); tt != nil {
+		return tt
+	}
+	return defaultTooltipTemplates
+}
+
+// TooltipsDialog lets the user edit the tooltip templates.
+type TooltipsDialog struct {
+	span      widget.Editor
+	goroutine widget.Editor
+
+	apply widget.PrimaryClickable
+	reset widget.PrimaryClickable
+	err   string
+}
+
+func NewTooltipsDialog() *TooltipsDialog {
+	td := &TooltipsDialog{}
+	userConfigMu.Lock()
+	var cfg TooltipConfig
+	if userConfig.Tooltips != nil {
+		cfg = *userConfig.Tooltips
+	}
+	userConfigMu.Unlock()
+	td.set(cfg)
+	return td
+}
+
+func (td *TooltipsDialog) set(cfg TooltipConfig) {
+	td.span.SetText(stdcmp.Or(cfg.Span, defaultSpanTooltipTemplate))
+	td.goroutine.SetText(stdcmp.Or(cfg.Goroutine, defaultGoroutineTooltipTemplate))
+}
+
+// config returns the configuration described by the dialog's inputs. Templates that match the defaults are left empty,
+// so that changes to the defaults take effect.
+func (td *TooltipsDialog) config() (TooltipConfig, error) {
+	cfg := TooltipConfig{Span: td.span.Text(), Goroutine: td.goroutine.Text()}
+	if cfg.Span == defaultSpanTooltipTemplate {
+		cfg.Span = ""
+	}
+	if cfg.Goroutine == defaultGoroutineTooltipTemplate {
+		cfg.Goroutine = ""
+	}
+	if _, err := compileTooltipConfig(cfg); err != nil {
+		return TooltipConfig{}, err
+	}
+	return cfg, nil
+}
+
+func (td *TooltipsDialog) save(win *theme.Window, cfg TooltipConfig) {
+	if err := applyTooltipConfig(cfg); err != nil {
+		td.err = err.Error()
+		return
+	}
+	td.err = ""
+
+	userConfigMu.Lock()
+	defer userConfigMu.Unlock()
+	if cfg == (TooltipConfig{}) {
+		userConfig.Tooltips = nil
+	} else {
+		userConfig.Tooltips = &cfg
+	}
+	if err := userConfig.Save(); err != nil {
+		win.Notify(theme.NotificationError, local.Sprintf("Couldn't save configuration: %s", err))
+	}
+}
+
+func (td *TooltipsDialog) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.TooltipsDialog.Layout").End()
+
+	for td.apply.Clicked(gtx) {
+		if cfg, err := td.config(); err != nil {
+			td.err = err.Error()
+		} else {
+			td.save(win, cfg)
+		}
+	}
+	for td.reset.Clicked(gtx) {
+		td.set(TooltipConfig{})
+		td.save(win, TooltipConfig{})
+	}
+
+	fieldNames := func(fields []tooltipField) string {
+		names := make([]string, len(fields))
+		for i, f := range fields {
+			names[i] = "{" + f.Name + "}: " + f.Description
+		}
+		return strings.Join(names, "\n")
+	}
+	template := func(title string, ed *widget.Editor, fields []tooltipField) layout.Widget {
+		return func(gtx layout.Context) layout.Dimensions {
+			return layout.Rigids(gtx, layout.Vertical,
+				func(gtx layout.Context) layout.Dimensions {
+					return theme.LineLabel(win.Theme, title).Layout(win, gtx)
+				},
+				func(gtx layout.Context) layout.Dimensions {
+					return layout.Flex{Axis: layout.Horizontal}.Layout(gtx,
+						layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+							return theme.TextBox(win.Theme, ed, "").Layout(win, gtx)
+						}),
+						layout.Rigid(layout.Spacer{Width: 10}.Layout),
+						layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+							return theme.Label(win.Theme, fieldNames(fields)).Layout(win, gtx)
+						}),
+					)
+				},
+			)
+		}
+	}
+
+	return layout.Rigids(gtx, layout.Vertical,
+		func(gtx layout.Context) layout.Dimensions {
+			return theme.Label(win.Theme, tr("{field} displays the value of a field. Text in square brackets is only displayed if all of its fields have values. Lines that end up empty are left out.")).Layout(win, gtx)
+		},
+		layout.Spacer{Height: 10}.Layout,
+		template("Goroutine spans", &td.span, spanTooltipFields),
+		layout.Spacer{Height: 10}.Layout,
+		template("Goroutines", &td.goroutine, goroutineTooltipFields),
+		layout.Spacer{Height: 10}.Layout,
+		func(gtx layout.Context) layout.Dimensions {
+			return layout.Rigids(gtx, layout.Horizontal,
+				func(gtx layout.Context) layout.Dimensions {
+					return theme.Button(win.Theme, &td.apply.Clickable, tr("Apply")).Layout(win, gtx)
+				},
+				layout.Spacer{Width: 5}.Layout,
+				func(gtx layout.Context) layout.Dimensions {
+					return theme.Button(win.Theme, &td.reset.Clickable, tr("Reset to defaults")).Layout(win, gtx)
+				},
+			)
+		},
+		func(gtx layout.Context) layout.Dimensions {
+			if td.err == "" {
+				return layout.Dimensions{}
+			}
+			l := theme.Label(win.Theme, td.err)
+			l.Color = colors[colorStateBlocked]
+			return l.Layout(win, gtx)
+		},
+	)
+}
+
+func displayTooltipsDialog(win *theme.Window) {
+	td := NewTooltipsDialog()
+	win.SetModal(func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+		return theme.Dialog(win.Theme, tr("Tooltips")).Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+			gtx.Constraints.Min = gtx.Constraints.Constrain(image.Pt(900, 600))
+			gtx.Constraints.Max = gtx.Constraints.Min
+			return td.Layout(win, gtx)
+		})
+	})
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"container/heap"
+	"context"
+	rtrace "runtime/trace"
+	"slices"
+	"strings"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/trace/ptrace"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/op/clip"
+	"gioui.org/text"
+	exptrace "golang.org/x/exp/trace"
+)
+
+type topSpan struct {
+	g    *ptrace.Goroutine
+	span *ptrace.Span
+}
+
+// topSpanHeap is a min-heap of spans, ordered by duration, used for finding the N longest spans.
+type topSpanHeap []topSpan
+
+func (h topSpanHeap) Len() int           { return len(h) }
+func (h topSpanHeap) Less(i, j int) bool { return h[i].span.Duration() < h[j].span.Duration() }
+func (h topSpanHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *topSpanHeap) Push(x any)        { *h = append(*h, x.(topSpan)) }
+func (h *topSpanHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+type topSpansFilter struct {
+	N int
+	// The state to filter by, or StateNone for all states.
+	State ptrace.SchedulingState
+	// The function of goroutines to filter by, or nil for all goroutines.
+	Function *ptrace.Function
+	// The time range that spans have to overlap, if Start < End.
+	Start, End exptrace.Time
+	// The global filter, which applies in addition to the other criteria.
+	Global *GlobalFilter
+}
+
+// spansOverlapping returns the subslice of spans that overlap the time range [start, end). spans must be sorted and
+// not overlap each other.
+func spansOverlapping(spans []ptrace.Span, start, end exptrace.Time) []ptrace.Span {
+	// Spans are sorted by start time and don't overlap, so they're also sorted by end time.
+	lo, _ := slices.BinarySearchFunc(spans, start, func(s ptrace.Span, t exptrace.Time) int {
+		return cmp(s.End, t, false)
+	})
+	hi, _ := slices.BinarySearchFunc(spans, end, func(s ptrace.Span, t exptrace.Time) int {
+		return cmp(s.Start, t, false)
+	})
+	return spans[lo:max(lo, hi)]
+}
+
+// topSpans returns the f.N longest goroutine spans matching the filter, in descending order of duration. Spans that
+// haven't ended by the end of the trace are ignored because their duration is unknown.
+func topSpans(tr *Trace, f topSpansFilter, cancelled <-chan struct{}) []topSpan {
+	defer rtrace.StartRegion(context.Background(), "main.topSpans").End()
+
+	gs := tr.Goroutines
+	if f.Function != nil {
+		gs = f.Function.Goroutines
+	}
+	h := make(topSpanHeap, 0, f.N)
+	for _, g := range gs {
+		select {
+		case <-cancelled:
+			return nil
+		default:
+		}
+		if !f.Global.MatchGoroutine(g) {
+			continue
+		}
+
+		spans := g.Spans
+		if f.Start < f.End {
+			spans = spansOverlapping(spans, f.Start, f.End)
+		}
+		for i := range spans {
+			span := &spans[i]
+			if span.EndEvent == -1 || (f.State != ptrace.StateNone && span.State != f.State) || !f.Global.MatchSpan(span) {
+				continue
+			}
+			if len(h) < f.N {
+				heap.Push(&h, topSpan{g, span})
+			} else if span.Duration() > h[0].span.Duration() {
+				h[0] = topSpan{g, span}
+				heap.Fix(&h, 0)
+			}
+		}
+	}
+
+	out := []topSpan(h)
+	slices.SortFunc(out, func(a, b topSpan) int {
+		return cmp(a.span.Duration(), b.span.Duration(), true)
+	})
+	return out
+}
+
+// TopSpansComponent lists the longest goroutine spans, optionally filtered by state, goroutine function, and time
+// range. This finds outliers without having to scan the timelines for them.
+type TopSpansComponent struct {
+	trace  *Trace
+	canvas *Canvas
+
+	n        *theme.NumberInputState[int]
+	state    theme.ComboBoxState
+	function theme.ComboBoxState
+	visible  widget.Bool
+	// The time range that was visible in the canvas when the user checked visible.
+	start, end exptrace.Time
+	// The state of the global filter that spans was computed with.
+	globalKey globalFilterKey
+
+	spans *theme.Future[[]topSpan]
+
+	table         *theme.Table
+	scrollState   theme.YScrollableListState
+	cellFormatter CellFormatter
+}
+
+func NewTopSpansComponent(tr *Trace, cv *Canvas) *TopSpansComponent {
+	tc := &TopSpansComponent{
+		trace:  tr,
+		canvas: cv,
+		n:      theme.NewIntInput(100, 1, 10000, 10),
+	}
+
+	states := []theme.ListWindowItem{{Item: ptrace.StateNone, Label: local.Sprintf("All states")}}
+	for state := ptrace.StateInactive; state <= ptrace.StateGCSweep; state++ {
+		if stateNamesCapitalized[state] == "" {
+			continue
+		}
+		states = append(states, theme.ListWindowItem{Item: state, Label: stateNamesCapitalized[state]})
+	}
+	tc.state.SetItems(states)
+	tc.state.SetSelected(0)
+
+	fns := make([]*ptrace.Function, 0, len(tr.Functions))
+	for _, fn := range tr.Functions {
+		if len(fn.Goroutines) > 0 {
+			fns = append(fns, fn)
+		}
+	}
+	slices.SortFunc(fns, func(a, b *ptrace.Function) int {
+		return strings.Compare(a.Func, b.Func)
+	})
+	items := make([]theme.ListWindowItem, 0, len(fns)+1)
+	items = append(items, theme.ListWindowItem{Item: (*ptrace.Function)(nil), Label: local.Sprintf("All functions")})
+	for _, fn := range fns {
+		items = append(items, theme.ListWindowItem{Item: fn, Label: fn.Func})
+	}
+	tc.function.SetItems(items)
+	tc.function.SetSelected(0)
+
+	return tc
+}
+
+func (tc *TopSpansComponent) filter() topSpansFilter {
+	f := topSpansFilter{N: tc.n.Value(), Global: tc.trace.filter.Clone()}
+	if item, ok := tc.state.Selected(); ok {
+		f.State = item.Item.(ptrace.SchedulingState)
+	}
+	if item, ok := tc.function.Selected(); ok {
+		f.Function = item.Item.(*ptrace.Function)
+	}
+	if tc.visible.Value {
+		f.Start, f.End = tc.start, tc.end
+	}
+	return f
+}
+
+func (tc *TopSpansComponent) compute(win *theme.Window) {
+	f := tc.filter()
+	tc.spans = theme.NewFuture(win, func(cancelled <-chan struct{}) []topSpan {
+		return topSpans(tc.trace, f, cancelled)
+	})
+}
+
+// Title implements theme.Component.
+func (*TopSpansComponent) Title() string {
+	return tr("Longest spans")
+}
+
+// Transition implements theme.Component.
+func (*TopSpansComponent) Transition(state theme.ComponentState) {}
+
+// WantsTransition implements theme.Component.
+func (*TopSpansComponent) WantsTransition(gtx layout.Context) theme.ComponentState {
+	return theme.ComponentStateNone
+}
+
+func (tc *TopSpansComponent) HoveredLink() ObjectLink {
+	return tc.cellFormatter.HoveredLink()
+}
+
+func (tc *TopSpansComponent) initTable(win *theme.Window, gtx layout.Context) {
+	if tc.table != nil {
+		return
+	}
+	tc.table = &theme.Table{}
+	tc.table.SetColumns(win, gtx, []theme.Column{
+		{Name: "Span", Alignment: text.Start},
+		{Name: "Duration", Alignment: text.End},
+		{Name: "Start", Alignment: text.End},
+		{Name: "State", Alignment: text.Start},
+		{Name: "Goroutine", Alignment: text.Start},
+		{Name: "Function", Alignment: text.Start},
+	})
+}
+
+// Layout implements theme.Component.
+func (tc *TopSpansComponent) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.TopSpansComponent.Layout").End()
+
+	// Don't short-circuit, so that all inputs consume their changes.
+	changed := tc.spans == nil
+	changed = tc.n.Changed() || changed
+	changed = tc.state.Changed() || changed
+	changed = tc.function.Changed() || changed
+	if k := tc.trace.filter.Key(); k != tc.globalKey {
+		tc.globalKey = k
+		changed = true
+	}
+	if tc.visible.Update(gtx) {
+		tc.start, tc.end = tc.canvas.start, tc.canvas.End()
+		changed = true
+	}
+	if changed {
+		tc.compute(win)
+	}
+
+	tc.initTable(win, gtx)
+	tc.table.Update(gtx)
+	tc.cellFormatter.Update(win, gtx)
+
+	visibleLabel := "Only spans in the visible time range"
+	if tc.visible.Value {
+		visibleLabel = local.Sprintf("Only spans between %s and %s",
+			formatTimestamp(nil, tc.trace.AdjustedTime(tc.start)), formatTimestamp(nil, tc.trace.AdjustedTime(tc.end)))
+	}
+	filters := func(gtx layout.Context) layout.Dimensions {
+		return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+			layout.Rigid(theme.Dumb(win, theme.LineLabel(win.Theme, tr("Number of spans: ")).Layout)),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				gtx.Constraints.Max.X = min(gtx.Constraints.Max.X, gtx.Dp(150))
+				gtx.Constraints.Min.X = gtx.Constraints.Max.X
+				return theme.NumberInput(win.Theme, tc.n, "Spans").Layout(win, gtx)
+			}),
+			layout.Rigid(layout.Spacer{Width: 10}.Layout),
+			layout.Rigid(theme.Dumb(win, theme.ComboBox(win.Theme, &tc.state, "State").Layout)),
+			layout.Rigid(layout.Spacer{Width: 10}.Layout),
+			layout.Rigid(theme.Dumb(win, theme.ComboBox(win.Theme, &tc.function, "Function").Layout)),
+			layout.Rigid(layout.Spacer{Width: 10}.Layout),
+			layout.Rigid(theme.Dumb(win, theme.CheckBox(win.Theme, &tc.visible, visibleLabel).Layout)),
+		)
+	}
+
+	results := func(gtx layout.Context) layout.Dimensions {
+		spans, ok := tc.spans.Result()
+		if !ok {
+			return theme.Loading(win.Theme, tr("Finding longest spans…")).Layout(win, gtx)
+		}
+		if len(spans) == 0 {
+			return layout.Center.Layout(gtx, theme.Dumb(win, theme.Label(win.Theme, tr("No spans match the filters.")).Layout))
+		}
+
+		cellFn := func(win *theme.Window, gtx layout.Context, row, col int) layout.Dimensions {
+			defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+
+			ts := &spans[row]
+			switch colName := tc.table.Columns[col].Name; colName {
+			case "Span":
+				items := SimpleItems[ptrace.Span, any]{items: []ptrace.Span{*ts.span}}
+				if tl := tc.canvas.itemToTimeline[ts.g]; tl != nil {
+					items.container = ItemContainer{Timeline: tl, Track: tl.tracks[0]}
+				}
+				return tc.cellFormatter.Spans(win, gtx, items)
+			case "Duration":
+				return tc.cellFormatter.Duration(win, gtx, ts.span.Duration(), false)
+			case "Start":
+				return tc.cellFormatter.Timestamp(win, gtx, tc.trace, ts.span.Start, "")
+			case "State":
+				return tc.cellFormatter.Text(win, gtx, stateNamesCapitalized[ts.span.State])
+			case "Goroutine":
+				return tc.cellFormatter.Goroutine(win, gtx, ts.g, "")
+			case "Function":
+				if ts.g.Function == nil {
+					return tc.cellFormatter.Text(win, gtx, "unknown")
+				}
+				return tc.cellFormatter.Function(win, gtx, ts.g.Function)
+			default:
+				panic(colName)
+			}
+		}
+		return theme.SimpleTable(win, gtx, tc.table, &tc.scrollState, len(spans), cellFn)
+	}
+
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(filters),
+		layout.Rigid(layout.Spacer{Height: 5}.Layout),
+		layout.Flexed(1, results),
+	)
+}
@@ -1,6 +1,11 @@
 package main
 
 import (
+	"path/filepath"
+	"strings"
+	"time"
+
+	"honnef.co/go/gotraceui/theme"
 	"honnef.co/go/gotraceui/trace/ptrace"
 
 	exptrace "golang.org/x/exp/trace"
@@ -15,19 +20,76 @@ type Trace struct {
 	GOROOT string
 	GOPATH string
 
+	// NetWaits are the periods during which goroutines were parked on network IO, and netWaitCounts the number of
+	// goroutines for each period. See netWaits.
+	NetWaits      []ptrace.Span
+	netWaitCounts []int
+
+	// If the trace was thinned while loading, ThinningThreshold is the duration below which spans got merged, and
+	// ThinnedSpans the number of spans that got removed.
+	ThinningThreshold time.Duration
+	ThinnedSpans      int
+
 	allGoroutineSpanLabels [][]string
 	allProcessorSpanLabels [][]string
+
+	// Memoized results of expensive analyses, shared by all panels.
+	analyses theme.Memo
+
+	// The filter that narrows down all views that opt into it.
+	filter GlobalFilter
+
+	// The imported distributed trace whose spans correspond to tasks, if any. Only accessed from the window's
+	// goroutine.
+	otel *OTelImport
 }
 
 // AdjustedTime represents a timestamp with the time offset already applied.
 type AdjustedTime exptrace.Time
 
+// timeOffset returns the offset to apply to timestamps for display. It is zero if the user asked for absolute
+// timestamps.
+func (t *Trace) timeOffset() exptrace.Time {
+	if currentTimeFormatConfig().AbsoluteTimestamps {
+		return 0
+	}
+	return t.TimeOffset
+}
+
 func (t *Trace) AdjustedTime(ts exptrace.Time) AdjustedTime {
-	return AdjustedTime(ts + t.TimeOffset)
+	return AdjustedTime(ts + t.timeOffset())
 }
 
 func (t *Trace) UnadjustedTime(ts AdjustedTime) exptrace.Time {
-	return exptrace.Time(ts) - t.TimeOffset
+	return exptrace.Time(ts) - t.timeOffset()
+}
+
+// displayPath shortens a path to a source file by replacing the GOROOT or GOPATH it is in with $GOROOT or $GOPATH.
+func (t *Trace) displayPath(file string) string {
+	if goroot := t.GOROOT; goroot != "" && strings.HasPrefix(file, goroot) {
+		return filepath.Join("$GOROOT", strings.TrimPrefix(file, goroot))
+	} else if gopath := t.GOPATH; gopath != "" && strings.HasPrefix(file, gopath) {
+		return filepath.Join("$GOPATH", strings.TrimPrefix(file, gopath))
+	} else if goroot == "" && gopath == "" {
+		// We couldn't detect goroot, which makes it very likely that the executable had paths trimmed. Detect if
+		// the trimmed path is in GOROOT or GOPATH based on if the first path element has a dot in it or not. Module
+		// paths without dots are reserved for the standard library. This has a small but negligible chance of false
+		// positives.
+
+		left, _, ok := strings.Cut(file, "/")
+		if ok {
+			if strings.Contains(left, ".") {
+				if strings.Contains(file, "@v") {
+					return filepath.Join("$GOPATH", "pkg", "mod", file)
+				} else {
+					return filepath.Join("$GOPATH", "src", file)
+				}
+			} else {
+				return filepath.Join("$GOROOT", "src", file)
+			}
+		}
+	}
+	return file
 }
 
 func (t *Trace) goroutineSpanLabels(g *ptrace.Goroutine) []string {
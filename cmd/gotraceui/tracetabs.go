@@ -0,0 +1,154 @@
+package main
+
+// Trace tabs
+//
+// A main window can display multiple traces, each in its own tab. The state that belongs to a trace, such as its
+// canvas, tabs, and panels, is stored in a traceTab. The fields of the MainWindow hold the state of the active trace;
+// switching to another trace stores them in the active trace's traceTab and loads the other trace's state into them.
+// This keeps the rest of the code, which refers to the MainWindow's fields, unaware of there being multiple traces.
+//
+// The tab bar is only displayed while more than one trace is open.
+
+import (
+	"path/filepath"
+	"slices"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+
+	"gioui.org/app"
+)
+
+// traceTab holds the state of one of the traces displayed in a main window.
+type traceTab struct {
+	title string
+
+	canvas        *Canvas
+	trace         *Trace
+	tracePath     string
+	external      *externalSources
+	processes     []*processTrace
+	panels        *panelArea
+	searchResults *SearchResultsComponent
+	tabs          []Tab
+	tabbedState   theme.TabbedState
+}
+
+// traceTitle returns the title of a trace's tab, which is the name of the trace's file, if it is known.
+func traceTitle(path string) string {
+	if path == "" {
+		return "Trace"
+	}
+	return filepath.Base(path)
+}
+
+// saveTraceTab stores the state of the active trace in tt.
+func (mwin *MainWindow) saveTraceTab(tt *traceTab) {
+	tt.canvas = mwin.canvas
+	tt.trace = mwin.trace
+	tt.tracePath = mwin.tracePath
+	tt.external = mwin.external
+	tt.processes = mwin.processes
+	tt.panels = mwin.panels
+	tt.searchResults = mwin.searchResults
+	tt.tabs = mwin.tabs
+	tt.tabbedState = mwin.tabbedState
+}
+
+// restoreTraceTab makes tt the active trace.
+func (mwin *MainWindow) restoreTraceTab(tt *traceTab) {
+	mwin.canvas = tt.canvas
+	mwin.trace = tt.trace
+	mwin.tracePath = tt.tracePath
+	mwin.external = tt.external
+	mwin.processes = tt.processes
+	mwin.panels = tt.panels
+	mwin.searchResults = tt.searchResults
+	mwin.tabs = tt.tabs
+	mwin.tabbedState = tt.tabbedState
+	mwin.activeTrace = tt
+	mwin.win.Option(app.Title("gotraceui - " + tt.title))
+}
+
+// newTraceTab adds a tab for a trace that is about to be loaded into cv and makes it the active trace. The new trace
+// starts with the default tabs and an empty panel area that has the same structure as the previous trace's.
+func (mwin *MainWindow) newTraceTab(cv *Canvas) {
+	if mwin.activeTrace != nil {
+		mwin.saveTraceTab(mwin.activeTrace)
+	}
+	panels := newPanelArea()
+	panels.restore(mwin.panels.config())
+	tt := &traceTab{
+		canvas: cv,
+		panels: panels,
+		tabs: []Tab{
+			{
+				Component:  &TimelinesComponent{cv: cv},
+				Unclosable: true,
+			},
+		},
+	}
+	mwin.traceTabs = append(mwin.traceTabs, tt)
+	mwin.traceTabBar.Current = len(mwin.traceTabs) - 1
+	mwin.restoreTraceTab(tt)
+}
+
+// closeTraceTab stops the work that is being done for a trace that is no longer displayed.
+func (tt *traceTab) close() {
+	if tt.external != nil {
+		tt.external.cancel()
+	}
+	tt.panels.root.leaves(func(n *panelNode) {
+		for _, t := range n.group.tabs {
+			if t.panel != nil {
+				t.panel.Transition(theme.ComponentStateClosed)
+			}
+		}
+	})
+}
+
+// updateTraceTabs processes the events of the trace tab bar, switching between traces and closing them.
+func (mwin *MainWindow) updateTraceTabs(gtx layout.Context) {
+	if len(mwin.traceTabs) < 2 {
+		return
+	}
+	for _, ev := range mwin.traceTabBar.Update(gtx) {
+		switch ev.Kind {
+		case theme.TabClosed:
+			tt := mwin.traceTabs[ev.Index]
+			if tt == mwin.activeTrace {
+				mwin.saveTraceTab(tt)
+				mwin.activeTrace = nil
+			}
+			tt.close()
+			mwin.traceTabs = slices.Delete(mwin.traceTabs, ev.Index, ev.Index+1)
+		case theme.TabMoved:
+			tt := mwin.traceTabs[ev.Index]
+			mwin.traceTabs = slices.Delete(mwin.traceTabs, ev.Index, ev.Index+1)
+			mwin.traceTabs = slices.Insert(mwin.traceTabs, ev.To, tt)
+		}
+	}
+	mwin.traceTabBar.Current = min(mwin.traceTabBar.Current, len(mwin.traceTabs)-1)
+	if tt := mwin.traceTabs[mwin.traceTabBar.Current]; tt != mwin.activeTrace {
+		if mwin.activeTrace != nil {
+			mwin.saveTraceTab(mwin.activeTrace)
+		}
+		mwin.restoreTraceTab(tt)
+		// The selection may refer to an object of the previous trace.
+		mwin.twin.Selection.Set(nil)
+	}
+}
+
+// layoutTraceTabs displays the tab bar of the open traces, if there is more than one.
+func (mwin *MainWindow) layoutTraceTabs(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	if len(mwin.traceTabs) < 2 {
+		return layout.Dimensions{}
+	}
+	titles := make([]string, len(mwin.traceTabs))
+	for i, tt := range mwin.traceTabs {
+		titles[i] = tt.title
+	}
+	tb := theme.TabBar(win.Theme, &mwin.traceTabBar, titles)
+	tb.Closable = true
+	return tb.Layout(win, gtx)
+}
@@ -0,0 +1,341 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	rtrace "runtime/trace"
+	"slices"
+	"strings"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/mem"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/trace/ptrace"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/font"
+	"gioui.org/op/clip"
+	"gioui.org/text"
+	exptrace "golang.org/x/exp/trace"
+)
+
+type uniqueStacksKey struct{}
+
+// A uniqueStack is a sequence of PCs, together with all events that have it as their stack.
+type uniqueStack struct {
+	PCs []uint64
+	// Kinds is a bitmask of the exptrace.EventKind of the events.
+	Kinds  uint64
+	Events []ptrace.EventID
+}
+
+// Leaf returns the innermost function of the stack that isn't part of the runtime, or the innermost function if all
+// of them are.
+func (us *uniqueStack) Leaf(tr *Trace) string {
+	for _, pc := range us.PCs {
+		if fn := tr.FuncName(pc); !strings.HasPrefix(fn, "runtime.") {
+			return fn
+		}
+	}
+	if len(us.PCs) > 0 {
+		return tr.FuncName(us.PCs[0])
+	}
+	return "<empty stack>"
+}
+
+// KindNames returns the names of the kinds of events that the stack appears in.
+func (us *uniqueStack) KindNames() string {
+	var names []string
+	for k := range 64 {
+		if us.Kinds&(1<<k) != 0 {
+			names = append(names, exptrace.EventKind(k).String())
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// computeUniqueStacks groups the events of the trace by their stacks. Identical stacks from different generations
+// share a stack ID and are merged. The result is sorted by the number of events, in descending order.
+func computeUniqueStacks(tr *Trace) []uniqueStack {
+	defer rtrace.StartRegion(context.Background(), "main.computeUniqueStacks").End()
+
+	var out []uniqueStack
+	byStack := map[ptrace.StackID]int{}
+	for i, n := 0, tr.Events.Len(); i < n; i++ {
+		ev := tr.Events.Ptr(i)
+		stk := ev.Stack()
+		if stk == exptrace.NoStack {
+			continue
+		}
+		id := tr.StackID(stk)
+		idx, ok := byStack[id]
+		if !ok {
+			idx = len(out)
+			byStack[id] = idx
+			out = append(out, uniqueStack{PCs: tr.Stacks[id]})
+		}
+		us := &out[idx]
+		us.Kinds |= 1 << ev.Kind()
+		us.Events = append(us.Events, ptrace.EventID(i))
+	}
+	slices.SortStableFunc(out, func(a, b uniqueStack) int {
+		return len(b.Events) - len(a.Events)
+	})
+	return out
+}
+
+// UniqueStacksComponent lists every unique stack in the trace, how often it occurs and in which kinds of events. It is
+// useful for auditing which code paths were active at all. Selecting a stack shows its frames and its occurrences.
+type UniqueStacksComponent struct {
+	trace  *Trace
+	stacks *theme.Future[[]uniqueStack]
+
+	search     widget.Editor
+	prevSearch string
+	filtered   []int
+
+	table         *theme.Table
+	scrollState   theme.YScrollableListState
+	cellFormatter CellFormatter
+	selects       []widget.PrimaryClickable
+
+	// selected is an index into the unique stacks, or -1.
+	selected    int
+	split       theme.SplitterState
+	tabbedState theme.TabbedState
+	frames      widget.List
+	clicks      mem.BucketSlice[Link]
+	hoveredLink ObjectLink
+	instances   struct {
+		table         *theme.Table
+		scrollState   theme.YScrollableListState
+		cellFormatter CellFormatter
+	}
+}
+
+func NewUniqueStacksComponent(win *theme.Window, tr *Trace) *UniqueStacksComponent {
+	usc := &UniqueStacksComponent{
+		trace: tr,
+		stacks: theme.Compute(&tr.analyses, win, uniqueStacksKey{}, func() []uniqueStack {
+			return computeUniqueStacks(tr)
+		}),
+		selected: -1,
+		split: theme.SplitterState{
+			Axis:  layout.Vertical,
+			Ratio: 0.5,
+		},
+	}
+	usc.search.SingleLine = true
+	usc.frames.Axis = layout.Vertical
+	return usc
+}
+
+// Title implements theme.Component.
+func (*UniqueStacksComponent) Title() string {
+	return tr("Unique stacks")
+}
+
+// Transition implements theme.Component.
+func (*UniqueStacksComponent) Transition(state theme.ComponentState) {}
+
+// WantsTransition implements theme.Component.
+func (*UniqueStacksComponent) WantsTransition(gtx layout.Context) theme.ComponentState {
+	return theme.ComponentStateNone
+}
+
+func (usc *UniqueStacksComponent) HoveredLink() ObjectLink {
+	if usc.hoveredLink != nil {
+		return usc.hoveredLink
+	}
+	if l := usc.instances.cellFormatter.HoveredLink(); l != nil {
+		return l
+	}
+	return usc.cellFormatter.HoveredLink()
+}
+
+// filter updates the list of stacks that contain a function matching the search query.
+func (usc *UniqueStacksComponent) filter(stacks []uniqueStack) {
+	q := strings.TrimSpace(usc.search.Text())
+	usc.filtered = usc.filtered[:0]
+	for i := range stacks {
+		if q == "" || slices.ContainsFunc(stacks[i].PCs, func(pc uint64) bool {
+			return strings.Contains(usc.trace.FuncName(pc), q)
+		}) {
+			usc.filtered = append(usc.filtered, i)
+		}
+	}
+	usc.prevSearch = q
+}
+
+// Layout implements theme.Component.
+func (usc *UniqueStacksComponent) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.UniqueStacksComponent.Layout").End()
+
+	stacks, ok := usc.stacks.Result()
+	if !ok {
+		return theme.Loading(win.Theme, tr("Collecting stacks…")).Layout(win, gtx)
+	}
+	if usc.selects == nil {
+		usc.selects = make([]widget.PrimaryClickable, len(stacks))
+		usc.filter(stacks)
+	} else if strings.TrimSpace(usc.search.Text()) != usc.prevSearch {
+		usc.filter(stacks)
+		usc.scrollState = theme.YScrollableListState{}
+	}
+
+	if usc.table == nil {
+		usc.table = &theme.Table{}
+		usc.table.SetColumns(win, gtx, []theme.Column{
+			{Name: "Function", Alignment: text.Start},
+			{Name: "Depth", Alignment: text.End},
+			{Name: "Occurrences", Alignment: text.End},
+			{Name: "Event kinds", Alignment: text.Start},
+		})
+	}
+	usc.table.Update(gtx)
+	usc.cellFormatter.Update(win, gtx)
+	for _, idx := range usc.filtered {
+		if usc.selects[idx].Clicked(gtx) {
+			usc.selected = idx
+			usc.frames.Position = layout.Position{}
+			usc.instances.scrollState = theme.YScrollableListState{}
+		}
+	}
+
+	cellFn := func(win *theme.Window, gtx layout.Context, row, col int) layout.Dimensions {
+		defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+
+		idx := usc.filtered[row]
+		us := &stacks[idx]
+		switch col {
+		case 0:
+			return usc.selects[idx].Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				c := win.Theme.Palette.Link
+				if idx == usc.selected {
+					c = win.Theme.Palette.Foreground
+				}
+				return widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, font.Font{}, win.Theme.TextSize, us.Leaf(usc.trace), win.ColorMaterial(gtx, c))
+			})
+		case 1:
+			return usc.cellFormatter.Number(win, gtx, len(us.PCs))
+		case 2:
+			return usc.cellFormatter.Number(win, gtx, len(us.Events))
+		case 3:
+			return usc.cellFormatter.Text(win, gtx, us.KindNames())
+		default:
+			panic(fmt.Sprintf("unreachable: %d", col))
+		}
+	}
+
+	stacksTable := func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return theme.TextBox(win.Theme, &usc.search, "Search functions").Layout(win, gtx)
+			}),
+			layout.Rigid(layout.Spacer{Height: 5}.Layout),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				s := local.Sprintf("%d unique stacks", len(stacks))
+				if len(usc.filtered) != len(stacks) {
+					s = local.Sprintf("%d of %d unique stacks match", len(usc.filtered), len(stacks))
+				}
+				return theme.LineLabel(win.Theme, s).Layout(win, gtx)
+			}),
+			layout.Rigid(layout.Spacer{Height: 5}.Layout),
+			layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+				return theme.SimpleTable(win, gtx, usc.table, &usc.scrollState, len(usc.filtered), cellFn)
+			}),
+		)
+	}
+	if usc.selected == -1 {
+		return stacksTable(win, gtx)
+	}
+	return theme.Splitter(win.Theme, &usc.split).Layout(win, gtx, stacksTable, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+		return usc.layoutSelected(win, gtx, &stacks[usc.selected])
+	})
+}
+
+func (usc *UniqueStacksComponent) layoutSelected(win *theme.Window, gtx layout.Context, us *uniqueStack) layout.Dimensions {
+	tabs := []string{
+		"Stack",
+		local.Sprintf("Occurrences (%d)", len(us.Events)),
+	}
+	return theme.Tabbed(&usc.tabbedState, tabs).Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+		gtx.Constraints.Min = gtx.Constraints.Max
+		switch usc.tabbedState.Current {
+		case 0:
+			return usc.layoutFrames(win, gtx, us)
+		case 1:
+			return usc.layoutInstances(win, gtx, us)
+		default:
+			panic("unreachable")
+		}
+	})
+}
+
+func (usc *UniqueStacksComponent) layoutFrames(win *theme.Window, gtx layout.Context, us *uniqueStack) layout.Dimensions {
+	handleLinkClicks(win, gtx, &usc.clicks)
+	usc.hoveredLink = nil
+	for i, n := 0, usc.clicks.Len(); i < n; i++ {
+		if c := usc.clicks.Ptr(i); c.Click.Hovered() {
+			usc.hoveredLink = c.Link
+			break
+		}
+	}
+	usc.clicks.Reset()
+
+	mono := font.Font{Typeface: win.Theme.MonospaceTypeface}
+	return theme.List(win.Theme, &usc.frames).Layout(win, gtx, len(us.PCs), func(gtx layout.Context, i int) layout.Dimensions {
+		f := usc.trace.StackFrame(us.PCs[i])
+		return layout.Inset{Bottom: 5}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			return layout.Rigids(gtx, layout.Vertical,
+				func(gtx layout.Context) layout.Dimensions {
+					return widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, mono, win.Theme.TextSize, f.Func, win.ColorMaterial(gtx, win.Theme.Palette.Foreground))
+				},
+				func(gtx layout.Context) layout.Dimensions {
+					click := usc.clicks.Grow()
+					click.Link = &SourceLocationObjectLink{File: f.File, Line: f.Line}
+					return layout.Inset{Left: 40}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+						return click.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+							s := fmt.Sprintf("%s:%d", usc.trace.displayPath(f.File), f.Line)
+							return widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, mono, win.Theme.TextSize, s, win.ColorMaterial(gtx, win.Theme.Palette.Link))
+						})
+					})
+				},
+			)
+		})
+	})
+}
+
+func (usc *UniqueStacksComponent) layoutInstances(win *theme.Window, gtx layout.Context, us *uniqueStack) layout.Dimensions {
+	it := &usc.instances
+	if it.table == nil {
+		it.table = &theme.Table{}
+		it.table.SetColumns(win, gtx, []theme.Column{
+			{Name: "Time", Alignment: text.End},
+			{Name: "Goroutine", Alignment: text.End},
+			{Name: "Event", Alignment: text.Start},
+		})
+	}
+	it.table.Update(gtx)
+	it.cellFormatter.Update(win, gtx)
+
+	cellFn := func(win *theme.Window, gtx layout.Context, row, col int) layout.Dimensions {
+		defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+
+		ev := usc.trace.Event(us.Events[row])
+		switch col {
+		case 0:
+			return it.cellFormatter.Timestamp(win, gtx, usc.trace, ev.Time(), "")
+		case 1:
+			if gid := ev.Goroutine(); gid != exptrace.NoGoroutine {
+				return it.cellFormatter.Goroutine(win, gtx, usc.trace.G(gid), "")
+			}
+			return layout.Dimensions{Size: gtx.Constraints.Min}
+		case 2:
+			return it.cellFormatter.Text(win, gtx, ev.Kind().String())
+		default:
+			panic(fmt.Sprintf("unreachable: %d", col))
+		}
+	}
+	return theme.SimpleTable(win, gtx, it.table, &it.scrollState, len(us.Events), cellFn)
+}
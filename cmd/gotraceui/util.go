@@ -11,6 +11,7 @@ import (
 	"honnef.co/go/gotraceui/widget"
 
 	"gioui.org/font"
+	"gioui.org/op/clip"
 	"gioui.org/text"
 	exptrace "golang.org/x/exp/trace"
 )
@@ -71,12 +72,15 @@ func (cf *CellFormatter) Timestamp(win *theme.Window, gtx layout.Context, tr *Tr
 			return widget.Label{
 				MaxLines:  1,
 				Alignment: text.Start,
-			}.Layout(gtx, win.Theme.Shaper, font.Font{}, 12, label, win.ColorMaterial(gtx, win.Theme.Palette.NavigationLink))
+			}.Layout(gtx, win.Theme.Shaper, font.Font{}, win.Theme.TextSize, label, win.ColorMaterial(gtx, win.Theme.Palette.NavigationLink))
 		})
 	})
 }
 
 func (cf *CellFormatter) Goroutine(win *theme.Window, gtx layout.Context, g *ptrace.Goroutine, label string) layout.Dimensions {
+	if win.Selection.Is(g) {
+		theme.FillShape(win, gtx.Ops, win.Theme.Palette.PrimarySelection, clip.Rect{Max: gtx.Constraints.Max}.Op())
+	}
 	return layout.RightAligned(gtx, func(gtx layout.Context) layout.Dimensions {
 		link := cf.Clicks.Grow()
 		link.Link = &GoroutineObjectLink{Goroutine: g}
@@ -87,7 +91,7 @@ func (cf *CellFormatter) Goroutine(win *theme.Window, gtx layout.Context, g *ptr
 			return widget.Label{
 				MaxLines:  1,
 				Alignment: text.Start,
-			}.Layout(gtx, win.Theme.Shaper, font.Font{}, 12, label, win.ColorMaterial(gtx, win.Theme.Palette.OpenLink))
+			}.Layout(gtx, win.Theme.Shaper, font.Font{}, win.Theme.TextSize, label, win.ColorMaterial(gtx, win.Theme.Palette.OpenLink))
 		})
 	})
 }
@@ -103,7 +107,7 @@ func (cf *CellFormatter) Task(win *theme.Window, gtx layout.Context, t *ptrace.T
 			return widget.Label{
 				MaxLines:  1,
 				Alignment: text.Start,
-			}.Layout(gtx, win.Theme.Shaper, font.Font{}, 12, label, win.ColorMaterial(gtx, win.Theme.Palette.OpenLink))
+			}.Layout(gtx, win.Theme.Shaper, font.Font{}, win.Theme.TextSize, label, win.ColorMaterial(gtx, win.Theme.Palette.OpenLink))
 		})
 	})
 }
@@ -118,7 +122,7 @@ func (cf *CellFormatter) Duration(win *theme.Window, gtx layout.Context, d time.
 		return widget.Label{
 			MaxLines:  1,
 			Alignment: text.Start,
-		}.Layout(gtx, win.Theme.Shaper, font.Font{}, 12, fmt.Sprintf("%s %s", value, unit), win.ColorMaterial(gtx, win.Theme.Palette.Foreground))
+		}.Layout(gtx, win.Theme.Shaper, font.Font{}, win.Theme.TextSize, fmt.Sprintf("%s %s", value, unit), win.ColorMaterial(gtx, win.Theme.Palette.Foreground))
 	})
 }
 
@@ -136,7 +140,7 @@ func (cf *CellFormatter) Function(win *theme.Window, gtx layout.Context, fn *ptr
 		return widget.Label{
 			MaxLines:  1,
 			Alignment: text.Start,
-		}.Layout(gtx, win.Theme.Shaper, font.Font{}, 12, label, win.ColorMaterial(gtx, win.Theme.Palette.OpenLink))
+		}.Layout(gtx, win.Theme.Shaper, font.Font{}, win.Theme.TextSize, label, win.ColorMaterial(gtx, win.Theme.Palette.OpenLink))
 	})
 }
 
@@ -145,7 +149,7 @@ func (cf *CellFormatter) Number(win *theme.Window, gtx layout.Context, num int)
 	return widget.Label{
 		MaxLines:  1,
 		Alignment: text.End,
-	}.Layout(gtx, win.Theme.Shaper, font.Font{}, 12, label, win.ColorMaterial(gtx, win.Theme.Palette.Foreground))
+	}.Layout(gtx, win.Theme.Shaper, font.Font{}, win.Theme.TextSize, label, win.ColorMaterial(gtx, win.Theme.Palette.Foreground))
 }
 
 func (cf *CellFormatter) EventID(win *theme.Window, gtx layout.Context, num ptrace.EventID) layout.Dimensions {
@@ -153,11 +157,11 @@ func (cf *CellFormatter) EventID(win *theme.Window, gtx layout.Context, num ptra
 	return widget.Label{
 		MaxLines:  1,
 		Alignment: text.End,
-	}.Layout(gtx, win.Theme.Shaper, font.Font{}, 12, label, win.ColorMaterial(gtx, win.Theme.Palette.Foreground))
+	}.Layout(gtx, win.Theme.Shaper, font.Font{}, win.Theme.TextSize, label, win.ColorMaterial(gtx, win.Theme.Palette.Foreground))
 }
 
 func (cf *CellFormatter) Text(win *theme.Window, gtx layout.Context, l string) layout.Dimensions {
-	return widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, font.Font{}, 12, l, win.ColorMaterial(gtx, win.Theme.Palette.Foreground))
+	return widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, font.Font{}, win.Theme.TextSize, l, win.ColorMaterial(gtx, win.Theme.Palette.Foreground))
 }
 
 func (cf *CellFormatter) Spans(win *theme.Window, gtx layout.Context, spans Items[ptrace.Span]) layout.Dimensions {
@@ -168,6 +172,6 @@ func (cf *CellFormatter) Spans(win *theme.Window, gtx layout.Context, spans Item
 		return widget.Label{
 			MaxLines:  1,
 			Alignment: text.Start,
-		}.Layout(gtx, win.Theme.Shaper, font.Font{}, 12, label, win.ColorMaterial(gtx, win.Theme.Palette.OpenLink))
+		}.Layout(gtx, win.Theme.Shaper, font.Font{}, win.Theme.TextSize, label, win.ColorMaterial(gtx, win.Theme.Palette.OpenLink))
 	})
 }
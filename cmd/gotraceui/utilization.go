@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	rtrace "runtime/trace"
+
+	"honnef.co/go/gotraceui/color"
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/trace/ptrace"
+	"honnef.co/go/gotraceui/widget"
+
+	exptrace "golang.org/x/exp/trace"
+)
+
+type utilizationKey struct{}
+
+// The number of time buckets that utilization divides the trace into. This is plenty for the widths of most
+// displays.
+const utilizationBuckets = 1000
+
+const (
+	utilizationUser = iota
+	utilizationGC
+	utilizationSyscall
+	utilizationIdle
+	utilizationCategories
+)
+
+var utilizationNames = [utilizationCategories]string{
+	utilizationUser:    "Running user code",
+	utilizationGC:      "GC",
+	utilizationSyscall: "Syscall",
+	utilizationIdle:    "Idle",
+}
+
+var utilizationColors = [utilizationCategories]colorIndex{
+	utilizationUser:    colorStateProcRunningG,
+	utilizationGC:      colorStateGC,
+	utilizationSyscall: colorStateProcRunningBlocked,
+	utilizationIdle:    colorStateInactive,
+}
+
+// utilization returns, for each kind of P activity, the average number of Ps engaged in it over time. The series have
+// one point per bucket and add up to the number of Ps.
+//
+// Ps that run without a goroutine, which is mostly the scheduler looking for work, count as idle. The number of Ps is
+// taken to be constant, so changes to GOMAXPROCS during the trace show up as idle or overcommitted time.
+func utilization(tr *Trace) []widget.LineChartSeries {
+	defer rtrace.StartRegion(context.Background(), "main.utilization").End()
+
+	start, end := tr.Start(), tr.End()
+	width := max((end-start)/utilizationBuckets, 1)
+	n := int((end-start)/width) + 1
+	var busy [utilizationCategories][]exptrace.Time
+	for i := range busy {
+		busy[i] = make([]exptrace.Time, n)
+	}
+
+	for _, p := range tr.Processors {
+		for i := range p.Spans {
+			s := &p.Spans[i]
+			var cat int
+			switch s.State {
+			case ptrace.StateProcRunningG:
+				if s.Tags&ptrace.SpanTagGC != 0 {
+					cat = utilizationGC
+				} else {
+					cat = utilizationUser
+				}
+			case ptrace.StateProcRunningBlocked:
+				cat = utilizationSyscall
+			default:
+				continue
+			}
+			sStart, sEnd := s.Start, s.End
+			if s.EndEvent == -1 {
+				sEnd = end
+			}
+			// Distribute the span over the buckets it overlaps.
+			for b := int((sStart - start) / width); b < n; b++ {
+				bStart := start + exptrace.Time(b)*width
+				if bStart >= sEnd {
+					break
+				}
+				overlap := min(sEnd, bStart+width) - max(sStart, bStart)
+				busy[cat][b] += overlap
+			}
+		}
+	}
+
+	out := make([]widget.LineChartSeries, utilizationCategories)
+	for cat := range out {
+		out[cat] = widget.LineChartSeries{
+			Name:   utilizationNames[cat],
+			Step:   true,
+			Points: make([]widget.LineChartPoint, n+1),
+		}
+	}
+	for b := range n {
+		x := float64(start + exptrace.Time(b)*width)
+		var total float64
+		for cat := range utilizationIdle {
+			y := float64(busy[cat][b]) / float64(width)
+			total += y
+			out[cat].Points[b] = widget.LineChartPoint{X: x, Y: y}
+		}
+		out[utilizationIdle].Points[b] = widget.LineChartPoint{X: x, Y: max(float64(len(tr.Processors))-total, 0)}
+	}
+	// Extend the last bucket to the end of the trace.
+	for cat := range out {
+		out[cat].Points[n] = widget.LineChartPoint{X: float64(end), Y: out[cat].Points[n-1].Y}
+	}
+	return out
+}
+
+// UtilizationComponent plots how many Ps were running user code, doing GC work, in syscalls, or idle over time, as a
+// stacked area chart. It is a denser alternative to the heatmap for judging the overall utilization of the machine.
+// Clicking on the chart scrolls the timelines to the clicked time.
+type UtilizationComponent struct {
+	trace  *Trace
+	series *theme.Future[[]widget.LineChartSeries]
+
+	chart       theme.LineChartState
+	initialized bool
+}
+
+func NewUtilizationComponent(win *theme.Window, tr *Trace) *UtilizationComponent {
+	return &UtilizationComponent{
+		trace: tr,
+		series: theme.Compute(&tr.analyses, win, utilizationKey{}, func() []widget.LineChartSeries {
+			return utilization(tr)
+		}),
+	}
+}
+
+// Title implements theme.Component.
+func (*UtilizationComponent) Title() string {
+	return tr("P utilization")
+}
+
+// Transition implements theme.Component.
+func (*UtilizationComponent) Transition(state theme.ComponentState) {}
+
+// WantsTransition implements theme.Component.
+func (*UtilizationComponent) WantsTransition(gtx layout.Context) theme.ComponentState {
+	return theme.ComponentStateNone
+}
+
+func (*UtilizationComponent) HoveredLink() ObjectLink {
+	return nil
+}
+
+// Layout implements theme.Component.
+func (uc *UtilizationComponent) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.UtilizationComponent.Layout").End()
+
+	series, ok := uc.series.Result()
+	if !ok {
+		return theme.Loading(win.Theme, tr("Computing utilization…")).Layout(win, gtx)
+	}
+	if !uc.initialized {
+		uc.initialized = true
+		uc.chart.SetSeries(series)
+	}
+	if x, ok := uc.chart.Clicked(); ok {
+		win.EmitAction(ShowTimestampAction(exptrace.Time(x)))
+	}
+
+	gtx.Constraints.Min = gtx.Constraints.Max
+	lc := theme.LineChart(win.Theme, &uc.chart)
+	lc.Stacked = true
+	lc.Colors = make([]color.Oklch, utilizationCategories)
+	for cat, c := range utilizationColors {
+		lc.Colors[cat] = colors[c]
+	}
+	lc.XLabel = "Time"
+	lc.YLabel = "Ps"
+	lc.FormatX = func(v float64) string {
+		return formatTimestamp(nil, uc.trace.AdjustedTime(exptrace.Time(v)))
+	}
+	lc.FormatY = func(v float64) string {
+		return local.Sprintf("%.2f", v)
+	}
+	return lc.Layout(win, gtx)
+}
@@ -0,0 +1,641 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"math"
+	rtrace "runtime/trace"
+	"slices"
+	"time"
+
+	"honnef.co/go/gotraceui/clip"
+	"honnef.co/go/gotraceui/color"
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/trace/ptrace"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/f32"
+	"gioui.org/font"
+	"gioui.org/op"
+	"gioui.org/text"
+	exptrace "golang.org/x/exp/trace"
+)
+
+const (
+	// maxWaitForNodes limits the size of the wait-for graph, keeping the nodes involved in the most waiting.
+	maxWaitForNodes = 60
+	// minWaitForChain is the number of nodes a chain of waits needs before we highlight it.
+	minWaitForChain = 3
+)
+
+var waitForChainColor = oklch(75, 0.15, 70)
+
+// A waitForNode is a goroutine, or a resource that goroutines waited on without another goroutine waking them up.
+type waitForNode struct {
+	// Goroutine is nil for resources.
+	Goroutine *ptrace.Goroutine
+	Resource  string
+	// Blocked is the time the node spent waiting during the range, Blocking the time others spent waiting on it.
+	Blocked, Blocking time.Duration
+	InCycle, InChain  bool
+	// Layer is the column of the node in the graph. Waiters are to the left of what they wait on.
+	Layer int
+}
+
+func (n *waitForNode) label() string {
+	if n.Goroutine == nil {
+		return n.Resource
+	}
+	if n.Goroutine.Function == nil {
+		return local.Sprintf("g%d", n.Goroutine.ID)
+	}
+	return local.Sprintf("g%d %s", n.Goroutine.ID, shortenFunctionName(n.Goroutine.Function.Func))
+}
+
+// A waitForEdge records that From waited for To, which is either the goroutine that unblocked it or a resource.
+type waitForEdge struct {
+	From, To int
+	Count    int
+	// Wait is the time From spent waiting for To, limited to the range.
+	Wait time.Duration
+	// State is the state of the longest wait.
+	State            ptrace.SchedulingState
+	longest          time.Duration
+	InCycle, InChain bool
+}
+
+type waitForGraph struct {
+	Start, End exptrace.Time
+	Nodes      []waitForNode
+	// Edges are sorted by wait time in descending order.
+	Edges []waitForEdge
+	// Cycles are the strongly connected components of the graph, that is, goroutines that waited on each other.
+	Cycles [][]int
+	// Chain is the longest chain of waits, as indices into Edges. It is empty if the chain is shorter than
+	// minWaitForChain nodes.
+	Chain  []int
+	Layers int
+	// Omitted is the number of nodes that were left out to keep the graph legible.
+	Omitted int
+}
+
+// waitForResource names what a goroutine waited on when no goroutine woke it up.
+func waitForResource(state ptrace.SchedulingState) string {
+	switch state {
+	case ptrace.StateBlockedNet:
+		return "network poller"
+	case ptrace.StateBlockedGC, ptrace.StateBlockedSyncTriggeringGC:
+		return "garbage collector"
+	default:
+		return "runtime"
+	}
+}
+
+func computeWaitForGraph(tr *Trace, start, end exptrace.Time) *waitForGraph {
+	defer rtrace.StartRegion(context.Background(), "main.computeWaitForGraph").End()
+
+	type edgeKey struct{ from, to int }
+	wg := &waitForGraph{Start: start, End: end}
+	gnodes := map[*ptrace.Goroutine]int{}
+	rnodes := map[string]int{}
+	edges := map[edgeKey]int{}
+	gnode := func(g *ptrace.Goroutine) int {
+		idx, ok := gnodes[g]
+		if !ok {
+			idx = len(wg.Nodes)
+			gnodes[g] = idx
+			wg.Nodes = append(wg.Nodes, waitForNode{Goroutine: g})
+		}
+		return idx
+	}
+	rnode := func(name string) int {
+		idx, ok := rnodes[name]
+		if !ok {
+			idx = len(wg.Nodes)
+			rnodes[name] = idx
+			wg.Nodes = append(wg.Nodes, waitForNode{Resource: name})
+		}
+		return idx
+	}
+
+	for _, g := range tr.Goroutines {
+		spans := spansOverlapping(g.Spans, start, end)
+		for i := range spans {
+			s := &spans[i]
+			switch s.State {
+			case ptrace.StateBlocked, ptrace.StateBlockedSend, ptrace.StateBlockedRecv, ptrace.StateBlockedSelect,
+				ptrace.StateBlockedSync, ptrace.StateBlockedSyncOnce, ptrace.StateBlockedSyncTriggeringGC,
+				ptrace.StateBlockedCond, ptrace.StateBlockedNet, ptrace.StateBlockedGC:
+			default:
+				// Syscalls and cgo calls aren't waits on other goroutines.
+				continue
+			}
+			d := overlap(s, start, end)
+			if d <= 0 {
+				continue
+			}
+
+			from := gnode(g)
+			var to int
+			if s.EndEvent == -1 {
+				to = rnode("never woken up")
+			} else if gid := tr.Event(s.EndEvent).Goroutine(); gid != exptrace.NoGoroutine && gid != g.ID {
+				to = gnode(tr.G(gid))
+			} else {
+				to = rnode(waitForResource(s.State))
+			}
+
+			key := edgeKey{from, to}
+			ei, ok := edges[key]
+			if !ok {
+				ei = len(wg.Edges)
+				edges[key] = ei
+				wg.Edges = append(wg.Edges, waitForEdge{From: from, To: to})
+			}
+			e := &wg.Edges[ei]
+			e.Count++
+			e.Wait += d
+			if d > e.longest {
+				e.longest = d
+				e.State = s.State
+			}
+			wg.Nodes[from].Blocked += d
+			wg.Nodes[to].Blocking += d
+		}
+	}
+
+	wg.prune()
+	slices.SortFunc(wg.Edges, func(a, b waitForEdge) int {
+		return cmp(a.Wait, b.Wait, true)
+	})
+	wg.analyze()
+	return wg
+}
+
+// prune limits the graph to the maxWaitForNodes nodes that were involved in the most waiting.
+func (wg *waitForGraph) prune() {
+	if len(wg.Nodes) <= maxWaitForNodes {
+		return
+	}
+	order := make([]int, len(wg.Nodes))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		na, nb := &wg.Nodes[a], &wg.Nodes[b]
+		return cmp(na.Blocked+na.Blocking, nb.Blocked+nb.Blocking, true)
+	})
+
+	remap := make([]int, len(wg.Nodes))
+	for i := range remap {
+		remap[i] = -1
+	}
+	nodes := make([]waitForNode, 0, maxWaitForNodes)
+	for _, idx := range order[:maxWaitForNodes] {
+		remap[idx] = len(nodes)
+		nodes = append(nodes, wg.Nodes[idx])
+	}
+	edges := wg.Edges[:0]
+	for _, e := range wg.Edges {
+		if remap[e.From] == -1 || remap[e.To] == -1 {
+			continue
+		}
+		e.From, e.To = remap[e.From], remap[e.To]
+		edges = append(edges, e)
+	}
+	wg.Omitted = len(wg.Nodes) - len(nodes)
+	wg.Nodes = nodes
+	wg.Edges = edges
+}
+
+// analyze finds cycles and the longest chain of waits, and assigns nodes to layers.
+func (wg *waitForGraph) analyze() {
+	n := len(wg.Nodes)
+	out := make([][]int, n)
+	for i, e := range wg.Edges {
+		out[e.From] = append(out[e.From], i)
+	}
+
+	// Find strongly connected components using Tarjan's algorithm. The graph is small enough for recursion.
+	index := make([]int, n)
+	low := make([]int, n)
+	onStack := make([]bool, n)
+	comp := make([]int, n)
+	for i := range index {
+		index[i] = -1
+	}
+	var stack []int
+	var comps [][]int
+	next := 0
+	var visit func(v int)
+	visit = func(v int) {
+		index[v], low[v] = next, next
+		next++
+		stack = append(stack, v)
+		onStack[v] = true
+		for _, ei := range out[v] {
+			w := wg.Edges[ei].To
+			if index[w] == -1 {
+				visit(w)
+				low[v] = min(low[v], low[w])
+			} else if onStack[w] {
+				low[v] = min(low[v], index[w])
+			}
+		}
+		if low[v] == index[v] {
+			var c []int
+			for {
+				w := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[w] = false
+				comp[w] = len(comps)
+				c = append(c, w)
+				if w == v {
+					break
+				}
+			}
+			comps = append(comps, c)
+		}
+	}
+	for v := range n {
+		if index[v] == -1 {
+			visit(v)
+		}
+	}
+
+	for _, c := range comps {
+		if len(c) > 1 {
+			wg.Cycles = append(wg.Cycles, c)
+			for _, v := range c {
+				wg.Nodes[v].InCycle = true
+			}
+		}
+	}
+	for i := range wg.Edges {
+		e := &wg.Edges[i]
+		e.InCycle = comp[e.From] == comp[e.To]
+	}
+
+	// Tarjan's algorithm emits components in reverse topological order, so iterating backwards visits waiters before
+	// what they wait on. That lets us compute the longest path to each component in a single pass.
+	layer := make([]int, len(comps))
+	pred := make([]int, len(comps))
+	for i := range pred {
+		pred[i] = -1
+	}
+	for c := len(comps) - 1; c >= 0; c-- {
+		for _, v := range comps[c] {
+			for _, ei := range out[v] {
+				w := comp[wg.Edges[ei].To]
+				if w != c && layer[c]+1 > layer[w] {
+					layer[w] = layer[c] + 1
+					pred[w] = ei
+				}
+			}
+		}
+	}
+
+	last := 0
+	for c := range comps {
+		if layer[c] > layer[last] {
+			last = c
+		}
+		wg.Layers = max(wg.Layers, layer[c]+1)
+	}
+	for v := range wg.Nodes {
+		wg.Nodes[v].Layer = layer[comp[v]]
+	}
+	if len(comps) > 0 && layer[last]+1 >= minWaitForChain {
+		for ei := pred[last]; ei != -1; ei = pred[comp[wg.Edges[ei].From]] {
+			wg.Chain = append(wg.Chain, ei)
+		}
+		slices.Reverse(wg.Chain)
+		for _, ei := range wg.Chain {
+			e := &wg.Edges[ei]
+			e.InChain = true
+			wg.Nodes[e.From].InChain = true
+			wg.Nodes[e.To].InChain = true
+		}
+	}
+}
+
+// OpenWaitForGraphAction opens a wait-for graph for a range of time.
+type OpenWaitForGraphAction struct {
+	Start, End exptrace.Time
+}
+
+func (*OpenWaitForGraphAction) IsAction()     {}
+func (*OpenWaitForGraphAction) IsOpenAction() {}
+func (l *OpenWaitForGraphAction) Open(gtx layout.Context, mwin *MainWindow) {
+	mwin.openWaitForGraph(l.Start, l.End)
+}
+
+// WaitForGraphComponent shows which goroutines and resources goroutines waited on during a range of time, as derived
+// from the goroutines that unblocked them. Cycles hint at near-deadlocks, long chains at convoys.
+type WaitForGraphComponent struct {
+	trace      *Trace
+	start, end exptrace.Time
+	graph      *theme.Future[*waitForGraph]
+
+	nodes       []widget.PrimaryClickable
+	hoveredLink ObjectLink
+	tabbedState theme.TabbedState
+
+	table         *theme.Table
+	scrollState   theme.YScrollableListState
+	cellFormatter CellFormatter
+}
+
+func NewWaitForGraphComponent(win *theme.Window, tr *Trace, start, end exptrace.Time) *WaitForGraphComponent {
+	return &WaitForGraphComponent{
+		trace: tr,
+		start: start,
+		end:   end,
+		graph: theme.NewFuture(win, func(cancelled <-chan struct{}) *waitForGraph {
+			return computeWaitForGraph(tr, start, end)
+		}),
+	}
+}
+
+// Title implements theme.Component.
+func (wc *WaitForGraphComponent) Title() string {
+	return local.Sprintf("Wait-for graph of %s – %s",
+		formatTimestamp(nil, wc.trace.AdjustedTime(wc.start)), formatTimestamp(nil, wc.trace.AdjustedTime(wc.end)))
+}
+
+// Transition implements theme.Component.
+func (*WaitForGraphComponent) Transition(state theme.ComponentState) {}
+
+// WantsTransition implements theme.Component.
+func (*WaitForGraphComponent) WantsTransition(gtx layout.Context) theme.ComponentState {
+	return theme.ComponentStateNone
+}
+
+func (wc *WaitForGraphComponent) HoveredLink() ObjectLink {
+	return wc.hoveredLink
+}
+
+// Layout implements theme.Component.
+func (wc *WaitForGraphComponent) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.WaitForGraphComponent.Layout").End()
+
+	wg, ok := wc.graph.Result()
+	if !ok {
+		return theme.Loading(win.Theme, tr("Computing wait-for graph…")).Layout(win, gtx)
+	}
+
+	wc.hoveredLink = nil
+	if len(wc.nodes) != len(wg.Nodes) {
+		wc.nodes = make([]widget.PrimaryClickable, len(wg.Nodes))
+	}
+	for i := range wc.nodes {
+		g := wg.Nodes[i].Goroutine
+		if g == nil {
+			continue
+		}
+		for wc.nodes[i].Clicked(gtx) {
+			win.EmitAction((&GoroutineObjectLink{Goroutine: g}).Action(0))
+		}
+		if wc.nodes[i].Hovered() {
+			wc.hoveredLink = &GoroutineObjectLink{Goroutine: g}
+		}
+	}
+
+	waiters := countWaitForNodes(wg, func(n *waitForNode) bool { return n.Blocked > 0 })
+	waitedOn := countWaitForNodes(wg, func(n *waitForNode) bool { return n.Blocking > 0 })
+	summary := local.Sprintf("%d goroutines waited on %d goroutines and resources.", waiters, waitedOn)
+	if len(wg.Cycles) > 0 {
+		summary += local.Sprintf(" %d groups of goroutines waited on each other.", len(wg.Cycles))
+	}
+	if len(wg.Chain) > 0 {
+		summary += local.Sprintf(" The longest chain of waits spans %d nodes.", len(wg.Chain)+1)
+	}
+	if wg.Omitted > 0 {
+		summary += local.Sprintf(" %d nodes that waited the least were omitted.", wg.Omitted)
+	}
+
+	tabs := []string{"Graph", "Waits"}
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(theme.Dumb(win, theme.Label(win.Theme, summary).Layout)),
+		layout.Rigid(layout.Spacer{Height: 5}.Layout),
+		layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+			if len(wg.Edges) == 0 {
+				return layout.Center.Layout(gtx, theme.Dumb(win, theme.Label(win.Theme, tr("No goroutines were blocked during the range.")).Layout))
+			}
+			return theme.Tabbed(&wc.tabbedState, tabs).Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+				gtx.Constraints.Min = gtx.Constraints.Max
+				switch wc.tabbedState.Current {
+				case 0:
+					return wc.layoutGraph(win, gtx, wg)
+				case 1:
+					return wc.layoutWaits(win, gtx, wg)
+				default:
+					panic("unreachable")
+				}
+			})
+		}),
+	)
+}
+
+func countWaitForNodes(wg *waitForGraph, fn func(*waitForNode) bool) int {
+	var n int
+	for i := range wg.Nodes {
+		if fn(&wg.Nodes[i]) {
+			n++
+		}
+	}
+	return n
+}
+
+func (wc *WaitForGraphComponent) layoutGraph(win *theme.Window, gtx layout.Context, wg *waitForGraph) layout.Dimensions {
+	defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+
+	size := gtx.Constraints.Max
+	layers := make([][]int, wg.Layers)
+	for i := range wg.Nodes {
+		l := wg.Nodes[i].Layer
+		layers[l] = append(layers[l], i)
+	}
+
+	// Place nodes in columns by layer, ordering each column by how much the nodes were involved in waiting.
+	colWidth := float32(size.X) / float32(max(1, len(layers)))
+	nodeWidth := min(colWidth-float32(gtx.Dp(40)), float32(gtx.Dp(220)))
+	nodeWidth = max(nodeWidth, colWidth/2)
+	rects := make([]clip.FRect, len(wg.Nodes))
+	for l, nodes := range layers {
+		slices.SortStableFunc(nodes, func(a, b int) int {
+			na, nb := &wg.Nodes[a], &wg.Nodes[b]
+			return cmp(na.Blocked+na.Blocking, nb.Blocked+nb.Blocking, true)
+		})
+		rowHeight := min(float32(size.Y)/float32(len(nodes)), float32(gtx.Dp(50)))
+		nodeHeight := min(rowHeight*0.75, float32(gtx.Dp(24)))
+		top := (float32(size.Y) - rowHeight*float32(len(nodes))) / 2
+		x := colWidth*float32(l) + (colWidth-nodeWidth)/2
+		for i, n := range nodes {
+			y := top + rowHeight*float32(i) + (rowHeight-nodeHeight)/2
+			rects[n] = clip.FRect{Min: f32.Pt(x, y), Max: f32.Pt(x+nodeWidth, y+nodeHeight)}
+		}
+	}
+
+	var maxWait time.Duration
+	for i := range wg.Edges {
+		maxWait = max(maxWait, wg.Edges[i].Wait)
+	}
+	// Draw edges first so that nodes cover their ends. Draw highlighted edges last so that they aren't obscured.
+	for _, highlighted := range []bool{false, true} {
+		for i := range wg.Edges {
+			e := &wg.Edges[i]
+			if (e.InCycle || e.InChain) != highlighted {
+				continue
+			}
+			c := win.Theme.Palette.Foreground
+			c.A = 0.4
+			switch {
+			case e.InCycle:
+				c = colors[colorStateBlocked]
+			case e.InChain:
+				c = waitForChainColor
+			}
+			width := float32(gtx.Dp(1)) + float32(gtx.Dp(3))*float32(e.Wait)/float32(maxWait)
+			drawWaitForEdge(win, gtx, rects[e.From], rects[e.To], width, c)
+		}
+	}
+
+	lineHeight := float32(gtx.Sp(win.Theme.TextSize))
+	for i := range wg.Nodes {
+		n := &wg.Nodes[i]
+		r := rects[i]
+		stack := op.Offset(image.Pt(int(r.Min.X), int(r.Min.Y))).Push(gtx.Ops)
+		ngtx := gtx
+		ngtx.Constraints = layout.Exact(image.Pt(int(r.Dx()), int(r.Dy())))
+		wc.nodes[i].Layout(ngtx, func(gtx layout.Context) layout.Dimensions {
+			box := clip.FRect{Max: f32.Pt(r.Dx(), r.Dy())}
+			bg := win.Theme.Palette.Background
+			if n.Goroutine == nil {
+				bg = colors[colorStateInactive]
+			}
+			theme.FillShape(win, gtx.Ops, bg, box.Op(gtx.Ops))
+			border, borderWidth := win.Theme.Palette.Border, float32(gtx.Dp(1))
+			switch {
+			case n.InCycle:
+				border, borderWidth = colors[colorStateBlocked], float32(gtx.Dp(2))
+			case n.InChain:
+				border, borderWidth = waitForChainColor, float32(gtx.Dp(2))
+			}
+			theme.FillShape(win, gtx.Ops, border, clip.RectangularOutline{Rect: box, Width: borderWidth}.Op(gtx.Ops))
+
+			if r.Dy() >= lineHeight {
+				defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+				gtx.Constraints.Min = image.Point{}
+				gtx.Constraints.Max.X -= 2 * gtx.Dp(4)
+				defer op.Offset(image.Pt(gtx.Dp(4), int((r.Dy()-lineHeight)/2))).Push(gtx.Ops).Pop()
+				fg := win.Theme.Palette.Foreground
+				if n.Goroutine != nil {
+					fg = win.Theme.Palette.Link
+				}
+				widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, font.Font{}, win.Theme.TextSize, n.label(), win.ColorMaterial(gtx, fg))
+			}
+			return layout.Dimensions{Size: gtx.Constraints.Max}
+		})
+		stack.Pop()
+	}
+
+	return layout.Dimensions{Size: size}
+}
+
+// drawWaitForEdge draws an arrow from one node to another. Edges between nodes in the same column, which only happen
+// in cycles, curve to the right of the column.
+func drawWaitForEdge(win *theme.Window, gtx layout.Context, from, to clip.FRect, width float32, c color.Oklch) {
+	var p clip.Path
+	p.Begin(gtx.Ops)
+	var start, end, ctrl f32.Point
+	if to.Min.X > from.Max.X {
+		start = f32.Pt(from.Max.X, (from.Min.Y+from.Max.Y)/2)
+		end = f32.Pt(to.Min.X, (to.Min.Y+to.Max.Y)/2)
+		ctrl = f32.Pt((start.X+end.X)/2, (start.Y+end.Y)/2)
+	} else {
+		start = f32.Pt(from.Max.X, (from.Min.Y+from.Max.Y)/2)
+		end = f32.Pt(to.Max.X, (to.Min.Y+to.Max.Y)/2)
+		bulge := min(float32(gtx.Dp(30)), float32(math.Abs(float64(end.Y-start.Y)))/2+float32(gtx.Dp(10)))
+		ctrl = f32.Pt(start.X+bulge, (start.Y+end.Y)/2)
+	}
+	p.MoveTo(start)
+	p.QuadTo(ctrl, end)
+	theme.FillShape(win, gtx.Ops, c, clip.Stroke{Path: p.End(), Width: width}.Op())
+
+	// Draw the arrowhead, pointing in the direction the curve arrives at its end.
+	dir := end.Sub(ctrl)
+	l := float32(math.Hypot(float64(dir.X), float64(dir.Y)))
+	if l == 0 {
+		return
+	}
+	dir = dir.Mul(1 / l)
+	normal := f32.Pt(-dir.Y, dir.X)
+	size := float32(gtx.Dp(6)) + width
+	base := end.Sub(dir.Mul(size))
+	p.Begin(gtx.Ops)
+	p.MoveTo(end)
+	p.LineTo(base.Add(normal.Mul(size / 2)))
+	p.LineTo(base.Sub(normal.Mul(size / 2)))
+	p.Close()
+	theme.FillShape(win, gtx.Ops, c, clip.Outline{Path: p.End()}.Op())
+}
+
+func (wc *WaitForGraphComponent) layoutWaits(win *theme.Window, gtx layout.Context, wg *waitForGraph) layout.Dimensions {
+	if wc.table == nil {
+		wc.table = &theme.Table{}
+		wc.table.SetColumns(win, gtx, []theme.Column{
+			{Name: "Waiter", Alignment: text.Start},
+			{Name: "Waited on", Alignment: text.Start},
+			{Name: "Longest wait reason", Alignment: text.Start},
+			{Name: "Count", Alignment: text.End},
+			{Name: "Total wait", Alignment: text.End},
+			{Name: "Part of", Alignment: text.Start},
+		})
+	}
+	wc.table.Update(gtx)
+	wc.cellFormatter.Update(win, gtx)
+	if wc.hoveredLink == nil {
+		wc.hoveredLink = wc.cellFormatter.HoveredLink()
+	}
+
+	node := func(win *theme.Window, gtx layout.Context, n *waitForNode) layout.Dimensions {
+		if n.Goroutine == nil {
+			return wc.cellFormatter.Text(win, gtx, n.Resource)
+		}
+		return wc.cellFormatter.Goroutine(win, gtx, n.Goroutine, "")
+	}
+	cellFn := func(win *theme.Window, gtx layout.Context, row, col int) layout.Dimensions {
+		defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+
+		e := &wg.Edges[row]
+		switch colName := wc.table.Columns[col].Name; colName {
+		case "Waiter":
+			return node(win, gtx, &wg.Nodes[e.From])
+		case "Waited on":
+			return node(win, gtx, &wg.Nodes[e.To])
+		case "Longest wait reason":
+			return wc.cellFormatter.Text(win, gtx, stateNamesCapitalized[e.State])
+		case "Count":
+			return wc.cellFormatter.Number(win, gtx, e.Count)
+		case "Total wait":
+			return wc.cellFormatter.Duration(win, gtx, e.Wait, false)
+		case "Part of":
+			switch {
+			case e.InCycle && e.InChain:
+				return wc.cellFormatter.Text(win, gtx, "cycle, longest chain")
+			case e.InCycle:
+				return wc.cellFormatter.Text(win, gtx, "cycle")
+			case e.InChain:
+				return wc.cellFormatter.Text(win, gtx, "longest chain")
+			default:
+				return layout.Dimensions{Size: gtx.Constraints.Min}
+			}
+		default:
+			panic(fmt.Sprintf("unhandled column %q", colName))
+		}
+	}
+	return theme.SimpleTable(win, gtx, wc.table, &wc.scrollState, len(wg.Edges), cellFn)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	rtrace "runtime/trace"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+
+	"gioui.org/op/clip"
+	"gioui.org/text"
+)
+
+// WarningsComponent lists the problems that were encountered while loading the trace, such as events that we don't
+// understand, with how often and during which time range they occurred.
+type WarningsComponent struct {
+	trace *Trace
+
+	table         *theme.Table
+	scrollState   theme.YScrollableListState
+	cellFormatter CellFormatter
+}
+
+func NewWarningsComponent(tr *Trace) *WarningsComponent {
+	return &WarningsComponent{trace: tr}
+}
+
+// Title implements theme.Component.
+func (*WarningsComponent) Title() string {
+	return tr("Warnings")
+}
+
+// Transition implements theme.Component.
+func (*WarningsComponent) Transition(state theme.ComponentState) {}
+
+// WantsTransition implements theme.Component.
+func (*WarningsComponent) WantsTransition(gtx layout.Context) theme.ComponentState {
+	return theme.ComponentStateNone
+}
+
+func (wc *WarningsComponent) HoveredLink() ObjectLink {
+	return wc.cellFormatter.HoveredLink()
+}
+
+func (wc *WarningsComponent) initTable(win *theme.Window, gtx layout.Context) {
+	if wc.table != nil {
+		return
+	}
+	wc.table = &theme.Table{}
+	wc.table.SetColumns(win, gtx, []theme.Column{
+		{Name: "Kind", Alignment: text.Start},
+		{Name: "Warning", Alignment: text.Start},
+		{Name: "Count", Alignment: text.End},
+		{Name: "First", Alignment: text.End},
+		{Name: "Last", Alignment: text.End},
+	})
+}
+
+// Layout implements theme.Component.
+func (wc *WarningsComponent) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.WarningsComponent.Layout").End()
+
+	warnings := wc.trace.Warnings
+	if len(warnings) == 0 {
+		return layout.Center.Layout(gtx, theme.Dumb(win, theme.Label(win.Theme, tr("No problems were encountered while loading the trace.")).Layout))
+	}
+
+	wc.initTable(win, gtx)
+	wc.table.Update(gtx)
+	wc.cellFormatter.Update(win, gtx)
+
+	cellFn := func(win *theme.Window, gtx layout.Context, row, col int) layout.Dimensions {
+		defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+
+		w := &warnings[row]
+		switch colName := wc.table.Columns[col].Name; colName {
+		case "Kind":
+			return wc.cellFormatter.Text(win, gtx, w.Kind.String())
+		case "Warning":
+			return wc.cellFormatter.Text(win, gtx, w.Message)
+		case "Count":
+			return wc.cellFormatter.Number(win, gtx, w.Count)
+		case "First":
+			return wc.cellFormatter.Timestamp(win, gtx, wc.trace, w.Start, "")
+		case "Last":
+			return wc.cellFormatter.Timestamp(win, gtx, wc.trace, w.End, "")
+		default:
+			panic(colName)
+		}
+	}
+
+	return theme.SimpleTable(win, gtx, wc.table, &wc.scrollState, len(warnings), cellFn)
+}
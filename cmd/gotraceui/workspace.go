@@ -0,0 +1,79 @@
+package main
+
+import (
+	"honnef.co/go/gotraceui/theme"
+)
+
+// defaultMainRatio is the default fraction of the main window occupied by the tabs.
+const defaultMainRatio = 0.70
+
+// restoreLayout applies the window layout stored in the configuration.
+func (mwin *MainWindow) restoreLayout() {
+	userConfigMu.Lock()
+	defer userConfigMu.Unlock()
+	cfg := userConfig.Layout
+	if cfg == nil {
+		return
+	}
+	if side, ok := theme.ParseDockSide(cfg.PanelSide); ok {
+		mwin.dock.Side = side
+	}
+	if cfg.MainRatio > 0 && cfg.MainRatio < 1 {
+		mwin.dock.Ratio = cfg.MainRatio
+	}
+	mwin.dock.Hidden = cfg.PanelHidden
+	if cfg.Panels != nil {
+		mwin.panels.restore(cfg.Panels)
+	}
+}
+
+// saveLayout stores the window layout in the configuration, so that it can be restored the next time a window opens.
+func (mwin *MainWindow) saveLayout() error {
+	cfg := &LayoutConfig{
+		PanelSide:   mwin.dock.Side.String(),
+		MainRatio:   mwin.dock.Ratio,
+		PanelHidden: mwin.dock.Hidden,
+		Panels:      mwin.panels.config(),
+	}
+
+	userConfigMu.Lock()
+	defer userConfigMu.Unlock()
+	old := userConfig.Layout
+	if old == nil {
+		// Don't create a configuration file just to store the default layout.
+		old = &LayoutConfig{PanelSide: theme.DockRight.String(), MainRatio: defaultMainRatio}
+	}
+	if old.PanelSide == cfg.PanelSide && old.MainRatio == cfg.MainRatio && old.PanelHidden == cfg.PanelHidden && old.Panels.equal(cfg.Panels) {
+		return nil
+	}
+	userConfig.Layout = cfg
+	return userConfig.Save()
+}
+
+// restoreScale applies the UI scale stored in the configuration.
+func (mwin *MainWindow) restoreScale() {
+	userConfigMu.Lock()
+	scale := userConfig.UIScale
+	userConfigMu.Unlock()
+	if scale > 0 {
+		mwin.twin.SetScale(scale)
+	}
+	mwin.scale = mwin.twin.Scale()
+}
+
+// saveScale stores the window's UI scale in the configuration if it has changed.
+func (mwin *MainWindow) saveScale() error {
+	scale := mwin.twin.Scale()
+	if scale == mwin.scale {
+		return nil
+	}
+	mwin.scale = scale
+
+	userConfigMu.Lock()
+	defer userConfigMu.Unlock()
+	if scale == 1 && userConfig.UIScale == 0 {
+		return nil
+	}
+	userConfig.UIScale = scale
+	return userConfig.Save()
+}
@@ -350,6 +350,11 @@ func (t *IntervalTree[T, V]) find(
 
 	out = t.find(node.Children[Left], min, max, out)
 
+	if node.Key.Min > max {
+		// This node and the right subtree start after our end point.
+		return out
+	}
+
 	if node.Key.Overlaps(Interval[T]{min, max}) {
 		out = append(out, node)
 	}
@@ -378,6 +383,11 @@ func (t *IntervalTree[T, V]) findIter(
 		return true
 	}
 
+	if node.Key.Min > max {
+		// This node and the right subtree start after our end point.
+		return false
+	}
+
 	if node.Key.Overlaps(Interval[T]{min, max}) {
 		if cb(node) {
 			return true
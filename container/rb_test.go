@@ -0,0 +1,112 @@
+package container
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestIntervalTreeFind(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	tree := NewIntervalTree[int, int]()
+	tree.AllowDuplicates = true
+	var ivals []Interval[int]
+	for i := range 2000 {
+		min := r.Intn(10000)
+		max := min + r.Intn(200)
+		if i%10 == 0 {
+			// Some long intervals, which make the pruning by MaxSubtree matter.
+			max = min + r.Intn(5000)
+		}
+		if i%50 == 0 && len(ivals) > 0 {
+			// Some duplicates.
+			min, max = ivals[len(ivals)-1].Min, ivals[len(ivals)-1].Max
+		}
+		ivals = append(ivals, Interval[int]{min, max})
+		tree.Insert(min, max, i)
+	}
+
+	for range 500 {
+		min := r.Intn(11000) - 500
+		max := min + r.Intn(300)
+		q := Interval[int]{min, max}
+
+		var want []int
+		for i, ival := range ivals {
+			if ival.Overlaps(q) {
+				want = append(want, i)
+			}
+		}
+
+		var got []int
+		for _, n := range tree.Find(min, max, nil) {
+			for _, v := range n.Values {
+				got = append(got, v.Value)
+			}
+		}
+		slices.Sort(got)
+		if !slices.Equal(got, want) {
+			t.Fatalf("Find(%d, %d) = %v, want %v", min, max, got, want)
+		}
+
+		got = got[:0]
+		var prev Interval[int]
+		tree.FindIter(min, max, func(n *RBNode[Interval[int], Value[int, int]]) bool {
+			if n.Key.Compare(prev) < 0 {
+				t.Errorf("FindIter(%d, %d) visited %v after %v", min, max, n.Key, prev)
+			}
+			prev = n.Key
+			for _, v := range n.Values {
+				got = append(got, v.Value)
+			}
+			return false
+		})
+		slices.Sort(got)
+		if !slices.Equal(got, want) {
+			t.Fatalf("FindIter(%d, %d) = %v, want %v", min, max, got, want)
+		}
+	}
+}
+
+func TestIntervalTreeFindIterStop(t *testing.T) {
+	tree := NewIntervalTree[int, int]()
+	for i := range 100 {
+		tree.Insert(i, i+10, i)
+	}
+
+	var got []int
+	tree.FindIter(20, 40, func(n *RBNode[Interval[int], Value[int, int]]) bool {
+		got = append(got, n.Values[0].Value)
+		return len(got) == 3
+	})
+	if want := []int{10, 11, 12}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestIntervalTreeBoundaries(t *testing.T) {
+	tree := NewIntervalTree[int, string]()
+	tree.Insert(10, 19, "a")
+	tree.Insert(20, 29, "b")
+
+	tests := []struct {
+		min, max int
+		want     []string
+	}{
+		{0, 9, nil},
+		{0, 10, []string{"a"}},
+		{19, 19, []string{"a"}},
+		{19, 20, []string{"a", "b"}},
+		{29, 40, []string{"b"}},
+		{30, 40, nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, n := range tree.Find(tt.min, tt.max, nil) {
+			got = append(got, n.Values[0].Value)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Find(%d, %d) = %v, want %v", tt.min, tt.max, got, tt.want)
+		}
+	}
+}
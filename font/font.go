@@ -3,6 +3,7 @@ package font
 import (
 	_ "embed"
 	"fmt"
+	"os"
 	"sync"
 
 	"gioui.org/font"
@@ -13,6 +14,9 @@ import (
 //go:embed fallback.ttf
 var fallback []byte
 
+// FallbackTypeface is the typeface of the built-in font that provides the symbols that the Go fonts lack.
+const FallbackTypeface = "Fallback"
+
 var (
 	once       sync.Once
 	collection []font.FontFace
@@ -29,7 +33,7 @@ func Collection() []font.FontFace {
 
 		fc := font.FontFace{
 			Font: font.Font{
-				Typeface: "Fallback",
+				Typeface: FallbackTypeface,
 			},
 			Face: face,
 		}
@@ -40,3 +44,30 @@ func Collection() []font.FontFace {
 	})
 	return collection
 }
+
+// Load loads the fonts in a TrueType or OpenType file, which may be a font collection.
+func Load(path string) ([]font.FontFace, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	faces, err := opentype.ParseCollection(b)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't parse font %s: %w", path, err)
+	}
+	return faces, nil
+}
+
+// Typefaces returns the distinct typefaces in a collection, in the order they first appear.
+func Typefaces(collection []font.FontFace) []font.Typeface {
+	var out []font.Typeface
+	seen := map[font.Typeface]struct{}{}
+	for _, f := range collection {
+		if _, ok := seen[f.Font.Typeface]; ok {
+			continue
+		}
+		seen[f.Font.Typeface] = struct{}{}
+		out = append(out, f.Font.Typeface)
+	}
+	return out
+}
@@ -2,8 +2,6 @@ package gesture
 
 import "gioui.org/gesture"
 
-type Drag = gesture.Drag
-
 type Axis = gesture.Axis
 
 const (
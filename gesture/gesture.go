@@ -9,11 +9,22 @@ import (
 	"gioui.org/io/key"
 	"gioui.org/io/pointer"
 	"gioui.org/op"
+	"gioui.org/unit"
 )
 
 // The duration is somewhat arbitrary.
 const doubleClickDuration = 200 * time.Millisecond
 
+// The minimum distance a pointer has to move before a drag grabs the pointer.
+const touchSlop = unit.Dp(3)
+
+// The distance, in pixels, a touch may move and still count as a long press.
+const longPressSlop = 10
+
+// How long a touch has to be held without moving to count as a long press. Long presses are reported as presses of
+// the secondary button, the same as right clicks.
+const longPressDuration = 500 * time.Millisecond
+
 const (
 	// KindPress is reported for the first pointer
 	// press.
@@ -49,6 +60,20 @@ type Click struct {
 	buttons [3]clickButton
 	// The e.Buttons of the previous pointer event.
 	prevButtons pointer.Buttons
+
+	// State of a touch that may turn into a long press.
+	touch struct {
+		pending  bool
+		pid      pointer.ID
+		start    f32.Point
+		pressed  time.Time
+		position image.Point
+		// fired is set once the long press has been reported. The release of the touch then doesn't count as a click.
+		fired bool
+	}
+
+	// storage reused by Update
+	events []ClickEvent
 }
 
 // ClickEvent represent a click action, either a
@@ -71,8 +96,12 @@ type ClickKind uint8
 func (c *Click) Add(ops *op.Ops) {
 	pointer.InputOp{
 		Tag:   c,
-		Kinds: pointer.Press | pointer.Release | pointer.Enter | pointer.Leave,
+		Kinds: pointer.Press | pointer.Drag | pointer.Release | pointer.Enter | pointer.Leave,
 	}.Add(ops)
+	if c.touch.pending {
+		// Make sure we get to report the long press even if nothing else causes a frame.
+		op.InvalidateOp{At: c.touch.pressed.Add(longPressDuration)}.Add(ops)
+	}
 }
 
 // Hovered returns whether a pointer is inside the area.
@@ -93,9 +122,9 @@ func (c *Click) Pressed(btn pointer.Buttons) bool {
 	return false
 }
 
-// Update state and return the click events.
+// Update state and return the click events. The returned slice is only valid until the next call to Update.
 func (c *Click) Update(q event.Queue) []ClickEvent {
-	var events []ClickEvent
+	events := c.events[:0]
 	for _, evt := range q.Events(c) {
 		e, ok := evt.(pointer.Event)
 		if !ok {
@@ -106,7 +135,25 @@ func (c *Click) Update(q event.Queue) []ClickEvent {
 		c.prevButtons = e.Buttons
 
 		switch e.Kind {
+		case pointer.Drag:
+			if c.touch.pending && e.PointerID == c.touch.pid {
+				// Moving the finger turns the touch into something other than a long press, such as a drag.
+				if d := e.Position.Sub(c.touch.start); d.X*d.X+d.Y*d.Y > longPressSlop*longPressSlop {
+					c.touch.pending = false
+				}
+			}
+
 		case pointer.Release:
+			if e.Source == pointer.Touch && e.PointerID == c.touch.pid {
+				c.touch.pending = false
+				if c.touch.fired {
+					c.touch.fired = false
+					c.buttons[0].pressed = false
+					events = append(events, ClickEvent{Kind: KindCancel, Button: pointer.ButtonPrimary})
+					continue
+				}
+			}
+
 			// e.Buttons contains the buttons which are still pressed, so we need to process the bits that aren't set
 
 			for i := 0; i < 3; i++ {
@@ -128,6 +175,8 @@ func (c *Click) Update(q event.Queue) []ClickEvent {
 		case pointer.Cancel:
 			// Cancel affects all buttons
 			c.prevButtons = 0
+			c.touch.pending = false
+			c.touch.fired = false
 			for i := 0; i < 3; i++ {
 				btn := &c.buttons[i]
 				wasPressed := btn.pressed
@@ -149,6 +198,10 @@ func (c *Click) Update(q event.Queue) []ClickEvent {
 				if e.Source == pointer.Mouse && buttons&(1<<i) == 0 {
 					continue
 				}
+				if e.Source == pointer.Touch && i != 0 {
+					// Touches act as the primary button. Long presses act as the secondary button.
+					continue
+				}
 				if !c.hovered {
 					btn.pid = e.PointerID
 				}
@@ -163,6 +216,14 @@ func (c *Click) Update(q event.Queue) []ClickEvent {
 				}
 				btn.clickedAt = e.Time
 				events = append(events, ClickEvent{Kind: KindPress, Position: e.Position.Round(), Source: e.Source, Button: 1 << i, Modifiers: e.Modifiers, NumClicks: btn.clicks})
+				if e.Source == pointer.Touch {
+					c.touch.pending = true
+					c.touch.fired = false
+					c.touch.pid = e.PointerID
+					c.touch.start = e.Position
+					c.touch.position = e.Position.Round()
+					c.touch.pressed = time.Now()
+				}
 			}
 		case pointer.Leave:
 			// Leave affects all buttons
@@ -190,11 +251,114 @@ func (c *Click) Update(q event.Queue) []ClickEvent {
 			}
 		}
 	}
+	if c.touch.pending && time.Since(c.touch.pressed) >= longPressDuration {
+		c.touch.pending = false
+		c.touch.fired = true
+		events = append(events, ClickEvent{Kind: KindPress, Position: c.touch.position, Source: pointer.Touch, Button: pointer.ButtonSecondary, NumClicks: 1})
+	}
+	c.events = events
 	return events
 }
 
 func (ClickEvent) ImplementsEvent() {}
 
+// Drag detects drag gestures in the form of pointer.Drag events. Unlike Gio's Drag, it reuses the slice returned by
+// Update between calls.
+type Drag struct {
+	// MultiTouch causes Update to also report the events of touch pointers other than the one doing the dragging,
+	// so that users can implement gestures such as pinching. Callers can tell them apart by their PointerID.
+	MultiTouch bool
+
+	dragging bool
+	pressed  bool
+	pid      pointer.ID
+	start    f32.Point
+	grab     bool
+
+	// storage reused by Update
+	events []pointer.Event
+}
+
+// Add the handler to the operation list to receive drag events.
+func (d *Drag) Add(ops *op.Ops) {
+	pointer.InputOp{
+		Tag:   d,
+		Grab:  d.grab,
+		Kinds: pointer.Press | pointer.Drag | pointer.Release,
+	}.Add(ops)
+}
+
+// Update state and return the drag events. The returned slice is only valid until the next call to Update.
+func (d *Drag) Update(cfg unit.Metric, q event.Queue, axis Axis) []pointer.Event {
+	events := d.events[:0]
+	for _, e := range q.Events(d) {
+		e, ok := e.(pointer.Event)
+		if !ok {
+			continue
+		}
+
+		switch e.Kind {
+		case pointer.Press:
+			if !(e.Buttons == pointer.ButtonPrimary || e.Source == pointer.Touch) {
+				continue
+			}
+			if d.dragging && e.PointerID != d.pid && d.MultiTouch && e.Source == pointer.Touch {
+				break
+			}
+			d.pressed = true
+			if d.dragging {
+				continue
+			}
+			d.dragging = true
+			d.pid = e.PointerID
+			d.start = e.Position
+		case pointer.Drag:
+			if d.dragging && e.PointerID != d.pid && d.MultiTouch && e.Source == pointer.Touch {
+				break
+			}
+			if !d.dragging || e.PointerID != d.pid {
+				continue
+			}
+			switch axis {
+			case Horizontal:
+				e.Position.Y = d.start.Y
+			case Vertical:
+				e.Position.X = d.start.X
+			case Both:
+				// Do nothing
+			}
+			if e.Priority < pointer.Grabbed {
+				diff := e.Position.Sub(d.start)
+				slop := cfg.Dp(touchSlop)
+				if diff.X*diff.X+diff.Y*diff.Y > float32(slop*slop) {
+					d.grab = true
+				}
+			}
+		case pointer.Release, pointer.Cancel:
+			if d.dragging && e.PointerID != d.pid && d.MultiTouch && e.Source == pointer.Touch {
+				break
+			}
+			d.pressed = false
+			if !d.dragging || e.PointerID != d.pid {
+				continue
+			}
+			d.dragging = false
+			d.grab = false
+		}
+
+		events = append(events, e)
+	}
+
+	d.events = events
+	return events
+}
+
+// Dragging reports whether it is currently in use.
+func (d *Drag) Dragging() bool { return d.dragging }
+
+// Pressed returns whether a pointer is pressing.
+func (d *Drag) Pressed() bool { return d.pressed }
+
 // Hover detects hover events and tracks the pointer's position.
 type Hover struct {
 	hovered   bool
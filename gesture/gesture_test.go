@@ -0,0 +1,42 @@
+package gesture
+
+import (
+	"testing"
+
+	"gioui.org/f32"
+	"gioui.org/io/event"
+	"gioui.org/io/pointer"
+	"gioui.org/unit"
+)
+
+type queue []event.Event
+
+func (q queue) Events(event.Tag) []event.Event { return q }
+
+func TestUpdateAllocs(t *testing.T) {
+	// Converting the queue to an event.Queue allocates, so do it once, outside of the measured loops.
+	var q event.Queue = queue{
+		pointer.Event{Kind: pointer.Enter, Source: pointer.Mouse},
+		pointer.Event{Kind: pointer.Press, Source: pointer.Mouse, Buttons: pointer.ButtonPrimary},
+		pointer.Event{Kind: pointer.Drag, Source: pointer.Mouse, Buttons: pointer.ButtonPrimary, Position: f32.Pt(10, 10)},
+		pointer.Event{Kind: pointer.Release, Source: pointer.Mouse},
+		pointer.Event{Kind: pointer.Leave, Source: pointer.Mouse},
+	}
+
+	var c Click
+	if got := len(c.Update(q)); got != 2 {
+		t.Fatalf("got %d click events, want 2", got)
+	}
+	if n := testing.AllocsPerRun(100, func() { c.Update(q) }); n != 0 {
+		t.Errorf("Click.Update: got %v allocations, want 0", n)
+	}
+
+	var d Drag
+	cfg := unit.Metric{PxPerDp: 1, PxPerSp: 1}
+	if got := len(d.Update(cfg, q, Both)); got != len(q.Events(nil)) {
+		t.Fatalf("got %d drag events, want %d", got, len(q.Events(nil)))
+	}
+	if n := testing.AllocsPerRun(100, func() { d.Update(cfg, q, Both) }); n != 0 {
+		t.Errorf("Drag.Update: got %v allocations, want 0", n)
+	}
+}
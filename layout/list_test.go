@@ -0,0 +1,62 @@
+package layout
+
+import (
+	"image"
+	"testing"
+	"time"
+
+	"gioui.org/f32"
+	"gioui.org/io/pointer"
+	"gioui.org/io/router"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+)
+
+// TestListTouchScroll checks that dragging a finger scrolls a vertical list nested in a horizontal one, the way
+// tables are laid out, even when the rows handle pointer events of their own.
+func TestListTouchScroll(t *testing.T) {
+	var r router.Router
+	var outer, inner List
+	outer.Axis = Horizontal
+	inner.Axis = Vertical
+	var row int
+	frame := func() {
+		ops := new(op.Ops)
+		gtx := Context{Ops: ops, Queue: &r, Now: time.Now(), Constraints: Exact(image.Pt(100, 100))}
+		outer.Layout(gtx, 1, func(gtx Context, _ int) Dimensions {
+			return inner.Layout(gtx, 100, func(gtx Context, i int) Dimensions {
+				defer clip.Rect{Max: image.Pt(300, 20)}.Push(gtx.Ops).Pop()
+				pointer.InputOp{Tag: &row, Kinds: pointer.Press | pointer.Drag | pointer.Release}.Add(gtx.Ops)
+				return Dimensions{Size: image.Pt(300, 20)}
+			})
+		})
+		r.Frame(ops)
+	}
+
+	touch := func(kind pointer.Kind, x, y float32) {
+		r.Queue(pointer.Event{Kind: kind, Source: pointer.Touch, Position: f32.Pt(x, y), PointerID: 1})
+		frame()
+	}
+
+	frame()
+	touch(pointer.Press, 50, 80)
+	for y := float32(78); y >= 20; y -= 2 {
+		touch(pointer.Move, 50, y)
+	}
+	touch(pointer.Release, 50, 20)
+	if inner.Position.First == 0 && inner.Position.Offset == 0 {
+		t.Errorf("vertical touch drag didn't scroll the list: %+v", inner.Position)
+	}
+	if outer.Position.Offset != 0 {
+		t.Errorf("vertical touch drag scrolled horizontally: %+v", outer.Position)
+	}
+
+	touch(pointer.Press, 80, 50)
+	for x := float32(78); x >= 20; x -= 2 {
+		touch(pointer.Move, x, 50)
+	}
+	touch(pointer.Release, 20, 50)
+	if outer.Position.Offset == 0 {
+		t.Errorf("horizontal touch drag didn't scroll the list: %+v", outer.Position)
+	}
+}
@@ -2,6 +2,8 @@ package mem
 
 import (
 	"math"
+	"reflect"
+	"unsafe"
 
 	"gioui.org/op"
 	"golang.org/x/exp/constraints"
@@ -159,6 +161,26 @@ func (rops *ReusableOps) Get() *op.Ops {
 	return &rops.ops
 }
 
+// Size returns the number of bytes allocated for the ops' buffers. It doesn't include memory that is merely
+// referenced by ops, such as images.
+func (rops *ReusableOps) Size() int {
+	// op.Ops doesn't expose its buffers, but reflection lets us look at their capacities.
+	v := reflect.ValueOf(&rops.ops.Internal).Elem()
+	return v.FieldByName("data").Cap() +
+		v.FieldByName("refs").Cap()*int(unsafe.Sizeof(any(nil))) +
+		v.FieldByName("stringRefs").Cap()*int(unsafe.Sizeof(""))
+}
+
+// Release frees the ops' buffers. Macros recorded into the ops must not be used afterwards.
+func (rops *ReusableOps) Release() {
+	rops.ops = op.Ops{}
+}
+
+// OpsLen returns the number of bytes of encoded operations in ops.
+func OpsLen(ops *op.Ops) int {
+	return reflect.ValueOf(&ops.Internal).Elem().FieldByName("data").Len()
+}
+
 // AllocationCache is a trivial cache of allocations. Put appends a value to a slice and Get pops a value from the
 // slice, or allocates a new value.
 type AllocationCache[T any] struct {
@@ -169,6 +191,11 @@ func (c *AllocationCache[T]) Put(x *T) {
 	c.items = append(c.items, x)
 }
 
+// Items returns the cached values, in the order they were put into the cache. The values remain in the cache.
+func (c *AllocationCache[T]) Items() []*T {
+	return c.items
+}
+
 func (c *AllocationCache[T]) Get() *T {
 	if len(c.items) == 0 {
 		return new(T)
@@ -0,0 +1,9 @@
+// Package gotraceui provides files that are shipped with Gotraceui, such as its release notes.
+package gotraceui
+
+import _ "embed"
+
+// News contains the release notes, in Markdown.
+//
+//go:embed NEWS.md
+var News string
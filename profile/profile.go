@@ -0,0 +1,385 @@
+// Package profile parses profiles in the protocol buffer format of pprof, as written by runtime/pprof. It decodes the
+// subset of the format that is needed for displaying samples and their stacks, and doesn't depend on a protocol buffer
+// library.
+package profile
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// A Profile is a set of samples, each consisting of a stack and values.
+type Profile struct {
+	// SampleTypes describes the values of samples. Each sample has one value per sample type.
+	SampleTypes []ValueType
+	Samples     []Sample
+	// DefaultSampleType is the type of the value to display by default. It is empty if the profile doesn't specify
+	// it, in which case the last sample type should be used.
+	DefaultSampleType string
+	// Time is when the profile was collected, and Duration the time span it covers.
+	Time     time.Time
+	Duration time.Duration
+	// PeriodType and Period describe the sampling period, such as one sample per 100 ms of CPU time.
+	PeriodType ValueType
+	Period     int64
+}
+
+// A ValueType is the type and unit of a value, such as "contentions" and "count", or "delay" and "nanoseconds".
+type ValueType struct {
+	Type string
+	Unit string
+}
+
+type Sample struct {
+	// Stack is the stack of the sample, leaf first. Calls that were inlined are expanded into frames of their own.
+	Stack []Frame
+	// Values has one value per sample type.
+	Values []int64
+	// Labels are the sample's string labels, such as those set with pprof.Do.
+	Labels map[string][]string
+}
+
+type Frame struct {
+	Function string
+	File     string
+	Line     int64
+}
+
+// DefaultValue returns the index of the sample type to display by default.
+func (p *Profile) DefaultValue() int {
+	for i, st := range p.SampleTypes {
+		if st.Type == p.DefaultSampleType {
+			return i
+		}
+	}
+	return len(p.SampleTypes) - 1
+}
+
+var errTruncated = errors.New("truncated protocol buffer")
+
+// Parse parses a profile, which may be compressed with gzip, as written by runtime/pprof.
+func Parse(r io.Reader) (*Profile, error) {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		r = gr
+	} else {
+		r = br
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > 0 && bytes.HasPrefix(b, []byte("goroutine profile:")) {
+		return nil, errors.New("profiles in the text format aren't supported; capture the profile with debug=0")
+	}
+	p, err := parse(b)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't parse profile: %w", err)
+	}
+	return p, nil
+}
+
+// A field is a single field of a protocol buffer message. Varints and fixed-size values are stored in num, and
+// length-delimited values in data.
+type field struct {
+	num  int
+	wire int
+	u    uint64
+	data []byte
+}
+
+// fields calls fn for every field in the encoded message b.
+func fields(b []byte, fn func(f field) error) error {
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			return errTruncated
+		}
+		b = b[n:]
+		f := field{num: int(key >> 3), wire: int(key & 7)}
+		switch f.wire {
+		case 0:
+			f.u, n = binary.Uvarint(b)
+			if n <= 0 {
+				return errTruncated
+			}
+			b = b[n:]
+		case 1:
+			if len(b) < 8 {
+				return errTruncated
+			}
+			f.u = binary.LittleEndian.Uint64(b)
+			b = b[8:]
+		case 2:
+			l, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < l {
+				return errTruncated
+			}
+			f.data = b[n : n+int(l)]
+			b = b[n+int(l):]
+		case 5:
+			if len(b) < 4 {
+				return errTruncated
+			}
+			f.u = uint64(binary.LittleEndian.Uint32(b))
+			b = b[4:]
+		default:
+			return fmt.Errorf("unsupported wire type %d", f.wire)
+		}
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// uints appends the values of a repeated integer field, which may or may not be packed.
+func uints(dst []uint64, f field) ([]uint64, error) {
+	if f.wire != 2 {
+		return append(dst, f.u), nil
+	}
+	b := f.data
+	for len(b) > 0 {
+		v, n := binary.Uvarint(b)
+		if n <= 0 {
+			return nil, errTruncated
+		}
+		dst = append(dst, v)
+		b = b[n:]
+	}
+	return dst, nil
+}
+
+type rawValueType struct{ typ, unit uint64 }
+
+type rawSample struct {
+	locations []uint64
+	values    []uint64
+	labels    [][2]uint64
+}
+
+type rawLine struct {
+	function uint64
+	line     int64
+}
+
+type rawFunction struct {
+	name, file uint64
+}
+
+func parseValueType(b []byte) (rawValueType, error) {
+	var vt rawValueType
+	err := fields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			vt.typ = f.u
+		case 2:
+			vt.unit = f.u
+		}
+		return nil
+	})
+	return vt, err
+}
+
+func parse(b []byte) (*Profile, error) {
+	var (
+		sampleTypes       []rawValueType
+		samples           []rawSample
+		locations         = map[uint64][]rawLine{}
+		functions         = map[uint64]rawFunction{}
+		strs              []string
+		timeNanos         int64
+		durationNanos     int64
+		periodType        rawValueType
+		period            int64
+		defaultSampleType uint64
+	)
+
+	err := fields(b, func(f field) error {
+		var err error
+		switch f.num {
+		case 1:
+			var vt rawValueType
+			vt, err = parseValueType(f.data)
+			sampleTypes = append(sampleTypes, vt)
+		case 2:
+			var s rawSample
+			err = fields(f.data, func(f field) error {
+				var err error
+				switch f.num {
+				case 1:
+					s.locations, err = uints(s.locations, f)
+				case 2:
+					s.values, err = uints(s.values, f)
+				case 3:
+					var key, str uint64
+					err = fields(f.data, func(f field) error {
+						switch f.num {
+						case 1:
+							key = f.u
+						case 2:
+							str = f.u
+						}
+						return nil
+					})
+					if str != 0 {
+						s.labels = append(s.labels, [2]uint64{key, str})
+					}
+				}
+				return err
+			})
+			samples = append(samples, s)
+		case 4:
+			var id uint64
+			var lines []rawLine
+			err = fields(f.data, func(f field) error {
+				switch f.num {
+				case 1:
+					id = f.u
+				case 4:
+					var l rawLine
+					err := fields(f.data, func(f field) error {
+						switch f.num {
+						case 1:
+							l.function = f.u
+						case 2:
+							l.line = int64(f.u)
+						}
+						return nil
+					})
+					lines = append(lines, l)
+					return err
+				}
+				return nil
+			})
+			locations[id] = lines
+		case 5:
+			var id uint64
+			var fn rawFunction
+			err = fields(f.data, func(f field) error {
+				switch f.num {
+				case 1:
+					id = f.u
+				case 2:
+					fn.name = f.u
+				case 4:
+					fn.file = f.u
+				}
+				return nil
+			})
+			functions[id] = fn
+		case 6:
+			strs = append(strs, string(f.data))
+		case 9:
+			timeNanos = int64(f.u)
+		case 10:
+			durationNanos = int64(f.u)
+		case 11:
+			periodType, err = parseValueType(f.data)
+		case 12:
+			period = int64(f.u)
+		case 14:
+			defaultSampleType = f.u
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	str := func(idx uint64) (string, error) {
+		if idx >= uint64(len(strs)) {
+			return "", fmt.Errorf("invalid string index %d", idx)
+		}
+		return strs[idx], nil
+	}
+	valueType := func(vt rawValueType) (ValueType, error) {
+		typ, err := str(vt.typ)
+		if err != nil {
+			return ValueType{}, err
+		}
+		unit, err := str(vt.unit)
+		return ValueType{Type: typ, Unit: unit}, err
+	}
+
+	p := &Profile{
+		Duration: time.Duration(durationNanos),
+		Period:   period,
+	}
+	if timeNanos != 0 {
+		p.Time = time.Unix(0, timeNanos)
+	}
+	if p.PeriodType, err = valueType(periodType); err != nil {
+		return nil, err
+	}
+	if p.DefaultSampleType, err = str(defaultSampleType); err != nil {
+		return nil, err
+	}
+	for _, raw := range sampleTypes {
+		vt, err := valueType(raw)
+		if err != nil {
+			return nil, err
+		}
+		p.SampleTypes = append(p.SampleTypes, vt)
+	}
+
+	// Many samples share locations, so convert each location only once.
+	frames := map[uint64][]Frame{}
+	for id, lines := range locations {
+		// Lines are ordered from the innermost inlined call to the physical frame, like stacks.
+		fs := make([]Frame, len(lines))
+		for i, l := range lines {
+			fn := functions[l.function]
+			name, err := str(fn.name)
+			if err != nil {
+				return nil, err
+			}
+			file, err := str(fn.file)
+			if err != nil {
+				return nil, err
+			}
+			fs[i] = Frame{Function: name, File: file, Line: l.line}
+		}
+		frames[id] = fs
+	}
+
+	p.Samples = make([]Sample, len(samples))
+	for i, raw := range samples {
+		if len(raw.values) != len(p.SampleTypes) {
+			return nil, fmt.Errorf("sample has %d values, expected %d", len(raw.values), len(p.SampleTypes))
+		}
+		s := &p.Samples[i]
+		for _, loc := range raw.locations {
+			s.Stack = append(s.Stack, frames[loc]...)
+		}
+		s.Values = make([]int64, len(raw.values))
+		for j, v := range raw.values {
+			s.Values[j] = int64(v)
+		}
+		for _, l := range raw.labels {
+			key, err := str(l[0])
+			if err != nil {
+				return nil, err
+			}
+			value, err := str(l[1])
+			if err != nil {
+				return nil, err
+			}
+			if s.Labels == nil {
+				s.Labels = map[string][]string{}
+			}
+			s.Labels[key] = append(s.Labels[key], value)
+		}
+	}
+	return p, nil
+}
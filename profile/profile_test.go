@@ -0,0 +1,48 @@
+package profile
+
+import (
+	"bytes"
+	"runtime/pprof"
+	"testing"
+)
+
+func TestParseGoroutineProfile(t *testing.T) {
+	var buf bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&buf, 0); err != nil {
+		t.Fatal(err)
+	}
+	p, err := Parse(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.SampleTypes) != 1 || p.SampleTypes[0] != (ValueType{Type: "goroutine", Unit: "count"}) {
+		t.Fatalf("unexpected sample types %v", p.SampleTypes)
+	}
+	if len(p.Samples) == 0 {
+		t.Fatal("profile has no samples")
+	}
+
+	// The profile includes the goroutine running this test.
+	var found bool
+	for _, s := range p.Samples {
+		for _, f := range s.Stack {
+			if f.Function == "honnef.co/go/gotraceui/profile.TestParseGoroutineProfile" {
+				found = true
+			}
+		}
+	}
+	if !found {
+		t.Error("couldn't find the test's stack in the profile")
+	}
+}
+
+func TestParseTruncated(t *testing.T) {
+	var buf bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&buf, 0); err != nil {
+		t.Fatal(err)
+	}
+	// Cut the profile in the middle of the gzip stream.
+	if _, err := Parse(bytes.NewReader(buf.Bytes()[:buf.Len()/2])); err == nil {
+		t.Error("expected an error for a truncated profile")
+	}
+}
@@ -0,0 +1,363 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// compiled is an expression that has been resolved against the columns of a table.
+type compiled struct {
+	kind Kind
+	eval func(row []Value) Value
+}
+
+func lookupColumn(columns []Column, name string) (int, bool) {
+	for i, c := range columns {
+		if strings.EqualFold(c.Name, name) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// compile compiles an expression that may refer to the given columns. Aggregate functions aren't allowed.
+func compile(e Expr, columns []Column) (compiled, error) {
+	switch e := e.(type) {
+	case Literal:
+		v := e.Value
+		return compiled{v.Kind, func([]Value) Value { return v }}, nil
+
+	case Ident:
+		if e.Name == "*" {
+			return compiled{}, fmt.Errorf("* can only be used in count(*)")
+		}
+		idx, ok := lookupColumn(columns, e.Name)
+		if !ok {
+			names := make([]string, len(columns))
+			for i, c := range columns {
+				names[i] = c.Name
+			}
+			return compiled{}, fmt.Errorf("unknown column %q, expected one of %s", e.Name, strings.Join(names, ", "))
+		}
+		return compiled{columns[idx].Kind, func(row []Value) Value { return row[idx] }}, nil
+
+	case Unary:
+		x, err := compile(e.X, columns)
+		if err != nil {
+			return compiled{}, err
+		}
+		switch e.Op {
+		case "not":
+			if x.kind != KindBool && x.kind != KindNull {
+				return compiled{}, fmt.Errorf("can't negate %s of type %s", e.X, x.kind)
+			}
+			return compiled{KindBool, func(row []Value) Value {
+				v := x.eval(row)
+				if v.IsNull() {
+					return v
+				}
+				return Bool(!v.truthy())
+			}}, nil
+		case "-":
+			if !x.kind.numeric() || x.kind == KindTime {
+				return compiled{}, fmt.Errorf("can't negate %s of type %s", e.X, x.kind)
+			}
+			return compiled{x.kind, func(row []Value) Value {
+				v := x.eval(row)
+				v.Int = -v.Int
+				v.Float = -v.Float
+				return v
+			}}, nil
+		default:
+			panic(fmt.Sprintf("unhandled operator %s", e.Op))
+		}
+
+	case Binary:
+		return compileBinary(e, columns)
+
+	case Call:
+		if _, ok := aggregates[e.Func]; ok {
+			return compiled{}, fmt.Errorf("aggregate function %s can only be used in stats", e.Func)
+		}
+		return compileCall(e, columns)
+
+	default:
+		panic(fmt.Sprintf("unhandled expression %T", e))
+	}
+}
+
+func compileBinary(e Binary, columns []Column) (compiled, error) {
+	x, err := compile(e.X, columns)
+	if err != nil {
+		return compiled{}, err
+	}
+	// The right-hand side of regular expression matches is compiled once, ahead of time.
+	if e.Op == "~" || e.Op == "!~" {
+		lit, ok := e.Y.(Literal)
+		if !ok || lit.Value.Kind != KindString {
+			return compiled{}, fmt.Errorf("the right-hand side of %s must be a string", e.Op)
+		}
+		if x.kind != KindString && x.kind != KindNull {
+			return compiled{}, fmt.Errorf("can't match %s of type %s against a regular expression", e.X, x.kind)
+		}
+		re, err := regexp.Compile(lit.Value.Str)
+		if err != nil {
+			return compiled{}, err
+		}
+		negate := e.Op == "!~"
+		return compiled{KindBool, func(row []Value) Value {
+			v := x.eval(row)
+			if v.IsNull() {
+				return Bool(false)
+			}
+			return Bool(re.MatchString(v.Str) != negate)
+		}}, nil
+	}
+
+	y, err := compile(e.Y, columns)
+	if err != nil {
+		return compiled{}, err
+	}
+	mismatch := func() error {
+		return fmt.Errorf("can't apply %s to %s of type %s and %s of type %s", e.Op, e.X, x.kind, e.Y, y.kind)
+	}
+
+	switch e.Op {
+	case "and", "or":
+		if (x.kind != KindBool && x.kind != KindNull) || (y.kind != KindBool && y.kind != KindNull) {
+			return compiled{}, mismatch()
+		}
+		and := e.Op == "and"
+		return compiled{KindBool, func(row []Value) Value {
+			if xv := x.eval(row).truthy(); xv != and {
+				// Short-circuit: false and …, true or …
+				return Bool(xv)
+			}
+			return Bool(y.eval(row).truthy())
+		}}, nil
+
+	case "=", "!=", "<", "<=", ">", ">=":
+		// Comparing with null checks whether a value is null.
+		if x.kind == KindNull || y.kind == KindNull {
+			if e.Op != "=" && e.Op != "!=" {
+				return compiled{}, fmt.Errorf("null can only be compared with = and !=")
+			}
+			want := e.Op == "="
+			return compiled{KindBool, func(row []Value) Value {
+				return Bool((x.eval(row).IsNull() && y.eval(row).IsNull()) == want)
+			}}, nil
+		}
+		if !(x.kind.numeric() && y.kind.numeric()) && x.kind != y.kind {
+			return compiled{}, mismatch()
+		}
+		var test func(c int) bool
+		switch e.Op {
+		case "=":
+			test = func(c int) bool { return c == 0 }
+		case "!=":
+			test = func(c int) bool { return c != 0 }
+		case "<":
+			test = func(c int) bool { return c < 0 }
+		case "<=":
+			test = func(c int) bool { return c <= 0 }
+		case ">":
+			test = func(c int) bool { return c > 0 }
+		case ">=":
+			test = func(c int) bool { return c >= 0 }
+		}
+		return compiled{KindBool, func(row []Value) Value {
+			xv, yv := x.eval(row), y.eval(row)
+			if xv.IsNull() || yv.IsNull() {
+				return Bool(false)
+			}
+			return Bool(test(Compare(xv, yv)))
+		}}, nil
+
+	case "+", "-", "*", "/", "%":
+		kind, ok := arithmeticKind(e.Op, x.kind, y.kind)
+		if !ok {
+			return compiled{}, mismatch()
+		}
+		op := e.Op
+		return compiled{kind, func(row []Value) Value {
+			return arithmetic(op, kind, x.eval(row), y.eval(row))
+		}}, nil
+
+	default:
+		panic(fmt.Sprintf("unhandled operator %s", e.Op))
+	}
+}
+
+// arithmeticKind returns the kind of the result of applying op to values of kinds x and y.
+func arithmeticKind(op string, x, y Kind) (Kind, bool) {
+	if x == KindNull || y == KindNull {
+		return KindNull, true
+	}
+	if !x.numeric() || !y.numeric() {
+		if op == "+" && x == KindString && y == KindString {
+			return KindString, true
+		}
+		return 0, false
+	}
+	plain := func(k Kind) bool { return k == KindInt || k == KindFloat }
+	switch {
+	case plain(x) && plain(y):
+		if op != "%" && (x == KindFloat || y == KindFloat) {
+			return KindFloat, true
+		}
+		if op == "%" && (x == KindFloat || y == KindFloat) {
+			return 0, false
+		}
+		return KindInt, true
+	case x == KindDuration && y == KindDuration:
+		switch op {
+		case "+", "-", "%":
+			return KindDuration, true
+		case "/":
+			return KindFloat, true
+		}
+	case x == KindTime && y == KindDuration && (op == "+" || op == "-"):
+		return KindTime, true
+	case x == KindDuration && y == KindTime && op == "+":
+		return KindTime, true
+	case x == KindTime && y == KindTime && op == "-":
+		return KindDuration, true
+	case x == KindDuration && plain(y) && (op == "*" || op == "/"):
+		return KindDuration, true
+	case plain(x) && y == KindDuration && op == "*":
+		return KindDuration, true
+	}
+	return 0, false
+}
+
+func arithmetic(op string, kind Kind, x, y Value) Value {
+	if x.IsNull() || y.IsNull() {
+		return Null()
+	}
+	if kind == KindString {
+		return String(x.Str + y.Str)
+	}
+	if x.Kind == KindFloat || y.Kind == KindFloat || (kind == KindFloat && op == "/") {
+		a, b := x.float(), y.float()
+		var r float64
+		switch op {
+		case "+":
+			r = a + b
+		case "-":
+			r = a - b
+		case "*":
+			r = a * b
+		case "/":
+			if b == 0 {
+				return Null()
+			}
+			r = a / b
+		}
+		if kind == KindFloat {
+			return Float(r)
+		}
+		return Value{Kind: kind, Int: int64(r)}
+	}
+	a, b := x.Int, y.Int
+	var r int64
+	switch op {
+	case "+":
+		r = a + b
+	case "-":
+		r = a - b
+	case "*":
+		r = a * b
+	case "/":
+		if b == 0 {
+			return Null()
+		}
+		r = a / b
+	case "%":
+		if b == 0 {
+			return Null()
+		}
+		r = a % b
+	}
+	return Value{Kind: kind, Int: r}
+}
+
+// scalarFunctions are the functions that can be used in expressions. Each function checks the kinds of its arguments
+// and returns the kind of its result.
+var scalarFunctions = map[string]struct {
+	check func(args []Kind) (Kind, bool)
+	call  func(args []Value) Value
+}{
+	"lower": {
+		check: stringArgs(1, KindString),
+		call:  func(args []Value) Value { return String(strings.ToLower(args[0].Str)) },
+	},
+	"upper": {
+		check: stringArgs(1, KindString),
+		call:  func(args []Value) Value { return String(strings.ToUpper(args[0].Str)) },
+	},
+	"contains": {
+		check: stringArgs(2, KindBool),
+		call:  func(args []Value) Value { return Bool(strings.Contains(args[0].Str, args[1].Str)) },
+	},
+	"startswith": {
+		check: stringArgs(2, KindBool),
+		call:  func(args []Value) Value { return Bool(strings.HasPrefix(args[0].Str, args[1].Str)) },
+	},
+	"endswith": {
+		check: stringArgs(2, KindBool),
+		call:  func(args []Value) Value { return Bool(strings.HasSuffix(args[0].Str, args[1].Str)) },
+	},
+	// ns converts a duration or timestamp to an integer number of nanoseconds.
+	"ns": {
+		check: func(args []Kind) (Kind, bool) {
+			return KindInt, len(args) == 1 && (args[0] == KindDuration || args[0] == KindTime || args[0] == KindInt)
+		},
+		call: func(args []Value) Value { return Int(args[0].Int) },
+	},
+}
+
+func stringArgs(n int, result Kind) func(args []Kind) (Kind, bool) {
+	return func(args []Kind) (Kind, bool) {
+		if len(args) != n {
+			return 0, false
+		}
+		for _, k := range args {
+			if k != KindString && k != KindNull {
+				return 0, false
+			}
+		}
+		return result, true
+	}
+}
+
+func compileCall(e Call, columns []Column) (compiled, error) {
+	fn, ok := scalarFunctions[e.Func]
+	if !ok {
+		return compiled{}, fmt.Errorf("unknown function %s", e.Func)
+	}
+	args := make([]compiled, len(e.Args))
+	kinds := make([]Kind, len(e.Args))
+	for i, arg := range e.Args {
+		c, err := compile(arg, columns)
+		if err != nil {
+			return compiled{}, err
+		}
+		args[i] = c
+		kinds[i] = c.kind
+	}
+	kind, ok := fn.check(kinds)
+	if !ok {
+		return compiled{}, fmt.Errorf("invalid arguments in %s", e)
+	}
+	return compiled{kind, func(row []Value) Value {
+		vals := make([]Value, len(args))
+		for i, arg := range args {
+			vals[i] = arg.eval(row)
+			if vals[i].IsNull() {
+				return Null()
+			}
+		}
+		return fn.call(vals)
+	}}, nil
+}
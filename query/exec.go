@@ -0,0 +1,391 @@
+package query
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// aggregates are the aggregate functions that can be used in stats. result returns the kind of the aggregate, given
+// the kind of its argument.
+var aggregates = map[string]struct {
+	// args is the number of arguments the function expects.
+	args   int
+	result func(arg Kind) (Kind, bool)
+}{
+	"count":    {0, func(Kind) (Kind, bool) { return KindInt, true }},
+	"distinct": {1, func(Kind) (Kind, bool) { return KindInt, true }},
+	"sum":      {1, sumKind},
+	"avg":      {1, avgKind},
+	"min":      {1, sameKind},
+	"max":      {1, sameKind},
+	"p50":      {1, sameKind},
+	"p90":      {1, sameKind},
+	"p99":      {1, sameKind},
+}
+
+func sumKind(k Kind) (Kind, bool) {
+	switch k {
+	case KindInt, KindFloat, KindDuration, KindNull:
+		return k, true
+	default:
+		return 0, false
+	}
+}
+
+func avgKind(k Kind) (Kind, bool) {
+	switch k {
+	case KindInt, KindFloat:
+		return KindFloat, true
+	case KindDuration, KindNull:
+		return k, true
+	default:
+		return 0, false
+	}
+}
+
+func sameKind(k Kind) (Kind, bool) { return k, true }
+
+// Run runs a query against the tables in cat. At most maxRows rows are returned; if the query produced more,
+// Result.Truncated is set. A maxRows of zero or less doesn't limit the number of rows.
+func Run(q *Query, cat Catalog, maxRows int) (*Result, error) {
+	src, ok := cat[strings.ToLower(q.From)]
+	if !ok {
+		names := make([]string, 0, len(cat))
+		for name := range cat {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		return nil, fmt.Errorf("unknown table %q, expected one of %s", q.From, strings.Join(names, ", "))
+	}
+
+	for _, st := range q.Stages {
+		var err error
+		switch st := st.(type) {
+		case Where:
+			src, err = where(src, st)
+		case Select:
+			src, err = project(src, st)
+		case Stats:
+			src, err = stats(src, st)
+		case Sort:
+			src, err = sortRows(src, st)
+		case Limit:
+			src = limit(src, st.N)
+		default:
+			panic(fmt.Sprintf("unhandled stage %T", st))
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	res := &Result{Columns: src.Columns()}
+	src.Scan(func(row []Value) bool {
+		if maxRows > 0 && len(res.Rows) == maxRows {
+			res.Truncated = true
+			return false
+		}
+		res.Rows = append(res.Rows, slices.Clone(row))
+		return true
+	})
+	return res, nil
+}
+
+func where(src Source, st Where) (Source, error) {
+	cond, err := compile(st.Cond, src.Columns())
+	if err != nil {
+		return nil, err
+	}
+	if cond.kind != KindBool && cond.kind != KindNull {
+		return nil, fmt.Errorf("where needs a condition, but %s is of type %s", st.Cond, cond.kind)
+	}
+	return NewSource(src.Columns(), func(yield func(row []Value) bool) {
+		src.Scan(func(row []Value) bool {
+			if !cond.eval(row).truthy() {
+				return true
+			}
+			return yield(row)
+		})
+	}), nil
+}
+
+func project(src Source, st Select) (Source, error) {
+	columns := make([]Column, len(st.Fields))
+	fields := make([]compiled, len(st.Fields))
+	for i, f := range st.Fields {
+		c, err := compile(f.Expr, src.Columns())
+		if err != nil {
+			return nil, err
+		}
+		fields[i] = c
+		columns[i] = Column{Name: f.Name, Kind: c.kind}
+	}
+	return NewSource(columns, func(yield func(row []Value) bool) {
+		out := make([]Value, len(fields))
+		src.Scan(func(row []Value) bool {
+			for i, f := range fields {
+				out[i] = f.eval(row)
+			}
+			return yield(out)
+		})
+	}), nil
+}
+
+// accumulator computes the value of an aggregate function for one group.
+type accumulator struct {
+	count  int64
+	sum    Value
+	values []Value
+	seen   map[string]struct{}
+	best   Value
+}
+
+type aggregate struct {
+	fn   string
+	arg  compiled
+	kind Kind
+}
+
+func (agg *aggregate) add(acc *accumulator, row []Value) {
+	if agg.arg.eval == nil {
+		// count()
+		acc.count++
+		return
+	}
+	v := agg.arg.eval(row)
+	if v.IsNull() {
+		return
+	}
+	acc.count++
+	switch agg.fn {
+	case "count":
+	case "distinct":
+		if acc.seen == nil {
+			acc.seen = map[string]struct{}{}
+		}
+		acc.seen[key(v)] = struct{}{}
+	case "sum", "avg":
+		if acc.count == 1 {
+			acc.sum = v
+		} else if v.Kind == KindFloat {
+			acc.sum.Float += v.Float
+		} else {
+			acc.sum.Int += v.Int
+		}
+	case "min":
+		if acc.count == 1 || Compare(v, acc.best) < 0 {
+			acc.best = v
+		}
+	case "max":
+		if acc.count == 1 || Compare(v, acc.best) > 0 {
+			acc.best = v
+		}
+	case "p50", "p90", "p99":
+		acc.values = append(acc.values, v)
+	default:
+		panic(fmt.Sprintf("unhandled aggregate %s", agg.fn))
+	}
+}
+
+func (agg *aggregate) result(acc *accumulator) Value {
+	switch agg.fn {
+	case "count":
+		return Int(acc.count)
+	case "distinct":
+		return Int(int64(len(acc.seen)))
+	}
+	if acc.count == 0 {
+		return Null()
+	}
+	switch agg.fn {
+	case "sum":
+		return acc.sum
+	case "avg":
+		if agg.kind == KindFloat {
+			return Float(acc.sum.float() / float64(acc.count))
+		}
+		return Value{Kind: agg.kind, Int: acc.sum.Int / acc.count}
+	case "min", "max":
+		return acc.best
+	case "p50", "p90", "p99":
+		// Nearest-rank percentiles
+		p, _ := strconv.Atoi(agg.fn[1:])
+		slices.SortFunc(acc.values, Compare)
+		rank := int(math.Ceil(float64(p) / 100 * float64(len(acc.values))))
+		return acc.values[max(rank-1, 0)]
+	default:
+		panic(fmt.Sprintf("unhandled aggregate %s", agg.fn))
+	}
+}
+
+// key encodes a value so that values that compare equal have equal keys.
+func key(v Value) string {
+	switch v.Kind {
+	case KindString:
+		return "s" + v.Str
+	case KindFloat:
+		if v.Float == math.Trunc(v.Float) && math.Abs(v.Float) < 1<<63 {
+			return "n" + strconv.FormatInt(int64(v.Float), 10)
+		}
+		return "f" + strconv.FormatFloat(v.Float, 'g', -1, 64)
+	case KindInt, KindDuration, KindTime:
+		return "n" + strconv.FormatInt(v.Int, 10)
+	default:
+		return strconv.Itoa(int(v.Kind)) + strconv.FormatInt(v.Int, 10)
+	}
+}
+
+func stats(src Source, st Stats) (Source, error) {
+	var columns []Column
+	by := make([]compiled, len(st.By))
+	for i, f := range st.By {
+		c, err := compile(f.Expr, src.Columns())
+		if err != nil {
+			return nil, err
+		}
+		by[i] = c
+		columns = append(columns, Column{Name: f.Name, Kind: c.kind})
+	}
+	aggs := make([]aggregate, len(st.Aggregates))
+	for i, f := range st.Aggregates {
+		call, ok := f.Expr.(Call)
+		if !ok {
+			return nil, fmt.Errorf("%s isn't an aggregate function", f.Expr)
+		}
+		spec, ok := aggregates[call.Func]
+		if !ok {
+			return nil, fmt.Errorf("%s isn't an aggregate function", call.Func)
+		}
+		args := call.Args
+		if call.Func == "count" && len(args) == 1 {
+			// count(*) and count(x) both count rows, but the latter only counts rows in which x isn't null.
+			if id, ok := args[0].(Ident); ok && id.Name == "*" {
+				args = nil
+			}
+		}
+		if len(args) != spec.args && !(call.Func == "count" && len(args) == 1) {
+			return nil, fmt.Errorf("%s expects %d arguments, got %d", call.Func, spec.args, len(args))
+		}
+		agg := aggregate{fn: call.Func}
+		var argKind Kind
+		if len(args) == 1 {
+			c, err := compile(args[0], src.Columns())
+			if err != nil {
+				return nil, err
+			}
+			agg.arg = c
+			argKind = c.kind
+		}
+		kind, ok := spec.result(argKind)
+		if !ok {
+			return nil, fmt.Errorf("can't compute %s of %s of type %s", call.Func, args[0], argKind)
+		}
+		agg.kind = kind
+		aggs[i] = agg
+		columns = append(columns, Column{Name: f.Name, Kind: kind})
+	}
+
+	return NewSource(columns, func(yield func(row []Value) bool) {
+		type group struct {
+			by   []Value
+			accs []accumulator
+		}
+		var groups []*group
+		index := map[string]*group{}
+		var sb strings.Builder
+		src.Scan(func(row []Value) bool {
+			sb.Reset()
+			for _, c := range by {
+				sb.WriteString(key(c.eval(row)))
+				sb.WriteByte(0)
+			}
+			g, ok := index[sb.String()]
+			if !ok {
+				g = &group{by: make([]Value, len(by)), accs: make([]accumulator, len(aggs))}
+				for i, c := range by {
+					g.by[i] = c.eval(row)
+				}
+				index[sb.String()] = g
+				groups = append(groups, g)
+			}
+			for i := range aggs {
+				aggs[i].add(&g.accs[i], row)
+			}
+			return true
+		})
+		if len(groups) == 0 && len(by) == 0 {
+			// Aggregating no rows still produces a row, e.g. a count of zero.
+			groups = append(groups, &group{accs: make([]accumulator, len(aggs))})
+		}
+
+		out := make([]Value, len(columns))
+		for _, g := range groups {
+			copy(out, g.by)
+			for i := range aggs {
+				out[len(by)+i] = aggs[i].result(&g.accs[i])
+			}
+			if !yield(out) {
+				return
+			}
+		}
+	}), nil
+}
+
+func sortRows(src Source, st Sort) (Source, error) {
+	keys := make([]compiled, len(st.Keys))
+	for i, k := range st.Keys {
+		c, err := compile(k.Expr, src.Columns())
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = c
+	}
+	return NewSource(src.Columns(), func(yield func(row []Value) bool) {
+		type sortRow struct {
+			row  []Value
+			keys []Value
+		}
+		var rows []sortRow
+		src.Scan(func(row []Value) bool {
+			r := sortRow{row: slices.Clone(row), keys: make([]Value, len(keys))}
+			for i, k := range keys {
+				r.keys[i] = k.eval(row)
+			}
+			rows = append(rows, r)
+			return true
+		})
+		slices.SortStableFunc(rows, func(a, b sortRow) int {
+			for i, k := range st.Keys {
+				c := Compare(a.keys[i], b.keys[i])
+				if k.Descending {
+					c = -c
+				}
+				if c != 0 {
+					return c
+				}
+			}
+			return 0
+		})
+		for _, r := range rows {
+			if !yield(r.row) {
+				return
+			}
+		}
+	}), nil
+}
+
+func limit(src Source, n int) Source {
+	return NewSource(src.Columns(), func(yield func(row []Value) bool) {
+		if n == 0 {
+			return
+		}
+		i := 0
+		src.Scan(func(row []Value) bool {
+			i++
+			return yield(row) && i < n
+		})
+	})
+}
@@ -0,0 +1,666 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Query is a parsed query.
+type Query struct {
+	// From is the name of the table to query.
+	From   string
+	Stages []Stage
+}
+
+// A Stage is one step of a query's pipeline.
+type Stage interface {
+	stage()
+}
+
+// Where keeps the rows that match a condition.
+type Where struct {
+	Cond Expr
+}
+
+// Select computes new columns from the existing ones.
+type Select struct {
+	Fields []NamedExpr
+}
+
+// Stats aggregates rows. Each aggregate is a call of an aggregate function. If By isn't empty, rows are grouped by the
+// values of its expressions, and there is one row per group.
+type Stats struct {
+	Aggregates []NamedExpr
+	By         []NamedExpr
+}
+
+// Sort sorts rows.
+type Sort struct {
+	Keys []SortKey
+}
+
+// Limit keeps the first N rows.
+type Limit struct {
+	N int
+}
+
+func (Where) stage()  {}
+func (Select) stage() {}
+func (Stats) stage()  {}
+func (Sort) stage()   {}
+func (Limit) stage()  {}
+
+// A NamedExpr is an expression and the name of the column it computes.
+type NamedExpr struct {
+	Expr Expr
+	Name string
+}
+
+type SortKey struct {
+	Expr       Expr
+	Descending bool
+}
+
+// An Expr is an expression.
+type Expr interface {
+	String() string
+	expr()
+}
+
+type Literal struct{ Value Value }
+
+type Ident struct{ Name string }
+
+type Unary struct {
+	Op string
+	X  Expr
+}
+
+type Binary struct {
+	Op   string
+	X, Y Expr
+}
+
+type Call struct {
+	Func string
+	Args []Expr
+}
+
+func (Literal) expr() {}
+func (Ident) expr()   {}
+func (Unary) expr()   {}
+func (Binary) expr()  {}
+func (Call) expr()    {}
+
+func (e Literal) String() string {
+	if e.Value.Kind == KindString {
+		return strconv.Quote(e.Value.Str)
+	}
+	if e.Value.Kind == KindNull {
+		return "null"
+	}
+	return e.Value.String()
+}
+
+func (e Ident) String() string { return e.Name }
+
+func (e Unary) String() string {
+	if e.Op == "not" {
+		return "not " + e.X.String()
+	}
+	return e.Op + e.X.String()
+}
+
+func (e Binary) String() string { return "(" + e.X.String() + " " + e.Op + " " + e.Y.String() + ")" }
+
+func (e Call) String() string {
+	args := make([]string, len(e.Args))
+	for i, arg := range e.Args {
+		args[i] = arg.String()
+	}
+	return e.Func + "(" + strings.Join(args, ", ") + ")"
+}
+
+// defaultName returns the name of the column computed by e, if the query doesn't name it.
+func defaultName(e Expr) string {
+	switch e := e.(type) {
+	case Ident:
+		return e.Name
+	case Call:
+		if len(e.Args) == 1 {
+			if id, ok := e.Args[0].(Ident); ok && id.Name != "*" {
+				return e.Func + "_" + id.Name
+			}
+		}
+		return e.Func
+	default:
+		return e.String()
+	}
+}
+
+// A SyntaxError describes a query that couldn't be parsed.
+type SyntaxError struct {
+	// Offset is the byte offset in the query at which the error was found.
+	Offset int
+	Msg    string
+}
+
+func (err *SyntaxError) Error() string {
+	return fmt.Sprintf("at offset %d: %s", err.Offset, err.Msg)
+}
+
+type tokenKind uint8
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokDuration
+	tokString
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	// The decoded value of strings, numbers and durations.
+	value Value
+	off   int
+}
+
+// keyword reports whether the token is the given keyword. Keywords are case-insensitive.
+func (t token) keyword(kw string) bool {
+	return t.kind == tokIdent && strings.EqualFold(t.text, kw)
+}
+
+func (t token) op(op string) bool {
+	return t.kind == tokOp && t.text == op
+}
+
+func (t token) String() string {
+	if t.kind == tokEOF {
+		return "end of query"
+	}
+	return strconv.Quote(t.text)
+}
+
+// operators lists the operators, longer ones first so that they take precedence.
+var operators = []string{"==", "!=", "<>", "<=", ">=", "!~", "|", "(", ")", ",", "=", "<", ">", "~", "+", "-", "*", "/", "%", ";"}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || r == '.' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func lex(s string) ([]token, error) {
+	var toks []token
+	for off := 0; off < len(s); {
+		r, size := utf8.DecodeRuneInString(s[off:])
+		switch {
+		case unicode.IsSpace(r):
+			off += size
+		case r == '"' || r == '\'':
+			end := off + 1
+			for end < len(s) {
+				if r == '"' && s[end] == '\\' {
+					end += 2
+					continue
+				}
+				if s[end] == byte(r) {
+					if r == '\'' && end+1 < len(s) && s[end+1] == '\'' {
+						end += 2
+						continue
+					}
+					break
+				}
+				end++
+			}
+			if end >= len(s) {
+				return nil, &SyntaxError{off, "unterminated string"}
+			}
+			text := s[off : end+1]
+			var str string
+			if r == '"' {
+				var err error
+				if str, err = strconv.Unquote(text); err != nil {
+					return nil, &SyntaxError{off, "invalid string " + text}
+				}
+			} else {
+				// Single-quoted strings don't support escapes other than a doubled quote, as in SQL.
+				str = strings.ReplaceAll(text[1:len(text)-1], "''", "'")
+			}
+			toks = append(toks, token{kind: tokString, text: text, value: String(str), off: off})
+			off = end + 1
+		case unicode.IsDigit(r):
+			end := off
+			for end < len(s) {
+				r, size := utf8.DecodeRuneInString(s[end:])
+				if !isIdentRune(r) && r != 'µ' {
+					break
+				}
+				end += size
+			}
+			text := s[off:end]
+			tok := token{kind: tokNumber, text: text, off: off}
+			if v, err := strconv.ParseInt(text, 10, 64); err == nil {
+				tok.value = Int(v)
+			} else if v, err := strconv.ParseFloat(text, 64); err == nil {
+				tok.value = Float(v)
+			} else if d, err := time.ParseDuration(text); err == nil {
+				tok.kind = tokDuration
+				tok.value = Duration(d)
+			} else {
+				return nil, &SyntaxError{off, "invalid number " + text}
+			}
+			toks = append(toks, tok)
+			off = end
+		case isIdentRune(r):
+			end := off
+			for end < len(s) {
+				r, size := utf8.DecodeRuneInString(s[end:])
+				if !isIdentRune(r) {
+					break
+				}
+				end += size
+			}
+			toks = append(toks, token{kind: tokIdent, text: s[off:end], off: off})
+			off = end
+		default:
+			found := false
+			for _, op := range operators {
+				if strings.HasPrefix(s[off:], op) {
+					toks = append(toks, token{kind: tokOp, text: op, off: off})
+					off += len(op)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, &SyntaxError{off, fmt.Sprintf("unexpected character %q", r)}
+			}
+		}
+	}
+	toks = append(toks, token{kind: tokEOF, off: len(s)})
+	return toks, nil
+}
+
+type parser struct {
+	toks []token
+	pos  int
+}
+
+func (p *parser) peek() token { return p.toks[p.pos] }
+
+func (p *parser) next() token {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) errorf(t token, format string, args ...any) error {
+	return &SyntaxError{t.off, fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) acceptKeyword(kw string) bool {
+	if p.peek().keyword(kw) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) acceptOp(op string) bool {
+	if p.peek().op(op) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectOp(op string) error {
+	if !p.acceptOp(op) {
+		return p.errorf(p.peek(), "expected %q, found %s", op, p.peek())
+	}
+	return nil
+}
+
+// reserved are the keywords that can't be used as column names.
+var reserved = map[string]bool{
+	"and": true, "or": true, "not": true, "as": true, "by": true, "asc": true, "desc": true, "is": true, "like": true,
+	"true": true, "false": true, "null": true,
+}
+
+func (p *parser) ident() (string, error) {
+	t := p.next()
+	if t.kind != tokIdent || reserved[strings.ToLower(t.text)] {
+		return "", p.errorf(t, "expected a name, found %s", t)
+	}
+	return t.text, nil
+}
+
+// Parse parses a query in the pipeline or SQL syntax described in the package documentation. Queries that start with
+// SELECT use the SQL syntax.
+func Parse(s string) (*Query, error) {
+	toks, err := lex(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+	if p.acceptKeyword("select") {
+		return p.parseSQL()
+	}
+	from, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	q := &Query{From: from}
+	for p.acceptOp("|") {
+		st, err := p.stage()
+		if err != nil {
+			return nil, err
+		}
+		q.Stages = append(q.Stages, st)
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, p.errorf(t, "expected | or end of query, found %s", t)
+	}
+	return q, nil
+}
+
+func (p *parser) stage() (Stage, error) {
+	t := p.next()
+	switch {
+	case t.keyword("where"):
+		cond, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		return Where{Cond: cond}, nil
+	case t.keyword("select"):
+		fields, err := p.namedExprs()
+		if err != nil {
+			return nil, err
+		}
+		return Select{Fields: fields}, nil
+	case t.keyword("stats"):
+		aggs, err := p.namedExprs()
+		if err != nil {
+			return nil, err
+		}
+		st := Stats{Aggregates: aggs}
+		if p.acceptKeyword("by") {
+			if st.By, err = p.namedExprs(); err != nil {
+				return nil, err
+			}
+		}
+		return st, nil
+	case t.keyword("sort"):
+		var keys []SortKey
+		for {
+			e, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			key := SortKey{Expr: e}
+			if p.acceptKeyword("desc") {
+				key.Descending = true
+			} else {
+				p.acceptKeyword("asc")
+			}
+			keys = append(keys, key)
+			if !p.acceptOp(",") {
+				break
+			}
+		}
+		return Sort{Keys: keys}, nil
+	case t.keyword("limit"):
+		n := p.next()
+		if n.kind != tokNumber || n.value.Kind != KindInt || n.value.Int < 0 {
+			return nil, p.errorf(n, "expected a number of rows, found %s", n)
+		}
+		return Limit{N: int(n.value.Int)}, nil
+	default:
+		return nil, p.errorf(t, "expected where, select, stats, sort or limit, found %s", t)
+	}
+}
+
+func (p *parser) namedExprs() ([]NamedExpr, error) {
+	var out []NamedExpr
+	for {
+		e, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		ne := NamedExpr{Expr: e, Name: defaultName(e)}
+		if p.acceptKeyword("as") {
+			if ne.Name, err = p.ident(); err != nil {
+				return nil, err
+			}
+		}
+		out = append(out, ne)
+		if !p.acceptOp(",") {
+			return out, nil
+		}
+	}
+}
+
+func (p *parser) expr() (Expr, error) {
+	return p.or()
+}
+
+func (p *parser) or() (Expr, error) {
+	x, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("or") {
+		y, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		x = Binary{Op: "or", X: x, Y: y}
+	}
+	return x, nil
+}
+
+func (p *parser) and() (Expr, error) {
+	x, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("and") {
+		y, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		x = Binary{Op: "and", X: x, Y: y}
+	}
+	return x, nil
+}
+
+func (p *parser) not() (Expr, error) {
+	if p.acceptKeyword("not") {
+		x, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		return Unary{Op: "not", X: x}, nil
+	}
+	return p.comparison()
+}
+
+func (p *parser) comparison() (Expr, error) {
+	x, err := p.additive()
+	if err != nil {
+		return nil, err
+	}
+	t := p.peek()
+	switch {
+	case t.keyword("is"):
+		// x IS [NOT] NULL
+		p.next()
+		op := "="
+		if p.acceptKeyword("not") {
+			op = "!="
+		}
+		if t := p.next(); !t.keyword("null") {
+			return nil, p.errorf(t, "expected NULL, found %s", t)
+		}
+		return Binary{Op: op, X: x, Y: Literal{Value: Null()}}, nil
+	case t.keyword("like"):
+		// x LIKE 'pattern', where % matches any number of characters and _ matches one character.
+		p.next()
+		pat := p.next()
+		if pat.kind != tokString {
+			return nil, p.errorf(pat, "expected a pattern, found %s", pat)
+		}
+		return Binary{Op: "~", X: x, Y: Literal{Value: String(likeToRegexp(pat.value.Str))}}, nil
+	case t.kind != tokOp:
+		return x, nil
+	}
+	op := t.text
+	switch op {
+	case "==":
+		op = "="
+	case "<>":
+		op = "!="
+	case "=", "!=", "<", "<=", ">", ">=", "~", "!~":
+	default:
+		return x, nil
+	}
+	p.next()
+	y, err := p.additive()
+	if err != nil {
+		return nil, err
+	}
+	return Binary{Op: op, X: x, Y: y}, nil
+}
+
+func (p *parser) additive() (Expr, error) {
+	x, err := p.multiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if !t.op("+") && !t.op("-") {
+			return x, nil
+		}
+		p.next()
+		y, err := p.multiplicative()
+		if err != nil {
+			return nil, err
+		}
+		x = Binary{Op: t.text, X: x, Y: y}
+	}
+}
+
+func (p *parser) multiplicative() (Expr, error) {
+	x, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if !t.op("*") && !t.op("/") && !t.op("%") {
+			return x, nil
+		}
+		p.next()
+		y, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		x = Binary{Op: t.text, X: x, Y: y}
+	}
+}
+
+func (p *parser) unary() (Expr, error) {
+	if p.acceptOp("-") {
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return Unary{Op: "-", X: x}, nil
+	}
+	return p.primary()
+}
+
+func (p *parser) primary() (Expr, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber, tokDuration, tokString:
+		return Literal{Value: t.value}, nil
+	case tokOp:
+		if t.text == "(" {
+			e, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectOp(")"); err != nil {
+				return nil, err
+			}
+			return e, nil
+		}
+		if t.text == "*" {
+			// Only valid as the argument of count, as in count(*).
+			return Ident{Name: "*"}, nil
+		}
+	case tokIdent:
+		switch strings.ToLower(t.text) {
+		case "true":
+			return Literal{Value: Bool(true)}, nil
+		case "false":
+			return Literal{Value: Bool(false)}, nil
+		case "null":
+			return Literal{Value: Null()}, nil
+		}
+		if reserved[strings.ToLower(t.text)] {
+			break
+		}
+		if !p.acceptOp("(") {
+			return Ident{Name: t.text}, nil
+		}
+		call := Call{Func: strings.ToLower(t.text)}
+		if p.acceptOp(")") {
+			return call, nil
+		}
+		for {
+			arg, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			call.Args = append(call.Args, arg)
+			if p.acceptOp(")") {
+				return call, nil
+			}
+			if err := p.expectOp(","); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return nil, p.errorf(t, "expected an expression, found %s", t)
+}
+
+// likeToRegexp translates a pattern of SQL's LIKE operator to an anchored regular expression.
+func likeToRegexp(pat string) string {
+	var sb strings.Builder
+	sb.WriteString("(?s)^")
+	for _, r := range pat {
+		switch r {
+		case '%':
+			sb.WriteString(".*")
+		case '_':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	sb.WriteString("$")
+	return sb.String()
+}
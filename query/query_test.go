@@ -0,0 +1,193 @@
+package query
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func testCatalog() Catalog {
+	columns := []Column{
+		{"function", KindString},
+		{"state", KindString},
+		{"start", KindTime},
+		{"duration", KindDuration},
+	}
+	rows := [][]Value{
+		{String("main.main"), String("active"), Time(5), Duration(time.Millisecond)},
+		{String("main.main"), String("blocked"), Time(7), Duration(3 * time.Millisecond)},
+		{String("main.worker"), String("blocked"), Time(9), Duration(2 * time.Millisecond)},
+		{String("main.worker"), String("blocked"), Time(11), Duration(10 * time.Microsecond)},
+	}
+	return Catalog{
+		"spans": NewSource(columns, func(yield func(row []Value) bool) {
+			for _, row := range rows {
+				if !yield(row) {
+					return
+				}
+			}
+		}),
+	}
+}
+
+func formatResult(res *Result) string {
+	var sb strings.Builder
+	for i, col := range res.Columns {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString(col.Name)
+	}
+	for _, row := range res.Rows {
+		sb.WriteString("\n")
+		for i, v := range row {
+			if i > 0 {
+				sb.WriteString(",")
+			}
+			sb.WriteString(v.String())
+		}
+	}
+	return sb.String()
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{
+			`spans | where state = "blocked" and duration > 1ms | stats count(), p99(duration) by function | sort count desc`,
+			"function,count,p99_duration\nmain.main,1,3ms\nmain.worker,1,2ms",
+		},
+		{
+			`spans | stats count(*), distinct(state), sum(duration), avg(duration)`,
+			"count,distinct_state,sum_duration,avg_duration\n4,2,6.01ms,1.5025ms",
+		},
+		{
+			`spans | select function, duration * 2 as double | sort double desc | limit 2`,
+			"function,double\nmain.main,6ms\nmain.worker,4ms",
+		},
+		{
+			`spans | where function ~ 'worker$' | select ns(duration) / 1000 as us`,
+			"us\n2000\n10",
+		},
+		{
+			`spans | where duration / 1ms >= 1.5 and not startswith(state, "act")`,
+			"function,state,start,duration\nmain.main,blocked,7,3ms\nmain.worker,blocked,9,2ms",
+		},
+		{
+			`spans | where state = "running" | stats count()`,
+			"count\n0",
+		},
+	}
+	for _, tt := range tests {
+		q, err := Parse(tt.query)
+		if err != nil {
+			t.Errorf("Parse(%q): %s", tt.query, err)
+			continue
+		}
+		res, err := Run(q, testCatalog(), 0)
+		if err != nil {
+			t.Errorf("Run(%q): %s", tt.query, err)
+			continue
+		}
+		if got := formatResult(res); got != tt.want {
+			t.Errorf("%s:\ngot\n%s\nwant\n%s", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []string{
+		`spans | where (`,
+		`spans | where nope = 1`,
+		`spans | where state > 1`,
+		`spans | where duration`,
+		`spans | stats sum(state)`,
+		`spans | select count()`,
+		`spans | limit x`,
+		`goroutines`,
+	}
+	for _, s := range tests {
+		q, err := Parse(s)
+		if err == nil {
+			_, err = Run(q, testCatalog(), 0)
+		}
+		if err == nil {
+			t.Errorf("%s: expected an error", s)
+		}
+	}
+}
+
+func TestTruncated(t *testing.T) {
+	q, err := Parse("spans")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := Run(q, testCatalog(), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Rows) != 3 || !res.Truncated {
+		t.Errorf("got %d rows, truncated = %t, want 3 rows, truncated = true", len(res.Rows), res.Truncated)
+	}
+}
+
+func TestSQL(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{
+			`SELECT function, count(*) AS n, max(duration) FROM spans WHERE state = 'blocked' GROUP BY function ORDER BY n DESC, function`,
+			"function,n,max_duration\nmain.worker,2,2ms\nmain.main,1,3ms",
+		},
+		{
+			`select state, sum(duration) / count(*) as mean from spans group by state having count(*) > 1`,
+			"state,mean\nblocked,1.67ms",
+		},
+		{
+			`SELECT * FROM spans WHERE function LIKE '%.wor_er' AND duration IS NOT NULL ORDER BY start DESC LIMIT 1`,
+			"function,state,start,duration\nmain.worker,blocked,11,10µs",
+		},
+		{
+			`SELECT duration * 2 AS d FROM spans ORDER BY 1 LIMIT 2;`,
+			"d\n20µs\n2ms",
+		},
+		{
+			`SELECT count() FROM spans`,
+			"count\n4",
+		},
+	}
+	for _, tt := range tests {
+		q, err := Parse(tt.query)
+		if err != nil {
+			t.Errorf("Parse(%q): %s", tt.query, err)
+			continue
+		}
+		res, err := Run(q, testCatalog(), 0)
+		if err != nil {
+			t.Errorf("Run(%q): %s", tt.query, err)
+			continue
+		}
+		if got := formatResult(res); got != tt.want {
+			t.Errorf("%s:\ngot\n%s\nwant\n%s", tt.query, got, tt.want)
+		}
+	}
+
+	for _, s := range []string{
+		`SELECT state, count(*) FROM spans`,
+		`SELECT * FROM spans GROUP BY state`,
+		`SELECT state FROM spans WHERE count(*) > 1`,
+		`SELECT sum(count(*)) FROM spans`,
+		`SELECT state spans`,
+	} {
+		q, err := Parse(s)
+		if err == nil {
+			_, err = Run(q, testCatalog(), 0)
+		}
+		if err == nil {
+			t.Errorf("%s: expected an error", s)
+		}
+	}
+}
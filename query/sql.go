@@ -0,0 +1,286 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// sqlKeywords end the expressions of a clause and can't be used as aliases without AS.
+var sqlKeywords = map[string]bool{
+	"select": true, "from": true, "where": true, "group": true, "having": true, "order": true, "limit": true,
+}
+
+// parseSQL parses a query of the form
+//
+//	SELECT fields FROM table [WHERE cond] [GROUP BY exprs] [HAVING cond] [ORDER BY keys] [LIMIT n]
+//
+// and translates it to the equivalent pipeline. The SELECT keyword has already been consumed.
+func (p *parser) parseSQL() (*Query, error) {
+	var fields []NamedExpr
+	star := p.acceptOp("*")
+	if !star {
+		for {
+			e, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			f := NamedExpr{Expr: e, Name: defaultName(e)}
+			if p.acceptKeyword("as") {
+				if f.Name, err = p.ident(); err != nil {
+					return nil, err
+				}
+			} else if t := p.peek(); t.kind == tokIdent && !sqlKeywords[strings.ToLower(t.text)] && !reserved[strings.ToLower(t.text)] {
+				f.Name = p.next().text
+			}
+			fields = append(fields, f)
+			if !p.acceptOp(",") {
+				break
+			}
+		}
+	}
+
+	if t := p.next(); !t.keyword("from") {
+		return nil, p.errorf(t, "expected FROM, found %s", t)
+	}
+	from, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		where, having Expr
+		groupBy       []Expr
+		orderBy       []SortKey
+		limit         = -1
+	)
+	if p.acceptKeyword("where") {
+		if where, err = p.expr(); err != nil {
+			return nil, err
+		}
+	}
+	if p.acceptKeyword("group") {
+		if t := p.next(); !t.keyword("by") {
+			return nil, p.errorf(t, "expected BY, found %s", t)
+		}
+		for {
+			e, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			groupBy = append(groupBy, e)
+			if !p.acceptOp(",") {
+				break
+			}
+		}
+	}
+	if p.acceptKeyword("having") {
+		if having, err = p.expr(); err != nil {
+			return nil, err
+		}
+	}
+	if p.acceptKeyword("order") {
+		if t := p.next(); !t.keyword("by") {
+			return nil, p.errorf(t, "expected BY, found %s", t)
+		}
+		for {
+			e, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			key := SortKey{Expr: e}
+			if p.acceptKeyword("desc") {
+				key.Descending = true
+			} else {
+				p.acceptKeyword("asc")
+			}
+			orderBy = append(orderBy, key)
+			if !p.acceptOp(",") {
+				break
+			}
+		}
+	}
+	if p.acceptKeyword("limit") {
+		n := p.next()
+		if n.kind != tokNumber || n.value.Kind != KindInt || n.value.Int < 0 {
+			return nil, p.errorf(n, "expected a number of rows, found %s", n)
+		}
+		limit = int(n.value.Int)
+	}
+	p.acceptOp(";")
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, p.errorf(t, "expected end of query, found %s", t)
+	}
+
+	return translateSQL(from, fields, star, where, groupBy, having, orderBy, limit)
+}
+
+// translateSQL builds the pipeline of a SQL query. Aggregations are computed by a stats stage whose columns have
+// internal names, which the later stages refer to. The SELECT list becomes the final select stage.
+func translateSQL(from string, fields []NamedExpr, star bool, where Expr, groupBy []Expr, having Expr, orderBy []SortKey, limit int) (*Query, error) {
+	q := &Query{From: from}
+	if where != nil {
+		if hasAggregate(where) {
+			return nil, fmt.Errorf("aggregate functions can't be used in WHERE, use HAVING instead")
+		}
+		q.Stages = append(q.Stages, Where{Cond: where})
+	}
+
+	// Like many databases, we allow GROUP BY and ORDER BY to refer to the aliases of the SELECT list, and ORDER BY to
+	// refer to its fields by position.
+	alias := func(e Expr) Expr {
+		if id, ok := e.(Ident); ok {
+			for _, f := range fields {
+				if strings.EqualFold(f.Name, id.Name) {
+					return f.Expr
+				}
+			}
+		}
+		return e
+	}
+	for i, e := range groupBy {
+		groupBy[i] = alias(e)
+	}
+	for i, k := range orderBy {
+		if lit, ok := k.Expr.(Literal); ok && lit.Value.Kind == KindInt {
+			if lit.Value.Int < 1 || int(lit.Value.Int) > len(fields) {
+				return nil, fmt.Errorf("ORDER BY position %d is out of range", lit.Value.Int)
+			}
+			orderBy[i].Expr = fields[lit.Value.Int-1].Expr
+			continue
+		}
+		orderBy[i].Expr = alias(k.Expr)
+	}
+
+	aggregated := len(groupBy) > 0 || (having != nil && hasAggregate(having))
+	for _, f := range fields {
+		aggregated = aggregated || hasAggregate(f.Expr)
+	}
+	for _, k := range orderBy {
+		aggregated = aggregated || hasAggregate(k.Expr)
+	}
+
+	if aggregated {
+		if star {
+			return nil, fmt.Errorf("SELECT * can't be used with GROUP BY or aggregate functions")
+		}
+		r := &sqlRewriter{}
+		for _, e := range groupBy {
+			r.stats.By = append(r.stats.By, NamedExpr{Expr: e, Name: "group#" + strconv.Itoa(len(r.stats.By))})
+		}
+		var err error
+		for i := range fields {
+			if fields[i].Expr, err = r.rewrite(fields[i].Expr); err != nil {
+				return nil, err
+			}
+		}
+		if having != nil {
+			if having, err = r.rewrite(having); err != nil {
+				return nil, err
+			}
+		}
+		for i := range orderBy {
+			if orderBy[i].Expr, err = r.rewrite(orderBy[i].Expr); err != nil {
+				return nil, err
+			}
+		}
+		q.Stages = append(q.Stages, r.stats)
+		if having != nil {
+			q.Stages = append(q.Stages, Where{Cond: having})
+		}
+	} else if having != nil {
+		q.Stages = append(q.Stages, Where{Cond: having})
+	}
+
+	if len(orderBy) > 0 {
+		q.Stages = append(q.Stages, Sort{Keys: orderBy})
+	}
+	if !star {
+		q.Stages = append(q.Stages, Select{Fields: fields})
+	}
+	if limit >= 0 {
+		q.Stages = append(q.Stages, Limit{N: limit})
+	}
+	return q, nil
+}
+
+func hasAggregate(e Expr) bool {
+	switch e := e.(type) {
+	case Unary:
+		return hasAggregate(e.X)
+	case Binary:
+		return hasAggregate(e.X) || hasAggregate(e.Y)
+	case Call:
+		if _, ok := aggregates[e.Func]; ok {
+			return true
+		}
+		for _, arg := range e.Args {
+			if hasAggregate(arg) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sqlRewriter collects the aggregates and groups of a SQL query into a stats stage, and rewrites expressions to refer
+// to its columns.
+type sqlRewriter struct {
+	stats Stats
+}
+
+func (r *sqlRewriter) rewrite(e Expr) (Expr, error) {
+	for _, g := range r.stats.By {
+		if g.Expr.String() == e.String() {
+			return Ident{Name: g.Name}, nil
+		}
+	}
+	switch e := e.(type) {
+	case Literal:
+		return e, nil
+	case Ident:
+		return nil, fmt.Errorf("column %s must appear in GROUP BY or be used in an aggregate function", e.Name)
+	case Unary:
+		x, err := r.rewrite(e.X)
+		if err != nil {
+			return nil, err
+		}
+		return Unary{Op: e.Op, X: x}, nil
+	case Binary:
+		x, err := r.rewrite(e.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := r.rewrite(e.Y)
+		if err != nil {
+			return nil, err
+		}
+		return Binary{Op: e.Op, X: x, Y: y}, nil
+	case Call:
+		if _, ok := aggregates[e.Func]; ok {
+			for _, arg := range e.Args {
+				if hasAggregate(arg) {
+					return nil, fmt.Errorf("aggregate functions can't be nested in %s", e)
+				}
+			}
+			for _, a := range r.stats.Aggregates {
+				if a.Expr.String() == e.String() {
+					return Ident{Name: a.Name}, nil
+				}
+			}
+			name := "aggregate#" + strconv.Itoa(len(r.stats.Aggregates))
+			r.stats.Aggregates = append(r.stats.Aggregates, NamedExpr{Expr: e, Name: name})
+			return Ident{Name: name}, nil
+		}
+		args := make([]Expr, len(e.Args))
+		for i, arg := range e.Args {
+			var err error
+			if args[i], err = r.rewrite(arg); err != nil {
+				return nil, err
+			}
+		}
+		return Call{Func: e.Func, Args: args}, nil
+	default:
+		panic(fmt.Sprintf("unhandled expression %T", e))
+	}
+}
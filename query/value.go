@@ -0,0 +1,215 @@
+// Package query implements a small language for filtering, grouping and aggregating tables, such as the spans and
+// goroutines of a trace.
+//
+// A query names a table and transforms its rows with a pipeline of stages:
+//
+//	spans | where state = "blocked (pollable I/O)" and duration > 1ms | stats count(), p99(duration) by function | sort count desc | limit 10
+//
+// The stages are:
+//
+//   - where EXPR keeps the rows for which EXPR is true.
+//   - select EXPR [as NAME], ... computes new columns.
+//   - stats AGG [as NAME], ... [by EXPR [as NAME], ...] aggregates rows, optionally grouped by the values of
+//     expressions. The aggregate functions are count, sum, avg, min, max, p50, p90, p99 and distinct.
+//   - sort EXPR [asc|desc], ... sorts rows.
+//   - limit N keeps the first N rows.
+//
+// Queries can also be written in SQL, which is translated to the equivalent pipeline:
+//
+//	SELECT function, count(*) AS n, p99(duration) FROM spans WHERE duration > 1ms GROUP BY function HAVING n > 10 ORDER BY n DESC LIMIT 10
+//
+// Expressions support the comparison operators =, !=, <, <=, > and >=, regular expression matching with ~ and !~, SQL's
+// LIKE, IS NULL and IS NOT NULL, the boolean operators and, or and not, and arithmetic with +, -, * and /. Literals are
+// numbers, strings in double or single quotes, durations such as 1.5ms, true, false and null.
+package query
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kind is the type of a value.
+type Kind uint8
+
+const (
+	KindNull Kind = iota
+	KindBool
+	KindInt
+	KindFloat
+	KindString
+	KindDuration
+	// KindTime is a timestamp in nanoseconds.
+	KindTime
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindNull:
+		return "null"
+	case KindBool:
+		return "bool"
+	case KindInt:
+		return "int"
+	case KindFloat:
+		return "float"
+	case KindString:
+		return "string"
+	case KindDuration:
+		return "duration"
+	case KindTime:
+		return "time"
+	default:
+		return fmt.Sprintf("Kind(%d)", k)
+	}
+}
+
+// numeric reports whether values of the kind are numbers and can be compared with each other.
+func (k Kind) numeric() bool {
+	switch k {
+	case KindInt, KindFloat, KindDuration, KindTime:
+		return true
+	default:
+		return false
+	}
+}
+
+// A Value is a single value of a row.
+type Value struct {
+	Kind Kind
+	// Int holds booleans, integers, durations and timestamps.
+	Int   int64
+	Float float64
+	Str   string
+}
+
+func Null() Value                       { return Value{} }
+func Int(v int64) Value                 { return Value{Kind: KindInt, Int: v} }
+func Float(v float64) Value             { return Value{Kind: KindFloat, Float: v} }
+func String(v string) Value             { return Value{Kind: KindString, Str: v} }
+func Duration(v time.Duration) Value    { return Value{Kind: KindDuration, Int: int64(v)} }
+func Time(v int64) Value                { return Value{Kind: KindTime, Int: v} }
+func Bool(v bool) Value                 { return Value{Kind: KindBool, Int: b2i(v)} }
+func (v Value) IsNull() bool            { return v.Kind == KindNull }
+func (v Value) Duration() time.Duration { return time.Duration(v.Int) }
+
+func b2i(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// float returns a numeric value as a float64.
+func (v Value) float() float64 {
+	if v.Kind == KindFloat {
+		return v.Float
+	}
+	return float64(v.Int)
+}
+
+// truthy reports whether v is the boolean true.
+func (v Value) truthy() bool {
+	return v.Kind == KindBool && v.Int != 0
+}
+
+// String formats the value. Durations use the notation of time.Duration and timestamps are in nanoseconds.
+func (v Value) String() string {
+	switch v.Kind {
+	case KindNull:
+		return ""
+	case KindBool:
+		return strconv.FormatBool(v.Int != 0)
+	case KindInt, KindTime:
+		return strconv.FormatInt(v.Int, 10)
+	case KindFloat:
+		return strconv.FormatFloat(v.Float, 'g', -1, 64)
+	case KindString:
+		return v.Str
+	case KindDuration:
+		return time.Duration(v.Int).String()
+	default:
+		panic(fmt.Sprintf("unhandled kind %s", v.Kind))
+	}
+}
+
+// Compare orders values. Nulls sort before all other values, numbers are compared by value regardless of their
+// kind, and strings are compared lexically. Values of other, differing kinds are ordered by their kind.
+func Compare(a, b Value) int {
+	switch {
+	case a.Kind == KindNull || b.Kind == KindNull:
+		return cmpInt(b2i(a.Kind != KindNull), b2i(b.Kind != KindNull))
+	case a.Kind.numeric() && b.Kind.numeric():
+		if a.Kind != KindFloat && b.Kind != KindFloat {
+			return cmpInt(a.Int, b.Int)
+		}
+		af, bf := a.float(), b.float()
+		switch {
+		case af < bf:
+			return -1
+		case af > bf:
+			return 1
+		case math.IsNaN(af) || math.IsNaN(bf):
+			return cmpInt(b2i(!math.IsNaN(af)), b2i(!math.IsNaN(bf)))
+		default:
+			return 0
+		}
+	case a.Kind != b.Kind:
+		return cmpInt(int64(a.Kind), int64(b.Kind))
+	case a.Kind == KindString:
+		return strings.Compare(a.Str, b.Str)
+	default:
+		return cmpInt(a.Int, b.Int)
+	}
+}
+
+func cmpInt(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// A Column describes a column of a table.
+type Column struct {
+	Name string
+	Kind Kind
+}
+
+// A Source is a table that can be queried.
+type Source interface {
+	Columns() []Column
+	// Scan calls yield for each row, until yield returns false. Rows have one value per column. They may be reused
+	// once yield returns.
+	Scan(yield func(row []Value) bool)
+}
+
+type funcSource struct {
+	columns []Column
+	scan    func(yield func(row []Value) bool)
+}
+
+func (s funcSource) Columns() []Column                 { return s.columns }
+func (s funcSource) Scan(yield func(row []Value) bool) { s.scan(yield) }
+
+// NewSource returns a table whose rows are produced by scan.
+func NewSource(columns []Column, scan func(yield func(row []Value) bool)) Source {
+	return funcSource{columns, scan}
+}
+
+// A Catalog maps table names to tables.
+type Catalog map[string]Source
+
+// Result is the result of a query.
+type Result struct {
+	Columns []Column
+	Rows    [][]Value
+	// Truncated is set if the query produced more rows than were requested.
+	Truncated bool
+}
@@ -0,0 +1,69 @@
+package theme
+
+import (
+	"context"
+	rtrace "runtime/trace"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/mem"
+
+	"gioui.org/op"
+	"gioui.org/unit"
+)
+
+// Cache records the ops of a widget and replays them in later frames instead of laying out the widget again. This
+// limits the cost of frames caused by input that only affects a small part of the window, such as hovering a
+// different widget.
+//
+// The cache is invalidated automatically when the constraints or the metric change, and manually by calling
+// Invalidate. Cached widgets don't get to see input events, so a widget's input has to be processed before calling
+// Layout, invalidating the cache if the input affects the widget's appearance. Input ops recorded by the widget
+// remain in effect.
+type Cache struct {
+	ops         mem.ReusableOps
+	call        op.CallOp
+	dims        layout.Dimensions
+	constraints layout.Constraints
+	metric      unit.Metric
+	valid       bool
+}
+
+// Invalidate causes the next call to Layout to lay out the widget again.
+func (c *Cache) Invalidate() {
+	c.valid = false
+}
+
+// Size returns the memory used by the cache's op buffer.
+func (c *Cache) Size() int {
+	return c.ops.Size()
+}
+
+// Release frees the cache's op buffer and invalidates the cache.
+func (c *Cache) Release() {
+	c.ops.Release()
+	c.valid = false
+}
+
+func (c *Cache) Layout(win *Window, gtx layout.Context, w Widget) layout.Dimensions {
+	if c.valid && gtx.Constraints == c.constraints && gtx.Metric == c.metric {
+		win.HUD.RecordCacheLookup(true)
+		c.call.Add(gtx.Ops)
+		return c.dims
+	}
+	win.HUD.RecordCacheLookup(false)
+
+	defer rtrace.StartRegion(context.Background(), "theme.Cache.Layout").End()
+
+	origOps := gtx.Ops
+	gtx.Ops = c.ops.Get()
+	m := op.Record(gtx.Ops)
+	c.dims = w(win, gtx)
+	c.call = m.Stop()
+	c.call.Add(origOps)
+
+	c.constraints = gtx.Constraints
+	c.metric = gtx.Metric
+	c.valid = true
+
+	return c.dims
+}
@@ -0,0 +1,90 @@
+package theme
+
+import (
+	"context"
+	"image"
+	rtrace "runtime/trace"
+
+	"honnef.co/go/gotraceui/color"
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/font"
+	"gioui.org/io/pointer"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/unit"
+)
+
+// ChipStyle displays a short label in a rounded box, such as an active filter. Chips can optionally have a button for
+// removing them.
+type ChipStyle struct {
+	Label string
+	// Close is the clickable of the close button. Chips without a clickable don't have a close button.
+	Close *widget.PrimaryClickable
+
+	TextSize     unit.Sp
+	Foreground   color.Oklch
+	Background   color.Oklch
+	Border       color.Oklch
+	CloseHovered color.Oklch
+}
+
+func Chip(th *Theme, label string, close *widget.PrimaryClickable) ChipStyle {
+	return ChipStyle{
+		Label:        label,
+		Close:        close,
+		TextSize:     th.TextSize,
+		Foreground:   th.Palette.Foreground,
+		Background:   oklch(93.88, 0.033, 248.1),
+		Border:       oklch(71.79, 0.051, 248.1),
+		CloseHovered: th.Palette.NavigationLink,
+	}
+}
+
+func (cs ChipStyle) Layout(win *Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "theme.ChipStyle.Layout").End()
+
+	gtx.Constraints.Min = image.Point{}
+	label := func(gtx layout.Context, s string, c color.Oklch) layout.Dimensions {
+		return widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, font.Font{}, cs.TextSize, s, win.ColorMaterial(gtx, c))
+	}
+
+	m := op.Record(gtx.Ops)
+	dims := layout.Inset{Top: 2, Bottom: 2, Left: 6, Right: 6}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		return layout.Rigids(gtx, layout.Horizontal,
+			func(gtx layout.Context) layout.Dimensions {
+				return label(gtx, cs.Label, cs.Foreground)
+			},
+			func(gtx layout.Context) layout.Dimensions {
+				if cs.Close == nil {
+					return layout.Dimensions{}
+				}
+				return layout.Rigids(gtx, layout.Horizontal,
+					layout.Spacer{Width: 4}.Layout,
+					func(gtx layout.Context) layout.Dimensions {
+						return cs.Close.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+							pointer.CursorPointer.Add(gtx.Ops)
+							c := cs.Foreground
+							if cs.Close.Hovered() {
+								c = cs.CloseHovered
+							}
+							return label(gtx, "×", c)
+						})
+					},
+				)
+			},
+		)
+	})
+	call := m.Stop()
+
+	// Fully round the chip's ends.
+	r := dims.Size.Y / 2
+	rect := image.Rectangle{Max: dims.Size}
+	FillShape(win, gtx.Ops, cs.Border, clip.UniformRRect(rect, r).Op(gtx.Ops))
+	border := gtx.Dp(1)
+	FillShape(win, gtx.Ops, cs.Background, clip.UniformRRect(rect.Inset(border), r-border).Op(gtx.Ops))
+	call.Add(gtx.Ops)
+
+	return dims
+}
@@ -156,7 +156,7 @@ func (cmd NormalCommand) Layout(win *Window, gtx layout.Context, current bool) l
 							}
 							f := font.Font{Style: font.Italic}
 							// XXX avoid the allocation
-							return widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, f, 12, "Category: "+cmd.Category, win.ColorMaterial(gtx, win.Theme.Palette.Foreground))
+							return widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, f, win.Theme.TextSize, "Category: "+cmd.Category, win.ColorMaterial(gtx, win.Theme.Palette.Foreground))
 						},
 					)
 
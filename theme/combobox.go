@@ -0,0 +1,151 @@
+package theme
+
+import (
+	"context"
+	"image"
+	rtrace "runtime/trace"
+	"strings"
+
+	"honnef.co/go/gotraceui/color"
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/unit"
+)
+
+// ComboBoxState is the state of a ComboBoxStyle.
+type ComboBoxState struct {
+	// BuildFilter builds the filter used for typing to filter options. If nil, options are filtered by
+	// case-insensitive substring matches on their labels and filter labels.
+	BuildFilter func(string) Filter
+
+	items    []ListWindowItem
+	selected int
+	changed  bool
+
+	click widget.PrimaryClickable
+}
+
+// SetItems sets the available options. The selection is reset.
+func (cb *ComboBoxState) SetItems(items []ListWindowItem) {
+	cb.items = items
+	cb.selected = -1
+}
+
+func (cb *ComboBoxState) Items() []ListWindowItem { return cb.items }
+
+// Selected returns the selected option, if any.
+func (cb *ComboBoxState) Selected() (ListWindowItem, bool) {
+	if cb.selected < 0 || cb.selected >= len(cb.items) {
+		return ListWindowItem{}, false
+	}
+	return cb.items[cb.selected], true
+}
+
+// SetSelected selects the option at index idx, or no option if idx is -1.
+func (cb *ComboBoxState) SetSelected(idx int) {
+	cb.selected = idx
+}
+
+// Changed reports whether the user selected an option since the last call to Changed.
+func (cb *ComboBoxState) Changed() bool {
+	changed := cb.changed
+	cb.changed = false
+	return changed
+}
+
+type substringFilter string
+
+func (f substringFilter) Filter(item ListWindowItem) bool {
+	if strings.Contains(strings.ToLower(item.Label), string(f)) {
+		return true
+	}
+	for _, l := range item.FilterLabels {
+		if strings.Contains(strings.ToLower(l), string(f)) {
+			return true
+		}
+	}
+	return false
+}
+
+// indexFilter applies a filter to the original items, given items whose values are indices.
+type indexFilter struct {
+	f     Filter
+	items []ListWindowItem
+}
+
+func (f indexFilter) Filter(item ListWindowItem) bool {
+	return f.f.Filter(f.items[item.Item.(int)])
+}
+
+func (cb *ComboBoxState) open(win *Window, width, height unit.Dp, bg, border color.Oklch) {
+	// ListWindow reports the Item of the confirmed option, so we replace the items' values with their indices.
+	list := NewListWindow(win.Theme)
+	if cb.BuildFilter != nil {
+		list.BuildFilter = func(s string) Filter {
+			return indexFilter{cb.BuildFilter(s), cb.items}
+		}
+	} else {
+		list.BuildFilter = func(s string) Filter { return substringFilter(strings.ToLower(s)) }
+	}
+	items := make([]ListWindowItem, len(cb.items))
+	for i, item := range cb.items {
+		items[i] = ListWindowItem{Item: i, Label: item.Label, FilterLabels: item.FilterLabels}
+	}
+	list.SetItems(items)
+
+	win.SetPopup(func(win *Window, gtx layout.Context) layout.Dimensions {
+		gtx.Constraints.Min = gtx.Constraints.Constrain(image.Pt(gtx.Dp(width), gtx.Dp(height)))
+		gtx.Constraints.Max = gtx.Constraints.Min
+		dims := Background{Color: bg}.Layout(win, gtx, func(win *Window, gtx layout.Context) layout.Dimensions {
+			return Bordered{Color: border, Width: 1}.Layout(win, gtx, list.Layout)
+		})
+		if idx, ok := list.Confirmed(); ok {
+			cb.selected = idx.(int)
+			cb.changed = true
+			win.CloseModal()
+		} else if list.Cancelled() {
+			win.CloseModal()
+		}
+		return dims
+	})
+}
+
+// ComboBoxStyle displays the selected option of a ComboBoxState. Clicking it opens a popup that lists all options,
+// which can be filtered by typing and selected with the keyboard or the mouse.
+type ComboBoxStyle struct {
+	State *ComboBoxState
+	// Hint is displayed when no option is selected.
+	Hint string
+
+	PopupWidth      unit.Dp
+	PopupHeight     unit.Dp
+	PopupBackground color.Oklch
+	PopupBorder     color.Oklch
+}
+
+func ComboBox(th *Theme, state *ComboBoxState, hint string) ComboBoxStyle {
+	return ComboBoxStyle{
+		State:           state,
+		Hint:            hint,
+		PopupWidth:      400,
+		PopupHeight:     300,
+		PopupBackground: th.Palette.Background,
+		PopupBorder:     th.Palette.Border,
+	}
+}
+
+func (cbs ComboBoxStyle) Layout(win *Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "theme.ComboBoxStyle.Layout").End()
+
+	cb := cbs.State
+	for cb.click.Clicked(gtx) {
+		cb.open(win, cbs.PopupWidth, cbs.PopupHeight, cbs.PopupBackground, cbs.PopupBorder)
+	}
+
+	label := cbs.Hint
+	if item, ok := cb.Selected(); ok {
+		label = item.Label
+	}
+	return Button(win.Theme, &cb.click.Clickable, label+" ▼").Layout(win, gtx)
+}
@@ -0,0 +1,103 @@
+package theme
+
+import (
+	"context"
+	"fmt"
+	rtrace "runtime/trace"
+
+	"honnef.co/go/gotraceui/layout"
+)
+
+// DockSide is the side of the main area that a docked area is attached to.
+type DockSide uint8
+
+const (
+	DockRight DockSide = iota
+	DockBottom
+	DockLeft
+	DockTop
+)
+
+func (side DockSide) String() string {
+	switch side {
+	case DockRight:
+		return "right"
+	case DockBottom:
+		return "bottom"
+	case DockLeft:
+		return "left"
+	case DockTop:
+		return "top"
+	default:
+		return fmt.Sprintf("DockSide(%d)", side)
+	}
+}
+
+// ParseDockSide is the inverse of DockSide.String.
+func ParseDockSide(s string) (DockSide, bool) {
+	for side := DockRight; side <= DockTop; side++ {
+		if side.String() == s {
+			return side, true
+		}
+	}
+	return 0, false
+}
+
+// DockState is the state of a docked area.
+type DockState struct {
+	Side DockSide
+	// Ratio is the fraction of the available space occupied by the main area.
+	Ratio float32
+	// Hidden hides the docked area, giving all space to the main area.
+	Hidden bool
+
+	split SplitterState
+}
+
+type DockStyle struct {
+	State *DockState
+	Theme *Theme
+}
+
+func Dock(th *Theme, state *DockState) DockStyle {
+	return DockStyle{State: state, Theme: th}
+}
+
+// Layout lays out the main area and the docked area, separated by a handle that can be dragged to resize the areas.
+func (ds DockStyle) Layout(win *Window, gtx layout.Context, main, docked Widget) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "theme.DockStyle.Layout").End()
+
+	st := ds.State
+	if st.Hidden {
+		return main(win, gtx)
+	}
+
+	if st.Ratio <= 0 || st.Ratio >= 1 {
+		st.Ratio = 0.7
+	}
+
+	first, second := main, docked
+	st.split.Ratio = st.Ratio
+	switch st.Side {
+	case DockRight, DockBottom:
+	case DockLeft, DockTop:
+		first, second = docked, main
+		st.split.Ratio = 1 - st.Ratio
+	}
+	switch st.Side {
+	case DockRight, DockLeft:
+		st.split.Axis = layout.Horizontal
+	case DockBottom, DockTop:
+		st.split.Axis = layout.Vertical
+	}
+
+	dims := Splitter(ds.Theme, &st.split).Layout(win, gtx, first, second)
+
+	switch st.Side {
+	case DockRight, DockBottom:
+		st.Ratio = st.split.Ratio
+	case DockLeft, DockTop:
+		st.Ratio = 1 - st.split.Ratio
+	}
+	return dims
+}
@@ -0,0 +1,157 @@
+package theme
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"math"
+	rtrace "runtime/trace"
+
+	"honnef.co/go/gotraceui/color"
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/f32"
+	"gioui.org/font"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/unit"
+)
+
+type DonutSlice struct {
+	Label string
+	Value float64
+	// Color is the slice's color. If its alpha is zero, a color from DonutStyle.Colors is used.
+	Color color.Oklch
+}
+
+// DonutStyle displays the shares of a whole, such as the states of goroutines at a point in time, as a donut chart
+// with a legend. A HoleRatio of zero turns it into a pie chart.
+type DonutStyle struct {
+	Slices []DonutSlice
+	// HoleRatio is the radius of the hole, relative to the chart's radius.
+	HoleRatio float32
+	// Center is optional text displayed in the hole, such as the total.
+	Center string
+	// Legend controls whether a legend listing the slices and their percentages is displayed next to the chart.
+	Legend bool
+
+	Size       unit.Dp
+	Colors     []color.Oklch
+	EmptyColor color.Oklch
+	TextColor  color.Oklch
+	TextSize   unit.Sp
+}
+
+func Donut(th *Theme, slices []DonutSlice) DonutStyle {
+	return DonutStyle{
+		Slices:    slices,
+		HoleRatio: 0.6,
+		Legend:    true,
+		Size:      100,
+		Colors: []color.Oklch{
+			oklch(54.01, 0.139, 248.98),
+			oklch(63.31, 0.191, 36.99),
+			oklch(62.8, 0.176, 142.5),
+			oklch(52.31, 0.214, 313.78),
+			oklch(70.48, 0.145, 80.68),
+		},
+		EmptyColor: oklch(92, 0, 0),
+		TextColor:  th.Palette.Foreground,
+		TextSize:   th.TextSize,
+	}
+}
+
+func (ds DonutStyle) color(i int) color.Oklch {
+	if c := ds.Slices[i].Color; c.A != 0 {
+		return c
+	}
+	return ds.Colors[i%len(ds.Colors)]
+}
+
+func (ds DonutStyle) Layout(win *Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "theme.DonutStyle.Layout").End()
+
+	var total float64
+	for _, s := range ds.Slices {
+		total += max(s.Value, 0)
+	}
+
+	label := func(gtx layout.Context, s string, f font.Font) layout.Dimensions {
+		gtx.Constraints.Min = image.Point{}
+		return widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, f, ds.TextSize, s, win.ColorMaterial(gtx, ds.TextColor))
+	}
+
+	chart := func(gtx layout.Context) layout.Dimensions {
+		size := min(gtx.Dp(ds.Size), gtx.Constraints.Max.X, gtx.Constraints.Max.Y)
+		outer := float32(size) / 2
+		inner := outer * min(max(ds.HoleRatio, 0), 1)
+		c := f32.Pt(outer, outer)
+
+		fill := func(a0, a1 float64, col color.Oklch) {
+			var p clip.Path
+			p.Begin(gtx.Ops)
+			annulusSector(&p, c, inner, outer, a0, a1)
+			FillShape(win, gtx.Ops, col, clip.Outline{Path: p.End()}.Op())
+		}
+
+		if total <= 0 {
+			fill(0, 2*math.Pi, ds.EmptyColor)
+		} else {
+			var a float64
+			for i, s := range ds.Slices {
+				if s.Value <= 0 {
+					continue
+				}
+				da := 2 * math.Pi * s.Value / total
+				fill(a, a+da, ds.color(i))
+				a += da
+			}
+		}
+
+		if ds.Center != "" && inner > 0 {
+			m := op.Record(gtx.Ops)
+			dims := label(gtx, ds.Center, font.Font{Weight: font.Bold})
+			call := m.Stop()
+			defer op.Offset(image.Pt((size-dims.Size.X)/2, (size-dims.Size.Y)/2)).Push(gtx.Ops).Pop()
+			call.Add(gtx.Ops)
+		}
+
+		return layout.Dimensions{Size: image.Pt(size, size)}
+	}
+
+	if !ds.Legend {
+		return chart(gtx)
+	}
+
+	legend := func(gtx layout.Context) layout.Dimensions {
+		lineHeight := win.TextDimensions(gtx, widget.Label{MaxLines: 1}, font.Font{}, ds.TextSize, "0").Size.Y
+		swatch := lineHeight * 2 / 3
+		padding := gtx.Dp(4)
+		var width, y int
+		for i, s := range ds.Slices {
+			func() {
+				defer op.Offset(image.Pt(0, y)).Push(gtx.Ops).Pop()
+				func() {
+					defer op.Offset(image.Pt(0, (lineHeight-swatch)/2)).Push(gtx.Ops).Pop()
+					FillShape(win, gtx.Ops, ds.color(i), clip.Rect{Max: image.Pt(swatch, swatch)}.Op())
+				}()
+				var pct float64
+				if total > 0 {
+					pct = max(s.Value, 0) / total * 100
+				}
+				defer op.Offset(image.Pt(swatch+padding, 0)).Push(gtx.Ops).Pop()
+				dims := label(gtx, fmt.Sprintf("%s: %.1f%%", s.Label, pct), font.Font{})
+				width = max(width, swatch+padding+dims.Size.X)
+			}()
+			y += lineHeight
+		}
+		return layout.Dimensions{Size: image.Pt(width, y)}
+	}
+
+	return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+		layout.Rigid(chart),
+		layout.Rigid(layout.Spacer{Width: 10}.Layout),
+		layout.Rigid(legend),
+	)
+}
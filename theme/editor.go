@@ -106,9 +106,11 @@ func (tb TextBoxStyle) Layout(win *Window, gtx layout.Context) layout.Dimensions
 		tb.Color = oklch(62.8, 0.258, 29.234)
 	}
 	return Background{Color: oklch(100, 0, 0)}.Layout(win, gtx, func(win *Window, gtx layout.Context) layout.Dimensions {
-		return Bordered{Color: oklch(0, 0, 0), Width: 1}.Layout(win, gtx, func(win *Window, gtx layout.Context) layout.Dimensions {
-			return layout.UniformInset(2).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
-				return tb.EditorStyle.Layout(win, gtx)
+		return FocusRing{Focused: tb.Editor.Focused()}.Layout(win, gtx, func(win *Window, gtx layout.Context) layout.Dimensions {
+			return Bordered{Color: oklch(0, 0, 0), Width: 1}.Layout(win, gtx, func(win *Window, gtx layout.Context) layout.Dimensions {
+				return layout.UniformInset(2).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+					return tb.EditorStyle.Layout(win, gtx)
+				})
 			})
 		})
 	})
@@ -148,7 +148,7 @@ func (fg FlameGraphStyle) Layout(win *Window, gtx layout.Context) (dims layout.D
 		radius      = float32(gtx.Dp(radiusDp))
 		spanPadding = float32(gtx.Dp(spanPaddingDp))
 
-		height      = win.TextDimensions(gtx, widget.Label{}, font.Font{}, 12, "").Size.Y + 2*int(spanPadding)
+		height      = win.TextDimensions(gtx, widget.Label{}, font.Font{}, win.Theme.TextSize, "").Size.Y + 2*int(spanPadding)
 		levelHeight = float32(height + gtx.Dp(rowSpacingDp))
 	)
 
@@ -285,7 +285,7 @@ func (fg FlameGraphStyle) Layout(win *Window, gtx layout.Context) (dims layout.D
 
 		// Only display labels in spans that can fit more than just the ellipsis and 1-2 characters. This assumes that there
 		// are no labels that are shorter than that, which will be true for all real Go programs.
-		pxMinLabelWidth := float32(win.TextDimensions(gtx, widget.Label{}, font.Font{}, 12, " … ").Size.X) + 2*spanPadding
+		pxMinLabelWidth := float32(win.TextDimensions(gtx, widget.Label{}, font.Font{}, win.Theme.TextSize, " … ").Size.X) + 2*spanPadding
 
 		fg.StyleState.hover.Add(gtx.Ops)
 		fg.StyleState.click.Add(gtx.Ops)
@@ -395,10 +395,10 @@ func (fg FlameGraphStyle) Layout(win *Window, gtx layout.Context) (dims layout.D
 						}
 						m := op.Record(gtx.Ops)
 						l := frame.Name
-						if float32(win.TextLength(gtx, widget.Label{}, f, 12, l)) > pxSize.X {
+						if float32(win.TextLength(gtx, widget.Label{}, f, win.Theme.TextSize, l)) > pxSize.X {
 							l = shortenFunctionName(frame.Name)
 						}
-						_, tinf := widget.Label{MaxLines: 1, Alignment: text.Middle}.LayoutDetailed(gtx, win.Theme.Shaper, f, 12, l, win.ColorMaterial(gtx, oklch(0, 0, 0)))
+						_, tinf := widget.Label{MaxLines: 1, Alignment: text.Middle}.LayoutDetailed(gtx, win.Theme.Shaper, f, win.Theme.TextSize, l, win.ColorMaterial(gtx, oklch(0, 0, 0)))
 						c := m.Stop()
 						// Don't display a label if it's just a period followed by an ellipsis
 						if tinf.Truncated == 0 || utf8.RuneCountInString(l)-tinf.Truncated != 1 || l[0] != '.' {
@@ -0,0 +1,119 @@
+package theme
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"math"
+	rtrace "runtime/trace"
+
+	"honnef.co/go/gotraceui/color"
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/f32"
+	"gioui.org/font"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/unit"
+)
+
+// annulusSector adds a sector of an annulus to p, centered on c, spanning the angles [a0, a1]. Angles are in radians,
+// measured clockwise from 12 o'clock. An inner radius of zero produces a pie slice.
+func annulusSector(p *clip.Path, c f32.Point, inner, outer float32, a0, a1 float64) {
+	// Approximate the arcs with line segments, at most 2° apart.
+	n := max(1, int(math.Ceil((a1-a0)/(2*math.Pi/180))))
+	pt := func(r float32, a float64) f32.Point {
+		return f32.Pt(c.X+r*float32(math.Sin(a)), c.Y-r*float32(math.Cos(a)))
+	}
+
+	p.MoveTo(pt(outer, a0))
+	for i := 1; i <= n; i++ {
+		p.LineTo(pt(outer, a0+(a1-a0)*float64(i)/float64(n)))
+	}
+	if inner <= 0 {
+		p.LineTo(c)
+	} else {
+		for i := n; i >= 0; i-- {
+			p.LineTo(pt(inner, a0+(a1-a0)*float64(i)/float64(n)))
+		}
+	}
+	p.Close()
+}
+
+// GaugeStyle displays a fraction, such as the share of time spent in GC, as a semicircular gauge with the percentage
+// and an optional label below it.
+type GaugeStyle struct {
+	// Value is the displayed fraction, in the range [0, 1].
+	Value float64
+	Label string
+
+	Size       unit.Dp
+	Thickness  unit.Dp
+	Color      color.Oklch
+	TrackColor color.Oklch
+	TextColor  color.Oklch
+	TextSize   unit.Sp
+}
+
+func Gauge(th *Theme, value float64, label string) GaugeStyle {
+	return GaugeStyle{
+		Value:      value,
+		Label:      label,
+		Size:       100,
+		Thickness:  12,
+		Color:      oklch(54.01, 0.139, 248.98),
+		TrackColor: oklch(92, 0, 0),
+		TextColor:  th.Palette.Foreground,
+		TextSize:   th.TextSize,
+	}
+}
+
+func (gs GaugeStyle) Layout(win *Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "theme.GaugeStyle.Layout").End()
+
+	width := min(gtx.Dp(gs.Size), gtx.Constraints.Max.X)
+	outer := float32(width) / 2
+	inner := max(0, outer-float32(gtx.Dp(gs.Thickness)))
+	c := f32.Pt(outer, outer)
+	value := min(max(gs.Value, 0), 1)
+	if math.IsNaN(value) {
+		value = 0
+	}
+
+	draw := func(a0, a1 float64, col color.Oklch) {
+		if a1 <= a0 {
+			return
+		}
+		var p clip.Path
+		p.Begin(gtx.Ops)
+		annulusSector(&p, c, inner, outer, a0, a1)
+		FillShape(win, gtx.Ops, col, clip.Outline{Path: p.End()}.Op())
+	}
+	// The gauge spans from 9 o'clock to 3 o'clock.
+	start, end := -math.Pi/2, math.Pi/2
+	mid := start + (end-start)*value
+	draw(start, mid, gs.Color)
+	draw(mid, end, gs.TrackColor)
+
+	label := func(gtx layout.Context, s string, f font.Font, y int) int {
+		gtx.Constraints.Min = image.Point{}
+		m := op.Record(gtx.Ops)
+		dims := widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, f, gs.TextSize, s, win.ColorMaterial(gtx, gs.TextColor))
+		call := m.Stop()
+		defer op.Offset(image.Pt((width-dims.Size.X)/2, y)).Push(gtx.Ops).Pop()
+		call.Add(gtx.Ops)
+		return dims.Size.Y
+	}
+
+	// The percentage sits inside the gauge, resting on its baseline.
+	lineHeight := win.TextDimensions(gtx, widget.Label{MaxLines: 1}, font.Font{Weight: font.Bold}, gs.TextSize, "0").Size.Y
+	height := int(math.Ceil(float64(outer)))
+	label(gtx, fmt.Sprintf("%.1f%%", value*100), font.Font{Weight: font.Bold}, height-lineHeight)
+	if gs.Label != "" {
+		height += gtx.Dp(2)
+		height += label(gtx, gs.Label, font.Font{}, height)
+	}
+
+	return layout.Dimensions{Size: image.Pt(width, height)}
+}
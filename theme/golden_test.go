@@ -0,0 +1,80 @@
+package theme_test
+
+import (
+	"image"
+	"os"
+	"runtime"
+	"testing"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+	"honnef.co/go/gotraceui/theme/themetest"
+	"honnef.co/go/gotraceui/widget"
+)
+
+func TestMain(m *testing.M) {
+	if runtime.GOOS == "linux" && os.Getenv("EGL_PLATFORM") == "" && os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == "" {
+		// Without a display, EGL fails to initialize on the default platform. Mesa's surfaceless platform doesn't need
+		// a display and falls back to software rendering if there is no GPU.
+		os.Setenv("EGL_PLATFORM", "surfaceless")
+	}
+	os.Exit(m.Run())
+}
+
+func TestGoldenStatusBar(t *testing.T) {
+	segments := []theme.StatusSegment{
+		{Text: "G 42"},
+		{Text: "Running"},
+		{Text: "1.5ms", Right: true},
+		{Text: "Zoom 100%", Right: true},
+	}
+	themetest.Golden(t, "statusbar", image.Pt(400, 30), func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+		gtx.Constraints.Min.Y = 0
+		return theme.StatusBar(win.Theme, segments).Layout(win, gtx)
+	})
+}
+
+func TestGoldenTreeView(t *testing.T) {
+	children := map[string][]string{
+		"":             {"runtime", "main"},
+		"runtime":      {"runtime.gcBgMarkWorker", "runtime.main"},
+		"main":         {"main.main", "main.worker"},
+		"runtime.main": {"main.main"},
+	}
+	tv := &widget.TreeView[string]{
+		Roots:       func() []string { return children[""] },
+		Children:    func(node string) []string { return children[node] },
+		HasChildren: func(node string) bool { return len(children[node]) != 0 },
+	}
+	tv.SetExpanded("runtime", true)
+	tv.Select("runtime.main")
+
+	themetest.Golden(t, "treeview", image.Pt(300, 150), func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+		return theme.TreeView(win.Theme, tv, func(win *theme.Window, gtx layout.Context, row widget.TreeRow[string]) layout.Dimensions {
+			return theme.LineLabel(win.Theme, row.Node).Layout(win, gtx)
+		}).Layout(win, gtx)
+	})
+}
+
+func TestGoldenLineChart(t *testing.T) {
+	var state theme.LineChartState
+	state.SetSeries([]widget.LineChartSeries{
+		{
+			Name:   "Goroutines",
+			Points: []widget.LineChartPoint{{X: 0, Y: 1}, {X: 10, Y: 4}, {X: 20, Y: 9}, {X: 30, Y: 6}, {X: 40, Y: 12}, {X: 50, Y: 8}},
+		},
+		{
+			Name:   "Heap",
+			Points: []widget.LineChartPoint{{X: 0, Y: 2}, {X: 15, Y: 5}, {X: 25, Y: 3}, {X: 40, Y: 7}, {X: 50, Y: 10}},
+			Step:   true,
+		},
+	})
+
+	themetest.Golden(t, "linechart", image.Pt(400, 250), func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+		lc := theme.LineChart(win.Theme, &state)
+		lc.XLabel = "Time"
+		lc.YLabel = "Count"
+		lc.Ranges = []widget.LineChartRange{{Start: 20, End: 30}}
+		return lc.Layout(win, gtx)
+	})
+}
@@ -18,6 +18,7 @@ import (
 	"gioui.org/font"
 	"gioui.org/io/key"
 	"gioui.org/io/pointer"
+	"gioui.org/io/semantic"
 	"gioui.org/op"
 	"gioui.org/text"
 	"gioui.org/unit"
@@ -149,6 +150,24 @@ func (hs *HistogramState) selectedRange(bin int) (start, end widget.FloatDuratio
 	return start, end
 }
 
+// summary describes the histogram's contents for screen readers.
+func (hs HistogramStyle) summary() string {
+	hist := hs.State.Histogram
+	n := hist.Count()
+	if n == 0 {
+		return Sprintf("Empty histogram")
+	}
+	fullest := 0
+	for i, v := range hist.Bins {
+		if v > hist.Bins[fullest] {
+			fullest = i
+		}
+	}
+	start, end := hist.BucketRange(fullest)
+	return Sprintf("Histogram of %d values between %s and %s. The fullest bin, from %s to %s, holds %d values.",
+		n, hist.Start.Floor(), hist.MaxValue, start.Floor(), end.Ceil(), hist.Bins[fullest])
+}
+
 func (hs HistogramStyle) Layout(win *Window, gtx layout.Context) layout.Dimensions {
 	defer rtrace.StartRegion(context.Background(), "theme.HistogramStyle.Layout").End()
 
@@ -165,6 +184,7 @@ func (hs HistogramStyle) Layout(win *Window, gtx layout.Context) layout.Dimensio
 	}
 
 	defer clip.Rect{Max: gtx.Constraints.Min}.Push(gtx.Ops).Pop()
+	semantic.DescriptionOp(hs.summary()).Add(gtx.Ops)
 
 	gtx.Constraints.Max = gtx.Constraints.Min
 
@@ -0,0 +1,645 @@
+package theme
+
+import (
+	"context"
+	"image"
+	"math"
+	rtrace "runtime/trace"
+	"strconv"
+	"strings"
+
+	"honnef.co/go/gotraceui/clip"
+	"honnef.co/go/gotraceui/color"
+	"honnef.co/go/gotraceui/gesture"
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/f32"
+	"gioui.org/font"
+	"gioui.org/io/key"
+	"gioui.org/io/pointer"
+	"gioui.org/op"
+	"gioui.org/text"
+	"gioui.org/unit"
+)
+
+type LineChartState struct {
+	series []widget.LineChartSeries
+	// Bounds of all series
+	minX, maxX float64
+
+	hover gesture.Hover
+	click gesture.Click
+	drag  gesture.Drag
+
+	// The visible range of X values, if zoomed in.
+	zoomed     bool
+	start, end float64
+
+	dragging struct {
+		active     bool
+		from       float32
+		start, end float64
+	}
+
+	// State for pinch zooming. Moving two fingers apart or together zooms around the point between them.
+	touch struct {
+		ids       [2]pointer.ID
+		positions [2]f32.Point
+		n         int
+		pinching  bool
+		prevDist  float32
+		prevMid   f32.Point
+	}
+
+	// The width of the plot in the previous frame, for mapping pointer positions to X values.
+	prevPlotWidth int
+
+	// The X value of the most recent click that hasn't been consumed by Clicked yet.
+	clickedX float64
+	clicked  bool
+}
+
+// SetSeries sets the series to display. The zoom is preserved.
+func (lcs *LineChartState) SetSeries(series []widget.LineChartSeries) {
+	lcs.series = series
+	lcs.minX, lcs.maxX = math.Inf(1), math.Inf(-1)
+	for i := range series {
+		if len(series[i].Points) == 0 {
+			continue
+		}
+		minX, maxX, _, _ := series[i].Bounds()
+		lcs.minX = min(lcs.minX, minX)
+		lcs.maxX = max(lcs.maxX, maxX)
+	}
+	if lcs.minX > lcs.maxX {
+		lcs.minX, lcs.maxX = 0, 0
+	}
+}
+
+func (lcs *LineChartState) Series() []widget.LineChartSeries { return lcs.series }
+
+// Zoomed reports whether the chart has been zoomed in.
+func (lcs *LineChartState) Zoomed() bool { return lcs.zoomed }
+
+// ResetZoom zooms out to show all points.
+func (lcs *LineChartState) ResetZoom() { lcs.zoomed = false }
+
+// VisibleRange returns the visible range of X values.
+func (lcs *LineChartState) VisibleRange() (start, end float64) {
+	if lcs.zoomed {
+		return lcs.start, lcs.end
+	}
+	return lcs.minX, lcs.maxX
+}
+
+// SetVisibleRange zooms to the range [start, end] of X values.
+func (lcs *LineChartState) SetVisibleRange(start, end float64) {
+	// Don't allow zooming in so far that we run into precision issues.
+	minWidth := (lcs.maxX - lcs.minX) / 1e6
+	if end-start < minWidth {
+		mid := start + (end-start)/2
+		start, end = mid-minWidth/2, mid+minWidth/2
+	}
+	if start <= lcs.minX && end >= lcs.maxX {
+		lcs.zoomed = false
+		return
+	}
+	// Don't allow panning past the data.
+	if start < lcs.minX {
+		end += lcs.minX - start
+		start = lcs.minX
+	}
+	if end > lcs.maxX {
+		start -= end - lcs.maxX
+		end = lcs.maxX
+	}
+	lcs.zoomed = true
+	lcs.start, lcs.end = max(start, lcs.minX), end
+}
+
+func (lcs *LineChartState) pxToX(px float32) float64 {
+	start, end := lcs.VisibleRange()
+	if lcs.prevPlotWidth == 0 {
+		return start
+	}
+	return start + float64(px)/float64(lcs.prevPlotWidth)*(end-start)
+}
+
+func (lcs *LineChartState) zoom(ticks float32, at f32.Point) {
+	const scrollSpeed = 0.001
+	start, end := lcs.VisibleRange()
+	x := lcs.pxToX(at.X)
+	ratio := scrollSpeed * math.Abs(float64(ticks))
+	var scale float64
+	// Scrolling up == into the screen == zooming in.
+	if ticks < 0 {
+		scale = 1 - ratio
+	} else {
+		scale = 1 / (1 - ratio)
+	}
+	lcs.SetVisibleRange(x-(x-start)*scale, x+(end-x)*scale)
+}
+
+// handleTouch tracks touch points and implements pinch zooming. It reports whether it consumed the event, in which
+// case the event must not be treated as part of a pan.
+func (lcs *LineChartState) handleTouch(ev pointer.Event) bool {
+	t := &lcs.touch
+	idx := -1
+	for i := 0; i < t.n; i++ {
+		if t.ids[i] == ev.PointerID {
+			idx = i
+		}
+	}
+
+	measure := func() (dist float32, mid f32.Point) {
+		a, b := t.positions[0], t.positions[1]
+		return max(float32(math.Abs(float64(a.X-b.X))), 1), a.Add(b).Mul(0.5)
+	}
+
+	switch ev.Kind {
+	case pointer.Press:
+		if idx == -1 && t.n < len(t.ids) {
+			t.ids[t.n] = ev.PointerID
+			t.positions[t.n] = ev.Position
+			t.n++
+		}
+		if t.n == 2 && !t.pinching {
+			lcs.dragging.active = false
+			t.pinching = true
+			t.prevDist, t.prevMid = measure()
+		}
+		return t.pinching
+
+	case pointer.Drag:
+		if idx == -1 {
+			return t.pinching
+		}
+		t.positions[idx] = ev.Position
+		if !t.pinching {
+			return false
+		}
+		dist, mid := measure()
+		start, end := lcs.VisibleRange()
+		scale := float64(t.prevDist / dist)
+		// Zoom around the previous midpoint, then pan by how far the midpoint moved.
+		x := lcs.pxToX(t.prevMid.X)
+		start, end = x-(x-start)*scale, x+(end-x)*scale
+		if lcs.prevPlotWidth > 0 {
+			d := float64(mid.X-t.prevMid.X) / float64(lcs.prevPlotWidth) * (end - start)
+			start, end = start-d, end-d
+		}
+		lcs.SetVisibleRange(start, end)
+		t.prevDist, t.prevMid = dist, mid
+		return true
+
+	case pointer.Release, pointer.Cancel:
+		if idx != -1 {
+			copy(t.ids[idx:], t.ids[idx+1:])
+			copy(t.positions[idx:], t.positions[idx+1:])
+			t.n--
+		}
+		if t.pinching {
+			// Lifting either finger ends the gesture. The remaining finger doesn't start a new pan.
+			if t.n < 2 {
+				t.pinching = false
+			}
+			return true
+		}
+		return false
+	}
+	return t.pinching
+}
+
+// Clicked returns the X value at which the plot was last clicked, if it was clicked since the last call to Clicked.
+func (lcs *LineChartState) Clicked() (float64, bool) {
+	x, ok := lcs.clickedX, lcs.clicked
+	lcs.clicked = false
+	return x, ok
+}
+
+// Update processes input events. Scrolling while holding the shortcut modifier zooms, dragging pans, pinching zooms,
+// and double-clicking resets the zoom.
+func (lcs *LineChartState) Update(gtx layout.Context) {
+	for _, click := range lcs.click.Update(gtx.Queue) {
+		if click.Kind != gesture.KindClick || click.Button != pointer.ButtonPrimary {
+			continue
+		}
+		switch click.NumClicks {
+		case 1:
+			if lcs.dragging.active && math.Abs(float64(float32(click.Position.X)-lcs.dragging.from)) > float64(gtx.Dp(2)) {
+				// The click ended a pan.
+				continue
+			}
+			lcs.clickedX = lcs.pxToX(float32(click.Position.X))
+			lcs.clicked = true
+		case 2:
+			lcs.ResetZoom()
+		}
+	}
+
+	for _, ev := range gtx.Events(lcs) {
+		if ev, ok := ev.(pointer.Event); ok && ev.Kind == pointer.Scroll && ev.Modifiers == key.ModShortcut {
+			lcs.zoom(ev.Scroll.Y, ev.Position)
+		}
+	}
+
+	lcs.drag.MultiTouch = true
+	for _, ev := range lcs.drag.Update(gtx.Metric, gtx, gesture.Horizontal) {
+		if ev.Source == pointer.Touch && lcs.handleTouch(ev) {
+			continue
+		}
+		switch ev.Kind {
+		case pointer.Press:
+			lcs.dragging.active = true
+			lcs.dragging.from = ev.Position.X
+			lcs.dragging.start, lcs.dragging.end = lcs.VisibleRange()
+		case pointer.Drag:
+			if lcs.dragging.active && lcs.zoomed && lcs.prevPlotWidth > 0 {
+				width := lcs.dragging.end - lcs.dragging.start
+				d := float64(ev.Position.X-lcs.dragging.from) / float64(lcs.prevPlotWidth) * width
+				lcs.SetVisibleRange(lcs.dragging.start-d, lcs.dragging.end-d)
+			}
+		case pointer.Release, pointer.Cancel:
+			lcs.dragging.active = false
+		}
+	}
+}
+
+// LineChartStyle displays one or more series as lines, with axes, a legend, and a crosshair that shows the values
+// at the hovered position.
+type LineChartStyle struct {
+	State *LineChartState
+
+	XLabel, YLabel string
+	// FormatX and FormatY format values for axis labels and tooltips.
+	FormatX, FormatY func(v float64) string
+	// Colors are the colors of the series. They are reused if there are more series than colors.
+	Colors []color.Oklch
+	// Stacked draws the series as filled areas on top of each other, for displaying the shares of a whole. All series
+	// must have points at the same X values. Tooltips still show the values of the individual series.
+	Stacked bool
+	// Ranges are highlighted in RangeColor behind the series.
+	Ranges     []widget.LineChartRange
+	RangeColor color.Oklch
+
+	TextColor      color.Oklch
+	TextSize       unit.Sp
+	LineColor      color.Oklch
+	CrosshairColor color.Oklch
+	LineWidth      unit.Dp
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', 4, 64)
+}
+
+func LineChart(th *Theme, state *LineChartState) LineChartStyle {
+	return LineChartStyle{
+		State:   state,
+		FormatX: formatFloat,
+		FormatY: formatFloat,
+		Colors: []color.Oklch{
+			oklch(54.01, 0.139, 248.98),
+			oklch(63.31, 0.191, 36.99),
+			oklch(62.8, 0.176, 142.5),
+			oklch(52.31, 0.214, 313.78),
+			oklch(70.48, 0.145, 80.68),
+		},
+		TextColor:      th.Palette.Foreground,
+		TextSize:       th.TextSize,
+		LineColor:      th.Palette.Border,
+		CrosshairColor: th.Palette.Foreground,
+		RangeColor:     oklcha(0, 0, 0, 0.1),
+		LineWidth:      1,
+	}
+}
+
+func (lc LineChartStyle) color(i int) color.Oklch {
+	return lc.Colors[i%len(lc.Colors)]
+}
+
+// fillStacked fills the area between the running sums top and below. below is nil for the first series, which is
+// filled down to zero.
+func (lc LineChartStyle) fillStacked(
+	win *Window,
+	gtx layout.Context,
+	toPx func(widget.LineChartPoint) f32.Point,
+	top, below []widget.LineChartPoint,
+	step bool,
+	c color.Oklch,
+) {
+	var p clip.Path
+	p.Begin(gtx.Ops)
+	outline := func(pts []widget.LineChartPoint, y func(int) float64, reverse bool) {
+		for k := range pts {
+			j := k
+			if reverse {
+				j = len(pts) - 1 - k
+			}
+			cur := toPx(widget.LineChartPoint{X: pts[j].X, Y: y(j)})
+			if k == 0 {
+				if reverse {
+					p.LineTo(cur)
+				} else {
+					p.MoveTo(cur)
+				}
+				continue
+			}
+			if step {
+				// Forward, the value changes at the new point. Backward, it changes at the previous one.
+				prevJ := j - 1
+				if reverse {
+					prevJ = j + 1
+				}
+				if reverse {
+					p.LineTo(toPx(widget.LineChartPoint{X: pts[prevJ].X, Y: y(j)}))
+				} else {
+					p.LineTo(toPx(widget.LineChartPoint{X: pts[j].X, Y: y(prevJ)}))
+				}
+			}
+			p.LineTo(cur)
+		}
+	}
+	outline(top, func(j int) float64 { return top[j].Y }, false)
+	outline(top, func(j int) float64 {
+		if below == nil {
+			return 0
+		}
+		return below[j].Y
+	}, true)
+	p.Close()
+	FillShape(win, gtx.Ops, c, clip.Outline{Path: p.End()}.Op())
+}
+
+func (lc LineChartStyle) Layout(win *Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "theme.LineChartStyle.Layout").End()
+
+	lc.State.Update(gtx)
+
+	defer clip.Rect{Max: gtx.Constraints.Min}.Push(gtx.Ops).Pop()
+	gtx.Constraints.Max = gtx.Constraints.Min
+
+	hovered := lc.State.hover.Update(gtx.Queue)
+
+	label := func(gtx layout.Context, s string, align text.Alignment) layout.Dimensions {
+		return widget.Label{MaxLines: 1, Alignment: align}.Layout(gtx, win.Theme.Shaper, font.Font{}, lc.TextSize, s, win.ColorMaterial(gtx, lc.TextColor))
+	}
+	measure := func(s string) image.Point {
+		gtx := gtx
+		gtx.Constraints.Min = image.Point{}
+		gtx.Constraints.Max = image.Pt(9999, 9999)
+		m := op.Record(gtx.Ops)
+		dims := label(gtx, s, text.Start)
+		m.Stop()
+		return dims.Size
+	}
+
+	start, end := lc.State.VisibleRange()
+	series := lc.State.series
+	visible := make([][]widget.LineChartPoint, len(series))
+	// The Y axis always includes zero.
+	var minY, maxY float64
+	for i := range series {
+		visible[i] = series[i].Visible(start, end)
+		if lc.Stacked && i > 0 {
+			// Replace the points with the running sum of all series so far.
+			pts := make([]widget.LineChartPoint, len(visible[i]))
+			for j, p := range visible[i] {
+				pts[j] = widget.LineChartPoint{X: p.X, Y: p.Y + visible[i-1][j].Y}
+			}
+			visible[i] = pts
+		}
+		for _, p := range visible[i] {
+			minY = min(minY, p.Y)
+			maxY = max(maxY, p.Y)
+		}
+	}
+	if minY == maxY {
+		maxY = minY + 1
+	}
+
+	var (
+		tickLength    = gtx.Dp(5)
+		tickThickness = gtx.Dp(1)
+		padding       = gtx.Dp(2)
+		borderWidth   = gtx.Dp(1)
+		lineHeight    = measure("0").Y
+		swatchSize    = lineHeight * 2 / 3
+	)
+
+	// Draw the legend
+	legendHeight := 0
+	if len(series) > 1 || (len(series) == 1 && series[0].Name != "") {
+		legendHeight = lineHeight + padding
+		x := 0
+		for i := range series {
+			func() {
+				defer op.Offset(image.Pt(x, (lineHeight-swatchSize)/2)).Push(gtx.Ops).Pop()
+				FillShape(win, gtx.Ops, lc.color(i), clip.Rect{Max: image.Pt(swatchSize, swatchSize)}.Op())
+			}()
+			x += swatchSize + padding
+			func() {
+				defer op.Offset(image.Pt(x, 0)).Push(gtx.Ops).Pop()
+				gtx := gtx
+				gtx.Constraints.Min = image.Point{}
+				x += label(gtx, series[i].Name, text.Start).Size.X + padding*5
+			}()
+		}
+	}
+
+	yTop, yBottom := lc.FormatY(maxY), lc.FormatY(minY)
+	yAxisWidth := max(measure(yTop).X, measure(yBottom).X) + tickLength + padding
+	if lc.YLabel != "" {
+		yAxisWidth += lineHeight + padding
+	}
+	xAxisHeight := tickLength + lineHeight
+	if lc.XLabel != "" {
+		xAxisHeight += lineHeight + padding
+	}
+
+	plotWidth := gtx.Constraints.Min.X - yAxisWidth
+	plotHeight := gtx.Constraints.Min.Y - legendHeight - xAxisHeight
+	if plotWidth <= 0 || plotHeight <= 0 {
+		return layout.Dimensions{Size: gtx.Constraints.Min}
+	}
+
+	// Draw Y axis
+	func() {
+		defer op.Offset(image.Pt(0, legendHeight)).Push(gtx.Ops).Pop()
+		gtx := gtx
+		gtx.Constraints.Min = image.Point{}
+
+		FillShape(win, gtx.Ops, lc.LineColor, clip.Rect{Min: image.Pt(yAxisWidth-tickLength, 0), Max: image.Pt(yAxisWidth, tickThickness)}.Op())
+		FillShape(win, gtx.Ops, lc.LineColor, clip.Rect{Min: image.Pt(yAxisWidth-tickLength, plotHeight-tickThickness), Max: image.Pt(yAxisWidth, plotHeight)}.Op())
+
+		gtx.Constraints.Min.X = yAxisWidth - tickLength - padding
+		gtx.Constraints.Max.X = gtx.Constraints.Min.X
+		label(gtx, yTop, text.End)
+		func() {
+			defer op.Offset(image.Pt(0, plotHeight-lineHeight)).Push(gtx.Ops).Pop()
+			label(gtx, yBottom, text.End)
+		}()
+
+		if lc.YLabel != "" {
+			gtx.Constraints.Min = image.Point{}
+			gtx.Constraints.Max = image.Pt(9999, 9999)
+			m := op.Record(gtx.Ops)
+			dims := label(gtx, lc.YLabel, text.Start)
+			c := m.Stop()
+			aff := f32.Affine2D{}.
+				Rotate(f32.Point{}, -math.Pi/2).
+				Offset(f32.Pt(0, float32(plotHeight)/2+float32(dims.Size.X)/2))
+			defer op.Affine(aff).Push(gtx.Ops).Pop()
+			c.Add(gtx.Ops)
+		}
+	}()
+
+	// Draw X axis
+	func() {
+		defer op.Offset(image.Pt(yAxisWidth, legendHeight+plotHeight)).Push(gtx.Ops).Pop()
+		gtx := gtx
+
+		FillShape(win, gtx.Ops, lc.LineColor, clip.Rect{Max: image.Pt(tickThickness, tickLength)}.Op())
+		FillShape(win, gtx.Ops, lc.LineColor, clip.Rect{Min: image.Pt(plotWidth-tickThickness, 0), Max: image.Pt(plotWidth, tickLength)}.Op())
+
+		defer op.Offset(image.Pt(0, tickLength)).Push(gtx.Ops).Pop()
+		gtx.Constraints.Min = image.Pt(plotWidth, 0)
+		gtx.Constraints.Max = image.Pt(plotWidth, lineHeight*2)
+		label(gtx, lc.FormatX(start), text.Start)
+		label(gtx, lc.FormatX(end), text.End)
+
+		if lc.XLabel != "" {
+			defer op.Offset(image.Pt(0, lineHeight+padding)).Push(gtx.Ops).Pop()
+			label(gtx, lc.XLabel, text.Middle)
+		}
+	}()
+
+	// Draw plot
+	func() {
+		defer op.Offset(image.Pt(yAxisWidth, legendHeight)).Push(gtx.Ops).Pop()
+		defer clip.Rect{Max: image.Pt(plotWidth, plotHeight)}.Push(gtx.Ops).Pop()
+
+		FillShape(win, gtx.Ops, lc.LineColor, clip.Rect{Max: image.Pt(borderWidth, plotHeight)}.Op())
+		FillShape(win, gtx.Ops, lc.LineColor, clip.Rect{Min: image.Pt(0, plotHeight-borderWidth), Max: image.Pt(plotWidth, plotHeight)}.Op())
+
+		pointer.InputOp{
+			Tag:          lc.State,
+			ScrollBounds: image.Rectangle{Min: image.Pt(-100, -100), Max: image.Pt(100, 100)},
+			Kinds:        pointer.Scroll,
+		}.Add(gtx.Ops)
+		lc.State.hover.Add(gtx.Ops)
+		lc.State.click.Add(gtx.Ops)
+		lc.State.drag.Add(gtx.Ops)
+		if lc.State.dragging.active && lc.State.zoomed {
+			pointer.CursorAllScroll.Add(gtx.Ops)
+		}
+		lc.State.prevPlotWidth = plotWidth
+
+		toPx := func(p widget.LineChartPoint) f32.Point {
+			x := float32((p.X - start) / (end - start) * float64(plotWidth))
+			y := float32(plotHeight) - float32((p.Y-minY)/(maxY-minY)*float64(plotHeight-borderWidth))
+			if end == start {
+				x = 0
+			}
+			return f32.Pt(x, y)
+		}
+
+		if len(lc.Ranges) > 0 {
+			var p clip.Path
+			p.Begin(gtx.Ops)
+			for _, r := range lc.Ranges {
+				if r.End < start || r.Start > end {
+					continue
+				}
+				xMin := toPx(widget.LineChartPoint{X: max(r.Start, start)}).X
+				xMax := toPx(widget.LineChartPoint{X: min(r.End, end)}).X
+				// Make short ranges visible.
+				xMax = max(xMax, xMin+1)
+				clip.FRect{Min: f32.Pt(xMin, 0), Max: f32.Pt(xMax, float32(plotHeight))}.IntoPath(&p)
+			}
+			FillShape(win, gtx.Ops, lc.RangeColor, clip.Outline{Path: p.End()}.Op())
+		}
+
+		for i := range series {
+			pts := visible[i]
+			if len(pts) == 0 {
+				continue
+			}
+			if lc.Stacked {
+				var below []widget.LineChartPoint
+				if i > 0 {
+					below = visible[i-1]
+				}
+				lc.fillStacked(win, gtx, toPx, pts, below, series[i].Step, lc.color(i))
+				continue
+			}
+			var p clip.Path
+			p.Begin(gtx.Ops)
+			prev := toPx(pts[0])
+			p.MoveTo(prev)
+
+			// Points that fall into the same pixel column are merged into a single vertical line, so that the cost of
+			// drawing doesn't depend on the number of points.
+			col := int(prev.X)
+			colMin, colMax := prev.Y, prev.Y
+			flush := func() {
+				if colMin != colMax {
+					p.LineTo(f32.Pt(prev.X, colMin))
+					p.LineTo(f32.Pt(prev.X, colMax))
+					p.LineTo(prev)
+				}
+			}
+			for _, pt := range pts[1:] {
+				cur := toPx(pt)
+				if int(cur.X) == col {
+					colMin, colMax = min(colMin, cur.Y), max(colMax, cur.Y)
+					prev.Y = cur.Y
+					continue
+				}
+				flush()
+				if series[i].Step && cur.Y != prev.Y {
+					p.LineTo(f32.Pt(cur.X, prev.Y))
+				}
+				p.LineTo(cur)
+				prev = cur
+				col = int(cur.X)
+				colMin, colMax = cur.Y, cur.Y
+			}
+			flush()
+
+			FillShape(win, gtx.Ops, lc.color(i), clip.Stroke{Path: p.End(), Width: float32(gtx.Dp(lc.LineWidth))}.Op())
+		}
+
+		if hovered && !lc.State.dragging.active {
+			px := lc.State.hover.Pointer().X
+			x := lc.State.pxToX(px)
+			FillShape(win, gtx.Ops, lc.CrosshairColor, clip.Rect{Min: image.Pt(int(px), 0), Max: image.Pt(int(px)+tickThickness, plotHeight)}.Op())
+
+			var sb strings.Builder
+			sb.WriteString(lc.XLabel)
+			if lc.XLabel != "" {
+				sb.WriteString(": ")
+			}
+			sb.WriteString(lc.FormatX(x))
+			for i := range series {
+				v, ok := series[i].ValueAt(x)
+				if !ok {
+					continue
+				}
+				sb.WriteString("\n")
+				if series[i].Name != "" {
+					sb.WriteString(series[i].Name)
+					sb.WriteString(": ")
+				}
+				sb.WriteString(lc.FormatY(v.Y))
+			}
+			s := sb.String()
+			win.SetTooltip(func(win *Window, gtx layout.Context) layout.Dimensions {
+				return Tooltip(win.Theme, s).Layout(win, gtx)
+			})
+		}
+	}()
+
+	return layout.Dimensions{Size: gtx.Constraints.Min}
+}
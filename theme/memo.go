@@ -0,0 +1,163 @@
+package theme
+
+import (
+	"context"
+	"sync"
+)
+
+// Memo memoizes the results of expensive computations, such as statistics and flame graphs, keyed by their parameters.
+//
+// Unlike futures, memoized computations aren't tied to a window and don't get cancelled when nobody is waiting for
+// them. This allows sharing results between panels and windows, and makes them available instantly when a panel gets
+// reopened.
+//
+// Keys include parameters such as the global filter, so the number of distinct keys grows as the user works with a
+// trace. To bound memory usage, a Memo holds at most maxMemoEntries results and forgets the least recently used ones
+// first.
+type Memo struct {
+	mu      sync.Mutex
+	entries map[any]*memoEntry
+	// clock is incremented on every lookup and used to find the least recently used entry.
+	clock uint64
+}
+
+// The maximum number of results a Memo holds.
+const maxMemoEntries = 64
+
+type memoEntry struct {
+	done  chan struct{}
+	value any
+	// The operation computing the value, for computations started by ComputeOperation.
+	op *Operation
+	// The value of Memo.clock when the entry was last used.
+	used uint64
+}
+
+// add adds an entry, evicting the least recently used entry if the memo is full. The caller must hold the lock.
+func (m *Memo) add(key any, e *memoEntry) {
+	if m.entries == nil {
+		m.entries = make(map[any]*memoEntry)
+	}
+	if _, ok := m.entries[key]; !ok && len(m.entries) >= maxMemoEntries {
+		var lruKey any
+		var lru *memoEntry
+		for k, o := range m.entries {
+			if lru == nil || o.used < lru.used {
+				lruKey, lru = k, o
+			}
+		}
+		// Computations that are still running will finish, but their results will only be seen by those already
+		// waiting for them.
+		delete(m.entries, lruKey)
+	}
+	m.entries[key] = e
+}
+
+func (m *Memo) entry(key any, fn func() any) *memoEntry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.clock++
+	e, ok := m.entries[key]
+	if !ok {
+		e = &memoEntry{done: make(chan struct{})}
+		m.add(key, e)
+		go func() {
+			e.value = fn()
+			close(e.done)
+		}()
+	}
+	e.used = m.clock
+	return e
+}
+
+// operationEntry is like entry, but runs fn as an operation. Computations that fail or get cancelled are forgotten
+// once they finish, and computations that have been cancelled but haven't finished yet get replaced, so that they can
+// be retried.
+func (m *Memo) operationEntry(key any, stages []string, fn func(o *Operation) (any, error)) *memoEntry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.clock++
+	e, ok := m.entries[key]
+	if !ok || (e.op != nil && e.op.Cancelled()) {
+		e = &memoEntry{done: make(chan struct{}), op: NewOperation(context.Background(), stages...)}
+		m.add(key, e)
+		go func() {
+			v, err := fn(e.op)
+			if err != nil || e.op.Cancelled() {
+				m.mu.Lock()
+				if m.entries[key] == e {
+					delete(m.entries, key)
+				}
+				m.mu.Unlock()
+			}
+			e.value = v
+			close(e.done)
+		}()
+	}
+	e.used = m.clock
+	return e
+}
+
+// Reset forgets all memoized results. Computations that are still running will finish, but their results will be
+// discarded.
+func (m *Memo) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	clear(m.entries)
+}
+
+// Memoized returns the result of fn, which is computed at most once per key. Concurrent calls for the same key wait
+// for the same computation. Keys must be comparable and should be of distinct types for different kinds of
+// computations.
+func Memoized[T any](m *Memo, key any, fn func() T) T {
+	e := m.entry(key, func() any { return fn() })
+	<-e.done
+	return e.value.(T)
+}
+
+// Compute is like Memoized, but returns a future instead of blocking. If the result has already been computed, the
+// future is immediately ready.
+func Compute[T any](m *Memo, win *Window, key any, fn func() T) *Future[T] {
+	e := m.entry(key, func() any { return fn() })
+	select {
+	case <-e.done:
+		return Immediate(e.value.(T))
+	default:
+	}
+	return NewFuture(win, func(cancelled <-chan struct{}) T {
+		select {
+		case <-e.done:
+			return e.value.(T)
+		case <-cancelled:
+			return *new(T)
+		}
+	})
+}
+
+// ComputeOperation is like Compute, but runs fn as an operation, which reports its progress and can be cancelled.
+// Concurrent calls for the same key share the operation, which can be displayed with OperationProgress. If the
+// operation gets cancelled or returns an error, the future's value is the zero value, the operation reports that it
+// has been cancelled, and the next call for the same key starts a new computation.
+func ComputeOperation[T any](m *Memo, win *Window, key any, stages []string, fn func(o *Operation) (T, error)) (*Future[T], *Operation) {
+	e := m.operationEntry(key, stages, func(o *Operation) (any, error) {
+		v, err := fn(o)
+		if err != nil {
+			// Mark the operation as failed, to distinguish the zero value from a valid result.
+			o.Cancel()
+		}
+		return v, err
+	})
+	select {
+	case <-e.done:
+		return Immediate(e.value.(T)), e.op
+	default:
+	}
+	return NewFuture(win, func(cancelled <-chan struct{}) T {
+		select {
+		case <-e.done:
+			return e.value.(T)
+		case <-cancelled:
+			return *new(T)
+		}
+	}), e.op
+}
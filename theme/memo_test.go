@@ -0,0 +1,36 @@
+package theme
+
+import (
+	"testing"
+)
+
+func TestMemoEviction(t *testing.T) {
+	var m Memo
+	calls := map[int]int{}
+	get := func(key int) int {
+		return Memoized(&m, key, func() int {
+			calls[key]++
+			return key * 2
+		})
+	}
+
+	for i := range maxMemoEntries {
+		get(i)
+	}
+	// Use the oldest entry so that it isn't the least recently used one anymore.
+	get(0)
+	get(maxMemoEntries)
+
+	if n := len(m.entries); n != maxMemoEntries {
+		t.Errorf("got %d entries, want %d", n, maxMemoEntries)
+	}
+	if _, ok := m.entries[1]; ok {
+		t.Error("least recently used entry wasn't evicted")
+	}
+	if got := get(0); got != 0 || calls[0] != 1 {
+		t.Errorf("recently used entry was recomputed: got %d after %d calls", got, calls[0])
+	}
+	if got := get(1); got != 2 || calls[1] != 2 {
+		t.Errorf("evicted entry wasn't recomputed: got %d after %d calls", got, calls[1])
+	}
+}
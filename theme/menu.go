@@ -122,7 +122,7 @@ func (m MenuStyle) Layout(win *Window, gtx layout.Context) layout.Dimensions {
 				dims := Background{Color: bg}.Layout(win, gtx, func(win *Window, gtx layout.Context) layout.Dimensions {
 					return g.click.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
 						return layout.UniformInset(1).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
-							return widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, font.Font{}, 12, g.Label, win.ColorMaterial(gtx, m.Foreground))
+							return widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, font.Font{}, win.Theme.TextSize, g.Label, win.ColorMaterial(gtx, m.Foreground))
 						})
 					})
 				})
@@ -198,10 +198,15 @@ func (g MenuGroupStyle) Layout(win *Window, gtx layout.Context) layout.Dimension
 type MenuItem struct {
 	Label    func() string
 	Shortcut string
+	// Icon is an optional glyph displayed in front of the label.
+	Icon     string
 	Disabled func() bool
 	// The action to execute when the menu item is activated. This is only used for context menus, not main
 	// menus.
 	Action func() Action
+	// Submenu returns the items of a submenu that replaces the context menu when the item is activated. Items with
+	// submenus don't have actions. Like Action, this is only used for context menus.
+	Submenu func() []*MenuItem
 
 	click widget.PrimaryClickable
 }
@@ -239,19 +244,27 @@ func (item MenuItemStyle) Layout(win *Window, gtx layout.Context) layout.Dimensi
 	dims := Background{Color: bg}.Layout(win, gtx, func(win *Window, gtx layout.Context) layout.Dimensions {
 		return item.Item.click.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
 			return layout.UniformInset(2).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				shortcut := item.Item.Shortcut
+				if item.Item.Submenu != nil {
+					shortcut = "➡"
+				}
 				l := func(gtx layout.Context) layout.Dimensions {
-					dims := widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, font.Font{}, 12, item.Item.Label(), win.ColorMaterial(gtx, fg))
-					if item.Item.Shortcut != "" {
+					label := item.Item.Label()
+					if item.Item.Icon != "" {
+						label = item.Item.Icon + " " + label
+					}
+					dims := widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, font.Font{}, win.Theme.TextSize, label, win.ColorMaterial(gtx, fg))
+					if shortcut != "" {
 						// add padding between label and shortcut
 						dims.Size.X += gtx.Dp(10)
 					}
 					return dims
 				}
 				r := func(gtx layout.Context) layout.Dimensions {
-					if item.Item.Shortcut == "" {
+					if shortcut == "" {
 						return layout.Dimensions{}
 					} else {
-						return widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, font.Font{}, 12, item.Item.Shortcut, win.ColorMaterial(gtx, fg))
+						return widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, font.Font{}, win.Theme.TextSize, shortcut, win.ColorMaterial(gtx, fg))
 					}
 				}
 				return layout.Flex{Axis: layout.Horizontal, Spacing: layout.SpaceBetween}.Layout(gtx, layout.Rigid(l), layout.Rigid(r))
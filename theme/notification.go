@@ -0,0 +1,227 @@
+package theme
+
+import (
+	"context"
+	"fmt"
+	"image"
+	rtrace "runtime/trace"
+	"sync"
+	"time"
+
+	"honnef.co/go/gotraceui/color"
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/op"
+)
+
+type NotificationKind uint8
+
+const (
+	NotificationInfo NotificationKind = iota
+	NotificationWarning
+	NotificationError
+)
+
+func (kind NotificationKind) String() string {
+	switch kind {
+	case NotificationInfo:
+		return "info"
+	case NotificationWarning:
+		return "warning"
+	case NotificationError:
+		return "error"
+	default:
+		return fmt.Sprintf("NotificationKind(%d)", kind)
+	}
+}
+
+// duration returns how long toasts of this kind are displayed. Problems stay around longer so that users have a
+// chance of reading them.
+func (kind NotificationKind) duration() time.Duration {
+	if kind == NotificationInfo {
+		return 1000 * time.Millisecond
+	}
+	return 5000 * time.Millisecond
+}
+
+// A Notification is a message that gets displayed as a toast and is kept in the window's notification history.
+type Notification struct {
+	Kind    NotificationKind
+	Message string
+	Time    time.Time
+}
+
+const (
+	// The maximum number of toasts displayed at once.
+	maxToasts = 3
+	// The maximum number of notifications kept in the history.
+	maxNotifications = 100
+)
+
+type notifications struct {
+	mu sync.Mutex
+	// history contains all notifications, oldest first.
+	history []Notification
+}
+
+// ShowNotification displays a transient informational message.
+func (win *Window) ShowNotification(gtx layout.Context, msg string) {
+	win.notify(NotificationInfo, msg, gtx.Now)
+}
+
+// Notify displays a transient message and records it in the notification history. Unlike ShowNotification, it may be
+// called from any goroutine, which makes it suitable for reporting the results of background work.
+func (win *Window) Notify(kind NotificationKind, msg string) {
+	win.notify(kind, msg, time.Now())
+	win.AppWindow.Invalidate()
+}
+
+func (win *Window) notify(kind NotificationKind, msg string, now time.Time) {
+	n := &win.notifications
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if len(n.history) == maxNotifications {
+		copy(n.history, n.history[1:])
+		n.history = n.history[:len(n.history)-1]
+	}
+	n.history = append(n.history, Notification{Kind: kind, Message: msg, Time: now})
+}
+
+// Notifications returns the notification history, most recent first.
+func (win *Window) Notifications() []Notification {
+	n := &win.notifications
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	out := make([]Notification, len(n.history))
+	for i, notif := range n.history {
+		out[len(out)-1-i] = notif
+	}
+	return out
+}
+
+// ClearNotifications clears the notification history.
+func (win *Window) ClearNotifications() {
+	n := &win.notifications
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.history = nil
+}
+
+func notificationColor(th *Theme, kind NotificationKind) color.Oklch {
+	switch kind {
+	case NotificationWarning:
+		return oklch(76.9, 0.164, 70.08)
+	case NotificationError:
+		return oklch(62.8, 0.258, 29.234)
+	default:
+		return th.Palette.Border
+	}
+}
+
+// Layout displays the active toasts at the bottom of the window, newest at the bottom.
+func (n *notifications) Layout(win *Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "theme.notifications.Layout").End()
+
+	n.mu.Lock()
+	var active [maxToasts]Notification
+	var num int
+	var nextExpiry time.Time
+	for i := len(n.history) - 1; i >= 0 && num < maxToasts; i-- {
+		notif := n.history[i]
+		expiry := notif.Time.Add(notif.Kind.duration())
+		if !gtx.Now.Before(expiry) {
+			continue
+		}
+		active[num] = notif
+		num++
+		if nextExpiry.IsZero() || expiry.Before(nextExpiry) {
+			nextExpiry = expiry
+		}
+	}
+	n.mu.Unlock()
+
+	if num == 0 {
+		return layout.Dimensions{}
+	}
+
+	// XXX compute width based on window size
+	// TODO(dh): limit height to something sensible, just in case
+	ngtx := gtx
+	ngtx.Constraints.Min = image.Point{}
+	ngtx.Constraints.Max.X = 500
+	y := gtx.Constraints.Max.Y - gtx.Dp(30)
+	for _, notif := range active[:num] {
+		bt := BorderedText(win.Theme, notif.Message)
+		bt.BorderColor = notificationColor(win.Theme, notif.Kind)
+		macro := op.Record(gtx.Ops)
+		dims := bt.Layout(win, ngtx)
+		call := macro.Stop()
+
+		y -= dims.Size.Y
+		stack := op.Offset(image.Pt(gtx.Constraints.Max.X/2-dims.Size.X/2, y)).Push(gtx.Ops)
+		call.Add(gtx.Ops)
+		stack.Pop()
+		y -= gtx.Dp(5)
+	}
+
+	op.InvalidateOp{At: nextExpiry}.Add(gtx.Ops)
+
+	return layout.Dimensions{Size: gtx.Constraints.Max}
+}
+
+// NotificationCenterState is the state of a NotificationCenterStyle.
+type NotificationCenterState struct {
+	list  widget.List
+	clear widget.PrimaryClickable
+}
+
+// NotificationCenterStyle lists the window's recent notifications.
+type NotificationCenterStyle struct {
+	State *NotificationCenterState
+}
+
+func NotificationCenter(state *NotificationCenterState) NotificationCenterStyle {
+	state.list.Axis = layout.Vertical
+	return NotificationCenterStyle{State: state}
+}
+
+func (nc NotificationCenterStyle) Layout(win *Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "theme.NotificationCenterStyle.Layout").End()
+
+	for nc.State.clear.Clicked(gtx) {
+		win.ClearNotifications()
+	}
+
+	// OPT(dh): avoid copying the history every frame
+	notifs := win.Notifications()
+	return layout.Rigids(gtx, layout.Vertical,
+		func(gtx layout.Context) layout.Dimensions {
+			return Button(win.Theme, &nc.State.clear.Clickable, "Clear").Layout(win, gtx)
+		},
+		layout.Spacer{Height: 5}.Layout,
+		func(gtx layout.Context) layout.Dimensions {
+			if len(notifs) == 0 {
+				return Label(win.Theme, "No notifications").Layout(win, gtx)
+			}
+			gtx.Constraints.Min = gtx.Constraints.Max
+			return List(win.Theme, &nc.State.list).Layout(win, gtx, len(notifs), func(gtx layout.Context, index int) layout.Dimensions {
+				notif := notifs[index]
+				return layout.Rigids(gtx, layout.Horizontal,
+					func(gtx layout.Context) layout.Dimensions {
+						l := LineLabel(win.Theme, notif.Time.Format("15:04:05")+" ")
+						l.Color = win.Theme.Palette.ForegroundDisabled
+						return l.Layout(win, gtx)
+					},
+					func(gtx layout.Context) layout.Dimensions {
+						l := Label(win.Theme, notif.Message)
+						if notif.Kind != NotificationInfo {
+							l.Color = notificationColor(win.Theme, notif.Kind)
+						}
+						return l.Layout(win, gtx)
+					},
+				)
+			})
+		},
+	)
+}
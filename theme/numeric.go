@@ -0,0 +1,209 @@
+package theme
+
+import (
+	"context"
+	"fmt"
+	rtrace "runtime/trace"
+	"strconv"
+	"strings"
+	"time"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/widget"
+
+	"golang.org/x/exp/constraints"
+)
+
+// NumberInputState is the state of a NumberInputStyle, an input for integers or integer-like values such as
+// time.Duration. Invalid input, such as input that cannot be parsed or that is out of range, is highlighted and
+// doesn't change the value.
+type NumberInputState[T constraints.Integer] struct {
+	// Min and Max are the inclusive bounds of the value.
+	Min, Max T
+	// Step is the amount by which the step buttons change the value.
+	Step T
+	// Parse and Format convert between values and text.
+	Parse  func(s string) (T, error)
+	Format func(v T) string
+
+	editor      widget.Editor
+	inc, dec    widget.PrimaryClickable
+	value       T
+	changed     bool
+	initialized bool
+}
+
+// NewIntInput returns the state of an input for integers in the range [min, max].
+func NewIntInput(value, min, max, step int) *NumberInputState[int] {
+	s := &NumberInputState[int]{
+		Min:    min,
+		Max:    max,
+		Step:   step,
+		Parse:  strconv.Atoi,
+		Format: strconv.Itoa,
+	}
+	s.SetValue(value)
+	s.editor.Filter = "-0123456789"
+	return s
+}
+
+// NewDurationInput returns the state of an input for durations in the range [min, max]. Durations are entered like
+// "150ms" or "2.5s".
+func NewDurationInput(value, min, max, step time.Duration) *NumberInputState[time.Duration] {
+	s := &NumberInputState[time.Duration]{
+		Min:    min,
+		Max:    max,
+		Step:   step,
+		Parse:  time.ParseDuration,
+		Format: time.Duration.String,
+	}
+	s.SetValue(value)
+	return s
+}
+
+func (s *NumberInputState[T]) init() {
+	if !s.initialized {
+		s.initialized = true
+		s.editor.SingleLine = true
+		s.editor.Submit = true
+	}
+}
+
+func (s *NumberInputState[T]) clamp(v T) T {
+	return min(max(v, s.Min), s.Max)
+}
+
+func (s *NumberInputState[T]) setText() {
+	text := s.Format(s.value)
+	s.editor.SetText(text)
+	s.editor.SetCaret(len(text), len(text))
+}
+
+// Value returns the most recent valid value.
+func (s *NumberInputState[T]) Value() T { return s.value }
+
+// SetValue sets the value, clamped to the bounds, and replaces the input's text.
+func (s *NumberInputState[T]) SetValue(v T) {
+	s.init()
+	s.value = s.clamp(v)
+	s.setText()
+}
+
+func (s *NumberInputState[T]) parse(text string) (T, error) {
+	v, err := s.Parse(strings.TrimSpace(text))
+	if err != nil {
+		return 0, err
+	}
+	if v < s.Min || v > s.Max {
+		return 0, fmt.Errorf("value must be between %s and %s", s.Format(s.Min), s.Format(s.Max))
+	}
+	return v, nil
+}
+
+// Valid reports whether the current input is a valid value.
+func (s *NumberInputState[T]) Valid() bool {
+	_, err := s.parse(s.editor.Text())
+	return err == nil
+}
+
+// Changed reports whether the value was changed by the user since the last call to Changed.
+func (s *NumberInputState[T]) Changed() bool {
+	changed := s.changed
+	s.changed = false
+	return changed
+}
+
+// Update processes input events and reports whether the value changed.
+func (s *NumberInputState[T]) Update(gtx layout.Context) bool {
+	s.init()
+	changed := false
+	step := func(delta T) {
+		v := s.value
+		// Check for overflow before stepping.
+		if delta > 0 && v > s.Max-delta {
+			v = s.Max
+		} else if delta < 0 && v < s.Min-delta {
+			v = s.Min
+		} else {
+			v += delta
+		}
+		if v != s.value {
+			s.SetValue(v)
+			changed = true
+		}
+	}
+	for s.inc.Clicked(gtx) {
+		step(s.Step)
+	}
+	for s.dec.Clicked(gtx) {
+		step(-s.Step)
+	}
+
+	for _, ev := range s.editor.Events() {
+		switch ev.(type) {
+		case widget.ChangeEvent:
+			if v, err := s.parse(s.editor.Text()); err == nil && v != s.value {
+				s.value = v
+				changed = true
+			}
+		case widget.SubmitEvent:
+			// Normalize the text, e.g. turning "1500ms" into "1.5s".
+			if s.Valid() {
+				s.setText()
+			}
+		}
+	}
+
+	if changed {
+		s.changed = true
+	}
+	return changed
+}
+
+// NumberInputStyle displays a NumberInputState as a text box with buttons for decrementing and incrementing the
+// value.
+type NumberInputStyle[T constraints.Integer] struct {
+	State *NumberInputState[T]
+	Hint  string
+	Theme *Theme
+}
+
+func NumberInput[T constraints.Integer](th *Theme, state *NumberInputState[T], hint string) NumberInputStyle[T] {
+	return NumberInputStyle[T]{
+		State: state,
+		Hint:  hint,
+		Theme: th,
+	}
+}
+
+func (ns NumberInputStyle[T]) Layout(win *Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "theme.NumberInputStyle.Layout").End()
+
+	s := ns.State
+	s.Update(gtx)
+
+	button := func(click *widget.PrimaryClickable, label string, enabled bool) layout.FlexChild {
+		return layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			if !enabled {
+				gtx.Queue = nil
+			}
+			return Button(ns.Theme, &click.Clickable, label).Layout(win, gtx)
+		})
+	}
+
+	return layout.Flex{Axis: layout.Horizontal}.Layout(gtx,
+		layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+			tb := TextBox(ns.Theme, &s.editor, ns.Hint)
+			tb.Validate = func(text string) bool {
+				// Don't highlight empty input, to avoid a red caret.
+				_, err := s.parse(text)
+				return text == "" || err == nil
+			}
+			return tb.Layout(win, gtx)
+		}),
+		layout.Rigid(layout.Spacer{Width: 2}.Layout),
+		button(&s.dec, " − ", s.value > s.Min),
+		layout.Rigid(layout.Spacer{Width: 2}.Layout),
+		button(&s.inc, " + ", s.value < s.Max),
+	)
+}
@@ -0,0 +1,168 @@
+package theme
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"math"
+	rtrace "runtime/trace"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/op"
+)
+
+// An Operation tracks a long-running operation, such as parsing a trace or computing statistics. The operation reports
+// its progress, which is displayed by OperationProgress, and checks its context to learn about cancellation.
+//
+// Operations are made up of one or more named stages, each with its own progress between 0 and 1. All methods are safe
+// for concurrent use.
+type Operation struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	progress atomic.Uint64
+
+	mu     sync.Mutex
+	stages []string
+	stage  int
+}
+
+// NewOperation returns a new operation, which gets cancelled when parent is done or Cancel is called.
+func NewOperation(parent context.Context, stages ...string) *Operation {
+	ctx, cancel := context.WithCancel(parent)
+	return &Operation{
+		ctx:    ctx,
+		cancel: cancel,
+		stages: stages,
+	}
+}
+
+// Context returns the context of the operation. It gets cancelled when the operation is cancelled.
+func (o *Operation) Context() context.Context { return o.ctx }
+
+// Cancel requests that the operation stops. It is up to the operation to notice the cancellation.
+func (o *Operation) Cancel() { o.cancel() }
+
+// Cancelled reports whether the operation has been cancelled.
+func (o *Operation) Cancelled() bool { return o.ctx.Err() != nil }
+
+func (o *Operation) SetProgressStages(names []string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.stages = names
+	o.stage = 0
+	o.progress.Store(0)
+}
+
+// SetProgressStage moves on to a new stage and resets the progress.
+func (o *Operation) SetProgressStage(idx int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.stage = idx
+	o.progress.Store(0)
+}
+
+// SetProgress sets the progress of the current stage, in the range [0, 1].
+func (o *Operation) SetProgress(p float64) {
+	o.progress.Store(math.Float64bits(p))
+}
+
+// Progress returns the current stage, the names of all stages, and the progress of the current stage.
+func (o *Operation) Progress() (stage int, stages []string, progress float64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.stage, o.stages, math.Float64frombits(o.progress.Load())
+}
+
+// OperationProgressState is the state of an OperationProgressStyle.
+type OperationProgressState struct {
+	cancel widget.PrimaryClickable
+
+	// The width of the label, cached per set of stages
+	labelWidth  int
+	widthStages []string
+}
+
+// OperationProgressStyle displays the progress of an operation, as well as a button for cancelling it.
+type OperationProgressStyle struct {
+	Operation *Operation
+	State     *OperationProgressState
+	// Cancellable controls whether the cancel button is shown.
+	Cancellable bool
+}
+
+func OperationProgress(o *Operation, state *OperationProgressState) OperationProgressStyle {
+	return OperationProgressStyle{
+		Operation:   o,
+		State:       state,
+		Cancellable: true,
+	}
+}
+
+func (ops OperationProgressStyle) Layout(win *Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "theme.OperationProgressStyle.Layout").End()
+
+	for ops.State.cancel.Clicked(gtx) {
+		ops.Operation.Cancel()
+	}
+
+	// Redraw continuously to show progress updates
+	op.InvalidateOp{}.Add(gtx.Ops)
+
+	stage, stages, progress := ops.Operation.Progress()
+	if ops.State.widthStages == nil || !slices.Equal(ops.State.widthStages, stages) {
+		var maxNameWidth int
+		for _, name := range stages {
+			width := Label(win.Theme, name).Length(win, gtx)
+			if width > maxNameWidth {
+				maxNameWidth = width
+			}
+		}
+		width := Label(win.Theme, fmt.Sprintf("100.00%% | (%d/%d) ", len(stages), len(stages))).Length(win, gtx)
+		ops.State.labelWidth = maxNameWidth + width
+		ops.State.widthStages = stages
+	}
+	labelWidth := ops.State.labelWidth
+
+	return layout.Rigids(gtx, layout.Vertical,
+		func(gtx layout.Context) layout.Dimensions {
+			var name string
+			if stage < len(stages) {
+				name = stages[stage]
+			} else {
+				name = "Unknown"
+			}
+			if ops.Operation.Cancelled() {
+				name = "Cancelling…"
+			}
+			gtx.Constraints.Min.X = gtx.Constraints.Constrain(image.Pt(labelWidth, 0)).X
+			gtx.Constraints.Max.X = gtx.Constraints.Min.X
+			pct := fmt.Sprintf("%5.2f%%", progress*100)
+			// Replace space with figure space for correct alignment
+			pct = strings.ReplaceAll(pct, " ", "\u2007")
+			return Label(win.Theme, fmt.Sprintf("%s | (%d/%d) %s", pct, stage+1, len(stages), name)).Layout(win, gtx)
+		},
+		func(gtx layout.Context) layout.Dimensions {
+			gtx.Constraints.Min = gtx.Constraints.Constrain(image.Pt(labelWidth, 15))
+			gtx.Constraints.Max = gtx.Constraints.Min
+			return ProgressBar(win.Theme, float32(progress)).Layout(win, gtx)
+		},
+		func(gtx layout.Context) layout.Dimensions {
+			if !ops.Cancellable {
+				return layout.Dimensions{}
+			}
+			return layout.Rigids(gtx, layout.Vertical,
+				layout.Spacer{Height: 5}.Layout,
+				func(gtx layout.Context) layout.Dimensions {
+					return Button(win.Theme, &ops.State.cancel.Clickable, "Cancel").Layout(win, gtx)
+				},
+			)
+		},
+	)
+}
@@ -14,6 +14,7 @@ type ComponentButtons struct {
 	back   widget.PrimaryClickable
 	detach widget.PrimaryClickable
 	attach widget.PrimaryClickable
+	float  widget.PrimaryClickable
 
 	state ComponentState
 }
@@ -25,6 +26,8 @@ func (pb *ComponentButtons) Transition(state ComponentState) {
 func (pb *ComponentButtons) WantsTransition(gtx layout.Context) ComponentState {
 	if pb.detach.Clicked(gtx) {
 		return ComponentStateTab
+	} else if pb.float.Clicked(gtx) {
+		return ComponentStateWindow
 	} else if pb.attach.Clicked(gtx) {
 		return ComponentStatePanel
 	} else if pb.close.Clicked(gtx) {
@@ -67,6 +70,14 @@ func (pb *ComponentButtons) Layout(win *Window, gtx layout.Context) layout.Dimen
 					PrimaryLabel: "Turn panel into tab",
 				},
 			},
+
+			{
+				&pb.float,
+				"Float",
+				NormalCommand{
+					PrimaryLabel: "Open panel in its own window",
+				},
+			},
 		}
 	case ComponentStateTab:
 	case ComponentStateWindow:
@@ -90,7 +101,7 @@ func (pb *ComponentButtons) Layout(win *Window, gtx layout.Context) layout.Dimen
 	}
 
 	var cmds CommandSlice
-	children := make([]layout.Widget, 0, 3)
+	children := make([]layout.Widget, 0, 2*len(buttons))
 	for _, btn := range buttons {
 		btn := btn
 		children = append(children,
@@ -0,0 +1,203 @@
+package theme
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"image"
+	"runtime/metrics"
+	rtrace "runtime/trace"
+	"slices"
+	"strings"
+	"time"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/mem"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/font"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+)
+
+const (
+	// How many frames the frame time statistics cover
+	hudFrames = 120
+	// How many areas to display, slowest first
+	hudAreas = 8
+	// How often to update statistics that are expensive to collect or that would be unreadable if they changed
+	// every frame
+	hudInterval = 500 * time.Millisecond
+)
+
+// PerformanceHUD is an overlay that displays statistics about the performance of gotraceui itself, such as frame
+// times, the amount of ops, cache hit rates, and heap usage. Components can measure the time spent in them by
+// calling StartRegion, which makes it easier to find the component responsible for slow frames.
+type PerformanceHUD struct {
+	Enabled bool
+
+	frameStart time.Time
+	frameTimes [hudFrames]time.Duration
+	numFrames  int
+	opsLen     int
+
+	// The areas measured in the current frame, and the slowest areas of the previous frame.
+	areas     []hudArea
+	prevAreas []hudArea
+
+	cacheHits, cacheMisses int
+
+	// Statistics that get updated every hudInterval
+	lastUpdate  time.Time
+	hitRate     float64
+	heapObjects uint64
+	heapGoal    uint64
+	samples     [2]metrics.Sample
+
+	text strings.Builder
+}
+
+type hudArea struct {
+	kind, name string
+	d          time.Duration
+}
+
+// HUDRegion is a region of time started by PerformanceHUD.StartRegion.
+type HUDRegion struct {
+	hud        *PerformanceHUD
+	kind, name string
+	start      time.Time
+}
+
+// StartRegion starts measuring the time spent in an area of the window, such as a tab or a panel. The kind describes
+// the type of area, such as "panel", and the name identifies the area, such as a panel's title. Regions of the same
+// kind and name get combined.
+func (hud *PerformanceHUD) StartRegion(kind, name string) HUDRegion {
+	if !hud.Enabled {
+		return HUDRegion{}
+	}
+	return HUDRegion{hud: hud, kind: kind, name: name, start: time.Now()}
+}
+
+// End ends the region.
+func (r HUDRegion) End() {
+	if r.hud == nil {
+		return
+	}
+	d := time.Since(r.start)
+	for i := range r.hud.areas {
+		if r.hud.areas[i].kind == r.kind && r.hud.areas[i].name == r.name {
+			r.hud.areas[i].d += d
+			return
+		}
+	}
+	r.hud.areas = append(r.hud.areas, hudArea{r.kind, r.name, d})
+}
+
+// RecordCacheLookup records whether a cached widget could replay its previous frame (hit) or had to be laid out
+// again (miss).
+func (hud *PerformanceHUD) RecordCacheLookup(hit bool) {
+	if !hud.Enabled {
+		return
+	}
+	if hit {
+		hud.cacheHits++
+	} else {
+		hud.cacheMisses++
+	}
+}
+
+func (hud *PerformanceHUD) startFrame() {
+	if !hud.Enabled {
+		return
+	}
+	hud.frameStart = time.Now()
+	hud.areas = hud.areas[:0]
+}
+
+func (hud *PerformanceHUD) endFrame(ops *op.Ops) {
+	if !hud.Enabled || hud.frameStart.IsZero() {
+		return
+	}
+	hud.frameTimes[hud.numFrames%hudFrames] = time.Since(hud.frameStart)
+	hud.numFrames++
+	hud.opsLen = mem.OpsLen(ops)
+
+	slices.SortFunc(hud.areas, func(a, b hudArea) int {
+		return cmp.Compare(b.d, a.d)
+	})
+	hud.areas, hud.prevAreas = hud.prevAreas[:0], hud.areas
+}
+
+func (hud *PerformanceHUD) update(now time.Time) {
+	if now.Sub(hud.lastUpdate) < hudInterval {
+		return
+	}
+	hud.lastUpdate = now
+
+	if n := hud.cacheHits + hud.cacheMisses; n > 0 {
+		hud.hitRate = float64(hud.cacheHits) / float64(n)
+	} else {
+		hud.hitRate = 0
+	}
+	hud.cacheHits = 0
+	hud.cacheMisses = 0
+
+	hud.samples[0].Name = "/memory/classes/heap/objects:bytes"
+	hud.samples[1].Name = "/gc/heap/goal:bytes"
+	metrics.Read(hud.samples[:])
+	hud.heapObjects = hud.samples[0].Value.Uint64()
+	hud.heapGoal = hud.samples[1].Value.Uint64()
+}
+
+// Layout displays the HUD in the top right corner of the window. It is called by the window after laying out its
+// contents.
+func (hud *PerformanceHUD) Layout(win *Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "theme.PerformanceHUD.Layout").End()
+
+	if !hud.Enabled {
+		return layout.Dimensions{}
+	}
+	hud.update(gtx.Now)
+
+	var last, total, worst time.Duration
+	n := min(hud.numFrames, hudFrames)
+	if n > 0 {
+		last = hud.frameTimes[(hud.numFrames-1)%hudFrames]
+	}
+	for _, d := range hud.frameTimes[:n] {
+		total += d
+		worst = max(worst, d)
+	}
+	var avg time.Duration
+	if n > 0 {
+		avg = total / time.Duration(n)
+	}
+
+	hud.text.Reset()
+	fmt.Fprintf(&hud.text, "frame %d: %s, avg %s, max %s\n", win.Frame, last.Round(time.Microsecond), avg.Round(time.Microsecond), worst.Round(time.Microsecond))
+	fmt.Fprintf(&hud.text, "ops: %.1f KiB\n", float64(hud.opsLen)/1024)
+	fmt.Fprintf(&hud.text, "cache hit rate: %.1f%%\n", hud.hitRate*100)
+	fmt.Fprintf(&hud.text, "heap: %.1f MiB, goal %.1f MiB", float64(hud.heapObjects)/1024/1024, float64(hud.heapGoal)/1024/1024)
+	for _, area := range hud.prevAreas[:min(len(hud.prevAreas), hudAreas)] {
+		fmt.Fprintf(&hud.text, "\n%s %q: %s", area.kind, area.name, area.d.Round(time.Microsecond))
+	}
+
+	gtx.Constraints.Min = image.Point{}
+	m := op.Record(gtx.Ops)
+	dims := layout.UniformInset(4).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		f := font.Font{Typeface: win.Theme.MonospaceTypeface}
+		return widget.Label{}.Layout(gtx, win.Theme.Shaper, f, win.Theme.TextSize, hud.text.String(), win.ColorMaterial(gtx, oklch(100, 0, 0)))
+	})
+	call := m.Stop()
+
+	defer op.Offset(image.Pt(gtx.Constraints.Max.X-dims.Size.X, 0)).Push(gtx.Ops).Pop()
+	defer clip.Rect{Max: dims.Size}.Push(gtx.Ops).Pop()
+	Fill(win, gtx.Ops, oklcha(0, 0, 0, 0.75))
+	call.Add(gtx.Ops)
+
+	// Keep the statistics current even if nothing else causes new frames.
+	op.InvalidateOp{At: gtx.Now.Add(hudInterval)}.Add(gtx.Ops)
+
+	return dims
+}
@@ -0,0 +1,174 @@
+package theme
+
+import (
+	"context"
+	"image"
+	rtrace "runtime/trace"
+
+	"honnef.co/go/gotraceui/clip"
+	"honnef.co/go/gotraceui/color"
+	"honnef.co/go/gotraceui/gesture"
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/font"
+	"gioui.org/io/pointer"
+	"gioui.org/unit"
+	"gioui.org/x/styledtext"
+)
+
+// RichTextState is the state of a RichTextStyle, holding parsed rich text and the state of its links.
+type RichTextState struct {
+	blocks []widget.RichTextBlock
+	// Clicks for link spans, indexed by block and span.
+	clicks  map[[2]int]*gesture.Click
+	clicked []string
+	list    layout.List
+	styles  []styledtext.SpanStyle
+}
+
+// SetMarkdown replaces the text with the parsed Markdown s. See widget.ParseMarkdown for the supported syntax.
+func (rt *RichTextState) SetMarkdown(s string) {
+	rt.blocks = widget.ParseMarkdown(s)
+	clear(rt.clicks)
+	rt.list.Position = layout.Position{}
+}
+
+func (rt *RichTextState) Blocks() []widget.RichTextBlock { return rt.blocks }
+
+// Update processes clicks on links and returns the targets of clicked links.
+func (rt *RichTextState) Update(gtx layout.Context) []string {
+	rt.clicked = rt.clicked[:0]
+	for idx, click := range rt.clicks {
+		for _, ev := range click.Update(gtx.Queue) {
+			if ev.Kind == gesture.KindClick && ev.Button == pointer.ButtonPrimary {
+				rt.clicked = append(rt.clicked, rt.blocks[idx[0]].Spans[idx[1]].Link)
+			}
+		}
+	}
+	return rt.clicked
+}
+
+func (rt *RichTextState) click(block, span int) *gesture.Click {
+	if rt.clicks == nil {
+		rt.clicks = make(map[[2]int]*gesture.Click)
+	}
+	c, ok := rt.clicks[[2]int{block, span}]
+	if !ok {
+		c = new(gesture.Click)
+		rt.clicks[[2]int{block, span}] = c
+	}
+	return c
+}
+
+// RichTextStyle displays rich text, such as documentation, in a vertically scrollable list. Links are displayed, but
+// it is up to the user to act on clicks, by calling RichTextState.Update before RichTextStyle.Layout.
+type RichTextStyle struct {
+	State *RichTextState
+
+	TextSize       unit.Sp
+	TextColor      color.Oklch
+	LinkColor      color.Oklch
+	CodeBackground color.Oklch
+	// Spacing is the vertical space between blocks.
+	Spacing unit.Dp
+}
+
+func RichText(th *Theme, state *RichTextState) RichTextStyle {
+	return RichTextStyle{
+		State:          state,
+		TextSize:       th.TextSize,
+		TextColor:      th.Palette.Foreground,
+		LinkColor:      th.Palette.Link,
+		CodeBackground: oklch(95, 0, 0),
+		Spacing:        8,
+	}
+}
+
+func (rts RichTextStyle) layoutSpans(win *Window, gtx layout.Context, blockIdx int, spans []widget.RichTextSpan, size unit.Sp, bold bool) layout.Dimensions {
+	rt := rts.State
+	rt.styles = rt.styles[:0]
+	for _, s := range spans {
+		style := styledtext.SpanStyle{
+			Content: s.Text,
+			Size:    size,
+			Color:   win.ConvertColor(rts.TextColor),
+		}
+		if bold || s.Bold {
+			style.Font.Weight = font.Bold
+		}
+		if s.Code {
+			style.Font.Typeface = win.Theme.MonospaceTypeface
+		}
+		if s.Link != "" {
+			style.Color = win.ConvertColor(rts.LinkColor)
+		}
+		rt.styles = append(rt.styles, style)
+	}
+
+	gtx.Constraints.Min.X = 0
+	return styledtext.Text(win.Theme.Shaper, rt.styles...).Layout(gtx, func(gtx layout.Context, i int, dims layout.Dimensions) {
+		if spans[i].Link == "" {
+			return
+		}
+		defer clip.Rect{Max: dims.Size}.Push(gtx.Ops).Pop()
+		rt.click(blockIdx, i).Add(gtx.Ops)
+		pointer.CursorPointer.Add(gtx.Ops)
+	})
+}
+
+func (rts RichTextStyle) layoutBlock(win *Window, gtx layout.Context, idx int) layout.Dimensions {
+	block := &rts.State.blocks[idx]
+	switch block.Kind {
+	case widget.RichTextHeading:
+		// Scale headings down with their level, but never below the size of regular text.
+		scale := max(1, 1.6-0.2*float32(block.Level-1))
+		return rts.layoutSpans(win, gtx, idx, block.Spans, rts.TextSize*unit.Sp(scale), true)
+
+	case widget.RichTextListItem:
+		bullet := gtx.Dp(unit.Dp(rts.TextSize))
+		return layout.Flex{Axis: layout.Horizontal}.Layout(gtx,
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				gtx.Constraints.Min = image.Point{}
+				dims := widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, font.Font{}, rts.TextSize, "•", win.ColorMaterial(gtx, rts.TextColor))
+				dims.Size.X = bullet
+				return dims
+			}),
+			layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+				return rts.layoutSpans(win, gtx, idx, block.Spans, rts.TextSize, false)
+			}),
+		)
+
+	case widget.RichTextCode:
+		return Background{Color: rts.CodeBackground}.Layout(win, gtx, func(win *Window, gtx layout.Context) layout.Dimensions {
+			return layout.UniformInset(4).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				gtx.Constraints.Min = image.Point{}
+				f := font.Font{Typeface: win.Theme.MonospaceTypeface}
+				return widget.Label{}.Layout(gtx, win.Theme.Shaper, f, rts.TextSize, block.Code, win.ColorMaterial(gtx, rts.TextColor))
+			})
+		})
+
+	default:
+		return rts.layoutSpans(win, gtx, idx, block.Spans, rts.TextSize, false)
+	}
+}
+
+func (rts RichTextStyle) Layout(win *Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "theme.RichTextStyle.Layout").End()
+
+	rt := rts.State
+	rt.list.Axis = layout.Vertical
+	spacing := gtx.Dp(rts.Spacing)
+	return rt.list.Layout(gtx, len(rt.blocks), func(gtx layout.Context, i int) layout.Dimensions {
+		dims := rts.layoutBlock(win, gtx, i)
+		if i < len(rt.blocks)-1 {
+			// List items are spaced more tightly than other blocks.
+			if rt.blocks[i].Kind == widget.RichTextListItem && rt.blocks[i+1].Kind == widget.RichTextListItem {
+				dims.Size.Y += spacing / 4
+			} else {
+				dims.Size.Y += spacing
+			}
+		}
+		return dims
+	})
+}
@@ -46,11 +46,22 @@ type ScrollIndicatorStyle struct {
 	Color, HoverColor color.Oklch
 }
 
+// A ScrollMark highlights a part of the scroll track, for example to show where in the content something of interest
+// is. Start and End are in the same range as the viewport, [0,1].
+type ScrollMark struct {
+	Start, End float32
+	// Intensity, in the range [0,1], scales the opacity of the mark.
+	Intensity float32
+}
+
 // ScrollbarStyle configures the presentation of a scrollbar.
 type ScrollbarStyle struct {
 	Scrollbar *widget.Scrollbar
 	Track     ScrollTrackStyle
 	Indicator ScrollIndicatorStyle
+	// Marks are drawn on top of the track, below the indicator, in MarkColor.
+	Marks     []ScrollMark
+	MarkColor color.Oklch
 }
 
 // Scrollbar configures the presentation of a scrollbar using the provided
@@ -140,6 +151,16 @@ func (s ScrollbarStyle) layout(win *Window, gtx layout.Context, axis layout.Axis
 			s.Scrollbar.AddTrack(gtx.Ops)
 
 			FillShape(win, gtx.Ops, s.Track.Color, clip.Rect(area).Op())
+			trackLen := axis.Convert(area.Max).X
+			minor := axis.Convert(area.Max).Y
+			for _, m := range s.Marks {
+				start := int(math.Round(float64(m.Start) * float64(trackLen)))
+				end := max(int(math.Round(float64(m.End)*float64(trackLen))), start+1)
+				c := s.MarkColor
+				c.A *= min(max(m.Intensity, 0), 1)
+				r := image.Rectangle{Min: axis.Convert(image.Pt(start, 0)), Max: axis.Convert(image.Pt(end, minor))}
+				FillShape(win, gtx.Ops, c, clip.Rect(r).Op())
+			}
 			return layout.Dimensions{}
 		}),
 		layout.Stacked(func(gtx layout.Context) layout.Dimensions {
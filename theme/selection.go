@@ -0,0 +1,42 @@
+package theme
+
+import "sync"
+
+// Selection holds the object that the user selected, such as a goroutine. Components highlight the selected object
+// wherever they display it, which links otherwise independent components together. Windows that display the same
+// data share a Selection.
+type Selection struct {
+	mu  sync.RWMutex
+	obj any
+	// gen is incremented whenever the selection changes, allowing windows to redraw when another window changed it.
+	gen uint64
+}
+
+// Get returns the selected object, or nil.
+func (s *Selection) Get() any {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.obj
+}
+
+// Set selects obj, replacing the previous selection. Setting nil clears the selection.
+func (s *Selection) Set(obj any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.obj != obj {
+		s.obj = obj
+		s.gen++
+	}
+}
+
+// Is reports whether obj is the selected object. It always returns false for nil.
+func (s *Selection) Is(obj any) bool {
+	return obj != nil && s.Get() == obj
+}
+
+// Generation returns a number that changes every time the selection changes.
+func (s *Selection) Generation() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.gen
+}
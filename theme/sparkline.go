@@ -0,0 +1,135 @@
+package theme
+
+import (
+	"context"
+	"image"
+	rtrace "runtime/trace"
+
+	"honnef.co/go/gotraceui/clip"
+	"honnef.co/go/gotraceui/color"
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/f32"
+	"gioui.org/font"
+	"gioui.org/unit"
+)
+
+type SparklineKind uint8
+
+const (
+	SparklineLine SparklineKind = iota
+	SparklineBar
+)
+
+// SparklineStyle draws a tiny chart without axes or labels, small enough to be displayed inline, for example in table
+// cells. It uses all available width.
+type SparklineStyle struct {
+	Values []float64
+	Kind   SparklineKind
+	// Max is the value that maps to the top of the sparkline. If zero, the largest value is used.
+	Max float64
+	// Height is the height of the sparkline. If zero, it matches the height of a line of text, so that sparklines
+	// align with text in neighbouring table cells.
+	Height    unit.Dp
+	LineWidth unit.Dp
+	Color     color.Oklch
+	// Baseline is the color of the line drawn at zero. It is only used if its alpha is non-zero.
+	Baseline color.Oklch
+	// Highlights, if not nil, has one entry per value and selects the bars to draw in HighlightColor. It is only used
+	// by bar sparklines.
+	Highlights     []bool
+	HighlightColor color.Oklch
+}
+
+func Sparkline(th *Theme, values []float64) SparklineStyle {
+	return SparklineStyle{
+		Values:    values,
+		LineWidth: 1,
+		Color:     oklch(55.58, 0.13, 256.1),
+		Baseline:  th.Palette.Border,
+		// A red that stands out against the default color.
+		HighlightColor: oklch(62.8, 0.2, 29.2),
+	}
+}
+
+func (ss SparklineStyle) Layout(win *Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "theme.SparklineStyle.Layout").End()
+
+	var height int
+	if ss.Height == 0 {
+		height = win.TextDimensions(gtx, widget.Label{MaxLines: 1}, font.Font{}, win.Theme.TextSize, "0").Size.Y
+	} else {
+		height = gtx.Dp(ss.Height)
+	}
+	size := gtx.Constraints.Constrain(image.Pt(gtx.Constraints.Max.X, height))
+	if size.X == 0 || size.Y == 0 || len(ss.Values) == 0 {
+		return layout.Dimensions{Size: size}
+	}
+
+	maxV := ss.Max
+	if maxV == 0 {
+		for _, v := range ss.Values {
+			maxV = max(maxV, v)
+		}
+	}
+	if maxV <= 0 {
+		maxV = 1
+	}
+	lw := float32(gtx.Dp(ss.LineWidth))
+	h := float32(size.Y)
+	// y returns the vertical position of v, leaving room for the line width so that lines at the extremes aren't cut
+	// off.
+	y := func(v float64) float32 {
+		f := float32(min(max(v/maxV, 0), 1))
+		return h - lw/2 - f*(h-lw)
+	}
+
+	if ss.Baseline.A != 0 {
+		FillShape(win, gtx.Ops, ss.Baseline, clip.Rect{Min: image.Pt(0, size.Y-1), Max: size}.Op())
+	}
+
+	step := float32(size.X) / float32(len(ss.Values))
+	switch ss.Kind {
+	case SparklineBar:
+		bars := func(highlighted bool, c color.Oklch) {
+			var p clip.Path
+			p.Begin(gtx.Ops)
+			for i, v := range ss.Values {
+				if v <= 0 || (i < len(ss.Highlights) && ss.Highlights[i]) != highlighted {
+					continue
+				}
+				// Leave a gap between bars if there is enough space for it.
+				gap := float32(0)
+				if step >= 3 {
+					gap = 1
+				}
+				r := clip.FRect{
+					Min: f32.Pt(float32(i)*step, min(y(v), h-1)),
+					Max: f32.Pt(float32(i+1)*step-gap, h),
+				}
+				r.IntoPath(&p)
+			}
+			FillShape(win, gtx.Ops, c, clip.Outline{Path: p.End()}.Op())
+		}
+		bars(false, ss.Color)
+		if ss.Highlights != nil {
+			bars(true, ss.HighlightColor)
+		}
+	case SparklineLine:
+		var p clip.Path
+		p.Begin(gtx.Ops)
+		for i, v := range ss.Values {
+			// Place points in the middle of their buckets.
+			pt := f32.Pt((float32(i)+0.5)*step, y(v))
+			if i == 0 {
+				p.MoveTo(pt)
+			} else {
+				p.LineTo(pt)
+			}
+		}
+		FillShape(win, gtx.Ops, ss.Color, clip.Stroke{Path: p.End(), Width: lw}.Op())
+	}
+
+	return layout.Dimensions{Size: size}
+}
@@ -0,0 +1,139 @@
+package theme
+
+import (
+	"context"
+	"image"
+	"math"
+	rtrace "runtime/trace"
+	"time"
+
+	"honnef.co/go/gotraceui/color"
+	"honnef.co/go/gotraceui/layout"
+
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/unit"
+)
+
+// SpinnerStyle draws an animated spinner, indicating that work is in progress.
+type SpinnerStyle struct {
+	Size  unit.Dp
+	Color color.Oklch
+}
+
+func Spinner(th *Theme) SpinnerStyle {
+	return SpinnerStyle{
+		Size:  16,
+		Color: th.Palette.Foreground,
+	}
+}
+
+func (ss SpinnerStyle) Layout(win *Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "theme.SpinnerStyle.Layout").End()
+
+	const (
+		numDots = 8
+		period  = time.Second
+	)
+
+	size := gtx.Dp(ss.Size)
+	radius := float64(size) / 2
+	dotRadius := max(1, float64(size)/8)
+	// The dot that is currently the most opaque. The other dots trail behind it.
+	head := int(gtx.Now.UnixNano()/int64(period/numDots)) % numDots
+
+	for i := 0; i < numDots; i++ {
+		angle := 2 * math.Pi * float64(i) / numDots
+		x := radius + (radius-dotRadius)*math.Sin(angle)
+		y := radius - (radius-dotRadius)*math.Cos(angle)
+		age := (head - i + numDots) % numDots
+		c := ss.Color
+		c.A *= 1 - float32(age)/numDots
+
+		rect := image.Rect(
+			int(math.Round(x-dotRadius)), int(math.Round(y-dotRadius)),
+			int(math.Round(x+dotRadius)), int(math.Round(y+dotRadius)),
+		)
+		FillShape(win, gtx.Ops, c, clip.Ellipse(rect).Op(gtx.Ops))
+	}
+
+	op.InvalidateOp{At: gtx.Now.Add(period / numDots)}.Add(gtx.Ops)
+	return layout.Dimensions{Size: image.Pt(size, size)}
+}
+
+// LoadingStyle displays a spinner next to a label describing the work in progress, such as "Computing statistics…".
+type LoadingStyle struct {
+	Spinner SpinnerStyle
+	Label   LabelStyle
+}
+
+func Loading(th *Theme, label string) LoadingStyle {
+	return LoadingStyle{
+		Spinner: Spinner(th),
+		Label:   Label(th, label),
+	}
+}
+
+func (ls LoadingStyle) Layout(win *Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "theme.LoadingStyle.Layout").End()
+
+	ls.Spinner.Size = unit.Dp(win.Theme.TextSize)
+	return layout.Rigids(gtx, layout.Horizontal,
+		Dumb(win, ls.Spinner.Layout),
+		layout.Spacer{Width: 5}.Layout,
+		Dumb(win, ls.Label.Layout),
+	)
+}
+
+// SkeletonStyle draws placeholder rows for content that is still being computed, such as the rows of a table. The
+// rows pulse to indicate that work is in progress.
+type SkeletonStyle struct {
+	Rows      int
+	RowHeight unit.Dp
+	Gap       unit.Dp
+	Color     color.Oklch
+}
+
+func Skeleton(th *Theme, rows int) SkeletonStyle {
+	return SkeletonStyle{
+		Rows:      rows,
+		RowHeight: unit.Dp(th.TextSize),
+		Gap:       5,
+		Color:     th.Palette.Placeholder,
+	}
+}
+
+func (ss SkeletonStyle) Layout(win *Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "theme.SkeletonStyle.Layout").End()
+
+	const (
+		period = 1500 * time.Millisecond
+		// The pulse doesn't need to be smooth, so we don't redraw any more often than this.
+		frame = 50 * time.Millisecond
+	)
+	// Vary the rows' widths so the placeholder looks like content, but deterministically, so that rows don't jump
+	// around between frames.
+	widths := [...]float32{1, 0.8, 0.9, 0.6, 0.95, 0.7}
+
+	now := gtx.Now.Truncate(frame)
+	phase := float64(now.UnixNano()%int64(period)) / float64(period)
+	c := ss.Color
+	c.L *= 0.95 + 0.05*float32(math.Sin(2*math.Pi*phase))
+
+	rowHeight := gtx.Dp(ss.RowHeight)
+	gap := gtx.Dp(ss.Gap)
+	width := gtx.Constraints.Max.X
+	y := 0
+	for i := 0; i < ss.Rows; i++ {
+		if i > 0 {
+			y += gap
+		}
+		w := int(float32(width) * widths[i%len(widths)])
+		r := gtx.Dp(2)
+		FillShape(win, gtx.Ops, c, clip.UniformRRect(image.Rect(0, y, w, y+rowHeight), r).Op(gtx.Ops))
+		y += rowHeight
+	}
+
+	op.InvalidateOp{At: now.Add(frame)}.Add(gtx.Ops)
+	return layout.Dimensions{Size: gtx.Constraints.Constrain(image.Pt(width, y))}
+}
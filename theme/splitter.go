@@ -0,0 +1,239 @@
+package theme
+
+import (
+	"context"
+	"image"
+	rtrace "runtime/trace"
+
+	"honnef.co/go/gotraceui/color"
+	"honnef.co/go/gotraceui/gesture"
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/f32"
+	"gioui.org/io/pointer"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/unit"
+)
+
+// SplitterCollapse describes which pane of a splitter, if any, is collapsed.
+type SplitterCollapse uint8
+
+const (
+	SplitterCollapseNone SplitterCollapse = iota
+	SplitterCollapseFirst
+	SplitterCollapseSecond
+)
+
+// SplitterState is the state of a SplitterStyle. Ratio and Collapsed can be stored and restored to persist the layout.
+type SplitterState struct {
+	// Axis is the axis along which the panes are laid out. A horizontal splitter has its panes side by side.
+	Axis layout.Axis
+	// Ratio is the fraction of the space, minus the handle, occupied by the first pane.
+	Ratio float32
+	// MinSize1 and MinSize2 are the minimum sizes of the two panes, unless they are collapsed.
+	MinSize1, MinSize2 unit.Dp
+	// Collapsed collapses one of the panes, giving all space to the other one.
+	Collapsed SplitterCollapse
+
+	drag           gesture.Drag
+	collapseFirst  widget.PrimaryClickable
+	collapseSecond widget.PrimaryClickable
+	// The size of the first pane, in pixels, as last computed by Layout.
+	size1 int
+}
+
+// Update processes clicks on the collapse buttons.
+func (ss *SplitterState) Update(gtx layout.Context) {
+	for ss.collapseFirst.Clicked(gtx) {
+		if ss.Collapsed == SplitterCollapseSecond {
+			ss.Collapsed = SplitterCollapseNone
+		} else {
+			ss.Collapsed = SplitterCollapseFirst
+		}
+	}
+	for ss.collapseSecond.Clicked(gtx) {
+		if ss.Collapsed == SplitterCollapseFirst {
+			ss.Collapsed = SplitterCollapseNone
+		} else {
+			ss.Collapsed = SplitterCollapseSecond
+		}
+	}
+}
+
+// SplitterStyle lays out two panes, separated by a handle that can be dragged to resize them.
+type SplitterStyle struct {
+	State *SplitterState
+	// CollapseButtons controls whether the handle has buttons for collapsing the panes.
+	CollapseButtons bool
+
+	HandleSize  unit.Dp
+	HandleColor color.Oklch
+	ButtonColor color.Oklch
+}
+
+func Splitter(th *Theme, state *SplitterState) SplitterStyle {
+	return SplitterStyle{
+		State:       state,
+		HandleSize:  5,
+		HandleColor: th.Palette.Border,
+		ButtonColor: th.Palette.Foreground,
+	}
+}
+
+// Layout lays out the two panes. The panes must be able to gracefully handle changes to their constraints, so that
+// resizing them is smooth.
+func (ss SplitterStyle) Layout(win *Window, gtx layout.Context, w1, w2 Widget) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "theme.SplitterStyle.Layout").End()
+
+	st := ss.State
+	st.Update(gtx)
+
+	handleSize := gtx.Dp(ss.HandleSize)
+	if ss.CollapseButtons {
+		handleSize = max(handleSize, gtx.Dp(10))
+	}
+	total := layout.Main(st.Axis, &gtx.Constraints.Max)
+	avail := max(0, *total-handleSize)
+
+	if st.Ratio <= 0 || st.Ratio >= 1 {
+		st.Ratio = 0.5
+	}
+
+	// The events' positions are relative to the handle as it was laid out in the previous frame, where the first pane
+	// ended at st.size1. Each position already includes the movements of the preceding events, so only the last one
+	// matters.
+	var (
+		dragged bool
+		pos     float32
+	)
+	for _, ev := range st.drag.Update(gtx.Metric, gtx, gesture.Axis(st.Axis)) {
+		if ev.Kind != pointer.Drag {
+			continue
+		}
+		dragged = true
+		pos = ev.Position.X
+		if st.Axis == layout.Vertical {
+			pos = ev.Position.Y
+		}
+	}
+	if dragged {
+		// Dragging the handle uncollapses the panes, starting from where the handle is displayed.
+		switch st.Collapsed {
+		case SplitterCollapseFirst:
+			st.size1 = 0
+		case SplitterCollapseSecond:
+			st.size1 = avail
+		}
+		st.Collapsed = SplitterCollapseNone
+		if avail > 0 {
+			st.Ratio = min(max(float32(st.size1+int(pos))/float32(avail), 0.001), 0.999)
+		}
+	}
+
+	var size1 int
+	switch st.Collapsed {
+	case SplitterCollapseFirst:
+		size1 = 0
+	case SplitterCollapseSecond:
+		size1 = avail
+	default:
+		size1 = int(st.Ratio * float32(avail))
+		min1, min2 := gtx.Dp(st.MinSize1), gtx.Dp(st.MinSize2)
+		if min1+min2 > avail {
+			// There isn't enough space for both minimums, so split the space proportionally.
+			if min1+min2 > 0 {
+				size1 = avail * min1 / (min1 + min2)
+			}
+		} else {
+			size1 = min(max(size1, min1), avail-min2)
+		}
+	}
+	st.size1 = size1
+	size2 := avail - size1
+
+	cross := *layout.Cross(st.Axis, &gtx.Constraints.Max)
+	pt := func(main, cross int) image.Point {
+		return st.Axis.Convert(image.Pt(main, cross))
+	}
+
+	// First pane
+	if size1 > 0 {
+		gtx := gtx
+		gtx.Constraints.Min = pt(size1, *layout.Cross(st.Axis, &gtx.Constraints.Min))
+		gtx.Constraints.Max = pt(size1, cross)
+		stack := clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops)
+		w1(win, gtx)
+		stack.Pop()
+	}
+
+	// Handle
+	func() {
+		defer op.Offset(pt(size1, 0)).Push(gtx.Ops).Pop()
+		size := pt(handleSize, cross)
+		defer clip.Rect{Max: size}.Push(gtx.Ops).Pop()
+		Fill(win, gtx.Ops, ss.HandleColor)
+
+		st.drag.Add(gtx.Ops)
+		if st.Axis == layout.Horizontal {
+			pointer.CursorColResize.Add(gtx.Ops)
+		} else {
+			pointer.CursorRowResize.Add(gtx.Ops)
+		}
+
+		if ss.CollapseButtons {
+			// Center the two buttons on the handle.
+			btn := handleSize
+			off := cross/2 - btn
+			if st.Collapsed != SplitterCollapseFirst {
+				ss.layoutCollapseButton(win, gtx, pt(0, off), btn, true, &st.collapseFirst)
+			}
+			if st.Collapsed != SplitterCollapseSecond {
+				ss.layoutCollapseButton(win, gtx, pt(0, off+btn), btn, false, &st.collapseSecond)
+			}
+		}
+	}()
+
+	// Second pane
+	if size2 > 0 {
+		defer op.Offset(pt(size1+handleSize, 0)).Push(gtx.Ops).Pop()
+		gtx := gtx
+		gtx.Constraints.Min = pt(size2, *layout.Cross(st.Axis, &gtx.Constraints.Min))
+		gtx.Constraints.Max = pt(size2, cross)
+		defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+		w2(win, gtx)
+	}
+
+	return layout.Dimensions{Size: pt(*total, cross)}
+}
+
+// layoutCollapseButton draws a triangle pointing towards the pane that it collapses. We draw the triangle ourselves
+// because not all fonts have the necessary glyphs.
+func (ss SplitterStyle) layoutCollapseButton(win *Window, gtx layout.Context, at image.Point, size int, first bool, click *widget.PrimaryClickable) {
+	defer op.Offset(at).Push(gtx.Ops).Pop()
+	gtx.Constraints = layout.Exact(image.Pt(size, size))
+	click.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		pointer.CursorPointer.Add(gtx.Ops)
+		s := float32(size)
+		// Points for a triangle pointing left, i.e. towards the first pane of a horizontal splitter.
+		pts := [3]f32.Point{{X: s * 0.25, Y: s * 0.5}, {X: s * 0.75, Y: s * 0.2}, {X: s * 0.75, Y: s * 0.8}}
+		for i, p := range pts {
+			if !first {
+				p.X = s - p.X
+			}
+			if ss.State.Axis == layout.Vertical {
+				p.X, p.Y = p.Y, p.X
+			}
+			pts[i] = p
+		}
+		var p clip.Path
+		p.Begin(gtx.Ops)
+		p.MoveTo(pts[0])
+		p.LineTo(pts[1])
+		p.LineTo(pts[2])
+		p.Close()
+		FillShape(win, gtx.Ops, ss.ButtonColor, clip.Outline{Path: p.End()}.Op())
+		return layout.Dimensions{Size: gtx.Constraints.Min}
+	})
+}
@@ -0,0 +1,47 @@
+package theme
+
+import (
+	"image"
+	"testing"
+	"time"
+
+	"honnef.co/go/gotraceui/layout"
+
+	"gioui.org/f32"
+	"gioui.org/io/pointer"
+	"gioui.org/io/router"
+	"gioui.org/op"
+)
+
+func TestSplitterDragSeveralEventsPerFrame(t *testing.T) {
+	var r router.Router
+	win := NewWindow(nil)
+	st := &SplitterState{Axis: layout.Horizontal, Ratio: 0.5}
+	empty := func(win *Window, gtx layout.Context) layout.Dimensions { return layout.Dimensions{} }
+	frame := func() {
+		ops := new(op.Ops)
+		gtx := layout.Context{Ops: ops, Queue: &r, Now: time.Now(), Constraints: layout.Exact(image.Pt(105, 50))}
+		Splitter(win.Theme, st).Layout(win, gtx, empty, empty)
+		r.Frame(ops)
+	}
+	mouse := func(kind pointer.Kind, x float32) {
+		r.Queue(pointer.Event{Kind: kind, Source: pointer.Mouse, Buttons: pointer.ButtonPrimary, Position: f32.Pt(x, 25)})
+	}
+
+	frame()
+	if st.size1 != 50 {
+		t.Fatalf("got first pane of size %d, want 50", st.size1)
+	}
+	mouse(pointer.Press, 52)
+	frame()
+	// Several movements within a single frame.
+	for x := float32(55); x <= 70; x += 5 {
+		mouse(pointer.Move, x)
+	}
+	frame()
+	frame()
+	// The handle follows the pointer.
+	if st.size1 != 70 {
+		t.Errorf("got first pane of size %d, want 70", st.size1)
+	}
+}
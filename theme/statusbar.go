@@ -0,0 +1,110 @@
+package theme
+
+import (
+	"context"
+	"image"
+	rtrace "runtime/trace"
+
+	"honnef.co/go/gotraceui/color"
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/font"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/unit"
+)
+
+// StatusSegment is a piece of information displayed in the window's status bar, such as the timestamp under the
+// cursor.
+type StatusSegment struct {
+	Text string
+	// Right places the segment on the right side of the status bar instead of the left.
+	Right bool
+}
+
+// AddStatus adds a segment to the window's status bar, if it has one. Segments are transient and only displayed for
+// the current frame, which means that components have to add them every frame for as long as they are relevant.
+func (win *Window) AddStatus(seg StatusSegment) {
+	win.status = append(win.status, seg)
+}
+
+type StatusBarStyle struct {
+	Segments []StatusSegment
+
+	Padding    unit.Dp
+	Foreground color.Oklch
+	Background color.Oklch
+	Border     color.Oklch
+}
+
+func StatusBar(th *Theme, segments []StatusSegment) StatusBarStyle {
+	return StatusBarStyle{
+		Segments:   segments,
+		Padding:    2,
+		Foreground: th.Palette.Foreground,
+		Background: th.Palette.Menu.Background,
+		Border:     th.Palette.Border,
+	}
+}
+
+// height returns the height of the status bar, which doesn't depend on its segments, so that the window can reserve
+// space for it before components have added their segments.
+func (sbs StatusBarStyle) height(win *Window, gtx layout.Context) int {
+	line := win.TextDimensions(gtx, widget.Label{MaxLines: 1}, font.Font{}, win.Theme.TextSize, "0").Size.Y
+	return line + 2*gtx.Dp(sbs.Padding) + gtx.Dp(1)
+}
+
+func (sbs StatusBarStyle) Layout(win *Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "theme.StatusBarStyle.Layout").End()
+
+	size := image.Pt(gtx.Constraints.Max.X, sbs.height(win, gtx))
+	defer clip.Rect{Max: size}.Push(gtx.Ops).Pop()
+	Fill(win, gtx.Ops, sbs.Background)
+	FillShape(win, gtx.Ops, sbs.Border, clip.Rect{Max: image.Pt(size.X, gtx.Dp(1))}.Op())
+
+	padding := gtx.Dp(sbs.Padding)
+	// Segments are separated by thin vertical lines, with some space on either side.
+	gap := gtx.Dp(6)
+	gtx.Constraints.Min = image.Point{}
+
+	label := func(gtx layout.Context, s string) (op.CallOp, layout.Dimensions) {
+		m := op.Record(gtx.Ops)
+		dims := widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, font.Font{}, win.Theme.TextSize, s, win.ColorMaterial(gtx, sbs.Foreground))
+		return m.Stop(), dims
+	}
+	separator := func(x int) {
+		FillShape(win, gtx.Ops, sbs.Border, clip.Rect{Min: image.Pt(x, gtx.Dp(1)+padding), Max: image.Pt(x+gtx.Dp(1), size.Y-padding)}.Op())
+	}
+
+	left, right := padding, size.X-padding
+	var numLeft, numRight int
+	for _, seg := range sbs.Segments {
+		call, dims := label(gtx, seg.Text)
+		var x int
+		if seg.Right {
+			if numRight > 0 {
+				right -= gap
+				separator(right)
+				right -= gap
+			}
+			right -= dims.Size.X
+			x = right
+			numRight++
+		} else {
+			if numLeft > 0 {
+				left += gap
+				separator(left)
+				left += gap
+			}
+			x = left
+			left += dims.Size.X
+			numLeft++
+		}
+		stack := op.Offset(image.Pt(x, gtx.Dp(1)+padding)).Push(gtx.Ops)
+		call.Add(gtx.Ops)
+		stack.Pop()
+	}
+
+	return layout.Dimensions{Size: size}
+}
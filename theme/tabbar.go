@@ -0,0 +1,305 @@
+package theme
+
+import (
+	"context"
+	"image"
+	rtrace "runtime/trace"
+	"slices"
+
+	"honnef.co/go/gotraceui/color"
+	"honnef.co/go/gotraceui/gesture"
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/font"
+	"gioui.org/io/pointer"
+	"gioui.org/io/semantic"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+)
+
+type TabBarEventKind uint8
+
+const (
+	// The tab at Index was selected.
+	TabSelected TabBarEventKind = iota
+	// The tab at Index should be closed.
+	TabClosed
+	// The tab at Index was dragged to position To.
+	TabMoved
+)
+
+type TabBarEvent struct {
+	Kind  TabBarEventKind
+	Index int
+	To    int
+}
+
+type tabBarTab struct {
+	click widget.Clickable
+	close widget.PrimaryClickable
+}
+
+// TabBarState is the state of a TabBarStyle. TabBarState.Update has to be called every frame, before TabBarStyle.Layout,
+// and its events have to be applied to the list of tabs, i.e. closed tabs have to be removed and moved tabs have to be
+// moved.
+type TabBarState struct {
+	// Current is the index of the selected tab.
+	Current int
+
+	tabs []tabBarTab
+	list layout.List
+	drag gesture.Drag
+	// The number of tabs and their widths in the previous frame
+	numTabs int
+	widths  []int
+
+	dragging struct {
+		active bool
+		index  int
+	}
+}
+
+// tabAt returns the index of the tab at the horizontal position x, which is relative to the start of the tab bar.
+func (tb *TabBarState) tabAt(x float32) int {
+	if len(tb.widths) == 0 {
+		return -1
+	}
+	pos := -float32(tb.list.Position.Offset)
+	for i := tb.list.Position.First; i < len(tb.widths); i++ {
+		pos += float32(tb.widths[i])
+		if x < pos {
+			return i
+		}
+	}
+	return len(tb.widths) - 1
+}
+
+// Update processes input events and returns the resulting events. The events have to be applied in order: the
+// indices of each event refer to the tabs as they are after applying the preceding events. In particular, tabs closed
+// in the same frame are reported from last to first.
+func (tb *TabBarState) Update(gtx layout.Context) []TabBarEvent {
+	var events []TabBarEvent
+
+	// Process dragging first. It moves the tabs' states along with the tabs, so the indices of the clicks below
+	// refer to the tabs' new positions.
+	for _, ev := range tb.drag.Update(gtx.Metric, gtx, gesture.Horizontal) {
+		switch ev.Kind {
+		case pointer.Press:
+			if ev.Buttons != pointer.ButtonPrimary {
+				break
+			}
+			if idx := tb.tabAt(ev.Position.X); idx != -1 {
+				tb.dragging.active = true
+				tb.dragging.index = idx
+			}
+		case pointer.Drag:
+			if !tb.dragging.active {
+				break
+			}
+			if idx := tb.tabAt(ev.Position.X); idx != -1 && idx != tb.dragging.index {
+				events = append(events, TabBarEvent{Kind: TabMoved, Index: tb.dragging.index, To: idx})
+				// Keep the moved tab selected, and the selected tab's state with it.
+				tab := tb.tabs[tb.dragging.index]
+				tb.tabs = slices.Delete(tb.tabs, tb.dragging.index, tb.dragging.index+1)
+				tb.tabs = slices.Insert(tb.tabs, idx, tab)
+				w := tb.widths[tb.dragging.index]
+				tb.widths = slices.Delete(tb.widths, tb.dragging.index, tb.dragging.index+1)
+				tb.widths = slices.Insert(tb.widths, idx, w)
+				if tb.Current == tb.dragging.index {
+					tb.Current = idx
+				} else if tb.Current > tb.dragging.index && tb.Current <= idx {
+					tb.Current--
+				} else if tb.Current < tb.dragging.index && tb.Current >= idx {
+					tb.Current++
+				}
+				tb.dragging.index = idx
+			}
+		case pointer.Release, pointer.Cancel:
+			tb.dragging.active = false
+		}
+	}
+
+	var closed []int
+	for i := range tb.tabs[:min(len(tb.tabs), tb.numTabs)] {
+		tab := &tb.tabs[i]
+		for tab.close.Clicked(gtx) {
+			closed = append(closed, i)
+		}
+		for {
+			click, ok := tab.click.Clicked(gtx)
+			if !ok {
+				break
+			}
+			switch click.Button {
+			case pointer.ButtonPrimary:
+				if tb.Current != i {
+					tb.Current = i
+					events = append(events, TabBarEvent{Kind: TabSelected, Index: i})
+				}
+			case pointer.ButtonTertiary:
+				// Middle-clicking closes tabs, like in browsers.
+				closed = append(closed, i)
+			}
+		}
+	}
+
+	return tb.closeTabs(events, closed)
+}
+
+// closeTabs removes the tabs at the given indices, which refer to the tabs before any of them were removed, and
+// appends a TabClosed event for each distinct tab to events. Tabs get removed from last to first, so that the index
+// of each event is still valid after applying the previous ones.
+func (tb *TabBarState) closeTabs(events []TabBarEvent, closed []int) []TabBarEvent {
+	slices.Sort(closed)
+	closed = slices.Compact(closed)
+	for _, idx := range slices.Backward(closed) {
+		events = append(events, TabBarEvent{Kind: TabClosed, Index: idx})
+		tb.tabs = slices.Delete(tb.tabs, idx, idx+1)
+		if idx < len(tb.widths) {
+			tb.widths = slices.Delete(tb.widths, idx, idx+1)
+		}
+		if tb.Current > idx || tb.Current == tb.numTabs-1 {
+			tb.Current = max(0, tb.Current-1)
+		}
+		if tb.dragging.active {
+			if tb.dragging.index == idx {
+				tb.dragging.active = false
+			} else if tb.dragging.index > idx {
+				tb.dragging.index--
+			}
+		}
+		tb.numTabs--
+	}
+	return events
+}
+
+// TabBarStyle displays a row of tabs. The tab bar can be scrolled if the tabs don't fit, tabs can be reordered by
+// dragging them, and they can optionally be closed.
+type TabBarStyle struct {
+	State *TabBarState
+	Tabs  []string
+	// Closable controls whether tabs have a close button.
+	Closable bool
+
+	TextColor        color.Oklch
+	HoveredColor     color.Oklch
+	ActiveLineColor  color.Oklch
+	LineColor        color.Oklch
+	BackgroundColor  color.Oklch
+	ActiveBackground color.Oklch
+}
+
+func TabBar(th *Theme, state *TabBarState, tabs []string) TabBarStyle {
+	return TabBarStyle{
+		State:            state,
+		Tabs:             tabs,
+		TextColor:        th.Palette.Foreground,
+		HoveredColor:     th.Palette.Table.HoveredRowBackground,
+		ActiveLineColor:  oklch(71.79, 0.151, 281.92),
+		LineColor:        oklch(0, 0, 0),
+		BackgroundColor:  th.Palette.Background,
+		ActiveBackground: th.Palette.Background,
+	}
+}
+
+func (tbs TabBarStyle) Layout(win *Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "theme.TabBarStyle.Layout").End()
+
+	tb := tbs.State
+	tb.numTabs = len(tbs.Tabs)
+	if len(tb.tabs) < len(tbs.Tabs) {
+		tb.tabs = slices.Grow(tb.tabs, len(tbs.Tabs))[:len(tbs.Tabs)]
+	}
+	if len(tb.widths) != len(tbs.Tabs) {
+		tb.widths = slices.Grow(tb.widths[:0], len(tbs.Tabs))[:len(tbs.Tabs)]
+	}
+	if tb.Current >= len(tbs.Tabs) {
+		tb.Current = max(0, len(tbs.Tabs)-1)
+	}
+
+	const padding = 5
+	const lineThickness = 1
+	const activeLineThickness = 3
+
+	return Background{Color: tbs.BackgroundColor}.Layout(win, gtx, func(win *Window, gtx layout.Context) layout.Dimensions {
+		return layout.Rigids(gtx, layout.Vertical,
+			func(gtx layout.Context) layout.Dimensions {
+				m := op.Record(gtx.Ops)
+				gtx.Constraints.Min.Y = 0
+				tb.list.Axis = layout.Horizontal
+				dims := tb.list.Layout(gtx, len(tbs.Tabs), func(gtx layout.Context, i int) layout.Dimensions {
+					tab := &tb.tabs[i]
+					tab.click.Focusable = true
+					dims := tab.click.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+						semantic.Button.Add(gtx.Ops)
+						semantic.SelectedOp(i == tb.Current).Add(gtx.Ops)
+						m := op.Record(gtx.Ops)
+						dims := layout.UniformInset(padding).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+							return layout.Rigids(gtx, layout.Horizontal,
+								func(gtx layout.Context) layout.Dimensions {
+									return widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, font.Font{Weight: font.Bold}, win.Theme.TextSize, tbs.Tabs[i], win.ColorMaterial(gtx, tbs.TextColor))
+								},
+								func(gtx layout.Context) layout.Dimensions {
+									if !tbs.Closable {
+										return layout.Dimensions{}
+									}
+									return layout.Rigids(gtx, layout.Horizontal,
+										layout.Spacer{Width: padding}.Layout,
+										func(gtx layout.Context) layout.Dimensions {
+											return tab.close.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+												semantic.Button.Add(gtx.Ops)
+												semantic.DescriptionOp(Sprintf("Close %s", tbs.Tabs[i])).Add(gtx.Ops)
+												pointer.CursorPointer.Add(gtx.Ops)
+												c := tbs.TextColor
+												if tab.close.Hovered() {
+													c = win.Theme.Palette.NavigationLink
+												}
+												return widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, font.Font{}, win.Theme.TextSize, "×", win.ColorMaterial(gtx, c))
+											})
+										},
+									)
+								},
+							)
+						})
+						call := m.Stop()
+
+						if i == tb.Current {
+							FillShape(win, gtx.Ops, tbs.ActiveBackground, clip.Rect{Max: dims.Size}.Op())
+						} else if tab.click.Hovered() {
+							FillShape(win, gtx.Ops, tbs.HoveredColor, clip.Rect{Max: dims.Size}.Op())
+						}
+						call.Add(gtx.Ops)
+
+						if i == tb.Current {
+							y0 := dims.Size.Y - gtx.Dp(activeLineThickness)
+							FillShape(win, gtx.Ops, tbs.ActiveLineColor, clip.Rect{Min: image.Pt(0, y0), Max: image.Pt(dims.Size.X, dims.Size.Y)}.Op())
+						}
+						return FocusRing{Focused: tab.click.Focused()}.Layout(win, gtx, func(win *Window, gtx layout.Context) layout.Dimensions {
+							return dims
+						})
+					})
+					tb.widths[i] = dims.Size.X
+					return dims
+				})
+				call := m.Stop()
+
+				// Handle dragging for the whole bar, so that we can track the pointer as it moves across tabs. We
+				// register the handler before the tabs, so that it is their parent and receives the events, too.
+				defer clip.Rect{Max: dims.Size}.Push(gtx.Ops).Pop()
+				tb.drag.Add(gtx.Ops)
+				if tb.dragging.active {
+					pointer.CursorGrabbing.Add(gtx.Ops)
+				}
+				call.Add(gtx.Ops)
+				return dims
+			},
+
+			func(gtx layout.Context) layout.Dimensions {
+				r := clip.Rect{Max: image.Pt(gtx.Constraints.Min.X, gtx.Dp(lineThickness))}
+				FillShape(win, gtx.Ops, tbs.LineColor, r.Op())
+				return layout.Dimensions{Size: r.Max}
+			},
+		)
+	})
+}
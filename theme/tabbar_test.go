@@ -0,0 +1,51 @@
+package theme
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTabBarCloseTabs(t *testing.T) {
+	tests := []struct {
+		name    string
+		current int
+		closed  []int
+		// The tabs left after applying the events to the tabs 0 through 4, in order.
+		want        []int
+		wantCurrent int
+	}{
+		{"one tab", 0, []int{2}, []int{0, 1, 3, 4}, 0},
+		{"several tabs", 3, []int{1, 3}, []int{0, 2, 4}, 2},
+		{"unsorted", 4, []int{4, 0, 2}, []int{1, 3}, 1},
+		{"same tab twice", 1, []int{2, 2}, []int{0, 1, 3, 4}, 1},
+	}
+	for _, tt := range tests {
+		tb := TabBarState{
+			Current: tt.current,
+			tabs:    make([]tabBarTab, 5),
+			widths:  []int{10, 11, 12, 13, 14},
+			numTabs: 5,
+		}
+		tabs := []int{0, 1, 2, 3, 4}
+		for _, ev := range tb.closeTabs(nil, slices.Clone(tt.closed)) {
+			if ev.Kind != TabClosed {
+				t.Fatalf("%s: got event of kind %d", tt.name, ev.Kind)
+			}
+			tabs = slices.Delete(tabs, ev.Index, ev.Index+1)
+		}
+		if !slices.Equal(tabs, tt.want) {
+			t.Errorf("%s: got tabs %v, want %v", tt.name, tabs, tt.want)
+		}
+		if tb.numTabs != len(tt.want) || len(tb.tabs) != len(tt.want) {
+			t.Errorf("%s: got %d tabs, want %d", tt.name, tb.numTabs, len(tt.want))
+		}
+		for i, tab := range tt.want {
+			if tb.widths[i] != 10+tab {
+				t.Errorf("%s: got width %d for tab %d, want %d", tt.name, tb.widths[i], tab, 10+tab)
+			}
+		}
+		if tb.Current != tt.wantCurrent {
+			t.Errorf("%s: got current tab %d, want %d", tt.name, tb.Current, tt.wantCurrent)
+		}
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"gioui.org/font"
 	"gioui.org/io/key"
 	"gioui.org/io/pointer"
+	"gioui.org/io/semantic"
 	"gioui.org/op"
 	"gioui.org/op/clip"
 	"gioui.org/text"
@@ -247,7 +248,6 @@ func (row TableRowStyle) Layout(win *Window, gtx layout.Context, w RowFn) layout
 		drag := &row.Table.drags[i]
 		col := &row.Table.Columns[i]
 		drag.hover.Update(gtx.Queue)
-		// OPT(dh): Events allocates
 		var delta float32
 		for _, ev := range drag.drag.Update(gtx.Metric, gtx.Queue, gesture.Horizontal) {
 			switch ev.Kind {
@@ -325,8 +325,21 @@ func (row TableRowStyle) Layout(win *Window, gtx layout.Context, w RowFn) layout
 
 			stack := op.Offset(image.Pt(start, 0)).Push(gtx.Ops)
 
-			dims := w(win, gtx, i)
-			dims.Size = gtx.Constraints.Constrain(dims.Size)
+			var dims layout.Dimensions
+			if row.Header {
+				dims = w(win, gtx, i)
+				dims.Size = gtx.Constraints.Constrain(dims.Size)
+			} else {
+				// Describe cells by their column, so that screen readers can tell which value is which.
+				m := op.Record(gtx.Ops)
+				dims = w(win, gtx, i)
+				dims.Size = gtx.Constraints.Constrain(dims.Size)
+				call := m.Stop()
+				cell := clip.Rect{Max: dims.Size}.Push(gtx.Ops)
+				semantic.DescriptionOp(row.Table.Columns[i].Name).Add(gtx.Ops)
+				call.Add(gtx.Ops)
+				cell.Pop()
+			}
 			tallestHeight = dims.Size.Y
 			if i == 0 && tallestHeight > origTallestHeight {
 				origTallestHeight = tallestHeight
@@ -491,6 +504,59 @@ type YScrollableListState struct {
 	horizList       layout.List
 	vertScroll      widget.Scrollbar
 	horizScroll     widget.Scrollbar
+	// focused is set while the list has keyboard focus, which lets it be scrolled with the arrow, page, home and end
+	// keys.
+	focused bool
+}
+
+// update processes keyboard input.
+func (s *YScrollableListState) update(gtx layout.Context) {
+	for _, ev := range gtx.Events(s) {
+		switch ev := ev.(type) {
+		case key.FocusEvent:
+			s.focused = ev.Focus
+		case key.Event:
+			if ev.State != key.Press {
+				continue
+			}
+			page := float32(max(1, s.vertList.Position.Count-1))
+			switch ev.Name {
+			case key.NameUpArrow:
+				s.vertList.ScrollBy(-1)
+			case key.NameDownArrow:
+				s.vertList.ScrollBy(1)
+			case key.NamePageUp:
+				s.vertList.ScrollBy(-page)
+			case key.NamePageDown:
+				s.vertList.ScrollBy(page)
+			case key.NameHome:
+				s.vertList.ScrollTo(0)
+			case key.NameEnd:
+				s.vertList.ScrollTo(max(0, s.rememberingList.len-1))
+			case key.NameLeftArrow:
+				s.horizList.ScrollBy(-float32(gtx.Dp(50)))
+			case key.NameRightArrow:
+				s.horizList.ScrollBy(float32(gtx.Dp(50)))
+			}
+		}
+	}
+}
+
+// RevealRow scrolls the list so that row n is visible, if it isn't already. Rows that are only partially visible count
+// as not visible.
+func (s *YScrollableListState) RevealRow(n int) {
+	pos := s.vertList.Position
+	first := pos.First
+	if pos.Offset > 0 {
+		first++
+	}
+	last := pos.First + pos.Count - 1
+	if pos.OffsetLast < 0 {
+		last--
+	}
+	if n < first || n > last {
+		s.vertList.ScrollTo(n)
+	}
 }
 
 type YScrollableListStyle struct {
@@ -526,6 +592,23 @@ func (tbl YScrollableListStyle) Layout(
 
 	scrollbarWidth := Scrollbar(win.Theme, nil).Width()
 
+	tbl.state.update(gtx)
+	func() {
+		defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+		key.InputOp{Tag: tbl.state, Keys: "↑|↓|←|→|⇞|⇟|⇱|⇲"}.Add(gtx.Ops)
+	}()
+
+	return FocusRing{Focused: tbl.state.focused}.Layout(win, gtx, func(win *Window, gtx layout.Context) layout.Dimensions {
+		return tbl.layout(win, gtx, scrollbarWidth, body)
+	})
+}
+
+func (tbl YScrollableListStyle) layout(
+	win *Window,
+	gtx layout.Context,
+	scrollbarWidth unit.Dp,
+	body func(win *Window, gtx layout.Context, list *RememberingList) layout.Dimensions,
+) layout.Dimensions {
 	var bodyDims layout.Dimensions
 	return layout.Rigids(gtx, layout.Vertical,
 		func(gtx layout.Context) layout.Dimensions {
@@ -634,6 +717,16 @@ func (row TableHeaderRowStyle) Layout(win *Window, gtx layout.Context) layout.Di
 			func(gtx layout.Context) layout.Dimensions {
 				if col.Clickable {
 					defer clip.Rect{Max: gtx.Constraints.Min}.Push(gtx.Ops).Pop()
+					semantic.Button.Add(gtx.Ops)
+					semantic.LabelOp(col.Name).Add(gtx.Ops)
+					if row.Table.SortedBy == colIdx {
+						switch row.Table.SortOrder {
+						case SortAscending:
+							semantic.DescriptionOp(Sprintf("Sorted in ascending order")).Add(gtx.Ops)
+						case SortDescending:
+							semantic.DescriptionOp(Sprintf("Sorted in descending order")).Add(gtx.Ops)
+						}
+					}
 					row.Table.headerClicks[colIdx].Add(gtx.Ops)
 					pointer.CursorPointer.Add(gtx.Ops)
 				}
@@ -687,7 +780,8 @@ func (row TableSimpleRowStyle) Layout(
 		c = win.Theme.Palette.Table.HoveredRowBackground
 	}
 
-	return layout.Overlay(gtx,
+	m := op.Record(gtx.Ops)
+	dims := layout.Overlay(gtx,
 		func(gtx layout.Context) layout.Dimensions {
 			return Background{Color: c}.Layout(win, gtx, func(win *Window, gtx layout.Context) layout.Dimensions {
 				return TableRow(row.Table, false).Layout(win, gtx, func(win *Window, gtx layout.Context, col int) layout.Dimensions {
@@ -713,6 +807,12 @@ func (row TableSimpleRowStyle) Layout(
 			}
 		},
 	)
+	call := m.Stop()
+
+	defer clip.Rect{Max: dims.Size}.Push(gtx.Ops).Pop()
+	semantic.LabelOp(Sprintf("Row %d", rowIdx+1)).Add(gtx.Ops)
+	call.Add(gtx.Ops)
+	return dims
 }
 
 func TableExpandedRow(tbl *Table) TableExpandedRowStyle {
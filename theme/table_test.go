@@ -0,0 +1,87 @@
+package theme
+
+import (
+	"image"
+	"slices"
+	"testing"
+	"time"
+
+	"honnef.co/go/gotraceui/layout"
+
+	"gioui.org/io/key"
+	"gioui.org/io/router"
+	"gioui.org/op"
+)
+
+func TestYScrollableListKeyboard(t *testing.T) {
+	var r router.Router
+	win := NewWindow(nil)
+	var state YScrollableListState
+	frame := func() {
+		ops := new(op.Ops)
+		gtx := layout.Context{Ops: ops, Queue: &r, Now: time.Now(), Constraints: layout.Exact(image.Pt(200, 100))}
+		YScrollableList(&state).Layout(win, gtx, func(win *Window, gtx layout.Context, list *RememberingList) layout.Dimensions {
+			return list.Layout(gtx, 100, func(gtx layout.Context, i int) layout.Dimensions {
+				return layout.Dimensions{Size: image.Pt(100, 20)}
+			})
+		})
+		r.Frame(ops)
+	}
+	press := func(name string) {
+		r.Queue(key.Event{Name: name, State: key.Press})
+		frame()
+	}
+
+	frame()
+	if !r.MoveFocus(router.FocusForward) {
+		t.Fatal("list can't be focused")
+	}
+	frame()
+	if !state.focused {
+		t.Fatal("list doesn't know that it has focus")
+	}
+
+	press(key.NameDownArrow)
+	press(key.NameDownArrow)
+	if got := state.vertList.Position.First; got != 2 {
+		t.Errorf("got first row %d after pressing down twice, want 2", got)
+	}
+	press(key.NameEnd)
+	if got := state.vertList.Position.First + state.vertList.Position.Count; got != 100 {
+		t.Errorf("got last visible row %d after pressing end, want 100", got)
+	}
+	press(key.NameHome)
+	if got := state.vertList.Position.First; got != 0 {
+		t.Errorf("got first row %d after pressing home, want 0", got)
+	}
+}
+
+func TestTableRowSemantics(t *testing.T) {
+	var r router.Router
+	win := NewWindow(nil)
+	var tbl Table
+	ops := new(op.Ops)
+	gtx := layout.Context{Ops: ops, Queue: &r, Now: time.Now(), Constraints: layout.Exact(image.Pt(200, 100))}
+	tbl.SetColumns(win, gtx, []Column{{Name: "Goroutine"}, {Name: "Duration"}})
+	gtx.Constraints.Max.Y = 20
+	TableSimpleRow(&tbl).Layout(win, gtx, 2, func(win *Window, gtx layout.Context, row, col int) layout.Dimensions {
+		return layout.Dimensions{Size: image.Pt(gtx.Constraints.Max.X, 20)}
+	})
+	r.Frame(ops)
+
+	var labels, descriptions []string
+	for _, n := range r.AppendSemantics(nil) {
+		if n.Desc.Label != "" {
+			labels = append(labels, n.Desc.Label)
+		}
+		if n.Desc.Description != "" {
+			descriptions = append(descriptions, n.Desc.Description)
+		}
+	}
+	if want := []string{"Row 3"}; !slices.Equal(labels, want) {
+		t.Errorf("got labels %q, want %q", labels, want)
+	}
+	if want := []string{"Goroutine", "Duration"}; !slices.Equal(descriptions, want) {
+		t.Errorf("got descriptions %q, want %q", descriptions, want)
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"gioui.org/f32"
 	"gioui.org/font"
 	"gioui.org/io/pointer"
+	"gioui.org/io/semantic"
 	"gioui.org/op"
 	"gioui.org/op/clip"
 	"gioui.org/text"
@@ -29,11 +30,18 @@ type Theme struct {
 	Palette       Palette
 	TextSize      unit.Sp
 	TextSizeLarge unit.Sp
+	// MonospaceTypeface is the typeface used for text that benefits from fixed-width characters, such as stack traces.
+	MonospaceTypeface font.Typeface
 
 	WindowPadding unit.Dp
 	WindowBorder  unit.Dp
 }
 
+// Sprintf formats the user-visible strings of widgets, such as their descriptions for screen readers. Applications can
+// replace it to translate the strings, using the format strings as keys. It must be set before any windows are laid
+// out.
+var Sprintf = fmt.Sprintf
+
 type Palette struct {
 	Background         color.Oklch
 	Foreground         color.Oklch
@@ -42,6 +50,10 @@ type Palette struct {
 	NavigationLink     color.Oklch
 	Link               color.Oklch
 	PrimarySelection   color.Oklch
+	// Focus is the color of focus rings around widgets that have keyboard focus.
+	Focus color.Oklch
+	// Placeholder is the color of skeletons standing in for content that is still being computed.
+	Placeholder color.Oklch
 
 	Border color.Oklch
 
@@ -78,6 +90,8 @@ var DefaultPalette = Palette{
 	OpenLink:           oklch(45.2, 0.31, 264.05),
 	Link:               oklch(45.2, 0.31, 264.05),
 	PrimarySelection:   oklcha(93.11, 0.101, 108.21, 0.6),
+	Focus:              oklch(62.31, 0.188, 259.81),
+	Placeholder:        oklch(92, 0, 0),
 	Border:             oklch(0, 0, 0),
 
 	Popup: struct {
@@ -123,18 +137,78 @@ var DefaultPalette = Palette{
 	},
 }
 
+// HighContrastPalette is a palette for low-vision users and poor displays. It uses a plain white background, black text
+// and borders, and only dark accent colors.
+var HighContrastPalette = func() Palette {
+	p := DefaultPalette
+	p.Background = oklch(100, 0, 0)
+	p.Foreground = oklch(0, 0, 0)
+	p.ForegroundDisabled = oklch(40, 0, 0)
+	p.NavigationLink = oklch(45, 0.2, 29.23)
+	p.OpenLink = oklch(35, 0.25, 264.05)
+	p.Link = oklch(35, 0.25, 264.05)
+	p.PrimarySelection = oklcha(85, 0.17, 108.21, 0.8)
+	p.Focus = oklch(45, 0.25, 259.81)
+	p.Placeholder = oklch(75, 0, 0)
+
+	p.Popup.TitleForeground = oklch(100, 0, 0)
+	p.Popup.TitleBackground = oklch(0, 0, 0)
+	p.Popup.Background = oklch(100, 0, 0)
+
+	p.Menu.Background = oklch(100, 0, 0)
+	p.Menu.Selected = oklch(80, 0.12, 195.81)
+	p.Menu.Border = oklch(0, 0, 0)
+	p.Menu.Disabled = oklch(45, 0, 0)
+
+	p.Table.EvenRowBackground = oklch(100, 0, 0)
+	p.Table.OddRowBackground = oklch(94, 0, 0)
+	p.Table.HoveredRowBackground = oklch(85, 0.08, 106.84)
+	p.Table.HeaderBackground = oklch(88, 0, 0)
+	p.Table.Divider = oklch(0, 0, 0)
+	p.Table.ExpandedBorder = oklch(0, 0, 0)
+	p.Table.ExpandedBackground = oklch(90, 0.06, 346)
+	return p
+}()
+
 func NewTheme(fontCollection []font.FontFace) *Theme {
 	return &Theme{
-		Palette:       DefaultPalette,
-		Shaper:        text.NewShaper(text.WithCollection(fontCollection), text.NoSystemFonts()),
-		TextSize:      12,
-		TextSizeLarge: 14,
+		Palette:           DefaultPalette,
+		Shaper:            text.NewShaper(text.WithCollection(fontCollection), text.NoSystemFonts()),
+		TextSize:          12,
+		TextSizeLarge:     14,
+		MonospaceTypeface: "Go Mono",
 
 		WindowPadding: 2,
 		WindowBorder:  1,
 	}
 }
 
+// SetFonts replaces the theme's text shaper with one using the fonts in collection. Text that doesn't ask for a specific
+// typeface uses the faces of typeface. Glyphs missing from a face are looked up in the faces of fallbacks, in order,
+// and then in the remaining faces of the collection.
+func (th *Theme) SetFonts(collection []font.FontFace, typeface font.Typeface, fallbacks ...font.Typeface) {
+	var preferred []font.Typeface
+	for _, tf := range append([]font.Typeface{typeface}, fallbacks...) {
+		if !slices.Contains(preferred, tf) {
+			preferred = append(preferred, tf)
+		}
+	}
+	ordered := make([]font.FontFace, 0, len(collection))
+	for _, tf := range preferred {
+		for _, f := range collection {
+			if f.Font.Typeface == tf {
+				ordered = append(ordered, f)
+			}
+		}
+	}
+	for _, f := range collection {
+		if !slices.Contains(preferred, f.Font.Typeface) {
+			ordered = append(ordered, f)
+		}
+	}
+	th.Shaper = text.NewShaper(text.WithCollection(ordered), text.NoSystemFonts())
+}
+
 type ProgressBarStyle struct {
 	ForegroundColor color.Oklch
 	BackgroundColor color.Oklch
@@ -196,6 +270,7 @@ func (c CheckBoxStyle) Layout(win *Window, gtx layout.Context) layout.Dimensions
 	defer rtrace.StartRegion(context.Background(), "theme.CheckBoxStyle.Layout").End()
 
 	return c.Checkbox.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		semantic.CheckBox.Add(gtx.Ops)
 		return layout.Rigids(gtx, layout.Horizontal,
 			func(gtx layout.Context) layout.Dimensions {
 				sizeDp := gtx.Metric.SpToDp(c.TextSize)
@@ -203,24 +278,27 @@ func (c CheckBoxStyle) Layout(win *Window, gtx layout.Context) layout.Dimensions
 
 				ngtx := gtx
 				ngtx.Constraints = layout.Exact(image.Pt(sizePx, sizePx))
-				return Border{
+				border := Border{
 					Color: c.ForegroundColor,
 					Width: 1,
-				}.Layout(win, ngtx, func(win *Window, gtx layout.Context) layout.Dimensions {
-					FillShape(win, gtx.Ops, c.BackgroundColor, clip.Rect{Max: gtx.Constraints.Min}.Op())
-					if c.Checkbox.Get() {
-						padding := gtx.Constraints.Min.X / 4
-						if padding == 0 {
-							padding = gtx.Dp(1)
+				}
+				return FocusRing{Focused: c.Checkbox.Focused()}.Layout(win, ngtx, func(win *Window, gtx layout.Context) layout.Dimensions {
+					return border.Layout(win, gtx, func(win *Window, gtx layout.Context) layout.Dimensions {
+						FillShape(win, gtx.Ops, c.BackgroundColor, clip.Rect{Max: gtx.Constraints.Min}.Op())
+						if c.Checkbox.Get() {
+							padding := gtx.Constraints.Min.X / 4
+							if padding == 0 {
+								padding = gtx.Dp(1)
+							}
+							minx := padding
+							miny := minx
+							maxx := gtx.Constraints.Min.X - padding
+							maxy := maxx
+							FillShape(win, gtx.Ops, c.ForegroundColor, clip.Rect{Min: image.Pt(minx, miny), Max: image.Pt(maxx, maxy)}.Op())
 						}
-						minx := padding
-						miny := minx
-						maxx := gtx.Constraints.Min.X - padding
-						maxy := maxx
-						FillShape(win, gtx.Ops, c.ForegroundColor, clip.Rect{Min: image.Pt(minx, miny), Max: image.Pt(maxx, maxy)}.Op())
-					}
-
-					return layout.Dimensions{Size: gtx.Constraints.Min}
+
+						return layout.Dimensions{Size: gtx.Constraints.Min}
+					})
 				})
 			},
 
@@ -313,6 +391,8 @@ func (chkgrp CheckBoxGroupStyle) Layout(win *Window, gtx layout.Context, checkbo
 	dims := layout.Rigids(gtx, layout.Vertical,
 		func(gtx layout.Context) layout.Dimensions {
 			return chkgrp.Clickable.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				semantic.CheckBox.Add(gtx.Ops)
+				semantic.SelectedOp(state == all).Add(gtx.Ops)
 				return layout.Rigids(gtx, layout.Horizontal,
 					func(gtx layout.Context) layout.Dimensions {
 						ngtx := gtx
@@ -619,11 +699,15 @@ func (b ButtonStyle) Layout(win *Window, gtx layout.Context) layout.Dimensions {
 		fg = b.TextColorDisabled
 	}
 
+	b.Button.Focusable = true
 	return Background{Color: bg}.Layout(win, gtx, func(win *Window, gtx layout.Context) layout.Dimensions {
 		return b.Button.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
-			return Bordered{Color: b.BorderColor, Width: 1}.Layout(win, gtx, func(win *Window, gtx layout.Context) layout.Dimensions {
-				return layout.UniformInset(1).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
-					return widget.Label{Alignment: text.Middle}.Layout(gtx, win.Theme.Shaper, font.Font{}, 12, b.Text, win.ColorMaterial(gtx, fg))
+			semantic.Button.Add(gtx.Ops)
+			return FocusRing{Focused: b.Button.Focused()}.Layout(win, gtx, func(win *Window, gtx layout.Context) layout.Dimensions {
+				return Bordered{Color: b.BorderColor, Width: 1}.Layout(win, gtx, func(win *Window, gtx layout.Context) layout.Dimensions {
+					return layout.UniformInset(1).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+						return widget.Label{Alignment: text.Middle}.Layout(gtx, win.Theme.Shaper, font.Font{}, win.Theme.TextSize, b.Text, win.ColorMaterial(gtx, fg))
+					})
 				})
 			})
 		})
@@ -716,43 +800,6 @@ func (g GridStyle) Layout(win *Window, gtx layout.Context, rows, cols int, dimen
 	return dim
 }
 
-type ResizeStyle struct {
-	res         *component.Resize
-	BorderColor color.Oklch
-}
-
-func Resize(th *Theme, state *component.Resize) ResizeStyle {
-	return ResizeStyle{
-		res:         state,
-		BorderColor: th.Palette.Border,
-	}
-}
-
-func (rs ResizeStyle) Layout(win *Window, gtx layout.Context, w1, w2 Widget) layout.Dimensions {
-	defer rtrace.StartRegion(context.Background(), "theme.ResizeStyle.Layout").End()
-
-	var hnd layout.Widget
-	switch rs.res.Axis {
-	case layout.Horizontal:
-		hnd = func(gtx layout.Context) layout.Dimensions {
-			gtx.Constraints.Max.X = 5
-			defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
-			Fill(win, gtx.Ops, rs.BorderColor)
-			return layout.Dimensions{Size: gtx.Constraints.Max}
-		}
-	case layout.Vertical:
-		hnd = func(gtx layout.Context) layout.Dimensions {
-			gtx.Constraints.Max.Y = 5
-			defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
-			Fill(win, gtx.Ops, rs.BorderColor)
-			return layout.Dimensions{Size: gtx.Constraints.Max}
-		}
-	default:
-		panic(fmt.Sprintf("unhandled case %v", rs.res.Axis))
-	}
-	return rs.res.Layout(gtx, Dumb(win, w1), Dumb(win, w2), hnd)
-}
-
 type SwitchStyle struct {
 	State       widget.Boolean
 	Left, Right string
@@ -803,11 +850,11 @@ func (ss SwitchStyle) Layout(win *Window, gtx layout.Context) layout.Dimensions
 	// results in dimensions >= the inactive font. If we used the actual fonts (one active and one inactive) then the
 	// overall size might change when toggling the switch.
 	m := op.Record(gtx.Ops)
-	dimsLeft := widget.Label{MaxLines: 1}.Layout(noMin(gtx), win.Theme.Shaper, activeFont, 12, ss.Left, leftForeground)
+	dimsLeft := widget.Label{MaxLines: 1}.Layout(noMin(gtx), win.Theme.Shaper, activeFont, win.Theme.TextSize, ss.Left, leftForeground)
 	m.Stop()
 
 	m = op.Record(gtx.Ops)
-	dimsRight := widget.Label{MaxLines: 1}.Layout(noMin(gtx), win.Theme.Shaper, activeFont, 12, ss.Right, rightForeground)
+	dimsRight := widget.Label{MaxLines: 1}.Layout(noMin(gtx), win.Theme.Shaper, activeFont, win.Theme.TextSize, ss.Right, rightForeground)
 	m.Stop()
 
 	var labelWidth int
@@ -831,7 +878,7 @@ func (ss SwitchStyle) Layout(win *Window, gtx layout.Context) layout.Dimensions
 						return layout.UniformInset(padding).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
 							defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
 							return layout.Center.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
-								return widget.Label{MaxLines: 1}.Layout(noMin(gtx), win.Theme.Shaper, leftFont, 12, ss.Left, leftForeground)
+								return widget.Label{MaxLines: 1}.Layout(noMin(gtx), win.Theme.Shaper, leftFont, win.Theme.TextSize, ss.Left, leftForeground)
 							})
 						})
 					})
@@ -858,7 +905,7 @@ func (ss SwitchStyle) Layout(win *Window, gtx layout.Context) layout.Dimensions
 						return layout.UniformInset(padding).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
 							defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
 							return layout.Center.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
-								return widget.Label{MaxLines: 1}.Layout(noMin(gtx), win.Theme.Shaper, rightFont, 12, ss.Right, rightForeground)
+								return widget.Label{MaxLines: 1}.Layout(noMin(gtx), win.Theme.Shaper, rightFont, win.Theme.TextSize, ss.Right, rightForeground)
 							})
 						})
 					})
@@ -937,7 +984,7 @@ func (ts TabbedStyle) Layout(win *Window, gtx layout.Context, w Widget) layout.D
 					return ts.State.clickables[i].Layout(gtx, func(gtx layout.Context) layout.Dimensions {
 						stack := op.Offset(image.Pt(gtx.Dp(padding), gtx.Dp(padding))).Push(gtx.Ops)
 
-						dims := widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, font.Font{Weight: font.Bold}, 12, ts.Tabs[i], win.ColorMaterial(gtx, win.Theme.Palette.Foreground))
+						dims := widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, font.Font{Weight: font.Bold}, win.Theme.TextSize, ts.Tabs[i], win.ColorMaterial(gtx, win.Theme.Palette.Foreground))
 						stack.Pop()
 
 						dims.Size.X += 2 * gtx.Dp(padding)
@@ -0,0 +1,180 @@
+// Package themetest renders widgets offscreen, for writing golden-image tests of widgets built on package theme.
+//
+// Widgets are laid out in a window with a deterministic theme: the embedded fonts and no system fonts, the default
+// palette, a scale of 1, one pixel per dp, and a fixed frame time. Rendering requires a GPU that Gio's headless
+// windows can use. On Linux, Mesa's software renderer works, too. Without a display, EGL fails to initialize on its
+// default platform; tests that want to fall back to software rendering can set the environment variable
+// EGL_PLATFORM=surfaceless in their TestMain, before anything gets rendered. Golden fails the test when rendering isn't possible, unless tests are run with -allow-no-gpu or with the
+// environment variable THEMETEST_ALLOW_NO_GPU=1, in which case it skips the test.
+//
+// Golden images are stored in the testdata directory of the package under test. Run tests with -update-golden to
+// create or update them.
+package themetest
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/theme"
+
+	"gioui.org/gpu/headless"
+	"gioui.org/io/router"
+	"gioui.org/io/system"
+	"gioui.org/op"
+	"gioui.org/unit"
+)
+
+var (
+	update     = flag.Bool("update-golden", false, "update golden images instead of comparing against them")
+	allowNoGPU = flag.Bool("allow-no-gpu", false, "skip golden tests instead of failing them when offscreen rendering isn't available")
+)
+
+// ErrUnavailable is returned by Render when no headless window could be created.
+var ErrUnavailable = errors.New("offscreen rendering isn't available")
+
+// Tolerance is the maximum difference of any color channel for pixels to be considered equal. Different GPUs and
+// drivers rasterize slightly differently, in particular when anti-aliasing.
+var Tolerance uint8 = 8
+
+// FrameTime is the time of all frames rendered by Render, so that animations are in a deterministic state.
+var FrameTime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// NewWindow returns a window with a deterministic theme. The window isn't backed by an app.Window, which means that
+// widgets that start futures, show notifications, or emit actions can't be rendered with it.
+func NewWindow() *theme.Window {
+	return theme.NewWindow(nil)
+}
+
+// Render lays out w in a window of the given size and returns the rendered image. The window's background is filled
+// with the theme's background color before w gets laid out, with its minimum and maximum constraints set to size.
+func Render(win *theme.Window, size image.Point, w theme.Widget) (*image.RGBA, error) {
+	hwin, err := headless.NewWindow(size.X, size.Y)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnavailable, err)
+	}
+	defer hwin.Release()
+
+	var ops op.Ops
+	ev := system.FrameEvent{
+		Now:    FrameTime,
+		Metric: unit.Metric{PxPerDp: 1, PxPerSp: 1},
+		Size:   size,
+		// Widgets handle input during layout. A router without any input gives them an empty queue.
+		Queue: new(router.Router),
+	}
+	win.Layout(&ops, ev, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+		theme.Fill(win, gtx.Ops, win.Theme.Palette.Background)
+		gtx.Constraints.Min = gtx.Constraints.Max
+		return w(win, gtx)
+	})
+	if err := hwin.Frame(&ops); err != nil {
+		return nil, err
+	}
+	img := image.NewRGBA(image.Rectangle{Max: size})
+	if err := hwin.Screenshot(img); err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// Golden renders w in a new window of the given size and compares the result against the golden image
+// testdata/<name>.png. When the images differ, the rendered image is written to a temporary file for inspection.
+func Golden(t testing.TB, name string, size image.Point, w theme.Widget) {
+	t.Helper()
+
+	img, err := Render(NewWindow(), size, w)
+	if errors.Is(err, ErrUnavailable) {
+		if *allowNoGPU || os.Getenv("THEMETEST_ALLOW_NO_GPU") == "1" {
+			t.Skip(err)
+		}
+		t.Fatalf("%s; run with -allow-no-gpu or THEMETEST_ALLOW_NO_GPU=1 to skip golden tests instead", err)
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	Compare(t, name, img)
+}
+
+// Compare compares img against the golden image testdata/<name>.png, or updates the golden image if tests are run
+// with -update-golden.
+func Compare(t testing.TB, name string, img *image.RGBA) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name+".png")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := writePNG(path, img); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("couldn't open golden image, run with -update-golden to create it: %s", err)
+	}
+	defer f.Close()
+	want, err := png.Decode(f)
+	if err != nil {
+		t.Fatalf("couldn't decode golden image %s: %s", path, err)
+	}
+
+	if n := countDifferences(want, img); n > 0 {
+		actual := filepath.Join(os.TempDir(), fmt.Sprintf("themetest-%s.png", filepath.Base(name)))
+		if err := writePNG(actual, img); err != nil {
+			t.Errorf("%s: %d pixels differ from the golden image; couldn't write rendered image: %s", name, n, err)
+		} else {
+			t.Errorf("%s: %d pixels differ from the golden image; wrote rendered image to %s", name, n, actual)
+		}
+	}
+}
+
+// countDifferences returns the number of pixels that differ between the two images by more than Tolerance. Pixels
+// that are only present in one of the images count as differing.
+func countDifferences(want image.Image, got *image.RGBA) int {
+	wb, gb := want.Bounds(), got.Bounds()
+	n := 0
+	if wb.Size() != gb.Size() {
+		// Compare the overlapping area and count the rest as different.
+		n = wb.Dx()*wb.Dy() + gb.Dx()*gb.Dy() - 2*min(wb.Dx(), gb.Dx())*min(wb.Dy(), gb.Dy())
+	}
+	diff := func(a, b uint32) bool {
+		// The channels of color.Color are 16 bits wide.
+		a, b = a>>8, b>>8
+		if a > b {
+			a, b = b, a
+		}
+		return b-a > uint32(Tolerance)
+	}
+	for y := 0; y < min(wb.Dy(), gb.Dy()); y++ {
+		for x := 0; x < min(wb.Dx(), gb.Dx()); x++ {
+			r1, g1, b1, a1 := want.At(wb.Min.X+x, wb.Min.Y+y).RGBA()
+			r2, g2, b2, a2 := got.At(gb.Min.X+x, gb.Min.Y+y).RGBA()
+			if diff(r1, r2) || diff(g1, g2) || diff(b1, b2) || diff(a1, a2) {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+func writePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
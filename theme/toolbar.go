@@ -0,0 +1,272 @@
+package theme
+
+import (
+	"context"
+	"image"
+	rtrace "runtime/trace"
+
+	"honnef.co/go/gotraceui/color"
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/font"
+	"gioui.org/io/pointer"
+	"gioui.org/io/semantic"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/unit"
+)
+
+// ToolbarItem is a button in a toolbar. Items display their icon, and their label in tooltips and in the toolbar's
+// overflow menu.
+type ToolbarItem struct {
+	Icon string
+	// Label returns the item's label. It is called every frame, so that labels can change, for example when the
+	// language changes.
+	Label    func() string
+	Shortcut string
+	// Toggle makes the item a toggle button, which is displayed as pressed while Selected is true. Clicking a toggle
+	// flips Selected.
+	Toggle   bool
+	Selected bool
+	Disabled func() bool
+
+	click widget.PrimaryClickable
+	menu  MenuItem
+	// overflowClicked records that the item was activated via the overflow menu.
+	overflowClicked bool
+}
+
+func (item *ToolbarItem) tooltip() string {
+	if item.Shortcut != "" {
+		return item.Label() + " (" + item.Shortcut + ")"
+	}
+	return item.Label()
+}
+
+func (item *ToolbarItem) disabled() bool {
+	return item.Disabled != nil && item.Disabled()
+}
+
+// Clicked reports whether the item was activated, either directly or via the overflow menu.
+func (item *ToolbarItem) Clicked(gtx layout.Context) bool {
+	if item.disabled() {
+		return false
+	}
+	clicked := item.overflowClicked || item.click.Clicked(gtx)
+	item.overflowClicked = false
+	if clicked && item.Toggle {
+		item.Selected = !item.Selected
+	}
+	return clicked
+}
+
+type Toolbar struct {
+	// Items are the toolbar's items. Nil items are displayed as separators.
+	Items []*ToolbarItem
+
+	overflow widget.PrimaryClickable
+	// The items that didn't fit in the previous frame.
+	hidden []*ToolbarItem
+}
+
+func (tb *Toolbar) openOverflow(win *Window) {
+	items := make([]Widget, 0, len(tb.hidden))
+	for _, item := range tb.hidden {
+		if item == nil {
+			items = append(items, MenuDivider(win.Theme).Layout)
+			continue
+		}
+		item.menu.Label = func() string {
+			if item.Toggle && item.Selected {
+				return Sprintf("%s (on)", item.Label())
+			}
+			return item.Label()
+		}
+		item.menu.Icon = item.Icon
+		item.menu.Shortcut = item.Shortcut
+		item.menu.Disabled = item.Disabled
+		items = append(items, NewMenuItemStyle(win.Theme, &item.menu).Layout)
+	}
+	hidden := tb.hidden
+	group := &MenuGroup{Items: items}
+
+	win.SetPopup(func(win *Window, gtx layout.Context) layout.Dimensions {
+		for _, item := range hidden {
+			if item != nil && item.menu.Clicked(gtx) {
+				item.overflowClicked = true
+				win.CloseModal()
+				// Make sure that the owner of the item gets to see the click.
+				op.InvalidateOp{}.Add(gtx.Ops)
+			}
+		}
+		return NewMenuGroupStyle(win.Theme, group).Layout(win, gtx)
+	})
+}
+
+// ToolbarStyle displays a row of icon buttons. Items that don't fit are moved into an overflow menu.
+type ToolbarStyle struct {
+	Toolbar *Toolbar
+
+	Padding    unit.Dp
+	Foreground color.Oklch
+	Background color.Oklch
+	Hovered    color.Oklch
+	Selected   color.Oklch
+	Disabled   color.Oklch
+	Separator  color.Oklch
+}
+
+func NewToolbarStyle(th *Theme, tb *Toolbar) ToolbarStyle {
+	return ToolbarStyle{
+		Toolbar:    tb,
+		Padding:    4,
+		Foreground: th.Palette.Foreground,
+		Background: th.Palette.Menu.Background,
+		Hovered:    th.Palette.Table.HoveredRowBackground,
+		Selected:   th.Palette.Menu.Selected,
+		Disabled:   th.Palette.Menu.Disabled,
+		Separator:  th.Palette.Border,
+	}
+}
+
+func (tbs ToolbarStyle) layoutButton(win *Window, gtx layout.Context, click *widget.PrimaryClickable, icon, label string, toggle, selected, disabled bool) layout.Dimensions {
+	fg := tbs.Foreground
+	if disabled {
+		fg = tbs.Disabled
+		gtx.Queue = nil
+	}
+	click.Focusable = true
+	return click.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		semantic.Button.Add(gtx.Ops)
+		semantic.LabelOp(label).Add(gtx.Ops)
+		if toggle {
+			semantic.SelectedOp(selected).Add(gtx.Ops)
+		}
+		m := op.Record(gtx.Ops)
+		dims := layout.UniformInset(tbs.Padding).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			return widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, font.Font{}, win.Theme.TextSize, icon, win.ColorMaterial(gtx, fg))
+		})
+		call := m.Stop()
+
+		if selected {
+			FillShape(win, gtx.Ops, tbs.Selected, clip.UniformRRect(image.Rectangle{Max: dims.Size}, gtx.Dp(2)).Op(gtx.Ops))
+		} else if !disabled && click.Hovered() {
+			FillShape(win, gtx.Ops, tbs.Hovered, clip.UniformRRect(image.Rectangle{Max: dims.Size}, gtx.Dp(2)).Op(gtx.Ops))
+		}
+		if !disabled {
+			pointer.CursorPointer.Add(gtx.Ops)
+		}
+		call.Add(gtx.Ops)
+		return FocusRing{Focused: click.Focused()}.Layout(win, gtx, func(win *Window, gtx layout.Context) layout.Dimensions {
+			return dims
+		})
+	})
+}
+
+func (tbs ToolbarStyle) Layout(win *Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "theme.ToolbarStyle.Layout").End()
+
+	tb := tbs.Toolbar
+	for tb.overflow.Clicked(gtx) {
+		tb.openOverflow(win)
+	}
+
+	type recorded struct {
+		call op.CallOp
+		dims layout.Dimensions
+	}
+	record := func(w layout.Widget) recorded {
+		m := op.Record(gtx.Ops)
+		gtx := gtx
+		gtx.Constraints.Min = image.Point{}
+		dims := w(gtx)
+		return recorded{m.Stop(), dims}
+	}
+
+	gap := gtx.Dp(2)
+	sepWidth := gtx.Dp(9)
+	buttons := make([]recorded, len(tb.Items))
+	var total, height int
+	for i, item := range tb.Items {
+		if item == nil {
+			total += sepWidth
+			continue
+		}
+		buttons[i] = record(func(gtx layout.Context) layout.Dimensions {
+			return tbs.layoutButton(win, gtx, &item.click, item.Icon, item.Label(), item.Toggle, item.Toggle && item.Selected, item.disabled())
+		})
+		if item.click.Hovered() {
+			win.SetTooltip(Tooltip(win.Theme, item.tooltip()).Layout)
+		}
+		total += buttons[i].dims.Size.X + gap
+		height = max(height, buttons[i].dims.Size.Y)
+	}
+
+	// Determine how many items fit, leaving room for the overflow button if not all of them do.
+	numVisible := len(tb.Items)
+	var overflow recorded
+	if total > gtx.Constraints.Max.X {
+		overflow = record(func(gtx layout.Context) layout.Dimensions {
+			return tbs.layoutButton(win, gtx, &tb.overflow, "…", Sprintf("More"), false, false, false)
+		})
+		height = max(height, overflow.dims.Size.Y)
+		avail := gtx.Constraints.Max.X - overflow.dims.Size.X
+		x := 0
+		for i, item := range tb.Items {
+			w := sepWidth
+			if item != nil {
+				w = buttons[i].dims.Size.X + gap
+			}
+			if x+w > avail {
+				numVisible = i
+				break
+			}
+			x += w
+		}
+	}
+	tb.hidden = tb.hidden[:0]
+	for _, item := range tb.Items[numVisible:] {
+		// Don't start the overflow menu with a separator.
+		if item == nil && len(tb.hidden) == 0 {
+			continue
+		}
+		tb.hidden = append(tb.hidden, item)
+	}
+
+	size := image.Pt(gtx.Constraints.Max.X, height)
+	defer clip.Rect{Max: size}.Push(gtx.Ops).Pop()
+	Fill(win, gtx.Ops, tbs.Background)
+
+	x := 0
+	for i, item := range tb.Items[:numVisible] {
+		if item == nil {
+			mid := x + sepWidth/2
+			FillShape(win, gtx.Ops, tbs.Separator, clip.Rect{Min: image.Pt(mid, gtx.Dp(tbs.Padding)), Max: image.Pt(mid+gtx.Dp(1), height-gtx.Dp(tbs.Padding))}.Op())
+			x += sepWidth
+			continue
+		}
+		stack := op.Offset(image.Pt(x, (height-buttons[i].dims.Size.Y)/2)).Push(gtx.Ops)
+		buttons[i].call.Add(gtx.Ops)
+		stack.Pop()
+		if item.click.Focused() && !item.click.Hovered() {
+			// Tooltips follow the pointer, which is of no use to keyboard users. Display the focused item's tooltip
+			// below the item instead.
+			stack := op.Offset(image.Pt(x, height)).Push(gtx.Ops)
+			m := op.Record(gtx.Ops)
+			gtx := gtx
+			gtx.Constraints.Min = image.Point{}
+			Tooltip(win.Theme, item.tooltip()).Layout(win, gtx)
+			op.Defer(gtx.Ops, m.Stop())
+			stack.Pop()
+		}
+		x += buttons[i].dims.Size.X + gap
+	}
+	if numVisible < len(tb.Items) {
+		stack := op.Offset(image.Pt(size.X-overflow.dims.Size.X, 0)).Push(gtx.Ops)
+		overflow.call.Add(gtx.Ops)
+		stack.Pop()
+	}
+
+	return layout.Dimensions{Size: size}
+}
@@ -0,0 +1,18 @@
+package theme
+
+import (
+	"testing"
+)
+
+func TestToolbarItemLabel(t *testing.T) {
+	label := "Undo"
+	item := &ToolbarItem{Label: func() string { return label }, Shortcut: "Ctrl-Z"}
+	if got, want := item.tooltip(), "Undo (Ctrl-Z)"; got != want {
+		t.Errorf("got tooltip %q, want %q", got, want)
+	}
+	// Labels are looked up every time they're displayed, for example so that they follow the language.
+	label = "Rückgängig"
+	if got, want := item.tooltip(), "Rückgängig (Ctrl-Z)"; got != want {
+		t.Errorf("got tooltip %q, want %q", got, want)
+	}
+}
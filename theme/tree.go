@@ -0,0 +1,183 @@
+package theme
+
+import (
+	"context"
+	"image"
+	rtrace "runtime/trace"
+
+	"honnef.co/go/gotraceui/color"
+	"honnef.co/go/gotraceui/layout"
+	"honnef.co/go/gotraceui/widget"
+
+	"gioui.org/f32"
+	"gioui.org/io/key"
+	"gioui.org/io/pointer"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/unit"
+)
+
+// TreeViewStyle displays a widget.TreeView. Only visible rows are laid out, which makes it suitable for trees with
+// many nodes.
+//
+// The tree can be navigated with the keyboard once it has focus: the up and down arrows move the selection, the
+// right arrow expands the selected node or moves to its first child, the left arrow collapses the selected node or
+// moves to its parent, and Enter or Space toggle the selected node.
+type TreeViewStyle[T comparable] struct {
+	State *widget.TreeView[T]
+	// Row lays out the contents of a row, to the right of its expander.
+	Row func(win *Window, gtx layout.Context, row widget.TreeRow[T]) layout.Dimensions
+
+	Indent            unit.Dp
+	ExpanderColor     color.Oklch
+	SelectedColor     color.Oklch
+	HoveredBackground color.Oklch
+}
+
+func TreeView[T comparable](th *Theme, state *widget.TreeView[T], row func(win *Window, gtx layout.Context, row widget.TreeRow[T]) layout.Dimensions) TreeViewStyle[T] {
+	return TreeViewStyle[T]{
+		State:             state,
+		Row:               row,
+		Indent:            15,
+		ExpanderColor:     th.Palette.Foreground,
+		SelectedColor:     th.Palette.PrimarySelection,
+		HoveredBackground: th.Palette.Table.HoveredRowBackground,
+	}
+}
+
+func (tvs TreeViewStyle[T]) Layout(win *Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "theme.TreeViewStyle.Layout").End()
+
+	tv := tvs.State
+
+	tv.PreviousClickables(func(node T, c *widget.TreeRowClickables) {
+		// Clicks on the expander are also clicks on the row, so make sure that double-clicking the expander doesn't
+		// toggle the node twice.
+		expanderClicked := false
+		for c.Expander.Clicked(gtx) {
+			tv.Toggle(node)
+			expanderClicked = true
+		}
+		for {
+			click, ok := c.Row.Clicked(gtx)
+			if !ok {
+				break
+			}
+			if click.Button != pointer.ButtonPrimary {
+				continue
+			}
+			tv.Select(node)
+			key.FocusOp{Tag: tv}.Add(gtx.Ops)
+			if click.NumClicks == 2 && !expanderClicked {
+				tv.Toggle(node)
+			}
+		}
+	})
+
+	for _, ev := range gtx.Events(tv) {
+		ev, ok := ev.(key.Event)
+		if !ok || ev.State != key.Press {
+			continue
+		}
+		switch ev.Name {
+		case key.NameUpArrow:
+			tv.MoveSelection(-1)
+		case key.NameDownArrow:
+			tv.MoveSelection(1)
+		case key.NamePageUp:
+			tv.MoveSelection(-max(1, tv.List.Position.Count-1))
+		case key.NamePageDown:
+			tv.MoveSelection(max(1, tv.List.Position.Count-1))
+		case key.NameHome:
+			tv.SelectFirst()
+		case key.NameEnd:
+			tv.SelectLast()
+		case key.NameRightArrow:
+			tv.ExpandSelected()
+		case key.NameLeftArrow:
+			tv.CollapseSelected()
+		case key.NameReturn, key.NameSpace:
+			if node, ok := tv.Selected(); ok {
+				tv.Toggle(node)
+			}
+		}
+	}
+
+	rows := tv.Rows()
+	if idx, ok := tv.ScrollToSelected(); ok {
+		pos := &tv.List.Position
+		if idx <= pos.First {
+			pos.First = idx
+			pos.Offset = 0
+		} else if last := pos.First + pos.Count - 1; idx >= last {
+			// Rows can have different heights, so this is only an approximation that assumes that the number of
+			// visible rows doesn't change.
+			pos.First = idx - pos.Count + 2
+			pos.Offset = 0
+		}
+	}
+
+	defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+	key.InputOp{Tag: tv, Keys: "↑|↓|←|→|⇞|⇟|⇱|⇲|⏎|Space"}.Add(gtx.Ops)
+
+	selected, hasSelected := tv.Selected()
+	dims := List(win.Theme, &tv.List).Layout(win, gtx, len(rows), func(gtx layout.Context, index int) layout.Dimensions {
+		row := rows[index]
+		c := tv.Clickables(row.Node)
+		gtx.Constraints.Min = image.Point{}
+
+		return c.Row.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			m := op.Record(gtx.Ops)
+			dims := layout.Rigids(gtx, layout.Horizontal,
+				layout.Spacer{Width: tvs.Indent * unit.Dp(row.Depth)}.Layout,
+				func(gtx layout.Context) layout.Dimensions {
+					return tvs.layoutExpander(win, gtx, row, &c.Expander)
+				},
+				func(gtx layout.Context) layout.Dimensions {
+					return tvs.Row(win, gtx, row)
+				},
+			)
+			call := m.Stop()
+			dims.Size.X = gtx.Constraints.Max.X
+
+			if hasSelected && row.Node == selected {
+				FillShape(win, gtx.Ops, tvs.SelectedColor, clip.Rect{Max: dims.Size}.Op())
+			} else if c.Row.Hovered() {
+				FillShape(win, gtx.Ops, tvs.HoveredBackground, clip.Rect{Max: dims.Size}.Op())
+			}
+			call.Add(gtx.Ops)
+			return dims
+		})
+	})
+
+	tv.EndFrame()
+	return dims
+}
+
+// layoutExpander draws a triangle that indicates whether the node is expanded, and which toggles the node when
+// clicked. We draw the triangle ourselves because not all fonts have the necessary glyphs.
+func (tvs TreeViewStyle[T]) layoutExpander(win *Window, gtx layout.Context, row widget.TreeRow[T], click *widget.PrimaryClickable) layout.Dimensions {
+	size := gtx.Sp(win.Theme.TextSize)
+	if !row.HasChildren {
+		return layout.Dimensions{Size: image.Pt(size, size)}
+	}
+
+	return click.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		pointer.CursorPointer.Add(gtx.Ops)
+		s := float32(size)
+		var p clip.Path
+		p.Begin(gtx.Ops)
+		if row.Expanded {
+			p.MoveTo(f32.Pt(s*0.2, s*0.3))
+			p.LineTo(f32.Pt(s*0.8, s*0.3))
+			p.LineTo(f32.Pt(s*0.5, s*0.75))
+		} else {
+			p.MoveTo(f32.Pt(s*0.3, s*0.2))
+			p.LineTo(f32.Pt(s*0.75, s*0.5))
+			p.LineTo(f32.Pt(s*0.3, s*0.8))
+		}
+		p.Close()
+		FillShape(win, gtx.Ops, tvs.ExpanderColor, clip.Outline{Path: p.End()}.Op())
+		return layout.Dimensions{Size: image.Pt(size, size)}
+	})
+}
@@ -54,6 +54,18 @@ func (b Border) Layout(win *Window, gtx layout.Context, w Widget) layout.Dimensi
 	return dims
 }
 
+// FocusRing marks a widget as having keyboard focus by drawing a border over its edges.
+type FocusRing struct {
+	Focused bool
+}
+
+func (f FocusRing) Layout(win *Window, gtx layout.Context, w Widget) layout.Dimensions {
+	if !f.Focused {
+		return w(win, gtx)
+	}
+	return Border{Color: win.Theme.Palette.Focus, Width: 2}.Layout(win, gtx, w)
+}
+
 type Background struct {
 	Color color.Oklch
 }
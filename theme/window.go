@@ -9,7 +9,6 @@ import (
 	rtrace "runtime/trace"
 	"strings"
 	"sync"
-	"time"
 	"unsafe"
 
 	"honnef.co/go/gotraceui/color"
@@ -40,8 +39,16 @@ type Window struct {
 	Futures   *Futures
 	Theme     *Theme
 	Menu      *Menu
+	// StatusBar controls whether the window displays a status bar, which components can add segments to using
+	// AddStatus.
+	StatusBar bool
 	// The current frame number
-	Frame                uint64
+	Frame uint64
+	// HUD is the window's performance HUD. It is only displayed if enabled.
+	HUD PerformanceHUD
+	// Selection is the object selected by the user. It may be shared with other windows.
+	Selection *Selection
+
 	contextMenu          []*MenuItem
 	shortcuts            map[Shortcut]struct{}
 	pressedShortcuts     []Shortcut
@@ -61,7 +68,7 @@ type Window struct {
 		at        f32.Point
 		w         Widget
 	}
-	notification notification
+	notifications notifications
 	windowFrameState
 
 	textLengths    *tinylfu.T[string, layout.Dimensions]
@@ -132,6 +139,25 @@ func (win *Window) TextDimensions(gtx layout.Context, l widget.Label, font font.
 	return dims
 }
 
+const (
+	MinScale  = 0.3
+	MaxScale  = 5
+	ScaleStep = 0.1
+)
+
+// Scale returns the UI scale of the window, which is applied on top of the scaling of the operating system.
+func (win *Window) Scale() float32 {
+	return win.scale
+}
+
+// SetScale sets the UI scale of the window, clamped to [MinScale, MaxScale].
+func (win *Window) SetScale(scale float32) {
+	// Round to avoid accumulating floating point error when repeatedly stepping the scale.
+	scale = float32(math.Round(float64(scale)*100) / 100)
+	win.scale = min(max(scale, MinScale), MaxScale)
+	win.AppWindow.Invalidate()
+}
+
 func (win *Window) AddCommandProvider(cp CommandProvider) {
 	win.commandProviders = append(win.commandProviders, cp)
 }
@@ -147,6 +173,7 @@ func NewWindow(win *app.Window) *Window {
 		oklchToSRGB: tinylfu.New[color.Oklch, stdcolor.NRGBA](1024, 1024*10),
 		shortcuts:   map[Shortcut]struct{}{},
 		scale:       1,
+		Selection:   &Selection{},
 		colorMaterials: map[struct {
 			ops *op.Ops
 			c   color.Oklch
@@ -156,6 +183,7 @@ func NewWindow(win *app.Window) *Window {
 
 type windowFrameState struct {
 	tooltip Widget
+	status  []StatusSegment
 }
 
 type Widget func(win *Window, gtx layout.Context) layout.Dimensions
@@ -213,7 +241,10 @@ func (win *Window) Update(ops *op.Ops, ev system.FrameEvent, w func(win *Window,
 	gtx := layout.NewContext(ops, ev)
 	gtx.Metric.PxPerDp *= win.scale
 	gtx.Metric.PxPerSp *= win.scale
-	win.windowFrameState = windowFrameState{}
+	win.windowFrameState = windowFrameState{
+		// Reuse the backing array to avoid allocating every frame.
+		status: win.status[:0],
+	}
 	win.pressedShortcuts = win.pressedShortcuts[:0]
 	clear(win.colorMaterials)
 
@@ -224,16 +255,6 @@ func (win *Window) Update(ops *op.Ops, ev system.FrameEvent, w func(win *Window,
 				continue
 			}
 
-			if ev.Modifiers == key.ModCtrl {
-				if ev.Name == "=" || ev.Name == "+" {
-					win.scale = min(win.scale+0.1, 5)
-				} else if ev.Name == "-" {
-					win.scale = max(win.scale-0.1, 0.3)
-				} else if ev.Name == "0" {
-					win.scale = 1
-				}
-			}
-
 			key := Shortcut{ev.Modifiers, ev.Name}
 			if _, ok := win.shortcuts[key]; ok {
 				win.pressedShortcuts = append(win.pressedShortcuts, key)
@@ -260,6 +281,13 @@ func (win *Window) Update(ops *op.Ops, ev system.FrameEvent, w func(win *Window,
 
 	for _, item := range win.contextMenu {
 		if item.Clicked(gtx) {
+			if item.Submenu != nil {
+				// Replace the menu with the submenu, keeping its position.
+				at := win.modal.at
+				win.SetContextMenu(item.Submenu())
+				win.modal.at = at
+				break
+			}
 			win.CloseModal()
 			win.EmitAction(item.Action())
 			win.contextMenu = nil
@@ -272,6 +300,7 @@ func (win *Window) Update(ops *op.Ops, ev system.FrameEvent, w func(win *Window,
 func (win *Window) Layout(ops *op.Ops, ev system.FrameEvent, w func(win *Window, gtx layout.Context) layout.Dimensions) {
 	defer rtrace.StartRegion(context.Background(), "theme.Window.Layout").End()
 
+	win.HUD.startFrame()
 	gtx := layout.NewContext(ops, ev)
 	gtx.Metric.PxPerDp *= win.scale
 	gtx.Metric.PxPerSp *= win.scale
@@ -281,16 +310,40 @@ func (win *Window) Layout(ops *op.Ops, ev system.FrameEvent, w func(win *Window,
 	key.InputOp{Tag: win}.Add(gtx.Ops)
 	pointer.InputOp{Tag: win, Kinds: 0xFF}.Add(gtx.Ops)
 
+	content := func(gtx layout.Context) layout.Dimensions {
+		if !win.StatusBar {
+			return w(win, gtx)
+		}
+
+		// Reserve space for the status bar, but only lay it out after the content, as the content adds the
+		// segments.
+		sb := StatusBar(win.Theme, nil)
+		h := sb.height(win, gtx)
+		size := gtx.Constraints.Max
+		func() {
+			gtx := gtx
+			gtx.Constraints.Max.Y = max(0, size.Y-h)
+			gtx.Constraints.Min.Y = min(gtx.Constraints.Min.Y, gtx.Constraints.Max.Y)
+			defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+			w(win, gtx)
+		}()
+
+		defer op.Offset(image.Pt(0, size.Y-h)).Push(gtx.Ops).Pop()
+		sb.Segments = win.status
+		sb.Layout(win, gtx)
+		return layout.Dimensions{Size: size}
+	}
+
 	if win.Menu != nil {
 		dims := NewMenuStyle(win.Theme, win.Menu).Layout(win, gtx)
 		layout.PixelInset{
 			Top: dims.Size.Y,
-		}.Layout(gtx, Dumb(win, w))
+		}.Layout(gtx, content)
 	} else {
-		w(win, gtx)
+		content(gtx)
 	}
 
-	win.notification.Layout(win, gtx)
+	win.notifications.Layout(win, gtx)
 	stack.Pop()
 
 	if win.tooltip != nil {
@@ -376,6 +429,9 @@ func (win *Window) Layout(ops *op.Ops, ev system.FrameEvent, w func(win *Window,
 		})
 	}
 
+	win.HUD.endFrame(gtx.Ops)
+	win.HUD.Layout(win, gtx)
+
 	win.Futures.Sweep()
 }
 
@@ -406,36 +462,3 @@ func (win *Window) SetModal(w Widget) {
 func (win *Window) CloseModal() {
 	win.modal.w = nil
 }
-
-func (win *Window) ShowNotification(gtx layout.Context, msg string) {
-	win.notification.message = msg
-	win.notification.shownAt = gtx.Now
-}
-
-type notification struct {
-	message string
-	shownAt time.Time
-}
-
-func (notif *notification) Layout(win *Window, gtx layout.Context) layout.Dimensions {
-	defer rtrace.StartRegion(context.Background(), "theme.notification.Layout").End()
-
-	if gtx.Now.After(notif.shownAt.Add(1000 * time.Millisecond)) {
-		return layout.Dimensions{}
-	}
-
-	// XXX compute width based on window size
-	// TODO(dh): limit height to something sensible, just in case
-	ngtx := gtx
-	ngtx.Constraints.Max.X = 500
-	macro := op.Record(gtx.Ops)
-	dims := BorderedText(win.Theme, notif.message).Layout(win, ngtx)
-	call := macro.Stop()
-
-	defer op.Offset(image.Pt(gtx.Constraints.Max.X/2-dims.Size.X/2, gtx.Constraints.Max.Y-dims.Size.Y-gtx.Dp(30))).Push(gtx.Ops).Pop()
-	call.Add(gtx.Ops)
-
-	op.InvalidateOp{At: notif.shownAt.Add(1000 * time.Millisecond)}.Add(gtx.Ops)
-
-	return dims
-}
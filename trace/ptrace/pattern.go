@@ -210,6 +210,18 @@ var patterns = [256][]pattern{
 			at:    1,
 		},
 	},
+
+	StateBlockedSyscall: {
+		{
+			// The runtime treats calls into C like syscalls. Skipping runtime frames will move At to the cgo
+			// wrapper, which is named after the C function.
+			state: StateBlockedSyscall,
+			relFns: [][]string{
+				{"runtime.cgocall"},
+			},
+			newState: StateCgo,
+		},
+	},
 }
 
 func applyPatterns(tr *Trace, s Span, pcs []uint64) Span {
@@ -225,7 +237,7 @@ patternLoop:
 			if fn == "" {
 				continue
 			}
-			if tr.PCs[pcs[i]].Func != fn {
+			if tr.FuncName(pcs[i]) != fn {
 				continue patternLoop
 			}
 		}
@@ -244,7 +256,7 @@ patternLoop:
 					if fn == "" {
 						continue
 					}
-					if tr.PCs[pcs[i+start]].Func != fn {
+					if tr.FuncName(pcs[i+start]) != fn {
 						continue offsetLoop
 					}
 				}
@@ -3,16 +3,15 @@ package ptrace
 
 import (
 	"cmp"
-	"errors"
 	"fmt"
 	"io"
-	"log"
 	"reflect"
 	"runtime"
 	"sort"
 	"strings"
 	"sync"
 	"time"
+	"unsafe"
 
 	"honnef.co/go/gotraceui/container"
 	"honnef.co/go/gotraceui/mem"
@@ -45,6 +44,8 @@ const (
 	StateBlockedNet
 	StateBlockedGC
 	StateBlockedSyscall
+	// The goroutine is calling into C via cgo
+	StateCgo
 	StateWaitingPreempted
 	StateStuck
 	StateReady
@@ -70,6 +71,20 @@ const (
 	StateLast
 )
 
+// StackID is the index of a stack in Trace.Stacks. The zero StackID refers to the empty stack.
+type StackID uint32
+
+// StringID is the index of a string in Trace.Strings. The zero StringID refers to the empty string.
+type StringID uint32
+
+// Frame is a stack frame. Its function name and file path are stored in the trace's string table.
+type Frame struct {
+	PC   uint64
+	Func StringID
+	File StringID
+	Line uint64
+}
+
 type Metric struct {
 	Timestamps []exptrace.Time
 	Values     []uint64
@@ -89,13 +104,56 @@ type Trace struct {
 	CPUSamplesByG map[exptrace.GoID][]EventID
 	CPUSamplesByP map[exptrace.ProcID][]EventID
 	Events        mem.LargeBucketSlice[exptrace.Event]
-	PCs           map[uint64]exptrace.StackFrame
-	Stacks        map[exptrace.Stack][]uint64
+	// Stacks, Frames, and Strings are shared by all generations of the trace. Every distinct stack, identified by
+	// its PCs, is stored once in Stacks, and every distinct function name and file path is stored once in Strings,
+	// no matter how many generations and events repeat them. Use StackID to look up the stack of an event, and
+	// FuncName and StackFrame to look up frames.
+	Stacks  [][]uint64
+	Frames  map[uint64]Frame
+	Strings []string
+	// Warnings are the problems that were encountered while processing the trace.
+	Warnings []Warning
 
 	gsByID map[exptrace.GoID]*Goroutine
 	// psByID and msById will be unset after parsing finishes
 	psByID map[exptrace.ProcID]*Processor
 	msByID map[exptrace.ThreadID]*Machine
+	// warningsByKey will be unset after parsing finishes
+	warningsByKey map[warningKey]int
+
+	// stackIDs, stackIDsByPCs, and stringIDs will be unset after parsing finishes. Afterwards, stacks are looked up
+	// in stackIDsByTable, or in sparseStackIDs for the few stacks whose IDs are too sparse to be stored densely.
+	stackIDs        map[exptrace.Stack]StackID
+	stackIDsByPCs   map[string]StackID
+	stringIDs       map[string]StringID
+	stackIDsByTable map[unsafe.Pointer][]StackID
+	sparseStackIDs  map[stackRef]StackID
+}
+
+// stackRef mirrors the layout of exptrace.Stack, which consists of a pointer to its generation's tables and the
+// stack's ID in that generation.
+type stackRef struct {
+	table unsafe.Pointer
+	id    uint64
+}
+
+// Make sure that stackRef and exptrace.Stack stay the same size.
+var _ = [1]struct{}{}[unsafe.Sizeof(exptrace.Stack{})-unsafe.Sizeof(stackRef{})]
+
+func toStackRef(stk exptrace.Stack) stackRef {
+	return *(*stackRef)(unsafe.Pointer(&stk))
+}
+
+func (t *Trace) intern(str string) StringID {
+	if id, ok := t.stringIDs[str]; ok {
+		return id
+	}
+	// Copy the string so that we don't keep the generation's string table alive.
+	str = strings.Clone(str)
+	id := StringID(len(t.Strings))
+	t.Strings = append(t.Strings, str)
+	t.stringIDs[str] = id
+	return id
 }
 
 func (t *Trace) addStack(stk exptrace.Stack) {
@@ -103,7 +161,7 @@ func (t *Trace) addStack(stk exptrace.Stack) {
 		return
 	}
 
-	if _, ok := t.Stacks[stk]; ok {
+	if _, ok := t.stackIDs[stk]; ok {
 		return
 	}
 
@@ -133,12 +191,95 @@ func (t *Trace) addStack(stk exptrace.Stack) {
 	table := reflect.ValueOf(stk).FieldByName("table")
 	stacks := (*dataTable)(table.Elem().FieldByName("stacks").Addr().UnsafePointer())
 	pcs, _ := get(stacks, reflect.ValueOf(stk).FieldByName("id").Uint())
-	t.Stacks[stk] = pcs
+	if len(pcs) == 0 {
+		t.stackIDs[stk] = 0
+		return
+	}
 
-	stk.Frames(func(f exptrace.StackFrame) bool {
-		t.PCs[f.PC] = f
-		return true
-	})
+	// Stacks are only identified by their PCs, so that generations repeating the same stacks share them.
+	key := unsafe.String((*byte)(unsafe.Pointer(&pcs[0])), len(pcs)*8)
+	id, ok := t.stackIDsByPCs[key]
+	if !ok {
+		// Copy the PCs so that we don't keep the generation's stack table alive. The key has to refer to the copy,
+		// too.
+		pcs = slices.Clone(pcs)
+		key = unsafe.String((*byte)(unsafe.Pointer(&pcs[0])), len(pcs)*8)
+		id = StackID(len(t.Stacks))
+		t.Stacks = append(t.Stacks, pcs)
+		t.stackIDsByPCs[key] = id
+
+		stk.Frames(func(f exptrace.StackFrame) bool {
+			if _, ok := t.Frames[f.PC]; !ok {
+				t.Frames[f.PC] = Frame{PC: f.PC, Func: t.intern(f.Func), File: t.intern(f.File), Line: f.Line}
+			}
+			return true
+		})
+	}
+	t.stackIDs[stk] = id
+}
+
+// StackID returns the ID of a stack, which is zero for the empty stack.
+func (t *Trace) StackID(stk exptrace.Stack) StackID {
+	if t.stackIDs != nil {
+		// We're still parsing.
+		return t.stackIDs[stk]
+	}
+	ref := toStackRef(stk)
+	if ids, ok := t.stackIDsByTable[ref.table]; ok && ref.id < uint64(len(ids)) {
+		return ids[ref.id]
+	}
+	return t.sparseStackIDs[ref]
+}
+
+// compactStackIDs replaces the map of stacks to stack IDs with one slice per generation, indexed by the stacks' IDs
+// in the generation. Most generations number their stacks densely, which makes this much smaller than the map.
+func (t *Trace) compactStackIDs() {
+	type tableInfo struct {
+		n     int
+		maxID uint64
+	}
+	tables := map[unsafe.Pointer]tableInfo{}
+	for stk, id := range t.stackIDs {
+		if id == 0 {
+			continue
+		}
+		ref := toStackRef(stk)
+		info := tables[ref.table]
+		info.n++
+		info.maxID = max(info.maxID, ref.id)
+		tables[ref.table] = info
+	}
+
+	t.stackIDsByTable = make(map[unsafe.Pointer][]StackID, len(tables))
+	t.sparseStackIDs = map[stackRef]StackID{}
+	for table, info := range tables {
+		if info.maxID < uint64(2*info.n+1024) {
+			t.stackIDsByTable[table] = make([]StackID, info.maxID+1)
+		}
+	}
+	for stk, id := range t.stackIDs {
+		if id == 0 {
+			continue
+		}
+		ref := toStackRef(stk)
+		if ids, ok := t.stackIDsByTable[ref.table]; ok {
+			ids[ref.id] = id
+		} else {
+			t.sparseStackIDs[ref] = id
+		}
+	}
+	t.stackIDs = nil
+}
+
+// FuncName returns the name of the function of a PC.
+func (t *Trace) FuncName(pc uint64) string {
+	return t.Strings[t.Frames[pc].Func]
+}
+
+// StackFrame returns the frame of a PC.
+func (t *Trace) StackFrame(pc uint64) exptrace.StackFrame {
+	f := t.Frames[pc]
+	return exptrace.StackFrame{PC: f.PC, Func: t.Strings[f.Func], File: t.Strings[f.File], Line: f.Line}
 }
 
 // Start returns the time of the first event in the trace.
@@ -262,8 +403,13 @@ type Machine struct {
 	// OPT(dh): using Span for Ms is wasteful. We don't need tags, stacktrace offsets etc. We only care about what
 	// processor is running at what time. The only benefit of reusing Span is that we can use the same code for
 	// rendering Gs and Ms, but that doesn't seem worth the added cost.
-	Spans      []Span
+	Spans []Span
+	// Goroutines are the spans during which the thread was running a goroutine (StateActive) or was blocked in a
+	// syscall on behalf of a goroutine (StateBlockedSyscall). The spans start at the goroutines' state transitions.
 	Goroutines []Span
+	// The times of the first and last events on the thread. The runtime doesn't emit events for the creation and
+	// destruction of threads, so this is our best approximation of the thread's lifetime.
+	Start, End exptrace.Time
 }
 
 type Processor struct {
@@ -400,8 +546,13 @@ func Parse(r *exptrace.Reader, progress func(float64)) (*Trace, error) {
 		Metrics:       map[string]Metric{},
 		GC:            make(spansSlice, 0),
 		STW:           make(spansSlice, 0),
-		PCs:           make(map[uint64]exptrace.StackFrame),
-		Stacks:        make(map[exptrace.Stack][]uint64),
+		Stacks:        [][]uint64{nil},
+		Frames:        make(map[uint64]Frame),
+		Strings:       []string{""},
+		warningsByKey: map[warningKey]int{},
+		stackIDs:      map[exptrace.Stack]StackID{},
+		stackIDsByPCs: map[string]StackID{},
+		stringIDs:     map[string]StringID{"": 0},
 	}
 
 	makeProgresser := func(stage int, numStages int) func(float64) {
@@ -422,6 +573,10 @@ func Parse(r *exptrace.Reader, progress func(float64)) (*Trace, error) {
 
 	tr.psByID = nil
 	tr.msByID = nil
+	tr.warningsByKey = nil
+	tr.stackIDsByPCs = nil
+	tr.stringIDs = nil
+	tr.compactStackIDs()
 
 	return tr, nil
 }
@@ -523,6 +678,15 @@ func processEvents(r *exptrace.Reader, tr *Trace, progress func(float64)) error
 		tr.psByID[pid] = p
 		return p
 	}
+	getM := func(mid exptrace.ThreadID) *Machine {
+		m, ok := tr.msByID[mid]
+		if ok {
+			return m
+		}
+		m = &Machine{ID: mid}
+		tr.msByID[mid] = m
+		return m
+	}
 	addEventToCurrentSpan := func(gid exptrace.GoID, ev EventID) {
 		g := getG(gid)
 		g.Events = append(g.Events, ev)
@@ -548,6 +712,8 @@ func processEvents(r *exptrace.Reader, tr *Trace, progress func(float64)) error
 
 	synced := false
 	userRegionDepths := map[exptrace.GoID]int{}
+	// The threads that goroutines are running on or are blocked in syscalls on.
+	goroutineMs := map[exptrace.GoID]*Machine{}
 	var traceStart exptrace.Time
 	var gm goroutineMetrics
 	for {
@@ -569,9 +735,22 @@ func processEvents(r *exptrace.Reader, tr *Trace, progress func(float64)) error
 			gm.blockedGoroutines.add(traceStart, 0)
 		}
 
+		if tr.Events.Len() != 0 && ev.Time() < tr.Events.Ptr(tr.Events.Len()-1).Time() {
+			tr.warn(WarningOutOfOrder, ev.Time(), "event has an earlier timestamp than the event before it", nil)
+		}
+
 		evID := EventID(tr.Events.Len())
 		tr.Events.Append(ev)
 
+		if mid := ev.Thread(); mid != exptrace.NoThread {
+			m, ok := tr.msByID[mid]
+			if !ok {
+				m = &Machine{ID: mid, Start: ev.Time()}
+				tr.msByID[mid] = m
+			}
+			m.End = ev.Time()
+		}
+
 		// Cache all stacks
 		tr.addStack(ev.Stack())
 		if ev.Kind() == exptrace.EventStateTransition {
@@ -587,11 +766,13 @@ func processEvents(r *exptrace.Reader, tr *Trace, progress func(float64)) error
 			case exptrace.ResourceGoroutine:
 				g := getG(l.Resource.Goroutine())
 				if len(g.Spans) == 0 {
-					return fmt.Errorf("got label for goroutine %d but it has no spans", g.ID)
+					tr.warn(WarningInconsistent, ev.Time(), "label %q for goroutine without spans", l.Label)
+					continue
 				}
 				span := &g.Spans[len(g.Spans)-1]
 				if tr.Events.Ptr(int(span.StartEvent)).Kind() != exptrace.EventStateTransition {
-					return fmt.Errorf("got label for goroutine %d but last span isn't a state transition", g.ID)
+					tr.warn(WarningInconsistent, ev.Time(), "label %q for goroutine whose last span isn't a state transition", l.Label)
+					continue
 				}
 				switch l.Label {
 				case "GC (dedicated)":
@@ -601,7 +782,7 @@ func processEvents(r *exptrace.Reader, tr *Trace, progress func(float64)) error
 				case "GC (fractional)":
 					span.State = StateGCFractional
 				default:
-					log.Printf("unhandled label %q", l.Label)
+					tr.warn(WarningUnsupported, ev.Time(), "unknown goroutine label %q", l.Label)
 				}
 			default:
 				panic(fmt.Sprintf("unhandled kind %s", l.Resource.Kind))
@@ -635,6 +816,7 @@ func processEvents(r *exptrace.Reader, tr *Trace, progress func(float64)) error
 
 			switch scope := rangeActualScope(r); scope {
 			case rangeScopeUnknown:
+				tr.warn(WarningUnsupported, ev.Time(), "range %q of unknown scope", r.Name)
 			case rangeScopeGC:
 				tr.GC = append(tr.GC, s)
 			case rangeScopeSTW:
@@ -647,8 +829,10 @@ func processEvents(r *exptrace.Reader, tr *Trace, progress func(float64)) error
 				p.Ranges[r.Name] = append(p.Ranges[r.Name], s)
 			case rangeScopeThread:
 				// XXX implement
+				tr.warn(WarningUnsupported, ev.Time(), "range %q of a thread", r.Name)
 			case rangeScopeGlobal:
 				// XXX implement
+				tr.warn(WarningUnsupported, ev.Time(), "global range %q", r.Name)
 			default:
 				panic(fmt.Sprintf("unhandled range scope %d for range %q", scope, r.Name))
 			}
@@ -668,10 +852,9 @@ func processEvents(r *exptrace.Reader, tr *Trace, progress func(float64)) error
 			case rangeScopeProc:
 				p := getP(r.Scope.Proc())
 				prev = &p.Ranges[r.Name][len(p.Ranges[r.Name])-1]
-			case rangeScopeThread:
-				// XXX implement
-			case rangeScopeGlobal:
+			case rangeScopeThread, rangeScopeGlobal:
 				// XXX implement
+				continue
 			default:
 				panic(fmt.Sprintf("unhandled range scope %d", scope))
 			}
@@ -725,6 +908,9 @@ func processEvents(r *exptrace.Reader, tr *Trace, progress func(float64)) error
 				}
 			}
 		case exptrace.EventStackSample:
+			if ev.Stack() == exptrace.NoStack {
+				tr.warn(WarningMissingStack, ev.Time(), "CPU sample without a stack", nil)
+			}
 			tr.CPUSamples = append(tr.CPUSamples, evID)
 			if gid := ev.Goroutine(); gid != exptrace.NoGoroutine {
 				tr.CPUSamplesByG[gid] = append(tr.CPUSamplesByG[gid], evID)
@@ -821,7 +1007,8 @@ func processEvents(r *exptrace.Reader, tr *Trace, progress func(float64)) error
 						case exptrace.GoNotExist:
 							p := getP(ev.Proc())
 							if len(p.Spans) == 0 {
-								return errors.New("transitioning to GoNotExist but don't have existing span")
+								tr.warn(WarningInconsistent, ev.Time(), "goroutine exited on processor without spans", nil)
+								break
 							}
 							last := &p.Spans[len(p.Spans)-1]
 							last.End = ev.Time()
@@ -861,6 +1048,28 @@ func processEvents(r *exptrace.Reader, tr *Trace, progress func(float64)) error
 						}
 					}
 				}
+				if m, ok := goroutineMs[g.ID]; ok {
+					last := &m.Goroutines[len(m.Goroutines)-1]
+					last.End = ev.Time()
+					last.EndEvent = evID
+					delete(goroutineMs, g.ID)
+				}
+				if mid := ev.Thread(); mid != exptrace.NoThread && (to == exptrace.GoRunning || to == exptrace.GoSyscall) {
+					m := getM(mid)
+					ms := Span{
+						Start:      s.Start,
+						StartEvent: evID,
+						Kind:       SpanKindStateTransition,
+						State:      StateActive,
+						EndEvent:   -1,
+					}
+					if to == exptrace.GoSyscall {
+						ms.State = StateBlockedSyscall
+					}
+					m.Goroutines = append(m.Goroutines, ms)
+					goroutineMs[g.ID] = m
+				}
+
 				switch to {
 				case exptrace.GoNotExist:
 					g.End = container.Some(ev.Time())
@@ -927,7 +1136,8 @@ func processEvents(r *exptrace.Reader, tr *Trace, progress func(float64)) error
 					case "":
 						s.State = StateBlocked
 					default:
-						log.Printf("unhandled reason %q", trans.Reason)
+						tr.warn(WarningUnsupported, ev.Time(), "unknown reason %q for blocking", trans.Reason)
+						s.State = StateBlocked
 					}
 				default:
 					panic(fmt.Sprintf("unhandled state %s", to))
@@ -940,7 +1150,8 @@ func processEvents(r *exptrace.Reader, tr *Trace, progress func(float64)) error
 			t := ev.Task()
 			idx, ok := tr.task(t.ID)
 			if ok {
-				panic("task already exists")
+				tr.warn(WarningInconsistent, ev.Time(), "task began after events referring to it", nil)
+				continue
 			}
 			task := &Task{
 				ID:         t.ID,
@@ -984,6 +1195,7 @@ func processEvents(r *exptrace.Reader, tr *Trace, progress func(float64)) error
 			}
 		case exptrace.EventExperimental:
 			// TODO(dh): do something useful with these
+			tr.warn(WarningUnsupported, ev.Time(), "experimental event %s", ev.Experimental().Name)
 		default:
 			panic(fmt.Sprintf("unhandled kind %s", ev.Kind()))
 		}
@@ -995,7 +1207,7 @@ func processEvents(r *exptrace.Reader, tr *Trace, progress func(float64)) error
 			s := &g.Spans[i]
 			if s.StartEvent != 0 {
 				if stk := tr.Event(s.StartEvent).StateTransition().Stack; stk != exptrace.NoStack {
-					pcs := tr.Stacks[stk]
+					pcs := tr.Stacks[tr.StackID(stk)]
 					if len(pcs) > 0 {
 						f := tr.function(pcs[len(pcs)-1])
 						g.Function = f
@@ -1005,6 +1217,10 @@ func processEvents(r *exptrace.Reader, tr *Trace, progress func(float64)) error
 				}
 			}
 		}
+		if start, ok := g.Start.Get(); ok && g.Function == nil {
+			// Goroutine creation events always have the stack of the new goroutine.
+			tr.warn(WarningMissingStack, start, "couldn't determine the function of goroutine", nil)
+		}
 	}
 
 	tr.Metrics["/gotraceui/sched/goroutines/runnable:goroutines"] = Metric{
@@ -1028,11 +1244,11 @@ func postProcessSpans(tr *Trace, progress func(float64)) {
 	doG := func(g *Goroutine) {
 		for i := 0; i < len(g.Spans); i++ {
 			s := g.Spans[i]
-			pcs := tr.Stacks[tr.Events.Ptr(int(s.StartEvent)).Stack()]
+			pcs := tr.Stacks[tr.StackID(tr.Events.Ptr(int(s.StartEvent)).Stack())]
 			s = applyPatterns(tr, s, pcs)
 
 			// move s.At out of the runtime
-			for int(s.At+1) < len(pcs) && s.At < 255 && strings.HasPrefix(tr.PCs[pcs[s.At]].Func, "runtime.") {
+			for int(s.At+1) < len(pcs) && s.At < 255 && strings.HasPrefix(tr.FuncName(pcs[s.At]), "runtime.") {
 				s.At++
 			}
 
@@ -1173,6 +1389,11 @@ func populateObjects(tr *Trace, progress func(float64)) {
 				last.End = tr.Events.Ptr(tr.Events.Len() - 1).Time()
 			}
 		}
+		if len(m.Goroutines) > 0 {
+			if last := &m.Goroutines[len(m.Goroutines)-1]; last.EndEvent == -1 {
+				last.End = tr.Events.Ptr(tr.Events.Len() - 1).Time()
+			}
+		}
 	}
 	progress(3.0 / 5.0)
 
@@ -1201,7 +1422,7 @@ func populateObjects(tr *Trace, progress func(float64)) {
 }
 
 func (t *Trace) function(pc uint64) *Function {
-	frame := t.PCs[pc]
+	frame := t.StackFrame(pc)
 	f, ok := t.Functions[frame.Func]
 	if ok {
 		return f
@@ -0,0 +1,106 @@
+package ptrace
+
+import (
+	"os"
+	"slices"
+	"testing"
+	"unsafe"
+
+	exptrace "golang.org/x/exp/trace"
+)
+
+func TestParseStacks(t *testing.T) {
+	// testdata/go122-annotations.trace is x/exp/trace's go122-annotations test, converted with gotraceraw text2bytes.
+	f, err := os.Open("testdata/go122-annotations.trace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	r, err := exptrace.NewReader(f)
+	if err != nil {
+		t.Fatalf("can't read trace: %s", err)
+	}
+	tr, err := Parse(r, func(float64) {})
+	if err != nil {
+		t.Fatalf("can't parse trace: %s", err)
+	}
+	if tr.stackIDs != nil || tr.stackIDsByPCs != nil || tr.stringIDs != nil {
+		t.Error("interning maps weren't dropped after parsing")
+	}
+
+	var n int
+	for i := 0; i < tr.Events.Len(); i++ {
+		ev := tr.Events.Ptr(i)
+		stk := ev.Stack()
+		var want []uint64
+		stk.Frames(func(f exptrace.StackFrame) bool {
+			want = append(want, f.PC)
+			if got := tr.StackFrame(f.PC); got != f {
+				t.Errorf("got frame %v, want %v", got, f)
+			}
+			return true
+		})
+		if got := tr.Stacks[tr.StackID(stk)]; !slices.Equal(got, want) {
+			t.Fatalf("event %d: got stack %v, want %v", i, got, want)
+		}
+		n += len(want)
+	}
+	if n == 0 {
+		t.Fatal("trace has no stacks")
+	}
+}
+
+func TestCompactStackIDs(t *testing.T) {
+	var tables [2]byte
+	stack := func(table int, id uint64) exptrace.Stack {
+		ref := stackRef{table: unsafe.Pointer(&tables[table]), id: id}
+		return *(*exptrace.Stack)(unsafe.Pointer(&ref))
+	}
+
+	tr := &Trace{stackIDs: map[exptrace.Stack]StackID{}}
+	want := map[exptrace.Stack]StackID{}
+	for i := range uint64(100) {
+		want[stack(0, i+1)] = StackID(i + 1)
+	}
+	want[stack(0, 200)] = 0
+	// A generation with sparse stack IDs.
+	want[stack(1, 1<<40)] = 7
+	want[stack(1, 3)] = 8
+	for stk, id := range want {
+		tr.stackIDs[stk] = id
+	}
+
+	tr.compactStackIDs()
+	if tr.stackIDs != nil {
+		t.Error("map of stacks wasn't dropped")
+	}
+	for stk, id := range want {
+		if got := tr.StackID(stk); got != id {
+			t.Errorf("%v: got ID %d, want %d", toStackRef(stk), got, id)
+		}
+	}
+	for _, stk := range []exptrace.Stack{stack(0, 150), stack(0, 1000), stack(1, 4)} {
+		if got := tr.StackID(stk); got != 0 {
+			t.Errorf("%v: got ID %d for unknown stack, want 0", toStackRef(stk), got)
+		}
+	}
+}
+
+func TestStatisticsCategories(t *testing.T) {
+	var stats Statistics
+	stats[StateActive].Total = 1
+	stats[StateBlockedSyscall].Total = 10
+	stats[StateCgo].Total = 100
+	stats[StateReady].Total = 1000
+
+	if got := stats.Running(); got != 1 {
+		t.Errorf("Running: got %v, want 1ns", got)
+	}
+	// Calls into C are neither running Go code nor blocked.
+	if got := stats.Blocked(); got != 10 {
+		t.Errorf("Blocked: got %v, want 10ns", got)
+	}
+	if got := stats.Inactive(); got != 1000 {
+		t.Errorf("Inactive: got %v, want 1µs", got)
+	}
+}
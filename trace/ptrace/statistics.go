@@ -1,6 +1,7 @@
 package ptrace
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"sort"
@@ -54,6 +55,16 @@ func (spans spansSlice) AtPtr(idx int) *Span { return &spans[idx] }
 func (spans spansSlice) Len() int            { return len(spans) }
 
 func ComputeStatistics(spans Spans) Statistics {
+	stats, _ := ComputeStatisticsProgress(context.Background(), spans, func(float64) {})
+	return stats
+}
+
+// ComputeStatisticsProgress is like ComputeStatistics, but reports its progress and stops early when ctx gets
+// cancelled, returning the context's error.
+func ComputeStatisticsProgress(ctx context.Context, spans Spans, progress func(float64)) (Statistics, error) {
+	// How many spans to process between checking for cancellation and reporting progress.
+	const chunk = 10_000
+
 	var values [StateLast][]time.Duration
 
 	var stats Statistics
@@ -62,7 +73,15 @@ func ComputeStatistics(spans Spans) Statistics {
 		values[i] = values[i][:0]
 	}
 
-	for i := 0; i < spans.Len(); i++ {
+	n := spans.Len()
+	for i := 0; i < n; i++ {
+		if i%chunk == 0 {
+			if err := ctx.Err(); err != nil {
+				return Statistics{}, err
+			}
+			// Sorting the durations takes about as long as collecting them.
+			progress(float64(i) / float64(n) / 2)
+		}
 		s := spans.AtPtr(i)
 		stat := &stats[s.State]
 		stat.Count++
@@ -77,12 +96,18 @@ func ComputeStatistics(spans Spans) Statistics {
 		values[s.State] = append(values[s.State], d)
 	}
 
+	sorted := 0
 	for state := range stats {
 		stat := &stats[state]
 
 		if len(values[state]) == 0 {
 			continue
 		}
+		if err := ctx.Err(); err != nil {
+			return Statistics{}, err
+		}
+		progress(0.5 + float64(sorted)/float64(n)/2)
+		sorted += len(values[state])
 
 		stat.Average = float64(stat.Total) / float64(len(values[state]))
 
@@ -97,6 +122,7 @@ func ComputeStatistics(spans Spans) Statistics {
 			stat.Median = float64(values[state][len(values[state])/2])
 		}
 	}
+	progress(1)
 
-	return stats
+	return stats, nil
 }
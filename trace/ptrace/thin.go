@@ -0,0 +1,50 @@
+package ptrace
+
+import (
+	"time"
+)
+
+// Thin merges the spans of goroutines and processors that are shorter than threshold into the spans preceding them,
+// trading detail for fewer spans to display and analyze. Only spans that directly follow the preceding span are
+// merged, so that gaps, such as the time processors spend idle, are preserved. The first span of each goroutine and processor is always
+// kept. Events aren't removed, and spans keep referring to valid events, but merged spans take on the state of the
+// first span that was merged.
+//
+// Thin returns the number of spans that were removed.
+func Thin(tr *Trace, threshold time.Duration) int {
+	if threshold <= 0 {
+		return 0
+	}
+	var removed int
+	for _, g := range tr.Goroutines {
+		n := len(g.Spans)
+		g.Spans = thinSpans(g.Spans, threshold)
+		removed += n - len(g.Spans)
+	}
+	for _, p := range tr.Processors {
+		n := len(p.Spans)
+		p.Spans = thinSpans(p.Spans, threshold)
+		removed += n - len(p.Spans)
+	}
+	return removed
+}
+
+func thinSpans(spans []Span, threshold time.Duration) []Span {
+	if len(spans) == 0 {
+		return spans
+	}
+	out := spans[:1]
+	for _, s := range spans[1:] {
+		if last := &out[len(out)-1]; s.Duration() < threshold && last.End == s.Start {
+			last.End = s.End
+			last.EndEvent = s.EndEvent
+			continue
+		}
+		out = append(out, s)
+	}
+	// Release the memory of the removed spans if we removed a lot of them.
+	if cap(out) > 2*len(out) {
+		out = append([]Span(nil), out...)
+	}
+	return out
+}
@@ -0,0 +1,74 @@
+package ptrace
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	exptrace "golang.org/x/exp/trace"
+)
+
+func formatSpans(spans []Span) string {
+	var sb strings.Builder
+	for i, s := range spans {
+		if i > 0 {
+			sb.WriteString(" ")
+		}
+		fmt.Fprintf(&sb, "%d-%d", s.Start, s.End)
+	}
+	return sb.String()
+}
+
+func TestThinSpans(t *testing.T) {
+	span := func(start, end int) Span {
+		return Span{Start: exptrace.Time(start), End: exptrace.Time(end), StartEvent: EventID(start), EndEvent: EventID(end)}
+	}
+	tests := []struct {
+		name  string
+		spans []Span
+		want  string
+	}{
+		{
+			"no spans",
+			nil,
+			"",
+		},
+		{
+			"contiguous short spans",
+			[]Span{span(0, 100), span(100, 105), span(105, 108), span(108, 200)},
+			"0-108 108-200",
+		},
+		{
+			"first span is kept",
+			[]Span{span(0, 5), span(5, 8), span(8, 100)},
+			"0-8 8-100",
+		},
+		{
+			"gap before short span",
+			[]Span{span(0, 100), span(150, 155), span(155, 300)},
+			"0-100 150-155 155-300",
+		},
+		{
+			"gaps between short spans",
+			[]Span{span(0, 100), span(100, 105), span(200, 205), span(205, 207), span(300, 305)},
+			"0-105 200-207 300-305",
+		},
+		{
+			"long spans",
+			[]Span{span(0, 100), span(100, 200), span(250, 350)},
+			"0-100 100-200 250-350",
+		},
+	}
+	for _, tt := range tests {
+		got := thinSpans(tt.spans, 10*time.Nanosecond)
+		if s := formatSpans(got); s != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, s, tt.want)
+		}
+		for _, s := range got {
+			if s.EndEvent != EventID(s.End) {
+				t.Errorf("%s: span %d-%d ends with event %d", tt.name, s.Start, s.End, s.EndEvent)
+			}
+		}
+	}
+}
@@ -5,6 +5,7 @@ var legalStateTransitions = [256][StateLast]bool{
 		StateActive:         true,
 		StateReady:          true,
 		StateBlockedSyscall: true,
+		StateCgo:            true,
 
 		// Starting back into preempted mark assist
 		StateGCMarkAssist: true,
@@ -24,6 +25,7 @@ var legalStateTransitions = [256][StateLast]bool{
 		StateBlockedNet:              true,
 		StateBlockedGC:               true,
 		StateBlockedSyscall:          true,
+		StateCgo:                     true,
 		StateStuck:                   true,
 		StateDone:                    true,
 		StateGCMarkAssist:            true,
@@ -56,6 +58,7 @@ var legalStateTransitions = [256][StateLast]bool{
 		StateInactive:       true,
 		StateBlocked:        true,
 		StateBlockedSyscall: true,
+		StateCgo:            true,
 	},
 	StateReady: {
 		StateActive:       true,
@@ -77,6 +80,9 @@ var legalStateTransitions = [256][StateLast]bool{
 	StateBlockedSyscall: {
 		StateReady: true,
 	},
+	StateCgo: {
+		StateReady: true,
+	},
 
 	StateGCMarkAssist: {
 		// active -> ready occurs on preemption
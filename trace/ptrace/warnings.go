@@ -0,0 +1,73 @@
+package ptrace
+
+import (
+	"fmt"
+
+	exptrace "golang.org/x/exp/trace"
+)
+
+type WarningKind uint8
+
+const (
+	// The trace contains events, or properties of events, that we don't understand.
+	WarningUnsupported WarningKind = iota
+	// Events aren't in the order of their timestamps.
+	WarningOutOfOrder
+	// Events are lacking stacks that we expected them to have.
+	WarningMissingStack
+	// Events don't match the state that we've reconstructed from earlier events.
+	WarningInconsistent
+)
+
+func (k WarningKind) String() string {
+	switch k {
+	case WarningUnsupported:
+		return "unsupported"
+	case WarningOutOfOrder:
+		return "out of order"
+	case WarningMissingStack:
+		return "missing stack"
+	case WarningInconsistent:
+		return "inconsistent"
+	default:
+		return fmt.Sprintf("WarningKind(%d)", k)
+	}
+}
+
+// A Warning describes a problem that was encountered while processing a trace and that we could recover from, possibly
+// by ignoring events. All occurrences of the same problem are combined into one warning.
+type Warning struct {
+	Kind    WarningKind
+	Message string
+	// Count is the number of times the problem occurred.
+	Count int
+	// Start and End are the timestamps of the first and last occurrences of the problem.
+	Start exptrace.Time
+	End   exptrace.Time
+}
+
+type warningKey struct {
+	format string
+	arg    any
+}
+
+// warn records an occurrence of a problem at time ts. The problem is identified by format and arg, which get formatted
+// only once per problem, to keep warnings cheap for problems that occur for millions of events. arg may be nil if
+// format doesn't need an argument.
+func (t *Trace) warn(kind WarningKind, ts exptrace.Time, format string, arg any) {
+	key := warningKey{format, arg}
+	idx, ok := t.warningsByKey[key]
+	if !ok {
+		msg := format
+		if arg != nil {
+			msg = fmt.Sprintf(format, arg)
+		}
+		idx = len(t.Warnings)
+		t.Warnings = append(t.Warnings, Warning{Kind: kind, Message: msg, Start: ts, End: ts})
+		t.warningsByKey[key] = idx
+	}
+	w := &t.Warnings[idx]
+	w.Count++
+	w.Start = min(w.Start, ts)
+	w.End = max(w.End, ts)
+}
@@ -0,0 +1,216 @@
+// Package rawtrace operates on the wire format of runtime traces produced by Go 1.22 and later. It splits traces into
+// generations and batches without decoding individual events, which makes it cheap to extract self-contained parts of
+// a trace.
+package rawtrace
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	exptrace "golang.org/x/exp/trace"
+)
+
+const (
+	evEventBatch        = 1
+	evFrequency         = 8
+	evExperimentalBatch = 49
+
+	// maxBatchSize is the maximum size of a batch, as defined by the runtime.
+	maxBatchSize = 64 << 10
+
+	headerLen = 16
+)
+
+// Batch is a batch of events, as emitted by the runtime.
+type Batch struct {
+	// Experiment is the ID of the experiment for experimental batches and 0 otherwise.
+	Experiment   uint8
+	Experimental bool
+	Gen          uint64
+	M            uint64
+	// Time is the batch's base timestamp, in the runtime's timestamp units.
+	Time uint64
+	Data []byte
+}
+
+// Generation is a self-contained part of a trace. Each generation has its own string and stack tables, and begins
+// with a snapshot of the states of all goroutines and processors.
+type Generation struct {
+	Gen     uint64
+	Batches []Batch
+	// nsPerTick is the number of nanoseconds per timestamp unit. It is zero if the generation lacks a frequency batch.
+	nsPerTick float64
+	minTime   uint64
+	maxTime   uint64
+}
+
+// Start returns the earliest timestamp of the generation, in the same time base as the events produced by
+// golang.org/x/exp/trace.
+func (g *Generation) Start() exptrace.Time {
+	return exptrace.Time(float64(g.minTime) * g.nsPerTick)
+}
+
+// End returns the latest base timestamp of the generation's batches. Without decoding events we can't know the
+// timestamp of the generation's last event, but it is usually close to the base timestamp of the last batch.
+func (g *Generation) End() exptrace.Time {
+	return exptrace.Time(float64(g.maxTime) * g.nsPerTick)
+}
+
+// Trace is a trace split into its generations.
+type Trace struct {
+	// The trace's header, including the version.
+	Header      [headerLen]byte
+	Generations []Generation
+}
+
+// Read reads a trace in its entirety.
+func Read(r io.Reader) (*Trace, error) {
+	br := bufio.NewReader(r)
+	tr := &Trace{}
+	if _, err := io.ReadFull(br, tr.Header[:]); err != nil {
+		return nil, fmt.Errorf("couldn't read header: %w", err)
+	}
+	var minor int
+	if _, err := fmt.Sscanf(string(tr.Header[:]), "go 1.%d trace\x00\x00\x00", &minor); err != nil {
+		return nil, errors.New("not a runtime trace")
+	}
+	if minor < 22 || minor > 23 {
+		// Older traces aren't split into generations, and newer traces may use a different framing.
+		return nil, fmt.Errorf("unsupported trace version go 1.%d", minor)
+	}
+
+	for {
+		b, err := readBatch(br)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if n := len(tr.Generations); n == 0 || tr.Generations[n-1].Gen != b.Gen {
+			if n != 0 && b.Gen < tr.Generations[n-1].Gen {
+				return nil, fmt.Errorf("generation %d follows generation %d", b.Gen, tr.Generations[n-1].Gen)
+			}
+			tr.Generations = append(tr.Generations, Generation{Gen: b.Gen})
+		}
+		g := &tr.Generations[len(tr.Generations)-1]
+		g.Batches = append(g.Batches, b)
+		if g.minTime == 0 || (b.Time != 0 && b.Time < g.minTime) {
+			g.minTime = b.Time
+		}
+		g.maxTime = max(g.maxTime, b.Time)
+		if !b.Experimental && len(b.Data) > 0 && b.Data[0] == evFrequency {
+			freq, err := binary.ReadUvarint(bytes.NewReader(b.Data[1:]))
+			if err != nil {
+				return nil, fmt.Errorf("couldn't read frequency: %w", err)
+			}
+			if freq == 0 {
+				return nil, errors.New("invalid frequency of 0")
+			}
+			g.nsPerTick = 1e9 / float64(freq)
+		}
+	}
+
+	return tr, nil
+}
+
+func readBatch(r *bufio.Reader) (Batch, error) {
+	typ, err := r.ReadByte()
+	if err != nil {
+		return Batch{}, err
+	}
+	var b Batch
+	switch typ {
+	case evEventBatch:
+	case evExperimentalBatch:
+		b.Experimental = true
+		b.Experiment, err = r.ReadByte()
+		if err != nil {
+			return Batch{}, io.ErrUnexpectedEOF
+		}
+	default:
+		return Batch{}, fmt.Errorf("expected batch, got event type %d", typ)
+	}
+
+	var size uint64
+	for _, v := range []*uint64{&b.Gen, &b.M, &b.Time, &size} {
+		*v, err = binary.ReadUvarint(r)
+		if err != nil {
+			return Batch{}, fmt.Errorf("couldn't read batch header: %w", err)
+		}
+	}
+	if size > maxBatchSize {
+		return Batch{}, fmt.Errorf("invalid batch size %d", size)
+	}
+	b.Data = make([]byte, size)
+	if _, err := io.ReadFull(r, b.Data); err != nil {
+		return Batch{}, fmt.Errorf("couldn't read batch: %w", err)
+	}
+	return b, nil
+}
+
+// WriteTo writes the trace in the wire format.
+func (tr *Trace) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	n, _ := bw.Write(tr.Header[:])
+	total := int64(n)
+	var buf []byte
+	for _, g := range tr.Generations {
+		for _, b := range g.Batches {
+			buf = buf[:0]
+			if b.Experimental {
+				buf = append(buf, evExperimentalBatch, b.Experiment)
+			} else {
+				buf = append(buf, evEventBatch)
+			}
+			buf = binary.AppendUvarint(buf, b.Gen)
+			buf = binary.AppendUvarint(buf, b.M)
+			buf = binary.AppendUvarint(buf, b.Time)
+			buf = binary.AppendUvarint(buf, uint64(len(b.Data)))
+			n, _ := bw.Write(buf)
+			total += int64(n)
+			n, _ = bw.Write(b.Data)
+			total += int64(n)
+		}
+	}
+	return total, bw.Flush()
+}
+
+// Start returns the start time of the trace.
+func (tr *Trace) Start() exptrace.Time {
+	if len(tr.Generations) == 0 {
+		return 0
+	}
+	return tr.Generations[0].Start()
+}
+
+// Cut returns a trace containing only the generations that overlap the time range [from, to], relative to the start
+// of the trace. Because generations are the smallest self-contained unit of a trace, the returned trace may contain
+// events outside the requested range. A to of zero means the end of the trace.
+func (tr *Trace) Cut(from, to time.Duration) *Trace {
+	start := tr.Start()
+	out := &Trace{Header: tr.Header}
+	for i := range tr.Generations {
+		g := &tr.Generations[i]
+		gStart := time.Duration(g.Start() - start)
+		if to != 0 && gStart > to {
+			break
+		}
+		if i+1 < len(tr.Generations) {
+			if gEnd := time.Duration(tr.Generations[i+1].Start() - start); gEnd <= from {
+				continue
+			}
+		} else if gEnd := time.Duration(g.End() - start); gEnd < from {
+			// The last generation has no successor to bound it, so use the last batch instead.
+			continue
+		}
+		out.Generations = append(out.Generations, *g)
+	}
+	return out
+}
@@ -0,0 +1,71 @@
+package rawtrace
+
+import (
+	"bytes"
+	"encoding/binary"
+	"slices"
+	"testing"
+	"time"
+)
+
+func batch(gen, ts uint64, data ...byte) []byte {
+	out := []byte{evEventBatch}
+	out = binary.AppendUvarint(out, gen)
+	out = binary.AppendUvarint(out, 1)
+	out = binary.AppendUvarint(out, ts)
+	out = binary.AppendUvarint(out, uint64(len(data)))
+	return append(out, data...)
+}
+
+func TestReadCut(t *testing.T) {
+	// A frequency of 1e9 ticks per second makes timestamps equal nanoseconds.
+	freq := binary.AppendUvarint([]byte{evFrequency}, 1e9)
+
+	var in []byte
+	in = append(in, "go 1.23 trace\x00\x00\x00"...)
+	for gen := uint64(1); gen <= 5; gen++ {
+		ts := 1000 + (gen-1)*uint64(time.Second)
+		in = append(in, batch(gen, ts, 0xAA, 0xBB)...)
+		in = append(in, batch(gen, ts+10, freq...)...)
+		in = append(in, batch(gen, ts+uint64(900*time.Millisecond), 0xCC)...)
+	}
+
+	tr, err := Read(bytes.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tr.Generations) != 5 {
+		t.Fatalf("got %d generations, want 5", len(tr.Generations))
+	}
+
+	var out bytes.Buffer
+	if _, err := tr.WriteTo(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out.Bytes(), in) {
+		t.Errorf("round trip changed the trace")
+	}
+
+	tests := []struct {
+		from, to time.Duration
+		gens     []uint64
+	}{
+		{0, 0, []uint64{1, 2, 3, 4, 5}},
+		{1500 * time.Millisecond, 2500 * time.Millisecond, []uint64{2, 3}},
+		{2 * time.Second, 2 * time.Second, []uint64{3}},
+		{4500 * time.Millisecond, 0, []uint64{5}},
+		{0, 500 * time.Millisecond, []uint64{1}},
+		{4900 * time.Millisecond, 0, []uint64{5}},
+		{5 * time.Second, 0, nil},
+	}
+	for _, tt := range tests {
+		cut := tr.Cut(tt.from, tt.to)
+		var gens []uint64
+		for _, g := range cut.Generations {
+			gens = append(gens, g.Gen)
+		}
+		if !slices.Equal(gens, tt.gens) {
+			t.Errorf("Cut(%s, %s) = %v, want %v", tt.from, tt.to, gens, tt.gens)
+		}
+	}
+}
@@ -120,6 +120,7 @@ func (bit *BackedBit[T]) Layout(gtx layout.Context, w layout.Widget) layout.Dime
 type Boolean interface {
 	Set(bool)
 	Get() bool
+	Focused() bool
 	Update(layout.Context) bool
 	Layout(layout.Context, layout.Widget) layout.Dimensions
 }
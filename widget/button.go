@@ -15,9 +15,29 @@ import (
 	"gioui.org/op/clip"
 )
 
-// Clickable represents a clickable area.
+// Activatable is a Clickable that is always focusable.
 type Activatable struct {
 	Clickable
+}
+
+// Layout and update the button state.
+func (b *Activatable) Layout(gtx layout.Context, w layout.Widget) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "widget.Activatable.Layout").End()
+
+	b.Focusable = true
+	return b.Clickable.Layout(gtx, w)
+}
+
+// Clickable represents a clickable area.
+type Clickable struct {
+	// Focusable makes the element reachable via keyboard focus traversal. Focused elements can be clicked by pressing
+	// Enter or Space.
+	Focusable bool
+
+	click gesture.Click
+	// clicks is for saved clicks to support Clicked.
+	clicks          []Click
+	requestedClicks []Click
 
 	keyTag       struct{}
 	requestFocus bool
@@ -25,19 +45,64 @@ type Activatable struct {
 	pressedKey   string
 }
 
+// Click represents a click.
+type Click struct {
+	Button    pointer.Buttons
+	Modifiers key.Modifiers
+	NumClicks int
+}
+
+// Click executes a simple programmatic click.
+func (b *Clickable) Click(btn pointer.Buttons) {
+	b.requestedClicks = append(b.requestedClicks, Click{
+		Button:    btn,
+		Modifiers: 0,
+		NumClicks: 1,
+	})
+}
+
+// Clicked reports whether there are pending clicks as would be
+// reported by Clicks. If so, Clicked removes the earliest click.
+func (b *Clickable) Clicked(gtx layout.Context) (Click, bool) {
+	if len(b.clicks) > 0 {
+		c := b.clicks[0]
+		b.clicks = b.clicks[1:]
+		return c, true
+	}
+
+	b.clicks = b.Update(gtx)
+
+	if len(b.clicks) > 0 {
+		c := b.clicks[0]
+		b.clicks = b.clicks[1:]
+		return c, true
+	}
+	return Click{}, false
+}
+
 // Focus requests the input focus for the element.
-func (b *Activatable) Focus() {
+func (b *Clickable) Focus() {
 	b.requestFocus = true
 }
 
 // Focused reports whether b has focus.
-func (b *Activatable) Focused() bool {
+func (b *Clickable) Focused() bool {
 	return b.focused
 }
 
+// Hovered reports whether a pointer is over the element.
+func (b *Clickable) Hovered() bool {
+	return b.click.Hovered()
+}
+
+// Pressed reports whether a pointer is pressing the element.
+func (b *Clickable) Pressed(btn pointer.Buttons) bool {
+	return b.click.Pressed(btn)
+}
+
 // Layout and update the button state.
-func (b *Activatable) Layout(gtx layout.Context, w layout.Widget) layout.Dimensions {
-	defer rtrace.StartRegion(context.Background(), "widget.Activatable.Layout").End()
+func (b *Clickable) Layout(gtx layout.Context, w layout.Widget) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "widget.Clickable.Layout").End()
 
 	b.Update(gtx)
 	m := op.Record(gtx.Ops)
@@ -47,7 +112,7 @@ func (b *Activatable) Layout(gtx layout.Context, w layout.Widget) layout.Dimensi
 	enabled := gtx.Queue != nil
 	semantic.EnabledOp(enabled).Add(gtx.Ops)
 	b.click.Add(gtx.Ops)
-	if enabled {
+	if enabled && b.Focusable {
 		keys := key.Set("⏎|Space")
 		if !b.focused {
 			keys = ""
@@ -66,12 +131,11 @@ func (b *Activatable) Layout(gtx layout.Context, w layout.Widget) layout.Dimensi
 
 // Update the button state by processing events, and return the resulting
 // clicks, if any.
-func (b *Activatable) Update(gtx layout.Context) []Click {
-	b.clicks = nil
+func (b *Clickable) Update(gtx layout.Context) []Click {
 	if gtx.Queue == nil {
 		b.focused = false
 	}
-	if b.requestFocus {
+	if b.requestFocus && b.Focusable {
 		key.FocusOp{Tag: &b.keyTag}.Add(gtx.Ops)
 		b.requestFocus = false
 	}
@@ -80,7 +144,7 @@ func (b *Activatable) Update(gtx layout.Context) []Click {
 	clicks = append(clicks, b.requestedClicks...)
 	b.requestedClicks = b.requestedClicks[:0]
 
-	for _, e := range b.click.Update(gtx) {
+	for _, e := range b.click.Update(gtx.Queue) {
 		switch e.Kind {
 		case gesture.KindClick:
 			clicks = append(clicks, Click{
@@ -90,7 +154,7 @@ func (b *Activatable) Update(gtx layout.Context) []Click {
 			})
 		case gesture.KindCancel:
 		case gesture.KindPress:
-			if e.Source == pointer.Mouse {
+			if b.Focusable && e.Source == pointer.Mouse {
 				key.FocusOp{Tag: &b.keyTag}.Add(gtx.Ops)
 			}
 		}
@@ -119,6 +183,7 @@ func (b *Activatable) Update(gtx layout.Context) []Click {
 				// only register a key as a click if the key was pressed and released while this button was focused
 				b.pressedKey = ""
 				clicks = append(clicks, Click{
+					Button:    pointer.ButtonPrimary,
 					Modifiers: e.Modifiers,
 					NumClicks: 1,
 				})
@@ -129,97 +194,6 @@ func (b *Activatable) Update(gtx layout.Context) []Click {
 	return clicks
 }
 
-// Clickable represents a clickable area.
-type Clickable struct {
-	click gesture.Click
-	// clicks is for saved clicks to support Clicked.
-	clicks          []Click
-	requestedClicks []Click
-}
-
-// Click represents a click.
-type Click struct {
-	Button    pointer.Buttons
-	Modifiers key.Modifiers
-	NumClicks int
-}
-
-// Click executes a simple programmatic click.
-func (b *Clickable) Click(btn pointer.Buttons) {
-	b.requestedClicks = append(b.requestedClicks, Click{
-		Button:    btn,
-		Modifiers: 0,
-		NumClicks: 1,
-	})
-}
-
-// Clicked reports whether there are pending clicks as would be
-// reported by Clicks. If so, Clicked removes the earliest click.
-func (b *Clickable) Clicked(gtx layout.Context) (Click, bool) {
-	if len(b.clicks) > 0 {
-		c := b.clicks[0]
-		b.clicks = b.clicks[1:]
-		return c, true
-	}
-
-	b.clicks = b.Update(gtx)
-
-	if len(b.clicks) > 0 {
-		c := b.clicks[0]
-		b.clicks = b.clicks[1:]
-		return c, true
-	}
-	return Click{}, false
-}
-
-// Hovered reports whether a pointer is over the element.
-func (b *Clickable) Hovered() bool {
-	return b.click.Hovered()
-}
-
-// Pressed reports whether a pointer is pressing the element.
-func (b *Clickable) Pressed(btn pointer.Buttons) bool {
-	return b.click.Pressed(btn)
-}
-
-// Layout and update the button state.
-func (b *Clickable) Layout(gtx layout.Context, w layout.Widget) layout.Dimensions {
-	defer rtrace.StartRegion(context.Background(), "widget.Clickable.Layout").End()
-
-	b.Update(gtx)
-	m := op.Record(gtx.Ops)
-	dims := w(gtx)
-	c := m.Stop()
-	defer clip.Rect(image.Rectangle{Max: dims.Size}).Push(gtx.Ops).Pop()
-	semantic.EnabledOp(gtx.Queue != nil).Add(gtx.Ops)
-	b.click.Add(gtx.Ops)
-	c.Add(gtx.Ops)
-	return dims
-}
-
-// Update the button state by processing events, and return the resulting
-// clicks, if any.
-func (b *Clickable) Update(gtx layout.Context) []Click {
-	var clicks []Click
-	clicks = append(clicks, b.requestedClicks...)
-	b.requestedClicks = b.requestedClicks[:0]
-
-	for _, e := range b.click.Update(gtx.Queue) {
-		switch e.Kind {
-		case gesture.KindClick:
-			clicks = append(clicks, Click{
-				Button:    e.Button,
-				Modifiers: e.Modifiers,
-				NumClicks: e.NumClicks,
-			})
-		case gesture.KindCancel:
-		case gesture.KindPress:
-		}
-	}
-
-	return clicks
-}
-
 // PrimaryActivatable is like Activatable but ignores all press and click events for buttons other than the primary one.
 type PrimaryActivatable struct {
 	Activatable
@@ -244,7 +218,7 @@ func (b *PrimaryActivatable) Focus()        { b.Activatable.Focus() }
 func (b *PrimaryActivatable) Focused() bool { return b.Activatable.Focused() }
 func (b *PrimaryActivatable) Layout(gtx layout.Context, w layout.Widget) layout.Dimensions {
 	defer rtrace.StartRegion(context.Background(), "widget.PrimaryActivatable.Layout").End()
-	return b.Clickable.Layout(gtx, w)
+	return b.Activatable.Layout(gtx, w)
 }
 
 // PrimaryClickable is like Clickable but ignores all press and click events for buttons other than the primary one.
@@ -267,6 +241,8 @@ func (b *PrimaryClickable) Clicked(gtx layout.Context) bool {
 func (b *PrimaryClickable) Click()        { b.Clickable.Click(pointer.ButtonPrimary) }
 func (b *PrimaryClickable) Hovered() bool { return b.Clickable.Hovered() }
 func (b *PrimaryClickable) Pressed() bool { return b.Clickable.Pressed(pointer.ButtonPrimary) }
+func (b *PrimaryClickable) Focus()        { b.Clickable.Focus() }
+func (b *PrimaryClickable) Focused() bool { return b.Clickable.Focused() }
 func (b *PrimaryClickable) Layout(gtx layout.Context, w layout.Widget) layout.Dimensions {
 	defer rtrace.StartRegion(context.Background(), "widget.PrimaryClickable.Layout").End()
 	return b.Clickable.Layout(gtx, w)
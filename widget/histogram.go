@@ -236,6 +236,15 @@ func (hist *Histogram) HasOverflow() bool {
 	return hist.Overflow > 0 || hist.BinWidth == 0
 }
 
+// Count returns the number of values in the histogram.
+func (hist *Histogram) Count() int {
+	var n int
+	for _, v := range hist.Bins {
+		n += v
+	}
+	return n
+}
+
 func (hist *Histogram) BucketRange(i int) (start, end FloatDuration) {
 	start = hist.Start + hist.BinWidth*FloatDuration(i)
 	if !hist.HasOverflow() || i < len(hist.Bins)-1 {
@@ -0,0 +1,63 @@
+package widget
+
+import (
+	"math"
+	"sort"
+)
+
+type LineChartPoint struct {
+	X, Y float64
+}
+
+// LineChartRange is a range of X values, such as a period of time, that gets highlighted in a line chart.
+type LineChartRange struct {
+	Start, End float64
+}
+
+// LineChartSeries is a series of points in a line chart, such as the number of goroutines over time.
+type LineChartSeries struct {
+	Name string
+	// Points, sorted by X.
+	Points []LineChartPoint
+	// Step draws the series as a staircase instead of interpolating between points. This is more accurate for values
+	// that change at discrete points in time, such as the heap size.
+	Step bool
+}
+
+// Bounds returns the smallest and largest X and Y values of the series. It returns all NaNs for empty series.
+func (s *LineChartSeries) Bounds() (minX, maxX, minY, maxY float64) {
+	if len(s.Points) == 0 {
+		return math.NaN(), math.NaN(), math.NaN(), math.NaN()
+	}
+	minX = s.Points[0].X
+	maxX = s.Points[len(s.Points)-1].X
+	minY, maxY = s.Points[0].Y, s.Points[0].Y
+	for _, p := range s.Points[1:] {
+		minY = min(minY, p.Y)
+		maxY = max(maxY, p.Y)
+	}
+	return minX, maxX, minY, maxY
+}
+
+// ValueAt returns the last point whose X is less than or equal to x.
+func (s *LineChartSeries) ValueAt(x float64) (LineChartPoint, bool) {
+	idx := sort.Search(len(s.Points), func(i int) bool { return s.Points[i].X > x })
+	if idx == 0 {
+		return LineChartPoint{}, false
+	}
+	return s.Points[idx-1], true
+}
+
+// Visible returns the points in the range [minX, maxX], as well as the points immediately before and after the
+// range, so that lines leaving the range can be drawn.
+func (s *LineChartSeries) Visible(minX, maxX float64) []LineChartPoint {
+	start := sort.Search(len(s.Points), func(i int) bool { return s.Points[i].X >= minX })
+	end := sort.Search(len(s.Points), func(i int) bool { return s.Points[i].X > maxX })
+	if start > 0 {
+		start--
+	}
+	if end < len(s.Points) {
+		end++
+	}
+	return s.Points[start:end]
+}
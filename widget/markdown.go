@@ -0,0 +1,169 @@
+package widget
+
+import (
+	"strings"
+)
+
+type RichTextBlockKind uint8
+
+const (
+	RichTextParagraph RichTextBlockKind = iota
+	RichTextHeading
+	RichTextListItem
+	RichTextCode
+)
+
+// RichTextSpan is a run of text with uniform styling.
+type RichTextSpan struct {
+	Text string
+	Bold bool
+	Code bool
+	// Link is the target of a link, such as a URL. It is empty for spans that aren't links.
+	Link string
+}
+
+// RichTextBlock is a block-level element, such as a paragraph.
+type RichTextBlock struct {
+	Kind RichTextBlockKind
+	// Level is the level of headings, starting at 1.
+	Level int
+	// Spans are the contents of all blocks other than code blocks.
+	Spans []RichTextSpan
+	// Code is the verbatim contents of code blocks.
+	Code string
+}
+
+// ParseMarkdown parses a small subset of Markdown: ATX headings, paragraphs, bulleted lists, fenced code blocks, and
+// inline bold text, code, and links. Everything else is treated as plain text.
+func ParseMarkdown(s string) []RichTextBlock {
+	var blocks []RichTextBlock
+	var para []string
+	flush := func() {
+		if len(para) > 0 {
+			blocks = append(blocks, RichTextBlock{Kind: RichTextParagraph, Spans: parseInline(strings.Join(para, " "))})
+			para = para[:0]
+		}
+	}
+
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			flush()
+
+		case strings.HasPrefix(trimmed, "```"):
+			flush()
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				code = append(code, lines[i])
+			}
+			blocks = append(blocks, RichTextBlock{Kind: RichTextCode, Code: strings.Join(code, "\n")})
+
+		case strings.HasPrefix(trimmed, "#"):
+			level := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+			if level > 6 || (len(trimmed) > level && trimmed[level] != ' ') {
+				para = append(para, trimmed)
+				break
+			}
+			flush()
+			blocks = append(blocks, RichTextBlock{
+				Kind:  RichTextHeading,
+				Level: level,
+				Spans: parseInline(strings.TrimSpace(trimmed[level:])),
+			})
+
+		case strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "* "):
+			flush()
+			item := []string{strings.TrimSpace(trimmed[2:])}
+			// Indented lines continue the list item.
+			for i+1 < len(lines) && strings.HasPrefix(lines[i+1], "  ") && strings.TrimSpace(lines[i+1]) != "" {
+				i++
+				item = append(item, strings.TrimSpace(lines[i]))
+			}
+			blocks = append(blocks, RichTextBlock{Kind: RichTextListItem, Spans: parseInline(strings.Join(item, " "))})
+
+		default:
+			para = append(para, trimmed)
+		}
+	}
+	flush()
+
+	return blocks
+}
+
+// parseInline parses bold text, inline code, and links. Unterminated markup is treated as plain text.
+func parseInline(s string) []RichTextSpan {
+	var spans []RichTextSpan
+	var plain strings.Builder
+	emit := func(span RichTextSpan) {
+		if plain.Len() > 0 {
+			spans = append(spans, RichTextSpan{Text: plain.String()})
+			plain.Reset()
+		}
+		if span.Text != "" {
+			spans = append(spans, span)
+		}
+	}
+
+	for len(s) > 0 {
+		switch {
+		case s[0] == '`':
+			if end := strings.IndexByte(s[1:], '`'); end != -1 {
+				emit(RichTextSpan{Text: s[1 : end+1], Code: true})
+				s = s[end+2:]
+				continue
+			}
+		case strings.HasPrefix(s, "**"):
+			if end := strings.Index(s[2:], "**"); end != -1 {
+				emit(RichTextSpan{Text: s[2 : end+2], Bold: true})
+				s = s[end+4:]
+				continue
+			}
+		case s[0] == '[':
+			if text, target, n, ok := parseLink(s); ok {
+				emit(RichTextSpan{Text: text, Link: target})
+				s = s[n:]
+				continue
+			}
+		}
+		plain.WriteByte(s[0])
+		s = s[1:]
+	}
+	emit(RichTextSpan{})
+
+	return spans
+}
+
+// parseLink parses a link of the form [text](target) at the start of s and returns its length. Brackets in the text and
+// parentheses in the target have to be balanced.
+func parseLink(s string) (text, target string, n int, ok bool) {
+	closing := func(s string, open, close byte) int {
+		depth := 0
+		for i := 0; i < len(s); i++ {
+			switch s[i] {
+			case open:
+				depth++
+			case close:
+				depth--
+				if depth == 0 {
+					return i
+				}
+			}
+		}
+		return -1
+	}
+
+	mid := closing(s, '[', ']')
+	if mid == -1 || mid+1 >= len(s) || s[mid+1] != '(' {
+		return "", "", 0, false
+	}
+	end := closing(s[mid+1:], '(', ')')
+	if end == -1 {
+		return "", "", 0, false
+	}
+	end += mid + 1
+	return s[1:mid], s[mid+2 : end], end + 1, true
+}
@@ -0,0 +1,49 @@
+package widget
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseInline(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []RichTextSpan
+	}{
+		{"plain", []RichTextSpan{{Text: "plain"}}},
+		{"a **b** `c`", []RichTextSpan{{Text: "a "}, {Text: "b", Bold: true}, {Text: " "}, {Text: "c", Code: true}}},
+		{"see [docs](https://go.dev).", []RichTextSpan{{Text: "see "}, {Text: "docs", Link: "https://go.dev"}, {Text: "."}}},
+		// The first pair of brackets isn't followed by a target, so it isn't a link, and neither is the stray
+		// closing bracket.
+		{"[a] b](c)", []RichTextSpan{{Text: "[a] b](c)"}}},
+		{"[a [b] c](d)", []RichTextSpan{{Text: "a [b] c", Link: "d"}}},
+		{"[f](https://en.wikipedia.org/wiki/Go_(language)) x", []RichTextSpan{
+			{Text: "f", Link: "https://en.wikipedia.org/wiki/Go_(language)"},
+			{Text: " x"},
+		}},
+		{"[a](b) and [c](d)", []RichTextSpan{{Text: "a", Link: "b"}, {Text: " and "}, {Text: "c", Link: "d"}}},
+		{"[unterminated](link", []RichTextSpan{{Text: "[unterminated](link"}}},
+		{"[a] (b)", []RichTextSpan{{Text: "[a] (b)"}}},
+		{"**unterminated", []RichTextSpan{{Text: "**unterminated"}}},
+	}
+	for _, tt := range tests {
+		if got := parseInline(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: got %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseMarkdown(t *testing.T) {
+	in := "# Title\n\nSome [text](x)\ncontinued.\n\n- item\n  more\n* other\n\n```\ncode [a](b)\n```\n#nope"
+	want := []RichTextBlock{
+		{Kind: RichTextHeading, Level: 1, Spans: []RichTextSpan{{Text: "Title"}}},
+		{Kind: RichTextParagraph, Spans: []RichTextSpan{{Text: "Some "}, {Text: "text", Link: "x"}, {Text: " continued."}}},
+		{Kind: RichTextListItem, Spans: []RichTextSpan{{Text: "item more"}}},
+		{Kind: RichTextListItem, Spans: []RichTextSpan{{Text: "other"}}},
+		{Kind: RichTextCode, Code: "code [a](b)"},
+		{Kind: RichTextParagraph, Spans: []RichTextSpan{{Text: "#nope"}}},
+	}
+	if got := ParseMarkdown(in); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
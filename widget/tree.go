@@ -0,0 +1,278 @@
+package widget
+
+import (
+	"slices"
+
+	"honnef.co/go/gotraceui/layout"
+)
+
+// TreeRow is a visible row of a TreeView.
+type TreeRow[T comparable] struct {
+	Node  T
+	Depth int
+	// Parent is the index of the parent row, or -1 for roots.
+	Parent      int
+	Expanded    bool
+	HasChildren bool
+}
+
+// TreeView is the state of a tree of nodes of type T, such as the goroutine creation tree or the task hierarchy. It
+// tracks which nodes are expanded and which node is selected, and flattens the tree into the list of visible rows,
+// which allows for virtualized rendering of large trees.
+//
+// Children are loaded lazily, the first time their parent gets expanded, and cached until Invalidate is called.
+type TreeView[T comparable] struct {
+	// Roots returns the top-level nodes.
+	Roots func() []T
+	// Children returns the children of a node. It is only called when the node gets expanded.
+	Children func(node T) []T
+	// HasChildren reports whether a node has children, without having to load them. If nil, all nodes are assumed to
+	// have children until they have been expanded.
+	HasChildren func(node T) bool
+
+	List List
+
+	expanded map[T]bool
+	children map[T][]T
+	roots    []T
+	rows     []TreeRow[T]
+	dirty    bool
+	loaded   bool
+
+	selected    T
+	hasSelected bool
+	// scrollToSelected is set when the selection was changed by keyboard navigation and the list should scroll to keep
+	// it visible.
+	scrollToSelected bool
+
+	// Clickables for the rows, keyed by node. We keep the clickables of the current and the previous frame, so that
+	// clickables don't get reused for different nodes as rows scroll in and out of view.
+	clicks     map[T]*TreeRowClickables
+	prevClicks map[T]*TreeRowClickables
+}
+
+// TreeRowClickables are the clickable areas of a row in a TreeView.
+type TreeRowClickables struct {
+	Row      Clickable
+	Expander PrimaryClickable
+}
+
+func (tv *TreeView[T]) init() {
+	if tv.expanded == nil {
+		tv.expanded = map[T]bool{}
+		tv.children = map[T][]T{}
+		tv.List.Axis = layout.Vertical
+	}
+	if !tv.loaded {
+		tv.roots = tv.Roots()
+		tv.loaded = true
+		tv.dirty = true
+	}
+}
+
+// Invalidate discards all loaded nodes, causing them to be loaded again. Expansion state and selection are
+// preserved for nodes that still exist.
+func (tv *TreeView[T]) Invalidate() {
+	tv.loaded = false
+	clear(tv.children)
+	tv.dirty = true
+}
+
+func (tv *TreeView[T]) childrenOf(node T) []T {
+	if c, ok := tv.children[node]; ok {
+		return c
+	}
+	c := tv.Children(node)
+	tv.children[node] = c
+	return c
+}
+
+func (tv *TreeView[T]) hasChildren(node T) bool {
+	if c, ok := tv.children[node]; ok {
+		return len(c) != 0
+	}
+	if tv.HasChildren == nil {
+		return true
+	}
+	return tv.HasChildren(node)
+}
+
+// Rows returns the visible rows, in order.
+func (tv *TreeView[T]) Rows() []TreeRow[T] {
+	tv.init()
+	if !tv.dirty {
+		return tv.rows
+	}
+	tv.dirty = false
+	tv.rows = tv.rows[:0]
+
+	var add func(nodes []T, depth int, parent int)
+	add = func(nodes []T, depth int, parent int) {
+		for _, node := range nodes {
+			expanded := tv.expanded[node]
+			tv.rows = append(tv.rows, TreeRow[T]{
+				Node:        node,
+				Depth:       depth,
+				Parent:      parent,
+				Expanded:    expanded,
+				HasChildren: tv.hasChildren(node),
+			})
+			if expanded {
+				add(tv.childrenOf(node), depth+1, len(tv.rows)-1)
+			}
+		}
+	}
+	add(tv.roots, 0, -1)
+	return tv.rows
+}
+
+// IsExpanded reports whether node is expanded.
+func (tv *TreeView[T]) IsExpanded(node T) bool {
+	return tv.expanded[node]
+}
+
+// SetExpanded expands or collapses node.
+func (tv *TreeView[T]) SetExpanded(node T, expanded bool) {
+	tv.init()
+	if tv.expanded[node] == expanded {
+		return
+	}
+	if expanded {
+		tv.expanded[node] = true
+	} else {
+		delete(tv.expanded, node)
+	}
+	tv.dirty = true
+}
+
+// Toggle expands node if it is collapsed, and collapses it if it is expanded.
+func (tv *TreeView[T]) Toggle(node T) {
+	tv.SetExpanded(node, !tv.expanded[node])
+}
+
+// Selected returns the selected node, if any.
+func (tv *TreeView[T]) Selected() (T, bool) {
+	return tv.selected, tv.hasSelected
+}
+
+// Select selects node.
+func (tv *TreeView[T]) Select(node T) {
+	tv.selected = node
+	tv.hasSelected = true
+}
+
+// ClearSelection deselects the selected node.
+func (tv *TreeView[T]) ClearSelection() {
+	var zero T
+	tv.selected = zero
+	tv.hasSelected = false
+}
+
+// selectedRow returns the index of the selected row, or -1 if no visible row is selected.
+func (tv *TreeView[T]) selectedRow() int {
+	if !tv.hasSelected {
+		return -1
+	}
+	return slices.IndexFunc(tv.Rows(), func(row TreeRow[T]) bool { return row.Node == tv.selected })
+}
+
+func (tv *TreeView[T]) selectRow(idx int) {
+	rows := tv.Rows()
+	if len(rows) == 0 {
+		return
+	}
+	idx = max(0, min(idx, len(rows)-1))
+	tv.Select(rows[idx].Node)
+	tv.scrollToSelected = true
+}
+
+// MoveSelection moves the selection by delta visible rows. If no row is selected, the first row gets selected.
+func (tv *TreeView[T]) MoveSelection(delta int) {
+	idx := tv.selectedRow()
+	if idx == -1 {
+		tv.selectRow(0)
+		return
+	}
+	tv.selectRow(idx + delta)
+}
+
+// SelectFirst selects the first visible row.
+func (tv *TreeView[T]) SelectFirst() { tv.selectRow(0) }
+
+// SelectLast selects the last visible row.
+func (tv *TreeView[T]) SelectLast() { tv.selectRow(len(tv.Rows()) - 1) }
+
+// ExpandSelected expands the selected node, or moves to its first child if it is already expanded.
+func (tv *TreeView[T]) ExpandSelected() {
+	idx := tv.selectedRow()
+	if idx == -1 {
+		return
+	}
+	row := tv.rows[idx]
+	if !row.HasChildren {
+		return
+	}
+	if row.Expanded {
+		if idx+1 < len(tv.rows) && tv.rows[idx+1].Parent == idx {
+			tv.selectRow(idx + 1)
+		}
+	} else {
+		tv.SetExpanded(row.Node, true)
+	}
+}
+
+// CollapseSelected collapses the selected node, or moves to its parent if it is already collapsed.
+func (tv *TreeView[T]) CollapseSelected() {
+	idx := tv.selectedRow()
+	if idx == -1 {
+		return
+	}
+	row := tv.rows[idx]
+	if row.Expanded {
+		tv.SetExpanded(row.Node, false)
+	} else if row.Parent != -1 {
+		tv.selectRow(row.Parent)
+	}
+}
+
+// ScrollToSelected returns the index of the selected row if the selection was changed by keyboard navigation since
+// the last call, and should be scrolled into view.
+func (tv *TreeView[T]) ScrollToSelected() (int, bool) {
+	if !tv.scrollToSelected {
+		return 0, false
+	}
+	tv.scrollToSelected = false
+	idx := tv.selectedRow()
+	return idx, idx != -1
+}
+
+// Clickables returns the clickables for the row of node. Clickables of nodes that weren't displayed in the current
+// frame are discarded by EndFrame.
+func (tv *TreeView[T]) Clickables(node T) *TreeRowClickables {
+	if tv.clicks == nil {
+		tv.clicks = map[T]*TreeRowClickables{}
+	}
+	if c, ok := tv.clicks[node]; ok {
+		return c
+	}
+	c, ok := tv.prevClicks[node]
+	if !ok {
+		c = new(TreeRowClickables)
+	}
+	tv.clicks[node] = c
+	return c
+}
+
+// PreviousClickables calls fn for the clickables of each row that was displayed in the previous frame. It is used
+// to process clicks before laying out the current frame.
+func (tv *TreeView[T]) PreviousClickables(fn func(node T, c *TreeRowClickables)) {
+	for node, c := range tv.prevClicks {
+		fn(node, c)
+	}
+}
+
+// EndFrame discards clickables of rows that weren't displayed in the current frame.
+func (tv *TreeView[T]) EndFrame() {
+	tv.prevClicks, tv.clicks = tv.clicks, tv.prevClicks
+	clear(tv.clicks)
+}